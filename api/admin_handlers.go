@@ -37,7 +37,7 @@ func (s *Server) handleSeedDemo(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "overwrite is no longer supported; seeding is idempotent against the raw layer"})
 	}
 
-	report, err := seed.Run(c.Context(), s.driver, s.logger, singleTenantOrgID)
+	report, err := seed.Run(c.Context(), s.driver, s.logger, s.orgID(c))
 	if err != nil {
 		if errors.Is(err, seed.ErrUnsupportedDriver) {
 			return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: err.Error()})
@@ -53,6 +53,7 @@ func (s *Server) handleSeedDemo(c *fiber.Ctx) error {
 // POST /v1/admin/derive/run.
 type deriveRunner interface {
 	RederiveFromRaw(ctx context.Context, project string) (map[string]*derive.RederiveReport, error)
+	RederiveOrgFromRaw(ctx context.Context, project, orgID string) (map[string]*derive.RederiveReport, error)
 }
 
 // deriveRunResponse is the derive-run result, keyed by org.
@@ -78,7 +79,15 @@ func (s *Server) handleDeriveRun(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: "driver does not host the raw-turn layer"})
 	}
 
-	reports, err := runner.RederiveFromRaw(c.Context(), "")
+	// A multi-tenant admin rebuilds its own org; a single-tenant deployment's
+	// admin owns every row, including any stored before the org was settled.
+	var reports map[string]*derive.RederiveReport
+	var err error
+	if s.config.MultiTenant {
+		reports, err = runner.RederiveOrgFromRaw(c.Context(), "", s.orgID(c))
+	} else {
+		reports, err = runner.RederiveFromRaw(c.Context(), "")
+	}
 	if err != nil {
 		s.logger.Error("derive run", "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: err.Error()})
@@ -108,7 +117,7 @@ func (s *Server) handleRawTurnAttributionRepair(c *fiber.Ctx) error {
 	if err := validateRawTurnAttributionRepairRequest(req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: err.Error()})
 	}
-	req.OrgID = singleTenantOrgID
+	req.OrgID = s.orgID(c)
 	result, err := repairer.RepairRawTurnAttribution(c.Context(), "", req)
 	if err != nil {
 		switch {
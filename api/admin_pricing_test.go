@@ -0,0 +1,130 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	tapeslogger "github.com/papercomputeco/tapes/pkg/logger"
+	"github.com/papercomputeco/tapes/pkg/sessions"
+	"github.com/papercomputeco/tapes/pkg/storage"
+	"github.com/papercomputeco/tapes/pkg/storage/inmemory"
+)
+
+// pricingStub records what the pricing admin handlers hand to storage.
+type pricingStub struct {
+	storage.Driver
+	upserted  []storage.Price
+	discount  storage.PriceDiscount
+	listedOrg string
+}
+
+func (s *pricingStub) UpsertPrices(_ context.Context, prices []storage.Price) ([]storage.Price, error) {
+	s.upserted = prices
+	return prices, nil
+}
+
+func (s *pricingStub) ListPrices(context.Context, string) ([]storage.Price, error) {
+	return s.upserted, nil
+}
+
+func (s *pricingStub) DeletePrice(context.Context, string) error { return storage.ErrPriceNotFound }
+
+func (s *pricingStub) CreatePriceDiscount(_ context.Context, d storage.PriceDiscount) (*storage.PriceDiscount, error) {
+	s.discount = d
+	return &d, nil
+}
+
+func (s *pricingStub) ListPriceDiscounts(_ context.Context, orgID string) ([]storage.PriceDiscount, error) {
+	s.listedOrg = orgID
+	return nil, nil
+}
+
+func (s *pricingStub) DeletePriceDiscount(context.Context, string, string) error { return nil }
+
+func (s *pricingStub) PricingCatalog(context.Context, string) (*sessions.PricingCatalog, error) {
+	return sessions.NewPricingCatalog(nil, nil, nil), nil
+}
+
+var _ = Describe("pricing admin endpoints", func() {
+	newServer := func(driver storage.Driver) *Server {
+		s, err := NewServer(Config{ListenAddr: ":0"}, driver, tapeslogger.NewNoop())
+		Expect(err).NotTo(HaveOccurred())
+		return s
+	}
+
+	request := func(s *Server, method, path, body string) *http.Response {
+		req, err := http.NewRequestWithContext(context.Background(), method, path, bytes.NewBufferString(body))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := s.app.Test(req)
+		Expect(err).NotTo(HaveOccurred())
+		return resp
+	}
+
+	It("returns 501 when the driver does not host the catalog", func() {
+		resp := request(newServer(inmemory.NewDriver()), http.MethodGet, "/v1/admin/pricing", "")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusNotImplemented))
+	})
+
+	It("imports a price sheet at its effective date", func() {
+		stub := &pricingStub{Driver: inmemory.NewDriver()}
+		resp := request(newServer(stub), http.MethodPost, "/v1/admin/pricing/prices",
+			`{"effective_from":"2026-03-01T00:00:00Z","source":"sheet","prices":{"claude-sonnet-4-5":{"input":2,"output":10,"tiers":{"batch":0.5}}}}`)
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		var body pricingImportResponse
+		Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+		Expect(body.Prices).To(HaveLen(1))
+		Expect(stub.upserted[0].Model).To(Equal("claude-sonnet-4-5"))
+		Expect(stub.upserted[0].EffectiveFrom.Format("2006-01-02")).To(Equal("2026-03-01"))
+		Expect(stub.upserted[0].Pricing.Tiers).To(HaveKeyWithValue("batch", 0.5))
+	})
+
+	It("rejects a price sheet without an effective date", func() {
+		stub := &pricingStub{Driver: inmemory.NewDriver()}
+		resp := request(newServer(stub), http.MethodPost, "/v1/admin/pricing/prices",
+			`{"prices":{"claude-sonnet-4-5":{"input":2}}}`)
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		Expect(stub.upserted).To(BeNil())
+	})
+
+	It("scopes discounts to the deployment's org, never a caller-asserted one", func() {
+		stub := &pricingStub{Driver: inmemory.NewDriver()}
+		s := newServer(stub)
+
+		resp := request(s, http.MethodPost, "/v1/admin/pricing/discounts",
+			`{"org_id":"3f6d8c1a-2b4e-4c7f-9a10-5e2b7d9c0f41","percent":15,"effective_from":"2026-04-01T00:00:00Z"}`)
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+		Expect(stub.discount.OrgID).To(Equal(singleTenantOrgID))
+		Expect(stub.discount.Percent).To(Equal(15.0))
+
+		list := request(s, http.MethodGet, "/v1/admin/pricing", "")
+		defer list.Body.Close()
+		Expect(list.StatusCode).To(Equal(http.StatusOK))
+		Expect(stub.listedOrg).To(Equal(singleTenantOrgID))
+	})
+
+	It("rejects a discount outside (0, 100]", func() {
+		stub := &pricingStub{Driver: inmemory.NewDriver()}
+		resp := request(newServer(stub), http.MethodPost, "/v1/admin/pricing/discounts",
+			`{"percent":0,"effective_from":"2026-04-01T00:00:00Z"}`)
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+
+	It("maps a missing catalog price to 404", func() {
+		stub := &pricingStub{Driver: inmemory.NewDriver()}
+		resp := request(newServer(stub), http.MethodDelete, "/v1/admin/pricing/prices/nope", "")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+	})
+})
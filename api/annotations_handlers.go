@@ -0,0 +1,403 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/papercomputeco/tapes/pkg/llm"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+const (
+	// defaultAnnotationsLimit and maxAnnotationsLimit bound a
+	// GET /v1/annotations page.
+	defaultAnnotationsLimit = 100
+	maxAnnotationsLimit     = 1000
+
+	// maxAnnotationImport bounds one POST /v1/annotations/import, which
+	// writes in a single transaction.
+	maxAnnotationImport = 1000
+
+	maxAnnotationNameLength   = 100
+	maxAnnotationLabelLength  = 100
+	maxAnnotationAuthorLength = 200
+	maxAnnotationNoteLength   = 10000
+)
+
+// annotationRequest is one annotation as a client writes it. A trace or
+// span target needs no session_id; the server fills it from the trace.
+// An empty author is the caller: the JWT subject or the API token's name.
+type annotationRequest struct {
+	SessionID string   `json:"session_id,omitempty"`
+	TraceID   string   `json:"trace_id,omitempty"`
+	SpanID    string   `json:"span_id,omitempty"`
+	Name      string   `json:"name"`
+	Label     string   `json:"label,omitempty"`
+	Score     *float64 `json:"score,omitempty"`
+	Note      string   `json:"note,omitempty"`
+	Author    string   `json:"author,omitempty"`
+}
+
+// annotationImportRequest is the POST /v1/annotations/import body.
+type annotationImportRequest struct {
+	Annotations []annotationRequest `json:"annotations"`
+}
+
+// annotationImportResponse lists the annotations an import stored, in
+// request order.
+type annotationImportResponse struct {
+	Annotations []storage.Annotation `json:"annotations"`
+}
+
+// annotationUpdateRequest is the PATCH /v1/annotations/:id body. Each
+// field is optional; an explicit null or empty value clears it. The
+// handler decodes into a raw map to tell an absent field from a cleared
+// one, so this type only documents the body.
+type annotationUpdateRequest struct {
+	Label *string  `json:"label,omitempty"`
+	Score *float64 `json:"score,omitempty"`
+	Note  *string  `json:"note,omitempty"`
+}
+
+// AnnotationListResponse is the response for GET /v1/annotations. Items
+// is never null.
+type AnnotationListResponse struct {
+	Items []storage.Annotation `json:"items"`
+}
+
+// handleCreateAnnotation handles POST /v1/annotations. Writing a
+// judgment whose target, name and author already exist replaces it.
+func (s *Server) handleCreateAnnotation(c *fiber.Ctx) error {
+	store, ok := s.driver.(storage.AnnotationStore)
+	if !ok {
+		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: "annotations not supported by this backend"})
+	}
+
+	var req annotationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "invalid payload: " + err.Error()})
+	}
+	ann, err := annotationFromRequest(req, callerName(c))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: err.Error()})
+	}
+
+	subject, _ := ownSessionsSubject(c)
+	stored, err := store.PutAnnotations(c.Context(), s.orgID(c), subject, []storage.Annotation{ann})
+	if err != nil {
+		return s.annotationWriteError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(stored[0])
+}
+
+// handleImportAnnotations handles POST /v1/annotations/import: many
+// annotations in one transaction, so a label sheet or an evaluator's run
+// lands whole or not at all. Re-importing replaces rather than
+// duplicates.
+func (s *Server) handleImportAnnotations(c *fiber.Ctx) error {
+	store, ok := s.driver.(storage.AnnotationStore)
+	if !ok {
+		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: "annotations not supported by this backend"})
+	}
+
+	var req annotationImportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "invalid payload: " + err.Error()})
+	}
+	if len(req.Annotations) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "annotations is empty"})
+	}
+	if len(req.Annotations) > maxAnnotationImport {
+		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{
+			Error: fmt.Sprintf("at most %d annotations per import", maxAnnotationImport),
+		})
+	}
+
+	author := callerName(c)
+	anns := make([]storage.Annotation, 0, len(req.Annotations))
+	for i, r := range req.Annotations {
+		ann, err := annotationFromRequest(r, author)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: fmt.Sprintf("annotations[%d]: %v", i, err)})
+		}
+		anns = append(anns, ann)
+	}
+
+	subject, _ := ownSessionsSubject(c)
+	stored, err := store.PutAnnotations(c.Context(), s.orgID(c), subject, anns)
+	if err != nil {
+		return s.annotationWriteError(c, err)
+	}
+	return c.JSON(annotationImportResponse{Annotations: stored})
+}
+
+// annotationWriteError answers a failed PutAnnotations: a target that
+// does not exist (or that the caller may not see) is the client's, any
+// other failure the server's.
+func (s *Server) annotationWriteError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, storage.ErrAnnotationTarget) {
+		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: err.Error()})
+	}
+	s.logger.Error("put annotations", "error", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to write annotations"})
+}
+
+// annotationFromRequest trims and validates one written annotation.
+// author fills an empty Author.
+func annotationFromRequest(req annotationRequest, author string) (storage.Annotation, error) {
+	ann := storage.Annotation{
+		SessionID: strings.TrimSpace(req.SessionID),
+		TraceID:   strings.TrimSpace(req.TraceID),
+		SpanID:    strings.TrimSpace(req.SpanID),
+		Name:      strings.TrimSpace(req.Name),
+		Label:     strings.TrimSpace(req.Label),
+		Score:     req.Score,
+		Note:      strings.TrimSpace(req.Note),
+		Author:    strings.TrimSpace(req.Author),
+	}
+	if ann.Author == "" {
+		ann.Author = author
+	}
+	switch {
+	case ann.Name == "":
+		return ann, errors.New("name is required")
+	case utf8.RuneCountInString(ann.Name) > maxAnnotationNameLength:
+		return ann, fmt.Errorf("name must be at most %d characters", maxAnnotationNameLength)
+	case ann.SessionID == "" && ann.TraceID == "":
+		return ann, errors.New("session_id or trace_id is required")
+	case ann.SpanID != "" && ann.TraceID == "":
+		return ann, errors.New("span_id requires trace_id")
+	case utf8.RuneCountInString(ann.Author) > maxAnnotationAuthorLength:
+		return ann, fmt.Errorf("author must be at most %d characters", maxAnnotationAuthorLength)
+	}
+	if ann.SessionID != "" {
+		if _, err := uuid.Parse(ann.SessionID); err != nil {
+			return ann, errors.New("session_id must be a valid UUID")
+		}
+	}
+	return ann, validateAnnotationValue(ann)
+}
+
+// validateAnnotationValue checks what an annotation says: at least one of
+// label, score and note, each within its bound.
+func validateAnnotationValue(ann storage.Annotation) error {
+	switch {
+	case ann.Label == "" && ann.Score == nil && ann.Note == "":
+		return errors.New("one of label, score or note is required")
+	case utf8.RuneCountInString(ann.Label) > maxAnnotationLabelLength:
+		return fmt.Errorf("label must be at most %d characters", maxAnnotationLabelLength)
+	case utf8.RuneCountInString(ann.Note) > maxAnnotationNoteLength:
+		return fmt.Errorf("note must be at most %d characters", maxAnnotationNoteLength)
+	}
+	return nil
+}
+
+// handleListAnnotations handles GET /v1/annotations.
+func (s *Server) handleListAnnotations(c *fiber.Ctx) error {
+	store, ok := s.driver.(storage.AnnotationStore)
+	if !ok {
+		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: "annotations not supported by this backend"})
+	}
+
+	filter := storage.AnnotationFilter{
+		SessionID: c.Query("session_id"),
+		TraceID:   c.Query("trace_id"),
+		SpanID:    c.Query("span_id"),
+		Name:      c.Query("name"),
+		Label:     c.Query("label"),
+		Author:    c.Query("author"),
+		Limit:     defaultAnnotationsLimit,
+	}
+	if filter.SessionID != "" {
+		if _, err := uuid.Parse(filter.SessionID); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "session_id must be a valid UUID"})
+		}
+	}
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "limit must be a positive integer"})
+		}
+		filter.Limit = min(parsed, maxAnnotationsLimit)
+	}
+	if subject, restricted := ownSessionsSubject(c); restricted {
+		filter.AuthSubject = subject
+	}
+
+	anns, err := store.ListAnnotations(c.Context(), s.orgID(c), filter)
+	if err != nil {
+		s.logger.Error("list annotations", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to list annotations"})
+	}
+	if anns == nil {
+		anns = []storage.Annotation{}
+	}
+	return c.JSON(AnnotationListResponse{Items: anns})
+}
+
+// loadAnnotation fetches the :id annotation for the single-annotation
+// routes. On failure it returns the status and client-facing error to
+// answer with; an annotation on a session hidden from the caller is
+// answered like a missing one.
+func (s *Server) loadAnnotation(c *fiber.Ctx, store storage.AnnotationStore, action string) (*storage.Annotation, int, error) {
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fiber.StatusBadRequest, errors.New("id must be a valid UUID")
+	}
+	ann, err := store.GetAnnotation(c.Context(), s.orgID(c), id)
+	if errors.Is(err, storage.ErrAnnotationNotFound) {
+		return nil, fiber.StatusNotFound, errors.New("annotation not found")
+	}
+	if err != nil {
+		s.logger.Error("get annotation", "id", id, "error", err)
+		return nil, fiber.StatusInternalServerError, errors.New("failed to " + action + " annotation")
+	}
+	visible, err := s.sessionIDVisible(c, ann.SessionID)
+	if err != nil {
+		s.logger.Error("get session", "id", ann.SessionID, "error", err)
+		return nil, fiber.StatusInternalServerError, errors.New("failed to " + action + " annotation")
+	}
+	if !visible {
+		return nil, fiber.StatusNotFound, errors.New("annotation not found")
+	}
+	return ann, fiber.StatusOK, nil
+}
+
+// handleGetAnnotation handles GET /v1/annotations/:id.
+func (s *Server) handleGetAnnotation(c *fiber.Ctx) error {
+	store, ok := s.driver.(storage.AnnotationStore)
+	if !ok {
+		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: "annotations not supported by this backend"})
+	}
+	ann, status, err := s.loadAnnotation(c, store, "load")
+	if err != nil {
+		return c.Status(status).JSON(llm.ErrorResponse{Error: err.Error()})
+	}
+	return c.JSON(ann)
+}
+
+// handleUpdateAnnotation handles PATCH /v1/annotations/:id. Only the
+// label, score and note change; the target, name and author are the
+// annotation's identity, so changing them is a delete and a new write.
+func (s *Server) handleUpdateAnnotation(c *fiber.Ctx) error {
+	store, ok := s.driver.(storage.AnnotationStore)
+	if !ok {
+		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: "annotations not supported by this backend"})
+	}
+
+	// Decode into raw messages first so an absent field (left alone) is
+	// distinguishable from an explicit null (cleared), as PATCH
+	// /v1/sessions/:id does.
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(c.Body(), &raw); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "invalid request body"})
+	}
+	for key := range raw {
+		if key != "label" && key != "score" && key != "note" {
+			return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "only label, score and note can change"})
+		}
+	}
+	if len(raw) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "one of label, score or note is required"})
+	}
+
+	ann, status, err := s.loadAnnotation(c, store, "update")
+	if err != nil {
+		return c.Status(status).JSON(llm.ErrorResponse{Error: err.Error()})
+	}
+	for key, value := range raw {
+		switch key {
+		case "score":
+			var score *float64
+			if err := json.Unmarshal(value, &score); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "score must be a number or null"})
+			}
+			ann.Score = score
+		default:
+			var text *string
+			if err := json.Unmarshal(value, &text); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: key + " must be a string or null"})
+			}
+			trimmed := ""
+			if text != nil {
+				trimmed = strings.TrimSpace(*text)
+			}
+			if key == "label" {
+				ann.Label = trimmed
+			} else {
+				ann.Note = trimmed
+			}
+		}
+	}
+	if err := validateAnnotationValue(*ann); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: err.Error()})
+	}
+
+	updated, err := store.UpdateAnnotation(c.Context(), s.orgID(c), *ann)
+	if errors.Is(err, storage.ErrAnnotationNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(llm.ErrorResponse{Error: "annotation not found"})
+	}
+	if err != nil {
+		s.logger.Error("update annotation", "id", ann.ID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to update annotation"})
+	}
+	return c.JSON(updated)
+}
+
+// handleDeleteAnnotation handles DELETE /v1/annotations/:id.
+func (s *Server) handleDeleteAnnotation(c *fiber.Ctx) error {
+	store, ok := s.driver.(storage.AnnotationStore)
+	if !ok {
+		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: "annotations not supported by this backend"})
+	}
+	ann, status, err := s.loadAnnotation(c, store, "delete")
+	if err != nil {
+		return c.Status(status).JSON(llm.ErrorResponse{Error: err.Error()})
+	}
+	if err := store.DeleteAnnotation(c.Context(), s.orgID(c), ann.ID); err != nil {
+		if errors.Is(err, storage.ErrAnnotationNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(llm.ErrorResponse{Error: "annotation not found"})
+		}
+		s.logger.Error("delete annotation", "id", ann.ID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to delete annotation"})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// parseAnnotationMatch reads the GET /v1/sessions annotation filters.
+func parseAnnotationMatch(c *fiber.Ctx) (storage.AnnotationMatch, error) {
+	match := storage.AnnotationMatch{
+		Name:  c.Query("annotation"),
+		Label: c.Query("annotation_label"),
+	}
+	score := func(param string) (*float64, error) {
+		raw := c.Query(param)
+		if raw == "" {
+			return nil, nil
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("%s must be a number", param)
+		}
+		return &v, nil
+	}
+	var err error
+	if match.MinScore, err = score("annotation_min_score"); err != nil {
+		return match, err
+	}
+	if match.MaxScore, err = score("annotation_max_score"); err != nil {
+		return match, err
+	}
+	if match.MinScore != nil && match.MaxScore != nil && *match.MinScore > *match.MaxScore {
+		return match, errors.New("annotation_min_score must not exceed annotation_max_score")
+	}
+	return match, nil
+}
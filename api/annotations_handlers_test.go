@@ -0,0 +1,310 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/llm"
+	tapeslogger "github.com/papercomputeco/tapes/pkg/logger"
+	"github.com/papercomputeco/tapes/pkg/storage"
+	"github.com/papercomputeco/tapes/pkg/storage/inmemory"
+)
+
+// annotationsStubDriver adds the storage.AnnotationStore capability to
+// the stats stub, keeping annotations in a map. Trace targets resolve to
+// traceSessions; any other trace is missing.
+type annotationsStubDriver struct {
+	*statsStubDriver
+
+	annotations   map[string]storage.Annotation
+	traceSessions map[string]string
+	nextID        int
+
+	putCalls   int
+	lastFilter storage.AnnotationFilter
+	stats      []storage.AnnotationStats
+}
+
+func newAnnotationsStub() *annotationsStubDriver {
+	return &annotationsStubDriver{
+		statsStubDriver: &statsStubDriver{Driver: inmemory.NewDriver()},
+		annotations:     map[string]storage.Annotation{},
+		traceSessions:   map[string]string{"trc_a": "11111111-1111-1111-1111-111111111111"},
+	}
+}
+
+func (d *annotationsStubDriver) PutAnnotations(_ context.Context, _, _ string, anns []storage.Annotation) ([]storage.Annotation, error) {
+	d.putCalls++
+	out := make([]storage.Annotation, 0, len(anns))
+	for _, ann := range anns {
+		if ann.TraceID != "" {
+			session, ok := d.traceSessions[ann.TraceID]
+			if !ok {
+				return nil, fmt.Errorf("%w: trace %s", storage.ErrAnnotationTarget, ann.TraceID)
+			}
+			ann.SessionID = session
+		}
+		out = append(out, ann)
+	}
+	for i := range out {
+		d.nextID++
+		out[i].ID = fmt.Sprintf("00000000-0000-0000-0000-%012d", d.nextID)
+		d.annotations[out[i].ID] = out[i]
+	}
+	return out, nil
+}
+
+func (d *annotationsStubDriver) GetAnnotation(_ context.Context, _, id string) (*storage.Annotation, error) {
+	ann, ok := d.annotations[id]
+	if !ok {
+		return nil, storage.ErrAnnotationNotFound
+	}
+	return &ann, nil
+}
+
+func (d *annotationsStubDriver) ListAnnotations(_ context.Context, _ string, filter storage.AnnotationFilter) ([]storage.Annotation, error) {
+	d.lastFilter = filter
+	return nil, nil
+}
+
+func (d *annotationsStubDriver) UpdateAnnotation(_ context.Context, _ string, ann storage.Annotation) (*storage.Annotation, error) {
+	if _, ok := d.annotations[ann.ID]; !ok {
+		return nil, storage.ErrAnnotationNotFound
+	}
+	d.annotations[ann.ID] = ann
+	return &ann, nil
+}
+
+func (d *annotationsStubDriver) DeleteAnnotation(_ context.Context, _, id string) error {
+	if _, ok := d.annotations[id]; !ok {
+		return storage.ErrAnnotationNotFound
+	}
+	delete(d.annotations, id)
+	return nil
+}
+
+func (d *annotationsStubDriver) AggregateAnnotations(_ context.Context, _ string, _, _ *time.Time, _ string) ([]storage.AnnotationStats, error) {
+	return d.stats, nil
+}
+
+var _ = Describe("annotation handlers", func() {
+	const sessionID = "22222222-2222-2222-2222-222222222222"
+
+	var drv *annotationsStubDriver
+
+	BeforeEach(func() {
+		drv = newAnnotationsStub()
+	})
+
+	send := func(driver storage.Driver, method, path, body string) (int, []byte) {
+		server, err := NewServer(Config{ListenAddr: ":0"}, driver, tapeslogger.NewNoop())
+		Expect(err).NotTo(HaveOccurred())
+		req, err := http.NewRequestWithContext(context.Background(), method, path, strings.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := server.app.Test(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		raw, err := io.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		return resp.StatusCode, raw
+	}
+	errorOf := func(raw []byte) string {
+		var body llm.ErrorResponse
+		Expect(json.Unmarshal(raw, &body)).To(Succeed())
+		return body.Error
+	}
+
+	Describe("POST /v1/annotations", func() {
+		It("stores a span judgment and fills its session from the trace", func() {
+			status, raw := send(drv, http.MethodPost, "/v1/annotations",
+				`{"trace_id":"trc_a","span_id":"spn_1","name":" correctness ","label":"fail","score":0.25,"author":"reviewer@acme"}`)
+
+			Expect(status).To(Equal(fiber.StatusCreated))
+			var ann storage.Annotation
+			Expect(json.Unmarshal(raw, &ann)).To(Succeed())
+			Expect(ann.ID).NotTo(BeEmpty())
+			Expect(ann.SessionID).To(Equal("11111111-1111-1111-1111-111111111111"))
+			Expect(ann.SpanID).To(Equal("spn_1"))
+			Expect(ann.Name).To(Equal("correctness"))
+			Expect(ann.Score).To(HaveValue(Equal(0.25)))
+			Expect(ann.Author).To(Equal("reviewer@acme"))
+		})
+
+		It("rejects an annotation with no target, no value, or a span without its trace", func() {
+			for body, want := range map[string]string{
+				`{"name":"helpful","label":"yes"}`:                                       "session_id or trace_id is required",
+				`{"session_id":"` + sessionID + `","name":"helpful"}`:                    "one of label, score or note is required",
+				`{"session_id":"` + sessionID + `","span_id":"s","name":"x","note":"n"}`: "span_id requires trace_id",
+				`{"session_id":"not-a-uuid","name":"helpful","label":"yes"}`:             "session_id must be a valid UUID",
+			} {
+				status, raw := send(drv, http.MethodPost, "/v1/annotations", body)
+				Expect(status).To(Equal(fiber.StatusBadRequest), body)
+				Expect(errorOf(raw)).To(Equal(want), body)
+			}
+			Expect(drv.putCalls).To(Equal(0))
+		})
+
+		It("answers a trace that does not exist with 400", func() {
+			status, raw := send(drv, http.MethodPost, "/v1/annotations", `{"trace_id":"trc_gone","name":"helpful","label":"yes"}`)
+			Expect(status).To(Equal(fiber.StatusBadRequest))
+			Expect(errorOf(raw)).To(ContainSubstring("trace trc_gone"))
+		})
+
+		It("returns 501 when the driver stores no annotations", func() {
+			status, _ := send(inmemory.NewDriver(), http.MethodPost, "/v1/annotations",
+				`{"session_id":"`+sessionID+`","name":"helpful","label":"yes"}`)
+			Expect(status).To(Equal(fiber.StatusNotImplemented))
+		})
+	})
+
+	Describe("POST /v1/annotations/import", func() {
+		It("writes every entry in one call", func() {
+			status, raw := send(drv, http.MethodPost, "/v1/annotations/import", `{"annotations":[
+				{"session_id":"`+sessionID+`","name":"task_success","label":"pass"},
+				{"trace_id":"trc_a","name":"task_success","score":1}
+			]}`)
+
+			Expect(status).To(Equal(fiber.StatusOK))
+			var body annotationImportResponse
+			Expect(json.Unmarshal(raw, &body)).To(Succeed())
+			Expect(body.Annotations).To(HaveLen(2))
+			Expect(body.Annotations[0].SessionID).To(Equal(sessionID))
+			Expect(body.Annotations[1].TraceID).To(Equal("trc_a"))
+			Expect(drv.putCalls).To(Equal(1))
+		})
+
+		It("names the offending entry and writes none of them", func() {
+			status, raw := send(drv, http.MethodPost, "/v1/annotations/import", `{"annotations":[
+				{"session_id":"`+sessionID+`","name":"task_success","label":"pass"},
+				{"session_id":"`+sessionID+`","label":"pass"}
+			]}`)
+
+			Expect(status).To(Equal(fiber.StatusBadRequest))
+			Expect(errorOf(raw)).To(Equal("annotations[1]: name is required"))
+			Expect(drv.putCalls).To(Equal(0))
+		})
+
+		It("rejects an empty import", func() {
+			status, _ := send(drv, http.MethodPost, "/v1/annotations/import", `{"annotations":[]}`)
+			Expect(status).To(Equal(fiber.StatusBadRequest))
+		})
+	})
+
+	Describe("GET /v1/annotations", func() {
+		It("threads the filters through and answers no match with []", func() {
+			status, raw := send(drv, http.MethodGet,
+				"/v1/annotations?session_id="+sessionID+"&name=correctness&label=fail&author=ci&limit=5000", "")
+
+			Expect(status).To(Equal(fiber.StatusOK))
+			Expect(string(raw)).To(MatchJSON(`{"items":[]}`))
+			Expect(drv.lastFilter.SessionID).To(Equal(sessionID))
+			Expect(drv.lastFilter.Name).To(Equal("correctness"))
+			Expect(drv.lastFilter.Label).To(Equal("fail"))
+			Expect(drv.lastFilter.Author).To(Equal("ci"))
+			Expect(drv.lastFilter.Limit).To(Equal(maxAnnotationsLimit))
+		})
+
+		It("rejects a malformed session_id", func() {
+			status, _ := send(drv, http.MethodGet, "/v1/annotations?session_id=nope", "")
+			Expect(status).To(Equal(fiber.StatusBadRequest))
+		})
+	})
+
+	Describe("/v1/annotations/{id}", func() {
+		var id string
+
+		BeforeEach(func() {
+			score := 0.5
+			stored, err := drv.PutAnnotations(context.Background(), "", "", []storage.Annotation{{
+				SessionID: sessionID, Name: "helpful", Label: "somewhat", Score: &score, Author: "ci",
+			}})
+			Expect(err).NotTo(HaveOccurred())
+			id = stored[0].ID
+		})
+
+		It("gets the annotation, and 404s an unknown one", func() {
+			status, raw := send(drv, http.MethodGet, "/v1/annotations/"+id, "")
+			Expect(status).To(Equal(fiber.StatusOK))
+			var ann storage.Annotation
+			Expect(json.Unmarshal(raw, &ann)).To(Succeed())
+			Expect(ann.Label).To(Equal("somewhat"))
+
+			status, _ = send(drv, http.MethodGet, "/v1/annotations/99999999-9999-9999-9999-999999999999", "")
+			Expect(status).To(Equal(fiber.StatusNotFound))
+			status, _ = send(drv, http.MethodGet, "/v1/annotations/nope", "")
+			Expect(status).To(Equal(fiber.StatusBadRequest))
+		})
+
+		It("patches the value, leaving absent fields alone and clearing null ones", func() {
+			status, raw := send(drv, http.MethodPatch, "/v1/annotations/"+id, `{"score":null,"note":"  cites the wrong file  "}`)
+
+			Expect(status).To(Equal(fiber.StatusOK))
+			var ann storage.Annotation
+			Expect(json.Unmarshal(raw, &ann)).To(Succeed())
+			Expect(ann.Label).To(Equal("somewhat"))
+			Expect(ann.Score).To(BeNil())
+			Expect(ann.Note).To(Equal("cites the wrong file"))
+			Expect(ann.Author).To(Equal("ci"))
+		})
+
+		It("refuses to change the identity or to leave nothing", func() {
+			status, raw := send(drv, http.MethodPatch, "/v1/annotations/"+id, `{"name":"other"}`)
+			Expect(status).To(Equal(fiber.StatusBadRequest))
+			Expect(errorOf(raw)).To(Equal("only label, score and note can change"))
+
+			status, raw = send(drv, http.MethodPatch, "/v1/annotations/"+id, `{"label":"","score":null}`)
+			Expect(status).To(Equal(fiber.StatusBadRequest))
+			Expect(errorOf(raw)).To(Equal("one of label, score or note is required"))
+			Expect(drv.annotations[id].Label).To(Equal("somewhat"))
+		})
+
+		It("deletes the annotation", func() {
+			status, _ := send(drv, http.MethodDelete, "/v1/annotations/"+id, "")
+			Expect(status).To(Equal(fiber.StatusNoContent))
+			Expect(drv.annotations).NotTo(HaveKey(id))
+
+			status, _ = send(drv, http.MethodDelete, "/v1/annotations/"+id, "")
+			Expect(status).To(Equal(fiber.StatusNotFound))
+		})
+	})
+
+	Describe("GET /v1/stats", func() {
+		It("reports the annotation aggregates beside the totals", func() {
+			drv.stats = []storage.AnnotationStats{{
+				Name: "correctness", Count: 3, Scored: 2, Mean: 0.75, Min: 0.5, Max: 1,
+				Labels: []storage.LabelCount{{Label: "pass", Count: 2}, {Label: "fail", Count: 1}},
+			}, {
+				Name: "reviewed", Count: 1,
+			}}
+			status, raw := send(drv, http.MethodGet, "/v1/stats", "")
+
+			Expect(status).To(Equal(fiber.StatusOK))
+			var body StatsResponse
+			Expect(json.Unmarshal(raw, &body)).To(Succeed())
+			Expect(body.Annotations).To(Equal([]AnnotationStats{{
+				Name: "correctness", Count: 3, Scored: 2, MeanScore: 0.75, MinScore: 0.5, MaxScore: 1,
+				Labels: []AnnotationLabelCount{{Label: "pass", Count: 2}, {Label: "fail", Count: 1}},
+			}, {
+				Name: "reviewed", Count: 1, Labels: []AnnotationLabelCount{},
+			}}))
+		})
+
+		It("reports no annotations when the driver stores none", func() {
+			status, raw := send(&statsStubDriver{Driver: inmemory.NewDriver()}, http.MethodGet, "/v1/stats", "")
+			Expect(status).To(Equal(fiber.StatusOK))
+			var body map[string]json.RawMessage
+			Expect(json.Unmarshal(raw, &body)).To(Succeed())
+			Expect(string(body["annotations"])).To(Equal("[]"))
+		})
+	})
+})
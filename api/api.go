@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
@@ -40,6 +41,10 @@ type Server struct {
 	// document are answering from the same set.
 	contracts []cassette.ContractVersion
 
+	// tokens verifies API tokens when built-in auth is enabled, and is nil
+	// when it is not: secured routes are then described but not enforced.
+	tokens storage.APITokenStore
+
 	// openapi is the live description of this server's own surface, populated
 	// by the same calls that register the routes. GET /openapi compiles it —
 	// there is no other source for the published contract — which is why a
@@ -90,6 +95,17 @@ func newServer(config Config, driver storage.Driver, log *slog.Logger, docs tape
 		openapi:        NewOpenAPIParser(docs),
 	}
 
+	if config.RequireAuth {
+		tokens, ok := driver.(storage.APITokenStore)
+		if !ok {
+			return nil, errors.New("api auth requires a storage driver with an API token store (postgres)")
+		}
+		s.tokens = tokens
+	}
+	if config.MultiTenant && !s.enforcesAuth() {
+		return nil, errors.New("multi-tenant mode requires api auth or oidc verification: an anonymous request has no org")
+	}
+
 	// Correlation is the outermost request middleware so every downstream log,
 	// including metrics/recovery error paths, can share one validated ID.
 	app.Use(requestIDMiddleware(log))
@@ -122,6 +138,7 @@ func newServer(config Config, driver storage.Driver, log *slog.Logger, docs tape
 		// served directly by the API binary and has no frontend build step.
 		// HTML, not API surface, so it is not described.
 		app.Get("/", s.handleWebUI)
+		app.Get("/ui/:asset", s.handleWebUIAsset)
 	}
 
 	// The MCP server reads cassette tools from the live registry on each
@@ -145,6 +162,9 @@ func newServer(config Config, driver storage.Driver, log *slog.Logger, docs tape
 	// parameterised route that would otherwise swallow it. The route table in
 	// openapi_routes.go preserves that order.
 	router := oasfiber.Wrap(app, s.openapi, oasfiber.WithUndocumented(oasfiber.Fail))
+	if err := s.declareAuth(); err != nil {
+		return nil, err
+	}
 	s.mountV1(router)
 
 	// API reference UI. Always mounted — the viewer JS comes from a CDN
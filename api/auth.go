@@ -0,0 +1,273 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/papercomputeco/tapes/pkg/apitoken"
+	"github.com/papercomputeco/tapes/pkg/cassette"
+	"github.com/papercomputeco/tapes/pkg/llm"
+	"github.com/papercomputeco/tapes/pkg/oidc"
+	"github.com/papercomputeco/tapes/pkg/storage"
+	oas "github.com/papercomputeco/tapes/pkg/tapesoapi"
+	"github.com/papercomputeco/tapes/pkg/tapesoapi/oasfiber"
+)
+
+// bearerAuthScheme is the security scheme every secured operation names.
+const bearerAuthScheme = "bearerAuth"
+
+// tokenTouchInterval bounds how often a token's last_used_at is written. A
+// busy client would otherwise turn every read into a write; to the operator
+// reading `tapes auth token list`, a minute's resolution is the same answer.
+const tokenTouchInterval = time.Minute
+
+// callerLocal is the fiber.Ctx local an authenticated request's caller is
+// stored under.
+const callerLocal = "tapes.api_caller"
+
+// caller is who an authenticated request came from: an API token or a
+// verified JWT, never both.
+type caller struct {
+	token    *storage.APIToken
+	identity *oidc.Identity
+
+	// ownSessionsOnly narrows the caller's reads to sessions captured
+	// under identity.Subject.
+	ownSessionsOnly bool
+}
+
+// orgID returns the org the caller's credential is bound to, or empty when
+// a JWT carries none.
+func (c *caller) orgID() string {
+	if c.token != nil {
+		return c.token.OrgID
+	}
+
+	return c.identity.OrgID
+}
+
+// grants reports whether the caller holds scope.
+//
+// A JWT carries no scopes of its own, so they are derived: admin from the
+// configured admin claim, and otherwise read, write, and every cassette. A
+// caller restricted to its own sessions loses the cassettes, whose responses
+// cannot be narrowed to one subject.
+func (c *caller) grants(scope string) bool {
+	if c.token != nil {
+		return apitoken.Grants(c.token.Scopes, scope)
+	}
+	if c.identity.Admin {
+		return true
+	}
+	if c.ownSessionsOnly {
+		return scope == apitoken.ScopeRead || scope == apitoken.ScopeWrite
+	}
+
+	return scope != apitoken.ScopeAdmin
+}
+
+// declareAuth publishes the bearer scheme secured operations name.
+//
+// It is declared whether or not this server enforces it. The contract is a
+// property of the API, not of one deployment's configuration: a generated
+// client must be able to authenticate against a deployment that turned
+// auth on, and one that did not simply accepts requests without it.
+func (s *Server) declareAuth() error {
+	return s.openapi.AddSecurityScheme(bearerAuthScheme, &oas.SecurityScheme{
+		Type:   "http",
+		Scheme: "bearer",
+		Description: "An API token minted with `tapes auth token create`, or a JWT from the " +
+			"deployment's identity provider when it configures oidc.jwks_url. Enforced only when the " +
+			"deployment enables either; the scopes each operation lists are the ones its token must " +
+			"hold. admin grants every scope and write grants read.",
+	}, oas.Provenance{Name: "api auth"})
+}
+
+// enforcesAuth reports whether this server checks credentials at all.
+func (s *Server) enforcesAuth() bool {
+	return s.tokens != nil || s.config.Verifier != nil
+}
+
+// secured returns a router whose routes require scope: enforced when this
+// server checks credentials, and described in the contract either way.
+func (s *Server) secured(router *oasfiber.Router, scope string) *oasfiber.Router {
+	var guard fiber.Handler
+	if s.enforcesAuth() {
+		guard = s.requireScope(func(*fiber.Ctx) string { return scope })
+	}
+
+	return router.Secured(oas.SecurityRequirement{bearerAuthScheme: {scope}}, guard)
+}
+
+// cassetteGuard returns the handler chain for a cassette proxy mount: the
+// cassette's own scope in front of the proxy when auth is on, the proxy
+// alone otherwise. A name that is not a valid cassette name requires admin;
+// the proxy would 404 it anyway, and only admin may learn that.
+func (s *Server) cassetteGuard(handler fiber.Handler) []fiber.Handler {
+	if !s.enforcesAuth() {
+		return []fiber.Handler{handler}
+	}
+
+	return []fiber.Handler{s.requireScope(func(c *fiber.Ctx) string {
+		name, err := cassette.ParseName(c.Params("name"))
+		if err != nil {
+			return apitoken.ScopeAdmin
+		}
+
+		return apitoken.CassetteScope(name)
+	}), handler}
+}
+
+// requireScope authenticates the request's bearer credential and checks it
+// holds the scope the route requires. A credential carrying the API token
+// prefix is looked up in the token table; anything else is verified as a JWT
+// when this server has a verifier.
+//
+// The credential is a tapes credential, so it is removed from the request
+// once verified: a cassette or MCP tool behind this server receives the
+// caller's other identity headers, never a secret minted for tapes.
+func (s *Server) requireScope(required func(*fiber.Ctx) string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		secret, ok := oidc.BearerToken(c.Get(fiber.HeaderAuthorization))
+		if !ok {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="tapes"`)
+
+			return c.Status(fiber.StatusUnauthorized).JSON(llm.ErrorResponse{Error: "missing bearer token"})
+		}
+
+		var who *caller
+		switch {
+		case s.tokens != nil && strings.HasPrefix(secret, apitoken.Prefix):
+			token, err := s.tokens.LookupAPIToken(c.UserContext(), apitoken.Hash(secret))
+			if errors.Is(err, storage.ErrAPITokenNotFound) {
+				c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="tapes", error="invalid_token"`)
+
+				return c.Status(fiber.StatusUnauthorized).JSON(llm.ErrorResponse{Error: "invalid, revoked, or expired token"})
+			}
+			if err != nil {
+				s.logger.Error("api token lookup", "error", err)
+
+				return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to verify token"})
+			}
+			s.touchToken(c, token)
+			who = &caller{token: token}
+
+		case s.config.Verifier != nil:
+			identity, err := s.config.Verifier.Verify(c.UserContext(), secret)
+			if err != nil {
+				return oidc.Reject(c, err, s.logger)
+			}
+			who = &caller{
+				identity:        identity,
+				ownSessionsOnly: s.config.OwnSessionsOnly && !identity.Admin,
+			}
+
+		default:
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="tapes", error="invalid_token"`)
+
+			return c.Status(fiber.StatusUnauthorized).JSON(llm.ErrorResponse{Error: "invalid, revoked, or expired token"})
+		}
+
+		if s.config.MultiTenant && who.orgID() == "" {
+			return c.Status(fiber.StatusForbidden).JSON(llm.ErrorResponse{Error: "credential carries no org"})
+		}
+
+		scope := required(c)
+		if !who.grants(scope) {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="tapes", error="insufficient_scope", scope="`+scope+`"`)
+
+			return c.Status(fiber.StatusForbidden).JSON(llm.ErrorResponse{Error: "credential lacks the " + scope + " scope"})
+		}
+
+		c.Request().Header.Del(fiber.HeaderAuthorization)
+		c.Locals(callerLocal, who)
+		if who.identity != nil {
+			oidc.SetIdentity(c, who.identity)
+		}
+
+		return c.Next()
+	}
+}
+
+// touchToken records the token's use, at most once per tokenTouchInterval.
+// A failed write is logged and the request proceeds: last use is a hint for
+// the operator, not part of the credential.
+func (s *Server) touchToken(c *fiber.Ctx, token *storage.APIToken) {
+	if token.LastUsedAt != nil && time.Since(*token.LastUsedAt) <= tokenTouchInterval {
+		return
+	}
+	if err := s.tokens.TouchAPIToken(c.UserContext(), token.ID); err != nil {
+		s.logger.Warn("record api token use", "token_id", token.ID, "error", err)
+	}
+}
+
+// unrestricted wraps handler so a caller restricted to its own sessions is
+// refused. It guards the routes whose responses span every session and
+// cannot be narrowed to one subject, such as MCP tools served by cassettes.
+func unrestricted(handler fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, restricted := ownSessionsSubject(c); restricted {
+			return c.Status(fiber.StatusForbidden).JSON(llm.ErrorResponse{Error: "this route requires the admin claim"})
+		}
+
+		return handler(c)
+	}
+}
+
+// ownSessionsSubject returns the subject this request's reads are narrowed
+// to, and whether they are narrowed at all.
+func ownSessionsSubject(c *fiber.Ctx) (string, bool) {
+	who, _ := c.Locals(callerLocal).(*caller)
+	if who == nil || !who.ownSessionsOnly {
+		return "", false
+	}
+
+	return who.identity.Subject, true
+}
+
+// callerName names this request's caller for the records it writes: the
+// JWT subject, or the API token's name. Empty when the server runs without
+// auth.
+func callerName(c *fiber.Ctx) string {
+	who, _ := c.Locals(callerLocal).(*caller)
+	switch {
+	case who == nil:
+		return ""
+	case who.token != nil:
+		return who.token.Name
+	default:
+		return who.identity.Subject
+	}
+}
+
+// sessionVisible reports whether sess may be shown to this request's caller.
+// A session hidden from the caller is answered exactly like a missing one,
+// so its existence is not disclosed either.
+func sessionVisible(c *fiber.Ctx, sess *storage.SessionRecord) bool {
+	subject, restricted := ownSessionsSubject(c)
+
+	return !restricted || sess.AuthSubject == subject
+}
+
+// sessionIDVisible is sessionVisible for a session known only by id, such as
+// the one a trace belongs to or one about to be changed. The session is only
+// loaded when the caller is restricted, and one that cannot be found is
+// hidden from such a caller.
+func (s *Server) sessionIDVisible(c *fiber.Ctx, sessionID string) (bool, error) {
+	if _, restricted := ownSessionsSubject(c); !restricted {
+		return true, nil
+	}
+	reader, ok := s.driver.(sessionsReader)
+	if !ok {
+		return false, nil
+	}
+	sess, err := reader.GetSessionRecord(c.Context(), s.orgID(c), sessionID)
+	if err != nil {
+		return false, err
+	}
+
+	return sess != nil && sessionVisible(c, sess), nil
+}
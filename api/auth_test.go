@@ -0,0 +1,364 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/apitoken"
+	"github.com/papercomputeco/tapes/pkg/oidc"
+	"github.com/papercomputeco/tapes/pkg/oidc/oidctest"
+	"github.com/papercomputeco/tapes/pkg/storage"
+	"github.com/papercomputeco/tapes/pkg/storage/inmemory"
+)
+
+// tokenDriver adds an in-memory token table to the in-memory driver, which
+// deliberately hosts none. The Postgres table is exercised against a live
+// database.
+type tokenDriver struct {
+	*inmemory.Driver
+	tokens  map[string]storage.APIToken
+	touched []string
+}
+
+func newTokenDriver() *tokenDriver {
+	return &tokenDriver{Driver: inmemory.NewDriver(), tokens: map[string]storage.APIToken{}}
+}
+
+func (d *tokenDriver) mint(scopes ...string) string {
+	secret, hash, err := apitoken.New()
+	Expect(err).NotTo(HaveOccurred())
+	d.tokens[string(hash)] = storage.APIToken{ID: apitoken.Display(secret), Scopes: scopes}
+	return secret
+}
+
+func (d *tokenDriver) CreateAPIToken(context.Context, storage.APITokenCreate) (*storage.APIToken, error) {
+	panic("not used")
+}
+
+func (d *tokenDriver) ListAPITokens(context.Context, bool) ([]storage.APIToken, error) {
+	panic("not used")
+}
+
+func (d *tokenDriver) LookupAPIToken(_ context.Context, hash []byte) (*storage.APIToken, error) {
+	tok, ok := d.tokens[string(hash)]
+	if !ok || tok.RevokedAt != nil {
+		return nil, storage.ErrAPITokenNotFound
+	}
+	return &tok, nil
+}
+
+func (d *tokenDriver) TouchAPIToken(_ context.Context, id string) error {
+	d.touched = append(d.touched, id)
+	return nil
+}
+
+func (d *tokenDriver) RevokeAPIToken(context.Context, string) error { panic("not used") }
+
+var _ = Describe("API token auth", func() {
+	var (
+		driver *tokenDriver
+		server *Server
+	)
+
+	BeforeEach(func() {
+		driver = newTokenDriver()
+		var err error
+		server, err = NewServer(Config{ListenAddr: ":0", RequireAuth: true}, driver, slog.New(slog.DiscardHandler))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	call := func(method, target, token string) *http.Response {
+		GinkgoHelper()
+		req, err := http.NewRequest(method, target, nil)
+		Expect(err).NotTo(HaveOccurred())
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := server.app.Test(req, -1)
+		Expect(err).NotTo(HaveOccurred())
+		return resp
+	}
+
+	It("refuses to enable auth on a driver without a token store", func() {
+		_, err := NewServer(Config{ListenAddr: ":0", RequireAuth: true}, inmemory.NewDriver(), slog.New(slog.DiscardHandler))
+		Expect(err).To(MatchError(ContainSubstring("API token store")))
+	})
+
+	It("leaves the health check open", func() {
+		Expect(call(http.MethodGet, "/ping", "").StatusCode).To(Equal(http.StatusOK))
+	})
+
+	It("rejects a request without a token, naming the scheme", func() {
+		resp := call(http.MethodGet, "/v1/sessions", "")
+		Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+		Expect(resp.Header.Get("WWW-Authenticate")).To(HavePrefix("Bearer"))
+	})
+
+	It("rejects an unknown token", func() {
+		Expect(call(http.MethodGet, "/v1/sessions", "tapes_not-a-real-token").StatusCode).
+			To(Equal(http.StatusUnauthorized))
+	})
+
+	It("admits a read token to reads and records its use", func() {
+		token := driver.mint(apitoken.ScopeRead)
+		Expect(call(http.MethodGet, "/v1/stats", token).StatusCode).NotTo(BeElementOf(http.StatusUnauthorized, http.StatusForbidden))
+		Expect(driver.touched).To(HaveLen(1))
+	})
+
+	It("forbids a read token from deleting a session", func() {
+		token := driver.mint(apitoken.ScopeRead)
+		resp := call(http.MethodDelete, "/v1/sessions/00000000-0000-0000-0000-000000000001", token)
+		Expect(resp.StatusCode).To(Equal(http.StatusForbidden))
+		Expect(resp.Header.Get("WWW-Authenticate")).To(ContainSubstring(`scope="write"`))
+	})
+
+	It("keeps admin routes behind the admin scope", func() {
+		Expect(call(http.MethodPost, "/v1/admin/derive/run", driver.mint(apitoken.ScopeWrite)).StatusCode).
+			To(Equal(http.StatusForbidden))
+		Expect(call(http.MethodPost, "/v1/admin/derive/run", driver.mint(apitoken.ScopeAdmin)).StatusCode).
+			NotTo(BeElementOf(http.StatusUnauthorized, http.StatusForbidden))
+	})
+
+	It("scopes a cassette token to its own cassette", func() {
+		token := driver.mint("cassette:notes")
+		// Past auth, an uninstalled cassette is the proxy's 404.
+		Expect(call(http.MethodGet, "/v1/cassettes/notes/items", token).StatusCode).To(Equal(http.StatusNotFound))
+		Expect(call(http.MethodGet, "/v1/cassettes/todo/items", token).StatusCode).To(Equal(http.StatusForbidden))
+		Expect(call(http.MethodGet, "/v1/sessions", token).StatusCode).To(Equal(http.StatusForbidden))
+	})
+
+	It("does not pass the token on past the guard", func() {
+		var seen string
+		server.app.Get("/auth-probe", server.requireScope(func(*fiber.Ctx) string { return apitoken.ScopeRead }),
+			func(c *fiber.Ctx) error {
+				seen = c.Get(fiber.HeaderAuthorization)
+				return c.SendStatus(http.StatusNoContent)
+			})
+
+		Expect(call(http.MethodGet, "/auth-probe", driver.mint(apitoken.ScopeRead)).StatusCode).To(Equal(http.StatusNoContent))
+		Expect(seen).To(BeEmpty())
+	})
+
+	It("does not re-record a token used within the touch interval", func() {
+		secret, hash, err := apitoken.New()
+		Expect(err).NotTo(HaveOccurred())
+		recent := time.Now()
+		driver.tokens[string(hash)] = storage.APIToken{ID: "recent", Scopes: []string{apitoken.ScopeRead}, LastUsedAt: &recent}
+
+		call(http.MethodGet, "/v1/stats", secret)
+		Expect(driver.touched).To(BeEmpty())
+	})
+})
+
+var _ = Describe("API without built-in auth", func() {
+	It("serves secured routes without a token", func() {
+		server, err := NewServer(Config{ListenAddr: ":0"}, inmemory.NewDriver(), slog.New(slog.DiscardHandler))
+		Expect(err).NotTo(HaveOccurred())
+		req, err := http.NewRequest(http.MethodGet, "/v1/stats", nil)
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := server.app.Test(req, -1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.StatusCode).NotTo(Equal(http.StatusUnauthorized))
+	})
+})
+
+var _ = Describe("JWT auth", func() {
+	const (
+		ownID   = "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"
+		otherID = "bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb"
+	)
+
+	var (
+		issuer *oidctest.Issuer
+		driver *sessionsStubDriver
+		server *Server
+	)
+
+	newServer := func(ownSessionsOnly bool) {
+		GinkgoHelper()
+		verifier, err := oidc.NewVerifier(oidc.Config{
+			JWKSURL:    issuer.JWKSURL(),
+			AdminClaim: "groups",
+			AdminValue: "tapes-admins",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		server, err = NewServer(Config{ListenAddr: ":0", Verifier: verifier, OwnSessionsOnly: ownSessionsOnly},
+			driver, slog.New(slog.DiscardHandler))
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		issuer = oidctest.NewIssuer()
+		DeferCleanup(issuer.Close)
+		driver = &sessionsStubDriver{
+			Driver:    inmemory.NewDriver(),
+			deletable: map[string]bool{otherID: true},
+			getRecord: &storage.SessionRecord{ID: otherID, AuthSubject: "bob"},
+			listRecords: []storage.SessionRecord{
+				{ID: ownID, AuthSubject: "ada"},
+			},
+		}
+	})
+
+	call := func(method, target, token string) *http.Response {
+		GinkgoHelper()
+		req, err := http.NewRequest(method, target, nil)
+		Expect(err).NotTo(HaveOccurred())
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := server.app.Test(req, -1)
+		Expect(err).NotTo(HaveOccurred())
+		return resp
+	}
+
+	user := func(sub string) string { return issuer.Token(map[string]any{"sub": sub}) }
+	admin := func(sub string) string {
+		return issuer.Token(map[string]any{"sub": sub, "groups": []string{"tapes-admins"}})
+	}
+
+	It("admits a verified token and rejects a forged one", func() {
+		newServer(false)
+		Expect(call(http.MethodGet, "/v1/sessions", user("ada")).StatusCode).To(Equal(http.StatusOK))
+
+		other := oidctest.NewIssuer()
+		defer other.Close()
+		resp := call(http.MethodGet, "/v1/sessions", other.Token(map[string]any{"sub": "ada"}))
+		Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("keeps admin routes behind the admin claim", func() {
+		newServer(false)
+		Expect(call(http.MethodPost, "/v1/admin/derive/run", user("ada")).StatusCode).To(Equal(http.StatusForbidden))
+		Expect(call(http.MethodPost, "/v1/admin/derive/run", admin("root")).StatusCode).
+			NotTo(BeElementOf(http.StatusUnauthorized, http.StatusForbidden))
+	})
+
+	Describe("restricted to own sessions", func() {
+		BeforeEach(func() { newServer(true) })
+
+		It("narrows the list to the caller's subject, whatever the filter asks for", func() {
+			Expect(call(http.MethodGet, "/v1/sessions?auth_subject=bob", user("ada")).StatusCode).To(Equal(http.StatusOK))
+			Expect(driver.lastAuthSubject).To(Equal("ada"))
+		})
+
+		It("answers another subject's session as not found", func() {
+			Expect(call(http.MethodGet, "/v1/sessions/"+otherID, user("ada")).StatusCode).To(Equal(http.StatusNotFound))
+			Expect(call(http.MethodGet, "/v1/sessions/"+otherID, user("bob")).StatusCode).To(Equal(http.StatusOK))
+		})
+
+		It("does not let the caller delete another subject's session", func() {
+			Expect(call(http.MethodDelete, "/v1/sessions/"+otherID, user("ada")).StatusCode).To(Equal(http.StatusNotFound))
+			Expect(driver.deleteCalls).To(BeZero())
+		})
+
+		It("lets the admin claim see every session", func() {
+			Expect(call(http.MethodGet, "/v1/sessions/"+otherID, admin("root")).StatusCode).To(Equal(http.StatusOK))
+			Expect(call(http.MethodGet, "/v1/sessions?auth_subject=bob", admin("root")).StatusCode).To(Equal(http.StatusOK))
+			Expect(driver.lastAuthSubject).To(Equal("bob"))
+		})
+
+		It("refuses the routes that cannot be narrowed", func() {
+			Expect(call(http.MethodPost, "/v1/mcp", user("ada")).StatusCode).To(Equal(http.StatusForbidden))
+			Expect(call(http.MethodGet, "/v1/cassettes/example/spec", user("ada")).StatusCode).To(Equal(http.StatusForbidden))
+		})
+	})
+})
+
+var _ = Describe("Multi-tenant mode", func() {
+	const (
+		acme   = "11111111-1111-1111-1111-111111111111"
+		globex = "22222222-2222-2222-2222-222222222222"
+	)
+
+	var (
+		issuer *oidctest.Issuer
+		server *Server
+	)
+
+	BeforeEach(func() {
+		issuer = oidctest.NewIssuer()
+		DeferCleanup(issuer.Close)
+	})
+
+	newServer := func(driver storage.Driver) {
+		GinkgoHelper()
+		verifier, err := oidc.NewVerifier(oidc.Config{JWKSURL: issuer.JWKSURL(), OrgClaim: "org_id"})
+		Expect(err).NotTo(HaveOccurred())
+		server, err = NewServer(Config{ListenAddr: ":0", Verifier: verifier, MultiTenant: true},
+			driver, slog.New(slog.DiscardHandler))
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	call := func(method, target, token string) *http.Response {
+		GinkgoHelper()
+		req, err := http.NewRequest(method, target, nil)
+		Expect(err).NotTo(HaveOccurred())
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		req.Header.Set(orgHeader, globex)
+		resp, err := server.app.Test(req, -1)
+		Expect(err).NotTo(HaveOccurred())
+		return resp
+	}
+
+	member := func(org string) string {
+		return issuer.Token(map[string]any{"sub": "ada", "org_id": org})
+	}
+
+	It("refuses to start without a way to know the caller's org", func() {
+		_, err := NewServer(Config{ListenAddr: ":0", MultiTenant: true}, inmemory.NewDriver(), slog.New(slog.DiscardHandler))
+		Expect(err).To(MatchError(ContainSubstring("multi-tenant")))
+	})
+
+	It("scopes reads to the verified org, not the one the client asserts", func() {
+		driver := &sessionsStubDriver{Driver: inmemory.NewDriver()}
+		newServer(driver)
+
+		Expect(call(http.MethodGet, "/v1/sessions", member(acme)).StatusCode).To(Equal(http.StatusOK))
+		Expect(driver.lastListOrg).To(Equal(acme))
+
+		Expect(call(http.MethodGet, "/v1/sessions/"+globex, member(acme)).StatusCode).To(Equal(http.StatusNotFound))
+		Expect(driver.lastGetOrgID).To(Equal(acme))
+	})
+
+	It("rejects a token whose org claim is missing or malformed", func() {
+		newServer(&sessionsStubDriver{Driver: inmemory.NewDriver()})
+		Expect(call(http.MethodGet, "/v1/sessions", issuer.Token(map[string]any{"sub": "ada"})).StatusCode).
+			To(Equal(http.StatusUnauthorized))
+		Expect(call(http.MethodGet, "/v1/sessions", member("acme")).StatusCode).
+			To(Equal(http.StatusUnauthorized))
+	})
+
+	It("scopes an API token to the org it was minted for", func() {
+		driver := newTokenDriver()
+		var err error
+		server, err = NewServer(Config{ListenAddr: ":0", RequireAuth: true, MultiTenant: true},
+			driver, slog.New(slog.DiscardHandler))
+		Expect(err).NotTo(HaveOccurred())
+
+		var seen string
+		server.app.Get("/org-probe", server.requireScope(func(*fiber.Ctx) string { return apitoken.ScopeRead }),
+			server.withOrg(func(c *fiber.Ctx) error {
+				seen = c.Get(orgHeader)
+				return c.SendStatus(http.StatusNoContent)
+			}))
+
+		secret, hash, err := apitoken.New()
+		Expect(err).NotTo(HaveOccurred())
+		driver.tokens[string(hash)] = storage.APIToken{ID: "acme", OrgID: acme, Scopes: []string{apitoken.ScopeRead}}
+		Expect(call(http.MethodGet, "/org-probe", secret).StatusCode).To(Equal(http.StatusNoContent))
+		Expect(seen).To(Equal(acme))
+
+		// A token minted before the deployment went multi-tenant has no org.
+		Expect(call(http.MethodGet, "/org-probe", driver.mint(apitoken.ScopeRead)).StatusCode).
+			To(Equal(http.StatusForbidden))
+	})
+})
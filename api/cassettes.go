@@ -13,6 +13,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/papercomputeco/tapes/api/cassetterunner"
+	"github.com/papercomputeco/tapes/pkg/apitoken"
 	"github.com/papercomputeco/tapes/pkg/cassette"
 	"github.com/papercomputeco/tapes/pkg/tapesoapi/oasfiber"
 )
@@ -37,7 +38,7 @@ func (s *Server) mountCassettes(router *oasfiber.Router) {
 	app.Get("/openapi", s.handleCassetteAggregate)
 	app.Get("/v1/cassettes/:name/openapi.json", s.handleCassetteSpec)
 
-	router.Get("/v1/cassettes", s.handleCassetteDiscovery,
+	s.secured(router, apitoken.ScopeRead).Get("/v1/cassettes", s.handleCassetteDiscovery,
 		oasfiber.Doc("listCassettes").
 			Summary("Discover installed cassettes").
 			Description("Lists the cassettes served by this API, their public route and OpenAPI "+
@@ -50,8 +51,11 @@ func (s *Server) mountCassettes(router *oasfiber.Router) {
 	// cassettes declare, and those operations appear in the merged document at
 	// /openapi under their real paths. Publishing the mount itself would hand a
 	// generated client a wildcard it cannot call.
-	app.All("/v1/cassettes/:name", s.handleCassetteProxy)
-	app.All("/v1/cassettes/:name/*", s.handleCassetteProxy)
+	//
+	// Each mount requires its own cassette's scope, so a token can be issued
+	// for one cassette without opening the rest of the API.
+	app.All("/v1/cassettes/:name", s.cassetteGuard(s.withOrg(s.handleCassetteProxy))...)
+	app.All("/v1/cassettes/:name/*", s.cassetteGuard(s.withOrg(s.handleCassetteProxy))...)
 }
 
 // SetCassetteSources configures exact full OpenAPI document URLs on this
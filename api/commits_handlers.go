@@ -0,0 +1,99 @@
+package api
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/papercomputeco/tapes/pkg/llm"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+// maxCommitSessions caps the sessions one commit lookup returns. A sha
+// is normally produced once; several sessions share it only when one
+// pushed what another committed, or a short prefix is ambiguous.
+const maxCommitSessions = 50
+
+// CommitSessionsResponse is the response for GET
+// /v1/commits/{sha}/sessions: the sessions that committed or pushed the
+// commit, most recently active first.
+type CommitSessionsResponse struct {
+	SHA      string          `json:"sha"`
+	Sessions []CommitSession `json:"sessions"`
+}
+
+// CommitSession is one session behind a commit, with every git artifact
+// it produced in order — so the branch it pushed and the pull request it
+// opened come along with the commit itself.
+type CommitSession struct {
+	SessionID string            `json:"session_id"`
+	Artifacts []GitArtifactItem `json:"artifacts"`
+}
+
+// GitArtifactItem is one commit, push or pull request, with the trace
+// and tool span whose shell call produced it. SHA is as git printed it,
+// usually abbreviated. URL is the remote a push went to, or a pull
+// request's page.
+type GitArtifactItem struct {
+	Kind    string    `json:"kind"`
+	TraceID string    `json:"trace_id"`
+	SpanID  string    `json:"span_id"`
+	SHA     string    `json:"sha,omitempty"`
+	Branch  string    `json:"branch,omitempty"`
+	URL     string    `json:"url,omitempty"`
+	Subject string    `json:"subject,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// handleCommitSessions handles GET /v1/commits/:sha/sessions.
+func (s *Server) handleCommitSessions(c *fiber.Ctx) error {
+	sha := strings.ToLower(strings.TrimSpace(c.Params("sha")))
+	if !validCommitSHA(sha) {
+		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "sha must be 7 to 64 hex characters"})
+	}
+	reader, ok := s.driver.(storage.CommitSessionReader)
+	if !ok {
+		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: "driver does not support commit lookups"})
+	}
+	subject := c.Query("auth_subject")
+	if own, restricted := ownSessionsSubject(c); restricted {
+		subject = own
+	}
+	artifacts, err := reader.ListCommitSessions(c.Context(), s.orgID(c), sha, subject, maxCommitSessions)
+	if err != nil {
+		s.logger.Error("list commit sessions", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to look up commit"})
+	}
+
+	resp := CommitSessionsResponse{SHA: sha, Sessions: []CommitSession{}}
+	bySession := map[string]int{}
+	for _, a := range artifacts {
+		i, seen := bySession[a.SessionID]
+		if !seen {
+			i = len(resp.Sessions)
+			bySession[a.SessionID] = i
+			resp.Sessions = append(resp.Sessions, CommitSession{SessionID: a.SessionID})
+		}
+		resp.Sessions[i].Artifacts = append(resp.Sessions[i].Artifacts, GitArtifactItem{
+			Kind:    a.Kind,
+			TraceID: a.TraceID,
+			SpanID:  a.SpanID,
+			SHA:     a.SHA,
+			Branch:  a.Branch,
+			URL:     a.URL,
+			Subject: a.Subject,
+			At:      a.At,
+		})
+	}
+	return c.JSON(resp)
+}
+
+// validCommitSHA accepts an abbreviated or full lowercase hex object
+// name, SHA-1 or SHA-256.
+func validCommitSHA(sha string) bool {
+	if len(sha) < 7 || len(sha) > 64 {
+		return false
+	}
+	return strings.Trim(sha, "0123456789abcdef") == ""
+}
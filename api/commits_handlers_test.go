@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	tapeslogger "github.com/papercomputeco/tapes/pkg/logger"
+	"github.com/papercomputeco/tapes/pkg/storage"
+	"github.com/papercomputeco/tapes/pkg/storage/inmemory"
+)
+
+// commitsStubDriver adds the storage.CommitSessionReader capability to a
+// real driver with canned artifacts, recording the sha it was asked for.
+type commitsStubDriver struct {
+	storage.Driver
+
+	artifacts []storage.GitArtifactRecord
+	lastSHA   string
+}
+
+func (d *commitsStubDriver) ListCommitSessions(_ context.Context, _, sha, _ string, _ int) ([]storage.GitArtifactRecord, error) {
+	d.lastSHA = sha
+	return d.artifacts, nil
+}
+
+var _ = Describe("GET /v1/commits/{sha}/sessions", func() {
+	get := func(driver storage.Driver, path string) *http.Response {
+		server, err := NewServer(Config{ListenAddr: ":0"}, driver, tapeslogger.NewNoop())
+		Expect(err).NotTo(HaveOccurred())
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, path, nil)
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := server.app.Test(req)
+		Expect(err).NotTo(HaveOccurred())
+		return resp
+	}
+	at := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	It("groups each session's artifacts under it", func() {
+		drv := &commitsStubDriver{
+			Driver: inmemory.NewDriver(),
+			artifacts: []storage.GitArtifactRecord{
+				{SessionID: "s1", TraceID: "trc_a", SpanID: "t1", Kind: "commit", SHA: "1a2b3c4", Branch: "fix/retry", Subject: "Fix", At: at},
+				{SessionID: "s1", TraceID: "trc_a", SpanID: "t4", Kind: "pull_request", URL: "https://github.com/acme/widgets/pull/42", At: at},
+				{SessionID: "s2", TraceID: "trc_b", SpanID: "t9", Kind: "push", SHA: "1a2b3c4", Branch: "main", At: at},
+			},
+		}
+		resp := get(drv, "/v1/commits/1A2B3C4D5E6F7A8B9C0D1E2F3A4B5C6D7E8F9A0B/sessions")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(fiber.StatusOK))
+		var body CommitSessionsResponse
+		Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+
+		Expect(drv.lastSHA).To(Equal("1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b"))
+		Expect(body.Sessions).To(HaveLen(2))
+		Expect(body.Sessions[0].SessionID).To(Equal("s1"))
+		Expect(body.Sessions[0].Artifacts).To(HaveLen(2))
+		Expect(body.Sessions[0].Artifacts[1].URL).To(Equal("https://github.com/acme/widgets/pull/42"))
+		Expect(body.Sessions[1].Artifacts[0].Kind).To(Equal("push"))
+	})
+
+	It("answers an unknown commit with no sessions", func() {
+		resp := get(&commitsStubDriver{Driver: inmemory.NewDriver()}, "/v1/commits/abcdef0/sessions")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(fiber.StatusOK))
+		var body CommitSessionsResponse
+		Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+		Expect(body.Sessions).To(BeEmpty())
+	})
+
+	It("rejects a sha that is too short or not hex", func() {
+		for _, path := range []string{"/v1/commits/abc/sessions", "/v1/commits/not-a-sha/sessions"} {
+			resp := get(&commitsStubDriver{Driver: inmemory.NewDriver()}, path)
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(fiber.StatusBadRequest), path)
+		}
+	})
+
+	It("returns 501 when the driver has no commit lookups", func() {
+		resp := get(inmemory.NewDriver(), "/v1/commits/abcdef0/sessions")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(fiber.StatusNotImplemented))
+	})
+})
@@ -4,6 +4,8 @@ package api
 
 import (
 	"github.com/papercomputeco/tapes/pkg/cassette"
+	"github.com/papercomputeco/tapes/pkg/embedding"
+	"github.com/papercomputeco/tapes/pkg/oidc"
 	"github.com/papercomputeco/tapes/pkg/sessions"
 )
 
@@ -16,14 +18,43 @@ type Config struct {
 	// compute per-session cost. When nil, sessions.DefaultPricing() is used.
 	Pricing sessions.PricingTable
 
-	// EnableWebUI serves the minimal browser UI at /. It is disabled by default
+	// EnableWebUI serves the session browser UI at /. It is disabled by default
 	// so API-only servers do not expose a human-facing development UI unless
 	// explicitly requested.
 	EnableWebUI bool
 
+	// RequireAuth enforces the built-in API token auth: every route except
+	// the health check, the metrics scrape, the web UI, and the published
+	// contract requires a bearer token holding the route's scope. It needs
+	// a driver that hosts the token table.
+	RequireAuth bool
+
+	// Verifier, when set, accepts a JWT from the deployment's identity
+	// provider as the bearer credential on every secured route, alongside
+	// API tokens when RequireAuth is also set. A verified caller holds read,
+	// write, and every cassette scope; one carrying the configured admin
+	// claim holds admin.
+	Verifier *oidc.Verifier
+
+	// OwnSessionsOnly restricts a verified non-admin JWT caller's reads to
+	// the sessions captured under its own subject. Routes whose responses
+	// cannot be narrowed that way (cassettes, MCP) then require admin.
+	// API tokens are operator-minted credentials and are never restricted.
+	OwnSessionsOnly bool
+
+	// MultiTenant scopes every request to the caller's org: the org its API
+	// token was minted for, or its JWT's org claim. Without it every read
+	// scopes to the single-tenant sentinel. It requires RequireAuth or a
+	// Verifier, since an anonymous request has no org.
+	MultiTenant bool
+
 	// ContractVersions is the set of tapes contracts this server serves. A
 	// cassette whose depends.core falls outside the set is refused at
 	// admission, and the newest entry is what the discovery document
 	// advertises as current. Empty means DefaultContractVersions().
 	ContractVersions []cassette.ContractVersion
+
+	// Embedder embeds the query of GET /v1/search/spans. It must be the
+	// model the embed worker writes with; nil answers the route 503.
+	Embedder embedding.Embedder
 }
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/papercomputeco/tapes/pkg/llm"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+const (
+	defaultFileTracesLimit = 500
+	maxFileTracesLimit     = 2000
+)
+
+// FilesResponse is the response for GET /v1/files: every session whose
+// tool calls touched a file, most recently active first. Truncated is set
+// when more traces matched than the limit let through; the oldest were
+// dropped.
+type FilesResponse struct {
+	Path      string        `json:"path"`
+	Sessions  []FileSession `json:"sessions"`
+	Truncated bool          `json:"truncated"`
+}
+
+// FileSession is one session's activity on the file, summed over its
+// traces, with the per-trace rows it was summed from.
+type FileSession struct {
+	SessionID string `json:"session_id"`
+	FileActivity
+	Traces []FileTrace `json:"traces"`
+}
+
+// FileTrace is one trace's activity on the file. Path is the path as the
+// agent named it, which a suffix query may match more than one of.
+type FileTrace struct {
+	TraceID string `json:"trace_id"`
+	Path    string `json:"path"`
+	FileActivity
+}
+
+// FileActivity counts the operations tool calls performed on a file and
+// the lines their edits added and removed.
+type FileActivity struct {
+	Reads          int       `json:"reads"`
+	Creates        int       `json:"creates"`
+	Edits          int       `json:"edits"`
+	Deletes        int       `json:"deletes"`
+	LinesAdded     int       `json:"lines_added"`
+	LinesRemoved   int       `json:"lines_removed"`
+	FirstTouchedAt time.Time `json:"first_touched_at"`
+	LastTouchedAt  time.Time `json:"last_touched_at"`
+}
+
+// add folds another trace's activity into a.
+func (a *FileActivity) add(b FileActivity) {
+	a.Reads += b.Reads
+	a.Creates += b.Creates
+	a.Edits += b.Edits
+	a.Deletes += b.Deletes
+	a.LinesAdded += b.LinesAdded
+	a.LinesRemoved += b.LinesRemoved
+	if a.FirstTouchedAt.IsZero() || b.FirstTouchedAt.Before(a.FirstTouchedAt) {
+		a.FirstTouchedAt = b.FirstTouchedAt
+	}
+	if b.LastTouchedAt.After(a.LastTouchedAt) {
+		a.LastTouchedAt = b.LastTouchedAt
+	}
+}
+
+// handleListFiles handles GET /v1/files.
+func (s *Server) handleListFiles(c *fiber.Ctx) error {
+	path := strings.TrimSpace(c.Query("path"))
+	if path == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "path is required"})
+	}
+	limit := defaultFileTracesLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "limit must be a positive integer"})
+		}
+		limit = min(parsed, maxFileTracesLimit)
+	}
+	reader, ok := s.driver.(storage.FileTouchReader)
+	if !ok {
+		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: "driver does not support file lookups"})
+	}
+	subject := c.Query("auth_subject")
+	if own, restricted := ownSessionsSubject(c); restricted {
+		subject = own
+	}
+	rows, err := reader.ListFileTouches(c.Context(), s.orgID(c), path, subject, limit+1)
+	if err != nil {
+		s.logger.Error("list file touches", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to list file touches"})
+	}
+
+	resp := FilesResponse{Path: path, Sessions: []FileSession{}}
+	if len(rows) > limit {
+		rows, resp.Truncated = rows[:limit], true
+	}
+	// rows arrive newest first, so sessions land in order of their most
+	// recent activity.
+	bySession := map[string]int{}
+	for _, r := range rows {
+		activity := FileActivity{
+			Reads:          r.Reads,
+			Creates:        r.Creates,
+			Edits:          r.Edits,
+			Deletes:        r.Deletes,
+			LinesAdded:     r.LinesAdded,
+			LinesRemoved:   r.LinesRemoved,
+			FirstTouchedAt: r.FirstAt,
+			LastTouchedAt:  r.LastAt,
+		}
+		i, seen := bySession[r.SessionID]
+		if !seen {
+			i = len(resp.Sessions)
+			bySession[r.SessionID] = i
+			resp.Sessions = append(resp.Sessions, FileSession{SessionID: r.SessionID})
+		}
+		session := &resp.Sessions[i]
+		session.add(activity)
+		session.Traces = append(session.Traces, FileTrace{TraceID: r.TraceID, Path: r.Path, FileActivity: activity})
+	}
+	return c.JSON(resp)
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	tapeslogger "github.com/papercomputeco/tapes/pkg/logger"
+	"github.com/papercomputeco/tapes/pkg/storage"
+	"github.com/papercomputeco/tapes/pkg/storage/inmemory"
+)
+
+// filesStubDriver adds the storage.FileTouchReader capability to a real
+// driver with canned rows, recording what it was asked for.
+type filesStubDriver struct {
+	storage.Driver
+
+	rows      []storage.FileTouchRecord
+	lastPath  string
+	lastLimit int
+}
+
+func (d *filesStubDriver) ListFileTouches(_ context.Context, _, path, _ string, limit int) ([]storage.FileTouchRecord, error) {
+	d.lastPath = path
+	d.lastLimit = limit
+	return d.rows[:min(len(d.rows), limit)], nil
+}
+
+var _ = Describe("GET /v1/files", func() {
+	get := func(driver storage.Driver, path string) *http.Response {
+		server, err := NewServer(Config{ListenAddr: ":0"}, driver, tapeslogger.NewNoop())
+		Expect(err).NotTo(HaveOccurred())
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, path, nil)
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := server.app.Test(req)
+		Expect(err).NotTo(HaveOccurred())
+		return resp
+	}
+	at := func(h int) time.Time { return time.Date(2026, 10, 1, h, 0, 0, 0, time.UTC) }
+
+	It("groups the trace rows by session, most recent first", func() {
+		drv := &filesStubDriver{
+			Driver: inmemory.NewDriver(),
+			rows: []storage.FileTouchRecord{
+				{SessionID: "s2", TraceID: "trc_c", Path: "/repo/api/server.go", Edits: 1, LinesAdded: 4, FirstAt: at(9), LastAt: at(9)},
+				{SessionID: "s1", TraceID: "trc_b", Path: "/repo/api/server.go", Edits: 2, LinesAdded: 10, LinesRemoved: 3, FirstAt: at(5), LastAt: at(6)},
+				{SessionID: "s1", TraceID: "trc_a", Path: "/repo/api/server.go", Reads: 1, Creates: 1, LinesAdded: 40, FirstAt: at(2), LastAt: at(3)},
+			},
+		}
+		resp := get(drv, "/v1/files?path=api/server.go")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(fiber.StatusOK))
+		var body FilesResponse
+		Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+
+		Expect(drv.lastPath).To(Equal("api/server.go"))
+		Expect(body.Path).To(Equal("api/server.go"))
+		Expect(body.Truncated).To(BeFalse())
+		Expect(body.Sessions).To(HaveLen(2))
+		Expect(body.Sessions[0].SessionID).To(Equal("s2"))
+
+		s1 := body.Sessions[1]
+		Expect(s1.Traces).To(HaveLen(2))
+		Expect(s1.Reads).To(Equal(1))
+		Expect(s1.Creates).To(Equal(1))
+		Expect(s1.Edits).To(Equal(2))
+		Expect(s1.LinesAdded).To(Equal(50))
+		Expect(s1.LinesRemoved).To(Equal(3))
+		Expect(s1.FirstTouchedAt).To(BeTemporally("==", at(2)))
+		Expect(s1.LastTouchedAt).To(BeTemporally("==", at(6)))
+	})
+
+	It("marks the response truncated when more rows matched than the limit", func() {
+		drv := &filesStubDriver{
+			Driver: inmemory.NewDriver(),
+			rows: []storage.FileTouchRecord{
+				{SessionID: "s1", TraceID: "trc_b", Path: "go.mod", LastAt: at(2)},
+				{SessionID: "s1", TraceID: "trc_a", Path: "go.mod", LastAt: at(1)},
+			},
+		}
+		resp := get(drv, "/v1/files?path=go.mod&limit=1")
+		defer resp.Body.Close()
+		var body FilesResponse
+		Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+		Expect(drv.lastLimit).To(Equal(2))
+		Expect(body.Truncated).To(BeTrue())
+		Expect(body.Sessions[0].Traces).To(HaveLen(1))
+	})
+
+	It("requires a path", func() {
+		resp := get(&filesStubDriver{Driver: inmemory.NewDriver()}, "/v1/files")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(fiber.StatusBadRequest))
+	})
+
+	It("returns 501 when the driver has no file lookups", func() {
+		resp := get(inmemory.NewDriver(), "/v1/files?path=go.mod")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(fiber.StatusNotImplemented))
+	})
+})
@@ -16,6 +16,7 @@ import (
 var apiExemptions = openapicheck.Exemptions{
 	Undocumented: map[string]string{
 		"/":                                 "optional web UI; HTML, not an API surface",
+		"/ui/{asset}":                       "the optional web UI's stylesheet and script, not an API surface",
 		"/metrics":                          "Prometheus exposition — scraped by convention, not called by clients",
 		"/openapi":                          "serves the contract; an operation whose response is the document it appears in is circular",
 		"/swagger":                          "the spec viewer itself; HTML, not an API surface",
@@ -27,7 +28,8 @@ var apiExemptions = openapicheck.Exemptions{
 		"/v1/mcp": "app.All mount; the MCP transport implements GET, POST, and DELETE",
 	},
 	Conditional: map[string]string{
-		"/": "mounted only when Config.EnableWebUI is set",
+		"/":           "mounted only when Config.EnableWebUI is set",
+		"/ui/{asset}": "mounted only when Config.EnableWebUI is set",
 	},
 }
 
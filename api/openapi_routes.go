@@ -3,6 +3,7 @@ package api
 import (
 	"github.com/gofiber/adaptor/v2"
 
+	"github.com/papercomputeco/tapes/pkg/apitoken"
 	"github.com/papercomputeco/tapes/pkg/llm"
 	"github.com/papercomputeco/tapes/pkg/seed"
 	"github.com/papercomputeco/tapes/pkg/storage"
@@ -21,12 +22,21 @@ import (
 // description that names no route does not compile.
 
 // mountV1 registers every documented route on the read API.
+//
+// Each surface registers on a router secured by the scope it needs. MCP
+// needs write rather than read because a tools/call reaches a cassette and
+// may change what it serves.
 func (s *Server) mountV1(router *oasfiber.Router) {
+	read := s.secured(router, apitoken.ScopeRead)
+	write := s.secured(router, apitoken.ScopeWrite)
+
 	s.mountHealth(router)
-	s.mountSessions(router)
-	s.mountTraces(router)
-	s.mountAdmin(router)
-	s.mountMCP(router)
+	s.mountSessions(read, write)
+	s.mountAnnotations(read, write)
+	s.mountTraces(read)
+	s.mountSearch(read)
+	s.mountAdmin(s.secured(router, apitoken.ScopeAdmin))
+	s.mountMCP(write)
 }
 
 func (s *Server) mountHealth(router *oasfiber.Router) {
@@ -38,7 +48,7 @@ func (s *Server) mountHealth(router *oasfiber.Router) {
 			ContentResponse(200, "pong", "application/json", oas.String()))
 }
 
-func (s *Server) mountSessions(router *oasfiber.Router) {
+func (s *Server) mountSessions(router, write *oasfiber.Router) {
 	router.Get("/v1/stats", s.handleStats,
 		oasfiber.Doc("getStats").
 			Summary("Get aggregate session stats").
@@ -47,7 +57,9 @@ func (s *Server) mountSessions(router *oasfiber.Router) {
 				"agent time = sum of trace durations) so they agree with the session and trace views; "+
 				"turn_count counts traces. Filter the window with since/until, and narrow every total "+
 				"to one user with auth_subject — the same subject the /v1/sessions filter takes, so a "+
-				"personal surface can show totals that match the rows beside them.").
+				"personal surface can show totals that match the rows beside them. annotations folds "+
+				"the annotations on the window's sessions per name: counts, score spread and label "+
+				"counts.").
 			Tag("sessions").
 			QueryParam("since", oas.String(oas.Format("date-time")),
 				oas.ParamDescription("Only include records at or after this RFC3339 timestamp")).
@@ -61,6 +73,68 @@ func (s *Server) mountSessions(router *oasfiber.Router) {
 			JSONResponse(400, "Invalid query parameters", s.errorSchema()).
 			JSONResponse(500, "Failed to compute stats", s.errorSchema()))
 
+	router.Get("/v1/tools", s.handleTools,
+		oasfiber.Doc("getToolStats").
+			Summary("Get per-tool execution stats").
+			Description("Returns call, error and retry counts, error rate, and p50/p95 execution "+
+				"latency per tool over the tool spans started in the window, busiest tool first. "+
+				"Tools are keyed by their derive-time identity: the tool name without MCP "+
+				"namespacing, the MCP server providing it, and for shell calls the program run. "+
+				"Latency runs from the tool_use to its tool_result. Takes the same since/until "+
+				"window and auth_subject filter as /v1/stats.").
+			Tag("sessions").
+			QueryParam("since", oas.String(oas.Format("date-time")),
+				oas.ParamDescription("Only include tool calls at or after this RFC3339 timestamp")).
+			QueryParam("until", oas.String(oas.Format("date-time")),
+				oas.ParamDescription("Only include tool calls before this RFC3339 timestamp")).
+			QueryParam("auth_subject", oas.String(),
+				oas.ParamDescription("Narrow the figures to sessions captured for this gateway-stamped "+
+					"JWT subject (exact match). Omitted, they are org-wide")).
+			JSONResponse(200, "Per-tool stats for the window", s.schema(ToolsResponse{})).
+			JSONResponse(400, "Invalid query parameters", s.errorSchema()).
+			JSONResponse(500, "Failed to compute tool stats", s.errorSchema()).
+			JSONResponse(501, "The storage driver does not support tool analytics", s.errorSchema()))
+
+	router.Get("/v1/files", s.handleListFiles,
+		oasfiber.Doc("listFileSessions").
+			Summary("Find the sessions that touched a file").
+			Description("Returns every session whose tool calls read, created, edited or deleted "+
+				"the file, most recently active first, with per-session sums and the per-trace rows "+
+				"behind them. Activity is folded at derive time from Read/Write/Edit/MultiEdit, "+
+				"apply_patch and shell tool calls. A relative path also matches the absolute paths "+
+				"that end with it at a directory boundary.").
+			Tag("sessions").
+			QueryParam("path", oas.String(),
+				oas.ParamDescription("File path, absolute or relative (required)")).
+			QueryParam("limit", oas.Integer(oas.Minimum(1)),
+				oas.ParamDescription("Maximum number of trace rows to read (default 500, max 2000); "+
+					"truncated is set when more matched")).
+			QueryParam("auth_subject", oas.String(),
+				oas.ParamDescription("Narrow the sessions to those captured for this gateway-stamped "+
+					"JWT subject (exact match)")).
+			JSONResponse(200, "Sessions that touched the file", s.schema(FilesResponse{})).
+			JSONResponse(400, "Missing path or invalid query parameters", s.errorSchema()).
+			JSONResponse(500, "Failed to list file touches", s.errorSchema()).
+			JSONResponse(501, "The storage driver does not support file lookups", s.errorSchema()))
+
+	router.Get("/v1/commits/:sha/sessions", s.handleCommitSessions,
+		oasfiber.Doc("listCommitSessions").
+			Summary("Find the sessions behind a commit").
+			Description("Returns the sessions whose shell calls committed or pushed the commit, most "+
+				"recently active first, each with every git artifact it produced: commits, pushed "+
+				"branches, and pull requests opened with gh pr create. Artifacts are read at derive time "+
+				"from the calls and their output, so shas are as git printed them, usually abbreviated; "+
+				"the lookup matches them against a full or abbreviated sha either way.").
+			Tag("sessions").
+			PathParam("sha", oas.String(), oas.ParamDescription("Commit sha, 7 to 64 hex characters")).
+			QueryParam("auth_subject", oas.String(),
+				oas.ParamDescription("Narrow the sessions to those captured for this gateway-stamped "+
+					"JWT subject (exact match)")).
+			JSONResponse(200, "Sessions behind the commit; empty when none is known", s.schema(CommitSessionsResponse{})).
+			JSONResponse(400, "Malformed sha", s.errorSchema()).
+			JSONResponse(500, "Failed to look up the commit", s.errorSchema()).
+			JSONResponse(501, "The storage driver does not support commit lookups", s.errorSchema()))
+
 	router.Get("/v1/sessions", s.handleListSessions,
 		oasfiber.Doc("listSessions").
 			Summary("List sessions").
@@ -83,17 +157,38 @@ func (s *Server) mountSessions(router *oasfiber.Router) {
 			QueryParam("until", oas.String(oas.Format("date-time")),
 				oas.ParamDescription("Only include sessions with a turn started before this RFC3339 "+
 					"timestamp (activity window, matches /v1/stats)")).
+			QueryParam("outcome", oas.String(oas.Enum(
+				"completed", "interrupted", "errored", "refused", "looped",
+				"turn_limit", "gave_up", "awaiting_user", "abandoned", "failed", "unknown")),
+				oas.ParamDescription("Only include sessions that ended with this outcome")).
+			QueryParam("outcome_reason", oas.String(),
+				oas.ParamDescription("Only include sessions with an outcome reason of this code "+
+					"(loop|interrupted|terminal_error|refusal|turn_limit|gave_up|awaiting_user|unanswered) "+
+					"or terminal error category (rate_limit|overloaded|context_length|auth|timeout|"+
+					"max_tokens|provider_error|tool_error|tool_error_rate)")).
+			QueryParam("annotation", oas.String(),
+				oas.ParamDescription("Only include sessions with an annotation of this name, on the "+
+					"session or any of its traces and spans. The annotation filters apply to one "+
+					"annotation together: a name with a score range keeps sessions where that name "+
+					"scored in range")).
+			QueryParam("annotation_label", oas.String(),
+				oas.ParamDescription("Only include sessions with an annotation carrying this label")).
+			QueryParam("annotation_min_score", oas.Number(),
+				oas.ParamDescription("Only include sessions with an annotation scored at or above this")).
+			QueryParam("annotation_max_score", oas.Number(),
+				oas.ParamDescription("Only include sessions with an annotation scored at or below this")).
 			QueryParam("harness_id", oas.String(),
 				oas.ParamDescription("Combined with harness_session_id, narrows the filter to the "+
 					"single session with this harness id (exact match). Rejected alone (400): a "+
 					"harness id names a harness, not a session. Incompatible with cursor, sort, "+
-					"direction, since, and until (400); limit is ignored when the filter is active")).
+					"direction, since, until, outcome, and outcome_reason (400); limit is ignored "+
+					"when the filter is active")).
 			QueryParam("harness_session_id", oas.String(),
 				oas.ParamDescription("Filter to sessions with this harness session id (exact match). "+
 					"Alone it matches across all harnesses — the id is unique per harness, so at most "+
 					"one row per harness returns, in practice zero or one; with harness_id it is a "+
 					"single-harness point lookup. Incompatible with cursor, sort, direction, since, "+
-					"and until (400); limit is ignored when the filter is active")).
+					"until, outcome, and outcome_reason (400); limit is ignored when the filter is active")).
 			QueryParam("auth_subject", oas.String(),
 				oas.ParamDescription("Filter the paged list to sessions captured for this "+
 					"gateway-stamped JWT subject (exact match; ignored on the harness filter path)")).
@@ -103,6 +198,27 @@ func (s *Server) mountSessions(router *oasfiber.Router) {
 			JSONResponse(500, "Failed to list sessions", s.errorSchema()).
 			JSONResponse(501, "Sessions not supported by this backend", s.errorSchema()))
 
+	// Registered ahead of the /v1/sessions/:id family, which would
+	// otherwise take "compare" for an id.
+	router.Get("/v1/sessions/compare", s.handleCompareSessions,
+		oasfiber.Doc("compareSessions").
+			Summary("Compare two sessions").
+			Description("Aligns two sessions' traces by prompt similarity and tool sequence, and "+
+				"their tool calls by tool name, then reports the differences: tool calls added, "+
+				"dropped or changed, replies that moved, token, cost and duration deltas (b minus "+
+				"a), and the outcome. Built for A/B runs of one task across a prompt or model "+
+				"change.").
+			Tag("sessions").
+			QueryParam("a", oas.String(oas.Format("uuid")), oas.ParamRequired(),
+				oas.ParamDescription("Baseline session id")).
+			QueryParam("b", oas.String(oas.Format("uuid")), oas.ParamRequired(),
+				oas.ParamDescription("Candidate session id")).
+			JSONResponse(200, "The two sessions aligned and compared", s.schema(SessionCompareResponse{})).
+			JSONResponse(400, "Missing or malformed a or b", s.errorSchema()).
+			JSONResponse(404, "Session not found", s.errorSchema()).
+			JSONResponse(500, "Failed to load a session", s.errorSchema()).
+			JSONResponse(501, "Span traces not supported by this backend", s.errorSchema()))
+
 	router.Get("/v1/sessions/:id/traces", s.handleGetSessionTraces,
 		oasfiber.Doc("getSessionTraces").
 			Summary("Get a session's trace/span projection").
@@ -147,7 +263,7 @@ func (s *Server) mountSessions(router *oasfiber.Router) {
 			JSONResponse(500, "Failed to load session", s.errorSchema()).
 			JSONResponse(501, "Sessions not supported by this backend", s.errorSchema()))
 
-	router.Delete("/v1/sessions/:id", s.handleDeleteSession,
+	write.Delete("/v1/sessions/:id", s.handleDeleteSession,
 		oasfiber.Doc("deleteSession").
 			Summary("Delete a session").
 			Description("Permanently deletes a session and its subtree: subagent child sessions and "+
@@ -161,7 +277,7 @@ func (s *Server) mountSessions(router *oasfiber.Router) {
 			JSONResponse(500, "Failed to delete session", s.errorSchema()).
 			JSONResponse(501, "Sessions not supported by this backend", s.errorSchema()))
 
-	router.Patch("/v1/sessions/:id", s.handleUpdateSession,
+	write.Patch("/v1/sessions/:id", s.handleUpdateSession,
 		oasfiber.Doc("updateSession").
 			Summary("Update a session's title").
 			Description("Updates the user-editable display_name. An absent field is a 400; null or "+
@@ -178,6 +294,121 @@ func (s *Server) mountSessions(router *oasfiber.Router) {
 			JSONResponse(501, "Sessions not supported by this backend", s.errorSchema()))
 }
 
+func (s *Server) mountAnnotations(router, write *oasfiber.Router) {
+	router.Get("/v1/annotations", s.handleListAnnotations,
+		oasfiber.Doc("listAnnotations").
+			Summary("List annotations").
+			Description("Returns annotations — labels, scores and notes attached to sessions, traces "+
+				"and spans — oldest first. Every filter is an exact match.").
+			Tag("annotations").
+			QueryParam("session_id", oas.String(oas.Format("uuid")),
+				oas.ParamDescription("Only annotations on this session or its traces and spans")).
+			QueryParam("trace_id", oas.String(), oas.ParamDescription("Only annotations on this trace or its spans")).
+			QueryParam("span_id", oas.String(), oas.ParamDescription("Only annotations on this span")).
+			QueryParam("name", oas.String(), oas.ParamDescription("Only annotations with this name")).
+			QueryParam("label", oas.String(), oas.ParamDescription("Only annotations with this label")).
+			QueryParam("author", oas.String(), oas.ParamDescription("Only annotations by this author")).
+			QueryParam("limit", oas.Integer(oas.Minimum(1)),
+				oas.ParamDescription("Maximum number of annotations to return (default 100, max 1000)")).
+			JSONResponse(200, "Matching annotations", s.schema(AnnotationListResponse{})).
+			JSONResponse(400, "Invalid query parameters", s.errorSchema()).
+			JSONResponse(500, "Failed to list annotations", s.errorSchema()).
+			JSONResponse(501, "Annotations not supported by this backend", s.errorSchema()))
+
+	write.Post("/v1/annotations", s.handleCreateAnnotation,
+		oasfiber.Doc("createAnnotation").
+			Summary("Annotate a session, trace or span").
+			Description("Attaches a label, a numeric score and/or a free-text note under a name to a "+
+				"session (session_id), a trace (trace_id) or a span (trace_id and span_id). A trace "+
+				"target needs no session_id. author defaults to the caller. Annotations live outside "+
+				"the derived projection and attach by the deterministic trace and span ids, so a "+
+				"re-derive keeps them. Writing the same target, name and author again replaces the "+
+				"label, score and note.").
+			Tag("annotations").
+			JSONBody("Annotation", s.schema(annotationRequest{})).
+			JSONResponse(201, "The stored annotation", s.schema(storage.Annotation{})).
+			JSONResponse(400, "Invalid payload, or a target that does not exist", s.errorSchema()).
+			JSONResponse(500, "Failed to write the annotation", s.errorSchema()).
+			JSONResponse(501, "Annotations not supported by this backend", s.errorSchema()))
+
+	write.Post("/v1/annotations/import", s.handleImportAnnotations,
+		oasfiber.Doc("importAnnotations").
+			Summary("Import annotations in bulk").
+			Description("Writes up to 1000 annotations in one transaction: all of them land, or none "+
+				"do. Each entry is a POST /v1/annotations body, so a label sheet or an evaluator's "+
+				"scores can be re-imported and replace their earlier values.").
+			Tag("annotations").
+			JSONBody("Annotations", s.schema(annotationImportRequest{})).
+			JSONResponse(200, "The stored annotations, in request order", s.schema(annotationImportResponse{})).
+			JSONResponse(400, "Invalid payload, or a target that does not exist", s.errorSchema()).
+			JSONResponse(500, "Failed to write the annotations", s.errorSchema()).
+			JSONResponse(501, "Annotations not supported by this backend", s.errorSchema()))
+
+	router.Get("/v1/annotations/:id", s.handleGetAnnotation,
+		oasfiber.Doc("getAnnotation").
+			Summary("Get an annotation").
+			Tag("annotations").
+			PathParam("id", oas.String(), oas.ParamDescription("Annotation id (UUID)")).
+			JSONResponse(200, "The annotation", s.schema(storage.Annotation{})).
+			JSONResponse(400, "Malformed id", s.errorSchema()).
+			JSONResponse(404, "Annotation not found", s.errorSchema()).
+			JSONResponse(500, "Failed to load the annotation", s.errorSchema()).
+			JSONResponse(501, "Annotations not supported by this backend", s.errorSchema()))
+
+	write.Patch("/v1/annotations/:id", s.handleUpdateAnnotation,
+		oasfiber.Doc("updateAnnotation").
+			Summary("Update an annotation").
+			Description("Changes the label, score or note; an absent field is left alone and null "+
+				"clears it. The target, name and author cannot change.").
+			Tag("annotations").
+			PathParam("id", oas.String(), oas.ParamDescription("Annotation id (UUID)")).
+			JSONBody("Update request", s.schema(annotationUpdateRequest{})).
+			JSONResponse(200, "The updated annotation", s.schema(storage.Annotation{})).
+			JSONResponse(400, "Malformed id or payload, or an update that leaves no label, score or "+
+				"note", s.errorSchema()).
+			JSONResponse(404, "Annotation not found", s.errorSchema()).
+			JSONResponse(500, "Failed to update the annotation", s.errorSchema()).
+			JSONResponse(501, "Annotations not supported by this backend", s.errorSchema()))
+
+	write.Delete("/v1/annotations/:id", s.handleDeleteAnnotation,
+		oasfiber.Doc("deleteAnnotation").
+			Summary("Delete an annotation").
+			Tag("annotations").
+			PathParam("id", oas.String(), oas.ParamDescription("Annotation id (UUID)")).
+			EmptyResponse(204, "Annotation deleted").
+			JSONResponse(400, "Malformed id", s.errorSchema()).
+			JSONResponse(404, "Annotation not found", s.errorSchema()).
+			JSONResponse(500, "Failed to delete the annotation", s.errorSchema()).
+			JSONResponse(501, "Annotations not supported by this backend", s.errorSchema()))
+}
+
+func (s *Server) mountSearch(router *oasfiber.Router) {
+	router.Get("/v1/search/spans", s.handleSearchSpans,
+		oasfiber.Doc("searchSpans").
+			Summary("Search spans by meaning").
+			Description("Returns the main-conversation llm spans nearest the query, best first, each with "+
+				"its session, trace and the turn's user prompt. The query is embedded with the server's "+
+				"configured embedding model and compared by cosine similarity against the vectors the "+
+				"embed worker (tapes serve embed-worker) wrote under the same model, so spans derived "+
+				"since the worker last caught up are not found yet.").
+			Tag("search").
+			QueryParam("q", oas.String(), oas.ParamRequired(),
+				oas.ParamDescription("Query text")).
+			QueryParam("mode", oas.String(oas.Enum("semantic")),
+				oas.ParamDescription("Search mode; semantic is the default and the only mode")).
+			QueryParam("limit", oas.Integer(oas.Minimum(1)),
+				oas.ParamDescription("Maximum number of hits (default 10, max 50)")).
+			QueryParam("auth_subject", oas.String(),
+				oas.ParamDescription("Narrow the hits to sessions captured for this gateway-stamped "+
+					"JWT subject (exact match)")).
+			JSONResponse(200, "Matching spans, best first", s.schema(SpanSearchResponse{})).
+			JSONResponse(400, "Missing q or invalid query parameters", s.errorSchema()).
+			JSONResponse(500, "Failed to search spans", s.errorSchema()).
+			JSONResponse(501, "The storage driver does not support span search", s.errorSchema()).
+			JSONResponse(502, "The embedder failed to embed the query", s.errorSchema()).
+			JSONResponse(503, "No embedder is configured", s.errorSchema()))
+}
+
 func (s *Server) mountTraces(router *oasfiber.Router) {
 	router.Get("/v1/traces", s.handleListTraceSummaries,
 		oasfiber.Doc("listTraces").
@@ -268,6 +499,67 @@ func (s *Server) mountAdmin(router *oasfiber.Router) {
 			JSONResponse(409, "Correlation selector is ambiguous", s.errorSchema()).
 			JSONResponse(500, "Repair failed", s.errorSchema()).
 			JSONResponse(501, "Driver does not support attribution repair", s.errorSchema()))
+
+	router.Get("/v1/admin/pricing", s.handleListPricing,
+		oasfiber.Doc("listPricing").
+			Summary("List the pricing catalog (operator)").
+			Description("Returns the effective-dated pricing catalog and the caller's org's negotiated "+
+				"discounts. Derive costs each llm span at the catalog price in effect at the span's "+
+				"start, less any discount; models the catalog does not cover use the built-in rates.").
+			Tag("admin").
+			QueryParam("model", oas.String(), oas.ParamDescription("Only list prices for this model")).
+			JSONResponse(200, "Catalog prices and discounts", s.schema(pricingResponse{})).
+			JSONResponse(500, "Failed to list pricing", s.errorSchema()).
+			JSONResponse(501, "Driver does not host the pricing catalog", s.errorSchema()))
+
+	router.Post("/v1/admin/pricing/prices", s.handleImportPrices,
+		oasfiber.Doc("importPrices").
+			Summary("Import a price sheet (operator)").
+			Description("Adds catalog prices taking effect at effective_from. Re-importing a sheet "+
+				"with the same effective_from replaces that date's rows. Stored span costs change on "+
+				"the next derive run. Multi-tenant deployments share the catalog, so only the "+
+				"operator may change it, with tapes pricing import.").
+			Tag("admin").
+			JSONBody("Price sheet", s.schema(pricingImportRequest{})).
+			JSONResponse(200, "The stored catalog rows", s.schema(pricingImportResponse{})).
+			JSONResponse(400, "Invalid payload", s.errorSchema()).
+			JSONResponse(403, "Catalog is shared in a multi-tenant deployment", s.errorSchema()).
+			JSONResponse(500, "Failed to import prices", s.errorSchema()).
+			JSONResponse(501, "Driver does not host the pricing catalog", s.errorSchema()))
+
+	router.Delete("/v1/admin/pricing/prices/:id", s.handleDeletePrice,
+		oasfiber.Doc("deletePrice").
+			Summary("Delete a catalog price (operator)").
+			Tag("admin").
+			PathParam("id", oas.String(), oas.ParamDescription("Catalog price id (UUID)")).
+			EmptyResponse(204, "Price deleted").
+			JSONResponse(403, "Catalog is shared in a multi-tenant deployment", s.errorSchema()).
+			JSONResponse(404, "Price not found", s.errorSchema()).
+			JSONResponse(500, "Failed to delete price", s.errorSchema()).
+			JSONResponse(501, "Driver does not host the pricing catalog", s.errorSchema()))
+
+	router.Post("/v1/admin/pricing/discounts", s.handleCreatePriceDiscount,
+		oasfiber.Doc("createPriceDiscount").
+			Summary("Record a negotiated discount (operator)").
+			Description("Records a percentage discount off the catalog for the caller's org, for one "+
+				"model or (with no model) every model. A model-specific discount outranks an "+
+				"every-model one.").
+			Tag("admin").
+			JSONBody("Discount", s.schema(priceDiscountRequest{})).
+			JSONResponse(201, "The stored discount", s.schema(storage.PriceDiscount{})).
+			JSONResponse(400, "Invalid payload", s.errorSchema()).
+			JSONResponse(500, "Failed to create discount", s.errorSchema()).
+			JSONResponse(501, "Driver does not host the pricing catalog", s.errorSchema()))
+
+	router.Delete("/v1/admin/pricing/discounts/:id", s.handleDeletePriceDiscount,
+		oasfiber.Doc("deletePriceDiscount").
+			Summary("Delete a negotiated discount (operator)").
+			Tag("admin").
+			PathParam("id", oas.String(), oas.ParamDescription("Discount id (UUID)")).
+			EmptyResponse(204, "Discount deleted").
+			JSONResponse(404, "Discount not found", s.errorSchema()).
+			JSONResponse(500, "Failed to delete discount", s.errorSchema()).
+			JSONResponse(501, "Driver does not host the pricing catalog", s.errorSchema()))
 }
 
 // mountMCP registers the streamable MCP transport.
@@ -277,7 +569,7 @@ func (s *Server) mountAdmin(router *oasfiber.Router) {
 // not implement, and publishing those would hand a generated client operations
 // that cannot work.
 func (s *Server) mountMCP(router *oasfiber.Router) {
-	handler := adaptor.HTTPHandler(s.mcpServer.Handler())
+	handler := unrestricted(s.withOrg(adaptor.HTTPHandler(s.mcpServer.Handler())))
 
 	router.All("/v1/mcp", handler,
 		oasfiber.DocFor("POST", "invokeMcp").
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/papercomputeco/tapes/pkg/llm"
+	"github.com/papercomputeco/tapes/pkg/sessions"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+// pricingResponse is GET /v1/admin/pricing: the shared catalog and the
+// caller's org's discounts.
+type pricingResponse struct {
+	Prices    []storage.Price         `json:"prices"`
+	Discounts []storage.PriceDiscount `json:"discounts"`
+}
+
+// pricingImportRequest is the POST /v1/admin/pricing/prices body: one price
+// sheet, every model in it taking effect at the same instant. Prices uses the
+// same model-keyed shape as the pricing override file.
+type pricingImportRequest struct {
+	EffectiveFrom time.Time                   `json:"effective_from"`
+	Source        string                      `json:"source,omitempty"`
+	Prices        map[string]sessions.Pricing `json:"prices"`
+}
+
+// pricingImportResponse lists the catalog rows an import stored.
+type pricingImportResponse struct {
+	Prices []storage.Price `json:"prices"`
+}
+
+// priceDiscountRequest is the POST /v1/admin/pricing/discounts body. The
+// discount always belongs to the caller's org.
+type priceDiscountRequest struct {
+	Model          string     `json:"model,omitempty"`
+	Percent        float64    `json:"percent"`
+	EffectiveFrom  time.Time  `json:"effective_from"`
+	EffectiveUntil *time.Time `json:"effective_until,omitempty"`
+	Note           string     `json:"note,omitempty"`
+}
+
+// errSharedCatalog is the 403 a multi-tenant admin gets for writing the
+// catalog: list prices are shared by every tenant, so only the deployment's
+// operator changes them, with `tapes pricing import`.
+var errSharedCatalog = llm.ErrorResponse{Error: "the pricing catalog is shared by every tenant; the operator manages it with tapes pricing import"}
+
+// handleListPricing handles GET /v1/admin/pricing.
+func (s *Server) handleListPricing(c *fiber.Ctx) error {
+	store, ok := s.driver.(storage.PricingStore)
+	if !ok {
+		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: "driver does not host the pricing catalog"})
+	}
+
+	prices, err := store.ListPrices(c.Context(), c.Query("model"))
+	if err != nil {
+		s.logger.Error("list prices", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to list prices"})
+	}
+	discounts, err := store.ListPriceDiscounts(c.Context(), s.orgID(c))
+	if err != nil {
+		s.logger.Error("list price discounts", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to list price discounts"})
+	}
+
+	return c.JSON(pricingResponse{Prices: prices, Discounts: discounts})
+}
+
+// handleImportPrices handles POST /v1/admin/pricing/prices. Prices never
+// change in place: a new sheet is new rows from its effective_from onward,
+// and re-importing a sheet with the same date replaces that date's rows.
+// Existing spans keep their stored cost until the next derive run.
+func (s *Server) handleImportPrices(c *fiber.Ctx) error {
+	store, ok := s.driver.(storage.PricingStore)
+	if !ok {
+		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: "driver does not host the pricing catalog"})
+	}
+	if s.config.MultiTenant {
+		return c.Status(fiber.StatusForbidden).JSON(errSharedCatalog)
+	}
+
+	var req pricingImportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "invalid payload: " + err.Error()})
+	}
+	if req.EffectiveFrom.IsZero() {
+		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "effective_from is required"})
+	}
+	if len(req.Prices) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "prices is empty"})
+	}
+
+	prices := make([]storage.Price, 0, len(req.Prices))
+	for model, pricing := range req.Prices {
+		if strings.TrimSpace(model) == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "price has an empty model name"})
+		}
+		prices = append(prices, storage.Price{
+			Model:         model,
+			EffectiveFrom: req.EffectiveFrom,
+			Pricing:       pricing,
+			Source:        req.Source,
+		})
+	}
+	stored, err := store.UpsertPrices(c.Context(), prices)
+	if err != nil {
+		s.logger.Error("import prices", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to import prices"})
+	}
+
+	return c.JSON(pricingImportResponse{Prices: stored})
+}
+
+// handleDeletePrice handles DELETE /v1/admin/pricing/prices/:id.
+func (s *Server) handleDeletePrice(c *fiber.Ctx) error {
+	store, ok := s.driver.(storage.PricingStore)
+	if !ok {
+		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: "driver does not host the pricing catalog"})
+	}
+	if s.config.MultiTenant {
+		return c.Status(fiber.StatusForbidden).JSON(errSharedCatalog)
+	}
+
+	if err := store.DeletePrice(c.Context(), c.Params("id")); err != nil {
+		if errors.Is(err, storage.ErrPriceNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(llm.ErrorResponse{Error: "price not found"})
+		}
+		s.logger.Error("delete price", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to delete price"})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// handleCreatePriceDiscount handles POST /v1/admin/pricing/discounts.
+//
+// Unlike the catalog, a discount is the caller's own: agents bring their own
+// provider credentials, so the rates an org negotiated are its business, and
+// a multi-tenant admin may record them for their org.
+func (s *Server) handleCreatePriceDiscount(c *fiber.Ctx) error {
+	store, ok := s.driver.(storage.PricingStore)
+	if !ok {
+		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: "driver does not host the pricing catalog"})
+	}
+
+	var req priceDiscountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "invalid payload: " + err.Error()})
+	}
+	if err := validatePriceDiscountRequest(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: err.Error()})
+	}
+
+	discount, err := store.CreatePriceDiscount(c.Context(), storage.PriceDiscount{
+		OrgID:          s.orgID(c),
+		Model:          req.Model,
+		Percent:        req.Percent,
+		EffectiveFrom:  req.EffectiveFrom,
+		EffectiveUntil: req.EffectiveUntil,
+		Note:           req.Note,
+	})
+	if err != nil {
+		s.logger.Error("create price discount", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to create price discount"})
+	}
+	return c.Status(fiber.StatusCreated).JSON(discount)
+}
+
+func validatePriceDiscountRequest(req priceDiscountRequest) error {
+	if req.Percent <= 0 || req.Percent > 100 {
+		return errors.New("percent must be greater than 0 and at most 100")
+	}
+	if req.EffectiveFrom.IsZero() {
+		return errors.New("effective_from is required")
+	}
+	if req.EffectiveUntil != nil && !req.EffectiveUntil.After(req.EffectiveFrom) {
+		return errors.New("effective_until must be after effective_from")
+	}
+	return nil
+}
+
+// handleDeletePriceDiscount handles DELETE /v1/admin/pricing/discounts/:id.
+// Only the caller's org's discounts are visible to delete.
+func (s *Server) handleDeletePriceDiscount(c *fiber.Ctx) error {
+	store, ok := s.driver.(storage.PricingStore)
+	if !ok {
+		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: "driver does not host the pricing catalog"})
+	}
+
+	if err := store.DeletePriceDiscount(c.Context(), s.orgID(c), c.Params("id")); err != nil {
+		if errors.Is(err, storage.ErrPriceNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(llm.ErrorResponse{Error: "price discount not found"})
+		}
+		s.logger.Error("delete price discount", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to delete price discount"})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,79 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/papercomputeco/tapes/api"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+// Store is the read surface Load needs: the session lookups of the
+// sessions handlers and the span model behind the composite traces
+// response. The Postgres driver implements it.
+type Store interface {
+	storage.SpanModelReader
+	GetSessionRecord(ctx context.Context, orgID, id string) (*storage.SessionRecord, error)
+	ListSessionRecordsByHarnessSessionID(ctx context.Context, orgID, harnessSessionID string) ([]storage.SessionRecord, error)
+}
+
+// Load reads one stored session of the org as the composite GET
+// /v1/sessions/{id}/traces response — the live twin of Build for commands
+// that take a session by name. ref is a session id or, failing that, a
+// harness session id; one that names several sessions is an error.
+func Load(ctx context.Context, store Store, orgID, ref string, mode api.PayloadMode) (*api.SessionTracesResponse, error) {
+	sess, err := lookup(ctx, store, orgID, ref)
+	if err != nil {
+		return nil, err
+	}
+	turns, spans, links, err := store.ListSessionSpanModel(ctx, sess.ID)
+	if err != nil {
+		return nil, fmt.Errorf("load session traces: %w", err)
+	}
+	return api.BuildSessionTraces(api.SessionItemFromRecord(*sess, time.Now()), turns, spans, links, mode), nil
+}
+
+func lookup(ctx context.Context, store Store, orgID, ref string) (*storage.SessionRecord, error) {
+	if _, err := uuid.Parse(ref); err == nil {
+		sess, err := store.GetSessionRecord(ctx, orgID, ref)
+		if err != nil {
+			return nil, fmt.Errorf("load session: %w", err)
+		}
+		if sess != nil {
+			return sess, nil
+		}
+	}
+
+	matches, err := store.ListSessionRecordsByHarnessSessionID(ctx, orgID, ref)
+	if err != nil {
+		return nil, fmt.Errorf("load session: %w", err)
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("session %s not found", ref)
+	case 1:
+		return &matches[0], nil
+	}
+	return nil, fmt.Errorf("harness session id %s matches %d sessions; pass the session id instead", ref, len(matches))
+}
+
+// Find picks one session out of a Build result by session id or harness
+// session id, with the same ambiguity rule as Load.
+func Find(sessions []*Session, ref string) (*Session, error) {
+	var matched []*Session
+	for _, s := range sessions {
+		if s.Item.ID == ref || s.Item.HarnessSessionID == ref {
+			matched = append(matched, s)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return nil, fmt.Errorf("session %s not found", ref)
+	case 1:
+		return matched[0], nil
+	}
+	return nil, fmt.Errorf("harness session id %s matches %d sessions; pass the session id instead", ref, len(matched))
+}
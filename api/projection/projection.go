@@ -0,0 +1,415 @@
+// Package projection renders the read API's session/trace projection from
+// raw turns with no database: the rows are replayed through the real
+// deriver and the span records it emits are shaped exactly as the Postgres
+// read path returns them, so the api package's renderers produce the live
+// wire response. `tapes derive` and `tapes dev trace-fixtures` both render
+// through it; `tapes report` and `tapes compare` also read stored sessions
+// through Load, so a command takes a session from a corpus or a database
+// alike.
+package projection
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/papercomputeco/tapes/api"
+	"github.com/papercomputeco/tapes/pkg/derive"
+	"github.com/papercomputeco/tapes/pkg/llm"
+	"github.com/papercomputeco/tapes/pkg/sessions"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+// Session is one derived session: the embedded session stanza plus the
+// span read records the API renders its traces from, in read-path order.
+type Session struct {
+	Key   derive.SessionKey
+	Item  api.SessionItem
+	Turns []storage.SpanTurnRecord
+	Spans []storage.SpanRecord
+	Links []storage.SpanLinkRecord
+}
+
+// Traces renders the session as the composite GET
+// /v1/sessions/{id}/traces response.
+func (s *Session) Traces(mode api.PayloadMode) *api.SessionTracesResponse {
+	return api.BuildSessionTraces(s.Item, s.Turns, s.Spans, s.Links, mode)
+}
+
+// Build replays wire and transcript rows through the deriver and returns
+// every session that derived to at least one trace, ordered by first
+// activity. opts are passed to the span emitter (derive.WithPricing).
+func Build(wire, transcripts []storage.RawTurnRecord, opts ...derive.EmitOption) ([]*Session, error) {
+	set, err := derive.BuildDerivedSet(wire, "")
+	if err != nil {
+		return nil, fmt.Errorf("derive: %w", err)
+	}
+	files := make([]*derive.TranscriptFile, 0, len(transcripts))
+	for i := range transcripts {
+		file, err := derive.ParseTranscriptFile(&transcripts[i])
+		if err != nil {
+			return nil, fmt.Errorf("parse transcript row %d: %w", transcripts[i].ID, err)
+		}
+		files = append(files, file)
+	}
+	derive.ReconcileTranscripts(set, files)
+	spanSet := derive.EmitSpans(set, opts...)
+
+	// Traces group by their session; a cross-trace link belongs to the
+	// session of the trace it leaves from.
+	byKey := map[derive.SessionKey][]*derive.SpanTurn{}
+	traceSession := map[string]derive.SessionKey{}
+	var keys []derive.SessionKey
+	for _, turn := range spanSet.Turns {
+		if _, ok := byKey[turn.Session]; !ok {
+			keys = append(keys, turn.Session)
+		}
+		byKey[turn.Session] = append(byKey[turn.Session], turn)
+		traceSession[turn.TraceID] = turn.Session
+	}
+	crossLinks := map[derive.SessionKey][]*derive.SpanLink{}
+	for _, l := range spanSet.Links {
+		key, ok := traceSession[l.FromTraceID]
+		if !ok {
+			key = traceSession[l.ToTraceID]
+		}
+		crossLinks[key] = append(crossLinks[key], l)
+	}
+
+	rows := map[derive.SessionKey]*sessionRows{}
+	for _, src := range []struct {
+		recs       []storage.RawTurnRecord
+		transcript bool
+	}{{wire, false}, {transcripts, true}} {
+		for _, r := range src.recs {
+			key := derive.SessionKey{HarnessID: r.HarnessID, HarnessSessionID: r.HarnessSessionID}
+			sr := rows[key]
+			if sr == nil {
+				sr = &sessionRows{}
+				rows[key] = sr
+			}
+			if src.transcript {
+				sr.transcripts = append(sr.transcripts, r)
+			} else {
+				sr.wire = append(sr.wire, r)
+			}
+		}
+	}
+
+	out := make([]*Session, 0, len(keys))
+	for _, key := range keys {
+		sessionID := SessionID(key)
+		turns, spans, links := records(byKey[key], crossLinks[key], sessionID)
+		sr := rows[key]
+		if sr == nil {
+			sr = &sessionRows{}
+		}
+		item := foldSessionItem(key, sessionID, set, sr.wire, sr.transcripts, turns)
+		foldRollups(&item, spanSet, key)
+		out = append(out, &Session{Key: key, Item: item, Turns: turns, Spans: spans, Links: links})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Item.StartedAt.Before(out[j].Item.StartedAt)
+	})
+	return out, nil
+}
+
+// sessionRows is one session's share of the raw rows.
+type sessionRows struct {
+	wire, transcripts []storage.RawTurnRecord
+}
+
+// SessionID mints the session UUID as a pure function of the harness
+// identity, so re-rendering the same rows never churns ids.
+func SessionID(key derive.SessionKey) string {
+	name := "https://tapes.papercompute.com/fixtures/" + key.HarnessID + "/" + key.HarnessSessionID
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(name)).String()
+}
+
+// LinksTouching returns the links with either end on the trace — the
+// per-trace read query's WHERE clause.
+func LinksTouching(links []storage.SpanLinkRecord, traceID string) []storage.SpanLinkRecord {
+	var out []storage.SpanLinkRecord
+	for _, l := range links {
+		if l.FromTraceID == traceID || l.ToTraceID == traceID {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// foldRollups mirrors the deriver's session rollups the handler folds onto
+// the session record, so the stanza matches a re-derived session. Pinned
+// []/{} defaults keep an empty session's shape uniform.
+func foldRollups(item *api.SessionItem, spanSet *derive.SpanSet, key derive.SessionKey) {
+	item.Rollup.ModelUsage = modelUsageItems(spanSet.ModelUsage[key])
+	item.Rollup.Tasks = []api.TreeTask{}
+	item.Rollup.KindCounts = map[string]int{}
+	if tasks := spanSet.Tasks[key]; len(tasks) > 0 {
+		if b, err := json.Marshal(tasks); err == nil {
+			_ = json.Unmarshal(b, &item.Rollup.Tasks)
+		}
+	}
+	if kc := spanSet.KindCounts[key]; len(kc) > 0 {
+		item.Rollup.KindCounts = kc
+	}
+	if b, err := json.Marshal(spanSet.Context[key]); err == nil {
+		_ = json.Unmarshal(b, &item.Rollup.Context)
+	}
+	// derived_status is a deriver output, so the stanza reflects what a
+	// re-derive computes rather than a hard-coded value.
+	item.Rollup.Status = spanSet.Status[key].DerivedStatus
+	item.Rollup.Outcome = spanSet.Status[key].Outcome.Outcome
+	if b, err := json.Marshal(spanSet.Status[key].Outcome.Reasons); err == nil {
+		_ = json.Unmarshal(b, &item.Rollup.OutcomeReasons)
+	}
+}
+
+// records converts one session's emitter output into the storage read
+// records the API renders. The field mapping is the in-memory twin of
+// writeSpanSet + the postgres read path (pkg/storage/postgres/spans.go);
+// ordering matches the read queries' ORDER BY clauses so offline renders
+// and live responses agree positionally.
+func records(spanTurns []*derive.SpanTurn, crossLinks []*derive.SpanLink, sessionID string) ([]storage.SpanTurnRecord, []storage.SpanRecord, []storage.SpanLinkRecord) {
+	turns := make([]storage.SpanTurnRecord, 0, len(spanTurns))
+	spans := make([]storage.SpanRecord, 0, len(spanTurns))
+	links := make([]storage.SpanLinkRecord, 0, len(crossLinks))
+
+	for _, turn := range spanTurns {
+		rec := storage.SpanTurnRecord{
+			TraceID:             turn.TraceID,
+			SessionID:           sessionID,
+			UserPrompt:          turn.UserPrompt,
+			ResponsePreview:     turn.ResponsePreview,
+			Synthetic:           turn.Synthetic,
+			Source:              turn.Source,
+			ParentTraceID:       turn.ParentTraceID,
+			Abandoned:           turn.Abandoned,
+			Status:              "ok",
+			StartedAt:           turn.StartedAt.UTC(),
+			DurationNS:          turn.EndedAt.Sub(turn.StartedAt).Nanoseconds(),
+			TotalInputTokens:    turn.TotalInputTokens,
+			TotalOutputTokens:   turn.TotalOutputTokens,
+			MainInputTokens:     turn.MainInputTokens,
+			MainOutputTokens:    turn.MainOutputTokens,
+			CacheReadTokens:     turn.CacheReadTokens,
+			CacheCreationTokens: turn.CacheCreationTokens,
+			TotalCostUSD:        turn.TotalCostUSD,
+
+			PeakContextTokens:    turn.PeakContextTokens,
+			PeakContextOccupancy: turn.PeakContextOccupancy,
+			ContextGrowthTokens:  turn.ContextGrowthTokens,
+			ReclaimedTokens:      turn.ReclaimedTokens,
+		}
+		if !turn.EndedAt.IsZero() {
+			ended := turn.EndedAt.UTC()
+			rec.EndedAt = &ended
+		}
+		turns = append(turns, rec)
+
+		for _, s := range turn.Spans {
+			input, err := blocksJSON(s.Input)
+			if err != nil {
+				panic(fmt.Sprintf("marshal span %s input: %v", s.SpanID, err))
+			}
+			output, err := blocksJSON(s.Output)
+			if err != nil {
+				panic(fmt.Sprintf("marshal span %s output: %v", s.SpanID, err))
+			}
+			var usage json.RawMessage
+			if s.Usage != nil {
+				usage, err = json.Marshal(s.Usage)
+				if err != nil {
+					panic(fmt.Sprintf("marshal span %s usage: %v", s.SpanID, err))
+				}
+			}
+			var verdict json.RawMessage
+			if s.Verdict != nil {
+				verdict, err = json.Marshal(s.Verdict)
+				if err != nil {
+					panic(fmt.Sprintf("marshal span %s verdict: %v", s.SpanID, err))
+				}
+			}
+			spans = append(spans, storage.SpanRecord{
+				TraceID:      turn.TraceID,
+				SpanID:       s.SpanID,
+				ParentSpanID: s.ParentSpanID,
+				Kind:         s.Kind,
+				Name:         s.Name,
+				Status:       s.Status,
+				CallKind:     s.CallKind,
+				ThreadID:     s.ThreadID,
+				Model:        s.Model,
+				StopReason:   s.StopReason,
+				StartedAt:    s.StartedAt.UTC(),
+				DurationNS:   s.DurationNS,
+				Seq:          s.Seq,
+				Input:        input,
+				Output:       output,
+				Usage:        usage,
+				RawTurnID:    s.RawTurnID,
+				NodeHash:     s.NodeHash,
+				Verdict:      verdict,
+			})
+		}
+		for _, l := range turn.Links {
+			links = append(links, linkRecord(l))
+		}
+	}
+	for _, l := range crossLinks {
+		links = append(links, linkRecord(l))
+	}
+
+	// Read-path order: turns by (started_at, trace_id), spans by
+	// (trace_id, seq).
+	sort.SliceStable(turns, func(i, j int) bool {
+		if !turns[i].StartedAt.Equal(turns[j].StartedAt) {
+			return turns[i].StartedAt.Before(turns[j].StartedAt)
+		}
+		return turns[i].TraceID < turns[j].TraceID
+	})
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].TraceID != spans[j].TraceID {
+			return spans[i].TraceID < spans[j].TraceID
+		}
+		return spans[i].Seq < spans[j].Seq
+	})
+	return turns, spans, links
+}
+
+func linkRecord(l *derive.SpanLink) storage.SpanLinkRecord {
+	return storage.SpanLinkRecord{
+		FromTraceID: l.FromTraceID,
+		FromSpanID:  l.FromSpanID,
+		FromIO:      l.FromIO,
+		ToTraceID:   l.ToTraceID,
+		ToSpanID:    l.ToSpanID,
+		ToIO:        l.ToIO,
+		Kind:        l.Kind,
+	}
+}
+
+// blocksJSON mirrors the storage layer's contentJSON: empty payloads
+// stay null, not [].
+func blocksJSON(blocks []llm.ContentBlock) (json.RawMessage, error) {
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(blocks)
+}
+
+// foldSessionItem assembles the embedded session stanza from the raw
+// rows: identity and metadata fold from the ingest envelopes exactly as
+// ingest applies them (last-write-wins per key), the title from the
+// deriver's fold, counters from the span layer.
+func foldSessionItem(
+	key derive.SessionKey,
+	sessionID string,
+	set *derive.DerivedSet,
+	wire, transcriptRows []storage.RawTurnRecord,
+	turns []storage.SpanTurnRecord,
+) api.SessionItem {
+	item := api.SessionItem{
+		ID:               sessionID,
+		HarnessID:        key.HarnessID,
+		HarnessSessionID: key.HarnessSessionID,
+	}
+
+	var first, last time.Time
+	for _, rows := range [][]storage.RawTurnRecord{wire, transcriptRows} {
+		for _, r := range rows {
+			t := r.ReceivedAt.UTC()
+			if first.IsZero() || t.Before(first) {
+				first = t
+			}
+			if t.After(last) {
+				last = t
+			}
+		}
+	}
+	item.StartedAt = first
+	item.LastSeenAt = last
+	if !last.IsZero() {
+		ended := last
+		item.EndedAt = &ended
+	}
+
+	meta := map[string]any{}
+	var envelopeName string
+	for _, r := range wire {
+		if len(r.SessionEnvelope) == 0 {
+			continue
+		}
+		var env sessions.IngestEnvelope
+		if err := json.Unmarshal(r.SessionEnvelope, &env); err != nil {
+			continue
+		}
+		if env.Cwd != "" {
+			item.Cwd = env.Cwd
+		}
+		if env.HarnessVersion != "" {
+			item.HarnessVersion = env.HarnessVersion
+		}
+		if env.Name != "" {
+			envelopeName = env.Name
+		}
+		if len(env.HarnessMetadata) > 0 {
+			var m map[string]any
+			if err := json.Unmarshal(env.HarnessMetadata, &m); err == nil {
+				maps.Copy(meta, m)
+			}
+		}
+	}
+	if len(meta) > 0 {
+		item.HarnessMetadata = meta
+	}
+
+	// Display title: the folded title-gen output, envelope name as
+	// fallback — same precedence as the sessions read path.
+	if title, ok := set.SessionTitles[key]; ok && title != "" {
+		item.Rollup.Title = title
+	} else {
+		item.Rollup.Title = envelopeName
+	}
+
+	// Counters roll up from the span layer (the v2 direction; ingest
+	// counters retire). turn_count is user-visible turns.
+	item.Rollup.TurnCount = len(turns)
+	for _, turn := range turns {
+		item.Rollup.Usage.InputTokens += turn.TotalInputTokens
+		item.Rollup.Usage.OutputTokens += turn.TotalOutputTokens
+		item.Rollup.Usage.CostUSD += turn.TotalCostUSD
+	}
+
+	for _, turn := range turns {
+		if turn.Synthetic == "" && turn.UserPrompt != "" {
+			item.Rollup.Preview = turn.UserPrompt
+			break
+		}
+	}
+	return item
+}
+
+// modelUsageItems maps the deriver's per-model breakdown to the API shape
+// the session detail carries, so offline renders mirror the live response
+// field-for-field.
+func modelUsageItems(in []derive.ModelUsage) []api.ModelUsage {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make([]api.ModelUsage, len(in))
+	for i, mu := range in {
+		out[i] = api.ModelUsage{
+			Model:        mu.Model,
+			Calls:        mu.Calls,
+			InputTokens:  mu.InputTokens,
+			OutputTokens: mu.OutputTokens,
+			CostUsd:      mu.CostUSD,
+		}
+	}
+	return out
+}
@@ -0,0 +1,427 @@
+// Package report renders one session's composite traces response (GET
+// /v1/sessions/{id}/traces) as a self-contained HTML file: the turn list,
+// each turn's nested spans with their inputs and outputs, subagent
+// threads, token and cost rollups, and a timeline waterfall. The file
+// carries its own styles and no scripts, so it can be attached to a bug
+// report or post-mortem and opened anywhere.
+package report
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/papercomputeco/tapes/api"
+	"github.com/papercomputeco/tapes/pkg/llm"
+)
+
+//go:embed report.html
+var reportHTML string
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"tokens":   formatTokens,
+	"cost":     formatCost,
+	"duration": formatDuration,
+	"pct":      func(f float64) string { return fmt.Sprintf("%.2f%%", f) },
+}).Parse(reportHTML))
+
+// redactedMarker replaces a redacted tool output.
+const redactedMarker = "[redacted]"
+
+// Options tunes a report.
+type Options struct {
+	// RedactToolOutputs replaces every tool result, wherever it appears
+	// (tool span outputs and the llm inputs that carry them back to the
+	// model), with a marker. Prompts, replies and tool inputs are kept.
+	RedactToolOutputs bool
+
+	// GeneratedAt stamps the report footer; zero omits the stamp.
+	GeneratedAt time.Time
+}
+
+// Render writes doc as an HTML report to w.
+func Render(w io.Writer, doc *api.SessionTracesResponse, opts Options) error {
+	view, err := buildView(doc, opts)
+	if err != nil {
+		return err
+	}
+	if err := reportTemplate.Execute(w, view); err != nil {
+		return fmt.Errorf("render report: %w", err)
+	}
+	return nil
+}
+
+type reportView struct {
+	Title     string
+	Session   api.SessionItem
+	Schema    string
+	Generated string
+	Redacted  bool
+
+	Turns       int
+	Spans       int
+	Threads     int
+	DurationNS  int64
+	Usage       api.TraceUsage
+	Models      []api.ModelUsage
+	Timeline    []bar
+	Traces      []*traceView
+	HasTimeline bool
+}
+
+type traceView struct {
+	Index     int
+	ID        string
+	Prompt    string
+	Response  string
+	Synthetic string
+	Abandoned bool
+	Started   string
+	Trace     api.TraceItem
+	Roots     []*spanView
+	Waterfall []bar
+}
+
+type spanView struct {
+	ID         string
+	Kind       string
+	Name       string
+	Model      string
+	CallKind   string
+	ThreadID   string
+	Status     string
+	StopReason string
+	DurationNS int64
+	Usage      *llm.Usage
+	Input      []block
+	Output     []block
+	Children   []*spanView
+	Open       bool
+}
+
+// block is one content block, flattened for display.
+type block struct {
+	Type     string
+	Label    string
+	Body     string
+	IsError  bool
+	Redacted bool
+}
+
+// bar is one row of a waterfall: its offset and width as percentages of
+// the enclosing window.
+type bar struct {
+	Label    string
+	Anchor   string
+	Kind     string
+	Offset   float64
+	Width    float64
+	Duration int64
+	Muted    bool
+	Depth    int
+}
+
+func buildView(doc *api.SessionTracesResponse, opts Options) (*reportView, error) {
+	if doc == nil {
+		return nil, fmt.Errorf("render report: no session")
+	}
+	s := doc.Session
+	view := &reportView{
+		Title:    sessionTitle(s),
+		Session:  s,
+		Schema:   doc.Schema,
+		Redacted: opts.RedactToolOutputs,
+		Turns:    len(doc.Traces),
+		Models:   s.Rollup.ModelUsage,
+	}
+	if !opts.GeneratedAt.IsZero() {
+		view.Generated = opts.GeneratedAt.UTC().Format(time.RFC3339)
+	}
+
+	var start, end time.Time
+	for _, t := range doc.Traces {
+		started := t.Trace.StartedAt
+		ended := started.Add(time.Duration(t.Trace.DurationNS))
+		if start.IsZero() || started.Before(start) {
+			start = started
+		}
+		if ended.After(end) {
+			end = ended
+		}
+		view.Usage.InputTokens += t.Trace.Usage.InputTokens
+		view.Usage.OutputTokens += t.Trace.Usage.OutputTokens
+		view.Usage.CacheReadTokens += t.Trace.Usage.CacheReadTokens
+		view.Usage.CacheCreationTokens += t.Trace.Usage.CacheCreationTokens
+		view.Usage.CostUSD += t.Trace.Usage.CostUSD
+		view.Spans += len(t.Spans)
+	}
+	window := end.Sub(start)
+	view.DurationNS = window.Nanoseconds()
+
+	threads := map[string]bool{}
+	for i, t := range doc.Traces {
+		tv, err := buildTrace(i+1, t, opts)
+		if err != nil {
+			return nil, err
+		}
+		view.Traces = append(view.Traces, tv)
+		for _, sp := range t.Spans {
+			if sp.ThreadID != "" {
+				threads[sp.ThreadID] = true
+			}
+		}
+		label := tv.Prompt
+		if label == "" {
+			label = syntheticLabel(t.Trace.Synthetic)
+		}
+		view.Timeline = append(view.Timeline, place(bar{
+			Label:    fmt.Sprintf("#%d %s", i+1, truncate(label, 80)),
+			Anchor:   "trace-" + t.Trace.TraceID,
+			Duration: t.Trace.DurationNS,
+			Muted:    t.Trace.Abandoned || t.Trace.Synthetic != "",
+		}, start, t.Trace.StartedAt, window))
+	}
+	view.Threads = len(threads)
+	view.HasTimeline = len(view.Timeline) > 0
+	return view, nil
+}
+
+func buildTrace(index int, t api.TraceDetail, opts Options) (*traceView, error) {
+	tv := &traceView{
+		Index:     index,
+		ID:        t.Trace.TraceID,
+		Prompt:    t.Trace.UserPrompt,
+		Response:  t.Trace.ResponsePreview,
+		Synthetic: t.Trace.Synthetic,
+		Abandoned: t.Trace.Abandoned,
+		Started:   t.Trace.StartedAt.UTC().Format(time.RFC3339),
+		Trace:     t.Trace,
+	}
+
+	// Spans arrive in presentation order, so children are appended in
+	// order as their parents are found.
+	byID := map[string]*spanView{}
+	depth := map[string]int{}
+	window := time.Duration(t.Trace.DurationNS)
+	for _, sp := range t.Spans {
+		v, err := buildSpan(sp, opts)
+		if err != nil {
+			return nil, err
+		}
+		byID[sp.SpanID] = v
+		if parent, ok := byID[sp.ParentSpanID]; ok && sp.ParentSpanID != "" {
+			parent.Children = append(parent.Children, v)
+			depth[sp.SpanID] = depth[sp.ParentSpanID] + 1
+		} else {
+			tv.Roots = append(tv.Roots, v)
+		}
+
+		label := sp.Name
+		if sp.ThreadID != "" && sp.Kind == "agent" {
+			label += " (" + sp.ThreadID + ")"
+		}
+		tv.Waterfall = append(tv.Waterfall, place(bar{
+			Label:    label,
+			Kind:     sp.Kind,
+			Duration: sp.DurationNS,
+			Depth:    depth[sp.SpanID],
+		}, t.Trace.StartedAt, sp.StartedAt, window))
+	}
+	return tv, nil
+}
+
+func buildSpan(sp api.SpanItem, opts Options) (*spanView, error) {
+	v := &spanView{
+		ID:         sp.SpanID,
+		Kind:       sp.Kind,
+		Name:       sp.Name,
+		Model:      sp.Model,
+		CallKind:   sp.CallKind,
+		ThreadID:   sp.ThreadID,
+		Status:     sp.Status,
+		StopReason: sp.StopReason,
+		DurationNS: sp.DurationNS,
+		Open:       sp.Kind == "agent",
+	}
+	if len(sp.Usage) > 0 && string(sp.Usage) != "{}" && string(sp.Usage) != "null" {
+		var u llm.Usage
+		if err := json.Unmarshal(sp.Usage, &u); err != nil {
+			return nil, fmt.Errorf("span %s usage: %w", sp.SpanID, err)
+		}
+		v.Usage = &u
+	}
+	var err error
+	if v.Input, err = blocks(sp.Input, opts.RedactToolOutputs, false); err != nil {
+		return nil, fmt.Errorf("span %s input: %w", sp.SpanID, err)
+	}
+	// Everything a tool span outputs is the tool's result.
+	if v.Output, err = blocks(sp.Output, opts.RedactToolOutputs, sp.Kind == "tool"); err != nil {
+		return nil, fmt.Errorf("span %s output: %w", sp.SpanID, err)
+	}
+	return v, nil
+}
+
+// blocks flattens a content-block array for display. redactAll redacts
+// every block, not just tool results, when redact is set.
+func blocks(raw json.RawMessage, redact, redactAll bool) ([]block, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var in []llm.ContentBlock
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return nil, err
+	}
+	out := make([]block, 0, len(in))
+	for _, b := range in {
+		v := block{Type: b.Type, IsError: b.IsError}
+		switch {
+		case b.Type == "text":
+			v.Body = b.Text
+		case b.Type == "thinking":
+			v.Label = "thinking"
+			v.Body = b.Thinking
+		case b.Type == "tool_use" || b.Type == "server_tool_use":
+			v.Label = b.ToolName
+			v.Body = indentJSON(b.ToolInput)
+		case b.Type == "tool_result" || strings.HasSuffix(b.Type, "_tool_result"):
+			v.Label = "result"
+			if b.ToolResultID != "" {
+				v.Label += " · " + b.ToolResultID
+			}
+			v.Body = b.ToolOutput
+			if v.Body == "" && len(b.Content) > 0 {
+				v.Body = indentJSON(b.Content)
+			}
+			if redact {
+				v.Body, v.Redacted = redactedMarker, true
+			}
+		case b.Type == "image":
+			v.Label = "image"
+			v.Body = firstNonEmpty(b.MediaType, "image") + " (not embedded)"
+		default:
+			v.Label = b.Type
+			v.Body = indentJSON(b)
+		}
+		if redactAll && redact && !v.Redacted {
+			v.Body, v.Redacted = redactedMarker, true
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// place positions b inside a window that opens at origin.
+func place(b bar, origin, at time.Time, window time.Duration) bar {
+	if window <= 0 {
+		b.Width = 100
+		return b
+	}
+	b.Offset = clampPct(float64(at.Sub(origin)) / float64(window) * 100)
+	b.Width = float64(b.Duration) / float64(window) * 100
+	if b.Width < 0.5 {
+		b.Width = 0.5
+	}
+	if b.Offset+b.Width > 100 {
+		b.Width = 100 - b.Offset
+	}
+	return b
+}
+
+func clampPct(f float64) float64 {
+	switch {
+	case f < 0:
+		return 0
+	case f > 99.5:
+		return 99.5
+	}
+	return f
+}
+
+// sessionTitle is the label the report opens with: the server-resolved
+// display title, then the stanza's own fallbacks.
+func sessionTitle(s api.SessionItem) string {
+	return firstNonEmpty(s.DisplayTitle, s.Rollup.Title, s.Name, s.Rollup.Preview, s.HarnessSessionID, s.ID)
+}
+
+func syntheticLabel(kind string) string {
+	if kind == "" {
+		return "(no prompt)"
+	}
+	return "(" + kind + ")"
+}
+
+func indentJSON(v any) string {
+	if raw, ok := v.(json.RawMessage); ok {
+		var decoded any
+		if json.Unmarshal(raw, &decoded) != nil {
+			return string(raw)
+		}
+		v = decoded
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// formatTokens abbreviates a token count; it takes the int fields of
+// llm.Usage and the int64 rollups alike.
+func formatTokens(v any) string {
+	var n int64
+	switch t := v.(type) {
+	case int:
+		n = int64(t)
+	case int64:
+		n = t
+	}
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 10_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+func formatCost(usd float64) string {
+	if usd > 0 && usd < 0.01 {
+		return fmt.Sprintf("$%.4f", usd)
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
+
+func formatDuration(ns int64) string {
+	d := time.Duration(ns)
+	switch {
+	case d <= 0:
+		return "0s"
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	case d < time.Minute:
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
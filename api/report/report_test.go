@@ -0,0 +1,115 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/api"
+	"github.com/papercomputeco/tapes/api/report"
+)
+
+// sessionDoc is one turn: an llm call that asks for a file, the tool
+// call that reads it, and a subagent thread the turn spawned.
+func sessionDoc() *api.SessionTracesResponse {
+	start := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	raw := func(v any) json.RawMessage {
+		b, err := json.Marshal(v)
+		Expect(err).NotTo(HaveOccurred())
+		return b
+	}
+	return &api.SessionTracesResponse{
+		Schema: api.ProjectionSchema,
+		Session: api.SessionItem{
+			ID:               "0d4c2b8e-1f6a-4d7e-9c3b-5a2e8f1d6c40",
+			HarnessID:        "claude",
+			HarnessSessionID: "harness-1",
+			StartedAt:        start,
+			DisplayTitle:     "Fix the <flaky> test",
+			Rollup: api.SessionRollup{
+				Status:     "completed",
+				ModelUsage: []api.ModelUsage{{Model: "claude-sonnet-4-5", Calls: 2, InputTokens: 1200, OutputTokens: 80, CostUsd: 0.0042}},
+			},
+		},
+		Traces: []api.TraceDetail{{
+			Trace: api.TraceItem{
+				TraceID:         "trace-1",
+				UserPrompt:      "why does the test flake?",
+				ResponsePreview: "a race in the setup",
+				StartedAt:       start,
+				DurationNS:      int64(4 * time.Second),
+				Usage:           api.TraceUsage{InputTokens: 1200, OutputTokens: 80, CostUSD: 0.0042},
+			},
+			Spans: []api.SpanItem{
+				{
+					TraceID: "trace-1", SpanID: "llm-1", Kind: "llm", Name: "claude-sonnet-4-5",
+					StartedAt: start, DurationNS: int64(time.Second), Status: "ok",
+					Input:  raw([]map[string]any{{"type": "tool_result", "tool_result_id": "tu-1", "tool_output": "SECRET=hunter2"}}),
+					Output: raw([]map[string]any{{"type": "tool_use", "tool_name": "read_file", "tool_input": map[string]any{"path": "setup_test.go"}}}),
+					Usage:  raw(map[string]any{"prompt_tokens": 1200, "completion_tokens": 80}),
+				},
+				{
+					TraceID: "trace-1", SpanID: "tool-1", ParentSpanID: "llm-1", Kind: "tool", Name: "read_file",
+					StartedAt: start.Add(time.Second), DurationNS: int64(time.Second), Status: "ok",
+					Input:  raw([]map[string]any{{"type": "text", "text": `{"path":"setup_test.go"}`}}),
+					Output: raw([]map[string]any{{"type": "text", "text": "func TestSetup(t *testing.T) {}"}}),
+				},
+				{
+					TraceID: "trace-1", SpanID: "agent-1", ParentSpanID: "llm-1", Kind: "agent", Name: "explore",
+					ThreadID: "thread-7", StartedAt: start.Add(2 * time.Second), DurationNS: int64(2 * time.Second), Status: "ok",
+				},
+			},
+		}},
+	}
+}
+
+var _ = Describe("Render", func() {
+	render := func(opts report.Options) string {
+		var buf bytes.Buffer
+		Expect(report.Render(&buf, sessionDoc(), opts)).To(Succeed())
+		return buf.String()
+	}
+
+	It("renders a self-contained page with the turn, its span tree and rollups", func() {
+		html := render(report.Options{GeneratedAt: time.Date(2026, 6, 2, 0, 0, 0, 0, time.UTC)})
+
+		Expect(html).To(HavePrefix("<!doctype html>"))
+		Expect(html).NotTo(ContainSubstring("<script"))
+		Expect(html).NotTo(MatchRegexp(`(?i)(src|href)="https?:`))
+		Expect(html).To(ContainSubstring("Fix the &lt;flaky&gt; test"))
+		Expect(html).To(ContainSubstring("why does the test flake?"))
+		Expect(html).To(ContainSubstring(`id="trace-trace-1"`))
+		Expect(html).To(ContainSubstring(`href="#trace-trace-1"`))
+		Expect(html).To(ContainSubstring("read_file"))
+		Expect(html).To(ContainSubstring("thread thread-7"))
+		Expect(html).To(ContainSubstring("$0.0042"))
+		Expect(html).To(ContainSubstring(">1200<"))
+		Expect(html).To(ContainSubstring("2026-06-02T00:00:00Z"))
+		Expect(html).To(ContainSubstring("SECRET=hunter2"))
+		Expect(html).To(ContainSubstring("func TestSetup"))
+	})
+
+	It("places waterfall bars inside the trace window", func() {
+		html := render(report.Options{})
+
+		Expect(html).To(ContainSubstring("left: 25.00%; width: 25.00%"))
+		Expect(html).To(ContainSubstring("left: 50.00%; width: 50.00%"))
+	})
+
+	It("redacts tool results and tool span outputs but keeps the inputs", func() {
+		html := render(report.Options{RedactToolOutputs: true})
+
+		Expect(html).NotTo(ContainSubstring("SECRET=hunter2"))
+		Expect(html).NotTo(ContainSubstring("func TestSetup"))
+		Expect(html).To(ContainSubstring("[redacted]"))
+		Expect(html).To(ContainSubstring("setup_test.go"))
+		Expect(html).To(ContainSubstring("tool outputs redacted"))
+	})
+
+	It("rejects a missing session", func() {
+		Expect(report.Render(&bytes.Buffer{}, nil, report.Options{})).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/papercomputeco/tapes/pkg/llm"
+	"github.com/papercomputeco/tapes/pkg/spanembed"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+const (
+	defaultSpanSearchLimit = 10
+	maxSpanSearchLimit     = 50
+
+	// spanSearchModeSemantic is the only mode served: nearest neighbours
+	// of the query's embedding among the spans the embed worker wrote.
+	spanSearchModeSemantic = "semantic"
+
+	// spanHitTextRunes bounds the text a hit carries; the full payloads
+	// are a GET /v1/traces/{trace_id}/spans/{span_id} away.
+	spanHitTextRunes = 500
+)
+
+// SpanSearchResponse is the response for GET /v1/search/spans: the spans
+// nearest the query, best first, with the turn each belongs to.
+type SpanSearchResponse struct {
+	Query string `json:"query"`
+	Mode  string `json:"mode"`
+	// Model is the embedding model the query and the spans were embedded
+	// with.
+	Model string          `json:"model"`
+	Hits  []SpanSearchHit `json:"hits"`
+}
+
+// SpanSearchHit is one matched span. Score is cosine similarity, 1 being
+// identical. Text is the span's prompt and response text, truncated;
+// UserPrompt is the prompt of the turn the span belongs to.
+type SpanSearchHit struct {
+	SessionID  string    `json:"session_id"`
+	TraceID    string    `json:"trace_id"`
+	SpanID     string    `json:"span_id"`
+	Model      string    `json:"model"`
+	Score      float64   `json:"score"`
+	StartedAt  time.Time `json:"started_at"`
+	UserPrompt string    `json:"user_prompt"`
+	Text       string    `json:"text"`
+}
+
+// handleSearchSpans handles GET /v1/search/spans.
+func (s *Server) handleSearchSpans(c *fiber.Ctx) error {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "q is required"})
+	}
+	mode := c.Query("mode", spanSearchModeSemantic)
+	if mode != spanSearchModeSemantic {
+		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "mode must be semantic"})
+	}
+	limit := defaultSpanSearchLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "limit must be a positive integer"})
+		}
+		limit = min(parsed, maxSpanSearchLimit)
+	}
+	searcher, ok := s.driver.(storage.SpanSearcher)
+	if !ok {
+		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: "driver does not support span search"})
+	}
+	if s.config.Embedder == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(llm.ErrorResponse{Error: "semantic search is not configured: no embedder"})
+	}
+
+	vectors, err := s.config.Embedder.Embed(c.Context(), []string{query})
+	if err != nil {
+		s.logger.Error("embed search query", "error", err)
+		return c.Status(fiber.StatusBadGateway).JSON(llm.ErrorResponse{Error: "failed to embed the query"})
+	}
+	subject := c.Query("auth_subject")
+	if own, restricted := ownSessionsSubject(c); restricted {
+		subject = own
+	}
+	model := s.config.Embedder.Model()
+	rows, err := searcher.SearchSpans(c.Context(), s.orgID(c), model, vectors[0], subject, limit)
+	if err != nil {
+		s.logger.Error("search spans", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to search spans"})
+	}
+
+	resp := SpanSearchResponse{Query: query, Mode: mode, Model: model, Hits: make([]SpanSearchHit, 0, len(rows))}
+	for _, r := range rows {
+		resp.Hits = append(resp.Hits, SpanSearchHit{
+			SessionID:  r.SessionID,
+			TraceID:    r.TraceID,
+			SpanID:     r.SpanID,
+			Model:      r.Model,
+			Score:      r.Score,
+			StartedAt:  r.StartedAt,
+			UserPrompt: r.UserPrompt,
+			Text:       spanembed.Text(r.Input, r.Output, spanHitTextRunes),
+		})
+	}
+	return c.JSON(resp)
+}
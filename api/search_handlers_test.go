@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/llm"
+	tapeslogger "github.com/papercomputeco/tapes/pkg/logger"
+	"github.com/papercomputeco/tapes/pkg/storage"
+	"github.com/papercomputeco/tapes/pkg/storage/inmemory"
+)
+
+// searchStubDriver adds the storage.SpanSearcher capability to a real
+// driver with canned hits, recording what it was asked for.
+type searchStubDriver struct {
+	storage.Driver
+
+	hits       []storage.SpanSearchHit
+	lastModel  string
+	lastVector []float32
+	lastLimit  int
+}
+
+func (d *searchStubDriver) SearchSpans(_ context.Context, _, model string, vector []float32, _ string, limit int) ([]storage.SpanSearchHit, error) {
+	d.lastModel, d.lastVector, d.lastLimit = model, vector, limit
+	return d.hits[:min(len(d.hits), limit)], nil
+}
+
+// stubEmbedder embeds every text as the same vector, or fails.
+type stubEmbedder struct{ err error }
+
+func (stubEmbedder) Model() string { return "nomic-embed-text" }
+
+func (e stubEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	out := make([][]float32, len(texts))
+	for i := range texts {
+		out[i] = []float32{0.5, 0.25}
+	}
+	return out, nil
+}
+
+var _ = Describe("GET /v1/search/spans", func() {
+	get := func(cfg Config, driver storage.Driver, path string) *http.Response {
+		cfg.ListenAddr = ":0"
+		server, err := NewServer(cfg, driver, tapeslogger.NewNoop())
+		Expect(err).NotTo(HaveOccurred())
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, path, nil)
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := server.app.Test(req)
+		Expect(err).NotTo(HaveOccurred())
+		return resp
+	}
+	blocks := func(text string) json.RawMessage {
+		raw, err := json.Marshal([]llm.ContentBlock{{Type: "text", Text: text}})
+		Expect(err).NotTo(HaveOccurred())
+		return raw
+	}
+
+	It("embeds the query and returns the nearest spans with their turn", func() {
+		at := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+		drv := &searchStubDriver{
+			Driver: inmemory.NewDriver(),
+			hits: []storage.SpanSearchHit{{
+				SessionID: "s1", TraceID: "trc_a", SpanID: "spn_1", Model: "claude-sonnet-4", Score: 0.91,
+				StartedAt: at, UserPrompt: "why is the build red?",
+				Input: blocks("why is the build red?"), Output: blocks("A missing import."),
+			}},
+		}
+		resp := get(Config{Embedder: stubEmbedder{}}, drv, "/v1/search/spans?q=broken+build&mode=semantic&limit=500")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(fiber.StatusOK))
+		var body SpanSearchResponse
+		Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+
+		Expect(drv.lastModel).To(Equal("nomic-embed-text"))
+		Expect(drv.lastVector).To(Equal([]float32{0.5, 0.25}))
+		Expect(drv.lastLimit).To(Equal(maxSpanSearchLimit))
+		Expect(body.Query).To(Equal("broken build"))
+		Expect(body.Mode).To(Equal("semantic"))
+		Expect(body.Model).To(Equal("nomic-embed-text"))
+		Expect(body.Hits).To(HaveLen(1))
+		Expect(body.Hits[0].TraceID).To(Equal("trc_a"))
+		Expect(body.Hits[0].Score).To(BeNumerically("~", 0.91))
+		Expect(body.Hits[0].Text).To(Equal("why is the build red?\n\nA missing import."))
+	})
+
+	It("rejects a missing query and an unknown mode", func() {
+		drv := &searchStubDriver{Driver: inmemory.NewDriver()}
+		resp := get(Config{Embedder: stubEmbedder{}}, drv, "/v1/search/spans")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(fiber.StatusBadRequest))
+
+		resp = get(Config{Embedder: stubEmbedder{}}, drv, "/v1/search/spans?q=x&mode=keyword")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(fiber.StatusBadRequest))
+	})
+
+	It("returns 503 without an embedder and 502 when it fails", func() {
+		drv := &searchStubDriver{Driver: inmemory.NewDriver()}
+		resp := get(Config{}, drv, "/v1/search/spans?q=x")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(fiber.StatusServiceUnavailable))
+
+		resp = get(Config{Embedder: stubEmbedder{err: errors.New("connection refused")}}, drv, "/v1/search/spans?q=x")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(fiber.StatusBadGateway))
+	})
+
+	It("returns 501 when the driver has no span search", func() {
+		resp := get(Config{Embedder: stubEmbedder{}}, inmemory.NewDriver(), "/v1/search/spans?q=x")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(fiber.StatusNotImplemented))
+	})
+})
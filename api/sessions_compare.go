@@ -0,0 +1,570 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/papercomputeco/tapes/pkg/llm"
+)
+
+// Session comparison — two runs of the same task (a prompt or model change
+// between them) lined up turn by turn. Traces align by prompt similarity
+// and tool sequence, tool calls within an aligned pair by tool name, and
+// every difference the projection can ground is reported: tool calls
+// added, dropped or changed, replies that moved, and token, cost and
+// duration deltas. Deltas are always B minus A.
+
+// Alignment weights. A pair of traces scores promptWeight × prompt
+// similarity + toolWeight × tool-sequence similarity, and only pairs
+// scoring at least alignThreshold align — so the same prompt aligns
+// however differently the run went, while the same tools behind a
+// different prompt do not.
+const (
+	promptWeight   = 0.7
+	toolWeight     = 0.3
+	alignThreshold = 0.5
+)
+
+// comparePreviewRunes bounds the prompt, reply and tool payload previews a
+// comparison carries; the full payloads are a span drill-in away.
+const comparePreviewRunes = 240
+
+// Trace alignment outcomes and tool step operations.
+const (
+	CompareAligned = "aligned"
+	CompareOnlyA   = "only_a"
+	CompareOnlyB   = "only_b"
+	CompareSame    = "same"
+	CompareChanged = "changed"
+)
+
+// SessionCompareResponse is GET /v1/sessions/compare: both sessions'
+// headers and totals, their difference, and the turn-by-turn alignment.
+// Traces are in session order — an only_a or only_b trace sits where it
+// fell between the aligned pairs around it.
+type SessionCompareResponse struct {
+	Schema         string             `json:"schema"`
+	A              SessionCompareSide `json:"a"`
+	B              SessionCompareSide `json:"b"`
+	Delta          CompareTotals      `json:"delta"`
+	OutcomeChanged bool               `json:"outcome_changed"`
+	// Aligned counts the aligned trace pairs; Traces is the whole
+	// alignment, Tools the per-tool call counts on each side.
+	Aligned int               `json:"aligned"`
+	Traces  []TraceComparison `json:"traces"`
+	Tools   []ToolComparison  `json:"tools"`
+}
+
+// SessionCompareSide is one session of a comparison: its stanza (the
+// outcome and per-model usage ride in session.rollup) and its totals.
+type SessionCompareSide struct {
+	Session SessionItem   `json:"session"`
+	Totals  CompareTotals `json:"totals"`
+}
+
+// CompareTotals sums a session's or a trace's work. DurationNS is the sum
+// of trace durations — time spent working, not the session's wall clock,
+// which includes the user's think time. Pinned.
+type CompareTotals struct {
+	Traces       int     `json:"traces"`
+	LLMCalls     int     `json:"llm_calls"`
+	ToolCalls    int     `json:"tool_calls"`
+	ToolErrors   int     `json:"tool_errors"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+	DurationNS   int64   `json:"duration_ns"`
+}
+
+// TraceComparison is one step of the trace alignment. An aligned pair
+// carries both traces, the similarities it aligned on, whether the reply
+// or status moved, the B − A delta and its tool steps; an only_a or
+// only_b step carries its one trace.
+type TraceComparison struct {
+	Match            string         `json:"match"`
+	A                *CompareTrace  `json:"a,omitempty"`
+	B                *CompareTrace  `json:"b,omitempty"`
+	PromptSimilarity float64        `json:"prompt_similarity"`
+	ToolSimilarity   float64        `json:"tool_similarity"`
+	OutputSimilarity float64        `json:"output_similarity"`
+	OutputChanged    bool           `json:"output_changed"`
+	StatusChanged    bool           `json:"status_changed"`
+	Delta            *CompareTotals `json:"delta,omitempty"`
+	ToolSteps        []ToolStep     `json:"tool_steps,omitempty"`
+}
+
+// CompareTrace is a trace's header within a comparison. Index is its
+// 1-based position in its session.
+type CompareTrace struct {
+	Index           int           `json:"index"`
+	TraceID         string        `json:"trace_id"`
+	UserPrompt      string        `json:"user_prompt"`
+	ResponsePreview string        `json:"response_preview,omitempty"`
+	Status          string        `json:"status"`
+	Synthetic       string        `json:"synthetic,omitempty"`
+	Totals          CompareTotals `json:"totals"`
+}
+
+// ToolStep is one step of an aligned pair's tool-call alignment: the same
+// tool called on both sides (same, or changed when its input or output
+// differs), or a call only one side made.
+type ToolStep struct {
+	Op            string    `json:"op"`
+	A             *ToolCall `json:"a,omitempty"`
+	B             *ToolCall `json:"b,omitempty"`
+	InputChanged  bool      `json:"input_changed,omitempty"`
+	OutputChanged bool      `json:"output_changed,omitempty"`
+}
+
+// ToolCall is one tool span, its input and output previewed.
+type ToolCall struct {
+	SpanID   string `json:"span_id"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	ThreadID string `json:"thread_id,omitempty"`
+	Input    string `json:"input,omitempty"`
+	Output   string `json:"output,omitempty"`
+
+	// Full canonical payloads, compared but not served.
+	input, output string
+}
+
+// ToolComparison is one tool's call and error counts on each side.
+type ToolComparison struct {
+	Tool    string `json:"tool"`
+	CallsA  int    `json:"calls_a"`
+	CallsB  int    `json:"calls_b"`
+	ErrorsA int    `json:"errors_a"`
+	ErrorsB int    `json:"errors_b"`
+}
+
+// handleCompareSessions handles GET /v1/sessions/compare?a=&b=.
+func (s *Server) handleCompareSessions(c *fiber.Ctx) error {
+	sessions, ok := s.driver.(sessionsReader)
+	if !ok {
+		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: "sessions not supported by this backend"})
+	}
+	reader, ok := s.driver.(spanModelReader)
+	if !ok {
+		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: "span traces not supported by this backend"})
+	}
+
+	ids := [2]string{c.Query("a"), c.Query("b")}
+	for _, id := range ids {
+		if id == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "a and b parameters required"})
+		}
+		if _, err := uuid.Parse(id); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "a and b must be valid UUIDs"})
+		}
+	}
+
+	orgID := s.orgID(c)
+	var docs [2]*SessionTracesResponse
+	for i, id := range ids {
+		sess, err := sessions.GetSessionRecord(c.Context(), orgID, id)
+		if err != nil {
+			s.logger.Error("get session for compare", "id", id, "error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to load session"})
+		}
+		if sess == nil || !sessionVisible(c, sess) {
+			return c.Status(fiber.StatusNotFound).JSON(llm.ErrorResponse{Error: "session not found"})
+		}
+		turns, spans, links, err := reader.ListSessionSpanModel(c.Context(), id)
+		if err != nil {
+			s.logger.Error("list span model", "session_id", id, "error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to load session traces"})
+		}
+		docs[i] = BuildSessionTraces(sessionItemFromStorage(*sess, time.Now()), turns, spans, links, PayloadFull)
+	}
+
+	return c.JSON(CompareSessions(docs[0], docs[1]))
+}
+
+// CompareSessions aligns two composite session responses and reports
+// their differences. Pure: exported so `tapes compare` renders exactly
+// what the handler serves, from a corpus or a database.
+func CompareSessions(a, b *SessionTracesResponse) *SessionCompareResponse {
+	as, bs := compareTraces(a), compareTraces(b)
+	resp := &SessionCompareResponse{
+		Schema:         ProjectionSchema,
+		A:              SessionCompareSide{Session: a.Session, Totals: sumTotals(as)},
+		B:              SessionCompareSide{Session: b.Session, Totals: sumTotals(bs)},
+		OutcomeChanged: a.Session.Rollup.Outcome != b.Session.Rollup.Outcome,
+		Traces:         []TraceComparison{},
+		Tools:          toolCounts(as, bs),
+	}
+	resp.Delta = resp.B.Totals.minus(resp.A.Totals)
+
+	for _, step := range alignTraces(as, bs) {
+		switch {
+		case step.a == nil:
+			resp.Traces = append(resp.Traces, TraceComparison{Match: CompareOnlyB, B: &step.b.header})
+		case step.b == nil:
+			resp.Traces = append(resp.Traces, TraceComparison{Match: CompareOnlyA, A: &step.a.header})
+		default:
+			resp.Aligned++
+			resp.Traces = append(resp.Traces, comparePair(step.a, step.b))
+		}
+	}
+	return resp
+}
+
+// comparedTrace is a trace reduced to what a comparison reads.
+type comparedTrace struct {
+	header CompareTrace
+	words  map[string]int
+	reply  map[string]int
+	tools  []ToolCall
+}
+
+func compareTraces(doc *SessionTracesResponse) []*comparedTrace {
+	out := make([]*comparedTrace, 0, len(doc.Traces))
+	for i, t := range doc.Traces {
+		ct := &comparedTrace{
+			header: CompareTrace{
+				Index:           i + 1,
+				TraceID:         t.Trace.TraceID,
+				UserPrompt:      truncateRunes(t.Trace.UserPrompt, comparePreviewRunes),
+				ResponsePreview: truncateRunes(t.Trace.ResponsePreview, comparePreviewRunes),
+				Status:          t.Trace.Status,
+				Synthetic:       t.Trace.Synthetic,
+				Totals: CompareTotals{
+					Traces:       1,
+					InputTokens:  t.Trace.Usage.InputTokens,
+					OutputTokens: t.Trace.Usage.OutputTokens,
+					CostUSD:      t.Trace.Usage.CostUSD,
+					DurationNS:   t.Trace.DurationNS,
+				},
+			},
+			words: wordBag(t.Trace.UserPrompt),
+			reply: wordBag(t.Trace.ResponsePreview),
+		}
+		for _, sp := range t.Spans {
+			switch sp.Kind {
+			case "llm":
+				ct.header.Totals.LLMCalls++
+			case "tool":
+				ct.header.Totals.ToolCalls++
+				if sp.Status == "error" {
+					ct.header.Totals.ToolErrors++
+				}
+				ct.tools = append(ct.tools, toolCall(sp))
+			}
+		}
+		out = append(out, ct)
+	}
+	return out
+}
+
+// toolCall reads a tool span's call: its tool_use input as canonical JSON
+// (object keys sorted, so key order never reads as a change) and its
+// tool_result text.
+func toolCall(sp SpanItem) ToolCall {
+	call := ToolCall{SpanID: sp.SpanID, Name: sp.Name, Status: sp.Status, ThreadID: sp.ThreadID}
+	for _, blk := range decodeBlocks(sp.Input) {
+		if blk.Type == "tool_use" && blk.ToolInput != nil {
+			if b, err := json.Marshal(blk.ToolInput); err == nil {
+				call.input = string(b)
+			}
+			break
+		}
+	}
+	var out []string
+	for _, blk := range decodeBlocks(sp.Output) {
+		switch {
+		case blk.ToolOutput != "":
+			out = append(out, blk.ToolOutput)
+		case blk.Text != "":
+			out = append(out, blk.Text)
+		}
+	}
+	call.output = strings.Join(out, "\n")
+	call.Input = truncateRunes(call.input, comparePreviewRunes)
+	call.Output = truncateRunes(call.output, comparePreviewRunes)
+	return call
+}
+
+// traceStep is one step of the trace alignment; a nil side is a trace
+// the other session has no counterpart for.
+type traceStep struct {
+	a, b *comparedTrace
+}
+
+// alignTraces finds the order-preserving pairing of the two sessions'
+// traces that maximizes total similarity, pairing only traces that score
+// at least alignThreshold, and walks it back as one merged sequence.
+func alignTraces(as, bs []*comparedTrace) []traceStep {
+	n, m := len(as), len(bs)
+	score := make([][]float64, n+1)
+	best := make([][]float64, n+1)
+	for i := range best {
+		score[i] = make([]float64, m+1)
+		best[i] = make([]float64, m+1)
+	}
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			score[i][j] = traceScore(as[i-1], bs[j-1])
+			best[i][j] = math.Max(best[i-1][j], best[i][j-1])
+			if score[i][j] >= alignThreshold {
+				best[i][j] = math.Max(best[i][j], best[i-1][j-1]+score[i][j])
+			}
+		}
+	}
+
+	var steps []traceStep
+	i, j := n, m
+	for i > 0 || j > 0 {
+		switch {
+		case i > 0 && j > 0 && score[i][j] >= alignThreshold && best[i][j] == best[i-1][j-1]+score[i][j]:
+			steps = append(steps, traceStep{a: as[i-1], b: bs[j-1]})
+			i--
+			j--
+		case j > 0 && (i == 0 || best[i][j] == best[i][j-1]):
+			steps = append(steps, traceStep{b: bs[j-1]})
+			j--
+		default:
+			steps = append(steps, traceStep{a: as[i-1]})
+			i--
+		}
+	}
+	for l, r := 0, len(steps)-1; l < r; l, r = l+1, r-1 {
+		steps[l], steps[r] = steps[r], steps[l]
+	}
+	return steps
+}
+
+func traceScore(a, b *comparedTrace) float64 {
+	return promptWeight*promptSimilarity(a, b) + toolWeight*toolSimilarity(a.tools, b.tools)
+}
+
+// promptSimilarity compares the prompts that opened two traces. Synthetic
+// traces have no prompt, so two of the same kind match outright and a
+// synthetic trace never matches a prompted one.
+func promptSimilarity(a, b *comparedTrace) float64 {
+	if a.header.Synthetic != b.header.Synthetic {
+		return 0
+	}
+	if len(a.words) == 0 && len(b.words) == 0 {
+		return 1
+	}
+	return dice(a.words, b.words)
+}
+
+// toolSimilarity is the share of two tool sequences that their longest
+// common subsequence of tool names covers.
+func toolSimilarity(a, b []ToolCall) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	common := len(toolLCS(a, b))
+	return 2 * float64(common) / float64(len(a)+len(b))
+}
+
+// toolLCS returns the index pairs of a longest common subsequence of tool
+// names, in order.
+func toolLCS(a, b []ToolCall) [][2]int {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i].Name == b[j].Name {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var pairs [][2]int
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case a[i].Name == b[j].Name:
+			pairs = append(pairs, [2]int{i, j})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+// comparePair reports the differences between two aligned traces.
+func comparePair(a, b *comparedTrace) TraceComparison {
+	delta := b.header.Totals.minus(a.header.Totals)
+	tc := TraceComparison{
+		Match:            CompareAligned,
+		A:                &a.header,
+		B:                &b.header,
+		PromptSimilarity: round3(promptSimilarity(a, b)),
+		ToolSimilarity:   round3(toolSimilarity(a.tools, b.tools)),
+		OutputSimilarity: 1,
+		StatusChanged:    a.header.Status != b.header.Status,
+		Delta:            &delta,
+		ToolSteps:        toolSteps(a.tools, b.tools),
+	}
+	if len(a.reply) > 0 || len(b.reply) > 0 {
+		tc.OutputSimilarity = round3(dice(a.reply, b.reply))
+	}
+	tc.OutputChanged = strings.TrimSpace(a.header.ResponsePreview) != strings.TrimSpace(b.header.ResponsePreview)
+	return tc
+}
+
+// toolSteps aligns two traces' tool calls on their longest common
+// subsequence of tool names; calls off it are only_a or only_b, in the
+// order they were made.
+func toolSteps(a, b []ToolCall) []ToolStep {
+	var steps []ToolStep
+	i, j := 0, 0
+	flush := func(toI, toJ int) {
+		for ; i < toI; i++ {
+			steps = append(steps, ToolStep{Op: CompareOnlyA, A: &a[i]})
+		}
+		for ; j < toJ; j++ {
+			steps = append(steps, ToolStep{Op: CompareOnlyB, B: &b[j]})
+		}
+	}
+	for _, p := range toolLCS(a, b) {
+		flush(p[0], p[1])
+		step := ToolStep{
+			Op:            CompareSame,
+			A:             &a[i],
+			B:             &b[j],
+			InputChanged:  a[i].input != b[j].input,
+			OutputChanged: a[i].output != b[j].output || a[i].Status != b[j].Status,
+		}
+		if step.InputChanged || step.OutputChanged {
+			step.Op = CompareChanged
+		}
+		steps = append(steps, step)
+		i++
+		j++
+	}
+	flush(len(a), len(b))
+	return steps
+}
+
+// toolCounts tallies calls and errors per tool on each side, by name.
+func toolCounts(as, bs []*comparedTrace) []ToolComparison {
+	byName := map[string]*ToolComparison{}
+	tally := func(traces []*comparedTrace, sideB bool) {
+		for _, t := range traces {
+			for _, call := range t.tools {
+				tc := byName[call.Name]
+				if tc == nil {
+					tc = &ToolComparison{Tool: call.Name}
+					byName[call.Name] = tc
+				}
+				errored := call.Status == "error"
+				switch {
+				case sideB:
+					tc.CallsB++
+					if errored {
+						tc.ErrorsB++
+					}
+				default:
+					tc.CallsA++
+					if errored {
+						tc.ErrorsA++
+					}
+				}
+			}
+		}
+	}
+	tally(as, false)
+	tally(bs, true)
+
+	out := make([]ToolComparison, 0, len(byName))
+	for _, tc := range byName {
+		out = append(out, *tc)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Tool < out[j].Tool })
+	return out
+}
+
+func sumTotals(traces []*comparedTrace) CompareTotals {
+	var t CompareTotals
+	for _, ct := range traces {
+		t = t.plus(ct.header.Totals)
+	}
+	return t
+}
+
+func (t CompareTotals) plus(o CompareTotals) CompareTotals {
+	return CompareTotals{
+		Traces:       t.Traces + o.Traces,
+		LLMCalls:     t.LLMCalls + o.LLMCalls,
+		ToolCalls:    t.ToolCalls + o.ToolCalls,
+		ToolErrors:   t.ToolErrors + o.ToolErrors,
+		InputTokens:  t.InputTokens + o.InputTokens,
+		OutputTokens: t.OutputTokens + o.OutputTokens,
+		CostUSD:      t.CostUSD + o.CostUSD,
+		DurationNS:   t.DurationNS + o.DurationNS,
+	}
+}
+
+func (t CompareTotals) minus(o CompareTotals) CompareTotals {
+	return CompareTotals{
+		Traces:       t.Traces - o.Traces,
+		LLMCalls:     t.LLMCalls - o.LLMCalls,
+		ToolCalls:    t.ToolCalls - o.ToolCalls,
+		ToolErrors:   t.ToolErrors - o.ToolErrors,
+		InputTokens:  t.InputTokens - o.InputTokens,
+		OutputTokens: t.OutputTokens - o.OutputTokens,
+		CostUSD:      t.CostUSD - o.CostUSD,
+		DurationNS:   t.DurationNS - o.DurationNS,
+	}
+}
+
+// wordBag splits text into lowercase words with their counts.
+func wordBag(s string) map[string]int {
+	bag := map[string]int{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		bag[w]++
+	}
+	return bag
+}
+
+// dice is the Sørensen–Dice coefficient of two word bags: twice the shared
+// words over the total, 1 for identical text and 0 for nothing in common.
+func dice(a, b map[string]int) float64 {
+	var total, shared int
+	for w, n := range a {
+		total += n
+		shared += min(n, b[w])
+	}
+	for _, n := range b {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	return 2 * float64(shared) / float64(total)
+}
+
+func round3(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
+
+// truncateRunes bounds s to n runes, marking the cut.
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
@@ -0,0 +1,269 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/llm"
+	tapeslogger "github.com/papercomputeco/tapes/pkg/logger"
+	"github.com/papercomputeco/tapes/pkg/storage"
+	"github.com/papercomputeco/tapes/pkg/storage/inmemory"
+)
+
+// compareTool is one tool call in a hand-built compare fixture.
+type compareTool struct {
+	name, input, output string
+	failed              bool
+}
+
+// compareTurn is one trace in a hand-built compare fixture: a prompt, the
+// reply, one llm call and the tool calls it made.
+type compareTurn struct {
+	prompt, reply string
+	tokens        int64
+	tools         []compareTool
+}
+
+func compareDoc(id, outcome string, turns ...compareTurn) *SessionTracesResponse {
+	doc := &SessionTracesResponse{
+		Schema:  ProjectionSchema,
+		Session: SessionItem{ID: id},
+		Traces:  []TraceDetail{},
+		Links:   []SpanLinkItem{},
+	}
+	doc.Session.Rollup.Outcome = outcome
+	for i, turn := range turns {
+		traceID := fmt.Sprintf("%s-t%d", id, i+1)
+		detail := TraceDetail{
+			Trace: TraceItem{
+				TraceID:         traceID,
+				UserPrompt:      turn.prompt,
+				ResponsePreview: turn.reply,
+				Status:          "ok",
+				DurationNS:      int64(time.Second),
+				Usage:           TraceUsage{InputTokens: turn.tokens, OutputTokens: turn.tokens / 10, CostUSD: float64(turn.tokens) / 1e6},
+			},
+			Spans: []SpanItem{{TraceID: traceID, SpanID: "llm", Kind: "llm", Name: "claude-sonnet-4", Status: "ok"}},
+		}
+		for j, tool := range turn.tools {
+			in, err := json.Marshal([]llm.ContentBlock{{Type: "tool_use", ToolName: tool.name, ToolInput: map[string]any{"arg": tool.input}}})
+			Expect(err).NotTo(HaveOccurred())
+			out, err := json.Marshal([]llm.ContentBlock{{Type: "tool_result", ToolOutput: tool.output, IsError: tool.failed}})
+			Expect(err).NotTo(HaveOccurred())
+			status := "ok"
+			if tool.failed {
+				status = "error"
+			}
+			detail.Spans = append(detail.Spans, SpanItem{
+				TraceID: traceID, SpanID: fmt.Sprintf("tool%d", j+1), Kind: "tool", Name: tool.name,
+				Status: status, Input: in, Output: out,
+			})
+		}
+		doc.Traces = append(doc.Traces, detail)
+	}
+	return doc
+}
+
+var _ = Describe("CompareSessions", func() {
+	fixBuild := compareTurn{
+		prompt: "Fix the failing build in the parser package", reply: "Fixed the missing import.", tokens: 1000,
+		tools: []compareTool{{"Read", "parser.go", "package parser", false}, {"Edit", "parser.go", "ok", false}, {"Bash", "go test", "PASS", false}},
+	}
+	addTests := compareTurn{
+		prompt: "Now add a test for the empty input case", reply: "Added TestParseEmpty.", tokens: 2000,
+		tools: []compareTool{{"Write", "parser_test.go", "ok", false}, {"Bash", "go test", "PASS", false}},
+	}
+
+	It("aligns a session with itself and reports no differences", func() {
+		a := compareDoc("a", "completed", fixBuild, addTests)
+		b := compareDoc("b", "completed", fixBuild, addTests)
+
+		got := CompareSessions(a, b)
+		Expect(got.Schema).To(Equal(ProjectionSchema))
+		Expect(got.Aligned).To(Equal(2))
+		Expect(got.OutcomeChanged).To(BeFalse())
+		Expect(got.Delta).To(Equal(CompareTotals{}))
+		Expect(got.A.Totals).To(Equal(CompareTotals{
+			Traces: 2, LLMCalls: 2, ToolCalls: 5, InputTokens: 3000, OutputTokens: 300,
+			CostUSD: 0.003, DurationNS: int64(2 * time.Second),
+		}))
+		for _, tc := range got.Traces {
+			Expect(tc.Match).To(Equal(CompareAligned))
+			Expect(tc.PromptSimilarity).To(Equal(1.0))
+			Expect(tc.ToolSimilarity).To(Equal(1.0))
+			Expect(tc.OutputChanged).To(BeFalse())
+			for _, step := range tc.ToolSteps {
+				Expect(step.Op).To(Equal(CompareSame))
+			}
+		}
+	})
+
+	It("reports changed, added and dropped tool calls and the B minus A delta", func() {
+		reworked := fixBuild
+		reworked.reply = "Fixed the import and a typo."
+		reworked.tokens = 1500
+		reworked.tools = []compareTool{
+			{"Grep", "import", "parser.go:3", false},
+			{"Read", "parser.go", "package parser", false},
+			{"Edit", "parser.go", "ok", false},
+			{"Bash", "go test ./...", "PASS", false},
+		}
+		a := compareDoc("a", "completed", fixBuild)
+		b := compareDoc("b", "completed", reworked)
+
+		got := CompareSessions(a, b)
+		Expect(got.Traces).To(HaveLen(1))
+		tc := got.Traces[0]
+		Expect(tc.Match).To(Equal(CompareAligned))
+		Expect(tc.OutputChanged).To(BeTrue())
+		Expect(tc.OutputSimilarity).To(BeNumerically(">", 0))
+		Expect(tc.OutputSimilarity).To(BeNumerically("<", 1))
+		Expect(tc.ToolSimilarity).To(Equal(round3(6.0 / 7)))
+
+		var ops []string
+		for _, step := range tc.ToolSteps {
+			ops = append(ops, step.Op)
+		}
+		Expect(ops).To(Equal([]string{CompareOnlyB, CompareSame, CompareSame, CompareChanged}))
+		last := tc.ToolSteps[3]
+		Expect(last.InputChanged).To(BeTrue())
+		Expect(last.OutputChanged).To(BeFalse())
+		Expect(last.A.Input).To(Equal(`{"arg":"go test"}`))
+		Expect(last.B.Input).To(Equal(`{"arg":"go test ./..."}`))
+
+		Expect(tc.Delta.InputTokens).To(Equal(int64(500)))
+		Expect(tc.Delta.ToolCalls).To(Equal(1))
+		Expect(got.Delta.InputTokens).To(Equal(int64(500)))
+		Expect(got.Delta.CostUSD).To(BeNumerically("~", 0.0005, 1e-9))
+		Expect(got.Tools).To(ContainElement(ToolComparison{Tool: "Grep", CallsA: 0, CallsB: 1}))
+	})
+
+	It("aligns a reworded prompt and leaves an unrelated one unpaired, in session order", func() {
+		reworded := fixBuild
+		reworded.prompt = "Please fix the failing build in the parser package"
+		unrelated := compareTurn{prompt: "Write release notes for version two", reply: "Done.", tokens: 500}
+		a := compareDoc("a", "completed", reworded, addTests)
+		b := compareDoc("b", "completed", fixBuild, unrelated, addTests)
+
+		got := CompareSessions(a, b)
+		Expect(got.Aligned).To(Equal(2))
+		Expect(got.Traces).To(HaveLen(3))
+		Expect(got.Traces[0].Match).To(Equal(CompareAligned))
+		Expect(got.Traces[0].PromptSimilarity).To(BeNumerically("<", 1))
+		Expect(got.Traces[1].Match).To(Equal(CompareOnlyB))
+		Expect(got.Traces[1].B.Index).To(Equal(2))
+		Expect(got.Traces[1].A).To(BeNil())
+		Expect(got.Traces[2].Match).To(Equal(CompareAligned))
+		Expect(got.Traces[2].A.Index).To(Equal(2))
+		Expect(got.Traces[2].B.Index).To(Equal(3))
+		Expect(got.Delta.Traces).To(Equal(1))
+	})
+
+	It("does not align the same tools behind a different prompt", func() {
+		other := addTests
+		other.prompt = "Summarize the repository layout"
+		got := CompareSessions(compareDoc("a", "completed", addTests), compareDoc("b", "completed", other))
+		Expect(got.Aligned).To(Equal(0))
+		Expect(got.Traces).To(HaveLen(2))
+	})
+
+	It("reports an outcome change and counts tool errors per side", func() {
+		failing := addTests
+		failing.tools = []compareTool{{"Write", "parser_test.go", "ok", false}, {"Bash", "go test", "FAIL", true}}
+		got := CompareSessions(compareDoc("a", "completed", addTests), compareDoc("b", "errored", failing))
+
+		Expect(got.OutcomeChanged).To(BeTrue())
+		Expect(got.B.Totals.ToolErrors).To(Equal(1))
+		Expect(got.Delta.ToolErrors).To(Equal(1))
+		Expect(got.Traces[0].ToolSteps[1].Op).To(Equal(CompareChanged))
+		Expect(got.Traces[0].ToolSteps[1].OutputChanged).To(BeTrue())
+		Expect(got.Tools).To(Equal([]ToolComparison{
+			{Tool: "Bash", CallsA: 1, CallsB: 1, ErrorsB: 1},
+			{Tool: "Write", CallsA: 1, CallsB: 1},
+		}))
+	})
+})
+
+// compareStubDriver serves canned sessions and span models by id. The
+// embedded capabilities are nil apart from the two reads the compare
+// handler makes.
+type compareStubDriver struct {
+	*sessionsStubDriver
+	storage.SpanModelReader
+
+	records map[string]*storage.SessionRecord
+	models  map[string][]storage.SpanTurnRecord
+}
+
+func (d *compareStubDriver) GetSessionRecord(_ context.Context, _, id string) (*storage.SessionRecord, error) {
+	return d.records[id], nil
+}
+
+func (d *compareStubDriver) ListSessionSpanModel(_ context.Context, id string) ([]storage.SpanTurnRecord, []storage.SpanRecord, []storage.SpanLinkRecord, error) {
+	return d.models[id], nil, nil, nil
+}
+
+var _ = Describe("GET /v1/sessions/compare", func() {
+	const (
+		idA = "0ea3c2cc-fe9d-41ff-aab1-4134ad00c350"
+		idB = "5b0c8f2e-3a9d-4c71-9a43-2f1d7e6b8c90"
+	)
+
+	get := func(path string) (*http.Response, map[string]any) {
+		drv := &compareStubDriver{
+			sessionsStubDriver: &sessionsStubDriver{Driver: inmemory.NewDriver()},
+			records: map[string]*storage.SessionRecord{
+				idA: {ID: idA, HarnessID: "claude-code", HarnessSessionID: "run-a"},
+				idB: {ID: idB, HarnessID: "claude-code", HarnessSessionID: "run-b"},
+			},
+			models: map[string][]storage.SpanTurnRecord{
+				idA: {{TraceID: "ta", UserPrompt: "fix the build", Status: "ok", TotalInputTokens: 100}},
+				idB: {{TraceID: "tb", UserPrompt: "fix the build", Status: "ok", TotalInputTokens: 250}},
+			},
+		}
+		server, err := NewServer(Config{ListenAddr: ":0"}, drv, tapeslogger.NewNoop())
+		Expect(err).NotTo(HaveOccurred())
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, path, nil)
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := server.app.Test(req)
+		Expect(err).NotTo(HaveOccurred())
+		var body map[string]any
+		Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+		resp.Body.Close()
+		return resp, body
+	}
+
+	It("compares two stored sessions", func() {
+		resp, body := get("/v1/sessions/compare?a=" + idA + "&b=" + idB)
+		Expect(resp.StatusCode).To(Equal(fiber.StatusOK))
+		Expect(body["aligned"]).To(BeEquivalentTo(1))
+		Expect(body["delta"]).To(HaveKeyWithValue("input_tokens", BeEquivalentTo(150)))
+		Expect(body["a"]).To(HaveKeyWithValue("session", HaveKeyWithValue("harness_session_id", "run-a")))
+	})
+
+	It("rejects a missing or malformed session id", func() {
+		resp, _ := get("/v1/sessions/compare?a=" + idA)
+		Expect(resp.StatusCode).To(Equal(fiber.StatusBadRequest))
+		resp, _ = get("/v1/sessions/compare?a=" + idA + "&b=run-b")
+		Expect(resp.StatusCode).To(Equal(fiber.StatusBadRequest))
+	})
+
+	It("returns 404 when either session is unknown", func() {
+		resp, body := get("/v1/sessions/compare?a=" + idA + "&b=7d1e0c4a-8b2f-4e3d-9c5a-1f6b7a8c9d0e")
+		Expect(resp.StatusCode).To(Equal(fiber.StatusNotFound))
+		Expect(body).To(HaveKeyWithValue("error", "session not found"))
+	})
+
+	It("is not shadowed by the session routes", func() {
+		resp, _ := get("/v1/sessions/compare")
+		Expect(resp.StatusCode).To(Equal(fiber.StatusBadRequest))
+		Expect(resp.Header.Get("Content-Type")).To(ContainSubstring("application/json"))
+	})
+})
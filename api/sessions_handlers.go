@@ -14,6 +14,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 
+	"github.com/papercomputeco/tapes/pkg/derive"
 	"github.com/papercomputeco/tapes/pkg/llm"
 	"github.com/papercomputeco/tapes/pkg/storage"
 )
@@ -106,6 +107,11 @@ type SessionItem struct {
 	// Computed at response time so the console renders it directly instead
 	// of inferring "running" itself.
 	Live bool `json:"live"`
+	// RetentionTier records what a retention rule did to the session:
+	// absent while its content is as captured, "stripped" once prompts and
+	// responses were removed. A stripped session keeps its rollup (usage,
+	// cost, status) but its traces carry no span input/output.
+	RetentionTier string `json:"retention_tier,omitempty"`
 	// Rollup is the deriver-owned projection over the session's spans.
 	Rollup SessionRollup `json:"rollup"`
 }
@@ -120,6 +126,11 @@ const sessionLiveWindow = 5 * time.Minute
 // Every field is 'unknown'/zero/empty until the session first derives.
 type SessionRollup struct {
 	Status string `json:"status"`
+	// Outcome refines Status into how the session ended (interrupted,
+	// errored, looped, …); OutcomeReasons is the evidence, pinned to []
+	// so the rollup shape is uniform across sessions.
+	Outcome        string                 `json:"outcome"`
+	OutcomeReasons []SessionOutcomeReason `json:"outcome_reasons"`
 	// Title is the deriver's folded session title (derived_title),
 	// generated from the conversation. Empty until title generation
 	// produces one. It never falls back to the identity-row name, so it is
@@ -139,6 +150,33 @@ type SessionRollup struct {
 	KindCounts map[string]int `json:"kind_counts"`
 	Tasks      []TreeTask     `json:"tasks"`
 	Usage      SessionUsage   `json:"usage"`
+	// Context is the session's context-window fold, pinned like Usage.
+	Context SessionContext `json:"context"`
+}
+
+// SessionOutcomeReason is one piece of evidence behind a session's
+// outcome: a reason code, the terminal error category for a
+// terminal_error, where it was seen, and a loop's length.
+type SessionOutcomeReason struct {
+	Code     string `json:"code"`
+	Category string `json:"category,omitempty"`
+	TraceID  string `json:"trace_id,omitempty"`
+	SpanID   string `json:"span_id,omitempty"`
+	Count    int    `json:"count,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// SessionContext is the session-level context-window rollup: the fullest
+// the conversation's context got, how many compactions it took and the
+// tokens they reclaimed, and the share of every llm call's prompt tokens
+// the provider served from cache (cache efficiency).
+type SessionContext struct {
+	PeakTokens      int64   `json:"peak_tokens"`
+	PeakOccupancy   float64 `json:"peak_occupancy"`
+	Compactions     int     `json:"compactions"`
+	ReclaimedTokens int64   `json:"reclaimed_tokens"`
+	CacheReadTokens int64   `json:"cache_read_tokens"`
+	CacheHitRatio   float64 `json:"cache_hit_ratio"`
 }
 
 // SessionUsage is the session's total token/cost spend, folded from the
@@ -250,15 +288,18 @@ func sessionItemFromStorage(s storage.SessionRecord, now time.Time) SessionItem
 		DisplayName:      s.DisplayName,
 		DisplayTitle:     resolveSessionDisplayTitle(s),
 		Live:             s.EndedAt == nil && now.Sub(s.LastSeenAt) < sessionLiveWindow,
+		RetentionTier:    s.RetentionTier,
 		Rollup: SessionRollup{
-			Status:     s.DerivedStatus,
-			Title:      s.DerivedTitle,
-			Preview:    s.Preview,
-			TurnCount:  s.TurnCount,
-			Model:      s.Model,
-			ModelUsage: modelUsageFromStorage(s.ModelUsage),
-			KindCounts: map[string]int{},
-			Tasks:      []TreeTask{},
+			Status:         s.DerivedStatus,
+			Outcome:        derive.OutcomeAt(s.Outcome, s.LastSeenAt, now),
+			OutcomeReasons: []SessionOutcomeReason{},
+			Title:          s.DerivedTitle,
+			Preview:        s.Preview,
+			TurnCount:      s.TurnCount,
+			Model:          s.Model,
+			ModelUsage:     modelUsageFromStorage(s.ModelUsage),
+			KindCounts:     map[string]int{},
+			Tasks:          []TreeTask{},
 			Usage: SessionUsage{
 				InputTokens:  s.TotalInputTokens,
 				OutputTokens: s.TotalOutputTokens,
@@ -266,17 +307,32 @@ func sessionItemFromStorage(s storage.SessionRecord, now time.Time) SessionItem
 			},
 		},
 	}
-	// Tasks/kind_counts are stored as raw deriver JSON; decode them into
-	// the rollup, leaving the pinned []/{} on absent or malformed values.
+	// Tasks/kind_counts/context/outcome_reasons are stored as raw deriver
+	// JSON; decode them
+	// into the rollup, leaving the pinned zero values on absent or
+	// malformed values.
 	if len(s.Tasks) > 0 {
 		_ = json.Unmarshal(s.Tasks, &item.Rollup.Tasks)
 	}
 	if len(s.KindCounts) > 0 {
 		_ = json.Unmarshal(s.KindCounts, &item.Rollup.KindCounts)
 	}
+	if len(s.ContextUsage) > 0 {
+		_ = json.Unmarshal(s.ContextUsage, &item.Rollup.Context)
+	}
+	if len(s.OutcomeReasons) > 0 {
+		_ = json.Unmarshal(s.OutcomeReasons, &item.Rollup.OutcomeReasons)
+	}
 	return item
 }
 
+// SessionItemFromRecord renders a stored session as the API's session
+// stanza. Exported so `tapes report` renders the stanza the handlers
+// serve.
+func SessionItemFromRecord(s storage.SessionRecord, now time.Time) SessionItem {
+	return sessionItemFromStorage(s, now)
+}
+
 // modelUsageFromStorage maps the storage-layer per-model breakdown to
 // the API shape. Nil in stays nil out (omitted from the response).
 func modelUsageFromStorage(in []storage.ModelUsage) []ModelUsage {
@@ -417,12 +473,34 @@ func (s *Server) handleListSessions(c *fiber.Ctx) error {
 		opts.Until = &t
 	}
 
-	orgID := singleTenantOrgID
+	if raw := c.Query("outcome"); raw != "" {
+		if !derive.ValidOutcome(raw) {
+			return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "invalid outcome"})
+		}
+		opts.Outcome = raw
+	}
+	if raw := c.Query("outcome_reason"); raw != "" {
+		if !derive.ValidOutcomeReason(raw) {
+			return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "invalid outcome_reason"})
+		}
+		opts.OutcomeReason = raw
+	}
+	match, err := parseAnnotationMatch(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: err.Error()})
+	}
+	opts.Annotation = match
+
+	orgID := s.orgID(c)
 	// auth_subject is a caller-supplied filter, not an identity claim: it
 	// narrows results within this tenant and grants nothing. The verified
 	// subject is stamped at ingest from the JWT (x-paper-auth-subject) and
 	// is what gets stored; this only chooses which of those rows to show.
 	opts.AuthSubject = c.Query("auth_subject")
+	if subject, restricted := ownSessionsSubject(c); restricted {
+		// A caller restricted to its own sessions cannot widen the filter.
+		opts.AuthSubject = subject
+	}
 	// Fetch one extra item to detect whether a next page exists.
 	opts.Limit = limit + 1
 	sessions, err := reader.ListSessionRecords(c.Context(), orgID, opts)
@@ -462,10 +540,11 @@ func (s *Server) handleListSessions(c *fiber.Ctx) error {
 // form returns at most one row per harness (in practice zero or one). A
 // lone harness_id is rejected (400): it names a harness, not a session,
 // and would be an unbounded, unpaginated list. Cursor, sort, direction,
-// since, and until combined with the filter are rejected (400): they
-// belong to the paged-list path, and the lookup has no ordering or window
-// to apply them to. Returns the standard SessionListResponse envelope
-// with the matching items and no next_cursor.
+// since, until, outcome, outcome_reason, and the annotation filters
+// combined with the filter are rejected (400): they belong to the
+// paged-list path, and the lookup has no ordering, window or filter to
+// apply them to. Returns the standard SessionListResponse envelope with
+// the matching items and no next_cursor.
 func (s *Server) listSessionsByHarness(c *fiber.Ctx, reader sessionsReader) error {
 	harnessID := c.Query("harness_id")
 	harnessSessionID := c.Query("harness_session_id")
@@ -487,7 +566,10 @@ func (s *Server) listSessionsByHarness(c *fiber.Ctx, reader sessionsReader) erro
 	// the caller believes are in effect. This applies to the paired and
 	// lone forms alike.
 	var unsupported []string
-	for _, name := range []string{"sort", "direction", "since", "until"} {
+	for _, name := range []string{
+		"sort", "direction", "since", "until", "outcome", "outcome_reason",
+		"annotation", "annotation_label", "annotation_min_score", "annotation_max_score",
+	} {
 		if c.Query(name) != "" {
 			unsupported = append(unsupported, name)
 		}
@@ -498,7 +580,7 @@ func (s *Server) listSessionsByHarness(c *fiber.Ctx, reader sessionsReader) erro
 		})
 	}
 
-	orgID := singleTenantOrgID
+	orgID := s.orgID(c)
 
 	// No match is a normal outcome on either branch: the list envelope's
 	// empty items form expresses it (never 404 — that's the :id endpoint's
@@ -511,6 +593,9 @@ func (s *Server) listSessionsByHarness(c *fiber.Ctx, reader sessionsReader) erro
 			return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to list sessions"})
 		}
 		for _, rec := range recs {
+			if !sessionVisible(c, &rec) {
+				continue
+			}
 			items = append(items, sessionItemFromStorage(rec, time.Now()))
 		}
 		return c.JSON(SessionListResponse{Items: items})
@@ -521,7 +606,7 @@ func (s *Server) listSessionsByHarness(c *fiber.Ctx, reader sessionsReader) erro
 		s.logger.Error("get session by harness", "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to list sessions"})
 	}
-	if sess != nil {
+	if sess != nil && sessionVisible(c, sess) {
 		items = append(items, sessionItemFromStorage(*sess, time.Now()))
 	}
 	return c.JSON(SessionListResponse{Items: items})
@@ -544,13 +629,13 @@ func (s *Server) handleGetSession(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "id must be a valid UUID"})
 	}
 
-	orgID := singleTenantOrgID
+	orgID := s.orgID(c)
 	sess, err := reader.GetSessionRecord(c.Context(), orgID, id)
 	if err != nil {
 		s.logger.Error("get session", "id", id, "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to load session"})
 	}
-	if sess == nil {
+	if sess == nil || !sessionVisible(c, sess) {
 		return c.Status(fiber.StatusNotFound).JSON(llm.ErrorResponse{Error: "session not found"})
 	}
 
@@ -576,7 +661,15 @@ func (s *Server) handleDeleteSession(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "id must be a valid UUID"})
 	}
 
-	orgID := singleTenantOrgID
+	orgID := s.orgID(c)
+	visible, err := s.sessionIDVisible(c, id)
+	if err != nil {
+		s.logger.Error("get session", "id", id, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to delete session"})
+	}
+	if !visible {
+		return c.Status(fiber.StatusNotFound).JSON(llm.ErrorResponse{Error: "session not found"})
+	}
 	deleted, err := writer.DeleteSession(c.Context(), orgID, id)
 	if err != nil {
 		s.logger.Error("delete session", "id", id, "error", err)
@@ -657,7 +750,15 @@ func (s *Server) handleUpdateSession(c *fiber.Ctx) error {
 		}
 	}
 
-	orgID := singleTenantOrgID
+	orgID := s.orgID(c)
+	visible, err := s.sessionIDVisible(c, id)
+	if err != nil {
+		s.logger.Error("get session", "id", id, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to update session"})
+	}
+	if !visible {
+		return c.Status(fiber.StatusNotFound).JSON(llm.ErrorResponse{Error: "session not found"})
+	}
 	rowsAffected, err := reader.UpdateSessionDisplayName(c.Context(), orgID, id, normalized)
 	if err != nil {
 		s.logger.Error("update session display name", "id", id, "error", err)
@@ -672,7 +773,7 @@ func (s *Server) handleUpdateSession(c *fiber.Ctx) error {
 		s.logger.Error("get session", "id", id, "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to update session"})
 	}
-	if sess == nil {
+	if sess == nil || !sessionVisible(c, sess) {
 		return c.Status(fiber.StatusNotFound).JSON(llm.ErrorResponse{Error: "session not found"})
 	}
 
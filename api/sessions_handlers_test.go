@@ -35,6 +35,9 @@ type sessionsStubDriver struct {
 	listCalls       int
 	lastListOrg     string
 	lastAuthSubject string
+	lastOutcome     string
+	lastReason      string
+	lastAnnotation  storage.AnnotationMatch
 	lastLimit       int
 	lastCursorVal   *string
 	lastCursorID    *string
@@ -106,6 +109,9 @@ func (d *sessionsStubDriver) ListSessionRecords(_ context.Context, orgID string,
 	d.listCalls++
 	d.lastListOrg = orgID
 	d.lastAuthSubject = opts.AuthSubject
+	d.lastOutcome = opts.Outcome
+	d.lastReason = opts.OutcomeReason
+	d.lastAnnotation = opts.Annotation
 	d.lastLimit = opts.Limit
 	d.lastCursorVal = opts.CursorVal
 	d.lastCursorID = opts.CursorID
@@ -456,6 +462,67 @@ var _ = Describe("harness natural-key filter on GET /v1/sessions", func() {
 		Expect(drv.lastAuthSubject).To(BeEmpty())
 	})
 
+	It("threads the outcome filters through and decodes the outcome reasons", func() {
+		// Given a stored record that ended on a rate limit
+		errored := record
+		errored.Outcome = "errored"
+		errored.OutcomeReasons = json.RawMessage(`[{"code":"terminal_error","category":"rate_limit","trace_id":"trc_1"}]`)
+		drv := &sessionsStubDriver{
+			Driver:      inmemory.NewDriver(),
+			listRecords: []storage.SessionRecord{errored},
+		}
+		server := newSessionsServer(drv)
+
+		// When listing with both outcome filters
+		body, _, status := getSessionList(server, "/v1/sessions?outcome=errored&outcome_reason=rate_limit", "")
+
+		// Then both reach storage and the item carries the outcome back
+		Expect(status).To(Equal(fiber.StatusOK))
+		Expect(drv.lastOutcome).To(Equal("errored"))
+		Expect(drv.lastReason).To(Equal("rate_limit"))
+		Expect(body.Items).To(HaveLen(1))
+		Expect(body.Items[0].Rollup.Outcome).To(Equal("errored"))
+		Expect(body.Items[0].Rollup.OutcomeReasons).To(Equal([]SessionOutcomeReason{
+			{Code: "terminal_error", Category: "rate_limit", TraceID: "trc_1"},
+		}))
+	})
+
+	It("rejects an unknown outcome or outcome_reason before reaching storage", func() {
+		drv := &sessionsStubDriver{Driver: inmemory.NewDriver()}
+		server := newSessionsServer(drv)
+
+		_, _, status := getSessionList(server, "/v1/sessions?outcome=exploded", "")
+		Expect(status).To(Equal(fiber.StatusBadRequest))
+		_, _, status = getSessionList(server, "/v1/sessions?outcome_reason=bored", "")
+		Expect(status).To(Equal(fiber.StatusBadRequest))
+		Expect(drv.listCalls).To(Equal(0))
+	})
+
+	It("threads the annotation filters through as one match", func() {
+		drv := &sessionsStubDriver{Driver: inmemory.NewDriver()}
+		server := newSessionsServer(drv)
+
+		_, _, status := getSessionList(server,
+			"/v1/sessions?annotation=correctness&annotation_label=pass&annotation_min_score=0.5&annotation_max_score=1", "")
+
+		Expect(status).To(Equal(fiber.StatusOK))
+		Expect(drv.lastAnnotation.Name).To(Equal("correctness"))
+		Expect(drv.lastAnnotation.Label).To(Equal("pass"))
+		Expect(drv.lastAnnotation.MinScore).To(HaveValue(Equal(0.5)))
+		Expect(drv.lastAnnotation.MaxScore).To(HaveValue(Equal(1.0)))
+	})
+
+	It("rejects a malformed or inverted annotation score range before reaching storage", func() {
+		drv := &sessionsStubDriver{Driver: inmemory.NewDriver()}
+		server := newSessionsServer(drv)
+
+		_, _, status := getSessionList(server, "/v1/sessions?annotation_min_score=high", "")
+		Expect(status).To(Equal(fiber.StatusBadRequest))
+		_, _, status = getSessionList(server, "/v1/sessions?annotation_min_score=0.9&annotation_max_score=0.1", "")
+		Expect(status).To(Equal(fiber.StatusBadRequest))
+		Expect(drv.listCalls).To(Equal(0))
+	})
+
 	It("keeps the unfiltered paged list behavior when no harness params are supplied", func() {
 		older := record
 		older.ID = "bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb"
@@ -835,6 +902,20 @@ var _ = Describe("sessionItemFromStorage liveness", func() {
 	})
 })
 
+var _ = Describe("sessionItemFromStorage outcome", func() {
+	now := time.Date(2026, 7, 24, 12, 0, 0, 0, time.UTC)
+
+	It("ages a stored awaiting_user into abandoned once the session is idle", func() {
+		outcomeAt := func(lastSeen time.Time) string {
+			return sessionItemFromStorage(storage.SessionRecord{
+				ID: "s1", LastSeenAt: lastSeen, Outcome: "awaiting_user",
+			}, now).Rollup.Outcome
+		}
+		Expect(outcomeAt(now.Add(-time.Minute))).To(Equal("awaiting_user"))
+		Expect(outcomeAt(now.Add(-time.Hour))).To(Equal("abandoned"))
+	})
+})
+
 // doJSON issues a request (optionally with the org header) and returns the
 // decoded body map (on 2xx) plus the status code.
 func doJSON(server *Server, method, path, body, org string) (map[string]any, int) {
@@ -1,23 +1,58 @@
 package api
 
-// singleTenantOrgID is the org every row in this deployment belongs to.
+import "github.com/gofiber/fiber/v2"
+
+// singleTenantOrgID is the org every row in a single-tenant deployment
+// belongs to.
 //
-// A tapes deployment serves exactly one tenant: it runs in that tenant's
-// namespace against that tenant's own database, and the gateway in front of
-// it admits only tokens whose org_id claim matches that tenant's WorkOS
-// organization. There is nothing for a read to disambiguate, so reads scope
-// to the same sentinel the write path already stores.
+// By default a tapes deployment serves exactly one tenant: it runs in that
+// tenant's namespace against that tenant's own database, and the gateway in
+// front of it admits only tokens whose org_id claim matches that tenant's
+// WorkOS organization. There is nothing for a read to disambiguate, so reads
+// scope to the same sentinel the write path already stores.
 //
 // This replaces the X-Tapes-Org-Id request header, which let a caller name
 // its own tenant on every read with nothing verifying the claim — the header
 // was trusted the same way the ingest envelope's org_id is, which is to say
 // not at all. Anyone who could reach the read API could read any org by
-// asserting it. Tenancy is now settled before a request reaches this process,
-// by something positioned to check it.
-//
-// The constant is threaded through the handlers rather than pushed down into
-// the storage layer on purpose. The org_id columns and their composite
-// primary keys still exist; keeping the value at the handler boundary leaves
-// exactly one seam to delete when they go, instead of scattering the
-// assumption across the query layer.
+// asserting it. Tenancy is settled by something positioned to check it:
+// the deployment, or in multi-tenant mode the caller's verified credential.
 const singleTenantOrgID = "00000000-0000-0000-0000-000000000000"
+
+// orgHeader carries the request's org to a cassette. It is set by this
+// server, never passed through from the client, so a cassette may trust it
+// to scope its own reads.
+const orgHeader = "X-Tapes-Org-Id"
+
+// orgID returns the org this request reads and writes.
+//
+// In multi-tenant mode that is the org of the caller's credential, which
+// requireScope has already checked is present. A route that no guard
+// protects has no caller, and gets the sentinel, which holds no tenant's
+// rows in a multi-tenant deployment.
+//
+// The org is resolved here, at the handler boundary, rather than pushed down
+// into the storage layer: every reader already takes it as an argument, so
+// there is exactly one seam deciding it.
+func (s *Server) orgID(c *fiber.Ctx) string {
+	if !s.config.MultiTenant {
+		return singleTenantOrgID
+	}
+	who, _ := c.Locals(callerLocal).(*caller)
+	if who == nil {
+		return singleTenantOrgID
+	}
+
+	return who.orgID()
+}
+
+// withOrg wraps a handler that forwards to cassettes, directly or through an
+// MCP tool, so the request carries its org in orgHeader. Any value the client
+// sent is replaced, never passed through.
+func (s *Server) withOrg(handler fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Request().Header.Set(orgHeader, s.orgID(c))
+
+		return handler(c)
+	}
+}
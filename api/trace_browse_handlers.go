@@ -66,10 +66,28 @@ func traceItemFromTurn(turn storage.SpanTurnRecord, spanCount int) TraceItem {
 			InputTokens:  turn.MainInputTokens,
 			OutputTokens: turn.MainOutputTokens,
 		},
-		Synthetic: turn.Synthetic,
+		Context: TraceContext{
+			PeakTokens:      turn.PeakContextTokens,
+			PeakOccupancy:   turn.PeakContextOccupancy,
+			GrowthTokens:    turn.ContextGrowthTokens,
+			ReclaimedTokens: turn.ReclaimedTokens,
+			CacheHitRatio:   cacheHitRatio(turn.CacheReadTokens, turn.TotalInputTokens),
+		},
+		Synthetic:     turn.Synthetic,
+		ParentTraceID: turn.ParentTraceID,
+		Abandoned:     turn.Abandoned,
 	}
 }
 
+// cacheHitRatio is the share of prompt tokens served from cache, 0 when
+// there were none.
+func cacheHitRatio(cacheRead, input int64) float64 {
+	if input <= 0 {
+		return 0
+	}
+	return float64(cacheRead) / float64(input)
+}
+
 // handleListTraceSummaries handles GET /v1/traces?session_id=.
 func (s *Server) handleListTraceSummaries(c *fiber.Ctx) error {
 	sessions, ok := s.driver.(sessionsReader)
@@ -87,13 +105,13 @@ func (s *Server) handleListTraceSummaries(c *fiber.Ctx) error {
 	if _, err := uuid.Parse(sessionID); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "session_id must be a valid UUID"})
 	}
-	orgID := singleTenantOrgID
+	orgID := s.orgID(c)
 	sess, err := sessions.GetSessionRecord(c.Context(), orgID, sessionID)
 	if err != nil {
 		s.logger.Error("get session for trace summaries", "session_id", sessionID, "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to load session"})
 	}
-	if sess == nil {
+	if sess == nil || !sessionVisible(c, sess) {
 		return c.Status(fiber.StatusNotFound).JSON(llm.ErrorResponse{Error: "session not found"})
 	}
 	rows, err := reader.ListTraceSummaries(c.Context(), sessionID)
@@ -122,7 +140,7 @@ func (s *Server) handleGetTrace(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: "span traces not supported by this backend"})
 	}
 	traceID := c.Params("trace_id")
-	turn, spans, links, err := reader.GetTraceDetail(c.Context(), singleTenantOrgID, traceID)
+	turn, spans, links, err := reader.GetTraceDetail(c.Context(), s.orgID(c), traceID)
 	if err != nil {
 		s.logger.Error("get trace", "trace_id", traceID, "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to get trace"})
@@ -130,6 +148,14 @@ func (s *Server) handleGetTrace(c *fiber.Ctx) error {
 	if turn == nil {
 		return c.Status(fiber.StatusNotFound).JSON(llm.ErrorResponse{Error: "trace not found"})
 	}
+	visible, err := s.sessionIDVisible(c, turn.SessionID)
+	if err != nil {
+		s.logger.Error("get trace session", "trace_id", traceID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to get trace"})
+	}
+	if !visible {
+		return c.Status(fiber.StatusNotFound).JSON(llm.ErrorResponse{Error: "trace not found"})
+	}
 	return c.JSON(BuildTraceDetail(*turn, spans, links, payloadModeFromQuery(c.Query("payload"))))
 }
 
@@ -159,7 +185,7 @@ func (s *Server) handleGetSpan(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: "span traces not supported by this backend"})
 	}
 	traceID, spanID := c.Params("trace_id"), c.Params("span_id")
-	rec, err := reader.GetSpanRecord(c.Context(), singleTenantOrgID, traceID, spanID)
+	rec, err := reader.GetSpanRecord(c.Context(), s.orgID(c), traceID, spanID)
 	if err != nil {
 		s.logger.Error("get span", "trace_id", traceID, "span_id", spanID, "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to get span"})
@@ -167,6 +193,24 @@ func (s *Server) handleGetSpan(c *fiber.Ctx) error {
 	if rec == nil {
 		return c.Status(fiber.StatusNotFound).JSON(llm.ErrorResponse{Error: "span not found"})
 	}
+	if _, restricted := ownSessionsSubject(c); restricted {
+		turn, _, _, err := reader.GetTraceDetail(c.Context(), s.orgID(c), traceID)
+		if err != nil {
+			s.logger.Error("get span trace", "trace_id", traceID, "error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to get span"})
+		}
+		visible := turn != nil
+		if visible {
+			visible, err = s.sessionIDVisible(c, turn.SessionID)
+			if err != nil {
+				s.logger.Error("get span session", "trace_id", traceID, "error", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to get span"})
+			}
+		}
+		if !visible {
+			return c.Status(fiber.StatusNotFound).JSON(llm.ErrorResponse{Error: "span not found"})
+		}
+	}
 	item := spanItemFromRecord(*rec, PayloadFull)
 	return c.JSON(item)
 }
@@ -185,13 +229,13 @@ func (s *Server) handleListSessionRawTurns(c *fiber.Ctx) error {
 	if _, err := uuid.Parse(id); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "id must be a valid UUID"})
 	}
-	orgID := singleTenantOrgID
+	orgID := s.orgID(c)
 	sess, err := sessions.GetSessionRecord(c.Context(), orgID, id)
 	if err != nil {
 		s.logger.Error("get session for raw turns", "id", id, "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to load session"})
 	}
-	if sess == nil {
+	if sess == nil || !sessionVisible(c, sess) {
 		return c.Status(fiber.StatusNotFound).JSON(llm.ErrorResponse{Error: "session not found"})
 	}
 	rows, err := reader.ListRawTurnHeaders(c.Context(), orgID, sess.HarnessID, sess.HarnessSessionID)
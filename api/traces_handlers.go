@@ -14,8 +14,8 @@ import (
 // Trace read model — the span projection rendered for the console.
 // GET /v1/sessions/{id}/traces is the session-detail source: every
 // user-visible turn as a trace, spans nested by parent_span_id, and
-// dataflow links (cross-trace ones, e.g. compaction seams, at the
-// response top level).
+// dataflow links (cross-trace ones, e.g. compaction seams and forks, at
+// the response top level).
 
 type spanModelReader interface {
 	storage.SpanModelReader
@@ -82,11 +82,23 @@ type TraceItem struct {
 	// (permission checks, title-gen, web summaries) on the turn.
 	Usage     TraceUsage `json:"usage"`
 	MainUsage MainUsage  `json:"main_usage"`
+	// Context is how full the conversation's context window got during
+	// the turn, folded over its main-thread calls.
+	Context TraceContext `json:"context"`
 	// Synthetic is a typed deriver signal ("post-compaction" for a
 	// compaction continuation, "shadow-opener" for a shadow-only opener),
 	// promoted out of the old metadata grab-bag. Absent for genuine
 	// prompt-opened turns.
 	Synthetic string `json:"synthetic,omitempty"`
+	// ParentTraceID and Abandoned carry the session's branch structure:
+	// each trace names the trace it follows on its branch (absent for the
+	// session's first), so traces sharing a parent are the branches of a
+	// fork — an edited earlier message or a rewind, joined to its branch
+	// point by a fork link. Abandoned marks traces left off the live
+	// branch. Rendering traces as a tree keyed on parent_trace_id shows
+	// the session the way its merkle chain branched.
+	ParentTraceID string `json:"parent_trace_id,omitempty"`
+	Abandoned     bool   `json:"abandoned,omitempty"`
 }
 
 // TraceUsage is a trace's total token/cost rollup. Fields are pinned
@@ -99,6 +111,21 @@ type TraceUsage struct {
 	CostUSD             float64 `json:"cost_usd"`
 }
 
+// TraceContext is a trace's context-window fold. Tokens count the whole
+// prompt a call sent, cached part included; occupancy is that over the
+// model's context window (0 for a model of unknown window). GrowthTokens
+// is the closing call's context minus the parent trace's, negative across
+// a compaction; ReclaimedTokens is what the compaction that opened a
+// post-compaction trace freed. CacheHitRatio is the share of the trace's
+// prompt tokens, every llm call included, served from cache. Pinned.
+type TraceContext struct {
+	PeakTokens      int64   `json:"peak_tokens"`
+	PeakOccupancy   float64 `json:"peak_occupancy"`
+	GrowthTokens    int64   `json:"growth_tokens"`
+	ReclaimedTokens int64   `json:"reclaimed_tokens"`
+	CacheHitRatio   float64 `json:"cache_hit_ratio"`
+}
+
 // MainUsage is the task token slice of a trace: the main agent and its
 // subagents (call_kind=main across every thread), no cache split or cost
 // (those live on the total Usage). Deliberately not spine-only — a
@@ -137,6 +164,12 @@ type SpanItem struct {
 	// null on the wire; the oas tag states that, because a json.RawMessage
 	// carries no shape a reflector could recover.
 	Verdict json.RawMessage `json:"verdict" oas:"type=object,nullable"`
+	// Tool is a tool span's normalized identity, {name, server, command}:
+	// the tool without MCP namespacing, its MCP server, and the program a
+	// shell call ran. RetryOf names the errored tool span this one repeats
+	// with the same input. Both are absent off tool spans.
+	Tool    json.RawMessage `json:"tool,omitempty" oas:"type=object"`
+	RetryOf string          `json:"retry_of,omitempty"`
 	// Input/Output are content-block arrays (llm.ContentBlock), uniform for
 	// every kind (tool spans included — no unwrapping). Pinned to [] when
 	// empty.
@@ -151,8 +184,8 @@ type SpanItem struct {
 }
 
 // SpanLinkItem is a dataflow edge. kind is a typed top-level field
-// (rejoin / verdict / compaction-seam / emits / feeds); from/to trace ids
-// differ on cross-trace causality.
+// (rejoin / verdict / compaction-seam / fork / emits / feeds); from/to
+// trace ids differ on cross-trace causality.
 type SpanLinkItem struct {
 	Kind        string `json:"kind"`
 	FromTraceID string `json:"from_trace_id"`
@@ -224,13 +257,13 @@ func (s *Server) handleGetSessionTraces(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: "id must be a valid UUID"})
 	}
 
-	orgID := singleTenantOrgID
+	orgID := s.orgID(c)
 	sess, err := sessions.GetSessionRecord(c.Context(), orgID, id)
 	if err != nil {
 		s.logger.Error("get session for traces", "id", id, "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to load session"})
 	}
-	if sess == nil {
+	if sess == nil || !sessionVisible(c, sess) {
 		return c.Status(fiber.StatusNotFound).JSON(llm.ErrorResponse{Error: "session not found"})
 	}
 
@@ -312,6 +345,8 @@ func spanItemFromRecord(sp storage.SpanRecord, mode PayloadMode) SpanItem {
 		ThreadID:     sp.ThreadID,
 		RawTurnID:    sp.RawTurnID,
 		Verdict:      sp.Verdict, // already json.RawMessage; nil → null on the wire
+		Tool:         sp.Tool,
+		RetryOf:      sp.RetryOf,
 		Input:        contentArray(sp.Input, mode),
 		Output:       contentArray(sp.Output, mode),
 		Usage:        emptyObjectIfNil(sp.Usage),
@@ -29,6 +29,11 @@ import (
 //     rather than on each tool span's own timestamp (PCC-936).
 //   - CompletedCount counts distinct sessions whose denormalized
 //     derived_status is 'completed' (chain-aware, PCC-515).
+//   - Latency is per-model percentiles over the llm calls whose capture
+//     adapter timed them, busiest model first. Calls without a latency
+//     profile (transcript imports, pre-upgrade captures) are not counted.
+//   - Annotations folds the annotations on the window's sessions per
+//     name, most annotated first; empty when the driver stores none.
 type StatsResponse struct {
 	SessionCount    int     `json:"session_count"`
 	TurnCount       int     `json:"turn_count"`
@@ -38,6 +43,46 @@ type StatsResponse struct {
 	OutputTokens    int64   `json:"output_tokens"`
 	TotalDurationMs int64   `json:"total_duration_ms"`
 	ToolCalls       int     `json:"tool_calls"`
+
+	Latency     []ModelLatencyStats `json:"latency"`
+	Annotations []AnnotationStats   `json:"annotations"`
+}
+
+// AnnotationStats is one annotation name's figures in GET /v1/stats:
+// how many annotations carry it, the spread of the scored ones, and how
+// often each label was given. The score figures are zero when none is
+// scored.
+type AnnotationStats struct {
+	Name      string                 `json:"name"`
+	Count     int                    `json:"count"`
+	Scored    int                    `json:"scored"`
+	MeanScore float64                `json:"mean_score"`
+	MinScore  float64                `json:"min_score"`
+	MaxScore  float64                `json:"max_score"`
+	Labels    []AnnotationLabelCount `json:"labels"`
+}
+
+// AnnotationLabelCount is how many of a name's annotations carry one
+// label.
+type AnnotationLabelCount struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// ModelLatencyStats is one model's latency percentiles in GET /v1/stats,
+// in milliseconds. TTFB is the wait for the response to start; TTFT the wait
+// for its first generated content. Zero means no call measured the figure
+// (a non-streaming call has no token timings).
+type ModelLatencyStats struct {
+	Model                 string  `json:"model"`
+	Calls                 int     `json:"calls"`
+	TTFBP50Ms             float64 `json:"ttfb_p50_ms"`
+	TTFBP95Ms             float64 `json:"ttfb_p95_ms"`
+	TTFTP50Ms             float64 `json:"ttft_p50_ms"`
+	TTFTP95Ms             float64 `json:"ttft_p95_ms"`
+	InterTokenP50Ms       float64 `json:"inter_token_p50_ms"`
+	InterTokenP95Ms       float64 `json:"inter_token_p95_ms"`
+	OutputTokensPerSecP50 float64 `json:"output_tokens_per_sec_p50"`
 }
 
 // handleStats handles GET /v1/stats.
@@ -61,12 +106,28 @@ func (s *Server) handleStats(c *fiber.Ctx) error {
 	// meaning, as the /v1/sessions filter — a personal surface that scopes
 	// its rows and its totals passes the one value to both.
 	//
-	// Absent, it is empty and every total stays org-wide.
-	stats, err := reader.AggregateSpanStats(c.Context(), singleTenantOrgID, since, until, c.Query("auth_subject"))
+	// Absent, it is empty and every total stays org-wide. A caller restricted
+	// to its own sessions always gets its own totals.
+	subject := c.Query("auth_subject")
+	if own, restricted := ownSessionsSubject(c); restricted {
+		subject = own
+	}
+	stats, err := reader.AggregateSpanStats(c.Context(), s.orgID(c), since, until, subject)
 	if err != nil {
 		s.logger.Error("aggregate span stats", "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to compute stats"})
 	}
+	// Annotations are optional: a driver without the table reports none
+	// rather than failing the whole strip.
+	annotations := []AnnotationStats{}
+	if store, ok := s.driver.(storage.AnnotationStore); ok {
+		folded, err := store.AggregateAnnotations(c.Context(), s.orgID(c), since, until, subject)
+		if err != nil {
+			s.logger.Error("aggregate annotations", "error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to compute stats"})
+		}
+		annotations = annotationStats(folded)
+	}
 	return c.JSON(StatsResponse{
 		SessionCount:    stats.SessionCount,
 		TurnCount:       stats.TurnCount,
@@ -76,9 +137,120 @@ func (s *Server) handleStats(c *fiber.Ctx) error {
 		OutputTokens:    stats.OutputTokens,
 		TotalDurationMs: stats.TotalDurationNS / int64(time.Millisecond),
 		ToolCalls:       stats.ToolCalls,
+		Latency:         modelLatencyStats(stats.ModelLatency),
+		Annotations:     annotations,
 	})
 }
 
+// annotationStats converts the stored per-name folds. Labels is never
+// nil, so it serializes as [].
+func annotationStats(folded []storage.AnnotationStats) []AnnotationStats {
+	out := make([]AnnotationStats, 0, len(folded))
+	for _, f := range folded {
+		labels := make([]AnnotationLabelCount, 0, len(f.Labels))
+		for _, l := range f.Labels {
+			labels = append(labels, AnnotationLabelCount{Label: l.Label, Count: l.Count})
+		}
+		out = append(out, AnnotationStats{
+			Name:      f.Name,
+			Count:     f.Count,
+			Scored:    f.Scored,
+			MeanScore: f.Mean,
+			MinScore:  f.Min,
+			MaxScore:  f.Max,
+			Labels:    labels,
+		})
+	}
+	return out
+}
+
+// modelLatencyStats converts the stored nanosecond percentiles to the
+// response's milliseconds. The list is never nil, so it serializes as [].
+func modelLatencyStats(models []storage.ModelLatency) []ModelLatencyStats {
+	ms := func(ns int64) float64 { return float64(ns) / float64(time.Millisecond) }
+	out := make([]ModelLatencyStats, 0, len(models))
+	for _, m := range models {
+		out = append(out, ModelLatencyStats{
+			Model:                 m.Model,
+			Calls:                 m.Calls,
+			TTFBP50Ms:             ms(m.TTFBP50NS),
+			TTFBP95Ms:             ms(m.TTFBP95NS),
+			TTFTP50Ms:             ms(m.TTFTP50NS),
+			TTFTP95Ms:             ms(m.TTFTP95NS),
+			InterTokenP50Ms:       ms(m.InterTokenP50NS),
+			InterTokenP95Ms:       ms(m.InterTokenP95NS),
+			OutputTokensPerSecP50: m.OutputTokensPerSecP50,
+		})
+	}
+	return out
+}
+
+// ToolsResponse is the response for GET /v1/tools: execution figures per
+// tool identity over the window's tool spans, busiest first. Items is
+// never null.
+type ToolsResponse struct {
+	Items []ToolStatsItem `json:"items"`
+}
+
+// ToolStatsItem is one tool identity's figures in GET /v1/tools. Tool is
+// the tool name without MCP namespacing and Server the MCP server that
+// provides it; Command splits shell calls by the program they ran.
+// Latency is execution time, tool_use to tool_result, in milliseconds,
+// over the calls whose result arrived. ErrorRate is Errors over Calls;
+// Retries counts calls that repeated an errored call's input.
+type ToolStatsItem struct {
+	Tool      string  `json:"tool"`
+	Server    string  `json:"server,omitempty"`
+	Command   string  `json:"command,omitempty"`
+	Calls     int     `json:"calls"`
+	Errors    int     `json:"errors"`
+	Retries   int     `json:"retries"`
+	ErrorRate float64 `json:"error_rate"`
+	P50Ms     float64 `json:"p50_ms"`
+	P95Ms     float64 `json:"p95_ms"`
+}
+
+// handleTools handles GET /v1/tools. It takes the same window and
+// auth_subject filter as /v1/stats.
+func (s *Server) handleTools(c *fiber.Ctx) error {
+	since, until, err := parseStatsWindow(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{Error: err.Error()})
+	}
+	reader, ok := s.driver.(storage.ToolStatsReader)
+	if !ok {
+		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{Error: "driver does not support tool analytics"})
+	}
+	subject := c.Query("auth_subject")
+	if own, restricted := ownSessionsSubject(c); restricted {
+		subject = own
+	}
+	tools, err := reader.AggregateToolStats(c.Context(), s.orgID(c), since, until, subject)
+	if err != nil {
+		s.logger.Error("aggregate tool stats", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: "failed to compute tool stats"})
+	}
+	ms := func(ns int64) float64 { return float64(ns) / float64(time.Millisecond) }
+	items := make([]ToolStatsItem, 0, len(tools))
+	for _, t := range tools {
+		item := ToolStatsItem{
+			Tool:    t.Tool,
+			Server:  t.Server,
+			Command: t.Command,
+			Calls:   t.Calls,
+			Errors:  t.Errors,
+			Retries: t.Retries,
+			P50Ms:   ms(t.DurationP50NS),
+			P95Ms:   ms(t.DurationP95NS),
+		}
+		if t.Calls > 0 {
+			item.ErrorRate = float64(t.Errors) / float64(t.Calls)
+		}
+		items = append(items, item)
+	}
+	return c.JSON(ToolsResponse{Items: items})
+}
+
 // parseStatsWindow reads the optional since/until time window from query
 // params. /v1/stats has no pagination — it is one aggregate row — so the time
 // bounds and the auth_subject filter are the whole of its input; the subject
@@ -43,6 +43,22 @@ func (d *statsStubDriver) AggregateSpanStats(_ context.Context, orgID string, si
 	return d.stats, d.statsErr
 }
 
+// toolsStubDriver adds the storage.ToolStatsReader capability to a real
+// driver with canned rows, recording the window it was asked for.
+type toolsStubDriver struct {
+	storage.Driver
+
+	tools       []storage.ToolStats
+	lastSince   *time.Time
+	lastSubject string
+}
+
+func (d *toolsStubDriver) AggregateToolStats(_ context.Context, _ string, since, _ *time.Time, authSubject string) ([]storage.ToolStats, error) {
+	d.lastSince = since
+	d.lastSubject = authSubject
+	return d.tools, nil
+}
+
 var _ = Describe("v1 session handlers", func() {
 	Describe("GET /v1/stats", func() {
 		newStatsServer := func(driver storage.Driver) *Server {
@@ -178,6 +194,60 @@ var _ = Describe("v1 session handlers", func() {
 	})
 })
 
+var _ = Describe("GET /v1/tools", func() {
+	get := func(driver storage.Driver, path string) *http.Response {
+		server, err := NewServer(Config{ListenAddr: ":0"}, driver, tapeslogger.NewNoop())
+		Expect(err).NotTo(HaveOccurred())
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, path, nil)
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := server.app.Test(req)
+		Expect(err).NotTo(HaveOccurred())
+		return resp
+	}
+
+	It("serves per-tool figures in milliseconds with an error rate", func() {
+		drv := &toolsStubDriver{
+			Driver: inmemory.NewDriver(),
+			tools: []storage.ToolStats{
+				{Tool: "Bash", Command: "go", Calls: 8, Errors: 2, Retries: 1,
+					DurationP50NS: int64(1500 * time.Millisecond), DurationP95NS: int64(9 * time.Second)},
+				{Tool: "search", Server: "github", Calls: 3},
+			},
+		}
+		resp := get(drv, "/v1/tools?since=2026-04-01T00:00:00Z&auth_subject=user_a")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(fiber.StatusOK))
+		var body ToolsResponse
+		Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+
+		Expect(body.Items).To(HaveLen(2))
+		bash := body.Items[0]
+		Expect(bash.Tool).To(Equal("Bash"))
+		Expect(bash.Command).To(Equal("go"))
+		Expect(bash.ErrorRate).To(BeNumerically("~", 0.25))
+		Expect(bash.Retries).To(Equal(1))
+		Expect(bash.P50Ms).To(BeNumerically("~", 1500))
+		Expect(bash.P95Ms).To(BeNumerically("~", 9000))
+		Expect(body.Items[1].Server).To(Equal("github"))
+		Expect(body.Items[1].ErrorRate).To(BeZero())
+
+		Expect(drv.lastSince).NotTo(BeNil())
+		Expect(drv.lastSubject).To(Equal("user_a"))
+	})
+
+	It("returns 501 when the driver has no tool analytics", func() {
+		resp := get(inmemory.NewDriver(), "/v1/tools")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(fiber.StatusNotImplemented))
+	})
+
+	It("rejects a malformed window", func() {
+		resp := get(&toolsStubDriver{Driver: inmemory.NewDriver()}, "/v1/tools?since=yesterday")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(fiber.StatusBadRequest))
+	})
+})
+
 func decodeStats(server *Server, path string) StatsResponse {
 	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, path, nil)
 	Expect(err).NotTo(HaveOccurred())
@@ -1,18 +1,36 @@
 package api
 
 import (
-	_ "embed"
+	"embed"
+	"path"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-// tapesWebUIHTML is intentionally a tiny, Prometheus-style UI served directly
-// from the API binary. It browses the session/trace read surface with plain
-// DOM rendering, so there is no frontend build and no external scripts.
+// webUIAssets is the embedded session browser, a Prometheus-style UI
+// served directly from the API binary: index.html plus the stylesheet and
+// script it loads from /ui/. It renders the session/trace read surface
+// with plain DOM code, so there is no frontend build and no external
+// scripts.
 //
-//go:embed web_ui.html
-var tapesWebUIHTML string
+//go:embed webui
+var webUIAssets embed.FS
 
 func (s *Server) handleWebUI(c *fiber.Ctx) error {
-	return c.Type("html").SendString(tapesWebUIHTML)
+	return s.sendWebUIAsset(c, "index.html")
+}
+
+// handleWebUIAsset serves GET /ui/:asset. The param never carries a
+// slash, so only the top level of webui/ is reachable.
+func (s *Server) handleWebUIAsset(c *fiber.Ctx) error {
+	return s.sendWebUIAsset(c, c.Params("asset"))
+}
+
+func (s *Server) sendWebUIAsset(c *fiber.Ctx, name string) error {
+	body, err := webUIAssets.ReadFile(path.Join("webui", name))
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+	return c.Type(strings.TrimPrefix(path.Ext(name), ".")).Send(body)
 }
@@ -33,23 +33,48 @@ var _ = Describe("minimal web UI", func() {
 		Expect(resp.StatusCode).To(Equal(fiber.StatusNotFound))
 	})
 
-	It("serves the session browser UI from / without a frontend build", func() {
-		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+	get := func(path string) (*http.Response, string) {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, path, nil)
 		Expect(err).NotTo(HaveOccurred())
 		resp, err := server.app.Test(req)
 		Expect(err).NotTo(HaveOccurred())
+		raw, err := io.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		return resp, string(raw)
+	}
+
+	It("serves the session browser UI from / without a frontend build", func() {
+		resp, page := get("/")
 		Expect(resp.StatusCode).To(Equal(fiber.StatusOK))
 		Expect(resp.Header.Get("Content-Type")).To(ContainSubstring("text/html"))
+		// Its stylesheet and script are embedded assets served beside it;
+		// nothing loads from another origin.
+		Expect(page).To(ContainSubstring(`<script src="/ui/app.js">`))
+		Expect(page).To(ContainSubstring(`href="/ui/app.css"`))
+		Expect(page).NotTo(MatchRegexp(`(src|href)="(https?:)?//`))
 
-		raw, err := io.ReadAll(resp.Body)
-		Expect(err).NotTo(HaveOccurred())
-		// The UI reads the session/trace surface only; the node-view
-		// /v1/stems endpoints (and the D3 graph pane that consumed
-		// them) are gone, as are all external script tags.
-		Expect(string(raw)).To(ContainSubstring("/v1/sessions?limit="))
-		Expect(string(raw)).To(ContainSubstring("/traces?payload=preview"))
-		Expect(string(raw)).NotTo(ContainSubstring("/v1/stems"))
-		Expect(string(raw)).NotTo(ContainSubstring("<script src="))
+		resp, script := get("/ui/app.js")
+		Expect(resp.StatusCode).To(Equal(fiber.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(ContainSubstring("javascript"))
+		// The UI reads the session/trace surface and /v1/stats only; the
+		// node-view /v1/stems endpoints (and the D3 graph pane that
+		// consumed them) are gone.
+		Expect(script).To(ContainSubstring("/v1/sessions?"))
+		Expect(script).To(ContainSubstring("/traces?payload=preview"))
+		Expect(script).To(ContainSubstring("/spans/"))
+		Expect(script).To(ContainSubstring("/v1/stats?"))
+		Expect(script).NotTo(ContainSubstring("/v1/stems"))
+
+		resp, _ = get("/ui/app.css")
+		Expect(resp.StatusCode).To(Equal(fiber.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(ContainSubstring("text/css"))
+	})
+
+	It("serves only the embedded assets under /ui/", func() {
+		for _, path := range []string{"/ui/missing.js", "/ui/..%2fweb_ui.go", "/ui/"} {
+			resp, _ := get(path)
+			Expect(resp.StatusCode).To(Equal(fiber.StatusNotFound), path)
+		}
 	})
 
 	It("does not catch all unknown routes", func() {
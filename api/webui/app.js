@@ -0,0 +1,931 @@
+// The tapes session browser. Plain DOM code over the read API, no build
+// step: index.html loads this file and app.css from /ui/, all three
+// embedded in the API binary.
+//
+// Routes live in the hash so a reload or a pasted link lands on the same
+// place:
+//
+//   #/sessions                              the session list
+//   #/sessions/<id>[/<trace>[/<span>]]      a session, a turn, a span
+//   #/stats                                 the stats dashboard
+(function () {
+  'use strict';
+
+  var UUID = /^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$/i;
+
+  var state = {
+    cursor: '',
+    sessions: [],
+    listLoaded: false,
+    doc: null,        // the open session's GET /v1/sessions/{id}/traces response
+    traceID: '',
+    spanID: '',
+    tab: 'turns',
+    inspectorTab: 'payload',
+    fullSpans: {}     // trace_id/span_id -> full-payload span
+  };
+
+  // ---- helpers -----------------------------------------------------------
+
+  function byID(id) { return document.getElementById(id); }
+
+  // h builds an element. Text always goes in as text nodes, so nothing the
+  // API returns is ever parsed as HTML.
+  function h(tag, props) {
+    var node = document.createElement(tag);
+    Object.keys(props || {}).forEach(function (key) {
+      var value = props[key];
+      if (value == null || value === false) return;
+      if (key === 'class') node.className = value;
+      else if (key === 'text') node.textContent = value;
+      else if (key.slice(0, 2) === 'on') node.addEventListener(key.slice(2), value);
+      else node.setAttribute(key, value === true ? '' : value);
+    });
+    for (var i = 2; i < arguments.length; i++) append(node, arguments[i]);
+    return node;
+  }
+  function append(node, child) {
+    if (child == null || child === false) return;
+    if (Array.isArray(child)) { child.forEach(function (c) { append(node, c); }); return; }
+    node.appendChild(typeof child === 'object' ? child : document.createTextNode(String(child)));
+  }
+  function clear(node) { while (node.firstChild) node.removeChild(node.firstChild); return node; }
+
+  function shortID(id) { return id ? String(id).slice(0, 8) : ''; }
+  function truncate(s, n) {
+    s = String(s || '').replace(/\s+/g, ' ').trim();
+    return s.length > n ? s.slice(0, n - 1) + '…' : s;
+  }
+  function tokens(n) {
+    n = Number(n || 0);
+    if (n >= 1e6) return (n / 1e6).toFixed(1) + 'M';
+    if (n >= 1e4) return (n / 1e3).toFixed(1) + 'k';
+    return String(n);
+  }
+  function cost(usd) {
+    usd = Number(usd || 0);
+    return '$' + (usd > 0 && usd < 0.01 ? usd.toFixed(4) : usd.toFixed(2));
+  }
+  function duration(ns) {
+    var ms = Number(ns || 0) / 1e6;
+    if (ms <= 0) return '0s';
+    if (ms < 1000) return Math.round(ms) + 'ms';
+    var s = ms / 1000;
+    if (s < 60) return s.toFixed(1) + 's';
+    var m = Math.floor(s / 60);
+    if (m < 60) return m + 'm' + Math.round(s % 60) + 's';
+    return Math.floor(m / 60) + 'h' + (m % 60) + 'm';
+  }
+  function ago(iso) {
+    if (!iso) return '';
+    var s = (Date.now() - new Date(iso).getTime()) / 1000;
+    if (s < 60) return 'just now';
+    if (s < 3600) return Math.floor(s / 60) + 'm ago';
+    if (s < 86400) return Math.floor(s / 3600) + 'h ago';
+    return Math.floor(s / 86400) + 'd ago';
+  }
+  function when(iso) { return iso ? new Date(iso).toLocaleString() : ''; }
+  function pct(f) { return (Math.round(f * 100) / 100) + '%'; }
+  function parseJSON(raw, fallback) {
+    if (raw == null) return fallback;
+    if (typeof raw !== 'string') return raw;
+    try { return JSON.parse(raw); } catch (e) { return fallback; }
+  }
+
+  // The bearer token for a server started with --api-auth. It lives in
+  // sessionStorage: it lasts as long as the tab and never reaches disk.
+  var TOKEN_KEY = 'tapes.token';
+  var apiToken = '';
+  try { apiToken = sessionStorage.getItem(TOKEN_KEY) || ''; } catch (e) { /* storage disabled */ }
+  function setToken(value) {
+    apiToken = value;
+    try {
+      if (value) sessionStorage.setItem(TOKEN_KEY, value);
+      else sessionStorage.removeItem(TOKEN_KEY);
+    } catch (e) { /* storage disabled: the token lasts until reload */ }
+  }
+
+  async function fetchJSON(path, options) {
+    options = Object.assign({}, options);
+    if (apiToken) options.headers = Object.assign({ Authorization: 'Bearer ' + apiToken }, options.headers);
+    var res = await fetch(path, options);
+    var text = await res.text();
+    var data = text ? JSON.parse(text) : {};
+    if (!res.ok) {
+      var message = data.error || (res.status + ' ' + res.statusText);
+      if (res.status === 401) message = apiToken ? 'The API token was rejected: ' + message : 'This server requires an API token.';
+      var err = new Error(message);
+      err.status = res.status;
+      throw err;
+    }
+    return data;
+  }
+
+  function setStatus(message, isError) {
+    var el = byID('status');
+    el.className = isError ? 'error' : 'muted';
+    el.textContent = message;
+  }
+
+  function sessionTitle(item) {
+    var r = item.rollup || {};
+    return item.display_title || r.title || item.name || r.preview || shortID(item.id);
+  }
+
+  function outcomeBadge(r) {
+    var outcome = (r && (r.outcome && r.outcome !== 'unknown' ? r.outcome : r.status)) || '';
+    if (!outcome) return null;
+    var cls = outcome === 'completed' ? 'badge good'
+      : /errored|failed|refused|looped|interrupted|gave_up|abandoned/.test(outcome) ? 'badge bad' : 'badge';
+    return h('span', { class: cls, text: outcome });
+  }
+
+  // ---- routing -----------------------------------------------------------
+
+  function link(id, traceID, spanID) {
+    var parts = ['#', 'sessions'];
+    [id, traceID, spanID].forEach(function (p) { if (p) parts.push(encodeURIComponent(p)); });
+    return parts.join('/');
+  }
+  function go(id, traceID, spanID) { location.hash = link(id, traceID, spanID); }
+
+  async function route() {
+    var parts = location.hash.replace(/^#\/?/, '').split('/').filter(Boolean).map(decodeURIComponent);
+    var view = parts[0] === 'stats' ? 'stats' : 'sessions';
+    byID('view-sessions').hidden = view !== 'sessions';
+    byID('view-stats').hidden = view !== 'stats';
+    document.querySelectorAll('header nav a[data-view]').forEach(function (a) {
+      a.classList.toggle('active', a.getAttribute('data-view') === view);
+    });
+
+    if (view === 'stats') { loadStats(); return; }
+    if (!state.listLoaded) loadSessions(false);
+
+    var id = parts[1] || '';
+    if (!id) {
+      state.doc = null;
+      clear(byID('session')).appendChild(h('div', { class: 'empty', text: 'Select a session to browse its turns, spans and subagents.' }));
+      closeInspector();
+      return;
+    }
+    if (!state.doc || state.doc.session.id !== id) {
+      if (!(await loadSession(id))) return;
+    }
+    state.traceID = parts[2] || '';
+    state.spanID = parts[3] || '';
+    if (!state.traceID && state.doc.traces.length) state.traceID = state.doc.traces[0].trace.trace_id;
+    renderSessions();
+    renderSession();
+    renderInspector();
+  }
+
+  // ---- session list ------------------------------------------------------
+
+  function listPath(cursor) {
+    var q = new URLSearchParams();
+    q.set('limit', '50');
+    var since = byID('filter-since').value;
+    if (since) q.set('since', new Date(Date.now() - parseInt(since, 10) * 3600e3).toISOString());
+    if (byID('filter-outcome').value) q.set('outcome', byID('filter-outcome').value);
+    q.set('sort', byID('filter-sort').value);
+    q.set('direction', byID('filter-direction').value);
+    if (cursor) q.set('cursor', cursor);
+    return '/v1/sessions?' + q.toString();
+  }
+
+  async function loadSessions(more) {
+    state.listLoaded = true;
+    try {
+      setStatus('Loading sessions...', false);
+      var data = await fetchJSON(listPath(more ? state.cursor : ''));
+      state.sessions = (more ? state.sessions : []).concat(data.items || []);
+      state.cursor = data.next_cursor || '';
+      renderSessions();
+      setStatus(state.sessions.length + ' session' + (state.sessions.length === 1 ? '' : 's') + (state.cursor ? ' (more available)' : ''), false);
+    } catch (err) {
+      setStatus(err.message, true);
+    }
+  }
+
+  function renderSessions() {
+    var list = clear(byID('sessions'));
+    byID('more').hidden = !state.cursor;
+    if (!state.sessions.length) {
+      list.appendChild(h('div', { class: 'empty', text: 'No sessions match. Start the proxy, widen the filters, or click "Seed demo" for local sample data.' }));
+      return;
+    }
+    var current = state.doc ? state.doc.session.id : '';
+    state.sessions.forEach(function (item) {
+      var r = item.rollup || {};
+      var usage = r.usage || {};
+      list.appendChild(h('button', {
+        type: 'button',
+        class: 'session-card' + (item.id === current ? ' active' : ''),
+        onclick: function () { go(item.id); }
+      },
+      h('strong', {}, item.live ? h('span', { class: 'live', title: 'live' }) : null, sessionTitle(item)),
+      h('span', { class: 'row' },
+        outcomeBadge(r),
+        h('span', { class: 'muted', text: [item.harness_id, (r.turn_count || 0) + ' turns', cost(usage.cost_usd), r.model].filter(Boolean).join(' · ') })),
+      h('span', { class: 'muted' }, h('code', { text: shortID(item.id) }), ' · ', ago(item.last_seen_at))));
+    });
+  }
+
+  async function lookup(value) {
+    value = (value || '').trim();
+    if (!value) return;
+    if (UUID.test(value)) { go(value); return; }
+    try {
+      var data = await fetchJSON('/v1/sessions?harness_session_id=' + encodeURIComponent(value));
+      var items = data.items || [];
+      if (items.length === 1) { go(items[0].id); return; }
+      if (!items.length) { setStatus('No session has harness session id ' + value + '.', true); return; }
+      state.sessions = items;
+      state.cursor = '';
+      renderSessions();
+      setStatus(items.length + ' sessions share that harness session id.', false);
+    } catch (err) {
+      setStatus(err.message, true);
+    }
+  }
+
+  // ---- one session -------------------------------------------------------
+
+  async function loadSession(id) {
+    setStatus('Loading session ' + shortID(id) + '...', false);
+    try {
+      state.doc = await fetchJSON('/v1/sessions/' + encodeURIComponent(id) + '/traces?payload=preview');
+      state.doc.traces = state.doc.traces || [];
+      state.doc.links = state.doc.links || [];
+      state.fullSpans = {};
+      setStatus('Loaded ' + state.doc.traces.length + ' turns.', false);
+      return true;
+    } catch (err) {
+      state.doc = null;
+      setStatus(err.message, true);
+      clear(byID('session')).appendChild(h('div', { class: 'error', text: 'Could not load session ' + id + ': ' + err.message }));
+      closeInspector();
+      return false;
+    }
+  }
+
+  function currentTrace() {
+    if (!state.doc) return null;
+    return state.doc.traces.find(function (t) { return t.trace.trace_id === state.traceID; }) || null;
+  }
+  function traceIndex(traceID) {
+    return state.doc.traces.findIndex(function (t) { return t.trace.trace_id === traceID; }) + 1;
+  }
+  function findSpan(traceID, spanID) {
+    var t = state.doc.traces.find(function (d) { return d.trace.trace_id === traceID; });
+    return t ? (t.spans || []).find(function (s) { return s.span_id === spanID; }) || null : null;
+  }
+
+  function renderSession() {
+    var doc = state.doc;
+    var s = doc.session;
+    var r = s.rollup || {};
+    var usage = r.usage || {};
+    var ctx = r.context || {};
+    var root = clear(byID('session'));
+
+    var spanCount = 0;
+    doc.traces.forEach(function (t) { spanCount += (t.spans || []).length; });
+
+    root.appendChild(h('div', { class: 'detail-head' },
+      h('div', { class: 'row' }, h('h2', { text: sessionTitle(s) }), outcomeBadge(r), s.live ? h('span', { class: 'badge good', text: 'live' }) : null,
+        s.retention_tier ? h('span', { class: 'badge', text: 'content ' + s.retention_tier }) : null),
+      h('div', { class: 'meta' },
+        h('span', {}, 'session ', h('code', { text: s.id })),
+        h('span', {}, s.harness_id + ' ', h('code', { text: s.harness_session_id })),
+        s.harness_version ? h('span', { text: 'version ' + s.harness_version }) : null,
+        s.cwd ? h('span', {}, 'cwd ', h('code', { text: s.cwd })) : null,
+        h('span', { text: 'started ' + when(s.started_at) }),
+        h('span', { text: 'last seen ' + ago(s.last_seen_at) }),
+        s.parent_session_id ? h('a', { href: link(s.parent_session_id), text: 'parent session' }) : null),
+      (r.outcome_reasons || []).length ? h('div', { class: 'meta' }, r.outcome_reasons.map(function (reason) {
+        return h('span', { class: 'badge' + (reason.code === 'terminal_error' || reason.code === 'loop' ? ' bad' : '') },
+          [reason.code, reason.category, reason.count ? '×' + reason.count : '', truncate(reason.detail, 60)].filter(Boolean).join(' '));
+      })) : null,
+      h('div', { class: 'stats' },
+        stat(doc.traces.length, 'turns'),
+        stat(spanCount, 'spans'),
+        stat(threads().length, 'subagent threads'),
+        stat(cost(usage.cost_usd), 'cost'),
+        stat(tokens(usage.input_tokens), 'input tokens'),
+        stat(tokens(usage.output_tokens), 'output tokens'),
+        stat(tokens(ctx.peak_tokens) + (ctx.peak_occupancy ? ' · ' + Math.round(ctx.peak_occupancy * 100) + '%' : ''), 'peak context'),
+        stat(Math.round((ctx.cache_hit_ratio || 0) * 100) + '%', 'cache hits'),
+        stat(ctx.compactions || 0, 'compactions'))));
+
+    var tabs = [['turns', 'Turns'], ['subagents', 'Subagents'], ['links', 'Links & verdicts'], ['models', 'Models']];
+    root.appendChild(h('div', { class: 'tabs' }, tabs.map(function (tab) {
+      return h('button', {
+        type: 'button',
+        class: state.tab === tab[0] ? 'active' : '',
+        onclick: function () { state.tab = tab[0]; renderSession(); },
+        text: tab[1]
+      });
+    })));
+
+    var body = h('div', { class: 'tab-body' });
+    root.appendChild(body);
+    if (!doc.traces.length) {
+      body.appendChild(h('div', { class: 'empty', text: 'No turns derived for this session yet. POST /v1/admin/derive/run to (re)build the span projection from raw.' }));
+      return;
+    }
+    ({ turns: renderTurns, subagents: renderSubagents, links: renderLinks, models: renderModels })[state.tab](body);
+  }
+
+  function stat(value, label) {
+    return h('div', { class: 'stat' }, h('b', { text: value }), h('span', { text: label }));
+  }
+
+  // waterfallRow is one bar placed at [start, start+width) inside an
+  // extent, all in milliseconds; durationNS labels it.
+  function waterfallRow(opts) {
+    var extent = Math.max(opts.extent, 1);
+    var offset = Math.min(Math.max((opts.start / extent) * 100, 0), 99.5);
+    var width = Math.min(Math.max((opts.width / extent) * 100, 0.4), 100 - offset);
+    return h('div', {
+      class: 'wf-row' + (opts.active ? ' active' : '') + (opts.muted ? ' abandoned' : ''),
+      title: opts.title || opts.label,
+      onclick: opts.onclick
+    },
+    h('span', { class: 'wf-label', style: 'padding-left:' + (opts.depth || 0) + 'em' }, opts.label),
+    h('span', { class: 'wf-track' }, h('span', { class: 'wf-bar ' + (opts.kind || ''), style: 'left:' + pct(offset) + ';width:' + pct(width) })),
+    h('span', { class: 'wf-dur', text: duration(opts.durationNS) }));
+  }
+
+  function renderTurns(body) {
+    var doc = state.doc;
+    var start = Infinity, end = 0;
+    doc.traces.forEach(function (t) {
+      var s = new Date(t.trace.started_at).getTime();
+      start = Math.min(start, s);
+      end = Math.max(end, s + (t.trace.duration_ns || 0) / 1e6);
+    });
+
+    body.appendChild(h('h3', { text: 'Session timeline' }));
+    body.appendChild(h('div', { class: 'waterfall' }, doc.traces.map(function (t, i) {
+      var tr = t.trace;
+      return waterfallRow({
+        label: '#' + (i + 1) + ' ' + truncate(tr.user_prompt || '(' + (tr.synthetic || 'no prompt') + ')', 70),
+        start: new Date(tr.started_at).getTime() - start,
+        width: (tr.duration_ns || 0) / 1e6,
+        durationNS: tr.duration_ns,
+        extent: end - start,
+        kind: tr.synthetic || tr.abandoned ? 'muted' : '',
+        muted: tr.abandoned,
+        active: tr.trace_id === state.traceID,
+        onclick: function () { go(doc.session.id, tr.trace_id); }
+      });
+    })));
+
+    body.appendChild(h('h3', { text: 'Turns' }));
+    doc.traces.forEach(function (t, i) {
+      var tr = t.trace;
+      var active = tr.trace_id === state.traceID;
+      var u = tr.usage || {};
+      var card = h('div', { class: 'trace-card' + (active ? ' active' : '') + (tr.abandoned ? ' abandoned' : ''), id: 'trace-' + tr.trace_id },
+        h('div', { class: 'row muted' },
+          h('strong', { text: '#' + (i + 1) }),
+          when(tr.started_at), ' · ', duration(tr.duration_ns), ' · ', (tr.span_count || 0) + ' spans', ' · ',
+          tokens(u.input_tokens) + ' in / ' + tokens(u.output_tokens) + ' out', ' · ', cost(u.cost_usd),
+          tr.synthetic ? h('span', { class: 'badge', text: tr.synthetic }) : null,
+          tr.abandoned ? h('span', { class: 'badge', text: 'abandoned' }) : null,
+          tr.status && tr.status !== 'ok' ? h('span', { class: 'badge bad', text: tr.status }) : null),
+        h('div', {
+          class: 'prompt',
+          onclick: function () { go(doc.session.id, tr.trace_id); },
+          text: tr.user_prompt ? truncate(tr.user_prompt, active ? 4000 : 280) : '(' + (tr.synthetic || 'synthetic turn') + ')'
+        }),
+        tr.response_preview ? h('div', { class: 'response', text: truncate(tr.response_preview, active ? 4000 : 200) }) : null,
+        active ? spanWaterfall(t) : null);
+      body.appendChild(card);
+    });
+    var selected = byID('trace-' + state.traceID);
+    if (selected && !state.spanID) selected.scrollIntoView({ block: 'nearest' });
+  }
+
+  // spanOrder walks a trace's span tree depth first, children in seq
+  // order, so a waterfall reads like the nesting.
+  function spanOrder(spans) {
+    var ids = {};
+    var children = {};
+    spans.forEach(function (s) { ids[s.span_id] = true; });
+    var roots = [];
+    spans.forEach(function (s) {
+      if (s.parent_span_id && ids[s.parent_span_id]) (children[s.parent_span_id] = children[s.parent_span_id] || []).push(s);
+      else roots.push(s);
+    });
+    var out = [];
+    (function walk(list, depth) {
+      list.forEach(function (s) { out.push({ span: s, depth: depth }); walk(children[s.span_id] || [], depth + 1); });
+    })(roots, 0);
+    return out;
+  }
+
+  function spanWaterfall(t) {
+    var origin = new Date(t.trace.started_at).getTime();
+    var extent = Math.max((t.trace.duration_ns || 0) / 1e6, 1);
+    var spans = t.spans || [];
+    spans.forEach(function (s) {
+      extent = Math.max(extent, new Date(s.started_at).getTime() - origin + (s.duration_ns || 0) / 1e6);
+    });
+    return h('div', { class: 'waterfall' }, spanOrder(spans).map(function (entry) {
+      var s = entry.span;
+      var label = [h('span', { class: 'badge kind-' + s.kind, text: s.kind }), ' ', s.name];
+      if (s.kind === 'agent' && s.thread_id) label.push(h('span', { class: 'muted', text: ' ' + s.thread_id }));
+      if (s.call_kind && s.call_kind !== 'main') label.push(h('span', { class: 'muted', text: ' ' + s.call_kind }));
+      return waterfallRow({
+        label: label,
+        title: s.name,
+        depth: entry.depth,
+        start: new Date(s.started_at).getTime() - origin,
+        width: (s.duration_ns || 0) / 1e6,
+        durationNS: s.duration_ns,
+        extent: extent,
+        kind: s.kind + (s.status === 'error' ? ' error' : ''),
+        active: s.span_id === state.spanID,
+        onclick: function () { go(state.doc.session.id, t.trace.trace_id, s.span_id); }
+      });
+    }));
+  }
+
+  // threads lists the session's subagent threads: each agent span that
+  // carries a thread id, with the tool call that spawned it and the
+  // thread it was spawned from.
+  function threads() {
+    var out = [];
+    state.doc.traces.forEach(function (t) {
+      var spans = t.spans || [];
+      var byID = {};
+      spans.forEach(function (s) { byID[s.span_id] = s; });
+      spans.forEach(function (s) {
+        if (s.kind !== 'agent' || !s.thread_id) return;
+        var spawn = byID[s.parent_span_id];
+        var input = spawn ? (parseJSON(spawn.input, [])[0] || {}).tool_input || {} : {};
+        out.push({
+          thread: s.thread_id,
+          parent: spawn ? spawn.thread_id || '' : '',
+          label: input.description || input.subagent_type || (spawn ? spawn.name : 'subagent'),
+          type: input.subagent_type || '',
+          trace: t.trace.trace_id,
+          span: s,
+          calls: 0, input: 0, output: 0
+        });
+      });
+    });
+    var index = {};
+    out.forEach(function (th) { index[th.thread] = th; });
+    state.doc.traces.forEach(function (t) {
+      (t.spans || []).forEach(function (s) {
+        var th = index[s.thread_id];
+        if (!th || s.kind !== 'llm') return;
+        var u = parseJSON(s.usage, {}) || {};
+        th.calls++;
+        th.input += u.prompt_tokens || 0;
+        th.output += u.completion_tokens || 0;
+      });
+    });
+    return out;
+  }
+
+  function renderSubagents(body) {
+    var all = threads();
+    if (!all.length) {
+      body.appendChild(h('div', { class: 'empty', text: 'This session spawned no subagents.' }));
+      return;
+    }
+    var known = {};
+    all.forEach(function (th) { known[th.thread] = true; });
+    function subtree(parent) {
+      var kids = all.filter(function (th) { return th.parent === parent || (parent === '' && th.parent && !known[th.parent]); });
+      if (!kids.length) return null;
+      return h('ul', { class: 'tree' + (parent === '' ? ' root' : '') }, kids.map(function (th) {
+        return h('li', {},
+          h('div', { class: 'node' },
+            h('span', { class: 'badge kind-agent', text: th.type || 'subagent' }),
+            h('a', { href: link(state.doc.session.id, th.trace, th.span.span_id), text: truncate(th.label, 90) }),
+            h('code', { text: th.thread }),
+            h('span', { class: 'muted', text: 'turn #' + traceIndex(th.trace) + ' · ' + th.calls + ' calls · ' + tokens(th.input) + ' in / ' + tokens(th.output) + ' out · ' + duration(th.span.duration_ns) })),
+          subtree(th.thread));
+      }));
+    }
+    body.appendChild(h('ul', { class: 'tree root' }, h('li', {},
+      h('div', { class: 'node' }, h('span', { class: 'badge kind-agent', text: 'main' }), h('strong', { text: 'main thread' })),
+      subtree(''))));
+  }
+
+  function spanLabel(traceID, spanID) {
+    var s = findSpan(traceID, spanID);
+    return '#' + traceIndex(traceID) + ' ' + (s ? s.kind + ' ' + s.name : spanID);
+  }
+
+  function renderLinks(body) {
+    var doc = state.doc;
+    var counts = {};
+    doc.links.forEach(function (l) { counts[l.kind] = (counts[l.kind] || 0) + 1; });
+    body.appendChild(h('div', { class: 'row' }, Object.keys(counts).sort().map(function (kind) {
+      return h('span', { class: 'badge', text: kind + ' ' + counts[kind] });
+    })));
+
+    // emits/feeds edges are the dense per-call dataflow; the structural
+    // ones are what the list is for.
+    var shown = doc.links.filter(function (l) { return l.kind !== 'emits' && l.kind !== 'feeds'; });
+    body.appendChild(h('h3', { text: 'Structural links' }));
+    if (!shown.length) body.appendChild(h('div', { class: 'muted', text: 'No compaction seams, forks, rejoins or verdicts.' }));
+    else body.appendChild(h('table', {},
+      h('tr', {}, h('th', { text: 'kind' }), h('th', { text: 'from' }), h('th', { text: 'to' })),
+      shown.map(function (l) {
+        return h('tr', {},
+          h('td', {}, h('span', { class: 'badge', text: l.kind })),
+          h('td', {}, h('a', { href: link(doc.session.id, l.from_trace_id, l.from_span_id), text: spanLabel(l.from_trace_id, l.from_span_id) })),
+          h('td', {}, h('a', { href: link(doc.session.id, l.to_trace_id, l.to_span_id), text: spanLabel(l.to_trace_id, l.to_span_id) })));
+      })));
+
+    var verdicts = [];
+    doc.traces.forEach(function (t) {
+      (t.spans || []).forEach(function (s) {
+        var v = parseJSON(s.verdict, null);
+        if (v) verdicts.push({ trace: t.trace.trace_id, span: s, verdict: v });
+      });
+    });
+    body.appendChild(h('h3', { text: 'Verdicts' }));
+    if (!verdicts.length) body.appendChild(h('div', { class: 'muted', text: 'No permission checks carried a verdict.' }));
+    else body.appendChild(h('table', {},
+      h('tr', {}, h('th', { text: 'check' }), h('th', { text: 'disposition' }), h('th', { text: 'stage' }), h('th', { text: 'reasoned' })),
+      verdicts.map(function (v) {
+        return h('tr', {},
+          h('td', {}, h('a', { href: link(doc.session.id, v.trace, v.span.span_id), text: spanLabel(v.trace, v.span.span_id) })),
+          h('td', {}, h('span', { class: 'badge ' + (v.verdict.disposition === 'BLOCK' ? 'bad' : 'good'), text: v.verdict.disposition })),
+          h('td', { text: v.verdict.stage }),
+          h('td', { text: v.verdict.reasoned ? 'yes' : 'no' }));
+      })));
+  }
+
+  function renderModels(body) {
+    var r = state.doc.session.rollup || {};
+    var models = r.model_usage || [];
+    body.appendChild(h('h3', { text: 'Spend by model' }));
+    if (!models.length) body.appendChild(h('div', { class: 'muted', text: 'No priced llm calls.' }));
+    else body.appendChild(h('table', {},
+      h('tr', {}, h('th', { text: 'model' }), h('th', { class: 'num', text: 'calls' }), h('th', { class: 'num', text: 'input' }), h('th', { class: 'num', text: 'output' }), h('th', { class: 'num', text: 'cost' })),
+      models.map(function (m) {
+        return h('tr', {}, h('td', {}, h('code', { text: m.model })), h('td', { class: 'num', text: m.calls }),
+          h('td', { class: 'num', text: tokens(m.input_tokens) }), h('td', { class: 'num', text: tokens(m.output_tokens) }), h('td', { class: 'num', text: cost(m.cost_usd) }));
+      })));
+    var kinds = r.kind_counts || {};
+    body.appendChild(h('h3', { text: 'Calls by kind' }));
+    body.appendChild(h('div', { class: 'row' }, Object.keys(kinds).sort().map(function (k) {
+      return h('span', { class: 'badge', text: k + ' ' + kinds[k] });
+    })));
+  }
+
+  // ---- span inspector ----------------------------------------------------
+
+  function closeInspector() {
+    byID('inspector').hidden = true;
+    byID('view-sessions').classList.remove('with-inspector');
+  }
+
+  async function fullSpan(traceID, span) {
+    if (!span.payload) return span;
+    var key = traceID + '/' + span.span_id;
+    if (!state.fullSpans[key]) {
+      state.fullSpans[key] = await fetchJSON('/v1/traces/' + encodeURIComponent(traceID) + '/spans/' + encodeURIComponent(span.span_id));
+    }
+    return state.fullSpans[key];
+  }
+
+  // previousCall finds the llm call before span on the same thread,
+  // across the session's turns, for the input diff.
+  function previousCall(traceID, span) {
+    var prev = null;
+    for (var i = 0; i < state.doc.traces.length; i++) {
+      var t = state.doc.traces[i];
+      var spans = t.spans || [];
+      for (var j = 0; j < spans.length; j++) {
+        var s = spans[j];
+        if (s.span_id === span.span_id && t.trace.trace_id === traceID) return prev;
+        if (s.kind === 'llm' && s.thread_id === span.thread_id && s.call_kind === span.call_kind) prev = { trace: t.trace.trace_id, span: s };
+      }
+    }
+    return prev;
+  }
+
+  async function renderInspector() {
+    var panel = byID('inspector');
+    var span = state.spanID && findSpan(state.traceID, state.spanID);
+    if (!span) { closeInspector(); return; }
+    panel.hidden = false;
+    byID('view-sessions').classList.add('with-inspector');
+    clear(panel);
+
+    var traceID = state.traceID;
+    var u = parseJSON(span.usage, {}) || {};
+    var tool = parseJSON(span.tool, null);
+    var verdict = parseJSON(span.verdict, null);
+    panel.appendChild(h('div', { class: 'detail-head' },
+      h('div', { class: 'row' },
+        h('span', { class: 'badge kind-' + span.kind, text: span.kind }),
+        h('h2', { text: span.name }),
+        span.status && span.status !== 'ok' ? h('span', { class: 'badge bad', text: span.status }) : null,
+        verdict ? h('span', { class: 'badge ' + (verdict.disposition === 'BLOCK' ? 'bad' : 'good'), text: verdict.disposition }) : null,
+        h('button', { type: 'button', class: 'link', onclick: function () { go(state.doc.session.id, traceID); }, text: 'close' })),
+      h('div', { class: 'meta' },
+        h('span', {}, h('code', { text: span.span_id })),
+        span.thread_id ? h('span', { text: 'thread ' + span.thread_id }) : null,
+        span.call_kind ? h('span', { text: span.call_kind }) : null,
+        span.model && span.model !== span.name ? h('span', { text: span.model }) : null,
+        span.stop_reason ? h('span', { text: span.stop_reason }) : null,
+        h('span', { text: duration(span.duration_ns) + ' at ' + when(span.started_at) }),
+        u.prompt_tokens || u.completion_tokens ? h('span', { text: tokens(u.prompt_tokens) + ' in / ' + tokens(u.completion_tokens) + ' out' + (u.cache_read_input_tokens ? ' / ' + tokens(u.cache_read_input_tokens) + ' cached' : '') }) : null,
+        tool && (tool.server || tool.command) ? h('span', { text: [tool.server, tool.command].filter(Boolean).join(' · ') }) : null,
+        span.retry_of ? h('a', { href: link(state.doc.session.id, traceID, span.retry_of), text: 'retry of ' + span.retry_of }) : null,
+        span.raw_turn_id ? h('span', { text: 'raw turn ' + span.raw_turn_id }) : null)));
+
+    var tabs = [['payload', 'Payload'], ['json', 'JSON']];
+    if (span.kind === 'llm') tabs.splice(1, 0, ['diff', 'Diff vs previous call']);
+    if (!tabs.some(function (t) { return t[0] === state.inspectorTab; })) state.inspectorTab = 'payload';
+    panel.appendChild(h('div', { class: 'tabs' }, tabs.map(function (tab) {
+      return h('button', {
+        type: 'button',
+        class: state.inspectorTab === tab[0] ? 'active' : '',
+        onclick: function () { state.inspectorTab = tab[0]; renderInspector(); },
+        text: tab[1]
+      });
+    })));
+
+    var body = h('div', { class: 'tab-body', text: 'Loading...' });
+    panel.appendChild(body);
+    try {
+      var full = await fullSpan(traceID, span);
+      if (state.spanID !== span.span_id) return; // the selection moved on
+      clear(body);
+      if (state.inspectorTab === 'payload') renderPayload(body, full);
+      else if (state.inspectorTab === 'json') body.appendChild(h('pre', { text: JSON.stringify(full, null, 2) }));
+      else await renderDiff(body, traceID, full);
+    } catch (err) {
+      body.textContent = '';
+      body.appendChild(h('div', { class: 'error', text: err.message }));
+    }
+  }
+
+  function renderPayload(body, span) {
+    body.appendChild(h('div', { class: 'io-label', text: 'input' }));
+    renderBlocks(body, parseJSON(span.input, []));
+    body.appendChild(h('div', { class: 'io-label', text: 'output' }));
+    renderBlocks(body, parseJSON(span.output, []));
+  }
+
+  function renderBlocks(body, blocks) {
+    if (!blocks || !blocks.length) { body.appendChild(h('div', { class: 'muted', text: '(empty)' })); return; }
+    blocks.forEach(function (b) {
+      var view = blockView(b);
+      if (view.label) body.appendChild(h('div', { class: 'block-label', text: view.label }));
+      body.appendChild(h('pre', { class: b.is_error ? 'is-error' : '', text: view.body }));
+    });
+  }
+
+  // blockView flattens a content block to a label and a body, the same
+  // shape the diff compares line by line.
+  function blockView(b) {
+    switch (b.type) {
+      case 'text': return { label: '', body: b.text || '' };
+      case 'thinking': return { label: 'thinking', body: b.thinking || '' };
+      case 'tool_use':
+      case 'server_tool_use': return { label: 'tool_use ' + (b.tool_name || ''), body: JSON.stringify(b.tool_input || {}, null, 2) };
+      case 'image': return { label: 'image', body: (b.media_type || 'image') + ' (not shown)' };
+    }
+    if (b.type === 'tool_result' || /_tool_result$/.test(b.type || '')) {
+      return { label: 'result' + (b.tool_result_id ? ' ' + b.tool_result_id : '') + (b.is_error ? ' (error)' : ''), body: b.tool_output || JSON.stringify(b.content || '', null, 2) };
+    }
+    return { label: b.type, body: JSON.stringify(b, null, 2) };
+  }
+
+  function blockLines(blocks) {
+    var lines = [];
+    (blocks || []).forEach(function (b) {
+      var view = blockView(b);
+      lines.push('[' + (view.label || b.type) + ']');
+      String(view.body).split('\n').forEach(function (line) { lines.push(line); });
+    });
+    return lines;
+  }
+
+  async function renderDiff(body, traceID, span) {
+    var prev = previousCall(traceID, span);
+    if (!prev) {
+      body.appendChild(h('div', { class: 'muted', text: 'This is the first ' + (span.call_kind || '') + ' call on ' + (span.thread_id ? 'thread ' + span.thread_id : 'the main thread') + '; there is nothing to diff against.' }));
+      return;
+    }
+    var before = await fullSpan(prev.trace, prev.span);
+    var ops = diffLines(blockLines(parseJSON(before.input, [])), blockLines(parseJSON(span.input, [])));
+    var added = 0, removed = 0;
+    ops.forEach(function (op) { if (op[0] === 'add') added++; else if (op[0] === 'del') removed++; });
+    body.appendChild(h('div', { class: 'muted' },
+      'input against the previous call on this thread, ',
+      h('a', { href: link(state.doc.session.id, prev.trace, prev.span.span_id), text: spanLabel(prev.trace, prev.span.span_id) }),
+      ': +' + added + ' / −' + removed + ' lines'));
+    if (!added && !removed) {
+      body.appendChild(h('div', { class: 'muted', text: 'The inputs are identical.' }));
+      return;
+    }
+    body.appendChild(h('div', { class: 'diff' }, foldUnchanged(ops).map(function (op) {
+      return h('div', { class: op[0], text: op[1] });
+    })));
+  }
+
+  // diffLines is a line diff: the common head and tail are trimmed, and
+  // the middle is an LCS diff, or a plain replace when it is too large to
+  // table.
+  function diffLines(a, b) {
+    var head = 0;
+    while (head < a.length && head < b.length && a[head] === b[head]) head++;
+    var endA = a.length, endB = b.length;
+    while (endA > head && endB > head && a[endA - 1] === b[endB - 1]) { endA--; endB--; }
+    var ops = [];
+    var i, j;
+    for (i = 0; i < head; i++) ops.push(['same', a[i]]);
+    var midA = a.slice(head, endA), midB = b.slice(head, endB);
+    var n = midA.length, m = midB.length;
+    if (n * m > 4e6) {
+      midA.forEach(function (line) { ops.push(['del', line]); });
+      midB.forEach(function (line) { ops.push(['add', line]); });
+    } else {
+      var table = new Uint32Array((n + 1) * (m + 1));
+      for (i = n - 1; i >= 0; i--) {
+        for (j = m - 1; j >= 0; j--) {
+          table[i * (m + 1) + j] = midA[i] === midB[j]
+            ? table[(i + 1) * (m + 1) + j + 1] + 1
+            : Math.max(table[(i + 1) * (m + 1) + j], table[i * (m + 1) + j + 1]);
+        }
+      }
+      i = 0; j = 0;
+      while (i < n && j < m) {
+        if (midA[i] === midB[j]) { ops.push(['same', midA[i]]); i++; j++; }
+        else if (table[(i + 1) * (m + 1) + j] >= table[i * (m + 1) + j + 1]) ops.push(['del', midA[i++]]);
+        else ops.push(['add', midB[j++]]);
+      }
+      while (i < n) ops.push(['del', midA[i++]]);
+      while (j < m) ops.push(['add', midB[j++]]);
+    }
+    for (i = endA; i < a.length; i++) ops.push(['same', a[i]]);
+    return ops;
+  }
+
+  // foldUnchanged keeps three lines of context around each change.
+  function foldUnchanged(ops) {
+    var out = [];
+    var run = [];
+    function flush(atEnd) {
+      var lead = out.length ? 3 : 0;
+      var tail = atEnd ? 0 : 3;
+      if (run.length <= lead + tail + 1) { out = out.concat(run); }
+      else {
+        out = out.concat(run.slice(0, lead));
+        out.push(['fold', '… ' + (run.length - lead - tail) + ' unchanged lines']);
+        out = out.concat(run.slice(run.length - tail));
+      }
+      run = [];
+    }
+    ops.forEach(function (op) {
+      if (op[0] === 'same') { run.push(op); return; }
+      if (run.length) flush(false);
+      out.push(op);
+    });
+    if (run.length) flush(true);
+    return out;
+  }
+
+  // ---- stats dashboard ---------------------------------------------------
+
+  var DAY = 86400e3;
+
+  async function loadStats() {
+    var days = parseInt(byID('stats-range').value, 10);
+    var step = days > 31 ? 7 : 1;
+    var end = Math.ceil(Date.now() / DAY) * DAY;
+    var start = end - days * DAY;
+    var buckets = [];
+    for (var at = start; at < end; at += step * DAY) buckets.push({ since: at, until: Math.min(at + step * DAY, end) });
+
+    function query(since, until) {
+      var q = new URLSearchParams({ since: new Date(since).toISOString() });
+      if (until) q.set('until', new Date(until - 1000).toISOString());
+      return q.toString();
+    }
+
+    byID('stats-status').textContent = 'Loading...';
+    try {
+      var totals = fetchJSON('/v1/stats?' + query(start));
+      var tools = fetchJSON('/v1/tools?' + query(start)).catch(function (err) { return { error: err }; });
+      var series = Promise.all(buckets.map(function (b) { return fetchJSON('/v1/stats?' + query(b.since, b.until)); }));
+      renderStats(await totals, await series, await tools, buckets, step);
+      byID('stats-status').textContent = '';
+    } catch (err) {
+      byID('stats-status').textContent = err.message;
+    }
+  }
+
+  function renderStats(totals, series, tools, buckets, step) {
+    clear(byID('stats-totals')).appendChild(h('div', { class: 'stats' },
+      stat(totals.session_count || 0, 'sessions'),
+      stat(totals.turn_count || 0, 'turns'),
+      stat(totals.completed_count || 0, 'completed'),
+      stat(cost(totals.total_cost), 'cost'),
+      stat(tokens(totals.input_tokens), 'input tokens'),
+      stat(tokens(totals.output_tokens), 'output tokens'),
+      stat(duration((totals.total_duration_ms || 0) * 1e6), 'agent time'),
+      stat(totals.tool_calls || 0, 'tool calls')));
+
+    function label(b) {
+      var d = new Date(b.since);
+      return (step > 1 ? 'wk ' : '') + (d.getUTCMonth() + 1) + '/' + d.getUTCDate();
+    }
+    chart(byID('stats-cost'), series.map(function (s, i) {
+      return { label: label(buckets[i]), value: s.total_cost || 0, title: label(buckets[i]) + ': ' + cost(s.total_cost) };
+    }));
+    chart(byID('stats-turns'), series.map(function (s, i) {
+      return { label: label(buckets[i]), value: s.turn_count || 0, title: label(buckets[i]) + ': ' + (s.turn_count || 0) + ' turns, ' + (s.session_count || 0) + ' sessions' };
+    }));
+
+    var latency = totals.latency || [];
+    clear(byID('stats-latency')).appendChild(latency.length ? h('table', {},
+      h('tr', {}, ['model', 'calls', 'ttfb p50', 'ttfb p95', 'ttft p50', 'ttft p95', 'tok/s p50'].map(function (c, i) { return h('th', { class: i ? 'num' : '', text: c }); })),
+      latency.map(function (m) {
+        return h('tr', {}, h('td', {}, h('code', { text: m.model })), h('td', { class: 'num', text: m.calls }),
+          [m.ttfb_p50_ms, m.ttfb_p95_ms, m.ttft_p50_ms, m.ttft_p95_ms].map(function (v) { return h('td', { class: 'num', text: v ? Math.round(v) + 'ms' : '–' }); }),
+          h('td', { class: 'num', text: m.output_tokens_per_sec_p50 ? m.output_tokens_per_sec_p50.toFixed(1) : '–' }));
+      })) : h('div', { class: 'muted', text: 'No timed calls in this window.' }));
+
+    var toolBox = clear(byID('stats-tools'));
+    if (tools.error) { toolBox.appendChild(h('div', { class: 'muted', text: tools.error.status === 501 ? 'This backend has no tool analytics.' : tools.error.message })); return; }
+    var items = (tools.items || []).slice(0, 25);
+    toolBox.appendChild(items.length ? h('table', {},
+      h('tr', {}, ['tool', 'calls', 'errors', 'retries', 'p50', 'p95'].map(function (c, i) { return h('th', { class: i ? 'num' : '', text: c }); })),
+      items.map(function (t) {
+        return h('tr', {}, h('td', {}, h('code', { text: t.tool }), t.server || t.command ? h('span', { class: 'muted', text: ' ' + (t.server || t.command) }) : null),
+          h('td', { class: 'num', text: t.calls }),
+          h('td', { class: 'num', text: t.errors + (t.calls ? ' (' + Math.round(t.error_rate * 100) + '%)' : '') }),
+          h('td', { class: 'num', text: t.retries }),
+          h('td', { class: 'num', text: Math.round(t.p50_ms) + 'ms' }),
+          h('td', { class: 'num', text: Math.round(t.p95_ms) + 'ms' }));
+      })) : h('div', { class: 'muted', text: 'No tool calls in this window.' }));
+  }
+
+  function chart(box, points) {
+    clear(box);
+    var max = Math.max.apply(null, points.map(function (p) { return p.value; }).concat([0]));
+    var every = Math.ceil(points.length / 10);
+    points.forEach(function (p, i) {
+      box.appendChild(h('div', { class: 'col', title: p.title },
+        h('i', { style: 'height:' + (max ? pct((p.value / max) * 85) : '0%') }),
+        h('span', { text: i % every === 0 ? p.label : '' })));
+    });
+  }
+
+  // ---- wiring ------------------------------------------------------------
+
+  byID('filters').addEventListener('submit', function (event) {
+    event.preventDefault();
+    lookup(byID('lookup').value);
+  });
+  ['filter-since', 'filter-outcome', 'filter-sort', 'filter-direction'].forEach(function (id) {
+    byID(id).addEventListener('change', function () { loadSessions(false); });
+  });
+  byID('more').addEventListener('click', function () { loadSessions(true); });
+  byID('refresh').addEventListener('click', function () {
+    loadSessions(false);
+    state.doc = null;
+    route();
+  });
+  byID('seed').addEventListener('click', async function () {
+    try {
+      setStatus('Seeding demo data...', false);
+      await fetchJSON('/v1/admin/seed/demo', { method: 'POST' });
+      await loadSessions(false);
+    } catch (err) {
+      setStatus(err.message, true);
+    }
+  });
+  byID('stats-range').addEventListener('change', loadStats);
+  byID('token').value = apiToken;
+  byID('token').addEventListener('change', function () {
+    setToken(byID('token').value.trim());
+    state.listLoaded = false;
+    state.doc = null;
+    route();
+  });
+  document.addEventListener('keydown', function (event) {
+    if (event.key === 'Escape' && state.spanID && state.doc) go(state.doc.session.id, state.traceID);
+  });
+  window.addEventListener('hashchange', route);
+
+  // Links from the old single-page UI carried ?session=<id>.
+  var legacy = new URLSearchParams(location.search).get('session');
+  if (legacy && !location.hash) history.replaceState(null, '', '/' + link(legacy));
+  route();
+})();
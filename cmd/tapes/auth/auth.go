@@ -34,7 +34,9 @@ Examples:
   tapes auth anthropic           Prompt for Anthropic API key
   tapes auth --list              List stored credentials
   tapes auth --remove openai     Remove stored OpenAI credentials
-  echo $KEY | tapes auth openai  Pipe API key from stdin`
+  echo $KEY | tapes auth openai  Pipe API key from stdin
+
+To manage tokens for the tapes API server itself, see "tapes auth token".`
 
 const authShortDesc string = "Store API credentials for LLM providers"
 
@@ -74,6 +76,8 @@ func NewAuthCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&listFlag, "list", false, "List stored credentials")
 	cmd.Flags().StringVar(&removeFlag, "remove", "", "Remove stored credentials for a provider")
 
+	cmd.AddCommand(newTokenCmd())
+
 	return cmd
 }
 
@@ -135,4 +135,40 @@ var _ = Describe("Auth Command", func() {
 			Expect(directive).To(Equal(cobra.ShellCompDirectiveNoFileComp))
 		})
 	})
+
+	Describe("token subcommand", func() {
+		newTokenCmd := func(args ...string) *cobra.Command {
+			cmd := authcmder.NewAuthCmd()
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.PersistentFlags().String("config-dir", "", "Override path to .tapes/ config directory")
+			cmd.SetArgs(append(args, "--config-dir", tmpDir))
+			return cmd
+		}
+
+		It("registers create, list, and revoke", func() {
+			cmd := authcmder.NewAuthCmd()
+			token, _, err := cmd.Find([]string{"token"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token.Name()).To(Equal("token"))
+
+			var names []string
+			for _, sub := range token.Commands() {
+				names = append(names, sub.Name())
+			}
+			Expect(names).To(ContainElements("create", "list", "revoke"))
+		})
+
+		It("rejects an unknown scope before connecting", func() {
+			err := newTokenCmd("token", "create", "--name", "ci", "--scope", "superuser").Execute()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("superuser"))
+		})
+
+		It("requires a postgres DSN", func() {
+			err := newTokenCmd("token", "list").Execute()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("postgres DSN"))
+		})
+	})
 })
@@ -1,11 +1,13 @@
 // Package backfillcmder exposes offline backfills into a running tapes
-// deployment. Today that is the paperd wire-trace replay, which fills
-// the immutable raw-turn layer for sessions recorded before the raw
-// layer existed; the derive worker projects them into spans as usual.
+// deployment: the paperd wire-trace replay, which fills the immutable
+// raw-turn layer for sessions recorded before the raw layer existed,
+// harness transcript uploads, NDJSON batch files, and third-party trace
+// imports. The derive worker projects them into spans as usual.
 package backfillcmder
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -43,6 +45,154 @@ func NewBackfillCmd() *cobra.Command {
 	}
 	cmd.AddCommand(newWireTraceCmd())
 	cmd.AddCommand(newTranscriptsCmd())
+	cmd.AddCommand(newNDJSONCmd())
+	cmd.AddCommand(newImportCmd())
+	return cmd
+}
+
+const importLongDesc string = `Import third-party agent traces into the raw layer.
+
+Reads an export from another tracing or logging system, maps each LLM call
+it recorded onto the request/response pair a live capture would have
+stored, and streams the turns through {ingest-url}/v1/ingest/batch. The
+derive worker then projects them into sessions like any other capture.
+
+Formats:
+  langsmith         LangSmith run exports (llm runs; JSON array or JSONL)
+  langfuse          Langfuse traces with observations, or bare observations
+  openai-responses  stored OpenAI Responses, optionally paired with input_items
+  claude-code       Claude Code session transcripts (~/.claude/projects/*/*.jsonl)
+  helicone          Helicone request logs with stored bodies
+  litellm           LiteLLM StandardLoggingPayload objects or spend-log rows
+
+The path may be a file, a directory of .json/.jsonl/.ndjson files (each
+optionally .gz), or "-" for stdin. Every turn carries its provenance: the
+meta block records backfill_source "import/<format>" and the source id, and
+the session's harness_metadata records the format.
+
+Idempotent: each turn's request_id is the format plus the source system's
+call id, so re-importing an export, or an overlapping later one, dedupes.
+
+Example:
+  tapes backfill import --format langsmith ./runs.jsonl \
+    --ingest-url http://localhost:8090`
+
+type importCommander struct {
+	format     string
+	ingestURL  string
+	chunkBytes int
+	dryRun     bool
+	verbose    bool
+}
+
+func newImportCmd() *cobra.Command {
+	cmder := &importCommander{}
+
+	cmd := &cobra.Command{
+		Use:   "import <path>",
+		Short: "Import third-party agent traces (LangSmith, Langfuse, Helicone, ...)",
+		Long:  importLongDesc,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := backfill.Import(cmd.Context(), backfill.ImportOptions{
+				Format:     cmder.format,
+				Path:       args[0],
+				IngestURL:  cmder.ingestURL,
+				ChunkBytes: cmder.chunkBytes,
+				DryRun:     cmder.dryRun,
+				Verbose:    cmder.verbose,
+				Logf: func(format string, args ...any) {
+					fmt.Fprintf(cmd.ErrOrStderr(), format+"\n", args...)
+				},
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(),
+				"files %d, turns %d, skipped %d: accepted %d, deduped %d, rejected %d\n",
+				result.Files, result.Turns, result.Skipped, result.Accepted, result.Deduped, result.Rejected)
+			for _, f := range result.Failures {
+				fmt.Fprintf(cmd.OutOrStdout(), "  failure: %s\n", f)
+			}
+			if result.Rejected > 0 {
+				return fmt.Errorf("%d turn(s) rejected", result.Rejected)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&cmder.format, "format", "", "export format: "+strings.Join(backfill.ImportFormats(), ", ")+" (required)")
+	cmd.Flags().StringVar(&cmder.ingestURL, "ingest-url", "http://127.0.0.1:8090", "base URL of the tapes-ingest server")
+	cmd.Flags().IntVar(&cmder.chunkBytes, "chunk-bytes", backfill.DefaultNDJSONChunkBytes, "uncompressed bytes per batch request")
+	cmd.Flags().BoolVar(&cmder.dryRun, "dry-run", false, "map and reduce every record but skip the upload")
+	cmd.Flags().BoolVarP(&cmder.verbose, "verbose", "v", false, "log each record's outcome")
+	_ = cmd.MarkFlagRequired("format")
+
+	return cmd
+}
+
+const ndjsonLongDesc string = `Stream an NDJSON file of turns and transcripts through tapes-ingest.
+
+Each line is one JSON object carrying exactly one of "turn" (a
+/v1/ingest envelope) or "transcript" (a /v1/ingest/transcript body).
+The file may be gzip-compressed; "-" reads stdin. Lines are POSTed in
+gzip-compressed chunks to {ingest-url}/v1/ingest/batch, and the server
+answers each line as accepted, deduped, or rejected with a reason.
+
+Lines the server could not hand downstream are retried with backoff;
+any other rejection is reported with its line number and fails the run.
+
+Idempotent: every line dedupes exactly as it would posted alone, so an
+interrupted run is resumed by running it again.
+
+Example:
+  tapes backfill ndjson history.ndjson.gz \
+    --ingest-url http://localhost:8090`
+
+type ndjsonCommander struct {
+	ingestURL  string
+	chunkBytes int
+	verbose    bool
+}
+
+func newNDJSONCmd() *cobra.Command {
+	cmder := &ndjsonCommander{}
+
+	cmd := &cobra.Command{
+		Use:   "ndjson <file>",
+		Short: "Stream an NDJSON file of turns and transcripts through batch ingest",
+		Long:  ndjsonLongDesc,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := backfill.NDJSON(cmd.Context(), backfill.NDJSONOptions{
+				Path:       args[0],
+				IngestURL:  cmder.ingestURL,
+				ChunkBytes: cmder.chunkBytes,
+				Verbose:    cmder.verbose,
+				Logf: func(format string, args ...any) {
+					fmt.Fprintf(cmd.ErrOrStderr(), format+"\n", args...)
+				},
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(),
+				"lines %d: accepted %d, deduped %d, rejected %d\n",
+				result.Lines, result.Accepted, result.Deduped, result.Rejected)
+			for _, f := range result.Failures {
+				fmt.Fprintf(cmd.OutOrStdout(), "  failure: %s\n", f)
+			}
+			if result.Rejected > 0 {
+				return fmt.Errorf("%d line(s) rejected", result.Rejected)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&cmder.ingestURL, "ingest-url", "http://127.0.0.1:8090", "base URL of the tapes-ingest server")
+	cmd.Flags().IntVar(&cmder.chunkBytes, "chunk-bytes", backfill.DefaultNDJSONChunkBytes, "uncompressed bytes per batch request")
+	cmd.Flags().BoolVarP(&cmder.verbose, "verbose", "v", false, "log each line's outcome")
+
 	return cmd
 }
 
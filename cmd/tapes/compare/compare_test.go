@@ -0,0 +1,114 @@
+package comparecmder_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/api"
+	comparecmder "github.com/papercomputeco/tapes/cmd/tapes/compare"
+	"github.com/papercomputeco/tapes/pkg/derive"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+// chatTurn is one captured Anthropic call opening a session. stream and
+// tools make it classify as the conversation spine, as a harness call does.
+func chatTurn(id int64, session, prompt, reply string, outputTokens int) storage.RawTurnRecord {
+	req, err := json.Marshal(map[string]any{
+		"model": "claude-sonnet-4-5", "max_tokens": 1024, "stream": true,
+		"tools": []map[string]any{{
+			"name": "read_file", "description": "Read a file",
+			"input_schema": map[string]any{"type": "object"},
+		}},
+		"messages": []map[string]any{{"role": "user", "content": prompt}},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	resp, err := json.Marshal(map[string]any{
+		"done": true, "model": "claude-sonnet-4-5", "stop_reason": "end_turn",
+		"usage":   map[string]any{"prompt_tokens": 100, "completion_tokens": outputTokens, "total_tokens": 100 + outputTokens},
+		"message": map[string]any{"role": "assistant", "content": []map[string]any{{"type": "text", "text": reply}}},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	return storage.RawTurnRecord{
+		ID: id, Source: storage.RawTurnSourceWire, Provider: "anthropic",
+		HarnessID: "claude", HarnessSessionID: session,
+		RequestID: fmt.Sprintf("req-%d", id), RawRequest: req, Response: resp,
+		ReceivedAt: time.Unix(1700000000+id, 0).UTC(),
+	}
+}
+
+var _ = Describe("tapes compare", func() {
+	var dir, corpus string
+
+	writeCorpus := func(name string, rows ...storage.RawTurnRecord) string {
+		path := filepath.Join(dir, name)
+		var buf bytes.Buffer
+		Expect(derive.WriteCorpus(&buf, rows)).To(Succeed())
+		Expect(os.WriteFile(path, buf.Bytes(), 0o600)).To(Succeed())
+		return path
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		corpus = writeCorpus("corpus.jsonl.gz",
+			chatTurn(1, "run-a", "what is a tape?", "a recording", 20),
+			chatTurn(2, "run-z", "and a span?", "one step of a trace", 10),
+		)
+	})
+
+	execute := func(args ...string) (string, string, error) {
+		cmd := comparecmder.NewCompareCmd()
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return stdout.String(), stderr.String(), err
+	}
+
+	It("aligns two runs of a task and prints what moved", func() {
+		rerun := writeCorpus("rerun.jsonl.gz", chatTurn(1, "run-b", "what is a tape?", "a recording of an agent session", 45))
+		stdout, _, err := execute("run-a", "run-b", "--corpus", corpus, "--corpus", rerun)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stdout).To(ContainSubstring("claude/run-a"))
+		Expect(stdout).To(ContainSubstring("claude/run-b"))
+		Expect(stdout).To(MatchRegexp(`output tokens\s+20\s+45\s+\+25`))
+		Expect(stdout).To(ContainSubstring("turns: 1 aligned, 0 only in A, 0 only in B"))
+		Expect(stdout).To(ContainSubstring("= A#1 ↔ B#1  prompt 100%"))
+		Expect(stdout).To(ContainSubstring("reply changed"))
+	})
+
+	It("prints the API's JSON with --json and takes one corpus per session", func() {
+		other := writeCorpus("other.jsonl.gz", chatTurn(3, "run-c", "summarize the release", "done", 5))
+		out := filepath.Join(dir, "compare.json")
+		_, summary, err := execute("run-a", "run-c", "--corpus", corpus, "--corpus", other, "--json", "-o", out)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(summary).To(ContainSubstring("wrote " + out + ": 0 aligned turn(s), 1 only in A, 1 only in B"))
+
+		raw, err := os.ReadFile(out)
+		Expect(err).NotTo(HaveOccurred())
+		var cmp api.SessionCompareResponse
+		Expect(json.Unmarshal(raw, &cmp)).To(Succeed())
+		Expect(cmp.A.Session.HarnessSessionID).To(Equal("run-a"))
+		Expect(cmp.B.Session.HarnessSessionID).To(Equal("run-c"))
+		Expect(cmp.Traces).To(HaveLen(2))
+		Expect(cmp.Traces[0].Match).To(Equal(api.CompareOnlyA))
+		Expect(cmp.Traces[1].Match).To(Equal(api.CompareOnlyB))
+	})
+
+	It("reports a session the corpus does not hold", func() {
+		_, _, err := execute("run-a", "run-y", "--corpus", corpus)
+		Expect(err).To(MatchError(ContainSubstring("session run-y not found")))
+	})
+
+	It("rejects more than two corpora", func() {
+		_, _, err := execute("run-a", "run-z", "--corpus", corpus, "--corpus", corpus, "--corpus", corpus)
+		Expect(err).To(MatchError(ContainSubstring("--corpus takes one file, or two")))
+	})
+})
@@ -0,0 +1,157 @@
+// Package derivecmder provides the `tapes derive` command, which renders
+// a corpus file into the read API's session/trace projection offline.
+package derivecmder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/papercomputeco/tapes/api"
+	"github.com/papercomputeco/tapes/api/projection"
+	"github.com/papercomputeco/tapes/pkg/derive"
+	"github.com/papercomputeco/tapes/pkg/sessions"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+type deriveCommander struct {
+	in      string
+	out     string
+	payload string
+}
+
+const deriveLongDesc string = `Derive sessions from a corpus file, with no server.
+
+Reads a corpus file (the gzipped JSONL raw_turns dump that
+"tapes serve proxy --capture-file" and "tapes dev dump-corpus" write;
+plain JSONL works too), runs it through the same deriver a deployment
+runs, and writes the sessions, traces and spans it derives.
+
+The output is one JSON document:
+
+  {"schema": "...", "sessions": [ ... ]}
+
+Each entry in sessions is exactly the GET /v1/sessions/{id}/traces
+response for that session, so anything that reads the API reads the
+file. Session ids are minted from the harness session id, so deriving
+the same corpus twice gives the same ids. Costs are priced with the
+built-in pricing table.
+
+Examples:
+  tapes serve proxy --capture-file captures.jsonl.gz
+  tapes derive --in captures.jsonl.gz --out projection.json
+  tapes derive --in captures.jsonl.gz --payload preview > slim.json`
+
+const deriveShortDesc string = "Derive sessions from a corpus file offline"
+
+// projectionFile is the --out document: one composite traces response
+// per derived session, in order of first activity.
+type projectionFile struct {
+	Schema   string                       `json:"schema"`
+	Sessions []*api.SessionTracesResponse `json:"sessions"`
+}
+
+// NewDeriveCmd creates the `tapes derive` command.
+func NewDeriveCmd() *cobra.Command {
+	cmder := &deriveCommander{}
+
+	cmd := &cobra.Command{
+		Use:   "derive",
+		Short: deriveShortDesc,
+		Long:  deriveLongDesc,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmder.run(cmd)
+		},
+	}
+
+	cmd.Flags().StringVarP(&cmder.in, "in", "i", "", `corpus file to derive ("-" for stdin)`)
+	cmd.Flags().StringVarP(&cmder.out, "out", "o", "-", `projection file to write ("-" for stdout)`)
+	cmd.Flags().StringVar(&cmder.payload, "payload", string(api.PayloadFull), "span payloads: full, or preview to truncate them as ?payload=preview does")
+	_ = cmd.MarkFlagRequired("in")
+
+	return cmd
+}
+
+func (c *deriveCommander) run(cmd *cobra.Command) error {
+	mode := api.PayloadMode(c.payload)
+	if mode != api.PayloadFull && mode != api.PayloadPreview {
+		return fmt.Errorf("invalid --payload %q: want full or preview", c.payload)
+	}
+
+	wire, transcripts, err := c.load(cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+	if len(wire) == 0 {
+		return errors.New("corpus has no wire rows to derive")
+	}
+
+	rendered, err := projection.Build(wire, transcripts, derive.WithPricing(sessions.DefaultPricing()))
+	if err != nil {
+		return err
+	}
+
+	doc := projectionFile{
+		Schema:   api.ProjectionSchema,
+		Sessions: make([]*api.SessionTracesResponse, 0, len(rendered)),
+	}
+	var traces, spans int
+	for _, s := range rendered {
+		doc.Sessions = append(doc.Sessions, s.Traces(mode))
+		traces += len(s.Turns)
+		spans += len(s.Spans)
+	}
+
+	if err := c.write(cmd.OutOrStdout(), doc); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "derived %d session(s) from %d raw turn(s): %d trace(s), %d span(s)\n",
+		len(rendered), len(wire)+len(transcripts), traces, spans)
+	return nil
+}
+
+// load reads --in, from stdin when it is "-".
+func (c *deriveCommander) load(stdin io.Reader) (wire, transcripts []storage.RawTurnRecord, err error) {
+	if c.in == "-" {
+		wire, transcripts, err = derive.LoadCorpus(stdin)
+		if err != nil {
+			return nil, nil, fmt.Errorf("corpus on stdin: %w", err)
+		}
+		return wire, transcripts, nil
+	}
+	return derive.LoadCorpusFile(c.in)
+}
+
+// write encodes doc to --out: indented, and without HTML escaping, since
+// payload text is full of <tags> and the file is meant to be read.
+func (c *deriveCommander) write(stdout io.Writer, doc projectionFile) error {
+	out := stdout
+	var file *os.File
+	if c.out != "-" {
+		var err error
+		file, err = os.Create(c.out)
+		if err != nil {
+			return fmt.Errorf("create projection: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encode projection: %w", err)
+	}
+	if file != nil {
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("close projection: %w", err)
+		}
+	}
+	return nil
+}
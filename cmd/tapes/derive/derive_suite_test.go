@@ -0,0 +1,13 @@
+package derivecmder_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestDerive(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Derive Command Suite")
+}
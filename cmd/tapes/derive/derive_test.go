@@ -0,0 +1,140 @@
+package derivecmder_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	derivecmder "github.com/papercomputeco/tapes/cmd/tapes/derive"
+	"github.com/papercomputeco/tapes/pkg/derive"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+// chatTurn is one captured Anthropic call opening a session. stream and
+// tools make it classify as the conversation spine, as a harness call does.
+func chatTurn(id int64, session, prompt, reply string) storage.RawTurnRecord {
+	req, err := json.Marshal(map[string]any{
+		"model": "claude-sonnet-4-5", "max_tokens": 1024, "stream": true,
+		"tools": []map[string]any{{
+			"name": "read_file", "description": "Read a file",
+			"input_schema": map[string]any{"type": "object"},
+		}},
+		"messages": []map[string]any{{"role": "user", "content": prompt}},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	resp, err := json.Marshal(map[string]any{
+		"done": true, "model": "claude-sonnet-4-5", "stop_reason": "end_turn",
+		"usage":   map[string]any{"prompt_tokens": 100, "completion_tokens": 20, "total_tokens": 120},
+		"message": map[string]any{"role": "assistant", "content": []map[string]any{{"type": "text", "text": reply}}},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	return storage.RawTurnRecord{
+		ID: id, Source: storage.RawTurnSourceWire, Provider: "anthropic",
+		HarnessID: "claude", HarnessSessionID: session,
+		RequestID: fmt.Sprintf("req-%d", id), RawRequest: req, Response: resp,
+		ReceivedAt: time.Unix(1700000000+id, 0).UTC(),
+	}
+}
+
+type projection struct {
+	Schema   string `json:"schema"`
+	Sessions []struct {
+		Session struct {
+			ID               string `json:"id"`
+			HarnessSessionID string `json:"harness_session_id"`
+		} `json:"session"`
+		Traces []struct {
+			Trace struct {
+				UserPrompt string `json:"user_prompt"`
+			} `json:"trace"`
+			Spans []json.RawMessage `json:"spans"`
+		} `json:"traces"`
+	} `json:"sessions"`
+}
+
+var _ = Describe("tapes derive", func() {
+	var dir, corpus string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		corpus = filepath.Join(dir, "corpus.jsonl.gz")
+
+		var buf bytes.Buffer
+		Expect(derive.WriteCorpus(&buf, []storage.RawTurnRecord{
+			chatTurn(1, "session-a", "what is a tape?", "a recording"),
+			chatTurn(2, "session-b", "and a span?", "one step of a trace"),
+		})).To(Succeed())
+		Expect(os.WriteFile(corpus, buf.Bytes(), 0o600)).To(Succeed())
+	})
+
+	derived := func(args ...string) (projection, string) {
+		cmd := derivecmder.NewDeriveCmd()
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs(args)
+		Expect(cmd.Execute()).To(Succeed())
+
+		var doc projection
+		if stdout.Len() > 0 {
+			Expect(json.Unmarshal(stdout.Bytes(), &doc)).To(Succeed())
+		}
+		return doc, stderr.String()
+	}
+
+	It("renders every session of the corpus in the composite traces shape", func() {
+		doc, summary := derived("--in", corpus)
+
+		Expect(doc.Schema).NotTo(BeEmpty())
+		Expect(doc.Sessions).To(HaveLen(2))
+		Expect(doc.Sessions[0].Session.HarnessSessionID).To(Equal("session-a"))
+		Expect(doc.Sessions[1].Session.HarnessSessionID).To(Equal("session-b"))
+		Expect(doc.Sessions[0].Traces).To(HaveLen(1))
+		Expect(doc.Sessions[0].Traces[0].Trace.UserPrompt).To(Equal("what is a tape?"))
+		Expect(doc.Sessions[0].Traces[0].Spans).NotTo(BeEmpty())
+		Expect(summary).To(ContainSubstring("derived 2 session(s) from 2 raw turn(s)"))
+	})
+
+	It("writes --out and mints the same session ids on every run", func() {
+		out := filepath.Join(dir, "projection.json")
+		first, _ := derived("--in", corpus)
+		derived("--in", corpus, "--out", out)
+
+		raw, err := os.ReadFile(out)
+		Expect(err).NotTo(HaveOccurred())
+		var second projection
+		Expect(json.Unmarshal(raw, &second)).To(Succeed())
+		Expect(second.Sessions[0].Session.ID).To(Equal(first.Sessions[0].Session.ID))
+	})
+
+	It("reads a corpus that was decompressed by hand", func() {
+		gz, err := os.Open(corpus)
+		Expect(err).NotTo(HaveOccurred())
+		defer gz.Close()
+		zr, err := gzip.NewReader(gz)
+		Expect(err).NotTo(HaveOccurred())
+		plain, err := io.ReadAll(zr)
+		Expect(err).NotTo(HaveOccurred())
+		path := filepath.Join(dir, "corpus.jsonl")
+		Expect(os.WriteFile(path, plain, 0o600)).To(Succeed())
+
+		doc, _ := derived("--in", path)
+		Expect(doc.Sessions).To(HaveLen(2))
+	})
+
+	It("rejects an unknown payload mode", func() {
+		cmd := derivecmder.NewDeriveCmd()
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs([]string{"--in", corpus, "--payload", "everything"})
+		Expect(cmd.Execute()).To(MatchError(ContainSubstring("invalid --payload")))
+	})
+})
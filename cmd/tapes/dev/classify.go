@@ -0,0 +1,287 @@
+package devcmder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/papercomputeco/tapes/pkg/derive"
+	"github.com/papercomputeco/tapes/pkg/llm"
+	"github.com/papercomputeco/tapes/pkg/llm/provider"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+const classifyLongDesc string = `Report how the harness profiles classify a corpus.
+
+Replays every wire raw turn in each corpus file (a gzipped JSONL dump,
+as written by ` + "`tapes dev dump-corpus`" + `) through the call classifier
+and prints, per rule, how many calls and injected messages it claimed,
+then the calls no rule claimed. An unknown call is either a harness side
+call no profile catalogs yet or a classifier regression; the listing
+shows the tells a new rule would match on (system prompt, last message,
+max_tokens, tool count, stream).
+
+Profiles come from derive.harness_profiles in config.toml ahead of the
+built-in ones. --profile replaces the configured files, so a profile
+under development can be tried without editing config. This never
+touches a database.
+
+Examples:
+  tapes dev classify ./corpus-local/*.jsonl.gz
+  tapes dev classify --profile ./aider.toml --fail-on-unknown corpus.jsonl.gz`
+
+type classifyCommander struct {
+	profilePaths  []string
+	maxUnknowns   int
+	failOnUnknown bool
+	asJSON        bool
+}
+
+func newClassifyCmd() *cobra.Command {
+	cmder := &classifyCommander{}
+	cmd := &cobra.Command{
+		Use:   "classify <corpus>...",
+		Short: "Report harness-profile rule hits and unknown calls over a corpus",
+		Long:  classifyLongDesc,
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return cmder.run(cmd, args)
+		},
+	}
+	cmd.Flags().StringArrayVar(&cmder.profilePaths, "profile", nil, "harness profile file to consult ahead of the built-ins, instead of the configured ones (repeatable)")
+	cmd.Flags().IntVar(&cmder.maxUnknowns, "unknowns", 20, "list at most this many unknown calls per corpus")
+	cmd.Flags().BoolVar(&cmder.failOnUnknown, "fail-on-unknown", false, "exit non-zero when any call is unknown")
+	cmd.Flags().BoolVar(&cmder.asJSON, "json", false, "print the reports as JSON")
+	return cmd
+}
+
+func (c *classifyCommander) run(cmd *cobra.Command, paths []string) error {
+	profiles := derive.ActiveHarnessProfiles()
+	if len(c.profilePaths) > 0 {
+		var err error
+		profiles, err = derive.LoadHarnessProfiles(c.profilePaths...)
+		if err != nil {
+			return err
+		}
+	}
+
+	reports := make([]*classifyReport, 0, len(paths))
+	unknown := 0
+	for _, path := range paths {
+		report, err := classifyCorpusFile(profiles, path, c.maxUnknowns)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		reports = append(reports, report)
+		unknown += report.Unknown
+	}
+
+	if c.asJSON {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(reports); err != nil {
+			return err
+		}
+	} else {
+		cmd.Printf("profiles: %s\n", strings.Join(profiles.Names(), ", "))
+		for _, report := range reports {
+			writeClassifyReport(cmd.OutOrStdout(), report)
+		}
+	}
+
+	if c.failOnUnknown && unknown > 0 {
+		return fmt.Errorf("%d call(s) matched no harness profile rule", unknown)
+	}
+	return nil
+}
+
+// classifyReport is one corpus's classification: the hit count of every
+// rule that claimed something, and a sample of the calls none did.
+type classifyReport struct {
+	Corpus        string           `json:"corpus"`
+	Calls         int              `json:"calls"`
+	ParseFailures int              `json:"parse_failures"`
+	Unknown       int              `json:"unknown"`
+	CallRules     []ruleHits       `json:"call_rules"`
+	InjectedRules []ruleHits       `json:"injected_rules"`
+	Unknowns      []unknownCallRow `json:"unknowns,omitempty"`
+}
+
+type ruleHits struct {
+	Profile string `json:"profile"`
+	Rule    string `json:"rule"`
+	Kind    string `json:"kind"`
+	Hits    int    `json:"hits"`
+}
+
+// unknownCallRow carries the tells a new rule would match on.
+type unknownCallRow struct {
+	RawTurnID int64  `json:"raw_turn_id"`
+	HarnessID string `json:"harness_id"`
+	Model     string `json:"model"`
+	MaxTokens *int   `json:"max_tokens,omitempty"`
+	Tools     int    `json:"tools"`
+	Stream    bool   `json:"stream"`
+	System    string `json:"system"`
+	Last      string `json:"last_message"`
+}
+
+// unknownSnippetLen bounds the prompt text shown per unknown call.
+const unknownSnippetLen = 120
+
+func classifyCorpusFile(profiles *derive.HarnessProfiles, path string, maxUnknowns int) (*classifyReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	providers := make(map[string]provider.Provider)
+	for _, name := range provider.SupportedProviders() {
+		prov, err := provider.New(name)
+		if err != nil {
+			return nil, fmt.Errorf("create provider %s: %w", name, err)
+		}
+		providers[name] = prov
+	}
+
+	report := &classifyReport{Corpus: path}
+	callHits := map[derive.RuleMatch]int{}
+	injectedHits := map[derive.RuleMatch]int{}
+	err = derive.ReadCorpus(f, func(rec *storage.RawTurnRecord) error {
+		if rec.Source == storage.RawTurnSourceTranscript {
+			return nil
+		}
+		prov, ok := providers[rec.Provider]
+		if !ok {
+			report.ParseFailures++
+			return nil
+		}
+		req, err := prov.ParseRequest(rec.RawRequest)
+		if err != nil {
+			report.ParseFailures++
+			return nil
+		}
+		var resp llm.ChatResponse
+		if len(rec.Response) > 0 {
+			if err := json.Unmarshal(rec.Response, &resp); err != nil {
+				report.ParseFailures++
+				return nil
+			}
+		}
+		report.Calls++
+
+		match := profiles.MatchCall(req, &resp)
+		for _, msg := range req.Messages {
+			if injected := profiles.MatchInjected(msg); injected.Kind != "" {
+				injectedHits[injected]++
+			}
+		}
+		if match.Profile != "" {
+			callHits[match]++
+			return nil
+		}
+		report.Unknown++
+		if len(report.Unknowns) < maxUnknowns {
+			report.Unknowns = append(report.Unknowns, unknownCall(rec, req))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.CallRules = sortedHits(callHits)
+	report.InjectedRules = sortedHits(injectedHits)
+	return report, nil
+}
+
+func unknownCall(rec *storage.RawTurnRecord, req *llm.ChatRequest) unknownCallRow {
+	row := unknownCallRow{
+		RawTurnID: rec.ID,
+		HarnessID: rec.HarnessID,
+		Model:     req.Model,
+		MaxTokens: req.MaxTokens,
+		Tools:     len(req.Tools),
+		Stream:    req.Stream != nil && *req.Stream,
+		System:    snippet(req.System),
+	}
+	if n := len(req.Messages); n > 0 {
+		var text strings.Builder
+		for _, b := range req.Messages[n-1].Content {
+			text.WriteString(b.Text)
+		}
+		row.Last = snippet(text.String())
+	}
+	return row
+}
+
+func snippet(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > unknownSnippetLen {
+		return string(r[:unknownSnippetLen]) + "…"
+	}
+	return s
+}
+
+func sortedHits(hits map[derive.RuleMatch]int) []ruleHits {
+	out := make([]ruleHits, 0, len(hits))
+	for m, n := range hits {
+		out = append(out, ruleHits{Profile: m.Profile, Rule: m.Rule, Kind: m.Kind, Hits: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Hits != out[j].Hits {
+			return out[i].Hits > out[j].Hits
+		}
+		if out[i].Profile != out[j].Profile {
+			return out[i].Profile < out[j].Profile
+		}
+		return out[i].Rule < out[j].Rule
+	})
+	return out
+}
+
+func writeClassifyReport(out io.Writer, r *classifyReport) {
+	mark := "✓"
+	if r.Unknown > 0 {
+		mark = "✘"
+	}
+	fmt.Fprintf(out, "%s %s: %d call(s), %d unknown", mark, r.Corpus, r.Calls, r.Unknown)
+	if r.ParseFailures > 0 {
+		fmt.Fprintf(out, ", %d unparseable", r.ParseFailures)
+	}
+	fmt.Fprintln(out)
+
+	for _, h := range r.CallRules {
+		fmt.Fprintf(out, "    %6d  %s/%s → %s\n", h.Hits, h.Profile, h.Rule, h.Kind)
+	}
+	if len(r.InjectedRules) > 0 {
+		fmt.Fprintln(out, "  injected messages:")
+		for _, h := range r.InjectedRules {
+			fmt.Fprintf(out, "    %6d  %s/%s → %s\n", h.Hits, h.Profile, h.Rule, h.Kind)
+		}
+	}
+	if len(r.Unknowns) > 0 {
+		fmt.Fprintln(out, "  unknown calls:")
+		for _, u := range r.Unknowns {
+			maxTokens := "unset"
+			if u.MaxTokens != nil {
+				maxTokens = strconv.Itoa(*u.MaxTokens)
+			}
+			fmt.Fprintf(out, "    raw_turn %d  harness=%s model=%s max_tokens=%s tools=%d stream=%t\n",
+				u.RawTurnID, u.HarnessID, u.Model, maxTokens, u.Tools, u.Stream)
+			fmt.Fprintf(out, "      system: %q\n", u.System)
+			fmt.Fprintf(out, "      last:   %q\n", u.Last)
+		}
+		if r.Unknown > len(r.Unknowns) {
+			fmt.Fprintf(out, "    … %d more\n", r.Unknown-len(r.Unknowns))
+		}
+	}
+}
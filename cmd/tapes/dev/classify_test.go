@@ -0,0 +1,48 @@
+package devcmder
+
+import (
+	"path/filepath"
+	"runtime"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/derive"
+)
+
+var _ = Describe("classify", func() {
+	codexCorpus := func() string {
+		_, file, _, ok := runtime.Caller(0)
+		Expect(ok).To(BeTrue())
+		return filepath.Join(filepath.Dir(file), "..", "..", "..", "pkg", "seed", "corpus", "corpus-codex-delta.jsonl.gz")
+	}
+
+	It("attributes every call in a known-harness corpus to a built-in rule", func() {
+		profiles, err := derive.BuiltinHarnessProfiles()
+		Expect(err).NotTo(HaveOccurred())
+
+		report, err := classifyCorpusFile(profiles, codexCorpus(), 5)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Calls).To(BeNumerically(">", 0))
+		Expect(report.Unknown).To(BeZero())
+		Expect(report.CallRules).To(ContainElement(ruleHits{
+			Profile: "generic", Rule: "spine-tool-routing", Kind: derive.KindMain, Hits: report.Calls,
+		}))
+	})
+
+	It("lists the calls no rule claims, with the tells a rule would match on", func() {
+		probe, err := derive.ParseHarnessProfile([]byte("name = \"narrow\"\n[[call]]\nkind = \"offshoot:probe\"\nmax_tokens = 1\n"), ".toml")
+		Expect(err).NotTo(HaveOccurred())
+		// Without the generic spine rules nothing in the corpus is claimed.
+		profiles, err := derive.NewHarnessProfiles(*probe)
+		Expect(err).NotTo(HaveOccurred())
+
+		report, err := classifyCorpusFile(profiles, codexCorpus(), 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Unknown).To(Equal(report.Calls))
+		Expect(report.CallRules).To(BeEmpty())
+		Expect(report.Unknowns).To(HaveLen(2))
+		Expect(report.Unknowns[0].Stream).To(BeTrue())
+		Expect(report.Unknowns[0].Model).NotTo(BeEmpty())
+	})
+})
@@ -15,6 +15,9 @@ dump-corpus exports raw_turns back into corpus files (the inverse of the
 fixture replay), and rederive rebuilds the projection from raw (the
 direct-call form of POST /v1/admin/derive/run).
 
+classify replays corpus files through the call classifier and reports
+which harness-profile rules claimed each call, and which calls none did.
+
 openapi compiles a published contract from the route registrations. The
 servers publish the same document at their own /openapi; this one adds the
 per-field prose a deployed binary cannot read, because the comments it
@@ -30,6 +33,7 @@ func NewDevCmd() *cobra.Command {
 	cmd.AddCommand(newTraceFixturesCmd())
 	cmd.AddCommand(newDumpCorpusCmd())
 	cmd.AddCommand(newCheckInvariantsCmd())
+	cmd.AddCommand(newClassifyCmd())
 	cmd.AddCommand(newCheckOpenAPICmd())
 	cmd.AddCommand(newOpenAPICmd())
 	cmd.AddCommand(newRederiveCmd())
@@ -5,19 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"maps"
 	"os"
 	"path/filepath"
-	"sort"
-	"time"
 
-	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 
 	"github.com/papercomputeco/tapes/api"
+	"github.com/papercomputeco/tapes/api/projection"
 	"github.com/papercomputeco/tapes/pkg/derive"
-	"github.com/papercomputeco/tapes/pkg/llm"
-	"github.com/papercomputeco/tapes/pkg/sessions"
 	"github.com/papercomputeco/tapes/pkg/storage"
 )
 
@@ -136,54 +131,24 @@ func buildFixtureArtifacts(path string) ([]fixtureArtifact, corpusSummary, error
 		return nil, corpusSummary{}, errors.New("corpus has no wire rows")
 	}
 
-	set, err := derive.BuildDerivedSet(wire, "")
-	if err != nil {
-		return nil, corpusSummary{}, fmt.Errorf("derive: %w", err)
-	}
-	files := make([]*derive.TranscriptFile, 0, len(transcriptRows))
-	for i := range transcriptRows {
-		file, err := derive.ParseTranscriptFile(&transcriptRows[i])
-		if err != nil {
-			return nil, corpusSummary{}, fmt.Errorf("parse transcript row %d: %w", transcriptRows[i].ID, err)
-		}
-		files = append(files, file)
-	}
-	derive.ReconcileTranscripts(set, files)
-	spanSet := derive.EmitSpans(set)
-
-	key, err := singleSessionKey(spanSet)
+	rendered, err := projection.Build(wire, transcriptRows)
 	if err != nil {
 		return nil, corpusSummary{}, err
 	}
-	sessionID := fixtureSessionID(key)
-
-	turns, spans, links := recordsFromSpanSet(spanSet, sessionID)
-	session := foldSessionItem(key, sessionID, set, wire, transcriptRows, turns)
-	session.Rollup.ModelUsage = modelUsageItems(spanSet.ModelUsage[key])
-	// Mirror the deriver's session rollups the handler folds onto the
-	// session record, so fixtures match a re-derived session. Pinned
-	// []/{} default keeps an empty session's shape uniform.
-	session.Rollup.Tasks = []api.TreeTask{}
-	session.Rollup.KindCounts = map[string]int{}
-	if tasks := spanSet.Tasks[key]; len(tasks) > 0 {
-		if b, err := json.Marshal(tasks); err == nil {
-			_ = json.Unmarshal(b, &session.Rollup.Tasks)
-		}
-	}
-	if kc := spanSet.KindCounts[key]; len(kc) > 0 {
-		session.Rollup.KindCounts = kc
+	// Corpus dumps are per-session by construction.
+	if len(rendered) != 1 {
+		return nil, corpusSummary{}, fmt.Errorf("corpus derives to %d sessions, want exactly 1", len(rendered))
 	}
-	// derived_status is a deriver output now (Phase 1d), so the fixture
-	// reflects what a re-derive computes rather than a hard-coded value.
-	session.Rollup.Status = spanSet.Status[key].DerivedStatus
+	session := rendered[0]
+	key, turns, spans, links := session.Key, session.Turns, session.Spans, session.Links
 
 	short := key.HarnessSessionID
 	if len(short) > 8 {
 		short = short[:8]
 	}
 
-	full := api.BuildSessionTraces(session, turns, spans, links, api.PayloadFull)
-	slim := api.BuildSessionTraces(session, turns, spans, links, api.PayloadPreview)
+	full := session.Traces(api.PayloadFull)
+	slim := session.Traces(api.PayloadPreview)
 
 	summaries := make([]storage.TraceSummaryRecord, 0, len(turns))
 	spansByTrace := map[string][]storage.SpanRecord{}
@@ -201,7 +166,7 @@ func buildFixtureArtifacts(path string) ([]fixtureArtifact, corpusSummary, error
 	details := map[string]api.TraceDetail{}
 	for _, turn := range turns {
 		details[turn.TraceID] = api.BuildTraceDetail(
-			turn, spansByTrace[turn.TraceID], linksTouching(links, turn.TraceID), api.PayloadPreview)
+			turn, spansByTrace[turn.TraceID], projection.LinksTouching(links, turn.TraceID), api.PayloadPreview)
 	}
 
 	artifacts := []fixtureArtifact{
@@ -219,279 +184,6 @@ func buildFixtureArtifacts(path string) ([]fixtureArtifact, corpusSummary, error
 	return artifacts, summary, nil
 }
 
-// singleSessionKey asserts the corpus derives to exactly one session —
-// corpus dumps are per-session by construction.
-func singleSessionKey(spanSet *derive.SpanSet) (derive.SessionKey, error) {
-	keys := map[derive.SessionKey]bool{}
-	for _, turn := range spanSet.Turns {
-		keys[turn.Session] = true
-	}
-	if len(keys) != 1 {
-		return derive.SessionKey{}, fmt.Errorf("corpus derives to %d sessions, want exactly 1", len(keys))
-	}
-	for key := range keys {
-		return key, nil
-	}
-	return derive.SessionKey{}, errors.New("corpus emitted no traces")
-}
-
-// fixtureSessionID mints the session UUID as a pure function of the
-// harness identity, so regenerating fixtures never churns ids.
-func fixtureSessionID(key derive.SessionKey) string {
-	name := "https://tapes.papercompute.com/fixtures/" + key.HarnessID + "/" + key.HarnessSessionID
-	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(name)).String()
-}
-
-// recordsFromSpanSet converts the emitter's output into the storage
-// read records the API renders. The field mapping is the in-memory
-// twin of writeSpanSet + the postgres read path (pkg/storage/postgres/
-// spans.go); ordering matches the read queries' ORDER BY clauses so
-// fixtures and live responses agree positionally.
-func recordsFromSpanSet(spanSet *derive.SpanSet, sessionID string) ([]storage.SpanTurnRecord, []storage.SpanRecord, []storage.SpanLinkRecord) {
-	turns := make([]storage.SpanTurnRecord, 0, len(spanSet.Turns))
-	spans := make([]storage.SpanRecord, 0, len(spanSet.Turns))
-	links := make([]storage.SpanLinkRecord, 0, len(spanSet.Links))
-
-	for _, turn := range spanSet.Turns {
-		rec := storage.SpanTurnRecord{
-			TraceID:             turn.TraceID,
-			SessionID:           sessionID,
-			UserPrompt:          turn.UserPrompt,
-			ResponsePreview:     turn.ResponsePreview,
-			Synthetic:           turn.Synthetic,
-			Source:              turn.Source,
-			Status:              "ok",
-			StartedAt:           turn.StartedAt.UTC(),
-			DurationNS:          turn.EndedAt.Sub(turn.StartedAt).Nanoseconds(),
-			TotalInputTokens:    turn.TotalInputTokens,
-			TotalOutputTokens:   turn.TotalOutputTokens,
-			MainInputTokens:     turn.MainInputTokens,
-			MainOutputTokens:    turn.MainOutputTokens,
-			CacheReadTokens:     turn.CacheReadTokens,
-			CacheCreationTokens: turn.CacheCreationTokens,
-			TotalCostUSD:        turn.TotalCostUSD,
-		}
-		if !turn.EndedAt.IsZero() {
-			ended := turn.EndedAt.UTC()
-			rec.EndedAt = &ended
-		}
-		turns = append(turns, rec)
-
-		for _, s := range turn.Spans {
-			input, err := blocksJSON(s.Input)
-			if err != nil {
-				panic(fmt.Sprintf("marshal span %s input: %v", s.SpanID, err))
-			}
-			output, err := blocksJSON(s.Output)
-			if err != nil {
-				panic(fmt.Sprintf("marshal span %s output: %v", s.SpanID, err))
-			}
-			var usage json.RawMessage
-			if s.Usage != nil {
-				usage, err = json.Marshal(s.Usage)
-				if err != nil {
-					panic(fmt.Sprintf("marshal span %s usage: %v", s.SpanID, err))
-				}
-			}
-			var verdict json.RawMessage
-			if s.Verdict != nil {
-				verdict, err = json.Marshal(s.Verdict)
-				if err != nil {
-					panic(fmt.Sprintf("marshal span %s verdict: %v", s.SpanID, err))
-				}
-			}
-			spans = append(spans, storage.SpanRecord{
-				TraceID:      turn.TraceID,
-				SpanID:       s.SpanID,
-				ParentSpanID: s.ParentSpanID,
-				Kind:         s.Kind,
-				Name:         s.Name,
-				Status:       s.Status,
-				CallKind:     s.CallKind,
-				ThreadID:     s.ThreadID,
-				Model:        s.Model,
-				StopReason:   s.StopReason,
-				StartedAt:    s.StartedAt.UTC(),
-				DurationNS:   s.DurationNS,
-				Seq:          s.Seq,
-				Input:        input,
-				Output:       output,
-				Usage:        usage,
-				RawTurnID:    s.RawTurnID,
-				NodeHash:     s.NodeHash,
-				Verdict:      verdict,
-			})
-		}
-		for _, l := range turn.Links {
-			links = append(links, linkRecord(l))
-		}
-	}
-	for _, l := range spanSet.Links {
-		links = append(links, linkRecord(l))
-	}
-
-	// Read-path order: turns by (started_at, trace_id), spans by
-	// (trace_id, seq).
-	sort.SliceStable(turns, func(i, j int) bool {
-		if !turns[i].StartedAt.Equal(turns[j].StartedAt) {
-			return turns[i].StartedAt.Before(turns[j].StartedAt)
-		}
-		return turns[i].TraceID < turns[j].TraceID
-	})
-	sort.SliceStable(spans, func(i, j int) bool {
-		if spans[i].TraceID != spans[j].TraceID {
-			return spans[i].TraceID < spans[j].TraceID
-		}
-		return spans[i].Seq < spans[j].Seq
-	})
-	return turns, spans, links
-}
-
-func linkRecord(l *derive.SpanLink) storage.SpanLinkRecord {
-	return storage.SpanLinkRecord{
-		FromTraceID: l.FromTraceID,
-		FromSpanID:  l.FromSpanID,
-		FromIO:      l.FromIO,
-		ToTraceID:   l.ToTraceID,
-		ToSpanID:    l.ToSpanID,
-		ToIO:        l.ToIO,
-		Kind:        l.Kind,
-	}
-}
-
-// blocksJSON mirrors the storage layer's contentJSON: empty payloads
-// stay null, not [].
-func blocksJSON(blocks []llm.ContentBlock) (json.RawMessage, error) {
-	if len(blocks) == 0 {
-		return nil, nil
-	}
-	return json.Marshal(blocks)
-}
-
-// linksTouching returns the links with either end on the trace — the
-// per-trace read query's WHERE clause.
-func linksTouching(links []storage.SpanLinkRecord, traceID string) []storage.SpanLinkRecord {
-	var out []storage.SpanLinkRecord
-	for _, l := range links {
-		if l.FromTraceID == traceID || l.ToTraceID == traceID {
-			out = append(out, l)
-		}
-	}
-	return out
-}
-
-// foldSessionItem assembles the embedded session stanza from the
-// corpus: identity and metadata fold from the ingest envelopes
-// exactly as ingest applies them (last-write-wins per key), the title
-// from the deriver's fold, counters from the span layer.
-func foldSessionItem(
-	key derive.SessionKey,
-	sessionID string,
-	set *derive.DerivedSet,
-	wire, transcriptRows []storage.RawTurnRecord,
-	turns []storage.SpanTurnRecord,
-) api.SessionItem {
-	item := api.SessionItem{
-		ID:               sessionID,
-		HarnessID:        key.HarnessID,
-		HarnessSessionID: key.HarnessSessionID,
-	}
-
-	var first, last time.Time
-	for _, rows := range [][]storage.RawTurnRecord{wire, transcriptRows} {
-		for _, r := range rows {
-			t := r.ReceivedAt.UTC()
-			if first.IsZero() || t.Before(first) {
-				first = t
-			}
-			if t.After(last) {
-				last = t
-			}
-		}
-	}
-	item.StartedAt = first
-	item.LastSeenAt = last
-	if !last.IsZero() {
-		ended := last
-		item.EndedAt = &ended
-	}
-
-	meta := map[string]any{}
-	var envelopeName string
-	for _, r := range wire {
-		if len(r.SessionEnvelope) == 0 {
-			continue
-		}
-		var env sessions.IngestEnvelope
-		if err := json.Unmarshal(r.SessionEnvelope, &env); err != nil {
-			continue
-		}
-		if env.Cwd != "" {
-			item.Cwd = env.Cwd
-		}
-		if env.HarnessVersion != "" {
-			item.HarnessVersion = env.HarnessVersion
-		}
-		if env.Name != "" {
-			envelopeName = env.Name
-		}
-		if len(env.HarnessMetadata) > 0 {
-			var m map[string]any
-			if err := json.Unmarshal(env.HarnessMetadata, &m); err == nil {
-				maps.Copy(meta, m)
-			}
-		}
-	}
-	if len(meta) > 0 {
-		item.HarnessMetadata = meta
-	}
-
-	// Display title: the folded title-gen output, envelope name as
-	// fallback — same precedence as the sessions read path.
-	if title, ok := set.SessionTitles[key]; ok && title != "" {
-		item.Rollup.Title = title
-	} else {
-		item.Rollup.Title = envelopeName
-	}
-
-	// Counters roll up from the span layer (the v2 direction; ingest
-	// counters retire). turn_count is user-visible turns.
-	item.Rollup.TurnCount = len(turns)
-	for _, turn := range turns {
-		item.Rollup.Usage.InputTokens += turn.TotalInputTokens
-		item.Rollup.Usage.OutputTokens += turn.TotalOutputTokens
-		item.Rollup.Usage.CostUSD += turn.TotalCostUSD
-	}
-
-	for _, turn := range turns {
-		if turn.Synthetic == "" && turn.UserPrompt != "" {
-			item.Rollup.Preview = turn.UserPrompt
-			break
-		}
-	}
-	return item
-}
-
-// modelUsageItems maps the deriver's per-model breakdown to the API
-// shape the session detail carries, so fixtures mirror the live
-// response field-for-field.
-func modelUsageItems(in []derive.ModelUsage) []api.ModelUsage {
-	if len(in) == 0 {
-		return nil
-	}
-	out := make([]api.ModelUsage, len(in))
-	for i, mu := range in {
-		out[i] = api.ModelUsage{
-			Model:        mu.Model,
-			Calls:        mu.Calls,
-			InputTokens:  mu.InputTokens,
-			OutputTokens: mu.OutputTokens,
-			CostUsd:      mu.CostUSD,
-		}
-	}
-	return out
-}
-
 // marshalFixture encodes a fixture value the way the fixtures land on
 // disk: indented, no HTML escaping — payload text is full of <tags>, and
 // fixtures get read by humans in review. Shared so the idempotency gate
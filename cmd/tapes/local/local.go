@@ -183,6 +183,9 @@ func (c *localCommander) runUp() error {
 	if err := cliui.Step(os.Stdout, "Waiting for Postgres", waitForPostgresReady); err != nil {
 		return err
 	}
+	if err := cliui.Step(os.Stdout, "Enabling pgvector", enablePgvector); err != nil {
+		return err
+	}
 
 	ollamaURL := nativeURL
 	switch {
@@ -471,6 +474,17 @@ func waitForPostgresReady() error {
 	return fmt.Errorf("postgres container %q did not become ready within 30s", postgresContainer)
 }
 
+// enablePgvector installs the vector extension in the local database.
+// The span embedding migration creates its tables only where the
+// extension is already installed, so it must precede the first tapes
+// serve for semantic search to work out of the box.
+func enablePgvector() error {
+	_, err := dockerOutput("exec", postgresContainer,
+		"psql", "-U", postgresUser, "-d", postgresDB, "-v", "ON_ERROR_STOP=1",
+		"-c", "CREATE EXTENSION IF NOT EXISTS vector")
+	return err
+}
+
 func waitForOllamaReady() error {
 	deadline := time.Now().Add(60 * time.Second)
 	for time.Now().Before(deadline) {
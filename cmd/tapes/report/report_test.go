@@ -0,0 +1,94 @@
+package reportcmder_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	reportcmder "github.com/papercomputeco/tapes/cmd/tapes/report"
+	"github.com/papercomputeco/tapes/pkg/derive"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+// chatTurn is one captured Anthropic call opening a session. stream and
+// tools make it classify as the conversation spine, as a harness call does.
+func chatTurn(id int64, session, prompt, reply string) storage.RawTurnRecord {
+	req, err := json.Marshal(map[string]any{
+		"model": "claude-sonnet-4-5", "max_tokens": 1024, "stream": true,
+		"tools": []map[string]any{{
+			"name": "read_file", "description": "Read a file",
+			"input_schema": map[string]any{"type": "object"},
+		}},
+		"messages": []map[string]any{{"role": "user", "content": prompt}},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	resp, err := json.Marshal(map[string]any{
+		"done": true, "model": "claude-sonnet-4-5", "stop_reason": "end_turn",
+		"usage":   map[string]any{"prompt_tokens": 100, "completion_tokens": 20, "total_tokens": 120},
+		"message": map[string]any{"role": "assistant", "content": []map[string]any{{"type": "text", "text": reply}}},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	return storage.RawTurnRecord{
+		ID: id, Source: storage.RawTurnSourceWire, Provider: "anthropic",
+		HarnessID: "claude", HarnessSessionID: session,
+		RequestID: fmt.Sprintf("req-%d", id), RawRequest: req, Response: resp,
+		ReceivedAt: time.Unix(1700000000+id, 0).UTC(),
+	}
+}
+
+var _ = Describe("tapes report", func() {
+	var dir, corpus string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		corpus = filepath.Join(dir, "corpus.jsonl.gz")
+
+		var buf bytes.Buffer
+		Expect(derive.WriteCorpus(&buf, []storage.RawTurnRecord{
+			chatTurn(1, "session-a", "what is a tape?", "a recording"),
+			chatTurn(2, "session-b", "and a span?", "one step of a trace"),
+		})).To(Succeed())
+		Expect(os.WriteFile(corpus, buf.Bytes(), 0o600)).To(Succeed())
+	})
+
+	execute := func(args ...string) (string, string, error) {
+		cmd := reportcmder.NewReportCmd()
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return stdout.String(), stderr.String(), err
+	}
+
+	It("renders one session of a corpus by harness session id", func() {
+		out := filepath.Join(dir, "session.html")
+		_, summary, err := execute("session-b", "--corpus", corpus, "-o", out)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(summary).To(ContainSubstring("wrote " + out))
+
+		html, err := os.ReadFile(out)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(html)).To(ContainSubstring("and a span?"))
+		Expect(string(html)).To(ContainSubstring("one step of a trace"))
+		Expect(string(html)).NotTo(ContainSubstring("what is a tape?"))
+	})
+
+	It("writes to stdout by default", func() {
+		stdout, _, err := execute("session-a", "--corpus", corpus)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stdout).To(HavePrefix("<!doctype html>"))
+		Expect(stdout).To(ContainSubstring("what is a tape?"))
+	})
+
+	It("reports a session the corpus does not hold", func() {
+		_, _, err := execute("session-z", "--corpus", corpus)
+		Expect(err).To(MatchError(ContainSubstring("session session-z not found")))
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"path/filepath"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -13,13 +14,19 @@ import (
 	"github.com/papercomputeco/tapes/pkg/cassette"
 	"github.com/papercomputeco/tapes/pkg/config"
 	deriveworker "github.com/papercomputeco/tapes/pkg/derive/worker"
+	"github.com/papercomputeco/tapes/pkg/dotdir"
+	"github.com/papercomputeco/tapes/pkg/embedding"
 	"github.com/papercomputeco/tapes/pkg/git"
+	"github.com/papercomputeco/tapes/pkg/oidc"
+	"github.com/papercomputeco/tapes/pkg/retention"
+	"github.com/papercomputeco/tapes/pkg/spanembed"
 	"github.com/papercomputeco/tapes/pkg/storage/postgres"
 	"github.com/papercomputeco/tapes/proxy"
 )
 
 // Stack is the set of long-running services a tapes install is made of: the
-// capture proxy, the API server, the ingest sidecar, and the derive worker.
+// capture proxy, the API server, the ingest sidecar, the derive worker, the
+// embed worker, and — when rules are configured — the retention worker.
 //
 // It is a type rather than the body of one command so cassette registry setup
 // stays separate from service construction and lifecycle.
@@ -29,9 +36,12 @@ type Stack struct {
 	APIListen    string
 	IngestListen string
 
-	// APIWebUI serves the minimal browser UI at the API server's root.
+	// APIWebUI serves the session browser UI at the API server's root.
 	APIWebUI bool
 
+	// APIAuth enforces API token auth on the API server.
+	APIAuth bool
+
 	// Upstream, ProviderType, and Project configure capture.
 	Upstream     string
 	ProviderType string
@@ -40,6 +50,15 @@ type Stack struct {
 	// PostgresDSN is the capture and derived store.
 	PostgresDSN string
 
+	// MultiTenant scopes capture and reads to the caller's org.
+	MultiTenant bool
+
+	// ProxySpool write-ahead spools captures to SpoolDir (spool/ under the
+	// .tapes directory, resolved by Resolve) so none are lost to a restart
+	// or a database outage.
+	ProxySpool bool
+	SpoolDir   string
+
 	// CassetteSources are exact full OpenAPI document URLs transferred to the
 	// API server's lifetime-owned runtime.
 	CassetteSources []string
@@ -49,6 +68,24 @@ type Stack struct {
 	// actually serves the contract and therefore owns the answer.
 	ContractVersions []cassette.ContractVersion
 
+	// OIDC is the [oidc] config section. With no jwks_url the services
+	// verify no JWTs.
+	OIDC config.OIDCConfig
+
+	// Retention is the [retention] config section. With no rules the
+	// retention worker does not start.
+	Retention config.RetentionConfig
+
+	// Embedding is the [embedding] config section: the model the embed
+	// worker writes span vectors with and the API server embeds search
+	// queries with. ConfigDir locates a stored provider key.
+	Embedding config.EmbeddingConfig
+	ConfigDir string
+
+	// EmbedSpans runs the embed worker in-process. Off leaves span vectors
+	// to a separate `tapes serve embed-worker`, or to none at all.
+	EmbedSpans bool
+
 	// Started, when set, is called once every service has been launched. Cassette
 	// serving uses it to begin aggregation after the API server starts.
 	//
@@ -66,12 +103,19 @@ func (stack *Stack) AddFlags(cmd *cobra.Command, flags config.FlagSet) {
 	config.AddStringFlag(cmd, flags, config.FlagProxyListen, &stack.ProxyListen)
 	config.AddStringFlag(cmd, flags, config.FlagAPIListen, &stack.APIListen)
 	config.AddBoolFlag(cmd, flags, config.FlagAPIWebUI, &stack.APIWebUI)
+	config.AddBoolFlag(cmd, flags, config.FlagAPIAuth, &stack.APIAuth)
 	config.AddStringFlag(cmd, flags, config.FlagIngestListen, &stack.IngestListen)
 	config.AddStringFlag(cmd, flags, config.FlagUpstream, &stack.Upstream)
 	config.AddStringFlag(cmd, flags, config.FlagProvider, &stack.ProviderType)
 	config.AddStringFlag(cmd, flags, config.FlagProject, &stack.Project)
 	config.AddStringFlag(cmd, flags, config.FlagPostgres, &stack.PostgresDSN)
+	config.AddBoolFlag(cmd, flags, config.FlagMultiTenant, &stack.MultiTenant)
+	config.AddBoolFlag(cmd, flags, config.FlagProxySpool, &stack.ProxySpool)
 	config.AddStringSliceFlag(cmd, flags, config.FlagCassettes, &stack.CassetteSources)
+	config.AddStringFlag(cmd, flags, config.FlagEmbeddingProvider, &stack.Embedding.Provider)
+	config.AddStringFlag(cmd, flags, config.FlagEmbeddingTarget, &stack.Embedding.Target)
+	config.AddStringFlag(cmd, flags, config.FlagEmbeddingModel, &stack.Embedding.Model)
+	cmd.Flags().BoolVar(&stack.EmbedSpans, "embed-spans", true, "Run the embed worker in-process to populate semantic search")
 }
 
 // stackFlagKeys are the registered flags Resolve binds into viper's precedence
@@ -80,12 +124,18 @@ var stackFlagKeys = []string{
 	config.FlagProxyListen,
 	config.FlagAPIListen,
 	config.FlagAPIWebUI,
+	config.FlagAPIAuth,
 	config.FlagIngestListen,
 	config.FlagUpstream,
 	config.FlagProvider,
 	config.FlagPostgres,
+	config.FlagMultiTenant,
 	config.FlagProject,
+	config.FlagProxySpool,
 	config.FlagCassettes,
+	config.FlagEmbeddingProvider,
+	config.FlagEmbeddingTarget,
+	config.FlagEmbeddingModel,
 }
 
 // Resolve fills the stack from flags, environment, and config file. Call it
@@ -108,12 +158,49 @@ func (stack *Stack) Resolve(cmd *cobra.Command, flags config.FlagSet) error {
 	}
 
 	stack.PostgresDSN = v.GetString("storage.postgres_dsn")
+	stack.MultiTenant = v.GetBool("storage.multi_tenant")
 	stack.ProxyListen = v.GetString("proxy.listen")
 	stack.APIListen = v.GetString("api.listen")
 	stack.APIWebUI = v.GetBool("api.web_ui")
+	stack.APIAuth = v.GetBool("api.auth")
 	stack.IngestListen = v.GetString("ingest.listen")
 	stack.Upstream = v.GetString("proxy.upstream")
 	stack.ProviderType = v.GetString("proxy.provider")
+	stack.ProxySpool = v.GetBool("proxy.spool")
+
+	if stack.ProxySpool {
+		dir, err := dotdir.NewManager().Ensure(configDir)
+		if err != nil {
+			return fmt.Errorf("resolving spool directory: %w", err)
+		}
+		stack.SpoolDir = filepath.Join(dir, "spool")
+	}
+
+	if err := v.UnmarshalKey("oidc", &stack.OIDC); err != nil {
+		return fmt.Errorf("loading oidc config: %w", err)
+	}
+	if err := config.ValidateOIDCConfig(stack.OIDC); err != nil {
+		return err
+	}
+	if stack.MultiTenant {
+		if err := config.ValidateMultiTenantOIDC(stack.OIDC); err != nil {
+			return err
+		}
+	}
+
+	if err := v.UnmarshalKey("retention", &stack.Retention); err != nil {
+		return fmt.Errorf("loading retention rules: %w", err)
+	}
+	if err := config.ValidateRetentionConfig(stack.Retention); err != nil {
+		return err
+	}
+
+	stack.Embedding = config.EmbeddingConfig{
+		Provider: v.GetString("embedding.provider"),
+		Target:   v.GetString("embedding.target"),
+		Model:    v.GetString("embedding.model"),
+	}
+	stack.ConfigDir = configDir
 
 	stack.Project = v.GetString("proxy.project")
 	if stack.Project == "" {
@@ -134,6 +221,21 @@ func (stack *Stack) Run(ctx context.Context) error {
 	}
 	defer driver.Close()
 
+	// One verifier serves all three servers, so they share its key cache.
+	verifier, err := oidc.FromConfig(stack.OIDC)
+	if err != nil {
+		return err
+	}
+
+	// Embedding is optional in the stack: a section that cannot be
+	// satisfied (an openai provider with no key) disables semantic search
+	// rather than the whole install.
+	embedder, err := embedding.FromConfig(stack.Embedding, stack.ConfigDir)
+	if err != nil {
+		stack.Logger.Warn("semantic search disabled", "error", err.Error())
+		embedder = nil
+	}
+
 	// These constructors own worker pools whose lifecycle is closed explicitly
 	// below; neither constructor accepts an inherited context.
 	p, err := proxy.New(proxy.Config{ //nolint:contextcheck
@@ -141,6 +243,9 @@ func (stack *Stack) Run(ctx context.Context) error {
 		UpstreamURL:  stack.Upstream,
 		ProviderType: stack.ProviderType,
 		Project:      stack.Project,
+		Verifier:     verifier,
+		MultiTenant:  stack.MultiTenant,
+		SpoolDir:     stack.SpoolDir,
 	}, driver, stack.Logger)
 	if err != nil {
 		return fmt.Errorf("creating proxy: %w", err)
@@ -156,7 +261,12 @@ func (stack *Stack) Run(ctx context.Context) error {
 	apiServer, err := api.NewServer(api.Config{ //nolint:contextcheck // Fiber owns request contexts.
 		ListenAddr:       stack.APIListen,
 		EnableWebUI:      stack.APIWebUI,
+		RequireAuth:      stack.APIAuth,
+		Verifier:         verifier,
+		OwnSessionsOnly:  stack.OIDC.OwnSessionsOnly,
+		MultiTenant:      stack.MultiTenant,
 		ContractVersions: stack.ContractVersions,
+		Embedder:         embedder,
 	}, driver, stack.Logger)
 	if err != nil {
 		return fmt.Errorf("could not build new api server: %w", err)
@@ -176,8 +286,10 @@ func (stack *Stack) Run(ctx context.Context) error {
 	// not, the directive reads as unused and reds the lint gate on a tree
 	// that has not changed. Suppressing both keeps the gate deterministic.
 	ingestServer, err := ingest.New(ingest.Config{ //nolint:contextcheck,nolintlint
-		ListenAddr: stack.IngestListen,
-		Project:    stack.Project,
+		ListenAddr:  stack.IngestListen,
+		Project:     stack.Project,
+		Verifier:    verifier,
+		MultiTenant: stack.MultiTenant,
 	}, driver, stack.Logger)
 	if err != nil {
 		return fmt.Errorf("creating ingest server: %w", err)
@@ -200,7 +312,30 @@ func (stack *Stack) Run(ctx context.Context) error {
 	deriveW := deriveworker.NewWorker(deriveCfg, driver, stack.Logger)
 	stack.Logger.Info("starting derive worker (in-process)", "debounce", deriveCfg.Debounce)
 
-	errChan := make(chan error, 4)
+	// The embed worker runs in-process too, so semantic search works out
+	// of the box. It is best-effort here: with no embedding server or no
+	// pgvector it logs, backs off, and retries without taking capture
+	// down. Production runs it as its own process (`tapes serve
+	// embed-worker`), a failure domain apart from derivation.
+	var embedW *spanembed.Worker
+	if embedder != nil && stack.EmbedSpans {
+		embedW = spanembed.NewWorker(spanembed.Config{}, driver, embedder, stack.Logger)
+		stack.Logger.Info("starting embed worker (in-process)", "model", embedder.Model())
+	}
+
+	// Retention runs in-process only when rules are configured; without
+	// them raw turns are kept forever, as before.
+	var retentionW *retention.Worker
+	if len(stack.Retention.Rules) > 0 {
+		retentionCfg, err := retention.FromConfig(stack.Retention)
+		if err != nil {
+			return err
+		}
+		retentionW = retention.NewWorker(retentionCfg, driver, stack.Logger)
+		stack.Logger.Info("starting retention worker (in-process)", "rules", len(retentionCfg.Rules))
+	}
+
+	errChan := make(chan error, 5)
 
 	go func() {
 		if err := p.Run(); err != nil {
@@ -226,6 +361,22 @@ func (stack *Stack) Run(ctx context.Context) error {
 		}
 	}()
 
+	if embedW != nil {
+		go func() {
+			if err := embedW.Run(ctx); err != nil {
+				stack.Logger.Error("embed worker stopped", "error", err.Error())
+			}
+		}()
+	}
+
+	if retentionW != nil {
+		go func() {
+			if err := retentionW.Run(ctx); err != nil {
+				errChan <- fmt.Errorf("retention worker error: %w", err)
+			}
+		}()
+	}
+
 	if stack.Started != nil {
 		stack.Started(ctx, apiServer)
 	}
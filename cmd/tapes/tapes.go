@@ -11,15 +11,22 @@ import (
 
 	authcmder "github.com/papercomputeco/tapes/cmd/tapes/auth"
 	backfillcmder "github.com/papercomputeco/tapes/cmd/tapes/backfill"
+	comparecmder "github.com/papercomputeco/tapes/cmd/tapes/compare"
 	configcmder "github.com/papercomputeco/tapes/cmd/tapes/config"
+	derivecmder "github.com/papercomputeco/tapes/cmd/tapes/derive"
 	devcmder "github.com/papercomputeco/tapes/cmd/tapes/dev"
+	exportcmder "github.com/papercomputeco/tapes/cmd/tapes/export"
+	importcmder "github.com/papercomputeco/tapes/cmd/tapes/import"
 	initcmder "github.com/papercomputeco/tapes/cmd/tapes/init"
 	localcmder "github.com/papercomputeco/tapes/cmd/tapes/local"
+	pricingcmder "github.com/papercomputeco/tapes/cmd/tapes/pricing"
 	rawcmder "github.com/papercomputeco/tapes/cmd/tapes/raw"
+	reportcmder "github.com/papercomputeco/tapes/cmd/tapes/report"
 	servecmder "github.com/papercomputeco/tapes/cmd/tapes/serve"
 	statuscmder "github.com/papercomputeco/tapes/cmd/tapes/status"
 	versioncmder "github.com/papercomputeco/tapes/cmd/tapes/version"
 	"github.com/papercomputeco/tapes/pkg/config"
+	"github.com/papercomputeco/tapes/pkg/derive"
 	"github.com/papercomputeco/tapes/pkg/logger"
 	"github.com/papercomputeco/tapes/pkg/telemetry"
 	"github.com/papercomputeco/tapes/pkg/update"
@@ -52,6 +59,19 @@ Provision a local environment:
   tapes init                         Initialize a local .tapes directory
   tapes init --preset <preset|url>   Initialize with a provider preset or remote config
 
+Move history between deployments:
+  tapes export --sessions <id> --out <file>   Export sessions to a bundle
+  tapes export --since 30d --out <file>       Export recently active sessions
+  tapes import <file>                         Import a bundle and re-derive it
+
+Work offline, with no server:
+  tapes serve proxy --capture-file <file>     Capture turns to a corpus file
+  tapes derive --in <file> --out <file>       Derive a corpus file to sessions/traces/spans JSON
+
+Share and compare sessions:
+  tapes report <session-id> -o <file>         Render a session as a self-contained HTML report
+  tapes compare <session-a> <session-b>       Compare two runs of a task turn by turn
+
 Serve cassettes:
   Set cassettes = ["http://host/openapi"] or pass --cassettes, then run tapes serve
 
@@ -117,9 +137,15 @@ func NewTapesCmd() *cobra.Command {
 	cmd.AddCommand(devcmder.NewDevCmd())
 	cmd.AddCommand(authcmder.NewAuthCmd())
 	cmd.AddCommand(backfillcmder.NewBackfillCmd())
+	cmd.AddCommand(comparecmder.NewCompareCmd())
+	cmd.AddCommand(derivecmder.NewDeriveCmd())
+	cmd.AddCommand(exportcmder.NewExportCmd())
+	cmd.AddCommand(importcmder.NewImportCmd())
 	cmd.AddCommand(initcmder.NewInitCmd())
 	cmd.AddCommand(localcmder.NewLocalCmd())
+	cmd.AddCommand(pricingcmder.NewPricingCmd())
 	cmd.AddCommand(rawcmder.NewRawCmd())
+	cmd.AddCommand(reportcmder.NewReportCmd())
 	cmd.AddCommand(servecmder.NewServeCmd())
 	cmd.AddCommand(statuscmder.NewStatusCmd())
 	cmd.AddCommand(versioncmder.NewVersionCmd())
@@ -154,6 +180,17 @@ func preRun(cmd *cobra.Command, args []string) error {
 	cmd.SetContext(logger.WithSettings(cmd.Context(), settings))
 	slog.SetDefault(settings.New())
 
+	// Every command that derives — the services, import, derive, report,
+	// dev rederive — must classify with the same profiles, so they are
+	// installed here rather than per command.
+	if paths := v.GetStringSlice("derive.harness_profiles"); len(paths) > 0 {
+		profiles, err := derive.LoadHarnessProfiles(paths...)
+		if err != nil {
+			return fmt.Errorf("loading harness profiles: %w", err)
+		}
+		derive.UseHarnessProfiles(profiles)
+	}
+
 	if !v.GetBool("update.disabled") {
 		if msg := update.CheckForUpdate(utils.Version); msg != "" {
 			printUpdateNotice(os.Stderr, msg, logger.ColorEnabled(os.Stderr, settings.Color))
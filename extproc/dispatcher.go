@@ -319,6 +319,11 @@ type TurnMeta struct {
 	RequestBytes        int     `json:"request_bytes,omitempty"`
 	ResponseBytes       int     `json:"response_bytes,omitempty"`
 	ElapsedSeconds      float64 `json:"elapsed_seconds,omitempty"`
+
+	// Latency is the call's latency profile as this processor observed
+	// the response headers and body chunks arrive. The deriver copies it
+	// onto the llm span.
+	Latency *llm.Latency `json:"latency,omitempty"`
 }
 
 func (m TurnMeta) outcomeContext() OutcomeContext {
@@ -101,6 +101,10 @@ type streamState struct {
 	modelFamily            string
 	startedAt              time.Time
 
+	// latency times the response as its headers and body chunks arrive.
+	// Nil until the request headers start the clock.
+	latency *capture.LatencyObserver
+
 	// session is the parsed X-Tapes-* envelope from the inbound
 	// request. session.Present == false means no X-Tapes-* header
 	// arrived — the dispatcher omits the session block entirely
@@ -291,6 +295,7 @@ func (p *Processor) Process(stream extprocv3.ExternalProcessor_ProcessServer) er
 			st.statusCode = headers.StatusCode(v.ResponseHeaders)
 			st.contentType = headers.Get(v.ResponseHeaders, headers.ContentType)
 			st.contentEncoding = headers.Get(v.ResponseHeaders, headers.ContentEncoding)
+			st.latency.FirstByte()
 			resp = &extprocv3.ProcessingResponse{
 				Response: &extprocv3.ProcessingResponse_ResponseHeaders{
 					ResponseHeaders: &extprocv3.HeadersResponse{},
@@ -301,6 +306,7 @@ func (p *Processor) Process(stream extprocv3.ExternalProcessor_ProcessServer) er
 			if _, err := st.respBuf.Write(v.ResponseBody.GetBody()); err != nil {
 				slog.Warn("extproc: respBuf write error", "error", err)
 			}
+			_, _ = st.latency.Write(v.ResponseBody.GetBody())
 			resp = &extprocv3.ProcessingResponse{
 				Response: &extprocv3.ProcessingResponse_ResponseBody{
 					ResponseBody: &extprocv3.BodyResponse{},
@@ -333,6 +339,7 @@ func (p *Processor) Process(stream extprocv3.ExternalProcessor_ProcessServer) er
 
 func (p *Processor) onRequestHeaders(st *streamState, hdrs *extprocv3.HttpHeaders) {
 	st.startedAt = time.Now()
+	st.latency = capture.NewLatencyObserver(st.startedAt)
 	st.provider = p.resolveProvider(hdrs)
 	st.agentName = headers.Get(hdrs, headers.AgentName)
 	st.threadID = headers.ThreadID(hdrs)
@@ -674,6 +681,7 @@ func (p *Processor) dispatchTurn(ctx context.Context, st *streamState) {
 			RequestBytes:        len(rawReqBytes),
 			ResponseBytes:       len(respBytes),
 			ElapsedSeconds:      st.elapsedSeconds(),
+			Latency:             st.latency.Latency(outputTokens(chatResp)),
 		},
 		Session: buildSessionEnvelope(st),
 	}
@@ -703,6 +711,15 @@ func (p *Processor) dispatchTurn(ctx context.Context, st *streamState) {
 	p.dispatcher.Dispatch(ctx, envelope)
 }
 
+// outputTokens is the reduced response's output token count, 0 when the
+// reducer produced no usage.
+func outputTokens(resp *llm.ChatResponse) int {
+	if resp == nil || resp.Usage == nil {
+		return 0
+	}
+	return resp.Usage.CompletionTokens
+}
+
 // recordRawLane logs and meters the raw lane's pre-dispatch outcomes for one
 // turn. Both non-attaching outcomes change the fidelity the row lands with, so
 // neither is allowed to be silent. Attachment itself is deliberately NOT
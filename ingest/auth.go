@@ -0,0 +1,37 @@
+package ingest
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/papercomputeco/tapes/pkg/oidc"
+	oas "github.com/papercomputeco/tapes/pkg/tapesoapi"
+	"github.com/papercomputeco/tapes/pkg/tapesoapi/oasfiber"
+)
+
+// bearerAuthScheme is the security scheme the ingest routes name.
+const bearerAuthScheme = "bearerAuth"
+
+// declareAuth publishes the bearer scheme the ingest routes name. Like the
+// read API's, it is declared whether or not this deployment enforces it, so
+// the contract does not vary with configuration.
+func (s *Server) declareAuth() error {
+	return s.openapi.AddSecurityScheme(bearerAuthScheme, &oas.SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+		Description: "A JWT from the deployment's identity provider, verified in process against its " +
+			"JWKS. Enforced only when the deployment configures oidc.jwks_url; behind a gateway the " +
+			"gateway authenticates instead and stamps x-paper-auth-subject.",
+	}, oas.Provenance{Name: "ingest auth"})
+}
+
+// secured returns a router whose routes require a verified JWT when this
+// server has a verifier, and describe the requirement either way.
+func (s *Server) secured(router *oasfiber.Router) *oasfiber.Router {
+	var guard fiber.Handler
+	if s.config.Verifier != nil {
+		guard = oidc.Middleware(s.config.Verifier, fiber.HeaderAuthorization, s.logger)
+	}
+
+	return router.Secured(oas.SecurityRequirement{bearerAuthScheme: {}}, guard)
+}
@@ -0,0 +1,160 @@
+package ingest_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/ingest"
+	tapeslogger "github.com/papercomputeco/tapes/pkg/logger"
+	"github.com/papercomputeco/tapes/pkg/oidc"
+	"github.com/papercomputeco/tapes/pkg/oidc/oidctest"
+	"github.com/papercomputeco/tapes/pkg/sessions"
+)
+
+var _ = Describe("In-process JWT verification", func() {
+	const sessionID = "5b0c1f8e-7c7d-4d51-9a3c-2a1f3b9c4e10"
+
+	var (
+		issuer  *oidctest.Issuer
+		server  *ingest.Server
+		driver  *rawStoreDriver
+		baseURL string
+		client  *http.Client
+	)
+
+	BeforeEach(func() {
+		issuer = oidctest.NewIssuer()
+		DeferCleanup(issuer.Close)
+
+		verifier, err := oidc.NewVerifier(oidc.Config{JWKSURL: issuer.JWKSURL(), SubjectClaim: "email"})
+		Expect(err).NotTo(HaveOccurred())
+
+		driver = newRawStoreDriver()
+		server, err = ingest.New(ingest.Config{
+			ListenAddr: ":0",
+			Project:    "test-project",
+			Verifier:   verifier,
+		}, driver, tapeslogger.NewNoop())
+		Expect(err).NotTo(HaveOccurred())
+
+		ln, err := (&net.ListenConfig{}).Listen(context.Background(), "tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		go func() { _ = server.RunWithListener(ln) }()
+		DeferCleanup(server.Close)
+
+		baseURL = "http://" + ln.Addr().String()
+		client = &http.Client{Timeout: 5 * time.Second}
+	})
+
+	post := func(headers map[string]string) *http.Response {
+		body := mustJSON(ingest.TranscriptPayload{
+			Session: &sessions.IngestEnvelope{
+				AuthSubject:      "claimed-in-payload",
+				HarnessID:        "claude",
+				HarnessSessionID: sessionID,
+			},
+			Records: mustJSON([]map[string]string{{"type": "user", "uuid": "u-1"}}),
+		})
+		req, err := http.NewRequest(http.MethodPost, baseURL+"/v1/ingest/transcript", bytes.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := client.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(resp.Body.Close)
+		return resp
+	}
+
+	It("leaves /ping open", func() {
+		resp, err := client.Get(baseURL + "/ping")
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	})
+
+	It("rejects an ingest without a token", func() {
+		resp := post(nil)
+		Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+		Expect(resp.Header.Get("WWW-Authenticate")).To(ContainSubstring("Bearer"))
+	})
+
+	It("rejects a token the issuer did not sign", func() {
+		other := oidctest.NewIssuer()
+		defer other.Close()
+
+		resp := post(map[string]string{
+			"Authorization": "Bearer " + other.Token(map[string]any{"email": "mallory@example.com"}),
+		})
+		Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("stamps the verified subject over the payload and the gateway header", func() {
+		resp := post(map[string]string{
+			"Authorization":               "Bearer " + issuer.Token(map[string]any{"email": "ada@example.com"}),
+			ingest.HeaderPaperAuthSubject: "spoofed-header",
+		})
+		Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+
+		var envelope sessions.IngestEnvelope
+		Expect(json.Unmarshal(driver.lastRecord().SessionEnvelope, &envelope)).To(Succeed())
+		Expect(envelope.AuthSubject).To(Equal("ada@example.com"))
+	})
+})
+
+var _ = Describe("Multi-tenant ingest", func() {
+	const (
+		sessionID = "5b0c1f8e-7c7d-4d51-9a3c-2a1f3b9c4e11"
+		acme      = "11111111-1111-1111-1111-111111111111"
+	)
+
+	It("stores the turn under the verified org claim, not the one the payload asserts", func() {
+		issuer := oidctest.NewIssuer()
+		DeferCleanup(issuer.Close)
+		verifier, err := oidc.NewVerifier(oidc.Config{JWKSURL: issuer.JWKSURL(), OrgClaim: "org_id"})
+		Expect(err).NotTo(HaveOccurred())
+
+		driver := newRawStoreDriver()
+		server, err := ingest.New(ingest.Config{
+			ListenAddr:  ":0",
+			Project:     "test-project",
+			Verifier:    verifier,
+			MultiTenant: true,
+		}, driver, tapeslogger.NewNoop())
+		Expect(err).NotTo(HaveOccurred())
+		ln, err := (&net.ListenConfig{}).Listen(context.Background(), "tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		go func() { _ = server.RunWithListener(ln) }()
+		DeferCleanup(server.Close)
+
+		body := mustJSON(ingest.TranscriptPayload{
+			Session: &sessions.IngestEnvelope{
+				OrgID:            "9f9f9f9f-9f9f-4f9f-8f9f-9f9f9f9f9f9f",
+				HarnessID:        "claude",
+				HarnessSessionID: sessionID,
+			},
+			Records: mustJSON([]map[string]string{{"type": "user", "uuid": "u-1"}}),
+		})
+		req, err := http.NewRequest(http.MethodPost, "http://"+ln.Addr().String()+"/v1/ingest/transcript", bytes.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+issuer.Token(map[string]any{"sub": "ada", "org_id": acme}))
+		req.Header.Set(ingest.HeaderPaperAuthOrgID, "9f9f9f9f-9f9f-4f9f-8f9f-9f9f9f9f9f9f")
+		resp, err := (&http.Client{Timeout: 5 * time.Second}).Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+
+		var envelope sessions.IngestEnvelope
+		Expect(json.Unmarshal(driver.lastRecord().SessionEnvelope, &envelope)).To(Succeed())
+		Expect(envelope.OrgID).To(Equal(acme))
+	})
+})
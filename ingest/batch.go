@@ -0,0 +1,321 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/papercomputeco/tapes/pkg/llm"
+)
+
+// Batch ingest: many turns and transcripts in one POST, for backfilling
+// history that would otherwise cost one HTTP round-trip per write.
+//
+// The body is NDJSON — one BatchLine per line — optionally gzip-compressed.
+// Each line goes through exactly the code a single-write route runs, so a
+// batched write is stored, deduped, and rejected just as it would be posted
+// alone. The response streams one result per line as lines are processed,
+// so neither side holds a batch's results in memory. The request body is
+// streamed too: the server holds one line at a time, each bounded by
+// MaxIngestBodyBytes, and the body as a whole by MaxBatchBodyBytes.
+
+// MIMEApplicationNDJSON is the media type the batch route reads and
+// answers with.
+const MIMEApplicationNDJSON = "application/x-ndjson"
+
+// Batch line kinds and result statuses.
+const (
+	BatchKindTurn       = "turn"
+	BatchKindTranscript = "transcript"
+
+	BatchStatusAccepted = "accepted"
+	BatchStatusDeduped  = "deduped"
+	BatchStatusRejected = "rejected"
+)
+
+// MaxBatchBodyBytes bounds a batch body's wire bytes — compressed, for a
+// gzip body. It is separate from MaxIngestBodyBytes, which bounds each
+// line: the body is read a line at a time, so this cap is what one request
+// may hold a connection for, not what the server buffers. A longer backfill
+// is split across requests.
+const MaxBatchBodyBytes = 1 << 30
+
+// errBatchTooLarge ends a batch whose body runs past MaxBatchBodyBytes.
+var errBatchTooLarge = fmt.Errorf("body exceeds the %d-byte batch limit", MaxBatchBodyBytes)
+
+// batchFlushLines is how many results are buffered before the response is
+// flushed, trading a syscall per line for progress the client can see.
+const batchFlushLines = 64
+
+// batchAdmitTimeout bounds how long one turn line waits for worker-queue
+// room. A pool still saturated after it rejects the line as a downstream
+// failure, which the client retries like any other.
+const batchAdmitTimeout = 30 * time.Second
+
+// BatchLine is one line of a POST /v1/ingest/batch body. Exactly one of Turn
+// or Transcript is set: each is the body its single-write route takes.
+type BatchLine struct {
+	// Turn is a /v1/ingest envelope.
+	Turn *TurnPayload `json:"turn,omitempty"`
+
+	// Transcript is a /v1/ingest/transcript body.
+	Transcript *TranscriptPayload `json:"transcript,omitempty"`
+}
+
+// batchLine decodes a BatchLine with each envelope kept verbatim, for the
+// same reason ingestBody keeps its dual-view fields raw.
+type batchLine struct {
+	Turn       json.RawMessage `json:"turn"`
+	Transcript json.RawMessage `json:"transcript"`
+}
+
+// handleIngestBatch streams a batch body through the single-write paths.
+func (s *Server) handleIngestBatch(c *fiber.Ctx) error {
+	// The batch may end before its body does — a read error, a client gone
+	// — and fasthttp does not drain what is left, so the connection is not
+	// reused for a next request.
+	c.Context().SetConnectionClose()
+
+	// The undecoded stream: c.Body would buffer the body whole, and inflate
+	// a gzip one, where reading it through gzip.Reader keeps memory at one
+	// line. limitBody has already refused a declared length over the cap;
+	// capReader holds a chunked body to it.
+	var stream io.Reader = bytes.NewReader(nil)
+	if bs := c.Context().RequestBodyStream(); bs != nil {
+		stream = bs
+	}
+	body := &capReader{r: stream, n: MaxBatchBodyBytes}
+	br := bufio.NewReader(body)
+	magic, _ := br.Peek(2)
+
+	var r io.Reader = br
+	if isGzip(c.Get(fiber.HeaderContentEncoding), magic) {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{
+				Error: fmt.Sprintf("%s: gzip: %s", ErrEnvelope, err),
+			})
+		}
+		r = zr
+	}
+
+	// Everything the lines need from the request is read now: the stream
+	// writer runs after this handler has returned. The request stream
+	// outlives it — fasthttp releases it only once the response is written.
+	who := s.callerOf(c)
+	ctx := c.Context()
+
+	c.Status(fiber.StatusOK)
+	c.Set(fiber.HeaderContentType, MIMEApplicationNDJSON)
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		s.ingestBatch(ctx, who, r, body, w)
+	})
+
+	return nil
+}
+
+// capReader reads r up to n bytes and fails past them, where io.LimitReader
+// would end the body as if it were complete.
+type capReader struct {
+	r    io.Reader
+	n    int64
+	over bool
+}
+
+func (cr *capReader) Read(p []byte) (int, error) {
+	if cr.over {
+		return 0, errBatchTooLarge
+	}
+	if int64(len(p)) > cr.n+1 {
+		p = p[:cr.n+1]
+	}
+	n, err := cr.r.Read(p)
+	if int64(n) > cr.n {
+		// The byte past the cap is not handed on: the lines before it
+		// stand, and the one it cut off is not read as if it had ended.
+		cr.over = true
+		n = int(cr.n)
+		cr.n = 0
+		return n, errBatchTooLarge
+	}
+	cr.n -= int64(n)
+	return n, err
+}
+
+// isGzip reports whether a batch body is gzip-compressed: declared by
+// Content-Encoding, or recognized by its magic bytes so a .ndjson.gz file
+// posted as-is works too. An NDJSON line cannot start with 0x1f.
+func isGzip(contentEncoding string, magic []byte) bool {
+	if strings.EqualFold(strings.TrimSpace(contentEncoding), "gzip") {
+		return true
+	}
+	return len(magic) >= 2 && magic[0] == 0x1f && magic[1] == 0x8b
+}
+
+// ingestBatch reads lines from r and writes one result per non-blank line
+// to w. A read error — a corrupt gzip stream, a line over the body limit, a
+// body over the batch limit — is reported as a rejected result and ends the
+// batch; the lines before it stand. body is the capped wire stream under r.
+func (s *Server) ingestBatch(ctx context.Context, who caller, r io.Reader, body *capReader, w *bufio.Writer) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), MaxIngestBodyBytes)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		// A body cut off at the cap yields only its complete lines: the
+		// partial one after them is not a line the client sent.
+		if atEOF && body.over {
+			advance, token, err := bufio.ScanLines(data, false)
+			if token == nil && err == nil {
+				return 0, nil, errBatchTooLarge
+			}
+			return advance, token, err
+		}
+		return bufio.ScanLines(data, atEOF)
+	})
+
+	enc := json.NewEncoder(w)
+	line, pending := 0, 0
+	emit := func(res batchLineResult) bool {
+		if err := enc.Encode(res); err != nil {
+			return false
+		}
+		pending++
+		if pending >= batchFlushLines {
+			pending = 0
+			// A failed flush means the client has gone; stop writing
+			// on its behalf.
+			return w.Flush() == nil
+		}
+		return true
+	}
+
+	for scanner.Scan() {
+		line++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		if !emit(s.ingestBatchLine(ctx, who, line, raw)) {
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		code := fiber.StatusBadRequest
+		switch {
+		case errors.Is(err, bufio.ErrTooLong):
+			err = fmt.Errorf("line exceeds the %d-byte ingest body limit", MaxIngestBodyBytes)
+		case errors.Is(err, errBatchTooLarge):
+			code = fiber.StatusRequestEntityTooLarge
+		}
+		emit(batchLineResult{
+			Line:   line + 1,
+			Status: BatchStatusRejected,
+			Code:   code,
+			Error:  fmt.Sprintf("%s: %s", ErrEnvelope, err),
+		})
+	}
+	_ = w.Flush()
+}
+
+// ingestBatchLine writes one line through the single-write path its kind
+// names.
+func (s *Server) ingestBatchLine(ctx context.Context, who caller, line int, raw []byte) batchLineResult {
+	var bl batchLine
+	if err := json.Unmarshal(raw, &bl); err != nil {
+		s.metrics.ObserveWrite("", ResultRejectEnv, len(raw))
+		return rejectedLine(line, "", fiber.StatusBadRequest, fmt.Errorf("%w: %w", ErrEnvelope, err))
+	}
+
+	hasTranscript := present(bl.Transcript)
+	if present(bl.Turn) == hasTranscript {
+		s.metrics.ObserveWrite("", ResultRejectEnv, len(raw))
+		return rejectedLine(line, "", fiber.StatusBadRequest,
+			fmt.Errorf("%w: a batch line carries exactly one of turn or transcript", ErrEnvelope))
+	}
+
+	if hasTranscript {
+		return batchResult(line, BatchKindTranscript, s.ingestBatchTranscript(ctx, who, bl.Transcript))
+	}
+
+	var body ingestBody
+	if err := json.Unmarshal(bl.Turn, &body); err != nil {
+		return batchResult(line, BatchKindTurn, s.rejectTurnEnvelope("envelope", err, len(bl.Turn)))
+	}
+	if !s.awaitQueueRoom(ctx) {
+		s.metrics.ObserveWrite(body.Provider, ResultDownstreamErr, len(bl.Turn))
+		return rejectedLine(line, BatchKindTurn, fiber.StatusBadGateway,
+			fmt.Errorf("%w: worker queue full", ErrDownstream))
+	}
+	return batchResult(line, BatchKindTurn, s.ingestTurn(ctx, who, &body, len(bl.Turn)))
+}
+
+func (s *Server) ingestBatchTranscript(ctx context.Context, who caller, raw json.RawMessage) writeResult {
+	if s.rawStore == nil {
+		return writeResult{status: fiber.StatusNotImplemented, err: errNoRawLayer}
+	}
+	var payload TranscriptPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return s.rejectTranscript(err, len(raw))
+	}
+	return s.ingestTranscript(ctx, who, &payload, len(raw))
+}
+
+// awaitQueueRoom holds a batch until the worker queue is below half full, so
+// a backfill is paced by the workers instead of shedding the lines that
+// arrive while they catch up. It reports false when the queue stays full
+// for batchAdmitTimeout or ctx ends.
+func (s *Server) awaitQueueRoom(ctx context.Context) bool {
+	highWater := s.workerPool.Cap() / 2
+	if s.workerPool.Len() < highWater {
+		return true
+	}
+
+	deadline := time.Now().Add(batchAdmitTimeout)
+	backoff := 5 * time.Millisecond
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		if s.workerPool.Len() < highWater {
+			return true
+		}
+		backoff = min(backoff*2, 250*time.Millisecond)
+	}
+	return false
+}
+
+// present reports whether a line set a field: neither absent nor null.
+func present(raw json.RawMessage) bool {
+	return len(raw) > 0 && !bytes.Equal(raw, []byte("null"))
+}
+
+func batchResult(line int, kind string, res writeResult) batchLineResult {
+	switch {
+	case res.err != nil:
+		return rejectedLine(line, kind, res.status, res.err)
+	case res.deduped:
+		return batchLineResult{Line: line, Kind: kind, Status: BatchStatusDeduped}
+	default:
+		return batchLineResult{Line: line, Kind: kind, Status: BatchStatusAccepted}
+	}
+}
+
+func rejectedLine(line int, kind string, code int, err error) batchLineResult {
+	return batchLineResult{
+		Line:   line,
+		Kind:   kind,
+		Status: BatchStatusRejected,
+		Code:   code,
+		Error:  err.Error(),
+	}
+}
@@ -0,0 +1,202 @@
+package ingest_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/ingest"
+	"github.com/papercomputeco/tapes/pkg/sessions"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+// batchResult mirrors one line of the /v1/ingest/batch response.
+type batchResult struct {
+	Line   int    `json:"line"`
+	Kind   string `json:"kind"`
+	Status string `json:"status"`
+	Code   int    `json:"code"`
+	Error  string `json:"error"`
+}
+
+var _ = Describe("POST /v1/ingest/batch", func() {
+	var (
+		server  *ingest.Server
+		driver  *rawStoreDriver
+		baseURL string
+		client  *http.Client
+	)
+
+	BeforeEach(func() {
+		server, driver, baseURL = newTranscriptTestServer()
+		client = &http.Client{Timeout: 5 * time.Second}
+	})
+
+	AfterEach(func() {
+		Expect(server.Close()).To(Succeed())
+	})
+
+	turnLine := func(provider, requestID string) string {
+		req := json.RawMessage(`{"model":"claude-3-5-sonnet-20241022","max_tokens":64,"messages":[{"role":"user","content":"hi"}]}`)
+		return string(mustJSON(ingest.BatchLine{Turn: &ingest.TurnPayload{
+			Provider:   provider,
+			RawRequest: req,
+			Response:   reducedResponse("claude-3-5-sonnet-20241022", "hello", nil),
+			Meta:       ingest.TurnMeta{RequestID: requestID},
+		}}))
+	}
+
+	transcriptLine := func(sessionID string) string {
+		return string(mustJSON(ingest.BatchLine{Transcript: &ingest.TranscriptPayload{
+			Session: &sessions.IngestEnvelope{HarnessID: "claude", HarnessSessionID: sessionID},
+			Records: mustJSON([]map[string]string{{"type": "user", "uuid": "u-1"}}),
+		}}))
+	}
+
+	post := func(body []byte, headers map[string]string) (int, []batchResult) {
+		req, err := http.NewRequest(http.MethodPost, baseURL+"/v1/ingest/batch", bytes.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Type", ingest.MIMEApplicationNDJSON)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := client.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+
+		var results []batchResult
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var r batchResult
+			Expect(json.Unmarshal(scanner.Bytes(), &r)).To(Succeed(), scanner.Text())
+			results = append(results, r)
+		}
+		Expect(scanner.Err()).NotTo(HaveOccurred())
+		return resp.StatusCode, results
+	}
+
+	gzipped := func(body string) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, err := zw.Write([]byte(body))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(zw.Close()).To(Succeed())
+		return buf.Bytes()
+	}
+
+	It("reports one result per non-blank line, in order, and rejects only the bad lines", func() {
+		body := strings.Join([]string{
+			turnLine("anthropic", "req-1"),
+			`{not json`,
+			transcriptLine("0ea3c2cc-fe9d-41ff-aab1-4134ad00c350"),
+			"",
+			`{"turn":{"provider":"anthropic"},"transcript":{"records":[]}}`,
+			turnLine("nope", "req-2"),
+		}, "\n")
+
+		status, results := post([]byte(body), nil)
+		Expect(status).To(Equal(http.StatusOK))
+		Expect(results).To(HaveLen(5))
+
+		Expect(results[0]).To(Equal(batchResult{Line: 1, Kind: "turn", Status: "accepted"}))
+		Expect(results[1].Line).To(Equal(2))
+		Expect(results[1].Status).To(Equal("rejected"))
+		Expect(results[1].Code).To(Equal(http.StatusBadRequest))
+		Expect(results[2]).To(Equal(batchResult{Line: 3, Kind: "transcript", Status: "accepted"}))
+		Expect(results[3].Line).To(Equal(5), "the blank line has no result but keeps its number")
+		Expect(results[3].Error).To(ContainSubstring("exactly one of turn or transcript"))
+		Expect(results[4].Kind).To(Equal("turn"))
+		Expect(results[4].Code).To(Equal(http.StatusUnprocessableEntity))
+
+		// The unknown-provider turn is captured before parsing, as it would
+		// be posted alone.
+		Expect(driver.CountRawTurns(nil)).To(BeEquivalentTo(3))
+	})
+
+	It("reports a resent line the raw layer already holds as deduped", func() {
+		body := []byte(turnLine("anthropic", "req-dup") + "\n" + transcriptLine("0ea3c2cc-fe9d-41ff-aab1-4134ad00c350") + "\n")
+
+		_, first := post(body, nil)
+		Expect(first).To(HaveLen(2))
+		Expect(first[0].Status).To(Equal("accepted"))
+		Expect(first[1].Status).To(Equal("accepted"))
+
+		_, second := post(body, nil)
+		Expect(second).To(HaveLen(2))
+		Expect(second[0].Status).To(Equal("deduped"))
+		Expect(second[1].Status).To(Equal("deduped"))
+		Expect(driver.CountRawTurns(nil)).To(BeEquivalentTo(2))
+	})
+
+	It("reads a gzip body, declared or recognized by its magic bytes", func() {
+		body := gzipped(turnLine("anthropic", "req-gz") + "\n")
+
+		_, declared := post(body, map[string]string{"Content-Encoding": "gzip"})
+		Expect(declared).To(ConsistOf(batchResult{Line: 1, Kind: "turn", Status: "accepted"}))
+
+		_, sniffed := post(body, nil)
+		Expect(sniffed).To(ConsistOf(batchResult{Line: 1, Kind: "turn", Status: "deduped"}))
+	})
+
+	It("streams a batch larger than the single-write body limit", func() {
+		// Each line carries a ~4 MiB raw response; together they run past
+		// MaxIngestBodyBytes, which holds each line but not the body.
+		line := func(requestID string) string {
+			return string(mustJSON(ingest.BatchLine{Turn: &ingest.TurnPayload{
+				Provider:    "anthropic",
+				RawRequest:  json.RawMessage(`{"model":"claude-3-5-sonnet-20241022","max_tokens":64,"messages":[{"role":"user","content":"hi"}]}`),
+				Response:    reducedResponse("claude-3-5-sonnet-20241022", "hello", nil),
+				RawResponse: bytes.Repeat([]byte("x"), 3<<20),
+				Meta:        ingest.TurnMeta{RequestID: requestID},
+			}}))
+		}
+		var lines []string
+		for i := range 12 {
+			lines = append(lines, line(fmt.Sprintf("req-big-%d", i)))
+		}
+		body := []byte(strings.Join(lines, "\n"))
+		Expect(len(body)).To(BeNumerically(">", ingest.MaxIngestBodyBytes))
+		Expect(len(body)).To(BeNumerically("<=", ingest.MaxBatchBodyBytes))
+
+		client.Timeout = 30 * time.Second
+		status, results := post(body, nil)
+		Expect(status).To(Equal(http.StatusOK))
+		Expect(results).To(HaveLen(12))
+		for i, r := range results {
+			Expect(r).To(Equal(batchResult{Line: i + 1, Kind: "turn", Status: "accepted"}))
+		}
+		Expect(driver.CountRawTurns(nil)).To(BeEquivalentTo(12))
+	})
+
+	It("rejects a body declared gzip that is not", func() {
+		req, err := http.NewRequest(http.MethodPost, baseURL+"/v1/ingest/batch", strings.NewReader(turnLine("anthropic", "")))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Encoding", "gzip")
+		resp, err := client.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		Expect(driver.CountRawTurns(nil)).To(BeZero())
+	})
+
+	It("attributes every line to the caller the request resolved", func() {
+		_, results := post([]byte(transcriptLine("0ea3c2cc-fe9d-41ff-aab1-4134ad00c350")), map[string]string{
+			ingest.HeaderPaperAuthSubject: "user_gateway",
+		})
+		Expect(results).To(HaveLen(1))
+
+		rec := driver.lastRecord()
+		Expect(rec.Source).To(Equal(storage.RawTurnSourceTranscript))
+		var envelope sessions.IngestEnvelope
+		Expect(json.Unmarshal(rec.SessionEnvelope, &envelope)).To(Succeed())
+		Expect(envelope.AuthSubject).To(Equal("user_gateway"))
+	})
+})
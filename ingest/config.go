@@ -6,6 +6,8 @@
 // (pkg/spanembed), never at ingest time.
 package ingest
 
+import "github.com/papercomputeco/tapes/pkg/oidc"
+
 // Config is the ingest server configuration.
 type Config struct {
 	// ListenAddr is the address to listen on (e.g., ":8082")
@@ -13,4 +15,15 @@ type Config struct {
 
 	// Project is the git repository or project name to tag on captured turns.
 	Project string
+
+	// Verifier, when set, requires a bearer JWT on every ingest route and
+	// takes auth_subject from its verified claims instead of the gateway
+	// header. For deployments with no gateway in front of ingest.
+	Verifier *oidc.Verifier
+
+	// MultiTenant stores each turn under the caller's org: the verified org
+	// claim when Verifier is set, and the gateway's x-paper-auth-org-id
+	// header otherwise. Without it every turn takes the single-tenant
+	// sentinel, whatever the request says.
+	MultiTenant bool
 }
@@ -2,6 +2,7 @@ package ingest
 
 import (
 	"errors"
+	"io"
 	"log/slog"
 
 	"github.com/gofiber/fiber/v2"
@@ -19,7 +20,7 @@ func newBodyLimitErrorHandler(log *slog.Logger, metrics *Metrics) fiber.ErrorHan
 		// POST to any other path keeps the default response and no sample.
 		p := c.Path()
 		ingestRoute := c.Method() == fiber.MethodPost &&
-			(p == "/v1/ingest" || p == "/v1/ingest/transcript")
+			(p == "/v1/ingest" || p == "/v1/ingest/transcript" || p == batchPath)
 		if !errors.Is(err, fiber.ErrRequestEntityTooLarge) || !ingestRoute {
 			return fiber.DefaultErrorHandler(c, err)
 		}
@@ -29,7 +30,7 @@ func newBodyLimitErrorHandler(log *slog.Logger, metrics *Metrics) fiber.ErrorHan
 		metrics.ObserveWrite("", ResultRejectOversize, 0)
 		log.Warn("ingest body over limit",
 			"content_length", c.Request().Header.ContentLength(),
-			"limit", MaxIngestBodyBytes,
+			"limit", bodyLimit(c),
 			"path", c.Path(),
 		)
 
@@ -38,3 +39,53 @@ func newBodyLimitErrorHandler(log *slog.Logger, metrics *Metrics) fiber.ErrorHan
 		})
 	}
 }
+
+// batchPath is the one route whose body streams past MaxIngestBodyBytes.
+const batchPath = "/v1/ingest/batch"
+
+func isBatch(c *fiber.Ctx) bool {
+	return c.Method() == fiber.MethodPost && c.Path() == batchPath
+}
+
+// bodyLimit is the body limit the request's route is held to.
+func bodyLimit(c *fiber.Ctx) int {
+	if isBatch(c) {
+		return MaxBatchBodyBytes
+	}
+	return MaxIngestBodyBytes
+}
+
+// limitBody enforces the body limits, which the server's streamed request
+// bodies leave to it: with StreamRequestBody enabled fasthttp never applies
+// its max-body-size check, and c.Body() reads the whole stream. The declared
+// length rejects before any read. A chunked body, which declares none, is
+// read here up to the limit — except the batch body, which its handler
+// reads line by line under the same cap.
+//
+// A rejection surfaces as fiber.ErrRequestEntityTooLarge, so the error
+// handler answers it exactly as it did fasthttp's own.
+func limitBody(c *fiber.Ctx) error {
+	limit := bodyLimit(c)
+	if cl := c.Request().Header.ContentLength(); cl > limit {
+		// The body is left unread; close the connection so its bytes are
+		// never parsed as a next request.
+		c.Context().SetConnectionClose()
+		return fiber.ErrRequestEntityTooLarge
+	}
+
+	stream := c.Context().RequestBodyStream()
+	if isBatch(c) || stream == nil || c.Request().Header.ContentLength() != -1 {
+		return c.Next()
+	}
+	body, err := io.ReadAll(io.LimitReader(stream, int64(limit)+1))
+	if err != nil {
+		c.Context().SetConnectionClose()
+		return fiber.ErrBadRequest
+	}
+	if len(body) > limit {
+		c.Context().SetConnectionClose()
+		return fiber.ErrRequestEntityTooLarge
+	}
+	c.Request().SetBodyRaw(body)
+	return c.Next()
+}
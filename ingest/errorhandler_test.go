@@ -199,6 +199,32 @@ var _ = Describe("body-limit rejections", func() {
 		Expect(status).To(Equal(http.StatusMethodNotAllowed))
 	})
 
+	It("rejects an over-limit chunked POST that declares no length", func() {
+		// Streamed bodies skip fasthttp's own check, and a chunked one has
+		// no declared length to refuse up front: the limit is enforced on
+		// the bytes read.
+		size := ingest.MaxIngestBodyBytes + 1
+		dialer := &net.Dialer{Timeout: 5 * time.Second}
+		conn, err := dialer.DialContext(context.Background(), "tcp", strings.TrimPrefix(baseURL, "http://"))
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+		Expect(conn.SetDeadline(time.Now().Add(10 * time.Second))).To(Succeed())
+
+		head := "POST /v1/ingest HTTP/1.1\r\nHost: tapes-test\r\nContent-Type: application/json\r\nTransfer-Encoding: chunked\r\n\r\n"
+		_, err = io.WriteString(conn, head+fmt.Sprintf("%x\r\n", size))
+		Expect(err).NotTo(HaveOccurred())
+		_, _ = conn.Write(bytes.Repeat([]byte("x"), size))
+		_, _ = io.WriteString(conn, "\r\n0\r\n\r\n")
+
+		resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusRequestEntityTooLarge))
+		Expect(resp.Header.Get("Content-Type")).To(ContainSubstring("application/json"))
+		Expect(driver.CountRaw()).To(BeZero())
+		Expect(logBuf.String()).To(ContainSubstring("path=/v1/ingest"))
+	})
+
 	It("leaves body-limit rejections on non-ingest routes to the default handler", func() {
 		// Only ingest routes are counted: an oversized POST elsewhere keeps
 		// Fiber's plain-text 413 and must not record a reject_oversize sample.
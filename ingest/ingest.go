@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/papercomputeco/tapes/pkg/llm"
 	"github.com/papercomputeco/tapes/pkg/llm/provider"
 	tapeslogger "github.com/papercomputeco/tapes/pkg/logger"
+	"github.com/papercomputeco/tapes/pkg/oidc"
 	"github.com/papercomputeco/tapes/pkg/sessions"
 	"github.com/papercomputeco/tapes/pkg/storage"
 	oas "github.com/papercomputeco/tapes/pkg/tapesoapi"
@@ -192,6 +194,11 @@ type TurnMeta struct {
 	// no released producer sends it yet. See stampCaptureTime for the
 	// precedence and what happens when neither field is present.
 	CapturedAt string `json:"captured_at,omitempty"`
+
+	// Latency is the capture adapter's latency profile for the call: time
+	// to first byte and first token, inter-token gaps, and output rate.
+	// Ingest stores it verbatim; the deriver copies it onto the llm span.
+	Latency *llm.Latency `json:"latency,omitempty"`
 }
 
 // rawEnvelope is the shadow decode of an ingest body used for the
@@ -280,11 +287,18 @@ func newServer(config Config, driver storage.Driver, log *slog.Logger, docs oas.
 	// body-limit error handler records rejections on the same registry.
 	metrics := NewMetrics()
 
+	// Bodies stream so a batch is read line by line instead of buffered
+	// whole. Streaming turns fasthttp's max-body-size check off, so
+	// limitBody enforces the limits; BodyLimit still states the ceiling
+	// for every route but the batch one, and holds if streaming is ever
+	// disabled.
 	app := fiber.New(fiber.Config{
 		DisableStartupMessage: true,
+		StreamRequestBody:     true,
 		BodyLimit:             MaxIngestBodyBytes,
 		ErrorHandler:          newBodyLimitErrorHandler(log, metrics),
 	})
+	app.Use(limitBody)
 
 	wp, err := worker.NewPool(&worker.Config{
 		Driver:  driver,
@@ -322,6 +336,9 @@ func newServer(config Config, driver storage.Driver, log *slog.Logger, docs oas.
 	// and into the parser in one call — so this surface cannot serve an
 	// endpoint the published envelope contract does not describe.
 	router := oasfiber.Wrap(app, s.openapi, oasfiber.WithUndocumented(oasfiber.Fail))
+	if err := s.declareAuth(); err != nil {
+		return nil, err
+	}
 	s.mountRoutes(router)
 	if err := router.Err(); err != nil {
 		return nil, err
@@ -373,26 +390,61 @@ func decodeEnvelopeField(raw json.RawMessage, dst any) error {
 func (s *Server) handleIngest(c *fiber.Ctx) error {
 	bodySize := len(c.Body())
 
-	rejectEnvelope := func(reason string, err error) error {
-		s.logger.Warn("ingest envelope rejected",
-			"reason", reason,
-			"error", err,
-			"bytes", bodySize,
-		)
-		s.metrics.ObserveWrite("", ResultRejectEnv, bodySize)
-		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{
-			Error: fmt.Sprintf("%s: %s", ErrEnvelope, err),
-		})
-	}
-
 	// The dual-view fields stay json.RawMessage so one decode yields both the
-	// verbatim slices the raw row stores and, via the sub-decodes below, the
-	// typed payload.
-	var body ingestBody
+	// verbatim slices the raw row stores and, via the sub-decodes in
+	// ingestTurn, the typed payload.
+	var (
+		body ingestBody
+		res  writeResult
+	)
 	if err := c.BodyParser(&body); err != nil {
-		return rejectEnvelope("envelope", err)
+		res = s.rejectTurnEnvelope("envelope", err, bodySize)
+	} else {
+		res = s.ingestTurn(c.Context(), s.callerOf(c), &body, bodySize)
+	}
+	if res.err != nil {
+		return c.Status(res.status).JSON(llm.ErrorResponse{Error: res.err.Error()})
 	}
 
+	return c.Status(fiber.StatusAccepted).JSON(ingestAcceptedResponse{Status: "accepted"})
+}
+
+// writeResult is the outcome of one turn or transcript write, independent of
+// how it reached the server: the single-write routes answer with it as their
+// HTTP response, and the batch route reports it as one result line.
+type writeResult struct {
+	// status is the HTTP status the single-write route answers with.
+	status int
+
+	// deduped reports that the raw layer already held this write.
+	deduped bool
+
+	// records is the transcript's record count; zero for a turn.
+	records int
+
+	// err is non-nil when the write was rejected, and is the message the
+	// caller sees.
+	err error
+}
+
+// rejectTurnEnvelope records a turn envelope that could not be decoded or
+// validated.
+func (s *Server) rejectTurnEnvelope(reason string, err error, bodySize int) writeResult {
+	s.logger.Warn("ingest envelope rejected",
+		"reason", reason,
+		"error", err,
+		"bytes", bodySize,
+	)
+	s.metrics.ObserveWrite("", ResultRejectEnv, bodySize)
+	return writeResult{
+		status: fiber.StatusBadRequest,
+		err:    fmt.Errorf("%w: %w", ErrEnvelope, err),
+	}
+}
+
+// ingestTurn writes one decoded turn envelope: raw layer first, then the
+// provider parse and the worker pool.
+func (s *Server) ingestTurn(ctx context.Context, who caller, body *ingestBody, bodySize int) writeResult {
 	payload := TurnPayload{
 		Provider:            body.Provider,
 		AgentName:           body.AgentName,
@@ -402,25 +454,31 @@ func (s *Server) handleIngest(c *fiber.Ctx) error {
 		RawResponseWithheld: body.RawResponseWithheld,
 	}
 	if err := decodeEnvelopeField(body.Response, &payload.Response); err != nil {
-		return rejectEnvelope("envelope", err)
+		return s.rejectTurnEnvelope("envelope", err, bodySize)
 	}
 	if err := decodeEnvelopeField(body.Meta, &payload.Meta); err != nil {
-		return rejectEnvelope("envelope", err)
+		return s.rejectTurnEnvelope("envelope", err, bodySize)
 	}
 	if err := decodeEnvelopeField(body.Session, &payload.Session); err != nil {
-		return rejectEnvelope("envelope", err)
+		return s.rejectTurnEnvelope("envelope", err, bodySize)
 	}
 
 	if payload.Session != nil {
-		// This deployment is single-tenant: writes take the same sentinel
-		// reads scope to, so a client asserting an org in the envelope
-		// cannot store rows the read side will never surface. The field
-		// stays in the wire contract until the org_id columns go.
-		payload.Session.OrgID = ""
+		// The org is never the client's to assert: a single-tenant
+		// deployment writes the same sentinel reads scope to, and a
+		// multi-tenant one the caller's verified org.
+		payload.Session.OrgID = who.orgID
+
+		// A caller verified in process is who the turn belongs to,
+		// whatever the envelope claims. A turn without a session block
+		// has no sessions row to attribute, so there is nothing to stamp.
+		if who.identity != nil {
+			payload.Session.AuthSubject = who.identity.Subject
+		}
 	}
 
 	if err := payload.Session.Validate(); err != nil {
-		return rejectEnvelope("session", err)
+		return s.rejectTurnEnvelope("session", err, bodySize)
 	}
 
 	// A raw-only payload carries the verbatim upstream bytes and no reduction.
@@ -428,11 +486,12 @@ func (s *Server) handleIngest(c *fiber.Ctx) error {
 	// on which capture path produced it. This runs before the raw-layer write
 	// so the reduction lands on the same row as the bytes it came from, and
 	// before processTurn so the derived path sees a populated response.
-	reduced := s.reduceRawOnly(c.Context(), &payload)
+	reduced := s.reduceRawOnly(ctx, &payload)
 
 	// Persist the immutable raw envelope BEFORE parsing: a turn that
 	// fails provider parsing (422) is still captured, so a future
 	// parser fix re-derives it instead of needing a re-capture.
+	var res writeResult
 	if s.rawStore != nil {
 		raw := rawEnvelope{
 			Request:  body.RawRequest,
@@ -443,18 +502,19 @@ func (s *Server) handleIngest(c *fiber.Ctx) error {
 		if len(reduced) > 0 {
 			raw.Response = reduced
 		}
-		s.persistRawTurn(c.Context(), &payload, raw)
+		res.deduped = s.persistRawTurn(ctx, &payload, raw)
 	}
 
 	start := time.Now()
 	if err := s.processTurn(&payload, bodySize); err != nil {
 		s.recordProcessTurnError(payload.Provider, err, bodySize)
-		return s.writeProcessTurnError(c, err)
+		return s.processTurnFailure(err)
 	}
 	s.metrics.ObserveDAGLatency(payload.Provider, time.Since(start).Seconds())
 	s.metrics.ObserveWrite(payload.Provider, ResultAccepted, bodySize)
 
-	return c.Status(fiber.StatusAccepted).JSON(ingestAcceptedResponse{Status: "accepted"})
+	res.status = fiber.StatusAccepted
+	return res
 }
 
 // TranscriptPayload is the ingest body for one harness transcript file
@@ -521,38 +581,60 @@ const transcriptWriteProvider = "transcript"
 func (s *Server) handleTranscriptIngest(c *fiber.Ctx) error {
 	if s.rawStore == nil {
 		return c.Status(fiber.StatusNotImplemented).JSON(llm.ErrorResponse{
-			Error: "transcript ingest requires the raw-turn layer (Postgres driver)",
+			Error: errNoRawLayer.Error(),
 		})
 	}
 
 	bodySize := len(c.Body())
 
-	var payload TranscriptPayload
+	var (
+		payload TranscriptPayload
+		res     writeResult
+	)
 	if err := c.BodyParser(&payload); err != nil {
-		s.metrics.ObserveWrite(transcriptWriteProvider, ResultRejectEnv, bodySize)
-		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{
-			Error: fmt.Sprintf("%s: %s", ErrEnvelope, err),
-		})
+		res = s.rejectTranscript(err, bodySize)
+	} else {
+		res = s.ingestTranscript(c.Context(), s.callerOf(c), &payload, bodySize)
+	}
+	if res.err != nil {
+		return c.Status(res.status).JSON(llm.ErrorResponse{Error: res.err.Error()})
 	}
-	resolveGatewayIdentity(c, payload.Session)
+
+	return c.Status(fiber.StatusAccepted).JSON(transcriptAcceptedResponse{
+		Status:  "accepted",
+		Deduped: res.deduped,
+		Records: res.records,
+		AgentID: payload.AgentID,
+	})
+}
+
+// errNoRawLayer answers a transcript write on a driver without the raw-turn
+// layer.
+var errNoRawLayer = errors.New("transcript ingest requires the raw-turn layer (Postgres driver)")
+
+// rejectTranscript records a transcript body that could not be decoded or
+// validated.
+func (s *Server) rejectTranscript(err error, bodySize int) writeResult {
+	s.metrics.ObserveWrite(transcriptWriteProvider, ResultRejectEnv, bodySize)
+	return writeResult{
+		status: fiber.StatusBadRequest,
+		err:    fmt.Errorf("%w: %w", ErrEnvelope, err),
+	}
+}
+
+// ingestTranscript appends one decoded transcript file to the raw layer.
+// The caller has checked that the raw layer exists.
+func (s *Server) ingestTranscript(ctx context.Context, who caller, payload *TranscriptPayload, bodySize int) writeResult {
+	who.stampTranscript(payload.Session)
 	if err := payload.Session.Validate(); err != nil {
-		s.metrics.ObserveWrite(transcriptWriteProvider, ResultRejectEnv, bodySize)
-		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{
-			Error: fmt.Sprintf("%s: %s", ErrEnvelope, err),
-		})
+		return s.rejectTranscript(err, bodySize)
 	}
 	if payload.Session == nil || payload.Session.HarnessSessionID == "" {
-		s.metrics.ObserveWrite(transcriptWriteProvider, ResultRejectEnv, bodySize)
-		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{
-			Error: fmt.Sprintf("%s: transcript ingest requires session.harness_session_id", ErrEnvelope),
-		})
+		return s.rejectTranscript(errors.New("transcript ingest requires session.harness_session_id"), bodySize)
 	}
 	var records []json.RawMessage
 	if err := json.Unmarshal(payload.Records, &records); err != nil {
-		s.metrics.ObserveWrite(transcriptWriteProvider, ResultRejectEnv, bodySize)
-		return c.Status(fiber.StatusBadRequest).JSON(llm.ErrorResponse{
-			Error: fmt.Sprintf("%s: records must be a JSON array: %s", ErrEnvelope, err),
-		})
+		return s.rejectTranscript(fmt.Errorf("records must be a JSON array: %w", err), bodySize)
 	}
 
 	agentKey := payload.AgentID
@@ -574,15 +656,15 @@ func (s *Server) handleTranscriptIngest(c *fiber.Ctx) error {
 	})
 	if err != nil {
 		s.metrics.ObserveWrite(transcriptWriteProvider, ResultInternalErr, bodySize)
-		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: err.Error()})
+		return writeResult{status: fiber.StatusInternalServerError, err: err}
 	}
 	sessionJSON, err := json.Marshal(payload.Session)
 	if err != nil {
 		s.metrics.ObserveWrite(transcriptWriteProvider, ResultInternalErr, bodySize)
-		return c.Status(fiber.StatusInternalServerError).JSON(llm.ErrorResponse{Error: err.Error()})
+		return writeResult{status: fiber.StatusInternalServerError, err: err}
 	}
 
-	inserted, err := s.rawStore.PutRawTurn(c.Context(), storage.RawTurnRecord{
+	inserted, err := s.rawStore.PutRawTurn(ctx, storage.RawTurnRecord{
 		OrgID:            payload.Session.OrgID,
 		Source:           storage.RawTurnSourceTranscript,
 		HarnessID:        payload.Session.HarnessIDOrUnknown(),
@@ -600,55 +682,98 @@ func (s *Server) handleTranscriptIngest(c *fiber.Ctx) error {
 		if errors.Is(err, storage.ErrInvalidContent) {
 			s.logger.Warn("transcript ingest rejected: unstorable content", "error", err)
 			s.metrics.ObserveWrite(transcriptWriteProvider, ResultRejectParse, bodySize)
-			return c.Status(fiber.StatusUnprocessableEntity).JSON(llm.ErrorResponse{
-				Error: fmt.Sprintf("%s: %v", ErrUnprocessable, err),
-			})
+			return writeResult{
+				status: fiber.StatusUnprocessableEntity,
+				err:    fmt.Errorf("%w: %w", ErrUnprocessable, err),
+			}
 		}
 		s.logger.Error("transcript ingest failed", "error", err)
 		s.metrics.ObserveWrite(transcriptWriteProvider, ResultDownstreamErr, bodySize)
-		return c.Status(fiber.StatusBadGateway).JSON(llm.ErrorResponse{
-			Error: fmt.Sprintf("%s: %v", ErrDownstream, err),
-		})
+		return writeResult{
+			status: fiber.StatusBadGateway,
+			err:    fmt.Errorf("%w: %w", ErrDownstream, err),
+		}
 	}
 	s.metrics.ObserveWrite(transcriptWriteProvider, ResultAccepted, bodySize)
-	return c.Status(fiber.StatusAccepted).JSON(transcriptAcceptedResponse{
-		Status:  "accepted",
-		Deduped: !inserted,
-		Records: len(records),
-		AgentID: payload.AgentID,
-	})
+	return writeResult{
+		status:  fiber.StatusAccepted,
+		deduped: !inserted,
+		records: len(records),
+	}
+}
+
+// caller is who a request's writes are attributed to. It is resolved from
+// the request once, so the lines of a batch — processed while the response
+// streams, after the handler has returned — are attributed exactly as the
+// same writes posted one at a time would be.
+type caller struct {
+	// orgID is the org every write is stored under; see callerOf.
+	orgID string
+
+	// identity is the caller this server verified itself, nil without a
+	// Verifier.
+	identity *oidc.Identity
+
+	// gatewaySubject is the gateway's x-paper-auth-subject header.
+	gatewaySubject string
 }
 
-// resolveGatewayIdentity overrides the envelope's identity fields with
-// the server-trusted gateway headers when present. The transcript
-// client (paperd) cannot fill org_id itself — it holds a WorkOS org id,
-// not the platform org UUID the store keys on — so the payload value is
-// only trusted for direct in-cluster / override callers such as
-// `tapes backfill transcripts`. Anything arriving through the gateway
-// gets its identity from the edge-verified JWT, exactly like the
-// wire-capture path (tapes-extproc reads the same headers into the
-// session envelope at capture time). The override runs BEFORE envelope
-// validation so a malformed gateway-supplied org rejects loudly at the
-// HTTP boundary instead of corrupting attribution downstream.
-func resolveGatewayIdentity(c *fiber.Ctx, session *sessions.IngestEnvelope) {
+// callerOf resolves the org a request's writes are stored under, and the
+// subject they are attributed to.
+//
+// Single-tenant: the org is settled by the deployment, not the request.
+// Neither the payload nor the gateway header may store rows under an org the
+// read side will never surface, so the sentinel (empty) is the only org.
+//
+// Multi-tenant: the verified org claim when this server verifies JWTs, and
+// otherwise the org header the gateway stamps from the JWT it verified.
+func (s *Server) callerOf(c *fiber.Ctx) caller {
+	// Header values are copied: fiber reuses their buffers, and a batch
+	// reads them after the handler has returned.
+	who := caller{
+		identity:       oidc.FromCtx(c),
+		gatewaySubject: strings.Clone(c.Get(HeaderPaperAuthSubject)),
+	}
+	if s.config.MultiTenant {
+		if who.identity != nil {
+			who.orgID = who.identity.OrgID
+		} else {
+			who.orgID = strings.Clone(c.Get(HeaderPaperAuthOrgID))
+		}
+	}
+
+	return who
+}
+
+// stampTranscript overrides a transcript envelope's identity fields with
+// the server-trusted ones. The transcript client (paperd) cannot fill
+// org_id itself — it holds a WorkOS org id, not the platform org UUID the
+// store keys on — so the payload value is only trusted for direct
+// in-cluster / override callers such as `tapes backfill transcripts`.
+// Anything arriving through the gateway gets its identity from the
+// edge-verified JWT, exactly like the wire-capture path (tapes-extproc
+// reads the same headers into the session envelope at capture time). The
+// override runs BEFORE envelope validation so a malformed gateway-supplied
+// org rejects loudly at the HTTP boundary instead of corrupting
+// attribution downstream.
+//
+// When this server verifies JWTs itself there is no gateway to trust: the
+// header is whatever the client sent, so the verified subject is used and
+// the header ignored.
+func (who caller) stampTranscript(session *sessions.IngestEnvelope) {
 	if session == nil {
 		return
 	}
-	// Single-tenant: the org is settled by the deployment, not the request.
-	// Neither the payload nor the gateway header may store rows under an org
-	// the read side will never surface — the sentinel is the only org until
-	// the columns go. (In practice nothing ever stamped the org header: the
-	// edge strips it from clients and mints only the subject.)
-	session.OrgID = ""
-	if sub := c.Get(HeaderPaperAuthSubject); sub != "" {
-		session.AuthSubject = sub
+	session.OrgID = who.orgID
+	if who.identity != nil {
+		session.AuthSubject = who.identity.Subject
+		return
+	}
+	if who.gatewaySubject != "" {
+		session.AuthSubject = who.gatewaySubject
 	}
 }
 
-// persistRawTurn appends one captured turn to the immutable raw layer.
-// Failures are logged, never propagated: the raw layer must not take
-// down the node-ingest path, and a Postgres-level outage will surface
-// through processTurn anyway.
 // reduceRawOnly reduces a raw-only payload in place and returns the reduced
 // response as JSON for the raw layer, or nil when nothing was reduced.
 //
@@ -768,9 +893,10 @@ const MaxRawResponseBytes = 8 << 20
 // shed large-but-valid requests before they reach ingest.
 const MaxDecodedRequestBytes = 32 << 20
 
-// MaxIngestBodyBytes is the request body limit, derived from its parts rather
-// than chosen independently: a full decoded request (MaxDecodedRequestBytes),
-// plus raw_response travelling base64-encoded (MaxRawResponseBytes*4/3), plus a
+// MaxIngestBodyBytes is the body limit of one write — a single-write request,
+// or one line of a batch — derived from its parts rather than chosen
+// independently: a full decoded request (MaxDecodedRequestBytes), plus
+// raw_response travelling base64-encoded (MaxRawResponseBytes*4/3), plus a
 // reserve for the reduced response, the meta block, and JSON scaffolding. It is
 // derived, not a literal, so it can never silently desync from those budgets
 // and Fiber's 4 MiB default can't become the real limit: that default would
@@ -779,7 +905,12 @@ const MaxDecodedRequestBytes = 32 << 20
 // transport with no fidelity marker recorded anywhere.
 const MaxIngestBodyBytes = MaxDecodedRequestBytes + MaxRawResponseBytes*4/3 + 4<<20
 
-func (s *Server) persistRawTurn(ctx context.Context, turn *TurnPayload, raw rawEnvelope) {
+// persistRawTurn appends one captured turn to the immutable raw layer,
+// reporting whether the layer already held it (same org and request_id).
+// Failures are logged, never propagated: the raw layer must not take
+// down the node-ingest path, and a Postgres-level outage will surface
+// through processTurn anyway.
+func (s *Server) persistRawTurn(ctx context.Context, turn *TurnPayload, raw rawEnvelope) (deduped bool) {
 	rawResponse := turn.RawResponse
 	dropped := false
 	if len(rawResponse) > MaxRawResponseBytes {
@@ -832,18 +963,21 @@ func (s *Server) persistRawTurn(ctx context.Context, turn *TurnPayload, raw rawE
 		rec.HarnessID = turn.Session.HarnessIDOrUnknown()
 		rec.HarnessSessionID = turn.Session.HarnessSessionID
 	}
-	if _, err := s.rawStore.PutRawTurn(ctx, rec); err != nil {
+	inserted, err := s.rawStore.PutRawTurn(ctx, rec)
+	if err != nil {
 		s.logger.Error("raw turn persist failed",
 			"provider", turn.Provider,
 			"request_id", turn.Meta.RequestID,
 			"error", err,
 		)
+		return false
 	}
+	return !inserted
 }
 
 // recordProcessTurnError maps an internal error to the matching metric label
 // without affecting the HTTP response flow. Kept separate from
-// writeProcessTurnError so a caller can record the metric without also
+// processTurnFailure so a caller can record the metric without also
 // owning the HTTP reply.
 func (s *Server) recordProcessTurnError(provider string, err error, bodyBytes int) {
 	result := ResultRejectParse
@@ -864,10 +998,10 @@ func (s *Server) recordProcessTurnError(provider string, err error, bodyBytes in
 	s.metrics.ObserveWrite(provider, result, bodyBytes)
 }
 
-// writeProcessTurnError maps an error returned by processTurn to the matching
+// processTurnFailure maps an error returned by processTurn to the matching
 // HTTP status code. This is the mechanism that splits 400 / 422 / 502 so
 // operators can distinguish failure classes at a glance.
-func (s *Server) writeProcessTurnError(c *fiber.Ctx, err error) error {
+func (s *Server) processTurnFailure(err error) writeResult {
 	status := fiber.StatusUnprocessableEntity
 	reason := "unprocessable"
 	switch {
@@ -890,7 +1024,7 @@ func (s *Server) writeProcessTurnError(c *fiber.Ctx, err error) error {
 	} else {
 		s.logger.Warn("ingest rejected", logArgs...)
 	}
-	return c.Status(status).JSON(llm.ErrorResponse{Error: err.Error()})
+	return writeResult{status: status, err: err}
 }
 
 // validateReducedResponse is a sanity check ontop of a provided llm.ChatResponse
@@ -15,7 +15,7 @@ import (
 // This file is the ingest write surface's route table and its OpenAPI
 // description.
 //
-// The contract matters more than its three endpoints suggest: every capture
+// The contract matters more than its few endpoints suggest: every capture
 // path — tapes-extproc, tapesctl, paperd — writes this envelope, and "identical
 // fidelity whichever path captured it" is unenforceable while the shape those
 // paths must agree on lives only in Go structs.
@@ -118,7 +118,11 @@ func (s *Server) handleOpenAPI(c *fiber.Ctx) error {
 }
 
 // mountRoutes registers every documented route on the ingest server.
+//
+// The ingest routes are secured; /ping stays open for liveness probes.
 func (s *Server) mountRoutes(router *oasfiber.Router) {
+	ingest := s.secured(router)
+
 	router.Get("/ping", s.handlePing,
 		oasfiber.Doc("ingestPing").
 			Summary("Liveness probe").
@@ -127,7 +131,7 @@ func (s *Server) mountRoutes(router *oasfiber.Router) {
 			Tag("health").
 			JSONResponse(200, "The server is serving", s.schema(pingResponse{})))
 
-	router.Post("/v1/ingest", s.handleIngest,
+	ingest.Post("/v1/ingest", s.handleIngest,
 		oasfiber.Doc("ingestTurn").
 			Summary("Ingest one captured turn").
 			Description("Appends one completed LLM turn to the immutable raw-turn log. The raw "+
@@ -146,7 +150,7 @@ func (s *Server) mountRoutes(router *oasfiber.Router) {
 			JSONResponse(422, "Well-formed but unprocessable (e.g. unknown provider)", s.errorSchema()).
 			JSONResponse(502, "A downstream dependency failed", s.errorSchema()))
 
-	router.Post("/v1/ingest/transcript", s.handleTranscriptIngest,
+	ingest.Post("/v1/ingest/transcript", s.handleTranscriptIngest,
 		oasfiber.Doc("ingestTranscript").
 			Summary("Ingest one harness transcript file").
 			Description("Appends one harness transcript — the main session file or a single "+
@@ -166,6 +170,30 @@ func (s *Server) mountRoutes(router *oasfiber.Router) {
 			JSONResponse(413, "Body exceeds the ingest size limit", s.errorSchema()).
 			JSONResponse(500, "Persisting the transcript failed", s.errorSchema()).
 			JSONResponse(501, "Driver does not host the raw-turn layer", s.errorSchema()))
+
+	ingest.Post("/v1/ingest/batch", s.handleIngestBatch,
+		oasfiber.Doc("ingestBatch").
+			Summary("Ingest many turns and transcripts").
+			Description("Backfill path: an NDJSON body, one BatchLine per line, each carrying either "+
+				"a turn (the /v1/ingest envelope) or a transcript (the /v1/ingest/transcript body). "+
+				"Gzip-compress it with Content-Encoding: gzip, or post a .ndjson.gz file as-is."+
+				"\n\nEvery line is written exactly as its single-write route would write it: same "+
+				"raw-layer dedup, same validation, same attribution. One bad line rejects only itself."+
+				"\n\nThe response streams one result per non-blank line, in order, as lines are "+
+				"processed. A rejected result carries the status the single-write route would have "+
+				"answered with, so a client retries the 502s and fixes the 4xxs. A response that "+
+				"ends before the last line's result means the server stopped; resend from there."+
+				"\n\nThe body is read as it arrives, one line at a time. Each line is held to the "+
+				"single-write body limit; the body as a whole to a separate batch limit of 1 GiB on the "+
+				"wire, compressed or not. A body that declares more is refused with 413; one that "+
+				"declares no length and runs past the limit ends with a rejected result of code 413.").
+			Tag("ingest").
+			ContentBody("NDJSON of batch lines, optionally gzip-compressed", MIMEApplicationNDJSON,
+				s.schema(BatchLine{})).
+			ContentResponse(200, "One result per line, streamed as NDJSON", MIMEApplicationNDJSON,
+				s.schema(batchLineResult{})).
+			JSONResponse(400, "The body is not valid gzip", s.errorSchema()).
+			JSONResponse(413, "Declared body length exceeds the batch size limit", s.errorSchema()))
 }
 
 // schema reflects a Go type into this server's OpenAPI component registry.
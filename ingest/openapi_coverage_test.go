@@ -47,7 +47,7 @@ var _ = Describe("Ingest OpenAPI route coverage", func() {
 		Expect(err).NotTo(HaveOccurred())
 
 		tree := contract.Tree()
-		for _, path := range []string{"/v1/ingest", "/v1/ingest/transcript"} {
+		for _, path := range []string{"/v1/ingest", "/v1/ingest/transcript", "/v1/ingest/batch"} {
 			responses := treeAt(tree, "paths", path, "post", "responses")
 			Expect(treeAt(responses, "413")).NotTo(BeNil(),
 				"POST %s does not document the 413 body-limit rejection", path)
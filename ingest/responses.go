@@ -38,3 +38,26 @@ type transcriptAcceptedResponse struct {
 	// session file.
 	AgentID string `json:"agent_id,omitempty"`
 }
+
+// batchLineResult is one line of a POST /v1/ingest/batch response: the outcome
+// of the body line it names, in body order.
+type batchLineResult struct {
+	// Line is the 1-based line of the request body this result is for.
+	// Blank lines have no result.
+	Line int `json:"line"`
+
+	// Kind is "turn" or "transcript", empty when the line did not decode far
+	// enough to tell.
+	Kind string `json:"kind,omitempty" oas:"enum=turn|transcript"`
+
+	// Status is "accepted", "deduped" (already stored — a success), or
+	// "rejected".
+	Status string `json:"status" oas:"enum=accepted|deduped|rejected"`
+
+	// Code is, for a rejected line, the status the single-write route would
+	// have answered with: 4xx will fail again as sent, 502 is worth a retry.
+	Code int `json:"code,omitempty"`
+
+	// Error says why a line was rejected.
+	Error string `json:"error,omitempty"`
+}
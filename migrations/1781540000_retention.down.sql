@@ -0,0 +1,6 @@
+ALTER TABLE raw_turns
+    DROP COLUMN IF EXISTS payload_stripped_at;
+
+ALTER TABLE sessions
+    DROP COLUMN IF EXISTS retention_applied_at,
+    DROP COLUMN IF EXISTS retention_tier;
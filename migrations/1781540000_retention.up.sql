@@ -0,0 +1,38 @@
+-- Retention: the one sanctioned exception to raw_turns being append-only.
+--
+-- Prompt content has a bounded retention requirement; cost metadata does not.
+-- A retention rule (pkg/retention, configured under [retention] in
+-- config.toml) therefore either deletes a session outright — raw turns,
+-- attribution corrections, queue entry, and the cascading projection — or
+-- strips its payloads while keeping the usage and cost rollups the projection
+-- already folded.
+--
+-- Stripping is what makes the raw layer stop being the source of truth for a
+-- session: raw_request / raw_response go NULL and response is cut down to its
+-- model / stop_reason / usage skeleton, so a re-derive would fold an empty
+-- conversation and zero the rollups the rule meant to keep. Two markers keep
+-- that from happening:
+--
+--   sessions.retention_tier   '' (untouched) or 'stripped'. The deriver's
+--                             write path skips stripped sessions the same way
+--                             it skips unresolved ones, so the frozen
+--                             projection is never pruned or re-folded.
+--   raw_turns.payload_stripped_at
+--                             when the row's bytes were removed. NULL means
+--                             the row is as captured. Fidelity resolution
+--                             reports these rows as 'stripped', the worst
+--                             tier: the bytes existed and policy removed them.
+--
+-- retention_applied_at records when a rule last acted on the session, for
+-- operators auditing a policy change.
+
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS retention_tier       TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS retention_applied_at TIMESTAMPTZ;
+
+ALTER TABLE raw_turns
+    ADD COLUMN IF NOT EXISTS payload_stripped_at TIMESTAMPTZ;
+
+-- No index here: the worker's candidate scan ("sessions last seen before the
+-- cutoff", per org) is served by sessions_org_lastseen_idx
+-- (1779329142_session_tracking).
@@ -0,0 +1,29 @@
+-- API tokens: the read API's optional built-in authentication.
+--
+-- A token is a bearer secret minted by `tapes auth token create` and shown
+-- once. Only its SHA-256 digest is stored: tokens are 256 bits of randomness,
+-- so a fast hash is sufficient (there is no password to brute-force), and a
+-- leaked table grants nothing. token_prefix keeps the first characters of the
+-- secret so an operator can tell tokens apart in `tapes auth token list`
+-- without the secret itself.
+--
+-- scopes is the set the token was minted with (read, write, admin,
+-- cassette:<name>); pkg/apitoken owns their meaning. Revocation stamps
+-- revoked_at rather than deleting the row, so the list keeps an audit trail
+-- of what existed and when it stopped working.
+
+CREATE TABLE api_tokens (
+    id           UUID PRIMARY KEY,
+    org_id       UUID NOT NULL,
+    name         TEXT NOT NULL,
+    token_prefix TEXT NOT NULL,
+    token_hash   BYTEA NOT NULL,
+    scopes       TEXT[] NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ
+);
+
+-- Every authenticated request looks its token up by digest.
+CREATE UNIQUE INDEX api_tokens_hash_uq ON api_tokens (token_hash);
@@ -0,0 +1,115 @@
+-- Restore the unfiltered contract views before dropping the function they call.
+ALTER VIEW tapes_v1.sessions RESET (security_barrier);
+CREATE OR REPLACE VIEW tapes_v1.sessions AS
+SELECT
+    id,
+    org_id,
+    auth_subject,
+    harness_id,
+    harness_session_id,
+    name,
+    cwd,
+    harness_version,
+    parent_session_id,
+    started_at,
+    last_seen_at,
+    ended_at,
+    harness_metadata,
+    total_input_tokens,
+    total_output_tokens,
+    total_cost_usd,
+    turn_count,
+    derived_status,
+    has_git_activity,
+    tool_result_count,
+    tool_error_count,
+    derived_title,
+    derived_model,
+    model_usage,
+    total_tokens,
+    duration_ns,
+    tasks,
+    kind_counts,
+    display_name
+FROM sessions;
+
+ALTER VIEW tapes_v1.spans RESET (security_barrier);
+CREATE OR REPLACE VIEW tapes_v1.spans AS
+SELECT
+    org_id,
+    trace_id,
+    span_id,
+    parent_span_id,
+    session_id,
+    kind,
+    name,
+    status,
+    call_kind,
+    thread_id,
+    model,
+    stop_reason,
+    started_at,
+    duration_ns,
+    input,
+    output,
+    usage,
+    raw_turn_id,
+    node_hash,
+    seq,
+    verdict,
+    content_hash,
+    derive_seq,
+    fidelity
+FROM spans_20260615;
+
+ALTER VIEW tapes_v1.span_turns RESET (security_barrier);
+CREATE OR REPLACE VIEW tapes_v1.span_turns AS
+SELECT
+    org_id,
+    trace_id,
+    session_id,
+    user_prompt,
+    synthetic,
+    status,
+    started_at,
+    ended_at,
+    duration_ns,
+    total_input_tokens,
+    total_output_tokens,
+    total_cost_usd,
+    main_input_tokens,
+    main_output_tokens,
+    cache_read_tokens,
+    cache_creation_tokens,
+    response_preview,
+    source,
+    content_hash,
+    derive_seq,
+    fidelity
+FROM span_turns_20260615;
+
+ALTER VIEW tapes_v1.span_links RESET (security_barrier);
+CREATE OR REPLACE VIEW tapes_v1.span_links AS
+SELECT
+    org_id,
+    from_trace_id,
+    from_span_id,
+    from_io,
+    to_trace_id,
+    to_span_id,
+    to_io,
+    kind,
+    session_id
+FROM span_links_20260615;
+
+DROP POLICY IF EXISTS tenant_isolation ON sessions;
+ALTER TABLE sessions DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON spans_20260615;
+ALTER TABLE spans_20260615 DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON span_turns_20260615;
+ALTER TABLE span_turns_20260615 DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON span_links_20260615;
+ALTER TABLE span_links_20260615 DISABLE ROW LEVEL SECURITY;
+
+DROP FUNCTION IF EXISTS tenant_org_id(TEXT);
+DROP TABLE IF EXISTS tenant_roles;
@@ -0,0 +1,163 @@
+-- Tenant isolation for the tapes_v1 contract.
+--
+-- Multi-tenant deployments (storage.multi_tenant) scope every read-API query
+-- to the caller's org in application code. This migration is the database's
+-- half of that: a cassette connecting as its own Postgres role sees only its
+-- org's rows, even if its SQL forgets to filter.
+--
+-- tenant_roles maps a database role to the org it may read. Deployments add a
+-- row when they provision a per-tenant cassette role:
+--
+--   INSERT INTO tenant_roles (role_name, org_id) VALUES ('cassette_acme', '<org uuid>');
+--
+-- A role with no row is unrestricted, so single-tenant deployments and the
+-- tapes owner role keep working without any setup.
+--
+-- The tapes_v1 views are owned by the migrating role and so read their base
+-- tables with the owner's privileges, which bypass row-level security. The
+-- filter therefore lives in the views themselves (security_barrier, so a
+-- caller's predicates cannot leak filtered rows), and the physical tables
+-- additionally get RLS policies for any role granted them directly. A
+-- migration that registers a new projection generation MUST keep both: the
+-- WHERE clause on the repointed views and ENABLE ROW LEVEL SECURITY plus the
+-- tenant_isolation policy on the new tables.
+
+CREATE TABLE tenant_roles (
+    role_name TEXT PRIMARY KEY,
+    org_id    UUID NOT NULL
+);
+
+COMMENT ON TABLE tenant_roles IS
+    'Maps a database role to the org it may read through tapes_v1. Roles without a row are unrestricted.';
+
+-- SECURITY DEFINER so a cassette role needs no grant on tenant_roles to be
+-- filtered by it.
+CREATE FUNCTION tenant_org_id(role_name TEXT) RETURNS UUID
+    LANGUAGE sql STABLE SECURITY DEFINER
+    SET search_path = pg_catalog, public
+AS $$
+    SELECT org_id FROM tenant_roles WHERE tenant_roles.role_name = tenant_org_id.role_name
+$$;
+
+ALTER TABLE sessions ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON sessions
+    USING (tenant_org_id(current_user) IS NULL OR org_id = tenant_org_id(current_user));
+
+ALTER TABLE spans_20260615 ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON spans_20260615
+    USING (tenant_org_id(current_user) IS NULL OR org_id = tenant_org_id(current_user));
+
+ALTER TABLE span_turns_20260615 ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON span_turns_20260615
+    USING (tenant_org_id(current_user) IS NULL OR org_id = tenant_org_id(current_user));
+
+ALTER TABLE span_links_20260615 ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON span_links_20260615
+    USING (tenant_org_id(current_user) IS NULL OR org_id = tenant_org_id(current_user));
+
+CREATE OR REPLACE VIEW tapes_v1.sessions WITH (security_barrier) AS
+SELECT
+    id,
+    org_id,
+    auth_subject,
+    harness_id,
+    harness_session_id,
+    name,
+    cwd,
+    harness_version,
+    parent_session_id,
+    started_at,
+    last_seen_at,
+    ended_at,
+    harness_metadata,
+    total_input_tokens,
+    total_output_tokens,
+    total_cost_usd,
+    turn_count,
+    derived_status,
+    has_git_activity,
+    tool_result_count,
+    tool_error_count,
+    derived_title,
+    derived_model,
+    model_usage,
+    total_tokens,
+    duration_ns,
+    tasks,
+    kind_counts,
+    display_name
+FROM sessions
+WHERE tenant_org_id(current_user) IS NULL
+   OR org_id = tenant_org_id(current_user);
+
+CREATE OR REPLACE VIEW tapes_v1.spans WITH (security_barrier) AS
+SELECT
+    org_id,
+    trace_id,
+    span_id,
+    parent_span_id,
+    session_id,
+    kind,
+    name,
+    status,
+    call_kind,
+    thread_id,
+    model,
+    stop_reason,
+    started_at,
+    duration_ns,
+    input,
+    output,
+    usage,
+    raw_turn_id,
+    node_hash,
+    seq,
+    verdict,
+    content_hash,
+    derive_seq,
+    fidelity
+FROM spans_20260615
+WHERE tenant_org_id(current_user) IS NULL
+   OR org_id = tenant_org_id(current_user);
+
+CREATE OR REPLACE VIEW tapes_v1.span_turns WITH (security_barrier) AS
+SELECT
+    org_id,
+    trace_id,
+    session_id,
+    user_prompt,
+    synthetic,
+    status,
+    started_at,
+    ended_at,
+    duration_ns,
+    total_input_tokens,
+    total_output_tokens,
+    total_cost_usd,
+    main_input_tokens,
+    main_output_tokens,
+    cache_read_tokens,
+    cache_creation_tokens,
+    response_preview,
+    source,
+    content_hash,
+    derive_seq,
+    fidelity
+FROM span_turns_20260615
+WHERE tenant_org_id(current_user) IS NULL
+   OR org_id = tenant_org_id(current_user);
+
+CREATE OR REPLACE VIEW tapes_v1.span_links WITH (security_barrier) AS
+SELECT
+    org_id,
+    from_trace_id,
+    from_span_id,
+    from_io,
+    to_trace_id,
+    to_span_id,
+    to_io,
+    kind,
+    session_id
+FROM span_links_20260615
+WHERE tenant_org_id(current_user) IS NULL
+   OR org_id = tenant_org_id(current_user);
@@ -0,0 +1,11 @@
+-- The comments this migration restates predate it: 1781500000 set them, with
+-- this text. Rolling back restores that text rather than clearing it, so
+-- the views stay documented below this version.
+COMMENT ON VIEW tapes_v1.sessions IS
+    'v1 contract view over the sessions table.';
+COMMENT ON VIEW tapes_v1.spans IS
+    'v1 contract view over the current span projection generation (see derived_projection_schemas).';
+COMMENT ON VIEW tapes_v1.span_turns IS
+    'v1 contract view over the current span-turn projection generation (see derived_projection_schemas).';
+COMMENT ON VIEW tapes_v1.span_links IS
+    'v1 contract view over the current span-link projection generation (see derived_projection_schemas).';
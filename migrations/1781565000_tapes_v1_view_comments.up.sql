@@ -0,0 +1,14 @@
+-- Restate the tapes_v1 view comments.
+--
+-- 1781560000 rebuilt the contract views with CREATE OR REPLACE. Postgres
+-- keeps a view's comment across that, so a live database already has
+-- these; tools that replay migrations into their own catalog (sqlc) drop
+-- it, and restating them here keeps the generated models documented.
+COMMENT ON VIEW tapes_v1.sessions IS
+    'v1 contract view over the sessions table.';
+COMMENT ON VIEW tapes_v1.spans IS
+    'v1 contract view over the current span projection generation (see derived_projection_schemas).';
+COMMENT ON VIEW tapes_v1.span_turns IS
+    'v1 contract view over the current span-turn projection generation (see derived_projection_schemas).';
+COMMENT ON VIEW tapes_v1.span_links IS
+    'v1 contract view over the current span-link projection generation (see derived_projection_schemas).';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS pricing_discounts;
+DROP TABLE IF EXISTS pricing_catalog;
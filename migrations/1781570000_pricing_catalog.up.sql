@@ -0,0 +1,47 @@
+-- Pricing catalog: effective-dated model prices and per-org discounts.
+--
+-- Derive costs every llm span at the price in effect at the span's
+-- started_at: the latest pricing_catalog row for the model whose
+-- effective_from is at or before it, falling back to the built-in table
+-- (sessions.DefaultPricing) for models or instants the catalog does not
+-- cover. A price change is therefore a new row, never an edit, and a
+-- re-derive reproduces what each call cost when it was made.
+--
+-- pricing is a sessions.Pricing document: per-million token rates, cache
+-- TTL rates, per-request server tool fees, the long-context tier, and the
+-- service-tier multipliers (batch, flex, priority).
+--
+-- pricing_discounts are negotiated discounts off the catalog for one org.
+-- An empty model applies to every model; a model-specific discount outranks
+-- an every-model one.
+
+CREATE TABLE pricing_catalog (
+    id             UUID PRIMARY KEY,
+    model          TEXT NOT NULL,
+    effective_from TIMESTAMPTZ NOT NULL,
+    pricing        JSONB NOT NULL,
+    source         TEXT NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Re-importing a price sheet replaces the rows it dated rather than
+-- stacking duplicates.
+CREATE UNIQUE INDEX pricing_catalog_model_effective_uq ON pricing_catalog (model, effective_from);
+
+CREATE TABLE pricing_discounts (
+    id              UUID PRIMARY KEY,
+    org_id          UUID NOT NULL,
+    model           TEXT NOT NULL DEFAULT '',
+    percent         DOUBLE PRECISION NOT NULL CHECK (percent > 0 AND percent <= 100),
+    effective_from  TIMESTAMPTZ NOT NULL,
+    effective_until TIMESTAMPTZ,
+    note            TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (effective_until IS NULL OR effective_until > effective_from)
+);
+
+CREATE INDEX pricing_discounts_org_idx ON pricing_discounts (org_id, effective_from);
+
+ALTER TABLE pricing_discounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON pricing_discounts
+    USING (tenant_org_id(current_user) IS NULL OR org_id = tenant_org_id(current_user));
@@ -0,0 +1,3 @@
+ALTER TABLE span_turns_20260615
+    DROP COLUMN IF EXISTS parent_trace_id,
+    DROP COLUMN IF EXISTS abandoned;
@@ -0,0 +1,11 @@
+-- Branch structure on the trace projection.
+--
+-- Editing an earlier message or rewinding a session re-sends history up to
+-- a branch point and continues from there, so a session's traces form a
+-- tree rather than a list. The deriver records each trace's parent on its
+-- branch (parent_trace_id, '' for the session's first trace) and marks the
+-- traces a rewind displaced as abandoned; the branch point itself is a
+-- 'fork' row in span_links. A re-derive backfills both columns.
+ALTER TABLE span_turns_20260615
+    ADD COLUMN IF NOT EXISTS parent_trace_id TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS abandoned BOOLEAN NOT NULL DEFAULT false;
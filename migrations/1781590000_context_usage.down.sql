@@ -0,0 +1,7 @@
+ALTER TABLE sessions DROP COLUMN IF EXISTS context_usage;
+
+ALTER TABLE span_turns_20260615
+    DROP COLUMN IF EXISTS peak_context_tokens,
+    DROP COLUMN IF EXISTS peak_context_occupancy,
+    DROP COLUMN IF EXISTS context_growth_tokens,
+    DROP COLUMN IF EXISTS reclaimed_tokens;
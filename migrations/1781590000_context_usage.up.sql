@@ -0,0 +1,15 @@
+-- Context-window analytics.
+--
+-- The deriver sizes every llm span's prompt against its model's context
+-- window (usage.context on the span) and folds the main-thread figures
+-- onto the trace: the fullest call, growth over the parent trace, and on a
+-- post-compaction trace the tokens the compaction reclaimed. The session
+-- fold (peak occupancy, compaction count, cache hit ratio) is JSONB written
+-- per session in Go, like kind_counts. A re-derive backfills all of it.
+ALTER TABLE span_turns_20260615
+    ADD COLUMN IF NOT EXISTS peak_context_tokens BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS peak_context_occupancy DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS context_growth_tokens BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS reclaimed_tokens BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS context_usage JSONB;
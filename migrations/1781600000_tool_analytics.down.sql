@@ -0,0 +1,3 @@
+ALTER TABLE spans_20260615
+    DROP COLUMN IF EXISTS tool,
+    DROP COLUMN IF EXISTS retry_of;
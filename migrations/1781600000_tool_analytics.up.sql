@@ -0,0 +1,12 @@
+-- Tool execution analytics.
+--
+-- The deriver stamps every tool span with its normalized identity — the
+-- tool name without MCP namespacing, the MCP server that provides it, and
+-- the program a shell call ran — and, on a call that repeats an errored
+-- call with the same input, the span id of the attempt it retries. Tool
+-- spans' duration_ns now times execution alone, tool_use to tool_result.
+-- GET /v1/tools aggregates these over kind = 'tool' spans in a window,
+-- riding spans_20260615_org_kind_started_idx. A re-derive backfills them.
+ALTER TABLE spans_20260615
+    ADD COLUMN IF NOT EXISTS tool JSONB,
+    ADD COLUMN IF NOT EXISTS retry_of TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,34 @@
+-- File activity per trace.
+--
+-- The deriver folds each trace's Read/Write/Edit/MultiEdit/apply_patch and
+-- shell tool calls into one row per file the trace touched: how often it
+-- was read, created, edited and deleted, and the lines the edits added and
+-- removed. Session figures are sums over the session's rows. Rows follow
+-- their trace: a derive rewrites a trace's rows, and a pruned trace takes
+-- its rows with it.
+--
+-- GET /v1/files looks a path up by its final element (name) and then
+-- checks the rest, so a relative path finds the absolute paths that end
+-- with it.
+CREATE TABLE IF NOT EXISTS file_touches_20260615 (
+    org_id        UUID NOT NULL,
+    trace_id      TEXT NOT NULL,
+    path          TEXT NOT NULL,
+    session_id    UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    name          TEXT NOT NULL,
+    reads         INTEGER NOT NULL DEFAULT 0,
+    creates       INTEGER NOT NULL DEFAULT 0,
+    edits         INTEGER NOT NULL DEFAULT 0,
+    deletes       INTEGER NOT NULL DEFAULT 0,
+    lines_added   INTEGER NOT NULL DEFAULT 0,
+    lines_removed INTEGER NOT NULL DEFAULT 0,
+    first_at      TIMESTAMPTZ NOT NULL,
+    last_at       TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (org_id, trace_id, path),
+    FOREIGN KEY (org_id, trace_id) REFERENCES span_turns_20260615(org_id, trace_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS file_touches_20260615_org_name_idx
+    ON file_touches_20260615 (org_id, name);
+CREATE INDEX IF NOT EXISTS file_touches_20260615_session_idx
+    ON file_touches_20260615 (session_id);
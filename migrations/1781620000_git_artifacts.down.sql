@@ -0,0 +1 @@
+DROP TABLE IF EXISTS git_artifacts_20260615;
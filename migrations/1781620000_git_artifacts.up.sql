@@ -0,0 +1,31 @@
+-- Git artifacts per trace.
+--
+-- The deriver reads git commit, git push and gh pr create calls and their
+-- output into the commits, pushes and pull requests a trace produced. Like
+-- file_touches_20260615, rows follow their trace: a derive rewrites them
+-- and a pruned trace takes them with it.
+--
+-- GET /v1/commits/{sha}/sessions looks commits up by sha. git commit
+-- prints an abbreviated sha, so the lookup tries each prefix of the
+-- requested sha as an equality on git_artifacts_20260615_org_sha_idx.
+CREATE TABLE IF NOT EXISTS git_artifacts_20260615 (
+    org_id     UUID NOT NULL,
+    trace_id   TEXT NOT NULL,
+    ordinal    INTEGER NOT NULL,
+    session_id UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    span_id    TEXT NOT NULL,
+    kind       TEXT NOT NULL,
+    sha        TEXT NOT NULL DEFAULT '',
+    branch     TEXT NOT NULL DEFAULT '',
+    url        TEXT NOT NULL DEFAULT '',
+    subject    TEXT NOT NULL DEFAULT '',
+    at         TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (org_id, trace_id, ordinal),
+    FOREIGN KEY (org_id, trace_id) REFERENCES span_turns_20260615(org_id, trace_id) ON DELETE CASCADE,
+    CONSTRAINT git_artifacts_20260615_kind_chk CHECK (kind IN ('commit', 'push', 'pull_request'))
+);
+
+CREATE INDEX IF NOT EXISTS git_artifacts_20260615_org_sha_idx
+    ON git_artifacts_20260615 (org_id, sha) WHERE sha <> '';
+CREATE INDEX IF NOT EXISTS git_artifacts_20260615_session_idx
+    ON git_artifacts_20260615 (session_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS sessions_outcome_reasons_idx;
+DROP INDEX IF EXISTS sessions_org_outcome_idx;
+
+ALTER TABLE sessions
+    DROP COLUMN IF EXISTS outcome_reasons,
+    DROP COLUMN IF EXISTS outcome;
@@ -0,0 +1,20 @@
+-- Session outcome.
+--
+-- derived_status says whether a session went well; outcome says how it
+-- ended: interrupted, errored, refused, looped, turn_limit, gave_up,
+-- abandoned, completed, failed or unknown. outcome_reasons is the JSONB
+-- array of evidence behind it (loops, interruptions, categorized terminal
+-- errors), folded in Go by pkg/derive.FoldSessionOutcome and written with
+-- derived_status. A re-derive backfills both.
+--
+-- GET /v1/sessions filters on outcome by equality on
+-- sessions_org_outcome_idx and on a reason code by containment on
+-- sessions_outcome_reasons_idx.
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS outcome TEXT NOT NULL DEFAULT 'unknown',
+    ADD COLUMN IF NOT EXISTS outcome_reasons JSONB;
+
+CREATE INDEX IF NOT EXISTS sessions_org_outcome_idx
+    ON sessions (org_id, outcome);
+CREATE INDEX IF NOT EXISTS sessions_outcome_reasons_idx
+    ON sessions USING GIN (outcome_reasons jsonb_path_ops);
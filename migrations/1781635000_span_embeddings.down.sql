@@ -0,0 +1,4 @@
+-- The vector extension is left installed: an operator enabled it, and
+-- other schemas may use it.
+DROP TABLE IF EXISTS span_embed_cursors;
+DROP TABLE IF EXISTS span_embeddings_20260615;
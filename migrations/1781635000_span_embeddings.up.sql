@@ -0,0 +1,67 @@
+-- Span embeddings for semantic search, written by the embed worker and
+-- read by /v1/search/spans.
+--
+-- Opt-in: the vector column needs the pgvector extension, and the schema
+-- must keep migrating on a Postgres without it. The tables are created
+-- only when the extension is already installed, so a deployment enables
+-- semantic search by running CREATE EXTENSION vector (as a role allowed
+-- to) before this migration. Without it the migration is a no-op and the
+-- embed worker reports the tables missing. Every statement is idempotent:
+-- a database that migrated past this without pgvector can run the block
+-- by hand once the extension is in place.
+--
+-- 1781530000 dropped an earlier span_embeddings that the retired store
+-- created at runtime. These replace it under migration control, keyed by
+-- the deterministic span id: a re-derive that keeps a span keeps its key,
+-- one that changes the span's content changes its content_hash and the
+-- worker re-embeds it, and one that prunes the span takes the vector with
+-- it through the foreign key.
+--
+-- The column is an unsized vector because the width is the model's, and
+-- a model change writes vectors of another width beside the old ones
+-- until the old model is pruned; searches compare within one model.
+--
+-- sqlc does not read plpgsql, so pkg/storage/postgres/schema restates
+-- these tables for code generation. Keep the two in step.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'vector') THEN
+        RAISE NOTICE 'pgvector is not installed: span embedding tables not created';
+        RETURN;
+    END IF;
+
+    CREATE TABLE IF NOT EXISTS span_embeddings_20260615 (
+        org_id       UUID NOT NULL,
+        trace_id     TEXT NOT NULL,
+        span_id      TEXT NOT NULL,
+        model        TEXT NOT NULL,
+        content_hash TEXT NOT NULL,
+        embedding    vector NOT NULL,
+        embedded_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+        PRIMARY KEY (org_id, trace_id, span_id, model),
+        FOREIGN KEY (org_id, trace_id, span_id)
+            REFERENCES spans_20260615(org_id, trace_id, span_id) ON DELETE CASCADE
+    );
+
+    CREATE INDEX IF NOT EXISTS span_embeddings_20260615_org_model_idx
+        ON span_embeddings_20260615 (org_id, model);
+
+    -- The embed worker's position in the span change feed, per model. The
+    -- feed spans every org, so the cursor is not tenant data.
+    CREATE TABLE IF NOT EXISTS span_embed_cursors (
+        model      TEXT PRIMARY KEY,
+        derive_seq BIGINT NOT NULL,
+        org_id     UUID NOT NULL,
+        trace_id   TEXT NOT NULL,
+        span_id    TEXT NOT NULL,
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+    );
+
+    -- The same tenant isolation 1781560000 gives every projection table.
+    ALTER TABLE span_embeddings_20260615 ENABLE ROW LEVEL SECURITY;
+    IF NOT EXISTS (SELECT 1 FROM pg_policies
+                   WHERE tablename = 'span_embeddings_20260615' AND policyname = 'tenant_isolation') THEN
+        CREATE POLICY tenant_isolation ON span_embeddings_20260615
+            USING (tenant_org_id(current_user) IS NULL OR org_id = tenant_org_id(current_user));
+    END IF;
+END $$;
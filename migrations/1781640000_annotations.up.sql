@@ -0,0 +1,45 @@
+-- Annotations: human judgments and automated scores on captured work.
+--
+-- An annotation attaches a label, a numeric score and/or a free-text note
+-- under a name ("correctness", "helpful", "eval:task_success") to a
+-- session, one of its traces, or one span of a trace. author records who
+-- or what made the judgment: a reviewer, or the evaluator that scored it.
+--
+-- Annotations are not derived, so they live outside the projection.
+-- trace_id and span_id carry no foreign key to the span tables: a
+-- re-derive rewrites those rows, and the deterministic trace and span ids
+-- it writes them under are what keep an annotation attached across it.
+-- Only deleting the session (or its retention) removes its annotations.
+--
+-- (org_id, session_id, trace_id, span_id, name, author) is the natural
+-- key: writing the same judgment again replaces its label, score and
+-- note, so a re-run evaluator or a re-imported label sheet overwrites
+-- instead of piling up.
+CREATE TABLE IF NOT EXISTS annotations (
+    id         UUID PRIMARY KEY,
+    org_id     UUID NOT NULL,
+    session_id UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    trace_id   TEXT NOT NULL DEFAULT '',
+    span_id    TEXT NOT NULL DEFAULT '',
+    name       TEXT NOT NULL,
+    label      TEXT NOT NULL DEFAULT '',
+    score      DOUBLE PRECISION,
+    note       TEXT NOT NULL DEFAULT '',
+    author     TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT annotations_target_chk CHECK (span_id = '' OR trace_id <> ''),
+    CONSTRAINT annotations_value_chk CHECK (label <> '' OR score IS NOT NULL OR note <> '')
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS annotations_key_uq
+    ON annotations (org_id, session_id, trace_id, span_id, name, author);
+-- /v1/stats groups by name and label; the /v1/sessions filter probes them.
+CREATE INDEX IF NOT EXISTS annotations_org_name_idx
+    ON annotations (org_id, name, label);
+CREATE INDEX IF NOT EXISTS annotations_org_trace_idx
+    ON annotations (org_id, trace_id) WHERE trace_id <> '';
+
+ALTER TABLE annotations ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON annotations
+    USING (tenant_org_id(current_user) IS NULL OR org_id = tenant_org_id(current_user));
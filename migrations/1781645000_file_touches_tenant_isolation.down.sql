@@ -0,0 +1,2 @@
+DROP POLICY IF EXISTS tenant_isolation ON file_touches_20260615;
+ALTER TABLE file_touches_20260615 DISABLE ROW LEVEL SECURITY;
@@ -0,0 +1,10 @@
+-- Row-level security for file_touches_20260615.
+--
+-- 1781610000 created the table without the tenant_isolation policy that
+-- 1781560000 requires of every projection table, so a per-tenant role
+-- granted it directly could read every org's file activity. The policy
+-- lands here rather than in 1781610000 so databases already past that
+-- migration get it too.
+ALTER TABLE file_touches_20260615 ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON file_touches_20260615
+    USING (tenant_org_id(current_user) IS NULL OR org_id = tenant_org_id(current_user));
@@ -0,0 +1,2 @@
+DROP POLICY IF EXISTS tenant_isolation ON git_artifacts_20260615;
+ALTER TABLE git_artifacts_20260615 DISABLE ROW LEVEL SECURITY;
@@ -0,0 +1,10 @@
+-- Row-level security for git_artifacts_20260615.
+--
+-- 1781620000 created the table without the tenant_isolation policy that
+-- 1781560000 requires of every projection table, so a per-tenant role
+-- granted it directly could read every org's commits, pushes and pull
+-- requests. The policy lands here rather than in 1781620000 so databases
+-- already past that migration get it too.
+ALTER TABLE git_artifacts_20260615 ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON git_artifacts_20260615
+    USING (tenant_org_id(current_user) IS NULL OR org_id = tenant_org_id(current_user));
@@ -0,0 +1,116 @@
+// Package apitoken mints the read API's bearer tokens and defines what
+// their scopes grant.
+//
+// A token is "tapes_" followed by 32 random bytes, base64url-encoded. The
+// server stores only its SHA-256 digest (see storage.APITokenStore): the
+// secret is shown once, at creation, and never again.
+//
+// Scopes form a small lattice rather than a flat list, so a token needs
+// only the broadest scope it uses:
+//
+//	read             every read endpoint
+//	write            read, plus session edits and deletes and MCP tool calls
+//	admin            everything, including the /v1/admin operator endpoints
+//	                 and every cassette
+//	cassette:<name>  the routes one cassette serves under /v1/cassettes/<name>
+package apitoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/papercomputeco/tapes/pkg/cassette"
+)
+
+// Scopes a token can be minted with. A cassette scope is built with
+// CassetteScope.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+
+	cassetteScopePrefix = "cassette:"
+)
+
+// Prefix starts every token, so one pasted somewhere it does not belong is
+// recognisable to a secret scanner and to a reader.
+const Prefix = "tapes_"
+
+// displayLength is how much of a token Display keeps: the prefix and enough
+// of the random part to tell tokens apart, far too little to guess the rest.
+const displayLength = len(Prefix) + 8
+
+// CassetteScope is the scope that grants one cassette's routes.
+func CassetteScope(name cassette.Name) string {
+	return cassetteScopePrefix + string(name)
+}
+
+// ParseScopes validates and normalizes a token's scopes: each must be read,
+// write, admin, or cassette:<valid cassette name>. Duplicates are dropped
+// and the result sorted, so the stored set is canonical.
+func ParseScopes(values []string) ([]string, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("a token needs at least one scope (%s, %s, %s, or %s<name>)",
+			ScopeRead, ScopeWrite, ScopeAdmin, cassetteScopePrefix)
+	}
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		scope := strings.TrimSpace(value)
+		switch {
+		case scope == ScopeRead, scope == ScopeWrite, scope == ScopeAdmin:
+		case strings.HasPrefix(scope, cassetteScopePrefix):
+			if _, err := cassette.ParseName(strings.TrimPrefix(scope, cassetteScopePrefix)); err != nil {
+				return nil, fmt.Errorf("invalid scope %q: %w", value, err)
+			}
+		default:
+			return nil, fmt.Errorf("invalid scope %q: want %s, %s, %s, or %s<name>",
+				value, ScopeRead, ScopeWrite, ScopeAdmin, cassetteScopePrefix)
+		}
+		out = append(out, scope)
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+// Grants reports whether a token holding scopes may use a route that
+// requires required.
+func Grants(scopes []string, required string) bool {
+	for _, held := range scopes {
+		switch {
+		case held == required, held == ScopeAdmin:
+			return true
+		case held == ScopeWrite && required == ScopeRead:
+			return true
+		}
+	}
+	return false
+}
+
+// New mints a token. It returns the secret, to show the operator once, and
+// its digest, to store.
+func New() (secret string, hash []byte, err error) {
+	var random [32]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return "", nil, fmt.Errorf("read random token bytes: %w", err)
+	}
+	secret = Prefix + base64.RawURLEncoding.EncodeToString(random[:])
+	return secret, Hash(secret), nil
+}
+
+// Hash is the digest a token is stored and looked up under.
+func Hash(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
+
+// Display is the part of a token safe to show in a listing.
+func Display(secret string) string {
+	if len(secret) <= displayLength {
+		return secret
+	}
+	return secret[:displayLength]
+}
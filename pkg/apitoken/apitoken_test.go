@@ -0,0 +1,65 @@
+package apitoken_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/apitoken"
+)
+
+var _ = Describe("ParseScopes", func() {
+	It("accepts the fixed scopes and cassette scopes, canonically ordered", func() {
+		scopes, err := apitoken.ParseScopes([]string{"write", "cassette:notes", "read", "write"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(scopes).To(Equal([]string{"cassette:notes", "read", "write"}))
+	})
+
+	It("rejects an unknown scope", func() {
+		_, err := apitoken.ParseScopes([]string{"superuser"})
+		Expect(err).To(MatchError(ContainSubstring(`invalid scope "superuser"`)))
+	})
+
+	It("rejects a cassette scope naming no valid cassette", func() {
+		_, err := apitoken.ParseScopes([]string{"cassette:"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("requires at least one scope", func() {
+		_, err := apitoken.ParseScopes(nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Grants", func() {
+	DescribeTable("scope lattice",
+		func(held []string, required string, granted bool) {
+			Expect(apitoken.Grants(held, required)).To(Equal(granted))
+		},
+		Entry("read grants read", []string{"read"}, "read", true),
+		Entry("read does not grant write", []string{"read"}, "write", false),
+		Entry("write grants read", []string{"write"}, "read", true),
+		Entry("write does not grant admin", []string{"write"}, "admin", false),
+		Entry("admin grants everything", []string{"admin"}, "cassette:notes", true),
+		Entry("a cassette scope grants its cassette", []string{"cassette:notes"}, "cassette:notes", true),
+		Entry("a cassette scope grants no other cassette", []string{"cassette:notes"}, "cassette:todo", false),
+		Entry("a cassette scope grants no core route", []string{"cassette:notes"}, "read", false),
+		Entry("write grants no cassette", []string{"write"}, "cassette:notes", false),
+	)
+})
+
+var _ = Describe("New", func() {
+	It("mints distinct prefixed secrets whose hash matches Hash", func() {
+		first, hash, err := apitoken.New()
+		Expect(err).NotTo(HaveOccurred())
+		second, _, err := apitoken.New()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(first).To(HavePrefix(apitoken.Prefix))
+		Expect(first).NotTo(Equal(second))
+		Expect(hash).To(Equal(apitoken.Hash(first)))
+		Expect(apitoken.Display(first)).To(HaveLen(len(apitoken.Prefix) + 8))
+		Expect(strings.HasPrefix(first, apitoken.Display(first))).To(BeTrue())
+	})
+})
@@ -0,0 +1,187 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+const formatClaudeCode = "claude-code"
+
+// claudeCodeImporter reads Claude Code session transcripts
+// (~/.claude/projects/<project>/<session>.jsonl) and recovers the Messages
+// API calls behind them.
+//
+// A transcript records each assistant message once per content block, all
+// under the message's id, and every user message between them. The request
+// for an assistant message is rebuilt as the conversation that preceded
+// it; the system prompt and tool definitions the harness sent are not in
+// the transcript and are absent from the rebuilt request.
+//
+// Sidechain (subagent) records are skipped: their conversations live in
+// the subagents/ transcripts, which `tapes backfill transcripts` uploads
+// as such. Import a session here only if it was not captured live, since
+// the imported turns land in the same Claude session.
+type claudeCodeImporter struct{}
+
+type claudeCodeRecord struct {
+	Type        string          `json:"type"`
+	SessionID   string          `json:"sessionId"`
+	RequestID   string          `json:"requestId"`
+	Timestamp   json.RawMessage `json:"timestamp"`
+	Cwd         string          `json:"cwd"`
+	Version     string          `json:"version"`
+	IsSidechain bool            `json:"isSidechain"`
+	Message     json.RawMessage `json:"message"`
+}
+
+type claudeCodeMessage struct {
+	ID         string            `json:"id"`
+	Role       string            `json:"role"`
+	Model      string            `json:"model"`
+	Content    json.RawMessage   `json:"content"`
+	StopReason string            `json:"stop_reason"`
+	Usage      json.RawMessage   `json:"usage"`
+	Blocks     []json.RawMessage `json:"-"`
+}
+
+// claudeCodeSyntheticModel marks assistant messages the harness wrote
+// itself (API errors, interruptions) rather than received from the API.
+const claudeCodeSyntheticModel = "<synthetic>"
+
+func (claudeCodeImporter) Format() string { return formatClaudeCode }
+
+func (claudeCodeImporter) Import(ctx context.Context, r io.Reader, emit func(ImportedTurn) error, skip func(ref, reason string)) error {
+	var (
+		history []json.RawMessage
+		pending *claudeCodeMessage
+		turn    ImportedTurn
+
+		// cwd and version carry forward from whichever record last set
+		// them, so a turn gets them even when only user records do.
+		cwd, version string
+	)
+
+	// flush emits the assistant message being assembled, then appends it
+	// to the history the next request is built from.
+	flush := func() error {
+		if pending == nil {
+			return nil
+		}
+		msg := pending
+		pending = nil
+
+		content, _ := json.Marshal(msg.Blocks)
+		messages, _ := json.Marshal(history)
+		request, _ := json.Marshal(map[string]any{
+			"model":    msg.Model,
+			"messages": json.RawMessage(messages),
+		})
+		response := map[string]any{
+			"id":            msg.ID,
+			"type":          "message",
+			"role":          "assistant",
+			"model":         msg.Model,
+			"content":       json.RawMessage(content),
+			"stop_reason":   msg.StopReason,
+			"stop_sequence": nil,
+		}
+		if present(msg.Usage) {
+			response["usage"] = msg.Usage
+		}
+		turn.Provider = "anthropic"
+		turn.Request = request
+		turn.Response, _ = json.Marshal(response)
+		turn.HarnessID = "claude"
+		turn.Cwd, turn.HarnessVersion = cwd, version
+
+		history = append(history, marshalRaw(map[string]any{"role": "assistant", "content": json.RawMessage(content)}))
+		return emit(turn)
+	}
+
+	err := eachRecord(ctx, r, func(ref string, raw json.RawMessage) error {
+		var rec claudeCodeRecord
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			skip(ref, "not a transcript record: "+err.Error())
+			return nil
+		}
+		if rec.IsSidechain {
+			skip(ref, "sidechain record")
+			return nil
+		}
+		if rec.Cwd != "" {
+			cwd = rec.Cwd
+		}
+		if rec.Version != "" {
+			version = rec.Version
+		}
+
+		switch rec.Type {
+		case "user":
+			if err := flush(); err != nil {
+				return err
+			}
+			var msg claudeCodeMessage
+			if json.Unmarshal(rec.Message, &msg) != nil || !present(msg.Content) {
+				skip(ref, "user record without a message")
+				return nil
+			}
+			history = append(history, marshalRaw(map[string]any{"role": "user", "content": msg.Content}))
+			return nil
+
+		case "assistant":
+			var msg claudeCodeMessage
+			if err := json.Unmarshal(rec.Message, &msg); err != nil || msg.ID == "" {
+				skip(ref, "assistant record without a message id")
+				return nil
+			}
+			if msg.Model == claudeCodeSyntheticModel {
+				skip(ref, "harness-written assistant message")
+				return nil
+			}
+			if pending == nil || pending.ID != msg.ID {
+				if err := flush(); err != nil {
+					return err
+				}
+				pending = &msg
+				turn = ImportedTurn{
+					SourceID:  firstNonEmpty(rec.RequestID, msg.ID),
+					StartedAt: parseTraceTime(rec.Timestamp),
+					SessionID: rec.SessionID,
+				}
+			}
+			pending.Blocks = append(pending.Blocks, contentBlocks(msg.Content)...)
+			if msg.StopReason != "" {
+				pending.StopReason = msg.StopReason
+			}
+			if present(msg.Usage) {
+				pending.Usage = msg.Usage
+			}
+			turn.EndedAt = parseTraceTime(rec.Timestamp)
+			return nil
+
+		default:
+			skip(ref, "record type "+rec.Type)
+			return nil
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+// contentBlocks reads a message's content as blocks: an array as-is, a
+// bare string as one text block.
+func contentBlocks(content json.RawMessage) []json.RawMessage {
+	var blocks []json.RawMessage
+	if json.Unmarshal(content, &blocks) == nil {
+		return blocks
+	}
+	var text string
+	if json.Unmarshal(content, &text) == nil && strings.TrimSpace(text) != "" {
+		return []json.RawMessage{marshalRaw(map[string]string{"type": "text", "text": text})}
+	}
+	return nil
+}
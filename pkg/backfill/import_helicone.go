@@ -0,0 +1,90 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+const formatHelicone = "helicone"
+
+// heliconeImporter reads Helicone request logs: the request objects the
+// request query API and the dashboard export return, one per record.
+// Helicone stores the provider request and response bodies as proxied, so
+// calls map verbatim. Sessions come from the Helicone-Session-Id property.
+type heliconeImporter struct{}
+
+type heliconeRequest struct {
+	RequestID         string            `json:"request_id"`
+	RequestCreatedAt  json.RawMessage   `json:"request_created_at"`
+	ResponseCreatedAt json.RawMessage   `json:"response_created_at"`
+	DelayMs           float64           `json:"delay_ms"`
+	Provider          string            `json:"provider"`
+	Model             string            `json:"model"`
+	Status            int               `json:"response_status"`
+	RequestBody       json.RawMessage   `json:"request_body"`
+	ResponseBody      json.RawMessage   `json:"response_body"`
+	Properties        map[string]string `json:"request_properties"`
+	Path              string            `json:"request_path"`
+}
+
+func (heliconeImporter) Format() string { return formatHelicone }
+
+func (heliconeImporter) Import(ctx context.Context, r io.Reader, emit func(ImportedTurn) error, skip func(ref, reason string)) error {
+	return eachRecord(ctx, r, func(ref string, raw json.RawMessage) error {
+		var req heliconeRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			skip(ref, "not a request log: "+err.Error())
+			return nil
+		}
+		request, response := unquoteJSON(req.RequestBody), unquoteJSON(req.ResponseBody)
+		if !present(request) || !present(response) {
+			skip(ref, "request "+req.RequestID+" has no stored bodies")
+			return nil
+		}
+		if strings.Contains(req.Path, "embeddings") {
+			skip(ref, "embeddings request")
+			return nil
+		}
+		if req.Status >= 400 {
+			skip(ref, "request "+req.RequestID+" failed upstream")
+			return nil
+		}
+
+		provider := providerShape(response)
+		if provider == "" {
+			skip(ref, "response body is not a Messages or Chat Completions response")
+			return nil
+		}
+
+		turn := ImportedTurn{
+			Provider:       provider,
+			Request:        withModel(request, req.Model),
+			Response:       response,
+			Verbatim:       true,
+			SourceID:       req.RequestID,
+			StartedAt:      parseTraceTime(req.RequestCreatedAt),
+			EndedAt:        parseTraceTime(req.ResponseCreatedAt),
+			UpstreamStatus: req.Status,
+			SessionID:      heliconeProperty(req.Properties, "Helicone-Session-Id"),
+			SessionName:    heliconeProperty(req.Properties, "Helicone-Session-Name"),
+		}
+		if turn.EndedAt.IsZero() && !turn.StartedAt.IsZero() && req.DelayMs > 0 {
+			turn.EndedAt = turn.StartedAt.Add(time.Duration(req.DelayMs * float64(time.Millisecond)))
+		}
+		return emit(turn)
+	})
+}
+
+// heliconeProperty looks a custom property up case-insensitively: Helicone
+// stores the header name as the client sent it.
+func heliconeProperty(props map[string]string, name string) string {
+	for k, v := range props {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
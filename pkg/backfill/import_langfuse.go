@@ -0,0 +1,181 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+const formatLangfuse = "langfuse"
+
+// langfuseImporter reads Langfuse exports: trace objects with their
+// observations nested (the traces API with fields=observations), or bare
+// observation objects (the observations API, one per record).
+//
+// Only GENERATION observations are calls. Langfuse keeps the prompt and
+// the output message rather than provider bodies, so every generation is
+// rebuilt as a Chat Completions pair.
+type langfuseImporter struct{}
+
+type langfuseTrace struct {
+	ID           string                `json:"id"`
+	SessionID    string                `json:"sessionId"`
+	Name         string                `json:"name"`
+	Observations []langfuseObservation `json:"observations"`
+}
+
+type langfuseObservation struct {
+	ID              string                     `json:"id"`
+	TraceID         string                     `json:"traceId"`
+	SessionID       string                     `json:"sessionId"`
+	Type            string                     `json:"type"`
+	Name            string                     `json:"name"`
+	StartTime       json.RawMessage            `json:"startTime"`
+	EndTime         json.RawMessage            `json:"endTime"`
+	Model           string                     `json:"model"`
+	ModelParameters map[string]json.RawMessage `json:"modelParameters"`
+	Input           json.RawMessage            `json:"input"`
+	Output          json.RawMessage            `json:"output"`
+	Usage           *langfuseUsage             `json:"usage"`
+	UsageDetails    map[string]int             `json:"usageDetails"`
+}
+
+type langfuseUsage struct {
+	Input  int `json:"input"`
+	Output int `json:"output"`
+	Total  int `json:"total"`
+}
+
+func (langfuseImporter) Format() string { return formatLangfuse }
+
+func (langfuseImporter) Import(ctx context.Context, r io.Reader, emit func(ImportedTurn) error, skip func(ref, reason string)) error {
+	return eachRecord(ctx, r, func(ref string, raw json.RawMessage) error {
+		var trace langfuseTrace
+		if err := json.Unmarshal(raw, &trace); err != nil {
+			skip(ref, "not a trace or observation: "+err.Error())
+			return nil
+		}
+		if trace.Observations == nil {
+			var obs langfuseObservation
+			_ = json.Unmarshal(raw, &obs)
+			return langfuseGeneration(ref, obs, langfuseTrace{ID: obs.TraceID, SessionID: obs.SessionID}, emit, skip)
+		}
+		for i, obs := range trace.Observations {
+			if err := langfuseGeneration(ref+" observation "+strconv.Itoa(i+1), obs, trace, emit, skip); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func langfuseGeneration(ref string, obs langfuseObservation, trace langfuseTrace, emit func(ImportedTurn) error, skip func(ref, reason string)) error {
+	if obs.Type != "GENERATION" {
+		skip(ref, "observation type "+obs.Type)
+		return nil
+	}
+	messages, ok := langfuseMessages(obs.Input)
+	if !ok {
+		skip(ref, "generation input carries no messages")
+		return nil
+	}
+	message, ok := langfuseOutput(obs.Output)
+	if !ok {
+		skip(ref, "generation has no output")
+		return nil
+	}
+
+	turn := ImportedTurn{
+		Provider:    "openai",
+		SourceID:    obs.ID,
+		StartedAt:   parseTraceTime(obs.StartTime),
+		EndedAt:     parseTraceTime(obs.EndTime),
+		SessionID:   trace.SessionID,
+		SessionName: trace.Name,
+	}
+	if turn.SessionID == "" {
+		turn.SessionID = trace.ID
+	}
+
+	var usage *chatCompletionUsage
+	switch {
+	case obs.UsageDetails != nil:
+		usage = &chatCompletionUsage{PromptTokens: obs.UsageDetails["input"], CompletionTokens: obs.UsageDetails["output"]}
+	case obs.Usage != nil:
+		usage = &chatCompletionUsage{PromptTokens: obs.Usage.Input, CompletionTokens: obs.Usage.Output}
+	}
+	if usage != nil {
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+
+	turn.Request = buildChatRequest(obs.Model, messages, numericParams(obs.ModelParameters))
+	turn.Response = buildChatCompletion(obs.ID, obs.Model, turn.EndedAt, message, "stop", usage)
+	return emit(turn)
+}
+
+// langfuseMessages reads a generation's input: a message array, an object
+// holding one under "messages", or a bare prompt string.
+func langfuseMessages(input json.RawMessage) (json.RawMessage, bool) {
+	var list []json.RawMessage
+	if json.Unmarshal(input, &list) == nil && len(list) > 0 {
+		return input, true
+	}
+	var wrapped struct {
+		Messages []json.RawMessage `json:"messages"`
+	}
+	if json.Unmarshal(input, &wrapped) == nil && len(wrapped.Messages) > 0 {
+		out, _ := json.Marshal(wrapped.Messages)
+		return out, true
+	}
+	var prompt string
+	if json.Unmarshal(input, &prompt) == nil && prompt != "" {
+		out, _ := json.Marshal([]map[string]string{{"role": "user", "content": prompt}})
+		return out, true
+	}
+	return nil, false
+}
+
+// langfuseOutput reads a generation's output as an assistant message: a
+// message object, or a bare completion string.
+func langfuseOutput(output json.RawMessage) (json.RawMessage, bool) {
+	var text string
+	if json.Unmarshal(output, &text) == nil {
+		if text == "" {
+			return nil, false
+		}
+		out, _ := json.Marshal(map[string]string{"role": "assistant", "content": text})
+		return out, true
+	}
+	var msg map[string]json.RawMessage
+	if json.Unmarshal(output, &msg) != nil || len(msg) == 0 {
+		return nil, false
+	}
+	if !present(msg["role"]) {
+		msg["role"], _ = json.Marshal("assistant")
+	}
+	out, _ := json.Marshal(msg)
+	return out, true
+}
+
+// numericParams keeps the sampling parameters a request can carry as
+// numbers or booleans. Langfuse records model parameters as display
+// values, so a numeric string is read back as the number it was.
+func numericParams(params map[string]json.RawMessage) map[string]json.RawMessage {
+	out := map[string]json.RawMessage{}
+	for k, v := range params {
+		var s string
+		if json.Unmarshal(v, &s) == nil {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				out[k], _ = json.Marshal(f)
+			}
+			continue
+		}
+		var f float64
+		var b bool
+		if json.Unmarshal(v, &f) == nil || json.Unmarshal(v, &b) == nil {
+			out[k] = v
+		}
+	}
+	return out
+}
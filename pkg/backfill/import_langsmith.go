@@ -0,0 +1,195 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+)
+
+const formatLangSmith = "langsmith"
+
+// langSmithImporter reads LangSmith run exports: a JSON array or JSONL of
+// run objects, as the runs API and the UI's export write them.
+//
+// Only llm runs are calls. A run traced by wrap_openai or wrap_anthropic
+// keeps the SDK's request kwargs as inputs and the provider response as
+// outputs, so it maps verbatim. A LangChain chat-model run keeps
+// serialized messages and generations instead; those are rebuilt as a Chat
+// Completions pair carrying the same text.
+type langSmithImporter struct{}
+
+type langSmithRun struct {
+	ID        string          `json:"id"`
+	TraceID   string          `json:"trace_id"`
+	Name      string          `json:"name"`
+	RunType   string          `json:"run_type"`
+	StartTime json.RawMessage `json:"start_time"`
+	EndTime   json.RawMessage `json:"end_time"`
+	Inputs    json.RawMessage `json:"inputs"`
+	Outputs   json.RawMessage `json:"outputs"`
+	Extra     struct {
+		InvocationParams map[string]json.RawMessage `json:"invocation_params"`
+		Metadata         map[string]json.RawMessage `json:"metadata"`
+	} `json:"extra"`
+}
+
+func (langSmithImporter) Format() string { return formatLangSmith }
+
+func (langSmithImporter) Import(ctx context.Context, r io.Reader, emit func(ImportedTurn) error, skip func(ref, reason string)) error {
+	return eachRecord(ctx, r, func(ref string, raw json.RawMessage) error {
+		var run langSmithRun
+		if err := json.Unmarshal(raw, &run); err != nil {
+			skip(ref, "not a run object: "+err.Error())
+			return nil
+		}
+		if run.RunType != "llm" {
+			skip(ref, "run_type "+run.RunType)
+			return nil
+		}
+		if !present(run.Outputs) {
+			skip(ref, "llm run has no outputs (errored or still running)")
+			return nil
+		}
+
+		turn := ImportedTurn{
+			SourceID:  run.ID,
+			StartedAt: parseTraceTime(run.StartTime),
+			EndedAt:   parseTraceTime(run.EndTime),
+			SessionID: firstMetadataString(run.Extra.Metadata, "thread_id", "session_id", "conversation_id"),
+		}
+		if turn.SessionID == "" {
+			turn.SessionID = run.TraceID
+		}
+		model := firstMetadataString(run.Extra.InvocationParams, "model", "model_name")
+		if model == "" {
+			model = firstMetadataString(run.Extra.Metadata, "ls_model_name")
+		}
+
+		switch providerShape(run.Outputs) {
+		case "anthropic", "openai":
+			turn.Provider = providerShape(run.Outputs)
+			turn.Request = withModel(run.Inputs, model)
+			turn.Response = run.Outputs
+			turn.Verbatim = true
+		default:
+			messages, ok := langChainMessages(run.Inputs)
+			if !ok {
+				skip(ref, "llm run inputs carry no messages")
+				return nil
+			}
+			text, usage, ok := langChainGeneration(run.Outputs)
+			if !ok {
+				skip(ref, "llm run outputs carry no generation")
+				return nil
+			}
+			message, _ := json.Marshal(map[string]string{"role": "assistant", "content": text})
+			turn.Provider = "openai"
+			turn.Request = buildChatRequest(model, messages, nil)
+			turn.Response = buildChatCompletion(run.ID, model, turn.EndedAt, message, "stop", usage)
+		}
+		return emit(turn)
+	})
+}
+
+// langChainMessages recovers a Chat Completions message list from LangChain
+// chat-model inputs: {"messages": [[...]]} of serialized or dict messages,
+// or {"prompts": ["..."]} for a completion-style model.
+func langChainMessages(inputs json.RawMessage) (json.RawMessage, bool) {
+	var in struct {
+		Messages json.RawMessage `json:"messages"`
+		Prompts  []string        `json:"prompts"`
+	}
+	if json.Unmarshal(inputs, &in) != nil {
+		return nil, false
+	}
+
+	var batches [][]json.RawMessage
+	if json.Unmarshal(in.Messages, &batches) != nil || len(batches) == 0 {
+		var flat []json.RawMessage
+		if json.Unmarshal(in.Messages, &flat) == nil && len(flat) > 0 {
+			batches = [][]json.RawMessage{flat}
+		}
+	}
+	var out []map[string]any
+	if len(batches) > 0 {
+		for _, m := range batches[0] {
+			if msg, ok := langChainMessage(m); ok {
+				out = append(out, msg)
+			}
+		}
+	} else {
+		for _, p := range in.Prompts {
+			out = append(out, map[string]any{"role": "user", "content": p})
+		}
+	}
+	if len(out) == 0 {
+		return nil, false
+	}
+	raw, _ := json.Marshal(out)
+	return raw, true
+}
+
+// langChainRoles maps LangChain message types onto Chat Completions roles.
+var langChainRoles = map[string]string{
+	"human": "user", "HumanMessage": "user", "user": "user",
+	"ai": "assistant", "AIMessage": "assistant", "assistant": "assistant",
+	"system": "system", "SystemMessage": "system",
+	"tool": "tool", "ToolMessage": "tool",
+}
+
+// langChainMessage reads one message: a {"role","content"} dict, a
+// {"type","content"} dict, or a serialized {"lc":1,"id":[...],"kwargs":{}}.
+func langChainMessage(raw json.RawMessage) (map[string]any, bool) {
+	var m struct {
+		Role    string          `json:"role"`
+		Type    string          `json:"type"`
+		Content json.RawMessage `json:"content"`
+		ID      []string        `json:"id"`
+		Kwargs  *struct {
+			Type       string          `json:"type"`
+			Content    json.RawMessage `json:"content"`
+			ToolCallID string          `json:"tool_call_id"`
+		} `json:"kwargs"`
+		ToolCallID string `json:"tool_call_id"`
+	}
+	if json.Unmarshal(raw, &m) != nil {
+		return nil, false
+	}
+	role, content, toolCallID := m.Role, m.Content, m.ToolCallID
+	if role == "" {
+		role = m.Type
+	}
+	if m.Kwargs != nil {
+		content, toolCallID = m.Kwargs.Content, m.Kwargs.ToolCallID
+		role = m.Kwargs.Type
+		if role == "" && len(m.ID) > 0 {
+			role = m.ID[len(m.ID)-1]
+		}
+	}
+	role, ok := langChainRoles[role]
+	if !ok || !present(content) {
+		return nil, false
+	}
+	msg := map[string]any{"role": role, "content": content}
+	if toolCallID != "" {
+		msg["tool_call_id"] = toolCallID
+	}
+	return msg, true
+}
+
+// langChainGeneration reads the first generation's text and the token usage
+// from LangChain llm outputs.
+func langChainGeneration(outputs json.RawMessage) (string, *chatCompletionUsage, bool) {
+	var out struct {
+		Generations [][]struct {
+			Text string `json:"text"`
+		} `json:"generations"`
+		LLMOutput struct {
+			TokenUsage *chatCompletionUsage `json:"token_usage"`
+		} `json:"llm_output"`
+	}
+	if json.Unmarshal(outputs, &out) != nil || len(out.Generations) == 0 || len(out.Generations[0]) == 0 {
+		return "", nil, false
+	}
+	return out.Generations[0][0].Text, out.LLMOutput.TokenUsage, true
+}
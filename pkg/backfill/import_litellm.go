@@ -0,0 +1,76 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+)
+
+const formatLiteLLM = "litellm"
+
+// liteLLMImporter reads LiteLLM request logs: StandardLoggingPayload
+// objects (what the logging callbacks emit) or LiteLLM_SpendLogs rows,
+// one per record.
+//
+// LiteLLM logs every call in the OpenAI shape whichever provider served it,
+// so calls map to Chat Completions pairs; the model keeps its provider
+// prefix. Spend-log rows carry messages and response only when the proxy
+// ran with store_prompts_in_spend_logs.
+type liteLLMImporter struct{}
+
+type liteLLMLog struct {
+	ID              string                     `json:"id"`
+	RequestID       string                     `json:"request_id"`
+	CallType        string                     `json:"call_type"`
+	Model           string                     `json:"model"`
+	StartTime       json.RawMessage            `json:"startTime"`
+	EndTime         json.RawMessage            `json:"endTime"`
+	Status          string                     `json:"status"`
+	Messages        json.RawMessage            `json:"messages"`
+	Response        json.RawMessage            `json:"response"`
+	ModelParameters map[string]json.RawMessage `json:"model_parameters"`
+	SessionID       string                     `json:"session_id"`
+	TraceID         string                     `json:"trace_id"`
+	Metadata        map[string]json.RawMessage `json:"metadata"`
+}
+
+// liteLLMChatCallTypes are the call types that are chat completions.
+var liteLLMChatCallTypes = map[string]bool{
+	"completion": true, "acompletion": true,
+}
+
+func (liteLLMImporter) Format() string { return formatLiteLLM }
+
+func (liteLLMImporter) Import(ctx context.Context, r io.Reader, emit func(ImportedTurn) error, skip func(ref, reason string)) error {
+	return eachRecord(ctx, r, func(ref string, raw json.RawMessage) error {
+		var log liteLLMLog
+		if err := json.Unmarshal(raw, &log); err != nil {
+			skip(ref, "not a request log: "+err.Error())
+			return nil
+		}
+		if !liteLLMChatCallTypes[log.CallType] {
+			skip(ref, "call_type "+log.CallType)
+			return nil
+		}
+		if log.Status == "failure" {
+			skip(ref, "call failed upstream")
+			return nil
+		}
+		messages, response := unquoteJSON(log.Messages), unquoteJSON(log.Response)
+		if !present(messages) || !present(response) {
+			skip(ref, "messages or response not logged (store_prompts_in_spend_logs off?)")
+			return nil
+		}
+
+		turn := ImportedTurn{
+			Provider:  "openai",
+			Request:   buildChatRequest(log.Model, messages, numericParams(log.ModelParameters)),
+			Response:  response,
+			SourceID:  firstNonEmpty(log.ID, log.RequestID),
+			StartedAt: parseTraceTime(log.StartTime),
+			EndedAt:   parseTraceTime(log.EndTime),
+			SessionID: firstNonEmpty(log.SessionID, firstMetadataString(log.Metadata, "session_id"), log.TraceID),
+		}
+		return emit(turn)
+	})
+}
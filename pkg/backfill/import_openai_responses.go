@@ -0,0 +1,147 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+)
+
+const formatOpenAIResponses = "openai-responses"
+
+// openAIResponsesImporter reads stored OpenAI Responses: one Response
+// object per record, as GET /v1/responses/{id} returns it. A Response does
+// not carry its input, so a record may pair it with the items
+// GET /v1/responses/{id}/input_items?order=asc returned:
+//
+//	{"response": {...}, "input_items": [...]}
+//
+// input_items may also be the list object itself ({"data": [...]}). A bare
+// Response imports with only its previous_response_id as input.
+//
+// Responses chained by previous_response_id form one session, keyed by
+// the first response in the chain; a response in a conversation uses the
+// conversation id instead. The chain is followed in export order, so a
+// parent exported after its child starts a session of its own.
+type openAIResponsesImporter struct {
+	// roots maps each response id seen to its chain's first response.
+	roots map[string]string
+}
+
+type openAIStoredResponse struct {
+	ID                 string          `json:"id"`
+	Object             string          `json:"object"`
+	CreatedAt          json.RawMessage `json:"created_at"`
+	Model              string          `json:"model"`
+	PreviousResponseID string          `json:"previous_response_id"`
+	Conversation       json.RawMessage `json:"conversation"`
+	Error              json.RawMessage `json:"error"`
+}
+
+// openAIResponsesRequestKeys are the Response fields that echo the request
+// that produced it.
+var openAIResponsesRequestKeys = []string{
+	"model", "instructions", "previous_response_id", "tools", "tool_choice",
+	"parallel_tool_calls", "temperature", "top_p", "max_output_tokens",
+	"reasoning", "text", "truncation", "store", "metadata", "service_tier",
+}
+
+func (*openAIResponsesImporter) Format() string { return formatOpenAIResponses }
+
+func (imp *openAIResponsesImporter) Import(ctx context.Context, r io.Reader, emit func(ImportedTurn) error, skip func(ref, reason string)) error {
+	if imp.roots == nil {
+		imp.roots = map[string]string{}
+	}
+	return eachRecord(ctx, r, func(ref string, raw json.RawMessage) error {
+		var wrapped struct {
+			Response   json.RawMessage `json:"response"`
+			InputItems json.RawMessage `json:"input_items"`
+		}
+		_ = json.Unmarshal(raw, &wrapped)
+		body := raw
+		if present(wrapped.Response) {
+			body = wrapped.Response
+		}
+
+		var resp openAIStoredResponse
+		if err := json.Unmarshal(body, &resp); err != nil || resp.Object != "response" {
+			skip(ref, "not a Response object")
+			return nil
+		}
+		if present(resp.Error) {
+			skip(ref, "response "+resp.ID+" failed upstream")
+			return nil
+		}
+
+		request, err := openAIResponsesRequest(body, wrapped.InputItems)
+		if err != nil {
+			skip(ref, "response "+resp.ID+": "+err.Error())
+			return nil
+		}
+
+		root := resp.ID
+		if resp.PreviousResponseID != "" {
+			root = resp.PreviousResponseID
+			if r, ok := imp.roots[resp.PreviousResponseID]; ok {
+				root = r
+			}
+		}
+		imp.roots[resp.ID] = root
+		sessionID := root
+		if id := conversationID(resp.Conversation); id != "" {
+			sessionID = id
+		}
+
+		return emit(ImportedTurn{
+			Provider:  "openai",
+			Request:   request,
+			Response:  body,
+			Verbatim:  true,
+			SourceID:  resp.ID,
+			StartedAt: parseTraceTime(resp.CreatedAt),
+			SessionID: sessionID,
+		})
+	})
+}
+
+// openAIResponsesRequest rebuilds the request a stored Response answered:
+// the request fields the Response echoes, plus the input items.
+func openAIResponsesRequest(body, inputItems json.RawMessage) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	req := map[string]json.RawMessage{}
+	for _, k := range openAIResponsesRequestKeys {
+		if present(fields[k]) {
+			req[k] = fields[k]
+		}
+	}
+
+	var list struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if json.Unmarshal(inputItems, &list) == nil && present(list.Data) {
+		inputItems = list.Data
+	}
+	// An empty input still marks the body as a Responses request rather
+	// than a Chat Completions one with its messages missing.
+	req["input"] = json.RawMessage("[]")
+	if present(inputItems) {
+		req["input"] = inputItems
+	}
+	return json.Marshal(req)
+}
+
+// conversationID reads a Response's conversation, which the API spells as
+// an object ({"id": "conv_..."}) or a bare id.
+func conversationID(raw json.RawMessage) string {
+	var id string
+	if json.Unmarshal(raw, &id) == nil {
+		return id
+	}
+	var obj struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(raw, &obj)
+	return obj.ID
+}
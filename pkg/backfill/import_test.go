@@ -0,0 +1,250 @@
+package backfill_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/backfill"
+	"github.com/papercomputeco/tapes/pkg/llm"
+	"github.com/papercomputeco/tapes/pkg/llm/provider"
+	"github.com/papercomputeco/tapes/pkg/sessions"
+)
+
+// importedTurn is the /v1/ingest envelope an import posts, as the batch
+// endpoint receives it.
+type importedTurn struct {
+	Provider    string                  `json:"provider"`
+	Request     json.RawMessage         `json:"request"`
+	Response    llm.ChatResponse        `json:"response"`
+	RawResponse []byte                  `json:"raw_response"`
+	Meta        map[string]any          `json:"meta"`
+	Session     sessions.IngestEnvelope `json:"session"`
+}
+
+// recordingBatchServer accepts every batch line and keeps the turns.
+type recordingBatchServer struct {
+	mu    sync.Mutex
+	turns []importedTurn
+}
+
+func (s *recordingBatchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	zr, err := gzip.NewReader(r.Body)
+	Expect(err).NotTo(HaveOccurred())
+	enc := json.NewEncoder(w)
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var bl struct {
+			Turn importedTurn `json:"turn"`
+		}
+		Expect(json.Unmarshal(scanner.Bytes(), &bl)).To(Succeed())
+		s.mu.Lock()
+		s.turns = append(s.turns, bl.Turn)
+		s.mu.Unlock()
+		Expect(enc.Encode(map[string]any{"line": line, "kind": "turn", "status": "accepted"})).To(Succeed())
+	}
+}
+
+var _ = Describe("third-party trace import", func() {
+	var (
+		dir  string
+		rec  *recordingBatchServer
+		srv  *httptest.Server
+		opts backfill.ImportOptions
+	)
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		rec = &recordingBatchServer{}
+		srv = httptest.NewServer(rec)
+		opts = backfill.ImportOptions{IngestURL: srv.URL}
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	run := func(format, content string) (*backfill.ImportResult, []importedTurn) {
+		path := filepath.Join(dir, format+".jsonl")
+		Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+		opts.Format, opts.Path = format, path
+		result, err := backfill.Import(context.Background(), opts)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Every request must parse the way ingest will parse it.
+		for _, t := range rec.turns {
+			p, err := provider.New(t.Provider)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = p.ParseRequest(t.Request)
+			Expect(err).NotTo(HaveOccurred(), string(t.Request))
+		}
+		return result, rec.turns
+	}
+
+	text := func(r llm.ChatResponse) string {
+		var out string
+		for _, b := range r.Message.Content {
+			out += b.Text
+		}
+		return out
+	}
+
+	It("rejects an unknown format up front", func() {
+		_, err := backfill.Import(context.Background(), backfill.ImportOptions{Format: "nope", Path: dir})
+		Expect(err).To(MatchError(ContainSubstring("unknown import format")))
+	})
+
+	It("maps LangSmith llm runs, verbatim or rebuilt from LangChain generations", func() {
+		result, turns := run("langsmith", `[
+  {"id":"run-1","trace_id":"trace-1","run_type":"llm","name":"ChatOpenAI",
+   "start_time":"2025-03-01T10:00:00.000000","end_time":"2025-03-01T10:00:02.000000",
+   "inputs":{"messages":[{"role":"user","content":"hi"}]},
+   "outputs":{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"hello"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}},
+   "extra":{"invocation_params":{"model":"gpt-4o"},"metadata":{"thread_id":"thread-9"}}},
+  {"id":"run-2","trace_id":"trace-2","run_type":"llm",
+   "inputs":{"messages":[[{"lc":1,"type":"constructor","id":["langchain","schema","messages","HumanMessage"],"kwargs":{"content":"what is 2+2","type":"human"}}]]},
+   "outputs":{"generations":[[{"text":"4"}]],"llm_output":{"token_usage":{"prompt_tokens":5,"completion_tokens":1,"total_tokens":6}}},
+   "extra":{"invocation_params":{"model_name":"gpt-4o-mini"}}},
+  {"id":"run-3","trace_id":"trace-2","run_type":"chain","inputs":{},"outputs":{}}
+]`)
+		Expect(result.Turns).To(Equal(2))
+		Expect(result.Skipped).To(Equal(1))
+		Expect(result.Accepted).To(Equal(2))
+
+		Expect(turns[0].Provider).To(Equal("openai"))
+		Expect(text(turns[0].Response)).To(Equal("hello"))
+		Expect(turns[0].RawResponse).NotTo(BeEmpty(), "a wrapped SDK run keeps the provider body")
+		Expect(turns[0].Meta).To(HaveKeyWithValue("request_id", "langsmith:run-1"))
+		Expect(turns[0].Meta).To(HaveKeyWithValue("backfill_source", "import/langsmith"))
+		Expect(turns[0].Meta).To(HaveKeyWithValue("ts_request", "2025-03-01T10:00:00Z"))
+		Expect(turns[0].Session.HarnessID).To(Equal("langsmith"))
+		Expect(turns[0].Session.HarnessSessionID).To(Equal("thread-9"))
+		Expect(string(turns[0].Session.HarnessMetadata)).To(MatchJSON(`{"import_format":"langsmith"}`))
+
+		Expect(text(turns[1].Response)).To(Equal("4"))
+		Expect(turns[1].Response.Usage.PromptTokens).To(Equal(5))
+		Expect(turns[1].RawResponse).To(BeEmpty(), "a rebuilt response is not stored as raw bytes")
+		Expect(turns[1].Session.HarnessSessionID).To(Equal("trace-2"))
+		Expect(string(turns[1].Request)).To(ContainSubstring(`"model":"gpt-4o-mini"`))
+	})
+
+	It("maps Langfuse generations nested in traces and on their own", func() {
+		result, turns := run("langfuse", `{"id":"tr-1","sessionId":"sess-1","name":"support","observations":[
+  {"id":"obs-1","type":"SPAN","name":"retrieve"},
+  {"id":"obs-2","type":"GENERATION","model":"claude-3-5-sonnet","modelParameters":{"temperature":"0.2","max_tokens":"256"},
+   "startTime":"2025-03-01T10:00:00Z","endTime":"2025-03-01T10:00:01.5Z",
+   "input":[{"role":"system","content":"be brief"},{"role":"user","content":"hi"}],
+   "output":{"role":"assistant","content":"hello"},"usageDetails":{"input":7,"output":2}}]}
+{"id":"obs-3","traceId":"tr-2","type":"GENERATION","model":"gpt-4o","input":"say hi","output":"hi"}
+`)
+		Expect(result.Turns).To(Equal(2))
+		Expect(result.Skipped).To(Equal(1))
+
+		Expect(turns[0].Session.HarnessSessionID).To(Equal("sess-1"))
+		Expect(turns[0].Session.Name).To(Equal("support"))
+		Expect(text(turns[0].Response)).To(Equal("hello"))
+		Expect(turns[0].Response.Usage.CompletionTokens).To(Equal(2))
+		Expect(turns[0].Response.Usage.TotalDurationNs).To(BeEquivalentTo(1_500_000_000))
+		Expect(string(turns[0].Request)).To(ContainSubstring(`"temperature":0.2`))
+
+		Expect(turns[1].Session.HarnessSessionID).To(Equal("tr-2"))
+		Expect(text(turns[1].Response)).To(Equal("hi"))
+	})
+
+	It("chains stored OpenAI Responses into one session", func() {
+		result, turns := run("openai-responses", `{"response":{"id":"resp_1","object":"response","created_at":1740823200,"model":"gpt-4.1","status":"completed","output":[{"type":"message","id":"msg_1","role":"assistant","status":"completed","content":[{"type":"output_text","text":"first"}]}],"usage":{"input_tokens":4,"output_tokens":1,"total_tokens":5}},
+ "input_items":{"object":"list","data":[{"type":"message","role":"user","content":[{"type":"input_text","text":"one"}]}]}}
+{"id":"resp_2","object":"response","created_at":1740823260,"model":"gpt-4.1","status":"completed","previous_response_id":"resp_1","output":[{"type":"message","id":"msg_2","role":"assistant","status":"completed","content":[{"type":"output_text","text":"second"}]}]}
+`)
+		Expect(result.Turns).To(Equal(2))
+		Expect(turns[0].Session.HarnessSessionID).To(Equal("resp_1"))
+		Expect(turns[1].Session.HarnessSessionID).To(Equal("resp_1"))
+		Expect(text(turns[0].Response)).To(Equal("first"))
+		Expect(text(turns[1].Response)).To(Equal("second"))
+		Expect(turns[0].Meta).To(HaveKeyWithValue("endpoint", "responses"))
+		Expect(string(turns[0].Request)).To(ContainSubstring(`"input_text"`))
+		Expect(string(turns[1].Request)).To(ContainSubstring(`"previous_response_id":"resp_1"`))
+	})
+
+	It("rebuilds Claude Code calls from a transcript, merging per-block records", func() {
+		result, turns := run("claude-code", `{"type":"summary","summary":"greeting"}
+{"type":"user","sessionId":"0ea3c2cc-fe9d-41ff-aab1-4134ad00c350","cwd":"/work","version":"1.0.80","timestamp":"2025-03-01T10:00:00Z","message":{"role":"user","content":"hi"}}
+{"type":"assistant","sessionId":"0ea3c2cc-fe9d-41ff-aab1-4134ad00c350","requestId":"req_1","timestamp":"2025-03-01T10:00:01Z","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4","content":[{"type":"text","text":"let me look"}],"stop_reason":null}}
+{"type":"assistant","sessionId":"0ea3c2cc-fe9d-41ff-aab1-4134ad00c350","requestId":"req_1","timestamp":"2025-03-01T10:00:02Z","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4","content":[{"type":"tool_use","id":"tu_1","name":"Read","input":{"path":"a"}}],"stop_reason":"tool_use","usage":{"input_tokens":10,"output_tokens":5}}}
+{"type":"user","sessionId":"0ea3c2cc-fe9d-41ff-aab1-4134ad00c350","isSidechain":true,"message":{"role":"user","content":"subagent"}}
+{"type":"user","sessionId":"0ea3c2cc-fe9d-41ff-aab1-4134ad00c350","timestamp":"2025-03-01T10:00:03Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"tu_1","content":"file a"}]}}
+{"type":"assistant","sessionId":"0ea3c2cc-fe9d-41ff-aab1-4134ad00c350","requestId":"req_2","timestamp":"2025-03-01T10:00:04Z","message":{"id":"msg_2","type":"message","role":"assistant","model":"claude-sonnet-4","content":[{"type":"text","text":"done"}],"stop_reason":"end_turn","usage":{"input_tokens":20,"output_tokens":1}}}
+`)
+		Expect(result.Turns).To(Equal(2))
+		Expect(result.Skipped).To(Equal(2))
+
+		Expect(turns[0].Provider).To(Equal("anthropic"))
+		Expect(turns[0].Session.HarnessID).To(Equal("claude"))
+		Expect(turns[0].Session.HarnessSessionID).To(Equal("0ea3c2cc-fe9d-41ff-aab1-4134ad00c350"))
+		Expect(turns[0].Session.Cwd).To(Equal("/work"))
+		Expect(turns[0].Meta).To(HaveKeyWithValue("request_id", "claude-code:req_1"))
+		Expect(turns[0].Response.Message.Content).To(HaveLen(2))
+		Expect(turns[0].Response.StopReason).To(Equal("tool_use"))
+
+		var second struct {
+			Messages []json.RawMessage `json:"messages"`
+		}
+		Expect(json.Unmarshal(turns[1].Request, &second)).To(Succeed())
+		Expect(second.Messages).To(HaveLen(3), "user, the merged assistant message, tool result")
+		Expect(text(turns[1].Response)).To(Equal("done"))
+	})
+
+	It("maps Helicone logs verbatim with their session property", func() {
+		result, turns := run("helicone", `{"request_id":"h-1","request_created_at":"2025-03-01T10:00:00Z","delay_ms":1200,"response_status":200,"model":"claude-3-5-haiku",
+ "request_body":"{\"model\":\"claude-3-5-haiku\",\"max_tokens\":64,\"messages\":[{\"role\":\"user\",\"content\":\"hi\"}]}",
+ "response_body":{"id":"msg_h","type":"message","role":"assistant","model":"claude-3-5-haiku","content":[{"type":"text","text":"hey"}],"stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":1}},
+ "request_properties":{"helicone-session-id":"hs-1"}}
+{"request_id":"h-2","response_status":500,"request_body":{"model":"gpt-4o","messages":[]},"response_body":{"error":"boom"}}
+`)
+		Expect(result.Turns).To(Equal(1))
+		Expect(result.Skipped).To(Equal(1))
+		Expect(turns[0].Provider).To(Equal("anthropic"))
+		Expect(turns[0].Session.HarnessSessionID).To(Equal("hs-1"))
+		Expect(turns[0].RawResponse).NotTo(BeEmpty())
+		Expect(turns[0].Meta).To(HaveKeyWithValue("captured_at", "2025-03-01T10:00:01.2Z"))
+		Expect(text(turns[0].Response)).To(Equal("hey"))
+	})
+
+	It("maps LiteLLM logs and skips calls that are not chat completions", func() {
+		result, turns := run("litellm", `{"id":"ll-1","call_type":"acompletion","model":"anthropic/claude-3-5-sonnet","startTime":1740823200.5,"endTime":1740823201.5,"status":"success","trace_id":"lt-1",
+ "messages":[{"role":"user","content":"hi"}],"model_parameters":{"temperature":0.1},
+ "response":{"id":"chatcmpl-ll","object":"chat.completion","model":"claude-3-5-sonnet","choices":[{"index":0,"message":{"role":"assistant","content":"hello"},"finish_reason":"stop"}]}}
+{"id":"ll-2","call_type":"aembedding","model":"text-embedding-3-small"}
+{"request_id":"ll-3","call_type":"completion","model":"gpt-4o","messages":"{}","response":"{}"}
+`)
+		Expect(result.Turns).To(Equal(1))
+		Expect(result.Skipped).To(Equal(2))
+		Expect(turns[0].Session.HarnessSessionID).To(Equal("lt-1"))
+		Expect(turns[0].RawResponse).To(BeEmpty(), "LiteLLM logs a normalized response")
+		Expect(turns[0].Response.Usage).To(BeNil())
+		Expect(text(turns[0].Response)).To(Equal("hello"))
+		Expect(turns[0].Meta).To(HaveKeyWithValue("elapsed_seconds", BeNumerically("~", 1.0, 0.001)))
+	})
+
+	It("dedupes a re-import through stable request ids and reports nothing in a dry run", func() {
+		content := `{"id":"ll-1","call_type":"completion","model":"gpt-4o","messages":[{"role":"user","content":"hi"}],"response":{"choices":[{"message":{"role":"assistant","content":"hey"}}]}}`
+		_, first := run("litellm", content)
+		Expect(first).To(HaveLen(1))
+
+		opts.DryRun = true
+		result, again := run("litellm", content)
+		Expect(result.Turns).To(Equal(1))
+		Expect(again).To(HaveLen(1), "a dry run posts nothing")
+		Expect(first[0].Meta["request_id"]).To(Equal("litellm:ll-1"))
+	})
+})
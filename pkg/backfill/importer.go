@@ -0,0 +1,621 @@
+package backfill
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/papercomputeco/tapes/pkg/capture"
+	"github.com/papercomputeco/tapes/pkg/llm"
+	"github.com/papercomputeco/tapes/pkg/llm/provider"
+	"github.com/papercomputeco/tapes/pkg/sessions"
+)
+
+// Importer maps one third-party trace format onto ingest turns.
+//
+// An importer only translates: it reads records from r and emits one
+// ImportedTurn per LLM call it recognizes. Reduction, envelope building,
+// and the upload are the framework's, so every format lands in raw_turns
+// exactly the way a live capture of the same call would.
+type Importer interface {
+	// Format is the --format name, e.g. "langsmith".
+	Format() string
+
+	// Import reads one export file. skip reports a record that is not an
+	// LLM call (a chain run, a span, an embedding) so the run can count
+	// it; an error ends the file.
+	Import(ctx context.Context, r io.Reader, emit func(ImportedTurn) error, skip func(ref, reason string)) error
+}
+
+// ImportedTurn is one LLM call recovered from a third-party trace.
+type ImportedTurn struct {
+	// Provider is the wire format Request and Response are in: "anthropic"
+	// (Messages API) or "openai" (Chat Completions, or a Responses object).
+	// A trace that recorded a call in a normalized shape is mapped to the
+	// Chat Completions form, whatever model actually served it.
+	Provider string
+
+	// Request is the provider request body.
+	Request json.RawMessage
+
+	// Response is the provider's non-streaming response body.
+	Response json.RawMessage
+
+	// Verbatim says Response is the body exactly as the provider returned
+	// it, not one rebuilt from a trace's normalized fields. Only a
+	// verbatim body is stored as the raw layer's raw_response.
+	Verbatim bool
+
+	// SourceID is the call's id in the source system. It is the turn's
+	// idempotency key, so it must be stable across exports.
+	SourceID string
+
+	// StartedAt and EndedAt are the call's capture times; either may be
+	// zero when the trace did not record it.
+	StartedAt time.Time
+	EndedAt   time.Time
+
+	// UpstreamStatus is the provider's HTTP status, when recorded.
+	UpstreamStatus int
+
+	// SessionID groups calls into one session: the trace, thread, or
+	// conversation id the source system recorded. Empty lets ingest
+	// synthesize one per call.
+	SessionID string
+
+	// SessionName, Cwd, and HarnessVersion fill the session envelope when
+	// the source recorded them.
+	SessionName    string
+	Cwd            string
+	HarnessVersion string
+
+	// HarnessID overrides the session's harness; it defaults to the
+	// importer's format.
+	HarnessID string
+}
+
+// importers lists the formats `tapes backfill import` understands.
+// Constructed explicitly, as the capture reducers are, so no init() order
+// decides which formats exist.
+var importers = map[string]func() Importer{
+	formatLangSmith:       func() Importer { return langSmithImporter{} },
+	formatLangfuse:        func() Importer { return langfuseImporter{} },
+	formatOpenAIResponses: func() Importer { return &openAIResponsesImporter{} },
+	formatClaudeCode:      func() Importer { return claudeCodeImporter{} },
+	formatHelicone:        func() Importer { return heliconeImporter{} },
+	formatLiteLLM:         func() Importer { return liteLLMImporter{} },
+}
+
+// ImportFormats returns the importable format names, sorted.
+func ImportFormats() []string {
+	formats := make([]string, 0, len(importers))
+	for name := range importers {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// ImportOptions configures a third-party trace import.
+type ImportOptions struct {
+	// Format names the importer; see ImportFormats.
+	Format string
+
+	// Path is an export file, or a directory whose *.json, *.jsonl, and
+	// *.ndjson files (optionally .gz) are imported in name order. "-"
+	// reads stdin.
+	Path string
+
+	// IngestURL is the tapes-ingest base URL; turns are POSTed to
+	// {IngestURL}/v1/ingest/batch.
+	IngestURL string
+
+	// ChunkBytes caps one batch request; see NDJSONOptions.
+	ChunkBytes int
+
+	// DryRun maps and reduces every record but skips the upload.
+	DryRun bool
+
+	Verbose bool
+	Logf    func(format string, args ...any)
+}
+
+// ImportResult summarizes an import run.
+type ImportResult struct {
+	Files   int `json:"files"`
+	Turns   int `json:"turns"`
+	Skipped int `json:"skipped"`
+	BatchCounts
+}
+
+// importMetaBlock is the meta block of an imported turn: ingest.TurnMeta's
+// wire keys plus the provenance fields the raw layer keeps verbatim.
+type importMetaBlock struct {
+	RequestID      string  `json:"request_id"`
+	ContentType    string  `json:"content_type,omitempty"`
+	Method         string  `json:"method,omitempty"`
+	Path           string  `json:"path,omitempty"`
+	Endpoint       string  `json:"endpoint,omitempty"`
+	Model          string  `json:"model,omitempty"`
+	Stream         string  `json:"stream,omitempty"`
+	UpstreamStatus int     `json:"upstream_status,omitempty"`
+	RequestBytes   int     `json:"request_bytes,omitempty"`
+	ResponseBytes  int     `json:"response_bytes,omitempty"`
+	ElapsedSeconds float64 `json:"elapsed_seconds,omitempty"`
+	TsRequest      string  `json:"ts_request,omitempty"`
+	CapturedAt     string  `json:"captured_at,omitempty"`
+
+	// BackfillSource is "import/<format>"; SourceID and SourceFile say
+	// where in the export the turn came from.
+	BackfillSource string `json:"backfill_source"`
+	SourceID       string `json:"source_id"`
+	SourceFile     string `json:"source_file,omitempty"`
+}
+
+// importEnvelope is the /v1/ingest envelope an imported turn is sent as.
+type importEnvelope struct {
+	Provider    string                   `json:"provider"`
+	Request     json.RawMessage          `json:"request"`
+	Response    *llm.ChatResponse        `json:"response"`
+	RawResponse []byte                   `json:"raw_response,omitempty"`
+	Meta        importMetaBlock          `json:"meta"`
+	Session     *sessions.IngestEnvelope `json:"session,omitempty"`
+}
+
+// Import maps a third-party trace export onto raw turns and streams them
+// through POST /v1/ingest/batch. Each turn's request_id is derived from the
+// format and the call's source id, so re-importing the same export — or an
+// overlapping later one — dedupes instead of duplicating.
+func Import(ctx context.Context, opts ImportOptions) (*ImportResult, error) {
+	newImporter, ok := importers[opts.Format]
+	if !ok {
+		return nil, fmt.Errorf("unknown import format %q (supported: %s)", opts.Format, strings.Join(ImportFormats(), ", "))
+	}
+	if opts.Logf == nil {
+		opts.Logf = func(string, ...any) {}
+	}
+
+	files, err := importFiles(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{}
+	up := newBatchUploader(NDJSONOptions{
+		IngestURL:  opts.IngestURL,
+		ChunkBytes: opts.ChunkBytes,
+		Verbose:    opts.Verbose,
+		Logf:       opts.Logf,
+	}, &result.BatchCounts)
+
+	for _, path := range files {
+		result.Files++
+		if err := importFile(ctx, newImporter(), path, opts, up, result); err != nil {
+			return result, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+	return result, up.flush(ctx)
+}
+
+func importFile(ctx context.Context, imp Importer, path string, opts ImportOptions, up *batchUploader, result *ImportResult) error {
+	in, closeIn, err := openNDJSON(path)
+	if err != nil {
+		return err
+	}
+	defer closeIn()
+
+	skip := func(ref, reason string) {
+		result.Skipped++
+		if opts.Verbose {
+			opts.Logf("skip %s: %s", ref, reason)
+		}
+	}
+	emit := func(t ImportedTurn) error {
+		line, err := buildImportLine(ctx, imp.Format(), filepath.Base(path), t)
+		if err != nil {
+			// A call the importer recognized but the provider parser
+			// cannot read is a data problem in that one record, not a
+			// reason to abandon the export.
+			result.Rejected++
+			failure := t.SourceID + ": " + err.Error()
+			if len(result.Failures) < 25 {
+				result.Failures = append(result.Failures, failure)
+			}
+			opts.Logf("FAIL %s", failure)
+			return nil
+		}
+		result.Turns++
+		if opts.DryRun {
+			if opts.Verbose {
+				opts.Logf("dry-run %s: would post (session %q)", t.SourceID, t.SessionID)
+			}
+			return nil
+		}
+		return up.add(ctx, t.SourceID, line)
+	}
+	return imp.Import(ctx, in, emit, skip)
+}
+
+// importFiles resolves an import path to the files it names.
+func importFiles(path string) ([]string, error) {
+	if path == "-" {
+		return []string{path}, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("import path: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("read import dir: %w", err)
+	}
+	var files []string
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".gz")
+		if e.IsDir() || !slices.Contains([]string{".json", ".jsonl", ".ndjson"}, filepath.Ext(name)) {
+			continue
+		}
+		files = append(files, filepath.Join(path, e.Name()))
+	}
+	sort.Strings(files)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .json, .jsonl, or .ndjson files in %s", path)
+	}
+	return files, nil
+}
+
+// buildImportLine reduces one imported turn and encodes it as a batch line.
+func buildImportLine(ctx context.Context, format, sourceFile string, t ImportedTurn) ([]byte, error) {
+	if t.SourceID == "" {
+		return nil, errors.New("record has no id")
+	}
+	resp, endpoint, err := reduceImported(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	if resp.CreatedAt.IsZero() {
+		resp.CreatedAt = t.EndedAt
+	}
+	if resp.Usage != nil && resp.Usage.TotalDurationNs == 0 && !t.StartedAt.IsZero() && t.EndedAt.After(t.StartedAt) {
+		resp.Usage.TotalDurationNs = t.EndedAt.Sub(t.StartedAt).Nanoseconds()
+	}
+
+	var reqFields struct {
+		Model string `json:"model"`
+	}
+	_ = json.Unmarshal(t.Request, &reqFields)
+	model := reqFields.Model
+	if model == "" {
+		model = resp.Model
+	}
+
+	envelope := importEnvelope{
+		Provider: t.Provider,
+		Request:  t.Request,
+		Response: resp,
+		Meta: importMetaBlock{
+			// Namespaced by format so two systems' ids cannot collide in
+			// the raw layer's (org, request_id) dedup key.
+			RequestID:      format + ":" + t.SourceID,
+			ContentType:    "application/json",
+			Method:         "POST",
+			Path:           "/v1/" + endpoint,
+			Endpoint:       endpoint,
+			Model:          model,
+			Stream:         "false",
+			UpstreamStatus: t.UpstreamStatus,
+			RequestBytes:   len(t.Request),
+			ResponseBytes:  len(t.Response),
+			BackfillSource: "import/" + format,
+			SourceID:       t.SourceID,
+			SourceFile:     sourceFile,
+		},
+		Session: importSession(format, t),
+	}
+	if t.Verbatim {
+		envelope.RawResponse = t.Response
+	}
+	if !t.StartedAt.IsZero() {
+		envelope.Meta.TsRequest = t.StartedAt.UTC().Format(time.RFC3339Nano)
+	}
+	if !t.EndedAt.IsZero() {
+		envelope.Meta.CapturedAt = t.EndedAt.UTC().Format(time.RFC3339Nano)
+		if !t.StartedAt.IsZero() && t.EndedAt.After(t.StartedAt) {
+			envelope.Meta.ElapsedSeconds = t.EndedAt.Sub(t.StartedAt).Seconds()
+		}
+	}
+
+	line, err := json.Marshal(struct {
+		Turn importEnvelope `json:"turn"`
+	}{envelope})
+	if err != nil {
+		return nil, fmt.Errorf("marshal envelope: %w", err)
+	}
+	return line, nil
+}
+
+// reduceImported reduces a turn's response with the same code live capture
+// uses for its wire format, and names the endpoint the call went to.
+func reduceImported(ctx context.Context, t ImportedTurn) (*llm.ChatResponse, string, error) {
+	switch {
+	case t.Provider == capture.ProviderAnthropic:
+		resp, err := capture.NewAnthropicReducer().Reduce(ctx, bytes.NewReader(t.Request), bytes.NewReader(t.Response), "application/json")
+		return resp, "messages", err
+	case t.Provider == capture.ProviderOpenAI && isResponsesObject(t.Response):
+		resp, err := capture.NewOpenAIResponsesReducer().Reduce(ctx, bytes.NewReader(t.Request), bytes.NewReader(t.Response), "application/json")
+		return resp, "responses", err
+	case t.Provider == capture.ProviderOpenAI:
+		p, err := provider.New(t.Provider)
+		if err != nil {
+			return nil, "", err
+		}
+		resp, err := p.ParseResponse(t.Response)
+		return resp, "chat/completions", err
+	default:
+		return nil, "", fmt.Errorf("unsupported provider %q", t.Provider)
+	}
+}
+
+// isResponsesObject reports whether an OpenAI body is a Responses API
+// object rather than a chat completion.
+func isResponsesObject(body json.RawMessage) bool {
+	var probe struct {
+		Object string `json:"object"`
+	}
+	return json.Unmarshal(body, &probe) == nil && probe.Object == "response"
+}
+
+// importSession builds the session envelope an imported turn carries. The
+// provenance rides in harness_metadata, so it lands on the session row too.
+func importSession(format string, t ImportedTurn) *sessions.IngestEnvelope {
+	harnessID := t.HarnessID
+	if harnessID == "" {
+		harnessID = format
+	}
+	metadata, _ := json.Marshal(map[string]string{"import_format": format})
+	return &sessions.IngestEnvelope{
+		HarnessID:        harnessID,
+		HarnessSessionID: t.SessionID,
+		HarnessVersion:   t.HarnessVersion,
+		Cwd:              t.Cwd,
+		Name:             t.SessionName,
+		HarnessMetadata:  metadata,
+	}
+}
+
+// eachRecord calls fn for every JSON value in r: the elements of a
+// top-level array, or each value of an NDJSON (or concatenated JSON)
+// stream. Records are decoded one at a time, so an export of any size
+// reads in bounded memory.
+func eachRecord(ctx context.Context, r io.Reader, fn func(ref string, raw json.RawMessage) error) error {
+	br := bufio.NewReader(r)
+	first, err := peekNonSpace(br)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(br)
+	isArray := first == '['
+	if isArray {
+		if _, err := dec.Token(); err != nil {
+			return fmt.Errorf("read array: %w", err)
+		}
+	}
+	for n := 1; ; n++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if isArray && !dec.More() {
+			return nil
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if !isArray && errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("record %d: %w", n, err)
+		}
+		if err := fn("record "+strconv.Itoa(n), raw); err != nil {
+			return err
+		}
+	}
+}
+
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\n' && b != '\r' {
+			return b, br.UnreadByte()
+		}
+	}
+}
+
+// parseTraceTime reads a timestamp the way trace exports spell them: RFC
+// 3339 (with or without a zone, as several exporters omit it and mean
+// UTC), or epoch seconds as a number. Anything else is the zero time.
+func parseTraceTime(raw json.RawMessage) time.Time {
+	if len(raw) == 0 {
+		return time.Time{}
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05.999999999"} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t.UTC()
+			}
+		}
+		return time.Time{}
+	}
+	var secs float64
+	if json.Unmarshal(raw, &secs) == nil && secs > 0 {
+		whole := int64(secs)
+		return time.Unix(whole, int64((secs-float64(whole))*1e9)).UTC()
+	}
+	return time.Time{}
+}
+
+// chatCompletion is the Chat Completions response an importer rebuilds
+// from a trace that kept the output message but not the provider body.
+type chatCompletion struct {
+	ID      string               `json:"id"`
+	Object  string               `json:"object"`
+	Created int64                `json:"created,omitempty"`
+	Model   string               `json:"model"`
+	Choices []chatCompletionPick `json:"choices"`
+	Usage   *chatCompletionUsage `json:"usage,omitempty"`
+}
+
+type chatCompletionPick struct {
+	Index        int             `json:"index"`
+	Message      json.RawMessage `json:"message"`
+	FinishReason string          `json:"finish_reason,omitempty"`
+}
+
+type chatCompletionUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// buildChatCompletion renders a rebuilt Chat Completions response.
+func buildChatCompletion(id, model string, created time.Time, message json.RawMessage, finishReason string, usage *chatCompletionUsage) json.RawMessage {
+	cc := chatCompletion{
+		ID:      id,
+		Object:  "chat.completion",
+		Model:   model,
+		Choices: []chatCompletionPick{{Message: message, FinishReason: finishReason}},
+		Usage:   usage,
+	}
+	if !created.IsZero() {
+		cc.Created = created.Unix()
+	}
+	out, _ := json.Marshal(cc)
+	return out
+}
+
+// buildChatRequest renders a Chat Completions request from a model, the
+// message list, and any recorded sampling parameters. Parameters never
+// override the model or messages.
+func buildChatRequest(model string, messages json.RawMessage, params map[string]json.RawMessage) json.RawMessage {
+	req := map[string]json.RawMessage{}
+	for k, v := range params {
+		req[k] = v
+	}
+	if model != "" {
+		req["model"], _ = json.Marshal(model)
+	}
+	req["messages"] = messages
+	out, _ := json.Marshal(req)
+	return out
+}
+
+// providerShape names the provider whose response body raw is, or "" for a
+// normalized trace output.
+func providerShape(raw json.RawMessage) string {
+	var probe struct {
+		Type    string          `json:"type"`
+		Role    string          `json:"role"`
+		Object  string          `json:"object"`
+		Choices json.RawMessage `json:"choices"`
+	}
+	if json.Unmarshal(raw, &probe) != nil {
+		return ""
+	}
+	switch {
+	case probe.Type == "message" && probe.Role == "assistant":
+		return "anthropic"
+	case present(probe.Choices), probe.Object == "response":
+		return "openai"
+	}
+	return ""
+}
+
+// firstMetadataString returns the first of keys holding a non-empty string.
+func firstMetadataString(m map[string]json.RawMessage, keys ...string) string {
+	for _, k := range keys {
+		var s string
+		if json.Unmarshal(m[k], &s) == nil && strings.TrimSpace(s) != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// withModel adds "model" to a request object that lacks one: SDK wrappers
+// sometimes record the model only in the invocation params.
+func withModel(request json.RawMessage, model string) json.RawMessage {
+	if model == "" {
+		return request
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(request, &fields) != nil {
+		return request
+	}
+	if present(fields["model"]) {
+		return request
+	}
+	fields["model"], _ = json.Marshal(model)
+	out, _ := json.Marshal(fields)
+	return out
+}
+
+// present reports whether a field was set: neither absent, null, nor an
+// empty object.
+func present(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) > 0 && !bytes.Equal(raw, []byte("null")) && !bytes.Equal(raw, []byte("{}"))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// marshalRaw marshals a value assembled from already-decoded JSON parts,
+// which cannot fail to re-encode.
+func marshalRaw(v any) json.RawMessage {
+	out, _ := json.Marshal(v)
+	return out
+}
+
+// unquoteJSON returns the JSON a log stored as a string, or raw itself.
+// Log exports often keep bodies as escaped JSON text rather than nested
+// objects.
+func unquoteJSON(raw json.RawMessage) json.RawMessage {
+	var s string
+	if json.Unmarshal(raw, &s) != nil {
+		return raw
+	}
+	s = strings.TrimSpace(s)
+	if !json.Valid([]byte(s)) {
+		return nil
+	}
+	return json.RawMessage(s)
+}
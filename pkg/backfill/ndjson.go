@@ -0,0 +1,314 @@
+package backfill
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NDJSONOptions configures an NDJSON batch backfill run.
+type NDJSONOptions struct {
+	// Path is an NDJSON file of ingest batch lines — each a JSON object
+	// with exactly one of "turn" (a /v1/ingest envelope) or "transcript"
+	// (a /v1/ingest/transcript body) — optionally gzip-compressed. "-"
+	// reads stdin.
+	Path string
+
+	// IngestURL is the tapes-ingest base URL. Lines are POSTed in chunks
+	// to {IngestURL}/v1/ingest/batch.
+	IngestURL string
+
+	// ChunkBytes caps the uncompressed size of one batch request;
+	// defaults to DefaultNDJSONChunkBytes. A single line larger than the
+	// cap is sent alone.
+	ChunkBytes int
+
+	// Retries is how many times a line the server could not hand
+	// downstream (or never answered) is resent; defaults to 3.
+	Retries int
+
+	Verbose bool
+	Logf    func(format string, args ...any)
+}
+
+// NDJSONResult summarizes an NDJSON batch backfill run.
+type NDJSONResult struct {
+	Lines int `json:"lines"`
+	BatchCounts
+}
+
+// BatchCounts tallies the server's per-line verdicts across a batch
+// backfill run.
+type BatchCounts struct {
+	Accepted int      `json:"accepted"`
+	Deduped  int      `json:"deduped"`
+	Rejected int      `json:"rejected"`
+	Failures []string `json:"failures,omitempty"`
+}
+
+// DefaultNDJSONChunkBytes keeps each batch request small: the server streams
+// a batch, so the chunk bounds how much one failed request resends, not what
+// the server buffers.
+const DefaultNDJSONChunkBytes = 4 << 20
+
+// maxNDJSONLineBytes bounds one line read from the file, just above the
+// server's single-write body limit (~46.67 MiB): it rejects a longer line
+// anyway, and reading past it only costs memory.
+const maxNDJSONLineBytes = 48 << 20
+
+// batchLineResult mirrors one line of the ingest batch response.
+type batchLineResult struct {
+	Line   int    `json:"line"`
+	Kind   string `json:"kind"`
+	Status string `json:"status"`
+	Code   int    `json:"code"`
+	Error  string `json:"error"`
+}
+
+// batchItem is one line queued for a batch request, with the reference
+// failures are reported under ("line 12", a source record id).
+type batchItem struct {
+	ref string
+	raw []byte
+}
+
+// NDJSON streams an NDJSON file through POST /v1/ingest/batch in bounded
+// chunks, so neither the file nor its results are ever held whole.
+// Idempotent: every line dedupes server-side exactly as its single-write
+// route would, so an interrupted run is resumed by running it again.
+//
+// A line rejected as a downstream failure (502), or left unanswered by a
+// response cut short, is resent with backoff up to Retries times; every
+// other rejection is final and reported with its file line number.
+func NDJSON(ctx context.Context, opts NDJSONOptions) (*NDJSONResult, error) {
+	in, closeIn, err := openNDJSON(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeIn()
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxNDJSONLineBytes)
+
+	result := &NDJSONResult{}
+	up := newBatchUploader(opts, &result.BatchCounts)
+
+	fileLine := 0
+	for scanner.Scan() {
+		fileLine++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		result.Lines++
+		if err := up.add(ctx, "line "+strconv.Itoa(fileLine), raw); err != nil {
+			return result, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("read %s line %d: %w", opts.Path, fileLine+1, err)
+	}
+	return result, up.flush(ctx)
+}
+
+// openNDJSON opens path (or stdin for "-"), inflating it when it starts
+// with the gzip magic bytes.
+func openNDJSON(path string) (io.Reader, func(), error) {
+	var f *os.File
+	closeFn := func() {}
+	if path == "-" {
+		f = os.Stdin
+	} else {
+		var err error
+		if f, err = os.Open(path); err != nil {
+			return nil, nil, fmt.Errorf("open ndjson: %w", err)
+		}
+		closeFn = func() { _ = f.Close() }
+	}
+
+	br := bufio.NewReader(f)
+	magic, _ := br.Peek(2)
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			closeFn()
+			return nil, nil, fmt.Errorf("open ndjson: gzip: %w", err)
+		}
+		return zr, closeFn, nil
+	}
+	return br, closeFn, nil
+}
+
+// batchUploader packs lines into chunked POST /v1/ingest/batch requests
+// and tallies the verdicts. Only the chunk being built is held in memory.
+type batchUploader struct {
+	client *http.Client
+	opts   NDJSONOptions
+	counts *BatchCounts
+
+	chunk []batchItem
+	size  int
+}
+
+// newBatchUploader applies opts' defaults and returns an uploader that
+// tallies into counts.
+func newBatchUploader(opts NDJSONOptions, counts *BatchCounts) *batchUploader {
+	if opts.Logf == nil {
+		opts.Logf = func(string, ...any) {}
+	}
+	if opts.ChunkBytes <= 0 {
+		opts.ChunkBytes = DefaultNDJSONChunkBytes
+	}
+	if opts.Retries <= 0 {
+		opts.Retries = 3
+	}
+	return &batchUploader{client: &http.Client{}, opts: opts, counts: counts}
+}
+
+// add queues one line, sending the chunk first when the line would push
+// it past ChunkBytes. raw is copied.
+func (u *batchUploader) add(ctx context.Context, ref string, raw []byte) error {
+	if u.size > 0 && u.size+len(raw)+1 > u.opts.ChunkBytes {
+		if err := u.flush(ctx); err != nil {
+			return err
+		}
+	}
+	u.chunk = append(u.chunk, batchItem{ref: ref, raw: bytes.Clone(raw)})
+	u.size += len(raw) + 1
+	return nil
+}
+
+// flush sends the queued chunk, tallies its final results, and resends the
+// retryable remainder. It returns an error only when the server cannot be
+// reached or refuses the request as a whole.
+func (u *batchUploader) flush(ctx context.Context) error {
+	chunk := u.chunk
+	u.chunk, u.size = nil, 0
+	if len(chunk) == 0 {
+		return nil
+	}
+
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		retry, err := u.send(ctx, chunk, attempt == u.opts.Retries)
+		if err != nil {
+			return err
+		}
+		if len(retry) == 0 {
+			return nil
+		}
+		u.opts.Logf("retrying %d line(s) in %s", len(retry), backoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		chunk = retry
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+// send makes one batch request and returns the lines worth resending. On
+// the final attempt nothing is resent: those lines are tallied as rejected
+// instead.
+func (u *batchUploader) send(ctx context.Context, chunk []batchItem, final bool) ([]batchItem, error) {
+	var body bytes.Buffer
+	zw := gzip.NewWriter(&body)
+	for _, item := range chunk {
+		_, _ = zw.Write(item.raw)
+		_, _ = zw.Write([]byte{'\n'})
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("compress batch: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		strings.TrimRight(u.opts.IngestURL, "/")+"/v1/ingest/batch", &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("Content-Encoding", "gzip")
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return nil, fmt.Errorf("ingest returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	// The chunk carries no blank lines, so result line n is chunk[n-1].
+	answered := make([]bool, len(chunk))
+	var retry []batchItem
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var r batchLineResult
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("decode batch result: %w", err)
+		}
+		if r.Line < 1 || r.Line > len(chunk) || answered[r.Line-1] {
+			return nil, fmt.Errorf("batch result for unexpected line %d", r.Line)
+		}
+		answered[r.Line-1] = true
+		item := chunk[r.Line-1]
+
+		switch {
+		case r.Status == "accepted":
+			u.counts.Accepted++
+		case r.Status == "deduped":
+			u.counts.Deduped++
+		case r.Code == http.StatusBadGateway && !final:
+			retry = append(retry, item)
+			continue
+		default:
+			u.reject(item.ref, r.Code, r.Error)
+			continue
+		}
+		if u.opts.Verbose {
+			u.opts.Logf("%s %s: %s", r.Kind, item.ref, r.Status)
+		}
+	}
+	// A response cut short — the connection dropped, the server went
+	// away — leaves lines unanswered. Their writes may or may not have
+	// landed; resending is safe because every write dedupes.
+	readErr := scanner.Err()
+	for i, ok := range answered {
+		if ok {
+			continue
+		}
+		if final {
+			msg := "no result from server"
+			if readErr != nil {
+				msg += ": " + readErr.Error()
+			}
+			u.reject(chunk[i].ref, 0, msg)
+			continue
+		}
+		retry = append(retry, chunk[i])
+	}
+	return retry, nil
+}
+
+func (u *batchUploader) reject(ref string, code int, msg string) {
+	u.counts.Rejected++
+	failure := ref
+	if code != 0 {
+		failure += " (" + strconv.Itoa(code) + ")"
+	}
+	failure += ": " + msg
+	if len(u.counts.Failures) < 25 {
+		u.counts.Failures = append(u.counts.Failures, failure)
+	}
+	u.opts.Logf("FAIL %s", failure)
+}
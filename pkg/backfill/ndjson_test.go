@@ -0,0 +1,167 @@
+package backfill_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/backfill"
+)
+
+// fakeBatchServer answers /v1/ingest/batch from a per-line verdict keyed by
+// the line's "id" field, recording every line it was sent.
+type fakeBatchServer struct {
+	mu       sync.Mutex
+	requests int
+	received []string
+
+	// verdict returns the status and code for a line on its nth delivery.
+	verdict func(id string, delivery int) (string, int)
+
+	// cutAfter, when positive, drops the connection's remaining results
+	// after that many on the first request.
+	cutAfter int
+}
+
+func (f *fakeBatchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	Expect(r.URL.Path).To(Equal("/v1/ingest/batch"))
+	Expect(r.Header.Get("Content-Encoding")).To(Equal("gzip"))
+	zr, err := gzip.NewReader(r.Body)
+	Expect(err).NotTo(HaveOccurred())
+
+	f.mu.Lock()
+	f.requests++
+	first := f.requests == 1
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	scanner := bufio.NewScanner(zr)
+	line := 0
+	for scanner.Scan() {
+		line++
+		var l struct {
+			ID string `json:"id"`
+		}
+		Expect(json.Unmarshal(scanner.Bytes(), &l)).To(Succeed())
+
+		f.mu.Lock()
+		delivery := 0
+		for _, id := range f.received {
+			if id == l.ID {
+				delivery++
+			}
+		}
+		f.received = append(f.received, l.ID)
+		f.mu.Unlock()
+
+		if first && f.cutAfter > 0 && line > f.cutAfter {
+			continue
+		}
+		status, code := f.verdict(l.ID, delivery)
+		res := map[string]any{"line": line, "kind": "turn", "status": status}
+		if code != 0 {
+			res["code"] = code
+			res["error"] = "nope"
+		}
+		Expect(enc.Encode(res)).To(Succeed())
+	}
+}
+
+var _ = Describe("NDJSON backfill", func() {
+	var (
+		dir  string
+		fake *fakeBatchServer
+		srv  *httptest.Server
+	)
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		fake = &fakeBatchServer{verdict: func(string, int) (string, int) { return "accepted", 0 }}
+		srv = httptest.NewServer(fake)
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	writeFile := func(name string, lines ...string) string {
+		body := []byte(strings.Join(lines, "\n") + "\n")
+		if strings.HasSuffix(name, ".gz") {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			_, _ = zw.Write(body)
+			Expect(zw.Close()).To(Succeed())
+			body = buf.Bytes()
+		}
+		path := filepath.Join(dir, name)
+		Expect(os.WriteFile(path, body, 0o600)).To(Succeed())
+		return path
+	}
+
+	run := func(path string, chunkBytes int) *backfill.NDJSONResult {
+		result, err := backfill.NDJSON(context.Background(), backfill.NDJSONOptions{
+			Path:       path,
+			IngestURL:  srv.URL,
+			ChunkBytes: chunkBytes,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return result
+	}
+
+	It("tallies each line's verdict and reports rejections by file line", func() {
+		fake.verdict = func(id string, _ int) (string, int) {
+			switch id {
+			case "b":
+				return "deduped", 0
+			case "c":
+				return "rejected", http.StatusBadRequest
+			}
+			return "accepted", 0
+		}
+		path := writeFile("in.ndjson", `{"id":"a"}`, "", `{"id":"b"}`, `{"id":"c"}`)
+
+		result := run(path, 0)
+		Expect(result.Lines).To(Equal(3))
+		Expect(result.Accepted).To(Equal(1))
+		Expect(result.Deduped).To(Equal(1))
+		Expect(result.Rejected).To(Equal(1))
+		Expect(result.Failures).To(ConsistOf("line 4 (400): nope"))
+	})
+
+	It("reads a gzip file and splits it into chunks on line boundaries", func() {
+		path := writeFile("in.ndjson.gz", `{"id":"a"}`, `{"id":"b"}`, `{"id":"c"}`)
+
+		result := run(path, 24)
+		Expect(result.Accepted).To(Equal(3))
+		Expect(fake.requests).To(Equal(2))
+		Expect(fake.received).To(Equal([]string{"a", "b", "c"}))
+	})
+
+	It("resends downstream failures and lines a cut-short response left unanswered", func() {
+		fake.verdict = func(id string, delivery int) (string, int) {
+			if id == "a" && delivery == 0 {
+				return "rejected", http.StatusBadGateway
+			}
+			return "accepted", 0
+		}
+		fake.cutAfter = 2
+		path := writeFile("in.ndjson", `{"id":"a"}`, `{"id":"b"}`, `{"id":"c"}`)
+
+		result := run(path, 0)
+		Expect(result.Accepted).To(Equal(3))
+		Expect(result.Rejected).To(BeZero())
+		Expect(fake.received).To(Equal([]string{"a", "b", "c", "a", "c"}))
+	})
+})
@@ -0,0 +1,283 @@
+// Package bundle moves captured history between tapes deployments.
+//
+// A bundle is the raw layer, not the projection: raw_turns rows (wire
+// captures and transcript pushes alike) together with the attribution
+// corrections recorded against them. The destination re-derives on
+// arrival, so an imported session is projected by the destination's
+// deriver exactly as a live capture would be, and nothing derived from
+// an older deriver version travels with it.
+//
+// On disk a bundle is an uncompressed tar of three members, in order:
+//
+//	manifest.json           format, version, filters, and counts
+//	raw_turns.jsonl.gz      a corpus file (see derive.CorpusWriter)
+//	corrections.jsonl.gz    gzipped JSONL of storage.RawTurnCorrection
+//
+// The raw-turn member is an ordinary corpus dump, so a bundle's turns
+// replay through every tool that reads corpora. The manifest comes
+// first so a reader rejects an unknown version before it streams any
+// rows, and raw turns precede corrections so an importer has every
+// turn's destination id before it re-points the corrections at them.
+package bundle
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/papercomputeco/tapes/pkg/derive"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+// Format identifies a tapes bundle in its manifest.
+const Format = "tapes-bundle"
+
+// Version is the bundle layout this build writes and reads. A reader
+// refuses any other version rather than guessing at its layout.
+const Version = 1
+
+// Member names, in the order they appear in the tar.
+const (
+	memberManifest    = "manifest.json"
+	memberRawTurns    = "raw_turns.jsonl.gz"
+	memberCorrections = "corrections.jsonl.gz"
+)
+
+// Manifest describes one bundle.
+type Manifest struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Sessions and ActiveSince record the export's filters. Both empty
+	// means the bundle holds the whole raw layer.
+	Sessions    []string   `json:"sessions,omitempty"`
+	ActiveSince *time.Time `json:"active_since,omitempty"`
+
+	// RawTurns and Corrections count the rows in their members;
+	// SessionCount counts distinct harness sessions among the turns.
+	RawTurns     int `json:"raw_turns"`
+	Corrections  int `json:"corrections"`
+	SessionCount int `json:"session_count"`
+}
+
+// Writer accumulates a bundle's rows and assembles the tar on Finish.
+// A tar header carries its member's size, so rows are spooled to
+// temporary files until the counts are known; nothing is held in
+// memory. Close removes the spool files and is safe after Finish.
+type Writer struct {
+	rawFile *os.File
+	rawOut  *derive.CorpusWriter
+
+	corrFile *os.File
+	corrGz   *gzip.Writer
+	corrEnc  *json.Encoder
+
+	rawTurns    int
+	corrections int
+	sessions    map[string]struct{}
+}
+
+// NewWriter creates a Writer spooling to the default temp directory.
+func NewWriter() (*Writer, error) {
+	rawFile, err := os.CreateTemp("", "tapes-bundle-raw-*")
+	if err != nil {
+		return nil, fmt.Errorf("create raw-turn spool: %w", err)
+	}
+	corrFile, err := os.CreateTemp("", "tapes-bundle-corrections-*")
+	if err != nil {
+		removeSpool(rawFile)
+		return nil, fmt.Errorf("create correction spool: %w", err)
+	}
+	corrGz := gzip.NewWriter(corrFile)
+	return &Writer{
+		rawFile:  rawFile,
+		rawOut:   derive.NewCorpusWriter(rawFile),
+		corrFile: corrFile,
+		corrGz:   corrGz,
+		corrEnc:  json.NewEncoder(corrGz),
+		sessions: map[string]struct{}{},
+	}, nil
+}
+
+// WriteRawTurn appends one raw turn, verbatim.
+func (w *Writer) WriteRawTurn(rec *storage.RawTurnRecord) error {
+	if err := w.rawOut.Write(rec); err != nil {
+		return err
+	}
+	w.rawTurns++
+	if rec.HarnessSessionID != "" {
+		w.sessions[rec.HarnessID+"\x00"+rec.HarnessSessionID] = struct{}{}
+	}
+	return nil
+}
+
+// WriteCorrection appends one attribution correction.
+func (w *Writer) WriteCorrection(c *storage.RawTurnCorrection) error {
+	if err := w.corrEnc.Encode(c); err != nil {
+		return fmt.Errorf("encode correction %d: %w", c.ID, err)
+	}
+	w.corrections++
+	return nil
+}
+
+// Finish completes the manifest's counts and writes the bundle to out.
+// The returned manifest is the one written.
+func (w *Writer) Finish(out io.Writer, m Manifest) (*Manifest, error) {
+	if err := w.rawOut.Close(); err != nil {
+		return nil, err
+	}
+	if err := w.corrGz.Close(); err != nil {
+		return nil, fmt.Errorf("close correction gzip: %w", err)
+	}
+
+	m.Format = Format
+	m.Version = Version
+	m.RawTurns = w.rawTurns
+	m.Corrections = w.corrections
+	m.SessionCount = len(w.sessions)
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode manifest: %w", err)
+	}
+
+	tw := tar.NewWriter(out)
+	if err := writeMember(tw, memberManifest, int64(len(manifest)), m.CreatedAt, bytes.NewReader(manifest)); err != nil {
+		return nil, err
+	}
+	for _, member := range []struct {
+		name string
+		file *os.File
+	}{
+		{memberRawTurns, w.rawFile},
+		{memberCorrections, w.corrFile},
+	} {
+		size, err := member.file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, fmt.Errorf("size %s: %w", member.name, err)
+		}
+		if _, err := member.file.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("rewind %s: %w", member.name, err)
+		}
+		if err := writeMember(tw, member.name, size, m.CreatedAt, member.file); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("close bundle tar: %w", err)
+	}
+	return &m, nil
+}
+
+// Close removes the spool files.
+func (w *Writer) Close() error {
+	removeSpool(w.rawFile)
+	removeSpool(w.corrFile)
+	return nil
+}
+
+func writeMember(tw *tar.Writer, name string, size int64, modTime time.Time, body io.Reader) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    size,
+		ModTime: modTime,
+	}); err != nil {
+		return fmt.Errorf("write %s header: %w", name, err)
+	}
+	if _, err := io.Copy(tw, body); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+func removeSpool(f *os.File) {
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+}
+
+// Handler receives a bundle's rows as Read streams them.
+type Handler interface {
+	// RawTurn is called once per raw turn, in export order.
+	RawTurn(rec *storage.RawTurnRecord) error
+
+	// Correction is called once per correction, oldest first, after
+	// every raw turn.
+	Correction(c *storage.RawTurnCorrection) error
+}
+
+// Read streams a bundle from r into h and returns its manifest. The
+// manifest is checked before any row is delivered: a stream that is not
+// a tapes bundle, or is a version this build does not know, fails
+// without calling h.
+func Read(r io.Reader, h Handler) (*Manifest, error) {
+	tr := tar.NewReader(r)
+	next := func(want string) error {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("bundle ends before %s", want)
+		}
+		if err != nil {
+			return fmt.Errorf("read bundle: %w", err)
+		}
+		if hdr.Name != want {
+			return fmt.Errorf("bundle member %q where %s was expected", hdr.Name, want)
+		}
+		return nil
+	}
+
+	if err := next(memberManifest); err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.NewDecoder(tr).Decode(&m); err != nil {
+		return nil, fmt.Errorf("decode manifest: %w", err)
+	}
+	if m.Format != Format {
+		return nil, fmt.Errorf("not a tapes bundle (format %q)", m.Format)
+	}
+	if m.Version != Version {
+		return nil, fmt.Errorf("unsupported bundle version %d (this build reads version %d)", m.Version, Version)
+	}
+
+	if err := next(memberRawTurns); err != nil {
+		return nil, err
+	}
+	if err := derive.ReadCorpus(tr, h.RawTurn); err != nil {
+		return nil, fmt.Errorf("%s: %w", memberRawTurns, err)
+	}
+
+	if err := next(memberCorrections); err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(tr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: gunzip: %w", memberCorrections, err)
+	}
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var c storage.RawTurnCorrection
+		if err := json.Unmarshal(line, &c); err != nil {
+			return nil, fmt.Errorf("%s: decode correction: %w", memberCorrections, err)
+		}
+		if err := h.Correction(&c); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", memberCorrections, err)
+	}
+	return &m, nil
+}
@@ -0,0 +1,238 @@
+package bundle_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/bundle"
+	"github.com/papercomputeco/tapes/pkg/derive"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+// fakeStore is an in-memory storage.RawTurnTransferStore. It dedupes the
+// way the Postgres driver does — on request id, else on the full capture
+// identity — and assigns its own ids, so a round trip exercises the
+// id re-pointing of corrections. The Postgres implementation is exercised
+// against a live database.
+type fakeStore struct {
+	nextID      int64
+	turns       []storage.RawTurnRecord
+	corrections []storage.RawTurnCorrection
+}
+
+func newFakeStore(firstID int64) *fakeStore {
+	return &fakeStore{nextID: firstID}
+}
+
+func (f *fakeStore) ListRawTurnsForExport(_ context.Context, opts storage.RawTurnExportOpts) ([]storage.RawTurnRecord, error) {
+	var out []storage.RawTurnRecord
+	for _, t := range f.turns {
+		if t.ID <= opts.AfterID {
+			continue
+		}
+		if len(opts.HarnessSessionIDs) > 0 && !slices.Contains(opts.HarnessSessionIDs, t.HarnessSessionID) {
+			continue
+		}
+		out = append(out, t)
+		if int32(len(out)) == opts.Limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeStore) ListRawTurnCorrections(_ context.Context, ids []int64) ([]storage.RawTurnCorrection, error) {
+	var out []storage.RawTurnCorrection
+	for _, c := range f.corrections {
+		if slices.Contains(ids, c.RawTurnID) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeStore) ImportRawTurn(_ context.Context, rec storage.RawTurnRecord) (int64, bool, error) {
+	for _, t := range f.turns {
+		if rec.RequestID != "" && t.RequestID == rec.RequestID {
+			return t.ID, false, nil
+		}
+		if rec.RequestID == "" && t.RequestID == "" && t.Source == rec.Source &&
+			t.HarnessSessionID == rec.HarnessSessionID && t.ReceivedAt.Equal(rec.ReceivedAt) &&
+			bytes.Equal(t.RawRequest, rec.RawRequest) {
+			return t.ID, false, nil
+		}
+	}
+	f.nextID++
+	rec.ID = f.nextID
+	f.turns = append(f.turns, rec)
+	return rec.ID, true, nil
+}
+
+func (f *fakeStore) ImportRawTurnCorrection(_ context.Context, c storage.RawTurnCorrection) (bool, error) {
+	if !slices.ContainsFunc(f.turns, func(t storage.RawTurnRecord) bool { return t.ID == c.RawTurnID }) {
+		return false, storage.ErrRawTurnNotFound
+	}
+	for _, have := range f.corrections {
+		if have.RawTurnID == c.RawTurnID && have.HarnessSessionID == c.HarnessSessionID &&
+			have.Reason == c.Reason && have.CreatedAt.Equal(c.CreatedAt) {
+			return false, nil
+		}
+	}
+	f.nextID++
+	c.ID = f.nextID
+	f.corrections = append(f.corrections, c)
+	return true, nil
+}
+
+type fakeRederiver struct {
+	derived []string
+}
+
+func (r *fakeRederiver) RederiveSessionLocked(_ context.Context, _, _, harnessID, harnessSessionID string) (*derive.RederiveReport, error) {
+	r.derived = append(r.derived, harnessID+"/"+harnessSessionID)
+	return &derive.RederiveReport{}, nil
+}
+
+var _ = Describe("bundles", func() {
+	var (
+		ctx    context.Context
+		source *fakeStore
+		at     time.Time
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		at = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		source = newFakeStore(0)
+		for i, spec := range []struct{ session, requestID string }{
+			{"sess-a", "req-1"},
+			{"sess-a", ""},
+			{"sess-b", "req-3"},
+		} {
+			_, _, err := source.ImportRawTurn(ctx, storage.RawTurnRecord{
+				OrgID:               "org",
+				Source:              storage.RawTurnSourceWire,
+				HarnessID:           "claude-code",
+				HarnessSessionID:    spec.session,
+				RequestID:           spec.requestID,
+				RawRequest:          json.RawMessage(`{"n":1}`),
+				RawResponse:         []byte("data: verbatim\n\n"),
+				RawResponseEncoding: "identity",
+				ReceivedAt:          at.Add(time.Duration(i) * time.Minute),
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+		_, err := source.ImportRawTurnCorrection(ctx, storage.RawTurnCorrection{
+			OrgID: "org", RawTurnID: 2, HarnessID: "claude-code",
+			HarnessSessionID: "sess-c", Reason: "operator", CreatedAt: at.Add(time.Hour),
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	export := func(opts bundle.ExportOptions) *bytes.Buffer {
+		var buf bytes.Buffer
+		_, err := bundle.Export(ctx, source, &buf, opts)
+		Expect(err).NotTo(HaveOccurred())
+		return &buf
+	}
+
+	It("writes a manifest describing the export", func() {
+		var buf bytes.Buffer
+		m, err := bundle.Export(ctx, source, &buf, bundle.ExportOptions{PageSize: 2})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.Format).To(Equal(bundle.Format))
+		Expect(m.Version).To(Equal(bundle.Version))
+		Expect(m.RawTurns).To(Equal(3))
+		Expect(m.Corrections).To(Equal(1))
+		Expect(m.SessionCount).To(Equal(2))
+	})
+
+	It("round-trips turns verbatim, keeping receipt times and raw responses", func() {
+		dest := newFakeStore(100)
+		report, err := bundle.Import(ctx, dest, export(bundle.ExportOptions{}), bundle.ImportOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.RawTurnsInserted).To(Equal(3))
+		Expect(report.CorrectionsInserted).To(Equal(1))
+
+		Expect(dest.turns).To(HaveLen(3))
+		for i, t := range dest.turns {
+			Expect(t.ReceivedAt).To(BeTemporally("==", source.turns[i].ReceivedAt))
+			Expect(t.RawResponse).To(Equal(source.turns[i].RawResponse))
+			Expect(t.RawResponseEncoding).To(Equal("identity"))
+		}
+	})
+
+	It("re-points corrections at the destination's raw turn ids", func() {
+		dest := newFakeStore(100)
+		_, err := bundle.Import(ctx, dest, export(bundle.ExportOptions{}), bundle.ImportOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(dest.corrections).To(HaveLen(1))
+		Expect(dest.corrections[0].RawTurnID).To(Equal(dest.turns[1].ID))
+		Expect(dest.corrections[0].CreatedAt).To(BeTemporally("==", at.Add(time.Hour)))
+	})
+
+	It("is idempotent on re-import", func() {
+		dest := newFakeStore(100)
+		data := export(bundle.ExportOptions{}).Bytes()
+		_, err := bundle.Import(ctx, dest, bytes.NewReader(data), bundle.ImportOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		report, err := bundle.Import(ctx, dest, bytes.NewReader(data), bundle.ImportOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.RawTurnsInserted).To(BeZero())
+		Expect(report.RawTurnsDeduped).To(Equal(3))
+		Expect(report.CorrectionsDeduped).To(Equal(1))
+		Expect(report.Sessions).To(BeZero())
+		Expect(dest.turns).To(HaveLen(3))
+		Expect(dest.corrections).To(HaveLen(1))
+	})
+
+	It("re-derives every session the import touched", func() {
+		dest := newFakeStore(100)
+		rederiver := &fakeRederiver{}
+		report, err := bundle.Import(ctx, dest, export(bundle.ExportOptions{}), bundle.ImportOptions{Rederiver: rederiver})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rederiver.derived).To(Equal([]string{
+			"claude-code/sess-a", "claude-code/sess-b", "claude-code/sess-c",
+		}))
+		Expect(report.SessionsDerived).To(Equal(3))
+	})
+
+	It("exports only the selected sessions and their corrections", func() {
+		dest := newFakeStore(100)
+		report, err := bundle.Import(ctx, dest, export(bundle.ExportOptions{Sessions: []string{"sess-b"}}), bundle.ImportOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Manifest.Sessions).To(Equal([]string{"sess-b"}))
+		Expect(dest.turns).To(HaveLen(1))
+		Expect(dest.turns[0].HarnessSessionID).To(Equal("sess-b"))
+		Expect(dest.corrections).To(BeEmpty())
+	})
+
+	It("rejects a bundle of an unknown version before reading any rows", func() {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		manifest := []byte(`{"format":"tapes-bundle","version":99}`)
+		Expect(tw.WriteHeader(&tar.Header{Name: "manifest.json", Mode: 0o644, Size: int64(len(manifest))})).To(Succeed())
+		_, err := tw.Write(manifest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tw.Close()).To(Succeed())
+
+		dest := newFakeStore(100)
+		_, err = bundle.Import(ctx, dest, &buf, bundle.ImportOptions{})
+		Expect(err).To(MatchError(ContainSubstring("unsupported bundle version 99")))
+		Expect(dest.turns).To(BeEmpty())
+	})
+
+	It("rejects a stream that is not a bundle", func() {
+		_, err := bundle.Import(ctx, newFakeStore(0), bytes.NewReader([]byte("not a tar")), bundle.ImportOptions{})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,88 @@
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+// DefaultPageSize bounds one raw-turn page read during export.
+const DefaultPageSize = 500
+
+// ExportOptions selects what Export writes.
+type ExportOptions struct {
+	// Sessions limits the export to these harness session ids and their
+	// subagent sessions. Empty exports every session.
+	Sessions []string
+
+	// ActiveSince limits the export to sessions last seen at or after
+	// it, each with its whole history. Zero means no time filter.
+	ActiveSince time.Time
+
+	// PageSize bounds one raw-turn page (default DefaultPageSize).
+	PageSize int32
+}
+
+// Export writes a bundle of the raw turns opts selects, and every
+// correction recorded against them, to out.
+func Export(ctx context.Context, store storage.RawTurnTransferStore, out io.Writer, opts ExportOptions) (*Manifest, error) {
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	w, err := NewWriter()
+	if err != nil {
+		return nil, err
+	}
+	defer w.Close()
+
+	// Corrections are looked up per page of turns: a turn's corrections
+	// all come back in one call, oldest first, which is the order the
+	// importer must replay them in for the latest to stay effective.
+	var afterID int64
+	for {
+		rows, err := store.ListRawTurnsForExport(ctx, storage.RawTurnExportOpts{
+			HarnessSessionIDs: opts.Sessions,
+			ActiveSince:       opts.ActiveSince,
+			AfterID:           afterID,
+			Limit:             pageSize,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("export raw turns after id %d: %w", afterID, err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+		ids := make([]int64, 0, len(rows))
+		for i := range rows {
+			if err := w.WriteRawTurn(&rows[i]); err != nil {
+				return nil, err
+			}
+			ids = append(ids, rows[i].ID)
+			afterID = rows[i].ID
+		}
+		corrections, err := store.ListRawTurnCorrections(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("export corrections: %w", err)
+		}
+		for i := range corrections {
+			if err := w.WriteCorrection(&corrections[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	m := Manifest{
+		CreatedAt: time.Now().UTC(),
+		Sessions:  opts.Sessions,
+	}
+	if !opts.ActiveSince.IsZero() {
+		since := opts.ActiveSince.UTC()
+		m.ActiveSince = &since
+	}
+	return w.Finish(out, m)
+}
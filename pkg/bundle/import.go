@@ -0,0 +1,161 @@
+package bundle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/papercomputeco/tapes/pkg/derive"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+// Rederiver re-derives one harness session under its derive lock. The
+// Postgres driver's RederiveSessionLocked satisfies it.
+type Rederiver interface {
+	RederiveSessionLocked(ctx context.Context, project, orgID, harnessID, harnessSessionID string) (*derive.RederiveReport, error)
+}
+
+// ImportOptions tunes Import.
+type ImportOptions struct {
+	// Project tags the re-derived projection. It does not participate
+	// in node hashes.
+	Project string
+
+	// Rederiver, when set, re-derives every session the import touched
+	// before Import returns. Nil leaves them to the derive worker; every
+	// touched session is already marked derive-dirty either way.
+	Rederiver Rederiver
+}
+
+// ImportReport summarizes one import.
+type ImportReport struct {
+	Manifest *Manifest `json:"manifest"`
+
+	RawTurnsInserted int `json:"raw_turns_inserted"`
+	RawTurnsDeduped  int `json:"raw_turns_deduped"`
+
+	CorrectionsInserted int `json:"corrections_inserted"`
+	CorrectionsDeduped  int `json:"corrections_deduped"`
+
+	// CorrectionsOrphaned counts corrections whose raw turn is not in
+	// the bundle. They cannot be re-pointed and are skipped.
+	CorrectionsOrphaned int `json:"corrections_orphaned,omitempty"`
+
+	// Sessions is the number of harness sessions the import touched;
+	// SessionsDerived how many of them were re-derived.
+	Sessions        int `json:"sessions"`
+	SessionsDerived int `json:"sessions_derived"`
+}
+
+// Import reads a bundle from r into store. It is idempotent: turns and
+// corrections already present at the destination are recognised by
+// capture identity and not written again, so re-importing a bundle, or
+// importing overlapping ones, converges on the same raw layer.
+func Import(ctx context.Context, store storage.RawTurnTransferStore, r io.Reader, opts ImportOptions) (*ImportReport, error) {
+	imp := &importer{
+		ctx:     ctx,
+		store:   store,
+		ids:     map[int64]int64{},
+		touched: map[sessionKey]struct{}{},
+		report:  &ImportReport{},
+	}
+	m, err := Read(r, imp)
+	if err != nil {
+		return imp.report, err
+	}
+	imp.report.Manifest = m
+
+	keys := imp.touchedSessions()
+	imp.report.Sessions = len(keys)
+	if opts.Rederiver == nil {
+		return imp.report, nil
+	}
+	for _, key := range keys {
+		if _, err := opts.Rederiver.RederiveSessionLocked(ctx, opts.Project, key.orgID, key.harnessID, key.harnessSessionID); err != nil {
+			return imp.report, fmt.Errorf("derive imported session %s/%s: %w", key.harnessID, key.harnessSessionID, err)
+		}
+		imp.report.SessionsDerived++
+	}
+	return imp.report, nil
+}
+
+type sessionKey struct {
+	orgID            string
+	harnessID        string
+	harnessSessionID string
+}
+
+// importer is the Handler Import feeds the bundle through. ids maps each
+// bundle raw-turn id to its destination id, deduped or fresh, so the
+// corrections that follow can be re-pointed.
+type importer struct {
+	ctx     context.Context //nolint:containedctx // scoped to one Import call
+	store   storage.RawTurnTransferStore
+	ids     map[int64]int64
+	touched map[sessionKey]struct{}
+	report  *ImportReport
+}
+
+func (imp *importer) RawTurn(rec *storage.RawTurnRecord) error {
+	id, inserted, err := imp.store.ImportRawTurn(imp.ctx, *rec)
+	if err != nil {
+		return fmt.Errorf("import raw turn %d: %w", rec.ID, err)
+	}
+	imp.ids[rec.ID] = id
+	if !inserted {
+		imp.report.RawTurnsDeduped++
+		return nil
+	}
+	imp.report.RawTurnsInserted++
+	if rec.HarnessSessionID != "" {
+		imp.touched[sessionKey{rec.OrgID, rec.HarnessID, rec.HarnessSessionID}] = struct{}{}
+	}
+	return nil
+}
+
+func (imp *importer) Correction(c *storage.RawTurnCorrection) error {
+	id, ok := imp.ids[c.RawTurnID]
+	if !ok {
+		imp.report.CorrectionsOrphaned++
+		return nil
+	}
+	remapped := *c
+	remapped.RawTurnID = id
+	inserted, err := imp.store.ImportRawTurnCorrection(imp.ctx, remapped)
+	if errors.Is(err, storage.ErrRawTurnNotFound) {
+		imp.report.CorrectionsOrphaned++
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("import correction %d: %w", c.ID, err)
+	}
+	if !inserted {
+		imp.report.CorrectionsDeduped++
+		return nil
+	}
+	imp.report.CorrectionsInserted++
+	imp.touched[sessionKey{c.OrgID, c.HarnessID, c.HarnessSessionID}] = struct{}{}
+	return nil
+}
+
+// touchedSessions returns the sessions the import changed, sorted so the
+// derive pass runs in a stable order.
+func (imp *importer) touchedSessions() []sessionKey {
+	keys := make([]sessionKey, 0, len(imp.touched))
+	for k := range imp.touched {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.orgID != b.orgID {
+			return a.orgID < b.orgID
+		}
+		if a.harnessID != b.harnessID {
+			return a.harnessID < b.harnessID
+		}
+		return a.harnessSessionID < b.harnessSessionID
+	})
+	return keys
+}
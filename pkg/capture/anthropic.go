@@ -116,6 +116,9 @@ func (r *anthropicReducer) reduceOneShot(body io.Reader) (*llm.ChatResponse, err
 		usage.TotalTokens = totalInput + resp.Usage.OutputTokens
 		usage.CacheCreationInputTokens = resp.Usage.CacheCreationInputTokens
 		usage.CacheReadInputTokens = resp.Usage.CacheReadInputTokens
+		usage.CacheCreation1hInputTokens = resp.Usage.cacheCreation1h()
+		usage.ServerToolUse = llm.ServerToolUseCounts(resp.Usage.ServerToolUse)
+		usage.ServiceTier = resp.Usage.ServiceTier
 	}
 
 	role := resp.Role
@@ -33,6 +33,26 @@ type anthropicStrUse struct {
 	OutputTokens             int `json:"output_tokens"`
 	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
 	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+
+	// CacheCreation splits CacheCreationInputTokens by TTL.
+	CacheCreation *struct {
+		Ephemeral1hInputTokens int `json:"ephemeral_1h_input_tokens"`
+	} `json:"cache_creation,omitempty"`
+
+	// ServerToolUse counts server-executed tool requests
+	// ({"web_search_requests": 2}); it stays raw so an unfamiliar
+	// counter cannot fail the event. See llm.ServerToolUseCounts.
+	ServerToolUse json.RawMessage `json:"server_tool_use,omitempty"`
+
+	ServiceTier string `json:"service_tier,omitempty"`
+}
+
+// cacheCreation1h returns the one-hour-TTL share of the cache writes.
+func (u *anthropicStrUse) cacheCreation1h() int {
+	if u.CacheCreation == nil {
+		return 0
+	}
+	return u.CacheCreation.Ephemeral1hInputTokens
 }
 
 // content_block_start announces a new block at a given index.
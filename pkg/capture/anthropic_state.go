@@ -85,6 +85,8 @@ func (s *turnState) onMessageStart(data []byte) error {
 			ev.Message.Usage.CacheReadInputTokens
 		s.usage.CacheCreationInputTokens = ev.Message.Usage.CacheCreationInputTokens
 		s.usage.CacheReadInputTokens = ev.Message.Usage.CacheReadInputTokens
+		s.usage.CacheCreation1hInputTokens = ev.Message.Usage.cacheCreation1h()
+		s.usage.ServiceTier = ev.Message.Usage.ServiceTier
 	}
 	return nil
 }
@@ -228,6 +230,11 @@ func (s *turnState) onMessageDelta(data []byte) error {
 	}
 	if ev.Usage != nil {
 		s.usage.CompletionTokens = ev.Usage.OutputTokens
+		// Server tools run after message_start, so their counts arrive
+		// with the final (cumulative) usage on message_delta.
+		if counts := llm.ServerToolUseCounts(ev.Usage.ServerToolUse); counts != nil {
+			s.usage.ServerToolUse = counts
+		}
 	}
 	return nil
 }
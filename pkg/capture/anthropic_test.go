@@ -92,7 +92,7 @@ var _ = Describe("Anthropic Reducer", func() {
 			// via input_json_delta (like tool_use); web_search_tool_result
 			// arrives fully formed in content_block_start with no deltas.
 			stream := `event: message_start
-data: {"type":"message_start","message":{"id":"msg_ws","type":"message","role":"assistant","content":[],"model":"claude-opus-4-8","stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":10,"output_tokens":0,"cache_creation_input_tokens":0,"cache_read_input_tokens":0}}}
+data: {"type":"message_start","message":{"id":"msg_ws","type":"message","role":"assistant","content":[],"model":"claude-opus-4-8","stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":10,"output_tokens":0,"cache_creation_input_tokens":300,"cache_read_input_tokens":0,"cache_creation":{"ephemeral_5m_input_tokens":100,"ephemeral_1h_input_tokens":200}}}}
 
 event: content_block_start
 data: {"type":"content_block_start","index":0,"content_block":{"type":"server_tool_use","id":"srvtoolu_1","name":"web_search","input":{}}}
@@ -119,7 +119,7 @@ event: content_block_stop
 data: {"type":"content_block_stop","index":2}
 
 event: message_delta
-data: {"type":"message_delta","delta":{"stop_reason":"end_turn","stop_sequence":null},"usage":{"output_tokens":20}}
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn","stop_sequence":null},"usage":{"output_tokens":20,"server_tool_use":{"web_search_requests":1,"web_fetch_requests":0}}}
 
 event: message_stop
 data: {"type":"message_stop"}
@@ -129,6 +129,11 @@ data: {"type":"message_stop"}
 			Expect(err).NotTo(HaveOccurred())
 			Expect(resp.Message.Content).To(HaveLen(3))
 
+			Expect(resp.Usage.PromptTokens).To(Equal(310))
+			Expect(resp.Usage.CacheCreationInputTokens).To(Equal(300))
+			Expect(resp.Usage.CacheCreation1hInputTokens).To(Equal(200))
+			Expect(resp.Usage.ServerToolUse).To(Equal(map[string]int{"web_search": 1}))
+
 			Expect(resp.Message.Content[0].Type).To(Equal("server_tool_use"))
 			Expect(resp.Message.Content[0].ToolName).To(Equal("web_search"))
 			Expect(resp.Message.Content[0].ToolUseID).To(Equal("srvtoolu_1"))
@@ -0,0 +1,9 @@
+package capture
+
+import "time"
+
+// SetLatencyClockForTest replaces the observer's clock, which dates every
+// byte and frame it sees.
+func SetLatencyClockForTest(o *LatencyObserver, now func() time.Time) {
+	o.now = now
+}
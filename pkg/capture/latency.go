@@ -0,0 +1,240 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/papercomputeco/tapes/pkg/llm"
+)
+
+// maxLatencyLine bounds the bytes LatencyObserver holds for one unterminated
+// line. A frame longer than this (a large tool-call argument, a base64 image)
+// is still timed by its first byte; only its content check is skipped.
+const maxLatencyLine = 64 << 10
+
+// LatencyObserver timestamps a response body as the capture adapter sees it
+// go by, and turns those timestamps into an llm.Latency.
+//
+// It is an io.Writer so it can sit on the same tee that already feeds the
+// client and the reducer: the proxy writes upstream bytes to it as they are
+// read, and tapes-extproc writes each ResponseBody chunk Envoy hands it. Both
+// SSE (`data:` lines) and NDJSON (one JSON object per line) bodies are
+// understood, without being told which. Bytes the observer cannot parse —
+// a compressed body, say — still mark the first byte and are otherwise
+// ignored, so the profile degrades to TTFB rather than to nonsense.
+//
+// Timing is per frame arrival, not per token: a provider that batches
+// several tokens into one frame shows one gap, and frames read in the same
+// chunk share an instant.
+type LatencyObserver struct {
+	mu         sync.Mutex
+	start      time.Time
+	now        func() time.Time
+	firstByte  time.Time
+	firstToken time.Time
+	lastToken  time.Time
+	gaps       []time.Duration
+	line       []byte
+	overflow   bool
+}
+
+// NewLatencyObserver returns an observer whose durations are measured from
+// start, the instant the capture adapter received the request.
+func NewLatencyObserver(start time.Time) *LatencyObserver {
+	return &LatencyObserver{start: start, now: time.Now}
+}
+
+// FirstByte marks the arrival of the response. Callers invoke it when the
+// upstream's headers arrive; later calls, and Write's own marking, keep the
+// earliest instant.
+func (o *LatencyObserver) FirstByte() {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.markFirstByte(o.now())
+}
+
+func (o *LatencyObserver) markFirstByte(at time.Time) {
+	if o.firstByte.IsZero() {
+		o.firstByte = at
+	}
+}
+
+// Write records the arrival of p. It never fails, so it is safe inside an
+// io.MultiWriter ahead of the client.
+func (o *LatencyObserver) Write(p []byte) (int, error) {
+	if o == nil || len(p) == 0 {
+		return len(p), nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	at := o.now()
+	o.markFirstByte(at)
+
+	rest := p
+	for {
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			break
+		}
+		o.appendLine(rest[:i])
+		if !o.overflow && contentFrame(o.line) {
+			o.markToken(at)
+		}
+		o.line = o.line[:0]
+		o.overflow = false
+		rest = rest[i+1:]
+	}
+	o.appendLine(rest)
+
+	return len(p), nil
+}
+
+func (o *LatencyObserver) appendLine(b []byte) {
+	if o.overflow {
+		return
+	}
+	if len(o.line)+len(b) > maxLatencyLine {
+		o.overflow = true
+		o.line = o.line[:0]
+		return
+	}
+	o.line = append(o.line, b...)
+}
+
+func (o *LatencyObserver) markToken(at time.Time) {
+	if o.firstToken.IsZero() {
+		o.firstToken = at
+	} else {
+		o.gaps = append(o.gaps, at.Sub(o.lastToken))
+	}
+	o.lastToken = at
+}
+
+// Latency returns the profile observed so far, or nil when nothing was. A
+// trailing line without a newline is judged here, since the body has ended.
+// outputTokens is the provider-reported output count used for the rate; zero
+// leaves OutputTokensPerSec unset.
+func (o *LatencyObserver) Latency(outputTokens int) *llm.Latency {
+	if o == nil {
+		return nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(o.line) > 0 && !o.overflow && contentFrame(o.line) {
+		o.markToken(o.lastSeen())
+		o.line = o.line[:0]
+	}
+	if o.firstByte.IsZero() {
+		return nil
+	}
+
+	lat := &llm.Latency{TimeToFirstByteNs: o.firstByte.Sub(o.start).Nanoseconds()}
+	if o.firstToken.IsZero() {
+		return lat
+	}
+	lat.TimeToFirstTokenNs = o.firstToken.Sub(o.start).Nanoseconds()
+	if len(o.gaps) > 0 {
+		sorted := slices.Clone(o.gaps)
+		slices.Sort(sorted)
+		lat.InterTokenP50Ns = percentile(sorted, 50).Nanoseconds()
+		lat.InterTokenP95Ns = percentile(sorted, 95).Nanoseconds()
+	}
+	if generating := o.lastToken.Sub(o.firstToken); outputTokens > 0 && generating > 0 {
+		lat.OutputTokensPerSec = float64(outputTokens) / generating.Seconds()
+	}
+
+	return lat
+}
+
+// lastSeen is the latest instant the observer recorded, used to date a
+// final frame that arrived without its newline.
+func (o *LatencyObserver) lastSeen() time.Time {
+	if o.lastToken.After(o.firstByte) {
+		return o.lastToken
+	}
+
+	return o.firstByte
+}
+
+// percentile is the nearest-rank percentile of an ascending slice.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+// latencyFrame is the union of the streamed-frame shapes that carry
+// generated output, across the providers capture reduces.
+type latencyFrame struct {
+	// Anthropic (content_block_delta) and OpenAI Responses
+	// (response.*.delta) name their frames.
+	Type string `json:"type"`
+
+	// OpenAI Chat Completions.
+	Choices []struct {
+		Delta struct {
+			Content          string          `json:"content"`
+			ReasoningContent string          `json:"reasoning_content"`
+			ToolCalls        json.RawMessage `json:"tool_calls"`
+		} `json:"delta"`
+	} `json:"choices"`
+
+	// Ollama chat and generate.
+	Message *struct {
+		Content   string          `json:"content"`
+		Thinking  string          `json:"thinking"`
+		ToolCalls json.RawMessage `json:"tool_calls"`
+	} `json:"message"`
+	Response string `json:"response"`
+}
+
+// contentFrame reports whether one line of a streamed body is a frame
+// carrying generated output.
+func contentFrame(line []byte) bool {
+	line = bytes.TrimSpace(line)
+	if payload, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+		line = bytes.TrimSpace(payload)
+	}
+	if len(line) == 0 || line[0] != '{' {
+		return false
+	}
+
+	var f latencyFrame
+	if json.Unmarshal(line, &f) != nil {
+		return false
+	}
+	switch {
+	case f.Type == "content_block_delta":
+		return true
+	case strings.HasPrefix(f.Type, "response.") && strings.HasSuffix(f.Type, ".delta"):
+		return true
+	case f.Message != nil:
+		return f.Message.Content != "" || f.Message.Thinking != "" || present(f.Message.ToolCalls)
+	case f.Response != "":
+		return true
+	}
+	for _, c := range f.Choices {
+		if c.Delta.Content != "" || c.Delta.ReasoningContent != "" || present(c.Delta.ToolCalls) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// present reports whether a raw JSON field holds a value other than null.
+func present(raw json.RawMessage) bool {
+	return len(raw) > 0 && !bytes.Equal(raw, []byte("null"))
+}
@@ -0,0 +1,74 @@
+package capture_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/capture"
+)
+
+var _ = Describe("LatencyObserver", func() {
+	var (
+		start    time.Time
+		now      time.Time
+		observer *capture.LatencyObserver
+	)
+
+	BeforeEach(func() {
+		start = time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+		now = start
+		observer = capture.NewLatencyObserver(start)
+		capture.SetLatencyClockForTest(observer, func() time.Time { return now })
+	})
+
+	at := func(ms int, chunk string) {
+		now = start.Add(time.Duration(ms) * time.Millisecond)
+		_, err := observer.Write([]byte(chunk))
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	It("separates the wait for the first byte from the wait for the first token", func() {
+		now = start.Add(200 * time.Millisecond)
+		observer.FirstByte()
+		at(250, "event: message_start\ndata: {\"type\":\"message_start\"}\n\n")
+		at(400, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"text\":\"Hel\"}}\n\n")
+		at(420, "data: {\"type\":\"content_block_delta\",\"delta\":{\"text\":\"lo\"}}\n\n")
+		at(460, "data: {\"type\":\"content_block_delta\",\"delta\":{\"text\":\"!\"}}\n\n")
+		at(470, "data: {\"type\":\"message_stop\"}\n\n")
+
+		lat := observer.Latency(13)
+		Expect(lat.TimeToFirstByteNs).To(Equal((200 * time.Millisecond).Nanoseconds()))
+		Expect(lat.TimeToFirstTokenNs).To(Equal((400 * time.Millisecond).Nanoseconds()))
+		Expect(lat.InterTokenP50Ns).To(Equal((20 * time.Millisecond).Nanoseconds()))
+		Expect(lat.InterTokenP95Ns).To(Equal((40 * time.Millisecond).Nanoseconds()))
+		Expect(lat.OutputTokensPerSec).To(BeNumerically("~", 13/0.06, 0.001))
+	})
+
+	It("reads OpenAI chat and Ollama frames, including a frame split across chunks", func() {
+		at(100, `data: {"choices":[{"delta":{"role":"assistant"}}]}`+"\n")
+		at(150, `data: {"choices":[{"delta":{"content":"Hi"}}]}`+"\n")
+		at(180, `{"message":{"role":"assistant","con`)
+		at(190, `tent":"there"},"done":false}`+"\n")
+		at(200, `{"message":{"role":"assistant","content":""},"done":true}`)
+
+		lat := observer.Latency(0)
+		Expect(lat.TimeToFirstByteNs).To(Equal((100 * time.Millisecond).Nanoseconds()))
+		Expect(lat.TimeToFirstTokenNs).To(Equal((150 * time.Millisecond).Nanoseconds()))
+		Expect(lat.InterTokenP50Ns).To(Equal((40 * time.Millisecond).Nanoseconds()))
+		Expect(lat.OutputTokensPerSec).To(BeZero())
+	})
+
+	It("falls back to time-to-first-byte for a body it cannot read", func() {
+		at(300, "\x1f\x8b\x08\x00compressed\n{garbage")
+
+		lat := observer.Latency(10)
+		Expect(lat.TimeToFirstByteNs).To(Equal((300 * time.Millisecond).Nanoseconds()))
+		Expect(lat.TimeToFirstTokenNs).To(BeZero())
+	})
+
+	It("reports nothing before the response arrives", func() {
+		Expect(observer.Latency(0)).To(BeNil())
+	})
+})
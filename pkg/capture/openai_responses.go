@@ -196,6 +196,7 @@ type responsesObject struct {
 	CreatedAt         int64             `json:"created_at"`
 	Status            string            `json:"status"`
 	Model             string            `json:"model"`
+	ServiceTier       string            `json:"service_tier"`
 	Output            []json.RawMessage `json:"output"`
 	Usage             *responsesUsage   `json:"usage"`
 	IncompleteDetails *struct {
@@ -210,6 +211,33 @@ type responsesUsage struct {
 	InputTokensDetails *struct {
 		CachedTokens int `json:"cached_tokens"`
 	} `json:"input_tokens_details"`
+	OutputTokensDetails *struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"output_tokens_details"`
+}
+
+// responsesBilledToolCalls maps the hosted-tool output items OpenAI bills
+// per call to their llm.Usage.ServerToolUse key. The Response's usage block
+// does not count them, so they are counted off the output items.
+var responsesBilledToolCalls = map[string]string{
+	"web_search_call":  "web_search",
+	"file_search_call": "file_search",
+}
+
+// responsesServerToolUse counts the billed hosted-tool calls in content.
+func responsesServerToolUse(content []llm.ContentBlock) map[string]int {
+	var counts map[string]int
+	for _, block := range content {
+		tool, ok := responsesBilledToolCalls[block.Type]
+		if !ok {
+			continue
+		}
+		if counts == nil {
+			counts = map[string]int{}
+		}
+		counts[tool]++
+	}
+	return counts
 }
 
 // responsesOutputItem is the union of output item shapes (message,
@@ -328,6 +356,11 @@ func responsesObjectToChat(resp *responsesObject) *llm.ChatResponse {
 		if resp.Usage.InputTokensDetails != nil {
 			usage.CacheReadInputTokens = resp.Usage.InputTokensDetails.CachedTokens
 		}
+		if resp.Usage.OutputTokensDetails != nil {
+			usage.ReasoningTokens = resp.Usage.OutputTokensDetails.ReasoningTokens
+		}
+		usage.ServerToolUse = responsesServerToolUse(content)
+		usage.ServiceTier = resp.ServiceTier
 		out.Usage = usage
 	}
 	return out
@@ -74,6 +74,29 @@ var _ = Describe("OpenAI Responses reducer", func() {
 			Expect(resp.Message.Content[1].ToolInput).To(HaveKey("command"))
 		})
 
+		It("reports reasoning, cached and hosted-tool usage", func() {
+			body := `{
+				"object": "response", "id": "resp_x", "status": "completed",
+				"model": "gpt-5.5",
+				"output": [
+					{"type": "web_search_call", "id": "ws_1", "status": "completed"},
+					{"type": "web_search_call", "id": "ws_2", "status": "completed"},
+					{"type": "message", "role": "assistant", "content": [{"type": "output_text", "text": "done"}]}
+				],
+				"usage": {
+					"input_tokens": 1000, "output_tokens": 400, "total_tokens": 1400,
+					"input_tokens_details": {"cached_tokens": 600},
+					"output_tokens_details": {"reasoning_tokens": 300}
+				}
+			}`
+			resp, err := r.Reduce(ctx, nil, strings.NewReader(body), "application/json")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(resp.Usage.CacheReadInputTokens).To(Equal(600))
+			Expect(resp.Usage.ReasoningTokens).To(Equal(300))
+			Expect(resp.Usage.ServerToolUse).To(Equal(map[string]int{"web_search": 2}))
+		})
+
 		It("surfaces incomplete_details as the stop reason", func() {
 			body := `{
 				"object": "response", "id": "resp_x", "status": "incomplete",
@@ -66,18 +66,36 @@ func ValidConfigKeys() []string {
 		"proxy.upstream",
 		"proxy.listen",
 		"proxy.project",
+		"proxy.spool",
+		"proxy.ingest_url",
+		"proxy.ingest_token",
+		"proxy.capture_file",
 		"api.listen",
 		"api.web_ui",
+		"api.auth",
 		"ingest.listen",
 		"client.proxy_target",
 		"client.api_target",
 		"opencode.provider",
 		"opencode.model",
+		"embedding.provider",
+		"embedding.target",
+		"embedding.model",
 		"logging.level",
 		"logging.format",
 		"logging.color",
+		"storage.postgres_dsn",
+		"storage.multi_tenant",
 		"telemetry.disabled",
 		"update.disabled",
+		"oidc.jwks_url",
+		"oidc.issuer",
+		"oidc.audience",
+		"oidc.subject_claim",
+		"oidc.admin_claim",
+		"oidc.admin_value",
+		"oidc.org_claim",
+		"oidc.own_sessions_only",
 	}
 
 	// Sanity: only return keys that actually exist in the map.
@@ -152,6 +170,8 @@ func (c *Configer) LoadConfig() (*Config, error) {
 	// synthesize from defaults.
 	merged.Version = cfg.Version
 	merged.Cassettes = cfg.Cassettes
+	merged.Retention = cfg.Retention
+	merged.Derive = cfg.Derive
 	if err := validateLoggingConfig(merged.Logging); err != nil {
 		return nil, err
 	}
@@ -221,6 +241,8 @@ func (c *Configer) SetConfigValue(key string, value string) error {
 	// Preserve fields which are not scalar set/get keys.
 	updated.Version = cfg.Version
 	updated.Cassettes = cfg.Cassettes
+	updated.Retention = cfg.Retention
+	updated.Derive = cfg.Derive
 	if err := validateLoggingConfig(updated.Logging); err != nil {
 		return err
 	}
@@ -348,6 +370,9 @@ func ParseConfigTOML(data []byte) (*Config, error) {
 	if err := cfg.ValidateCassettes(); err != nil {
 		return nil, err
 	}
+	if err := cfg.ValidateRetention(); err != nil {
+		return nil, err
+	}
 
 	return cfg, nil
 }
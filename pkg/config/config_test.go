@@ -470,6 +470,11 @@ listen = ":7070"
 					ProxyTarget: "http://myhost:9090",
 					APITarget:   "http://myhost:9091",
 				},
+				Embedding: config.EmbeddingConfig{
+					Provider: "openai",
+					Target:   "http://myhost:8000/v1",
+					Model:    "text-embedding-3-small",
+				},
 				Logging: config.LoggingConfig{
 					Level:  "info",
 					Format: "auto",
@@ -10,6 +10,8 @@ const (
 	defaultClientProxyTarget = "http://localhost:8080"
 	defaultClientAPITarget   = "http://localhost:8081"
 
+	defaultEmbeddingProvider = "ollama"
+
 	defaultLogLevel  = "info"
 	defaultLogFormat = "auto"
 	defaultLogColor  = "auto"
@@ -36,6 +38,9 @@ func NewDefaultConfig() *Config {
 			ProxyTarget: defaultClientProxyTarget,
 			APITarget:   defaultClientAPITarget,
 		},
+		Embedding: EmbeddingConfig{
+			Provider: defaultEmbeddingProvider,
+		},
 		Logging: LoggingConfig{
 			Level:  defaultLogLevel,
 			Format: defaultLogFormat,
@@ -41,10 +41,15 @@ const (
 	FlagProxyListen         = "proxy-listen"
 	FlagAPIListen           = "api-listen"
 	FlagAPIWebUI            = "api-web-ui"
+	FlagAPIAuth             = "api-auth"
+	FlagMultiTenant         = "multi-tenant"
 	FlagUpstream            = "upstream"
 	FlagProvider            = "provider"
 	FlagPostgres            = "postgres"
 	FlagProject             = "project"
+	FlagProxySpool          = "proxy-spool"
+	FlagProxyIngestURL      = "proxy-ingest-url"
+	FlagProxyCaptureFile    = "proxy-capture-file"
 	FlagAPITarget           = "api-target"
 	FlagProxyTarget         = "proxy-target"
 	FlagTelemetryDisabled   = "telemetry-disabled"
@@ -70,6 +75,18 @@ const (
 	FlagDeriveWorkerMaxDeriveLag  = "derive-worker-max-derive-lag"
 	FlagDeriveWorkerMetricsListen = "derive-worker-metrics-listen"
 	FlagDeriveWorkerWaitForDB     = "derive-worker-wait-for-db"
+
+	// Embed worker (`tapes serve embed-worker`) and the embedding model it
+	// shares with the API's semantic search.
+	FlagEmbeddingProvider        = "embedding-provider"
+	FlagEmbeddingTarget          = "embedding-target"
+	FlagEmbeddingModel           = "embedding-model"
+	FlagEmbedWorkerPoll          = "embed-worker-poll-interval"
+	FlagEmbedWorkerMetricsListen = "embed-worker-metrics-listen"
+	FlagEmbedWorkerWaitForDB     = "embed-worker-wait-for-db"
+
+	// Retention worker (`tapes serve retention-worker`) tunables.
+	FlagRetentionDryRun = "retention-dry-run"
 )
 
 // AddStringFlag registers a string flag on cmd from the given FlagSet.
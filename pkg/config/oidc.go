@@ -0,0 +1,70 @@
+package config
+
+import (
+	"errors"
+	"strings"
+)
+
+// OIDCConfig configures in-process JWT verification for deployments with no
+// gateway in front of tapes. An empty JWKSURL disables it: identity then comes
+// only from the gateway's x-paper-auth-subject header, as before.
+type OIDCConfig struct {
+	// JWKSURL is the issuer's JSON Web Key Set document.
+	JWKSURL string `toml:"jwks_url,omitempty" mapstructure:"jwks_url"`
+
+	// Issuer and Audience, when set, must match the token's iss and aud.
+	Issuer   string `toml:"issuer,omitempty"   mapstructure:"issuer"`
+	Audience string `toml:"audience,omitempty" mapstructure:"audience"`
+
+	// SubjectClaim is the claim stamped as auth_subject ("sub" when empty;
+	// dotted paths reach nested claims).
+	SubjectClaim string `toml:"subject_claim,omitempty" mapstructure:"subject_claim"`
+
+	// AdminClaim and AdminValue mark a caller as an admin: the claim must
+	// equal (or, as an array, contain) the value, or be true when the value
+	// is empty.
+	AdminClaim string `toml:"admin_claim,omitempty" mapstructure:"admin_claim"`
+	AdminValue string `toml:"admin_value,omitempty" mapstructure:"admin_value"`
+
+	// OrgClaim is the claim carrying the caller's org UUID in a
+	// multi-tenant deployment (see storage.multi_tenant).
+	OrgClaim string `toml:"org_claim,omitempty" mapstructure:"org_claim"`
+
+	// OwnSessionsOnly restricts a verified non-admin caller's reads to the
+	// sessions captured under its own subject.
+	OwnSessionsOnly bool `toml:"own_sessions_only,omitempty" mapstructure:"own_sessions_only"`
+}
+
+// Enabled reports whether JWT verification is configured.
+func (oc OIDCConfig) Enabled() bool {
+	return oc.JWKSURL != ""
+}
+
+// ValidateOIDCConfig checks an [oidc] section. The settings that only mean
+// something with a key set are rejected without one, so a half-configured
+// section fails at startup instead of silently verifying nothing.
+func ValidateOIDCConfig(oc OIDCConfig) error {
+	if !oc.Enabled() {
+		if oc.Issuer != "" || oc.Audience != "" || oc.AdminClaim != "" || oc.OrgClaim != "" || oc.OwnSessionsOnly {
+			return errors.New("oidc: jwks_url is required when any other oidc setting is set")
+		}
+		return nil
+	}
+	if !strings.HasPrefix(oc.JWKSURL, "https://") && !strings.HasPrefix(oc.JWKSURL, "http://") {
+		return errors.New("oidc.jwks_url: must be an http(s) URL")
+	}
+	if oc.AdminValue != "" && oc.AdminClaim == "" {
+		return errors.New("oidc.admin_value: requires oidc.admin_claim")
+	}
+	return nil
+}
+
+// ValidateMultiTenantOIDC checks an [oidc] section for a multi-tenant
+// deployment: a verified JWT must say which org its caller belongs to, or the
+// services could only file its turns under the single-tenant sentinel.
+func ValidateMultiTenantOIDC(oc OIDCConfig) error {
+	if oc.Enabled() && oc.OrgClaim == "" {
+		return errors.New("oidc.org_claim: required when storage.multi_tenant is set")
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Retention rule actions, mirrored from pkg/storage so the config layer
+// validates without importing the storage stack.
+const (
+	RetentionActionDelete = "delete"
+	RetentionActionStrip  = "strip"
+)
+
+// RetentionConfig holds the retention worker's rules. An empty rule list
+// disables retention: raw turns are kept forever, as before.
+type RetentionConfig struct {
+	// Interval is how often the worker applies the rules (Go duration or
+	// a day count like "1d"; empty means the worker default).
+	Interval string `toml:"interval,omitempty" mapstructure:"interval"`
+
+	Rules []RetentionRule `toml:"rules,omitempty" mapstructure:"rules"`
+}
+
+// RetentionRule is one [[retention.rules]] table.
+type RetentionRule struct {
+	// Name labels the rule in logs and metrics.
+	Name string `toml:"name" mapstructure:"name"`
+
+	// Action is "delete" (remove the session and its raw turns) or
+	// "strip" (remove prompt/response content, keep usage and cost).
+	Action string `toml:"action" mapstructure:"action"`
+
+	// MaxAge is how long after a session was last seen the rule applies:
+	// a Go duration ("2160h") or a day count ("90d").
+	MaxAge string `toml:"max_age" mapstructure:"max_age"`
+
+	// Harness and AuthSubject narrow the rule; empty matches any.
+	Harness     string `toml:"harness,omitempty"      mapstructure:"harness"`
+	AuthSubject string `toml:"auth_subject,omitempty" mapstructure:"auth_subject"`
+}
+
+// ValidateRetention checks this config's retention rules.
+func (config *Config) ValidateRetention() error {
+	return ValidateRetentionConfig(config.Retention)
+}
+
+// ValidateRetentionConfig checks a retention section: every rule needs a
+// unique name, a known action, and a positive age.
+func ValidateRetentionConfig(rc RetentionConfig) error {
+	if rc.Interval != "" {
+		d, err := ParseRetentionAge(rc.Interval)
+		if err != nil {
+			return fmt.Errorf("retention.interval: %w", err)
+		}
+		if d <= 0 {
+			return errors.New("retention.interval: must be positive")
+		}
+	}
+	seen := make(map[string]int, len(rc.Rules))
+	for i, rule := range rc.Rules {
+		field := fmt.Sprintf("retention.rules[%d]", i)
+		if rule.Name == "" {
+			return fmt.Errorf("%s: name is required", field)
+		}
+		if prev, ok := seen[rule.Name]; ok {
+			return fmt.Errorf("%s: name %q duplicates retention.rules[%d]", field, rule.Name, prev)
+		}
+		seen[rule.Name] = i
+		switch rule.Action {
+		case RetentionActionDelete, RetentionActionStrip:
+		default:
+			return fmt.Errorf("%s: action must be %q or %q, got %q",
+				field, RetentionActionDelete, RetentionActionStrip, rule.Action)
+		}
+		age, err := ParseRetentionAge(rule.MaxAge)
+		if err != nil {
+			return fmt.Errorf("%s: max_age: %w", field, err)
+		}
+		if age <= 0 {
+			return fmt.Errorf("%s: max_age must be positive", field)
+		}
+	}
+	return nil
+}
+
+// ParseRetentionAge parses a retention duration: either a Go duration
+// ("720h", "30m") or a whole number of days ("90d"), since retention
+// policies are written in days and time.ParseDuration stops at hours.
+func ParseRetentionAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, errors.New("duration is required")
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid day count %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %w", value, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,71 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/config"
+)
+
+const validRetentionConfig = `version = 0
+
+[retention]
+interval = "6h"
+
+[[retention.rules]]
+name = "strip-old-content"
+action = "strip"
+max_age = "30d"
+
+[[retention.rules]]
+name = "purge-codex"
+action = "delete"
+max_age = "8760h"
+harness = "codex"
+`
+
+var _ = Describe("retention config", func() {
+	It("decodes rule tables", func() {
+		parsed, err := config.ParseConfigTOML([]byte(validRetentionConfig))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed.Retention.Interval).To(Equal("6h"))
+		Expect(parsed.Retention.Rules).To(HaveLen(2))
+		Expect(parsed.Retention.Rules[1].Harness).To(Equal("codex"))
+	})
+
+	DescribeTable("rejects invalid rules",
+		func(rules, want string) {
+			_, err := config.ParseConfigTOML([]byte(rules))
+			Expect(err).To(MatchError(ContainSubstring(want)))
+		},
+		Entry("missing name", "[[retention.rules]]\naction = \"strip\"\nmax_age = \"1d\"\n", "name is required"),
+		Entry("unknown action", "[[retention.rules]]\nname = \"x\"\naction = \"archive\"\nmax_age = \"1d\"\n", "action must be"),
+		Entry("missing age", "[[retention.rules]]\nname = \"x\"\naction = \"strip\"\n", "max_age"),
+		Entry("zero age", "[[retention.rules]]\nname = \"x\"\naction = \"strip\"\nmax_age = \"0d\"\n", "must be positive"),
+		Entry("duplicate name",
+			"[[retention.rules]]\nname = \"x\"\naction = \"strip\"\nmax_age = \"1d\"\n[[retention.rules]]\nname = \"x\"\naction = \"delete\"\nmax_age = \"2d\"\n",
+			"duplicates retention.rules[0]"),
+	)
+
+	It("parses day counts and Go durations", func() {
+		Expect(config.ParseRetentionAge("90d")).To(Equal(90 * 24 * time.Hour))
+		Expect(config.ParseRetentionAge("36h")).To(Equal(36 * time.Hour))
+		_, err := config.ParseRetentionAge("ninety days")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("preserves rules when setting another key", func() {
+		directory := GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(directory, "config.toml"), []byte(validRetentionConfig), 0o600)).To(Succeed())
+		configer, err := config.NewConfiger(directory)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(configer.SetConfigValue("proxy.provider", "anthropic")).To(Succeed())
+		loaded, err := configer.LoadConfig()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loaded.Retention.Rules).To(HaveLen(2))
+	})
+})
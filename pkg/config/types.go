@@ -13,6 +13,10 @@ type Config struct {
 	Logging   LoggingConfig   `toml:"logging"       mapstructure:"logging"`
 	Telemetry TelemetryConfig `toml:"telemetry"     mapstructure:"telemetry"`
 	Update    UpdateConfig    `toml:"update"        mapstructure:"update"`
+	Retention RetentionConfig `toml:"retention"     mapstructure:"retention"`
+	OIDC      OIDCConfig      `toml:"oidc"          mapstructure:"oidc"`
+	Derive    DeriveConfig    `toml:"derive"        mapstructure:"derive"`
+	Embedding EmbeddingConfig `toml:"embedding"     mapstructure:"embedding"`
 	// Cassettes contains exact OpenAPI document URLs for externally managed cassettes.
 	Cassettes []string `toml:"cassettes" mapstructure:"cassettes"`
 }
@@ -20,6 +24,29 @@ type Config struct {
 // StorageConfig holds shared storage settings used by both proxy and API.
 type StorageConfig struct {
 	PostgresDSN string `toml:"postgres_dsn,omitempty" mapstructure:"postgres_dsn"`
+
+	// MultiTenant scopes every read to the caller's org, resolved from its
+	// API token or verified org claim, instead of the single-tenant sentinel.
+	MultiTenant bool `toml:"multi_tenant,omitempty" mapstructure:"multi_tenant"`
+}
+
+// DeriveConfig holds settings for the deriver.
+type DeriveConfig struct {
+	// HarnessProfiles are call-classifier profile files (TOML or JSON),
+	// consulted ahead of the built-in harness profiles.
+	HarnessProfiles []string `toml:"harness_profiles,omitempty" mapstructure:"harness_profiles"`
+}
+
+// EmbeddingConfig selects the embedding model the embed worker writes span
+// vectors with and the API embeds search queries with. Both must agree, so
+// they read the same section.
+type EmbeddingConfig struct {
+	// Provider is "ollama" or "openai" (any OpenAI-compatible server).
+	Provider string `toml:"provider,omitempty" mapstructure:"provider"`
+	// Target is the embedding server's URL; empty uses the provider's.
+	Target string `toml:"target,omitempty"   mapstructure:"target"`
+	// Model is the embedding model; empty uses the provider's default.
+	Model string `toml:"model,omitempty"    mapstructure:"model"`
 }
 
 // ProxyConfig holds proxy-specific settings.
@@ -28,12 +55,26 @@ type ProxyConfig struct {
 	Upstream string `toml:"upstream,omitempty" mapstructure:"upstream"`
 	Listen   string `toml:"listen,omitempty"   mapstructure:"listen"`
 	Project  string `toml:"project,omitempty"  mapstructure:"project"`
+	// Spool write-ahead spools captures under the .tapes directory so a
+	// proxy restart or a store outage does not lose turns.
+	Spool bool `toml:"spool,omitempty" mapstructure:"spool"`
+	// IngestURL switches the proxy to remote capture: turns are forwarded
+	// to this tapes ingest server instead of written to a local database.
+	IngestURL string `toml:"ingest_url,omitempty" mapstructure:"ingest_url"`
+	// IngestToken is the bearer token sent to that server when it verifies
+	// callers.
+	IngestToken string `toml:"ingest_token,omitempty" mapstructure:"ingest_token"`
+	// CaptureFile switches the proxy to file capture: turns are appended
+	// to this corpus file instead of written to a database.
+	CaptureFile string `toml:"capture_file,omitempty" mapstructure:"capture_file"`
 }
 
 // APIConfig holds API server settings.
 type APIConfig struct {
 	Listen string `toml:"listen,omitempty" mapstructure:"listen"`
 	WebUI  bool   `toml:"web_ui,omitempty" mapstructure:"web_ui"`
+	// Auth enforces the built-in API token auth (see `tapes auth token`).
+	Auth bool `toml:"auth,omitempty" mapstructure:"auth"`
 }
 
 // IngestConfig holds ingest server settings for sidecar mode.
@@ -79,21 +120,40 @@ var configKeySet = map[string]bool{
 	"proxy.upstream":      true,
 	"proxy.listen":        true,
 	"proxy.project":       true,
+	"proxy.spool":         true,
+	"proxy.ingest_url":    true,
+	"proxy.ingest_token":  true,
+	"proxy.capture_file":  true,
 	"api.listen":          true,
 	"api.web_ui":          true,
+	"api.auth":            true,
 	"ingest.listen":       true,
 	"client.proxy_target": true,
 	"client.api_target":   true,
 	"opencode.provider":   true,
 	"opencode.model":      true,
 
+	"embedding.provider": true,
+	"embedding.target":   true,
+	"embedding.model":    true,
+
 	"logging.level":  true,
 	"logging.format": true,
 	"logging.color":  true,
 
 	"storage.postgres_dsn": true,
+	"storage.multi_tenant": true,
 
 	"telemetry.disabled": true,
 
 	"update.disabled": true,
+
+	"oidc.jwks_url":          true,
+	"oidc.issuer":            true,
+	"oidc.audience":          true,
+	"oidc.subject_claim":     true,
+	"oidc.admin_claim":       true,
+	"oidc.admin_value":       true,
+	"oidc.org_claim":         true,
+	"oidc.own_sessions_only": true,
 }
@@ -74,16 +74,22 @@ func setViperDefaults(v *viper.Viper) {
 
 	// Storage
 	v.SetDefault("storage.postgres_dsn", d.Storage.PostgresDSN)
+	v.SetDefault("storage.multi_tenant", d.Storage.MultiTenant)
 
 	// Proxy
 	v.SetDefault("proxy.provider", d.Proxy.Provider)
 	v.SetDefault("proxy.upstream", d.Proxy.Upstream)
 	v.SetDefault("proxy.listen", d.Proxy.Listen)
 	v.SetDefault("proxy.project", d.Proxy.Project)
+	v.SetDefault("proxy.spool", d.Proxy.Spool)
+	v.SetDefault("proxy.ingest_url", d.Proxy.IngestURL)
+	v.SetDefault("proxy.ingest_token", d.Proxy.IngestToken)
+	v.SetDefault("proxy.capture_file", d.Proxy.CaptureFile)
 
 	// API
 	v.SetDefault("api.listen", d.API.Listen)
 	v.SetDefault("api.web_ui", d.API.WebUI)
+	v.SetDefault("api.auth", d.API.Auth)
 
 	// Ingest
 	v.SetDefault("ingest.listen", d.Ingest.Listen)
@@ -96,6 +102,11 @@ func setViperDefaults(v *viper.Viper) {
 	v.SetDefault("opencode.provider", d.OpenCode.Provider)
 	v.SetDefault("opencode.model", d.OpenCode.Model)
 
+	// Embedding
+	v.SetDefault("embedding.provider", d.Embedding.Provider)
+	v.SetDefault("embedding.target", d.Embedding.Target)
+	v.SetDefault("embedding.model", d.Embedding.Model)
+
 	// Logging
 	v.SetDefault("logging.level", d.Logging.Level)
 	v.SetDefault("logging.format", d.Logging.Format)
@@ -106,4 +117,14 @@ func setViperDefaults(v *viper.Viper) {
 
 	// Update check
 	v.SetDefault("update.disabled", d.Update.Disabled)
+
+	// OIDC
+	v.SetDefault("oidc.jwks_url", d.OIDC.JWKSURL)
+	v.SetDefault("oidc.issuer", d.OIDC.Issuer)
+	v.SetDefault("oidc.audience", d.OIDC.Audience)
+	v.SetDefault("oidc.subject_claim", d.OIDC.SubjectClaim)
+	v.SetDefault("oidc.admin_claim", d.OIDC.AdminClaim)
+	v.SetDefault("oidc.admin_value", d.OIDC.AdminValue)
+	v.SetDefault("oidc.org_claim", d.OIDC.OrgClaim)
+	v.SetDefault("oidc.own_sessions_only", d.OIDC.OwnSessionsOnly)
 }
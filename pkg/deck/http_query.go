@@ -324,7 +324,7 @@ func (q *HTTPQuery) messagesFromLLMSpan(traceID string, sp httpSpanItem, lastTim
 	var inputCost, outputCost, totalCost float64
 	if model != "" {
 		if price, ok := sessions.PricingForModel(q.pricing, model); ok {
-			inputCost, outputCost, totalCost = sessions.CostForTokensWithCache(price, tokens.Input, tokens.Output, tokens.CacheCreation, tokens.CacheRead)
+			inputCost, outputCost, totalCost = sessions.CostForUsage(price, tokens)
 		}
 	}
 
@@ -646,15 +646,7 @@ func tokensFromMetrics(raw json.RawMessage) sessions.NodeTokens {
 	if err := json.Unmarshal(raw, &usage); err != nil {
 		return nt
 	}
-	nt.Input = int64(usage.PromptTokens)
-	nt.Output = int64(usage.CompletionTokens)
-	nt.CacheCreation = int64(usage.CacheCreationInputTokens)
-	nt.CacheRead = int64(usage.CacheReadInputTokens)
-	nt.Total = nt.Input + nt.Output
-	if usage.TotalTokens > 0 {
-		nt.Total = int64(usage.TotalTokens)
-	}
-	return nt
+	return sessions.TokensForUsage(&usage)
 }
 
 // effectiveSinceCutoff returns the timestamp below which sessions should
@@ -18,7 +18,9 @@ import (
 // through the deriver, so the loader lives here rather than in test
 // code.
 
-// corpusRow mirrors the raw_turns columns a corpus dump carries.
+// corpusRow mirrors the raw_turns columns a corpus dump carries. The
+// verbatim-response columns are omitted when empty, so corpora dumped
+// before they existed load unchanged.
 type corpusRow struct {
 	ID               int64           `json:"id"`
 	OrgID            string          `json:"org_id"`
@@ -33,6 +35,10 @@ type corpusRow struct {
 	Meta             json.RawMessage `json:"meta"`
 	SessionEnvelope  json.RawMessage `json:"session_envelope"`
 	ReceivedAt       time.Time       `json:"received_at"`
+
+	RawResponse         []byte `json:"raw_response,omitempty"`
+	RawResponseEncoding string `json:"raw_response_encoding,omitempty"`
+	RawResponseDropped  bool   `json:"raw_response_dropped,omitempty"`
 }
 
 // LoadCorpusFile reads a gzipped-JSONL corpus dump and returns its
@@ -110,6 +116,8 @@ func corpusRowFromRecord(r *storage.RawTurnRecord) corpusRow {
 		RequestID: r.RequestID, RawRequest: r.RawRequest,
 		Response: r.Response, Meta: r.Meta,
 		SessionEnvelope: r.SessionEnvelope, ReceivedAt: r.ReceivedAt,
+		RawResponse: r.RawResponse, RawResponseEncoding: r.RawResponseEncoding,
+		RawResponseDropped: r.RawResponseDropped,
 	}
 }
 
@@ -118,12 +126,40 @@ func corpusRowFromRecord(r *storage.RawTurnRecord) corpusRow {
 // io.Reader form serves embedded corpora (the demo seed bundles corpus
 // files into the binary); LoadCorpusFile wraps it for on-disk dumps.
 func LoadCorpus(r io.Reader) (wire, transcripts []storage.RawTurnRecord, err error) {
-	gz, err := gzip.NewReader(r)
+	err = ReadCorpus(r, func(rec *storage.RawTurnRecord) error {
+		if rec.Source == storage.RawTurnSourceTranscript {
+			transcripts = append(transcripts, *rec)
+		} else {
+			wire = append(wire, *rec)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, nil, fmt.Errorf("gunzip corpus: %w", err)
+		return nil, nil, err
+	}
+	return wire, transcripts, nil
+}
+
+// ReadCorpus streams a gzipped-JSONL corpus dump from r, calling fn once
+// per row in file order. It is the streaming form of LoadCorpus for
+// dumps too large to hold in memory (a whole-deployment export bundle).
+// An error from fn stops the read and is returned as-is.
+//
+// Concatenated gzip members read as one dump, which is how the proxy's
+// file sink appends, and a dump that was decompressed by hand reads as
+// plain JSONL.
+func ReadCorpus(r io.Reader, fn func(*storage.RawTurnRecord) error) error {
+	br := bufio.NewReader(r)
+	var rows io.Reader = br
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("gunzip corpus: %w", err)
+		}
+		rows = gz
 	}
 
-	scanner := bufio.NewScanner(gz)
+	scanner := bufio.NewScanner(rows)
 	scanner.Buffer(make([]byte, 0, 1<<20), 64<<20)
 	for scanner.Scan() {
 		line := scanner.Bytes()
@@ -132,7 +168,7 @@ func LoadCorpus(r io.Reader) (wire, transcripts []storage.RawTurnRecord, err err
 		}
 		var row corpusRow
 		if err := json.Unmarshal(line, &row); err != nil {
-			return nil, nil, fmt.Errorf("decode corpus row: %w", err)
+			return fmt.Errorf("decode corpus row: %w", err)
 		}
 		rec := storage.RawTurnRecord{
 			ID: row.ID, OrgID: row.OrgID, Source: row.Source,
@@ -141,15 +177,15 @@ func LoadCorpus(r io.Reader) (wire, transcripts []storage.RawTurnRecord, err err
 			RequestID: row.RequestID, RawRequest: row.RawRequest,
 			Response: row.Response, Meta: row.Meta,
 			SessionEnvelope: row.SessionEnvelope, ReceivedAt: row.ReceivedAt,
+			RawResponse: row.RawResponse, RawResponseEncoding: row.RawResponseEncoding,
+			RawResponseDropped: row.RawResponseDropped,
 		}
-		if rec.Source == storage.RawTurnSourceTranscript {
-			transcripts = append(transcripts, rec)
-		} else {
-			wire = append(wire, rec)
+		if err := fn(&rec); err != nil {
+			return err
 		}
 	}
 	if err := scanner.Err(); err != nil {
-		return nil, nil, fmt.Errorf("scan corpus: %w", err)
+		return fmt.Errorf("scan corpus: %w", err)
 	}
-	return wire, transcripts, nil
+	return nil
 }
@@ -75,6 +75,9 @@ type SpanSource struct {
 	// Source is the capture source of the raw turn this call came from
 	// ('wire' | 'transcript') — provenance carried onto the trace.
 	Source string
+	// Latency is the capture adapter's latency profile for the call (raw
+	// meta.latency), nil when the adapter measured none.
+	Latency *llm.Latency
 
 	// Chain holds the retained node for every chain position of this
 	// call (root → leaf; last is the response). New marks positions
@@ -130,12 +133,13 @@ type RederiveReport struct {
 // rawMetaFields is the minimal meta decode the deriver needs: original
 // capture time for chronology (captured_at is the completion instant
 // outright; backfilled rows carry ts_request; live rows fall back to
-// received_at).
+// received_at), the sub-thread, and the latency profile.
 type rawMetaFields struct {
-	CapturedAt     string  `json:"captured_at"`
-	TsRequest      string  `json:"ts_request"`
-	ElapsedSeconds float64 `json:"elapsed_seconds"`
-	ThreadID       string  `json:"thread_id"`
+	CapturedAt     string       `json:"captured_at"`
+	TsRequest      string       `json:"ts_request"`
+	ElapsedSeconds float64      `json:"elapsed_seconds"`
+	ThreadID       string       `json:"thread_id"`
+	Latency        *llm.Latency `json:"latency"`
 }
 
 // maxDeriveElapsedSeconds mirrors ingest's bound on a plausible
@@ -153,6 +157,16 @@ func threadIDFromMeta(meta json.RawMessage) string {
 	return m.ThreadID
 }
 
+// latencyFromMeta resolves the capture-side latency profile from a raw
+// row's meta block.
+func latencyFromMeta(meta json.RawMessage) *llm.Latency {
+	var m rawMetaFields
+	if len(meta) == 0 || json.Unmarshal(meta, &m) != nil {
+		return nil
+	}
+	return m.Latency
+}
+
 // CapturedAt resolves a raw record's original capture-side START
 // instant, for span chronology: captured_at (a completion instant)
 // rewound by the call's elapsed duration when the meta block carries
@@ -314,6 +328,7 @@ func (dv *Deriver) AddTurn(rec *storage.RawTurnRecord) {
 		ThreadID:   turn.threadID,
 		Session:    key,
 		Source:     rec.Source,
+		Latency:    latencyFromMeta(rec.Meta),
 	}
 	turn.source = source
 
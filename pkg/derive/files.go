@@ -0,0 +1,266 @@
+package derive
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// File operations a tool call can perform on a path.
+const (
+	FileOpRead   = "read"
+	FileOpCreate = "create"
+	FileOpEdit   = "edit"
+	FileOpDelete = "delete"
+)
+
+// FileTouch is one file's activity within a trace, folded from the
+// trace's tool spans at derive time: how often the agent read, created,
+// edited and deleted it, and the lines its edits added and removed.
+// Line counts come from the edit payloads — old/new strings, patch hunks,
+// written content — so a Write over an existing file counts its content
+// as added and nothing as removed.
+type FileTouch struct {
+	Path         string
+	Reads        int
+	Creates      int
+	Edits        int
+	Deletes      int
+	LinesAdded   int
+	LinesRemoved int
+	FirstAt      time.Time
+	LastAt       time.Time
+}
+
+// fileOp is one operation a single tool call performed on one path.
+type fileOp struct {
+	path    string
+	op      string
+	added   int
+	removed int
+}
+
+// patchBegin opens an apply_patch envelope, whether it arrives as the
+// apply_patch tool's input or inline in a shell command.
+const patchBegin = "*** Begin Patch"
+
+// readCommands are the shell programs whose file arguments are reads.
+var readCommands = map[string]bool{
+	"cat": true, "head": true, "tail": true, "less": true, "more": true,
+	"nl": true, "bat": true, "wc": true,
+}
+
+// FoldFileTouches folds a trace's tool spans into per-file activity,
+// sorted by path. Errored calls are skipped: a failed edit changed
+// nothing, and a failed read showed the agent nothing.
+func FoldFileTouches(spans []*Span) []FileTouch {
+	byPath := map[string]*FileTouch{}
+	for _, s := range spans {
+		if s.Kind != SpanKindTool || s.Status == "error" || len(s.Input) == 0 {
+			continue
+		}
+		var result string
+		if len(s.Output) > 0 {
+			result = s.Output[0].ToolOutput
+		}
+		for _, op := range toolFileOps(s.Name, s.Input[0].ToolInput, result) {
+			t := byPath[op.path]
+			if t == nil {
+				t = &FileTouch{Path: op.path, FirstAt: s.StartedAt}
+				byPath[op.path] = t
+			}
+			switch op.op {
+			case FileOpRead:
+				t.Reads++
+			case FileOpCreate:
+				t.Creates++
+			case FileOpEdit:
+				t.Edits++
+			case FileOpDelete:
+				t.Deletes++
+			}
+			t.LinesAdded += op.added
+			t.LinesRemoved += op.removed
+			if s.StartedAt.Before(t.FirstAt) {
+				t.FirstAt = s.StartedAt
+			}
+			if s.StartedAt.After(t.LastAt) {
+				t.LastAt = s.StartedAt
+			}
+		}
+	}
+	out := make([]FileTouch, 0, len(byPath))
+	for _, t := range byPath {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+// toolFileOps extracts the file operations of one tool call from its
+// (display) name, input and result text.
+func toolFileOps(name string, input map[string]any, result string) []fileOp {
+	str := func(key string) string {
+		v, _ := input[key].(string)
+		return v
+	}
+	switch name {
+	case "Read", "NotebookRead":
+		if p := str("file_path"); p != "" {
+			return []fileOp{{path: p, op: FileOpRead}}
+		}
+	case "Write":
+		p := str("file_path")
+		if p == "" {
+			return nil
+		}
+		op := FileOpEdit
+		if strings.Contains(result, "created successfully") {
+			op = FileOpCreate
+		}
+		return []fileOp{{path: p, op: op, added: countLines(str("content"))}}
+	case "Edit":
+		if p := str("file_path"); p != "" {
+			added, removed := lineDelta(str("old_string"), str("new_string"))
+			return []fileOp{{path: p, op: FileOpEdit, added: added, removed: removed}}
+		}
+	case "MultiEdit":
+		p := str("file_path")
+		edits, _ := input["edits"].([]any)
+		if p == "" || len(edits) == 0 {
+			return nil
+		}
+		op := fileOp{path: p, op: FileOpEdit}
+		for _, e := range edits {
+			m, _ := e.(map[string]any)
+			oldText, _ := m["old_string"].(string)
+			newText, _ := m["new_string"].(string)
+			added, removed := lineDelta(oldText, newText)
+			op.added += added
+			op.removed += removed
+		}
+		return []fileOp{op}
+	case "apply_patch":
+		for _, key := range []string{"input", "patch"} {
+			if patch := str(key); patch != "" {
+				return patchFileOps(patch)
+			}
+		}
+	case "Bash":
+		return shellFileOps(input)
+	}
+	return nil
+}
+
+// patchFileOps reads the file sections of an apply_patch envelope: Add,
+// Update (optionally Move to) and Delete, counting hunk lines.
+func patchFileOps(patch string) []fileOp {
+	var ops []fileOp
+	cur := -1 // index of the section hunk lines count towards
+	for _, line := range strings.Split(patch, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case strings.HasPrefix(line, "*** Add File: "):
+			ops = append(ops, fileOp{path: strings.TrimSpace(strings.TrimPrefix(line, "*** Add File: ")), op: FileOpCreate})
+			cur = len(ops) - 1
+		case strings.HasPrefix(line, "*** Update File: "):
+			ops = append(ops, fileOp{path: strings.TrimSpace(strings.TrimPrefix(line, "*** Update File: ")), op: FileOpEdit})
+			cur = len(ops) - 1
+		case strings.HasPrefix(line, "*** Delete File: "):
+			ops = append(ops, fileOp{path: strings.TrimSpace(strings.TrimPrefix(line, "*** Delete File: ")), op: FileOpDelete})
+			cur = -1
+		case strings.HasPrefix(line, "*** Move to: ") && cur >= 0:
+			ops[cur].path = strings.TrimSpace(strings.TrimPrefix(line, "*** Move to: "))
+		case strings.HasPrefix(line, "***"):
+			// Begin/End Patch, End of File
+		case cur < 0:
+		case strings.HasPrefix(line, "+"):
+			ops[cur].added++
+		case strings.HasPrefix(line, "-"):
+			ops[cur].removed++
+		}
+	}
+	return ops
+}
+
+// shellFileOps reads file operations out of a shell command: an inline
+// apply_patch envelope, the file arguments of reading programs (cat,
+// head, sed -n …) and rm's arguments. Only plain arguments count —
+// anything carrying globs, variables or redirections is skipped rather
+// than guessed at. Relative paths resolve against the call's workdir
+// when it names one.
+func shellFileOps(input map[string]any) []fileOp {
+	line := shellCommandLine(input)
+	if i := strings.Index(line, patchBegin); i >= 0 {
+		return patchFileOps(line[i:])
+	}
+	workdir, _ := input["workdir"].(string)
+	resolve := func(p string) string {
+		if workdir != "" && !path.IsAbs(p) {
+			return path.Join(workdir, p)
+		}
+		return p
+	}
+
+	var ops []fileOp
+	segments := strings.FieldsFunc(line, func(r rune) bool { return r == '&' || r == '|' || r == ';' || r == '\n' })
+	for _, seg := range segments {
+		fields := strings.Fields(seg)
+		for len(fields) > 0 && strings.Contains(fields[0], "=") {
+			fields = fields[1:]
+		}
+		if len(fields) < 2 {
+			continue
+		}
+		program, args := path.Base(fields[0]), fields[1:]
+		var op string
+		switch {
+		case readCommands[program]:
+			op = FileOpRead
+		case program == "sed" && args[0] == "-n" && len(args) >= 3:
+			op, args = FileOpRead, args[2:]
+		case program == "rm":
+			op = FileOpDelete
+		default:
+			continue
+		}
+		for _, arg := range args {
+			if strings.HasPrefix(arg, "-") || strings.ContainsAny(arg, "$*?<>`'\"(){}") || isCount(arg) {
+				continue
+			}
+			ops = append(ops, fileOp{path: resolve(arg), op: op})
+		}
+	}
+	return ops
+}
+
+// lineDelta counts the lines an edit from oldText to newText removes and
+// adds, ignoring the lines the two share at either end.
+func lineDelta(oldText, newText string) (added, removed int) {
+	oldLines, newLines := splitLines(oldText), splitLines(newText)
+	for len(oldLines) > 0 && len(newLines) > 0 && oldLines[0] == newLines[0] {
+		oldLines, newLines = oldLines[1:], newLines[1:]
+	}
+	for len(oldLines) > 0 && len(newLines) > 0 && oldLines[len(oldLines)-1] == newLines[len(newLines)-1] {
+		oldLines, newLines = oldLines[:len(oldLines)-1], newLines[:len(newLines)-1]
+	}
+	return len(newLines), len(oldLines)
+}
+
+// isCount reports whether a shell argument is a bare number, such as the
+// line count of head -n 20, rather than a path.
+func isCount(arg string) bool {
+	return strings.Trim(arg, "0123456789") == ""
+}
+
+func countLines(text string) int {
+	return len(splitLines(text))
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
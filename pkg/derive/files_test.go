@@ -0,0 +1,112 @@
+package derive_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/derive"
+	"github.com/papercomputeco/tapes/pkg/llm"
+)
+
+// fileSpan builds a finished tool span named as the emitter would name
+// it, with its input and the result text it came back with.
+func fileSpan(id, name string, at time.Time, input map[string]any, result string) *derive.Span {
+	return &derive.Span{
+		SpanID:    id,
+		Kind:      derive.SpanKindTool,
+		Name:      name,
+		Status:    "ok",
+		StartedAt: at,
+		Input:     []llm.ContentBlock{{Type: "tool_use", ToolUseID: id, ToolName: name, ToolInput: input}},
+		Output:    []llm.ContentBlock{{Type: "tool_result", ToolResultID: id, ToolOutput: result}},
+	}
+}
+
+var _ = Describe("FoldFileTouches", func() {
+	base := time.Unix(1781218500, 0)
+	at := func(s int) time.Time { return base.Add(time.Duration(s) * time.Second) }
+
+	It("folds Claude's file tools per path with edit line counts", func() {
+		files := derive.FoldFileTouches([]*derive.Span{
+			fileSpan("t1", "Read", at(0), map[string]any{"file_path": "/repo/main.go"}, "package main"),
+			fileSpan("t2", "Edit", at(1), map[string]any{
+				"file_path":  "/repo/main.go",
+				"old_string": "func main() {\n\trun()\n}",
+				"new_string": "func main() {\n\tif err := run(); err != nil {\n\t\tos.Exit(1)\n\t}\n}",
+			}, "The file has been updated."),
+			fileSpan("t3", "MultiEdit", at(2), map[string]any{
+				"file_path": "/repo/main.go",
+				"edits": []any{
+					map[string]any{"old_string": "a", "new_string": "b"},
+					map[string]any{"old_string": "c\nd", "new_string": ""},
+				},
+			}, "Applied 2 edits."),
+			fileSpan("t4", "Write", at(3), map[string]any{"file_path": "/repo/README.md", "content": "# Repo\n\nHello.\n"},
+				"File created successfully at: /repo/README.md"),
+		})
+
+		Expect(files).To(HaveLen(2))
+		readme, main := files[0], files[1]
+		Expect(readme).To(Equal(derive.FileTouch{
+			Path: "/repo/README.md", Creates: 1, LinesAdded: 3, FirstAt: at(3), LastAt: at(3),
+		}))
+		Expect(main.Path).To(Equal("/repo/main.go"))
+		Expect(main.Reads).To(Equal(1))
+		Expect(main.Edits).To(Equal(2))
+		// Edit: one line replaced by three; MultiEdit: a→b, then two lines removed
+		Expect(main.LinesAdded).To(Equal(3 + 1))
+		Expect(main.LinesRemoved).To(Equal(1 + 1 + 2))
+		Expect(main.FirstAt).To(Equal(at(0)))
+		Expect(main.LastAt).To(Equal(at(2)))
+	})
+
+	It("reads apply_patch envelopes, inline or as the tool's input", func() {
+		patch := "*** Begin Patch\n" +
+			"*** Update File: pkg/api/server.go\n@@\n-old line\n+new line\n+another\n" +
+			"*** Add File: pkg/api/routes.go\n+package api\n+\n" +
+			"*** Delete File: pkg/api/legacy.go\n" +
+			"*** Update File: pkg/a.go\n*** Move to: pkg/b.go\n@@\n-x\n+y\n" +
+			"*** End Patch"
+		files := derive.FoldFileTouches([]*derive.Span{
+			fileSpan("t1", "apply_patch", at(0), map[string]any{"input": patch}, "Success."),
+		})
+		Expect(files).To(ConsistOf(
+			derive.FileTouch{Path: "pkg/api/server.go", Edits: 1, LinesAdded: 2, LinesRemoved: 1, FirstAt: at(0), LastAt: at(0)},
+			derive.FileTouch{Path: "pkg/api/routes.go", Creates: 1, LinesAdded: 2, FirstAt: at(0), LastAt: at(0)},
+			derive.FileTouch{Path: "pkg/api/legacy.go", Deletes: 1, FirstAt: at(0), LastAt: at(0)},
+			derive.FileTouch{Path: "pkg/b.go", Edits: 1, LinesAdded: 1, LinesRemoved: 1, FirstAt: at(0), LastAt: at(0)},
+		))
+
+		inline := derive.FoldFileTouches([]*derive.Span{
+			fileSpan("t2", "Bash", at(0), map[string]any{"command": []any{"apply_patch", patch}}, "Success."),
+		})
+		Expect(inline).To(HaveLen(4))
+	})
+
+	It("reads plain file arguments of shell reads and removals", func() {
+		files := derive.FoldFileTouches([]*derive.Span{
+			fileSpan("t1", "Bash", at(0), map[string]any{
+				"command": []any{"bash", "-lc", "sed -n '1,80p' api/server.go && head -n 20 go.mod | cat"},
+				"workdir": "/repo",
+			}, ""),
+			fileSpan("t2", "Bash", at(1), map[string]any{"command": "rm -f build/out.bin && cat $HOME/.netrc"}, ""),
+		})
+		paths := map[string]derive.FileTouch{}
+		for _, f := range files {
+			paths[f.Path] = f
+		}
+		Expect(paths).To(HaveLen(3))
+		Expect(paths["/repo/api/server.go"].Reads).To(Equal(1))
+		Expect(paths["/repo/go.mod"].Reads).To(Equal(1))
+		Expect(paths["build/out.bin"].Deletes).To(Equal(1))
+	})
+
+	It("skips errored calls", func() {
+		failed := fileSpan("t1", "Edit", at(0), map[string]any{"file_path": "/repo/x.go", "old_string": "a", "new_string": "b"},
+			"String to replace not found in file.")
+		failed.Status = "error"
+		Expect(derive.FoldFileTouches([]*derive.Span{failed})).To(BeEmpty())
+	})
+})
@@ -0,0 +1,87 @@
+package derive
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Git artifact kinds.
+const (
+	ArtifactCommit      = "commit"
+	ArtifactPush        = "push"
+	ArtifactPullRequest = "pull_request"
+)
+
+// GitArtifact is something a trace's shell calls produced in git or on
+// the forge: a commit, a push of a branch, or an opened pull request.
+// SHA is as git printed it, abbreviated for a commit, so lookups match by
+// prefix. URL is the pushed-to remote for a push and the pull request's
+// page for a pull request.
+type GitArtifact struct {
+	Kind    string
+	SHA     string
+	Branch  string
+	URL     string
+	Subject string
+	SpanID  string
+	At      time.Time
+}
+
+var (
+	// gitCommitLine is git commit's summary line:
+	// "[main 1a2b3c4] Fix the thing", "[main (root-commit) 1a2b3c4] Init".
+	gitCommitLine = regexp.MustCompile(`(?m)^\[(.+?)(?: \(root-commit\))? ([0-9a-f]{7,40})\] (.*)$`)
+	// gitPushLine is one updated ref in git push's report:
+	// "   1a2b3c4..5d6e7f8  main -> main", forced updates with "+" and "...".
+	gitPushLine = regexp.MustCompile(`(?m)^\s*\+?\s*[0-9a-f]{7,40}\.\.\.?([0-9a-f]{7,40})\s+\S+ -> (\S+)`)
+	// gitPushNewBranch is a newly created remote branch in git push's
+	// report, which names no commit.
+	gitPushNewBranch = regexp.MustCompile(`(?m)^\s*\* \[new branch\]\s+\S+ -> (\S+)`)
+	gitPushRemote    = regexp.MustCompile(`(?m)^To (\S+)`)
+	// pullRequestURL matches a pull request page, not the "create a pull
+	// request" hint (/pull/new/<branch>) git push relays from GitHub.
+	pullRequestURL = regexp.MustCompile(`https?://\S+/pull/\d+`)
+)
+
+// FoldGitArtifacts reads the commits, pushes and pull requests out of a
+// trace's successful shell tool calls: git commit, git push and gh pr
+// create, read from the command and its output. A call that chains them
+// ("git commit … && git push") yields each.
+func FoldGitArtifacts(spans []*Span) []GitArtifact {
+	var out []GitArtifact
+	for _, s := range spans {
+		if s.Kind != SpanKindTool || s.Name != "Bash" || s.Status == "error" || len(s.Input) == 0 || len(s.Output) == 0 {
+			continue
+		}
+		command := strings.ToLower(shellCommandLine(s.Input[0].ToolInput))
+		result := s.Output[0].ToolOutput
+		at := func(a GitArtifact) GitArtifact {
+			a.SpanID, a.At = s.SpanID, s.StartedAt
+			return a
+		}
+		if strings.Contains(command, "git commit") {
+			for _, m := range gitCommitLine.FindAllStringSubmatch(result, -1) {
+				out = append(out, at(GitArtifact{Kind: ArtifactCommit, Branch: m[1], SHA: m[2], Subject: m[3]}))
+			}
+		}
+		if strings.Contains(command, "git push") {
+			var remote string
+			if m := gitPushRemote.FindStringSubmatch(result); m != nil {
+				remote = m[1]
+			}
+			for _, m := range gitPushLine.FindAllStringSubmatch(result, -1) {
+				out = append(out, at(GitArtifact{Kind: ArtifactPush, SHA: m[1], Branch: m[2], URL: remote}))
+			}
+			for _, m := range gitPushNewBranch.FindAllStringSubmatch(result, -1) {
+				out = append(out, at(GitArtifact{Kind: ArtifactPush, Branch: m[1], URL: remote}))
+			}
+		}
+		if strings.Contains(command, "gh pr create") {
+			if url := pullRequestURL.FindString(result); url != "" {
+				out = append(out, at(GitArtifact{Kind: ArtifactPullRequest, URL: url}))
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,49 @@
+package derive_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/derive"
+)
+
+var _ = Describe("FoldGitArtifacts", func() {
+	at := time.Unix(1781218500, 0)
+	bash := func(id, command, output string) *derive.Span {
+		return fileSpan(id, "Bash", at, map[string]any{"command": command}, output)
+	}
+
+	It("reads commits, pushes and pull requests from shell calls and their output", func() {
+		artifacts := derive.FoldGitArtifacts([]*derive.Span{
+			bash("t1", `git add -A && git commit -m "Fix the flaky retry test"`,
+				"[fix/retry 1a2b3c4] Fix the flaky retry test\n 2 files changed, 10 insertions(+), 3 deletions(-)"),
+			bash("t2", "git push -u origin fix/retry",
+				"To github.com:acme/widgets.git\n * [new branch]      fix/retry -> fix/retry\n"+
+					"remote: Create a pull request for 'fix/retry' on GitHub by visiting:\n"+
+					"remote:      https://github.com/acme/widgets/pull/new/fix/retry"),
+			bash("t3", "git push", "To github.com:acme/widgets.git\n   1a2b3c4..5d6e7f8  fix/retry -> fix/retry"),
+			bash("t4", `gh pr create --title "Fix retry" --body "..."`, "https://github.com/acme/widgets/pull/42\n"),
+		})
+		Expect(artifacts).To(Equal([]derive.GitArtifact{
+			{Kind: derive.ArtifactCommit, SHA: "1a2b3c4", Branch: "fix/retry", Subject: "Fix the flaky retry test", SpanID: "t1", At: at},
+			{Kind: derive.ArtifactPush, Branch: "fix/retry", URL: "github.com:acme/widgets.git", SpanID: "t2", At: at},
+			{Kind: derive.ArtifactPush, SHA: "5d6e7f8", Branch: "fix/retry", URL: "github.com:acme/widgets.git", SpanID: "t3", At: at},
+			{Kind: derive.ArtifactPullRequest, URL: "https://github.com/acme/widgets/pull/42", SpanID: "t4", At: at},
+		}))
+	})
+
+	It("reads a root commit and ignores failed or unrelated calls", func() {
+		failed := bash("t2", "git commit -m wip", "[main abcdef0] wip")
+		failed.Status = "error"
+		artifacts := derive.FoldGitArtifacts([]*derive.Span{
+			bash("t1", "git commit -m init", "[main (root-commit) 0f1e2d3] init"),
+			failed,
+			bash("t3", "git log -1", "[main 9999999] looks like a commit line"),
+		})
+		Expect(artifacts).To(HaveLen(1))
+		Expect(artifacts[0].SHA).To(Equal("0f1e2d3"))
+		Expect(artifacts[0].Branch).To(Equal("main"))
+	})
+})
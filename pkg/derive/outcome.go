@@ -0,0 +1,469 @@
+package derive
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/papercomputeco/tapes/pkg/llm"
+	"github.com/papercomputeco/tapes/pkg/sessions"
+)
+
+// Session outcomes. DerivedStatus says whether a session went well;
+// the outcome says how it ended.
+const (
+	OutcomeCompleted    = "completed"
+	OutcomeInterrupted  = "interrupted"
+	OutcomeErrored      = "errored"
+	OutcomeRefused      = "refused"
+	OutcomeLooped       = "looped"
+	OutcomeTurnLimit    = "turn_limit"
+	OutcomeGaveUp       = "gave_up"
+	OutcomeAwaitingUser = "awaiting_user"
+	OutcomeAbandoned    = "abandoned"
+	OutcomeFailed       = "failed"
+	OutcomeUnknown      = "unknown"
+)
+
+// AbandonAfter is how long a session left waiting on its user goes
+// without a turn before it reads as abandoned instead of awaiting_user.
+const AbandonAfter = 30 * time.Minute
+
+// Outcome reason codes.
+const (
+	ReasonLoop          = "loop"
+	ReasonInterrupted   = "interrupted"
+	ReasonTerminalError = "terminal_error"
+	ReasonRefusal       = "refusal"
+	ReasonTurnLimit     = "turn_limit"
+	ReasonGaveUp        = "gave_up"
+	ReasonAwaitingUser  = "awaiting_user"
+	ReasonUnanswered    = "unanswered"
+)
+
+// Terminal error categories, carried on a terminal_error reason.
+const (
+	ErrorRateLimit     = "rate_limit"
+	ErrorOverloaded    = "overloaded"
+	ErrorContextLength = "context_length"
+	ErrorAuth          = "auth"
+	ErrorTimeout       = "timeout"
+	ErrorMaxTokens     = "max_tokens"
+	ErrorProvider      = "provider_error"
+	ErrorTool          = "tool_error"
+	ErrorToolRate      = "tool_error_rate"
+)
+
+// ValidOutcome reports whether name is one of the outcome values.
+func ValidOutcome(name string) bool {
+	switch name {
+	case OutcomeCompleted, OutcomeInterrupted, OutcomeErrored, OutcomeRefused, OutcomeLooped,
+		OutcomeTurnLimit, OutcomeGaveUp, OutcomeAwaitingUser, OutcomeAbandoned, OutcomeFailed, OutcomeUnknown:
+		return true
+	}
+	return false
+}
+
+// ValidOutcomeReason reports whether name is a reason code or a terminal
+// error category, the two things a reason filter can name.
+func ValidOutcomeReason(name string) bool {
+	switch name {
+	case ReasonLoop, ReasonInterrupted, ReasonTerminalError, ReasonRefusal, ReasonTurnLimit,
+		ReasonGaveUp, ReasonAwaitingUser, ReasonUnanswered,
+		ErrorRateLimit, ErrorOverloaded, ErrorContextLength, ErrorAuth, ErrorTimeout,
+		ErrorMaxTokens, ErrorProvider, ErrorTool, ErrorToolRate:
+		return true
+	}
+	return false
+}
+
+// loopMinRepeats is how many identical tool calls in a row make a loop.
+const loopMinRepeats = 3
+
+// OutcomeReason is one piece of evidence for a session's outcome: what
+// was seen (Code), where (TraceID/SpanID), and for terminal errors which
+// kind (Category). Count is the length of a loop.
+type OutcomeReason struct {
+	Code     string `json:"code"`
+	Category string `json:"category,omitempty"`
+	TraceID  string `json:"trace_id,omitempty"`
+	SpanID   string `json:"span_id,omitempty"`
+	Count    int    `json:"count,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// SessionOutcome is how a session ended plus every reason the fold
+// found, in the order it found them. A reason does not have to decide
+// the outcome: a loop the agent broke out of is still recorded.
+type SessionOutcome struct {
+	Outcome string
+	Reasons []OutcomeReason
+}
+
+var (
+	// interruptMarkers are the texts harnesses put into the conversation
+	// when the user stops the agent mid-turn or rejects a tool call.
+	interruptMarkers = []string{
+		"[request interrupted by user",
+		"<turn_aborted>",
+		"the user doesn't want to proceed with this tool use",
+	}
+	// refusalPhrases open a model's refusal to do what was asked.
+	refusalPhrases = []string{
+		"i can't help with", "i cannot help with",
+		"i can't assist with", "i cannot assist with",
+		"i'm not able to help with", "i am not able to help with",
+		"i won't be able to help", "i'm unable to help with",
+	}
+	// gaveUpPhrases close an agent's admission that it could not finish.
+	gaveUpPhrases = []string{
+		"i was unable to", "i wasn't able to", "i was not able to",
+		"i couldn't get", "i could not get", "i'm unable to", "i am unable to",
+		"unable to complete", "i give up", "i'm giving up",
+	}
+	// errorCategories match terminal error text to a category, first
+	// match wins. needles match anywhere. providerWords, status codes and
+	// the like, are read only from a provider's own error and only as
+	// whole words: tool output can print any number, and "timeout" is as
+	// likely a flag as a failure.
+	errorCategories = []struct {
+		category      string
+		needles       []string
+		providerWords []string
+	}{
+		{ErrorRateLimit, []string{"rate limit", "rate_limit", "too many requests"}, []string{"429"}},
+		{ErrorOverloaded, []string{"overloaded", "service unavailable"}, []string{"529", "503"}},
+		{ErrorContextLength, []string{"prompt is too long", "context length", "context_length", "maximum context"}, nil},
+		{ErrorAuth, []string{"authentication", "invalid api key", "invalid x-api-key", "unauthorized"}, []string{"401"}},
+		{ErrorTimeout, []string{"timed out", "deadline exceeded"}, []string{"timeout"}},
+	}
+)
+
+// FoldSessionOutcome classifies how a session ended from its traces in
+// capture order, its terminal main-spine response (terminalMainSpan) and
+// its folded status. It records:
+//
+//   - loop: loopMinRepeats or more identical tool calls (same tool, same
+//     input) in a row on one thread;
+//   - interrupted: the user stopped a turn or rejected a tool call;
+//   - terminal_error: the session ended on an error it never recovered
+//     from, categorized (rate_limit, overloaded, context_length, auth,
+//     timeout, max_tokens, provider_error, tool_error), or with tool
+//     errors dominating (tool_error_rate);
+//   - refusal: the last answer was a refusal, by stop_reason or wording;
+//   - turn_limit: the conversation ended on tool calls the harness never
+//     ran, which is how a harness turn cap ends a session;
+//   - gave_up: the last answer admits defeat after tool errors;
+//   - awaiting_user / unanswered: the session ended waiting on the user,
+//     on a question or on a prompt nothing answered.
+//
+// The outcome is the first of interrupted, errored, refused, looped,
+// turn_limit and gave_up whose reason sits at the session's end; then
+// completed for a completed status; then, for a session left waiting,
+// awaiting_user until AbandonAfter has passed since its last turn at now
+// and abandoned after; then failed or unknown from the status. Like the
+// status it reads only the projection, so a re-derive recomputes it;
+// OutcomeAt ages a stored awaiting_user between derives.
+func FoldSessionOutcome(traces []*SpanTurn, terminal *Span, status SessionStatus, now time.Time) SessionOutcome {
+	out := SessionOutcome{Reasons: []OutcomeReason{}}
+	if len(traces) == 0 {
+		out.Outcome = OutcomeUnknown
+		return out
+	}
+	last := traces[len(traces)-1]
+	tools := map[string]*Span{}
+
+	interruptedAtEnd := false
+	// runs tracks the current streak of identical tool calls per thread.
+	type run struct {
+		key     string
+		first   *Span
+		traceID string
+		count   int
+	}
+	runs := map[string]*run{}
+	closeRun := func(r *run) {
+		if r == nil || r.count < loopMinRepeats {
+			return
+		}
+		out.Reasons = append(out.Reasons, OutcomeReason{
+			Code: ReasonLoop, TraceID: r.traceID, SpanID: r.first.SpanID,
+			Count: r.count, Detail: r.first.Name,
+		})
+	}
+
+	for _, turn := range traces {
+		for _, s := range turn.Spans {
+			switch s.Kind {
+			case SpanKindLLM:
+				if s.ThreadID != "" || s.CallKind != KindMain {
+					continue
+				}
+				if hasInterruptMarker(s.Input) {
+					out.Reasons = append(out.Reasons, OutcomeReason{
+						Code: ReasonInterrupted, TraceID: turn.TraceID, SpanID: s.SpanID,
+					})
+					interruptedAtEnd = turn == last
+				}
+			case SpanKindTool:
+				tools[s.SpanID] = s
+				if s.ThreadID == "" && len(s.Output) > 0 && hasInterruptMarker(s.Output) {
+					out.Reasons = append(out.Reasons, OutcomeReason{
+						Code: ReasonInterrupted, TraceID: turn.TraceID, SpanID: s.SpanID, Detail: s.Name,
+					})
+					interruptedAtEnd = turn == last
+				}
+				key := toolCallKey(s)
+				if key == "" {
+					continue
+				}
+				r := runs[s.ThreadID]
+				if r != nil && r.key == key {
+					r.count++
+					continue
+				}
+				closeRun(r)
+				runs[s.ThreadID] = &run{key: key, first: s, traceID: turn.TraceID, count: 1}
+			}
+		}
+	}
+	// A loop still running on the main thread when the session ends is
+	// the loop the session ended in.
+	loopAtEnd := runs[""] != nil && runs[""].count >= loopMinRepeats
+	threads := make([]string, 0, len(runs))
+	for thread := range runs {
+		threads = append(threads, thread)
+	}
+	sort.Strings(threads)
+	for _, thread := range threads {
+		closeRun(runs[thread])
+	}
+
+	var terminalError, refusal, turnLimit, gaveUp, waiting bool
+	if terminal == nil {
+		out.Reasons = append(out.Reasons, OutcomeReason{Code: ReasonUnanswered, TraceID: last.TraceID})
+		waiting = true
+	} else {
+		text := spanText(terminal.Output)
+		reason := strings.ToLower(strings.TrimSpace(terminal.StopReason))
+		// tool calls the terminal response asked for: errored ones are
+		// unrecovered, ones without a result were never run.
+		var failed *Span
+		pending := 0
+		for _, b := range terminal.Output {
+			if b.Type != blockToolUse {
+				continue
+			}
+			ts := tools[b.ToolUseID]
+			switch {
+			case ts == nil:
+			case len(ts.Output) == 0:
+				pending++
+			case ts.Status == "error" && failed == nil:
+				failed = ts
+			}
+		}
+		switch {
+		case reason == "refusal" || reason == "content_filter":
+			refusal = true
+			out.Reasons = append(out.Reasons, OutcomeReason{
+				Code: ReasonRefusal, TraceID: last.TraceID, SpanID: terminal.SpanID, Detail: terminal.StopReason,
+			})
+		case reason == "max_tokens" || reason == "length":
+			terminalError = true
+			out.Reasons = append(out.Reasons, OutcomeReason{
+				Code: ReasonTerminalError, Category: ErrorMaxTokens, TraceID: last.TraceID, SpanID: terminal.SpanID,
+			})
+		case strings.Contains(reason, "error"):
+			terminalError = true
+			out.Reasons = append(out.Reasons, OutcomeReason{
+				Code: ReasonTerminalError, Category: errorCategory(reason+" "+text, ErrorProvider),
+				TraceID: last.TraceID, SpanID: terminal.SpanID, Detail: terminal.StopReason,
+			})
+		case failed != nil:
+			terminalError = true
+			out.Reasons = append(out.Reasons, OutcomeReason{
+				Code: ReasonTerminalError, Category: errorCategory(spanToolOutput(failed), ErrorTool),
+				TraceID: last.TraceID, SpanID: failed.SpanID, Detail: failed.Name,
+			})
+		case pending > 0:
+			turnLimit = true
+			out.Reasons = append(out.Reasons, OutcomeReason{
+				Code: ReasonTurnLimit, TraceID: last.TraceID, SpanID: terminal.SpanID, Count: pending,
+			})
+		case isRefusal(text):
+			refusal = true
+			out.Reasons = append(out.Reasons, OutcomeReason{
+				Code: ReasonRefusal, TraceID: last.TraceID, SpanID: terminal.SpanID,
+			})
+		case status.ToolErrorCount > 0 && containsAny(normalizeQuotes(text), gaveUpPhrases):
+			gaveUp = true
+			out.Reasons = append(out.Reasons, OutcomeReason{
+				Code: ReasonGaveUp, TraceID: last.TraceID, SpanID: terminal.SpanID,
+			})
+		case strings.HasSuffix(strings.TrimSpace(text), "?"):
+			waiting = true
+			out.Reasons = append(out.Reasons, OutcomeReason{
+				Code: ReasonAwaitingUser, TraceID: last.TraceID, SpanID: terminal.SpanID,
+			})
+		}
+	}
+	if !terminalError && status.DerivedStatus == sessions.StatusFailed && !status.HasGitActivity {
+		out.Reasons = append(out.Reasons, OutcomeReason{
+			Code: ReasonTerminalError, Category: ErrorToolRate, Count: status.ToolErrorCount,
+		})
+		terminalError = true
+	}
+
+	switch {
+	case interruptedAtEnd:
+		out.Outcome = OutcomeInterrupted
+	case terminalError:
+		out.Outcome = OutcomeErrored
+	case refusal:
+		out.Outcome = OutcomeRefused
+	case loopAtEnd:
+		out.Outcome = OutcomeLooped
+	case turnLimit:
+		out.Outcome = OutcomeTurnLimit
+	case gaveUp:
+		out.Outcome = OutcomeGaveUp
+	case status.DerivedStatus == sessions.StatusCompleted && (!waiting || status.HasGitActivity):
+		out.Outcome = OutcomeCompleted
+	case waiting || status.DerivedStatus == sessions.StatusAbandoned:
+		out.Outcome = OutcomeAt(OutcomeAwaitingUser, turnEnd(last), now)
+	case status.DerivedStatus == sessions.StatusFailed:
+		out.Outcome = OutcomeFailed
+	default:
+		out.Outcome = OutcomeUnknown
+	}
+	return out
+}
+
+// OutcomeAt is outcome as of now for a session last active at lastSeen:
+// awaiting_user turns abandoned once AbandonAfter has passed. Every other
+// outcome is returned as is.
+func OutcomeAt(outcome string, lastSeen, now time.Time) string {
+	if outcome == OutcomeAwaitingUser && now.Sub(lastSeen) >= AbandonAfter {
+		return OutcomeAbandoned
+	}
+	return outcome
+}
+
+// turnEnd is when a trace last saw activity: its end, or its start for a
+// trace that recorded none.
+func turnEnd(t *SpanTurn) time.Time {
+	if t.EndedAt.IsZero() {
+		return t.StartedAt
+	}
+	return t.EndedAt
+}
+
+// toolCallKey is the identity two tool calls share when they are the
+// same call: tool, server and input. "" for a span that cannot be keyed.
+func toolCallKey(s *Span) string {
+	if s.Tool == nil || len(s.Input) == 0 {
+		return ""
+	}
+	input, err := json.Marshal(s.Input[0].ToolInput)
+	if err != nil {
+		return ""
+	}
+	return s.Tool.Name + "\x00" + s.Tool.Server + "\x00" + string(input)
+}
+
+func hasInterruptMarker(blocks []llm.ContentBlock) bool {
+	for _, b := range blocks {
+		if containsAny(strings.ToLower(b.Text+b.ToolOutput), interruptMarkers) {
+			return true
+		}
+	}
+	return false
+}
+
+func isRefusal(text string) bool {
+	text = normalizeQuotes(text)
+	if len(text) > 300 {
+		text = text[:300]
+	}
+	return containsAny(text, refusalPhrases)
+}
+
+// errorCategory names the kind of error text describes, fallback when
+// it matches none.
+// errorCategory categorizes error text, or returns fallback. Only a
+// provider's error text (fallback ErrorProvider) is matched against the
+// categories' providerWords.
+func errorCategory(text, fallback string) string {
+	text = strings.ToLower(text)
+	for _, c := range errorCategories {
+		if containsAny(text, c.needles) {
+			return c.category
+		}
+		if fallback == ErrorProvider && containsAnyWord(text, c.providerWords) {
+			return c.category
+		}
+	}
+	return fallback
+}
+
+func spanText(blocks []llm.ContentBlock) string {
+	var sb strings.Builder
+	for _, b := range blocks {
+		if b.Type == "text" {
+			sb.WriteString(b.Text)
+		}
+	}
+	return sb.String()
+}
+
+func spanToolOutput(s *Span) string {
+	if len(s.Output) == 0 {
+		return ""
+	}
+	return s.Output[0].ToolOutput
+}
+
+// normalizeQuotes lowercases text and folds typographic apostrophes so
+// phrase lists need one spelling.
+func normalizeQuotes(text string) string {
+	return strings.ReplaceAll(strings.ToLower(text), "’", "'")
+}
+
+func containsAny(text string, needles []string) bool {
+	for _, n := range needles {
+		if strings.Contains(text, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAnyWord is containsAny for whole words: a match must not sit
+// inside a longer run of letters, digits or underscores, so "429" misses
+// "14290" and "timeout" misses "timeouts".
+func containsAnyWord(text string, words []string) bool {
+	for _, w := range words {
+		for i := 0; ; {
+			j := strings.Index(text[i:], w)
+			if j < 0 {
+				break
+			}
+			start, end := i+j, i+j+len(w)
+			if !isWordByte(text, start-1) && !isWordByte(text, end) {
+				return true
+			}
+			i = start + 1
+		}
+	}
+	return false
+}
+
+func isWordByte(text string, i int) bool {
+	if i < 0 || i >= len(text) {
+		return false
+	}
+	c := text[i]
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
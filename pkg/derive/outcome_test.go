@@ -0,0 +1,185 @@
+package derive_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/derive"
+	"github.com/papercomputeco/tapes/pkg/llm"
+	"github.com/papercomputeco/tapes/pkg/sessions"
+)
+
+var _ = Describe("FoldSessionOutcome", func() {
+	mainCall := func(id, stopReason, text string, input ...string) *derive.Span {
+		return &derive.Span{
+			SpanID: id, Kind: derive.SpanKindLLM, CallKind: derive.KindMain,
+			StopReason: stopReason, Input: blocks(input...), Output: blocks(text),
+		}
+	}
+	toolCall := func(id, command, result string, failed bool) *derive.Span {
+		s := &derive.Span{
+			SpanID: id, Kind: derive.SpanKindTool, Name: "Bash", Status: "ok",
+			Tool:   &derive.ToolIdentity{Name: "Bash", Command: "go"},
+			Input:  []llm.ContentBlock{{Type: "tool_use", ToolUseID: id, ToolName: "Bash", ToolInput: map[string]any{"command": command}}},
+			Output: []llm.ContentBlock{{Type: "tool_result", ToolResultID: id, ToolOutput: result, IsError: failed}},
+		}
+		if failed {
+			s.Status = "error"
+		}
+		return s
+	}
+	asking := func(s *derive.Span, ids ...string) *derive.Span {
+		for _, id := range ids {
+			s.Output = append(s.Output, llm.ContentBlock{Type: "tool_use", ToolUseID: id, ToolName: "Bash"})
+		}
+		return s
+	}
+	trace := func(id string, spans ...*derive.Span) *derive.SpanTurn {
+		return &derive.SpanTurn{TraceID: id, Spans: spans}
+	}
+	completed := derive.SessionStatus{DerivedStatus: sessions.StatusCompleted}
+	// The traces below carry no timestamps, so any session left waiting
+	// has been idle long past derive.AbandonAfter.
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	fold := func(traces []*derive.SpanTurn, terminal *derive.Span, status derive.SessionStatus) derive.SessionOutcome {
+		return derive.FoldSessionOutcome(traces, terminal, status, now)
+	}
+
+	It("is completed for a clean session, with no reasons", func() {
+		done := mainCall("l1", "end_turn", "All tests pass.")
+		out := fold([]*derive.SpanTurn{trace("t1", done)}, done, completed)
+		Expect(out.Outcome).To(Equal(derive.OutcomeCompleted))
+		Expect(out.Reasons).To(BeEmpty())
+	})
+
+	It("records a loop, and is looped when the session ends in it", func() {
+		spans := []*derive.Span{}
+		for _, id := range []string{"a", "b", "c", "d"} {
+			spans = append(spans, toolCall(id, "go test ./...", "FAIL", true))
+		}
+		last := mainCall("l1", "end_turn", "Still failing.")
+		spans = append(spans, last)
+		out := fold([]*derive.SpanTurn{trace("t1", spans...)}, last,
+			derive.SessionStatus{DerivedStatus: sessions.StatusFailed, ToolResultCount: 4, ToolErrorCount: 4})
+		Expect(out.Outcome).To(Equal(derive.OutcomeErrored))
+		Expect(out.Reasons).To(ContainElement(derive.OutcomeReason{
+			Code: derive.ReasonLoop, TraceID: "t1", SpanID: "a", Count: 4, Detail: "Bash",
+		}))
+
+		out = fold([]*derive.SpanTurn{trace("t1", spans...)}, last,
+			derive.SessionStatus{DerivedStatus: sessions.StatusCompleted, ToolResultCount: 4})
+		Expect(out.Outcome).To(Equal(derive.OutcomeLooped))
+	})
+
+	It("keeps a loop the agent broke out of as a reason only", func() {
+		spans := []*derive.Span{
+			toolCall("a", "ls", "x", false), toolCall("b", "ls", "x", false), toolCall("c", "ls", "x", false),
+			toolCall("d", "go build", "ok", false),
+		}
+		last := mainCall("l1", "end_turn", "Built.")
+		out := fold([]*derive.SpanTurn{trace("t1", append(spans, last)...)}, last, completed)
+		Expect(out.Outcome).To(Equal(derive.OutcomeCompleted))
+		Expect(out.Reasons).To(HaveLen(1))
+		Expect(out.Reasons[0].Code).To(Equal(derive.ReasonLoop))
+	})
+
+	It("is interrupted when the user stopped the last turn", func() {
+		first := mainCall("l1", "end_turn", "Done.")
+		stopped := mainCall("l2", "end_turn", "Stopping.", "[Request interrupted by user]", "never mind")
+		out := fold([]*derive.SpanTurn{trace("t1", first), trace("t2", stopped)}, stopped, completed)
+		Expect(out.Outcome).To(Equal(derive.OutcomeInterrupted))
+		Expect(out.Reasons).To(Equal([]derive.OutcomeReason{{Code: derive.ReasonInterrupted, TraceID: "t2", SpanID: "l2"}}))
+	})
+
+	It("categorizes an unrecovered tool error", func() {
+		failed := toolCall("x", "curl api", "HTTP 429 Too Many Requests", true)
+		last := asking(mainCall("l1", "tool_use", ""), "x")
+		out := fold([]*derive.SpanTurn{trace("t1", last, failed)}, last, completed)
+		Expect(out.Outcome).To(Equal(derive.OutcomeErrored))
+		Expect(out.Reasons).To(Equal([]derive.OutcomeReason{{
+			Code: derive.ReasonTerminalError, Category: derive.ErrorRateLimit, TraceID: "t1", SpanID: "x", Detail: "Bash",
+		}}))
+	})
+
+	It("reads status codes and timeout as a category only in a provider's error", func() {
+		failed := toolCall("x", "go test -timeout 30s ./...", "FAIL: listening on :5030, 401 tests run", true)
+		last := asking(mainCall("l1", "tool_use", ""), "x")
+		out := fold([]*derive.SpanTurn{trace("t1", last, failed)}, last, completed)
+		Expect(out.Reasons[0].Category).To(Equal(derive.ErrorTool))
+
+		last = mainCall("l1", "error", "API Error: 529 {\"type\":\"error\"}")
+		out = fold([]*derive.SpanTurn{trace("t1", last)}, last, completed)
+		Expect(out.Reasons[0].Category).To(Equal(derive.ErrorOverloaded))
+
+		last = mainCall("l1", "error", "request_id req_4291503: upstream returned no body")
+		out = fold([]*derive.SpanTurn{trace("t1", last)}, last, completed)
+		Expect(out.Reasons[0].Category).To(Equal(derive.ErrorProvider))
+	})
+
+	It("reads a truncated answer as a max_tokens error", func() {
+		last := mainCall("l1", "max_tokens", "The function should")
+		out := fold([]*derive.SpanTurn{trace("t1", last)}, last, derive.SessionStatus{DerivedStatus: sessions.StatusFailed})
+		Expect(out.Outcome).To(Equal(derive.OutcomeErrored))
+		Expect(out.Reasons).To(HaveLen(1))
+		Expect(out.Reasons[0].Category).To(Equal(derive.ErrorMaxTokens))
+	})
+
+	It("is turn_limit when the session ends on tool calls that never ran", func() {
+		pending := &derive.Span{SpanID: "x", Kind: derive.SpanKindTool, Name: "Bash", Status: "ok"}
+		last := asking(mainCall("l1", "tool_use", ""), "x")
+		out := fold([]*derive.SpanTurn{trace("t1", last, pending)}, last, completed)
+		Expect(out.Outcome).To(Equal(derive.OutcomeTurnLimit))
+		Expect(out.Reasons[0].Count).To(Equal(1))
+	})
+
+	It("detects refusals by stop_reason and by wording", func() {
+		last := mainCall("l1", "refusal", "")
+		Expect(fold([]*derive.SpanTurn{trace("t1", last)}, last, completed).Outcome).
+			To(Equal(derive.OutcomeRefused))
+		last = mainCall("l1", "end_turn", "I can’t help with bypassing the license check.")
+		Expect(fold([]*derive.SpanTurn{trace("t1", last)}, last, completed).Outcome).
+			To(Equal(derive.OutcomeRefused))
+	})
+
+	It("is gave_up only when an admission of defeat follows tool errors", func() {
+		last := mainCall("l1", "end_turn", "I wasn't able to get the build working.")
+		Expect(fold([]*derive.SpanTurn{trace("t1", last)}, last,
+			derive.SessionStatus{DerivedStatus: sessions.StatusCompleted, ToolResultCount: 3, ToolErrorCount: 1}).Outcome).
+			To(Equal(derive.OutcomeGaveUp))
+		Expect(fold([]*derive.SpanTurn{trace("t1", last)}, last, completed).Outcome).
+			To(Equal(derive.OutcomeCompleted))
+	})
+
+	It("is abandoned when the session ends waiting on the user", func() {
+		last := mainCall("l1", "end_turn", "Should I also update the docs?")
+		out := fold([]*derive.SpanTurn{trace("t1", last)}, last, completed)
+		Expect(out.Outcome).To(Equal(derive.OutcomeAbandoned))
+		Expect(out.Reasons[0].Code).To(Equal(derive.ReasonAwaitingUser))
+
+		out = fold([]*derive.SpanTurn{trace("t1")}, nil, derive.SessionStatus{DerivedStatus: sessions.StatusUnknown})
+		Expect(out.Outcome).To(Equal(derive.OutcomeAbandoned))
+		Expect(out.Reasons).To(Equal([]derive.OutcomeReason{{Code: derive.ReasonUnanswered, TraceID: "t1"}}))
+	})
+
+	It("is awaiting_user until the session has been idle for AbandonAfter", func() {
+		last := mainCall("l1", "end_turn", "Should I also update the docs?")
+		turn := trace("t1", last)
+		turn.StartedAt = now.Add(-time.Minute)
+		turn.EndedAt = now.Add(-derive.AbandonAfter + time.Second)
+		out := derive.FoldSessionOutcome([]*derive.SpanTurn{turn}, last, completed, now)
+		Expect(out.Outcome).To(Equal(derive.OutcomeAwaitingUser))
+		Expect(out.Reasons[0].Code).To(Equal(derive.ReasonAwaitingUser))
+
+		out = derive.FoldSessionOutcome([]*derive.SpanTurn{turn}, last, completed, turn.EndedAt.Add(derive.AbandonAfter))
+		Expect(out.Outcome).To(Equal(derive.OutcomeAbandoned))
+	})
+
+	It("ages a stored awaiting_user into abandoned, and leaves other outcomes be", func() {
+		seen := now.Add(-derive.AbandonAfter)
+		Expect(derive.OutcomeAt(derive.OutcomeAwaitingUser, seen.Add(time.Second), now)).To(Equal(derive.OutcomeAwaitingUser))
+		Expect(derive.OutcomeAt(derive.OutcomeAwaitingUser, seen, now)).To(Equal(derive.OutcomeAbandoned))
+		Expect(derive.OutcomeAt(derive.OutcomeCompleted, seen, now)).To(Equal(derive.OutcomeCompleted))
+	})
+})
@@ -0,0 +1,445 @@
+package derive
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/papercomputeco/tapes/pkg/llm"
+)
+
+// builtinProfileFS holds the harness profiles compiled into the binary.
+// builtinProfileOrder is the order they are consulted in: harness tells
+// first, then the generic shape rules that decide the spine.
+//
+//go:embed profiles/*.toml
+var builtinProfileFS embed.FS
+
+var builtinProfileOrder = []string{"claude-code", "codex", "generic"}
+
+// HarnessProfile is one harness's declarative classifier tells: the call
+// rules that type shadow calls and the conversation spine, and the
+// injected-context rules that mark whole messages the harness prepends.
+// Profiles are TOML (or JSON) documents; see pkg/derive/profiles for the
+// built-in ones.
+type HarnessProfile struct {
+	Name        string         `toml:"name"                  json:"name"`
+	Description string         `toml:"description,omitempty" json:"description,omitempty"`
+	Calls       []CallRule     `toml:"call,omitempty"        json:"call,omitempty"`
+	Injected    []InjectedRule `toml:"injected,omitempty"    json:"injected,omitempty"`
+}
+
+// CallRule maps a call to a kind when every condition it sets holds.
+// List-valued conditions hold when any of their entries matches; unset
+// conditions are ignored, and a rule must set at least one.
+//
+// The texts a rule reads are the system prompt, the first and last
+// request messages, and the response. Prefix conditions test the text
+// with surrounding whitespace trimmed; contains conditions test it as is.
+type CallRule struct {
+	// Name labels the rule in `tapes dev classify` reports; it defaults
+	// to the kind.
+	Name string `toml:"name,omitempty" json:"name,omitempty"`
+	Kind string `toml:"kind"           json:"kind"`
+
+	// IgnoreCase makes every text condition case-insensitive.
+	IgnoreCase bool `toml:"ignore_case,omitempty" json:"ignore_case,omitempty"`
+
+	SystemContains      []string `toml:"system_contains,omitempty"       json:"system_contains,omitempty"`
+	FirstPrefix         []string `toml:"first_prefix,omitempty"          json:"first_prefix,omitempty"`
+	FirstContains       []string `toml:"first_contains,omitempty"        json:"first_contains,omitempty"`
+	LastPrefix          []string `toml:"last_prefix,omitempty"           json:"last_prefix,omitempty"`
+	LastContains        []string `toml:"last_contains,omitempty"         json:"last_contains,omitempty"`
+	ResponsePrefix      []string `toml:"response_prefix,omitempty"       json:"response_prefix,omitempty"`
+	ResponseContains    []string `toml:"response_contains,omitempty"     json:"response_contains,omitempty"`
+	ResponseContainsAll []string `toml:"response_contains_all,omitempty" json:"response_contains_all,omitempty"`
+
+	// MaxTokens and MaxTokensAtMost test the request's max_tokens; a
+	// request that sets none fails both.
+	MaxTokens       *int `toml:"max_tokens,omitempty"         json:"max_tokens,omitempty"`
+	MaxTokensAtMost *int `toml:"max_tokens_at_most,omitempty" json:"max_tokens_at_most,omitempty"`
+
+	// MinTools and MaxTools bound the number of client-side tool
+	// definitions.
+	MinTools *int `toml:"min_tools,omitempty" json:"min_tools,omitempty"`
+	MaxTools *int `toml:"max_tools,omitempty" json:"max_tools,omitempty"`
+
+	Stream *bool `toml:"stream,omitempty" json:"stream,omitempty"`
+
+	// ToolRouting tests whether a Responses API request declares active
+	// tool routing (an enabled tool_choice) without sending tool
+	// definitions itself.
+	ToolRouting *bool `toml:"tool_routing,omitempty" json:"tool_routing,omitempty"`
+}
+
+// InjectedRule marks a whole request message as injected context when
+// its text, trimmed, starts with one of Prefix. Only messages made
+// entirely of text blocks, from one of Roles (default user and system),
+// qualify.
+type InjectedRule struct {
+	Name   string   `toml:"name,omitempty"  json:"name,omitempty"`
+	Kind   string   `toml:"kind"            json:"kind"`
+	Prefix []string `toml:"prefix"          json:"prefix"`
+	Roles  []string `toml:"roles,omitempty" json:"roles,omitempty"`
+}
+
+// RuleMatch identifies the rule that decided a classification. Profile
+// and Rule are empty when no rule matched.
+type RuleMatch struct {
+	Profile string
+	Rule    string
+	Kind    string
+}
+
+// HarnessProfiles is an ordered set of profiles. Classification walks
+// the profiles in order, and each profile's rules in file order; the
+// first rule that matches decides the kind.
+type HarnessProfiles struct {
+	profiles []HarnessProfile
+}
+
+// activeProfiles is the set ClassifyCall and ClassifyInjected use. It is
+// process-wide because live ingest and re-derive must classify alike:
+// TurnChain stamps the kind onto nodes, and a re-derive that typed calls
+// differently from the ingest that first chained them would rebuild a
+// different projection.
+var activeProfiles atomic.Pointer[HarnessProfiles]
+
+func init() {
+	profiles, err := BuiltinHarnessProfiles()
+	if err != nil {
+		panic(fmt.Sprintf("derive: built-in harness profiles: %v", err))
+	}
+	activeProfiles.Store(profiles)
+}
+
+// ActiveHarnessProfiles returns the profiles classification currently
+// uses.
+func ActiveHarnessProfiles() *HarnessProfiles {
+	return activeProfiles.Load()
+}
+
+// UseHarnessProfiles makes profiles the set classification uses. Call it
+// at startup, before anything derives; existing projections pick the
+// change up on their next re-derive.
+func UseHarnessProfiles(profiles *HarnessProfiles) {
+	if profiles == nil {
+		return
+	}
+	activeProfiles.Store(profiles)
+}
+
+// NewHarnessProfiles returns a set of exactly profiles, in order, with no
+// built-ins. Most callers want LoadHarnessProfiles.
+func NewHarnessProfiles(profiles ...HarnessProfile) (*HarnessProfiles, error) {
+	for i := range profiles {
+		if err := profiles[i].validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", profiles[i].Name, err)
+		}
+	}
+	return &HarnessProfiles{profiles: profiles}, nil
+}
+
+// BuiltinHarnessProfiles returns the profiles compiled into the binary.
+func BuiltinHarnessProfiles() (*HarnessProfiles, error) {
+	set := &HarnessProfiles{}
+	for _, name := range builtinProfileOrder {
+		data, err := builtinProfileFS.ReadFile(path.Join("profiles", name+".toml"))
+		if err != nil {
+			return nil, err
+		}
+		profile, err := ParseHarnessProfile(data, ".toml")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		set.profiles = append(set.profiles, *profile)
+	}
+	return set, nil
+}
+
+// LoadHarnessProfiles reads the profile files at paths and returns them
+// ahead of the built-in profiles, so a configured profile can claim a
+// call before the built-in tells or the generic spine rules see it.
+func LoadHarnessProfiles(paths ...string) (*HarnessProfiles, error) {
+	builtin, err := BuiltinHarnessProfiles()
+	if err != nil {
+		return nil, err
+	}
+	set := &HarnessProfiles{}
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("read harness profile: %w", err)
+		}
+		profile, err := ParseHarnessProfile(data, filepath.Ext(p))
+		if err != nil {
+			return nil, fmt.Errorf("harness profile %s: %w", p, err)
+		}
+		set.profiles = append(set.profiles, *profile)
+	}
+	set.profiles = append(set.profiles, builtin.profiles...)
+	return set, nil
+}
+
+// ParseHarnessProfile decodes and validates one profile. ext picks the
+// format: ".json" is JSON, anything else TOML.
+func ParseHarnessProfile(data []byte, ext string) (*HarnessProfile, error) {
+	var profile HarnessProfile
+	if strings.EqualFold(ext, ".json") {
+		if err := json.Unmarshal(data, &profile); err != nil {
+			return nil, fmt.Errorf("parse: %w", err)
+		}
+	} else {
+		md, err := toml.Decode(string(data), &profile)
+		if err != nil {
+			return nil, fmt.Errorf("parse: %w", err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("unknown field %s", undecoded[0])
+		}
+	}
+	if err := profile.validate(); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (p *HarnessProfile) validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("profile has no name")
+	}
+	for i := range p.Calls {
+		r := &p.Calls[i]
+		if r.Kind != KindMain && !strings.HasPrefix(r.Kind, "offshoot:") {
+			return fmt.Errorf("call rule %d: kind %q is neither %q nor offshoot:*", i, r.Kind, KindMain)
+		}
+		if !r.hasCondition() {
+			return fmt.Errorf("call rule %d (%s): no conditions; it would match every call", i, r.Kind)
+		}
+	}
+	for i := range p.Injected {
+		r := &p.Injected[i]
+		if !strings.HasPrefix(r.Kind, "injected:") {
+			return fmt.Errorf("injected rule %d: kind %q is not injected:*", i, r.Kind)
+		}
+		if len(r.Prefix) == 0 {
+			return fmt.Errorf("injected rule %d (%s): no prefix", i, r.Kind)
+		}
+	}
+	return nil
+}
+
+func (r *CallRule) hasCondition() bool {
+	return len(r.SystemContains) > 0 || len(r.FirstPrefix) > 0 || len(r.FirstContains) > 0 ||
+		len(r.LastPrefix) > 0 || len(r.LastContains) > 0 || len(r.ResponsePrefix) > 0 ||
+		len(r.ResponseContains) > 0 || len(r.ResponseContainsAll) > 0 ||
+		r.MaxTokens != nil || r.MaxTokensAtMost != nil || r.MinTools != nil || r.MaxTools != nil ||
+		r.Stream != nil || r.ToolRouting != nil
+}
+
+// Names returns the profile names in consultation order.
+func (p *HarnessProfiles) Names() []string {
+	names := make([]string, len(p.profiles))
+	for i, profile := range p.profiles {
+		names[i] = profile.Name
+	}
+	return names
+}
+
+// ClassifyCall is ClassifyCall under this profile set.
+func (p *HarnessProfiles) ClassifyCall(req *llm.ChatRequest, resp *llm.ChatResponse) string {
+	return p.MatchCall(req, resp).Kind
+}
+
+// MatchCall classifies a call and reports which rule decided it. A call
+// no rule matches is KindUnknown with an empty Profile.
+func (p *HarnessProfiles) MatchCall(req *llm.ChatRequest, resp *llm.ChatResponse) RuleMatch {
+	if req == nil {
+		return RuleMatch{Kind: KindUnknown}
+	}
+	in := newCallInput(req, resp)
+	for _, profile := range p.profiles {
+		for i := range profile.Calls {
+			r := &profile.Calls[i]
+			if r.matches(in) {
+				return RuleMatch{Profile: profile.Name, Rule: ruleName(r.Name, r.Kind), Kind: r.Kind}
+			}
+		}
+	}
+	return RuleMatch{Kind: KindUnknown}
+}
+
+// ClassifyInjected is ClassifyInjected under this profile set.
+func (p *HarnessProfiles) ClassifyInjected(msg llm.Message) string {
+	return p.MatchInjected(msg).Kind
+}
+
+// MatchInjected reports the injected-context rule a message matches, or
+// a zero RuleMatch when the message is ordinary conversation.
+func (p *HarnessProfiles) MatchInjected(msg llm.Message) RuleMatch {
+	var text strings.Builder
+	for _, b := range msg.Content {
+		switch b.Type {
+		case "text", "":
+			text.WriteString(b.Text)
+		default:
+			// tool_use / tool_result / image blocks are never injected
+			// context; a mixed message is conversation.
+			return RuleMatch{}
+		}
+	}
+	t := strings.TrimSpace(text.String())
+	for _, profile := range p.profiles {
+		for i := range profile.Injected {
+			r := &profile.Injected[i]
+			if r.matches(msg.Role, t) {
+				return RuleMatch{Profile: profile.Name, Rule: ruleName(r.Name, r.Kind), Kind: r.Kind}
+			}
+		}
+	}
+	return RuleMatch{}
+}
+
+func ruleName(name, kind string) string {
+	if name != "" {
+		return name
+	}
+	return kind
+}
+
+func (r *InjectedRule) matches(role, text string) bool {
+	roles := r.Roles
+	if len(roles) == 0 {
+		roles = []string{"user", "system"}
+	}
+	found := false
+	for _, allowed := range roles {
+		if role == allowed {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false
+	}
+	for _, prefix := range r.Prefix {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// callInput is one call's classifiable surface, computed once and shared
+// by every rule. Lower-cased texts are built on first use.
+type callInput struct {
+	texts       [4]string
+	folded      [4]*string
+	maxTokens   *int
+	tools       int
+	stream      bool
+	toolRouting bool
+}
+
+const (
+	textSystem = iota
+	textFirst
+	textLast
+	textResponse
+)
+
+func newCallInput(req *llm.ChatRequest, resp *llm.ChatResponse) *callInput {
+	return &callInput{
+		texts:       [4]string{req.System, firstText(req), lastText(req), responseText(resp)},
+		maxTokens:   req.MaxTokens,
+		tools:       len(req.Tools),
+		stream:      req.Stream != nil && *req.Stream,
+		toolRouting: responsesToolRoutingEnabled(req),
+	}
+}
+
+func (in *callInput) text(which int, fold bool) string {
+	if !fold {
+		return in.texts[which]
+	}
+	if in.folded[which] == nil {
+		lower := strings.ToLower(in.texts[which])
+		in.folded[which] = &lower
+	}
+	return *in.folded[which]
+}
+
+func (r *CallRule) matches(in *callInput) bool {
+	if r.MaxTokens != nil && (in.maxTokens == nil || *in.maxTokens != *r.MaxTokens) {
+		return false
+	}
+	if r.MaxTokensAtMost != nil && (in.maxTokens == nil || *in.maxTokens > *r.MaxTokensAtMost) {
+		return false
+	}
+	if r.MinTools != nil && in.tools < *r.MinTools {
+		return false
+	}
+	if r.MaxTools != nil && in.tools > *r.MaxTools {
+		return false
+	}
+	if r.Stream != nil && in.stream != *r.Stream {
+		return false
+	}
+	if r.ToolRouting != nil && in.toolRouting != *r.ToolRouting {
+		return false
+	}
+
+	return r.anyOf(in, r.SystemContains, textSystem, false, strings.Contains) &&
+		r.anyOf(in, r.FirstPrefix, textFirst, true, strings.HasPrefix) &&
+		r.anyOf(in, r.FirstContains, textFirst, false, strings.Contains) &&
+		r.anyOf(in, r.LastPrefix, textLast, true, strings.HasPrefix) &&
+		r.anyOf(in, r.LastContains, textLast, false, strings.Contains) &&
+		r.anyOf(in, r.ResponsePrefix, textResponse, true, strings.HasPrefix) &&
+		r.anyOf(in, r.ResponseContains, textResponse, false, strings.Contains) &&
+		r.allOf(in, r.ResponseContainsAll, textResponse)
+}
+
+// anyOf holds when patterns is empty or test(text, p) holds for one of
+// them. The text is only read (and folded) when there is a pattern.
+func (r *CallRule) anyOf(in *callInput, patterns []string, which int, trim bool, test func(string, string) bool) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	text := in.text(which, r.IgnoreCase)
+	if trim {
+		text = strings.TrimSpace(text)
+	}
+	for _, p := range patterns {
+		if r.IgnoreCase {
+			p = strings.ToLower(p)
+		}
+		if test(text, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *CallRule) allOf(in *callInput, patterns []string, which int) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	text := in.text(which, r.IgnoreCase)
+	for _, p := range patterns {
+		if r.IgnoreCase {
+			p = strings.ToLower(p)
+		}
+		if !strings.Contains(text, p) {
+			return false
+		}
+	}
+	return true
+}
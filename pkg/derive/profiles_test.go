@@ -0,0 +1,86 @@
+package derive_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/derive"
+	"github.com/papercomputeco/tapes/pkg/llm"
+)
+
+var _ = Describe("HarnessProfiles", func() {
+	writeProfile := func(name, body string) string {
+		path := filepath.Join(GinkgoT().TempDir(), name)
+		Expect(os.WriteFile(path, []byte(body), 0o600)).To(Succeed())
+		return path
+	}
+
+	mainShaped := func(last string) *llm.ChatRequest {
+		return &llm.ChatRequest{
+			System:   "You are Acme, the in-house coding agent.",
+			Stream:   new(true),
+			Tools:    []json.RawMessage{json.RawMessage(`{"name":"edit"}`)},
+			Messages: []llm.Message{textMsg("user", last)},
+		}
+	}
+
+	It("consults the built-in profiles in order, ending with the generic spine rules", func() {
+		profiles, err := derive.BuiltinHarnessProfiles()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(profiles.Names()).To(Equal([]string{"claude-code", "codex", "generic"}))
+
+		match := profiles.MatchCall(mainShaped("fix the bug"), assistantText("done"))
+		Expect(match).To(Equal(derive.RuleMatch{Profile: "generic", Rule: "spine", Kind: derive.KindMain}))
+	})
+
+	It("lets a configured profile claim calls ahead of the built-ins", func() {
+		path := writeProfile("acme.toml", `
+name = "acme"
+
+[[call]]
+name = "lint-pass"
+kind = "offshoot:lint"
+system_contains = ["in-house coding agent"]
+last_prefix = ["[LINT]"]
+
+[[injected]]
+kind = "injected:repo-map"
+prefix = ["<repo_map>"]
+`)
+		profiles, err := derive.LoadHarnessProfiles(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(profiles.Names()).To(Equal([]string{"acme", "claude-code", "codex", "generic"}))
+
+		Expect(profiles.MatchCall(mainShaped("  [LINT] check src/"), nil)).
+			To(Equal(derive.RuleMatch{Profile: "acme", Rule: "lint-pass", Kind: "offshoot:lint"}))
+		Expect(profiles.ClassifyCall(mainShaped("please [LINT] later"), nil)).To(Equal(derive.KindMain))
+
+		Expect(profiles.ClassifyInjected(textMsg("user", "<repo_map>\nsrc/main.go"))).To(Equal("injected:repo-map"))
+		Expect(profiles.ClassifyInjected(textMsg("assistant", "<repo_map>"))).To(BeEmpty())
+	})
+
+	It("reads JSON profiles", func() {
+		path := writeProfile("acme.json", `{"name":"acme","call":[{"kind":"offshoot:probe","max_tokens_at_most":4,"max_tools":0}]}`)
+		profiles, err := derive.LoadHarnessProfiles(path)
+		Expect(err).NotTo(HaveOccurred())
+
+		req := &llm.ChatRequest{MaxTokens: new(3), Messages: []llm.Message{textMsg("user", "hi")}}
+		Expect(profiles.MatchCall(req, nil).Profile).To(Equal("acme"))
+	})
+
+	DescribeTable("rejects profiles that would misclassify",
+		func(body, wantErr string) {
+			_, err := derive.LoadHarnessProfiles(writeProfile("bad.toml", body))
+			Expect(err).To(MatchError(ContainSubstring(wantErr)))
+		},
+		Entry("no name", "[[call]]\nkind = \"main\"\nstream = true\n", "no name"),
+		Entry("a rule without conditions", "name = \"x\"\n[[call]]\nkind = \"offshoot:x\"\n", "no conditions"),
+		Entry("a call kind outside the taxonomy", "name = \"x\"\n[[call]]\nkind = \"lint\"\nstream = true\n", "offshoot:*"),
+		Entry("an injected kind outside the taxonomy", "name = \"x\"\n[[injected]]\nkind = \"offshoot:x\"\nprefix = [\"a\"]\n", "injected:*"),
+		Entry("a misspelled condition", "name = \"x\"\n[[call]]\nkind = \"main\"\nsytem_contains = [\"a\"]\n", "unknown field"),
+	)
+})
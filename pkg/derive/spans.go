@@ -3,6 +3,7 @@ package derive
 import (
 	"fmt"
 	"maps"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -53,6 +54,7 @@ const (
 	LinkRejoin         = "rejoin"          // subagent agent -> Task tool
 	LinkVerdict        = "verdict"         // shadow llm -> judged tool
 	LinkCompactionSeam = "compaction-seam" // compaction llm -> next trace's first llm
+	LinkFork           = "fork"            // branch-point llm -> rewound trace's first llm
 )
 
 // SpanSet is the emit stage's output for one derive pass: traces in
@@ -79,6 +81,11 @@ type SpanSet struct {
 	Tasks      map[SessionKey][]Task
 	KindCounts map[SessionKey]map[string]int
 
+	// Context is the per-session context-window fold: how full the
+	// conversation's context got, how often it was compacted, and how
+	// much of the prompt the provider served from cache.
+	Context map[SessionKey]ContextRollup
+
 	// Status is the per-session chain-aware outcome + signals, folded from
 	// the spans at derive time (moved off the ingest hot path).
 	Status map[SessionKey]SessionStatus
@@ -97,6 +104,19 @@ type ModelUsage struct {
 	CostUSD      float64 `json:"cost_usd"`
 }
 
+// ContextRollup is a session's context-window fold. Peak and reclaimed
+// figures count main-thread calls only — subagents run in contexts of
+// their own — while the cache figures cover every llm call.
+type ContextRollup struct {
+	PeakTokens      int64   `json:"peak_tokens"`
+	PeakOccupancy   float64 `json:"peak_occupancy"`
+	Compactions     int     `json:"compactions"`
+	ReclaimedTokens int64   `json:"reclaimed_tokens"`
+	InputTokens     int64   `json:"input_tokens"`
+	CacheReadTokens int64   `json:"cache_read_tokens"`
+	CacheHitRatio   float64 `json:"cache_hit_ratio"`
+}
+
 // SpanTurn is one user-visible turn: a trace. Everything the harness
 // did inside the turn — subagent runs and shadow calls included —
 // lives here.
@@ -122,6 +142,17 @@ type SpanTurn struct {
 	// trace ('wire' | 'transcript'), promoted from raw_turns.source.
 	Source string
 
+	// ParentTraceID is the trace this one follows on its branch of the
+	// session ("" for the session's first trace). Traces sharing a
+	// parent are the branches of a fork: an edited earlier message or a
+	// rewind re-sends history up to the branch point and continues from
+	// there.
+	ParentTraceID string
+
+	// Abandoned marks traces on a branch the session later rewound away
+	// from: they no longer lead to the session's live tip.
+	Abandoned bool
+
 	StartedAt time.Time
 	EndedAt   time.Time
 
@@ -145,6 +176,26 @@ type SpanTurn struct {
 	// table per request (PCC-936).
 	ToolCalls int
 
+	// Context-window fold over the trace's main-thread calls (the spine
+	// and its compactions). PeakContext* is the fullest call's
+	// usage.context. ContextGrowthTokens is the closing call's context
+	// minus the parent trace's — negative across a compaction.
+	// ReclaimedTokens is set on a post-compaction trace: the compaction
+	// call's context minus the continuation's first call's.
+	PeakContextTokens    int64
+	PeakContextOccupancy float64
+	ContextGrowthTokens  int64
+	ReclaimedTokens      int64
+
+	// Files is the per-file activity of the trace's tool calls, sorted
+	// by path (FoldFileTouches). Subagent calls count towards the trace
+	// that hosts them.
+	Files []FileTouch
+
+	// Artifacts are the commits, pushes and pull requests the trace's
+	// shell calls produced, in call order (FoldGitArtifacts).
+	Artifacts []GitArtifact
+
 	Spans []*Span
 	Links []*SpanLink
 }
@@ -202,6 +253,12 @@ type Span struct {
 	// span (nil elsewhere), extracted at derive time by ClassifyVerdict.
 	Verdict *Verdict
 
+	// Tool is a tool span's normalized identity (nil elsewhere). RetryOf
+	// names the errored tool span this one repeats with the same input
+	// ("" when it is not a retry); see foldToolRetries.
+	Tool    *ToolIdentity
+	RetryOf string
+
 	// RawTurnID is the raw row whose call produced this span (0 for
 	// tool/agent spans, which are assembled across calls).
 	RawTurnID int64
@@ -231,6 +288,7 @@ type SpanReport struct {
 	CallKinds    map[string]int `json:"call_kinds"`
 	LinkKinds    map[string]int `json:"link_kinds"`
 	Synthetic    int            `json:"synthetic_traces"`
+	Abandoned    int            `json:"abandoned_traces"`
 	OrphanShadow int            `json:"orphan_shadow_calls"`
 }
 
@@ -245,10 +303,49 @@ type spanEmitter struct {
 	agentSpan map[string]*Span // session|thread -> subagent agent span
 	agentTurn map[string]*SpanTurn
 	seam      map[SessionKey]*seamSource
+	spine     map[SessionKey]*sessionSpine
 
 	// spawnLabels is the reconciler's per-spawn console labeling
 	// (DerivedSet.SpawnLabels), folded into spawn tool spans' inputs.
 	spawnLabels map[string]SpawnLabel
+
+	// pricing prices each llm span at its start instant (WithPricing).
+	pricing sessions.PriceLookup
+
+	// capabilities sizes each llm span's context window.
+	capabilities sessions.CapabilityTable
+
+	// compacted maps each post-compaction trace to the compaction span
+	// that seeded it, for the reclaimed-tokens fold.
+	compacted map[*SpanTurn]*Span
+
+	// now is the instant the outcome fold measures idleness from
+	// (WithNow).
+	now time.Time
+}
+
+// EmitOption configures an EmitSpans pass.
+type EmitOption func(*spanEmitter)
+
+// WithPricing prices llm spans from lookup instead of the built-in
+// sessions.DefaultPricing table. The store passes the org's pricing
+// catalog, so a re-derive costs every call at the price in effect when it
+// was made.
+func WithPricing(lookup sessions.PriceLookup) EmitOption {
+	return func(em *spanEmitter) {
+		if lookup != nil {
+			em.pricing = lookup
+		}
+	}
+}
+
+// WithNow folds session outcomes as of now instead of the wall clock at
+// the EmitSpans call, so a session left waiting on its user reads the
+// same awaiting_user or abandoned on every pass.
+func WithNow(now time.Time) EmitOption {
+	return func(em *spanEmitter) {
+		em.now = now
+	}
 }
 
 type seamSource struct {
@@ -256,8 +353,25 @@ type seamSource struct {
 	span *Span
 }
 
+// sessionSpine tracks a session's branch structure across main calls:
+// the llm span behind every main-spine response seen so far, the latest
+// response (the live tip), and the live branch's traces, oldest first.
+type sessionSpine struct {
+	calls  map[string]branchPoint // response node hash -> its llm span
+	leaf   string
+	branch []*SpanTurn
+	traces map[string]*SpanTurn
+}
+
+// branchPoint is a main-spine llm span a later call may resume from.
+type branchPoint struct {
+	turn   *SpanTurn
+	spanID string
+}
+
 // EmitSpans projects a finished, reconciled DerivedSet into the span
-// model. Pure; safe to call repeatedly.
+// model. Pure apart from the clock the outcome fold reads (WithNow
+// pins it); safe to call repeatedly.
 //
 // The walk is phased because wire order races structure: a permission
 // check completes before the main call whose tool_use it judges
@@ -267,7 +381,7 @@ type seamSource struct {
 // anchor last, when every candidate tool span exists. Capture order is
 // preserved within each phase, and span ordering inside a trace is by
 // StartedAt, so phasing never reorders time.
-func EmitSpans(set *DerivedSet) *SpanSet {
+func EmitSpans(set *DerivedSet, opts ...EmitOption) *SpanSet {
 	em := &spanEmitter{
 		set: &SpanSet{Report: SpanReport{
 			SpanKinds: map[string]int{},
@@ -281,7 +395,16 @@ func EmitSpans(set *DerivedSet) *SpanSet {
 		agentSpan:   map[string]*Span{},
 		agentTurn:   map[string]*SpanTurn{},
 		seam:        map[SessionKey]*seamSource{},
+		spine:       map[SessionKey]*sessionSpine{},
 		spawnLabels: set.SpawnLabels,
+		pricing:     sessions.DefaultPricing(),
+
+		capabilities: sessions.DefaultCapabilities(),
+		compacted:    map[*SpanTurn]*Span{},
+		now:          time.Now(),
+	}
+	for _, opt := range opts {
+		opt(em)
 	}
 	var threadCalls, shadowCalls []*SpanSource
 	for _, src := range set.SpanSources {
@@ -364,9 +487,89 @@ func (em *spanEmitter) mainCall(src *SpanSource) {
 	turn := em.curTrace[src.Session]
 	prompt := freshGenuinePrompt(src)
 	if turn == nil || prompt != nil {
+		from := em.forkPoint(src)
 		turn = em.openTrace(src, prompt)
+		if from != nil {
+			em.fork(src, turn, from)
+		}
 	}
 	em.emitConversation(src, turn, turn.Spans[0])
+	em.advanceSpine(src, turn)
+}
+
+// forkPoint returns the llm span a new main-spine trace branches from
+// when the call re-sends history that stops short of the session's live
+// tip — the user edited an earlier message or rewound the conversation.
+//
+// TurnChain hashes every node over its parent's hash, so two calls share
+// exactly their common history: the deepest response in this request
+// that an earlier main call produced is where the branch leaves the old
+// spine. A request that still carries the tip is a plain continuation.
+// Calls past a compaction seam or a resume (#29) re-hash their history
+// and never share a response with the old spine, so they are gated out
+// explicitly rather than read as a rewind to the session root; so is a
+// branch point inside the tip trace, where no earlier trace is displaced
+// (an interrupted tool loop, a truncated stream).
+func (em *spanEmitter) forkPoint(src *SpanSource) *branchPoint {
+	sp := em.spine[src.Session]
+	if sp == nil || em.seam[src.Session] != nil || lastFreshAssistantIdx(src) >= 0 {
+		return nil
+	}
+	req := src.Chain[:len(src.Chain)-1]
+	for i := len(req) - 1; i >= 0; i-- {
+		hash := req[i].Node.Hash
+		if hash == sp.leaf {
+			return nil
+		}
+		if from, ok := sp.calls[hash]; ok {
+			if from.turn == sp.branch[len(sp.branch)-1] {
+				return nil
+			}
+			return &from
+		}
+	}
+	return nil
+}
+
+// fork re-parents a freshly opened trace onto its branch point, links
+// the two, and marks every trace the rewind displaced as abandoned. A
+// fork back onto a previously abandoned branch revives that branch.
+func (em *spanEmitter) fork(src *SpanSource, turn *SpanTurn, from *branchPoint) {
+	sp := em.spine[src.Session]
+	var live []*SpanTurn
+	for t := from.turn; t != nil; t = sp.traces[t.ParentTraceID] {
+		live = append(live, t)
+	}
+	slices.Reverse(live)
+	onLive := make(map[*SpanTurn]bool, len(live))
+	for _, t := range live {
+		onLive[t] = true
+		t.Abandoned = false
+	}
+	for _, t := range sp.branch {
+		if t != turn && !onLive[t] {
+			t.Abandoned = true
+		}
+	}
+
+	turn.ParentTraceID = from.turn.TraceID
+	sp.branch = append(live, turn)
+	em.link(turn, &SpanLink{
+		FromTraceID: from.turn.TraceID, FromSpanID: from.spanID, FromIO: "output",
+		ToTraceID: turn.TraceID, ToSpanID: "llm_" + callIdentity(src), ToIO: "input",
+		Kind: LinkFork,
+	})
+}
+
+// advanceSpine records a main-spine call's response as the session's
+// live tip and as a branch point later calls may resume from.
+func (em *spanEmitter) advanceSpine(src *SpanSource, turn *SpanTurn) {
+	sp := em.spine[src.Session]
+	leaf := src.Chain[len(src.Chain)-1].Node.Hash
+	sp.leaf = leaf
+	if _, ok := sp.calls[leaf]; !ok {
+		sp.calls[leaf] = branchPoint{turn: turn, spanID: "llm_" + callIdentity(src)}
+	}
 }
 
 // threadCall handles a subagent's API call: ensure the thread's agent
@@ -517,6 +720,10 @@ func (em *spanEmitter) emitConversation(src *SpanSource, turn *SpanTurn, parent
 	}
 
 	resp := src.Chain[len(src.Chain)-1].Node
+	// a tool starts once the response carrying its tool_use has
+	// arrived, so its span times execution alone, tool_use to
+	// tool_result, not the model call that asked for it.
+	issued := src.CapturedAt.Add(time.Duration(span.DurationNS))
 	for _, b := range resp.Bucket.Content {
 		if (b.Type != blockToolUse && b.Type != blockServerToolUse) || b.ToolUseID == "" {
 			continue
@@ -532,13 +739,15 @@ func (em *spanEmitter) emitConversation(src *SpanSource, turn *SpanTurn, parent
 			})
 			continue
 		}
+		name := displayToolName(b.ToolName, b.ToolInput)
 		ts := &Span{
 			SpanID:       b.ToolUseID,
 			ParentSpanID: parent.SpanID,
 			Kind:         SpanKindTool,
-			Name:         displayToolName(b.ToolName, b.ToolInput),
+			Name:         name,
 			Status:       "ok",
-			StartedAt:    src.CapturedAt,
+			StartedAt:    issued,
+			Tool:         identifyTool(name, b.ToolInput),
 			ThreadID:     src.ThreadID,
 			Input:        []llm.ContentBlock{em.spawnToolInput(src.Session, b)},
 		}
@@ -679,9 +888,23 @@ func (em *spanEmitter) openTrace(src *SpanSource, prompt *DerivedNode) *SpanTurn
 	em.curTrace[src.Session] = turn
 	em.timeline[src.Session] = append(em.timeline[src.Session], turn)
 
+	// A new trace follows the live branch's tip; fork re-parents it when
+	// the call turns out to rewind.
+	sp := em.spine[src.Session]
+	if sp == nil {
+		sp = &sessionSpine{calls: map[string]branchPoint{}, traces: map[string]*SpanTurn{}}
+		em.spine[src.Session] = sp
+	}
+	if n := len(sp.branch); n > 0 {
+		turn.ParentTraceID = sp.branch[n-1].TraceID
+	}
+	sp.branch = append(sp.branch, turn)
+	sp.traces[turn.TraceID] = turn
+
 	if s := em.seam[src.Session]; s != nil {
 		turn.Synthetic = "post-compaction"
 		em.set.Report.Synthetic++
+		em.compacted[turn] = s.span
 		// seam closes on the opening llm span, which is emitted right
 		// after openTrace returns; link to its deterministic id now.
 		em.link(turn, &SpanLink{
@@ -750,12 +973,37 @@ func (em *spanEmitter) llmSpan(src *SpanSource, parentID string, input []llm.Con
 	if resp.Usage != nil {
 		span.DurationNS = resp.Usage.TotalDurationNs
 	}
+	if src.Latency != nil {
+		// The node's usage is shared with every call that deduped onto
+		// it; the latency belongs to this call alone.
+		usage := llm.Usage{}
+		if resp.Usage != nil {
+			usage = *resp.Usage
+		}
+		usage.Latency = src.Latency
+		span.Usage = &usage
+	}
+	if resp.Usage != nil && resp.Usage.PromptTokens > 0 {
+		usage := *span.Usage
+		usage.Context = em.contextUsage(span.Model, int64(resp.Usage.PromptTokens))
+		span.Usage = &usage
+	}
 	// Permission-check spans carry the security-monitor verdict; extract
 	// it once, at derive time, so the read path never re-parses text.
 	span.Verdict = ClassifyVerdict(span.CallKind, span.Output)
 	return span
 }
 
+// contextUsage sizes a call's prompt against its model's context window.
+func (em *spanEmitter) contextUsage(model string, promptTokens int64) *llm.ContextUsage {
+	ctx := &llm.ContextUsage{Tokens: promptTokens}
+	if capability, ok := sessions.CapabilityForModel(em.capabilities, model); ok && capability.ContextWindow > 0 {
+		ctx.Window = capability.ContextWindow
+		ctx.Occupancy = float64(promptTokens) / float64(capability.ContextWindow)
+	}
+	return ctx
+}
+
 func (em *spanEmitter) eventSpan(turn *SpanTurn, parent *Span, kind, hash string, at time.Time, content []llm.ContentBlock) {
 	em.addSpan(turn, &Span{
 		SpanID:       "evt_" + hash[:16],
@@ -795,11 +1043,12 @@ func (em *spanEmitter) link(host *SpanTurn, l *SpanLink) {
 }
 
 func (em *spanEmitter) finish() {
-	// Default rates; override plumbing (sessions.LoadPricing) can ride
-	// the worker config later — the projection reprices on re-derive
-	// either way.
-	pricing := sessions.DefaultPricing()
 	em.set.Report.Traces = len(em.set.Turns)
+	for _, turn := range em.set.Turns {
+		if turn.Abandoned {
+			em.set.Report.Abandoned++
+		}
+	}
 	// Per-session, per-model spend fold (#28). Accumulated across every
 	// trace's llm spans below — subagent models included — then sorted
 	// into ModelUsage at the end.
@@ -851,10 +1100,8 @@ func (em *spanEmitter) finish() {
 					turn.MainOutputTokens += int64(s.Usage.CompletionTokens)
 				}
 				var total float64
-				if price, ok := sessions.PricingForModel(pricing, s.Model); ok {
-					_, _, total = sessions.CostForTokensWithCache(price,
-						int64(s.Usage.PromptTokens), int64(s.Usage.CompletionTokens),
-						int64(s.Usage.CacheCreationInputTokens), int64(s.Usage.CacheReadInputTokens))
+				if price, ok := em.pricing.PricingAt(s.Model, s.StartedAt); ok {
+					_, _, total = sessions.CostForUsage(price, sessions.TokensForUsage(s.Usage))
 					turn.TotalCostUSD += total
 				}
 				if s.Model != "" {
@@ -879,8 +1126,11 @@ func (em *spanEmitter) finish() {
 			root.DurationNS = turn.EndedAt.Sub(turn.StartedAt).Nanoseconds()
 		}
 		turn.ResponsePreview = responsePreview(turn)
+		turn.Files = FoldFileTouches(turn.Spans)
+		turn.Artifacts = FoldGitArtifacts(turn.Spans)
 	}
 	em.foldModelUsage(modelFold)
+	em.foldContext()
 
 	// Task fold, kind_counts, and chain-aware status are per-covered-session:
 	// every session with at least one trace resolves one, EMPTY folds included.
@@ -901,8 +1151,12 @@ func (em *spanEmitter) finish() {
 		em.set.Status = make(map[SessionKey]SessionStatus, len(sessionSet))
 		em.set.KindCounts = make(map[SessionKey]map[string]int, len(sessionSet))
 		for key := range sessionSet {
+			foldToolRetries(toolsBySession[key])
 			em.set.Tasks[key] = FoldSessionTasks(toolsBySession[key])
-			em.set.Status[key] = FoldSessionStatus(toolsBySession[key], em.terminalMainSpan(key))
+			terminal := em.terminalMainSpan(key)
+			st := FoldSessionStatus(toolsBySession[key], terminal)
+			st.Outcome = FoldSessionOutcome(em.timeline[key], terminal, st, em.now)
+			em.set.Status[key] = st
 			counts := kindFold[key]
 			if counts == nil {
 				counts = map[string]int{}
@@ -912,6 +1166,76 @@ func (em *spanEmitter) finish() {
 	}
 }
 
+// foldContext folds the llm spans' usage.context into the trace and
+// session context rollups. It runs once finish has put every trace's
+// spans in presentation order, which is what "closing" and "first" mean
+// below. Every covered session gets a rollup, an all-zero one included,
+// for the same rebuild-from-raw reason as the task fold.
+func (em *spanEmitter) foldContext() {
+	byID := make(map[string]*SpanTurn, len(em.set.Turns))
+	closing := map[*SpanTurn]int64{}
+	for _, turn := range em.set.Turns {
+		byID[turn.TraceID] = turn
+		for _, s := range turn.Spans {
+			ctx := spineContext(s)
+			if ctx == nil {
+				continue
+			}
+			turn.PeakContextTokens = max(turn.PeakContextTokens, ctx.Tokens)
+			turn.PeakContextOccupancy = max(turn.PeakContextOccupancy, ctx.Occupancy)
+			if s.CallKind == KindMain {
+				closing[turn] = ctx.Tokens
+			}
+		}
+	}
+
+	em.set.Context = map[SessionKey]ContextRollup{}
+	for _, turn := range em.set.Turns {
+		if tokens, ok := closing[turn]; ok {
+			turn.ContextGrowthTokens = tokens - closing[byID[turn.ParentTraceID]]
+		}
+		if compaction := spineContext(em.compacted[turn]); compaction != nil {
+			for _, s := range turn.Spans {
+				if ctx := spineContext(s); ctx != nil && s.CallKind == KindMain {
+					turn.ReclaimedTokens = max(compaction.Tokens-ctx.Tokens, 0)
+					break
+				}
+			}
+		}
+
+		r := em.set.Context[turn.Session]
+		r.PeakTokens = max(r.PeakTokens, turn.PeakContextTokens)
+		r.PeakOccupancy = max(r.PeakOccupancy, turn.PeakContextOccupancy)
+		r.ReclaimedTokens += turn.ReclaimedTokens
+		r.InputTokens += turn.TotalInputTokens
+		r.CacheReadTokens += turn.CacheReadTokens
+		for _, s := range turn.Spans {
+			if s.Kind == SpanKindLLM && s.CallKind == KindCompaction && s.ThreadID == "" {
+				r.Compactions++
+			}
+		}
+		em.set.Context[turn.Session] = r
+	}
+	for key, r := range em.set.Context {
+		if r.InputTokens > 0 {
+			r.CacheHitRatio = float64(r.CacheReadTokens) / float64(r.InputTokens)
+			em.set.Context[key] = r
+		}
+	}
+}
+
+// spineContext returns the usage.context of a main-thread conversation
+// or compaction llm span, nil for any other span.
+func spineContext(s *Span) *llm.ContextUsage {
+	if s == nil || s.Kind != SpanKindLLM || s.ThreadID != "" || s.Usage == nil {
+		return nil
+	}
+	if s.CallKind != KindMain && s.CallKind != KindCompaction {
+		return nil
+	}
+	return s.Usage.Context
+}
+
 // terminalMainSpan returns a session's closing main-spine llm span — the
 // last (latest trace, latest span) llm span with call_kind=main on the
 // main thread. It is the status leaf: the response the session ended on.
@@ -0,0 +1,108 @@
+package derive_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/derive"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+// codexUsageTurn builds one gpt-5.5 Codex call from role/text message
+// pairs, reporting promptTokens of prompt, cacheRead of it from cache.
+func codexUsageTurn(id int64, msgs [][2]string, reply string, promptTokens, cacheRead int) storage.RawTurnRecord {
+	items := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		blockType := "input_text"
+		if m[0] == "assistant" {
+			blockType = "output_text"
+		}
+		items = append(items, fmt.Sprintf(`{"type":"message","role":%q,"content":[{"type":%q,"text":%q}]}`, m[0], blockType, m[1]))
+	}
+	return storage.RawTurnRecord{
+		ID:               id,
+		Provider:         "openai",
+		HarnessID:        "codex",
+		HarnessSessionID: "sess-context",
+		RequestID:        fmt.Sprintf("resp_req_%d", id),
+		ReceivedAt:       time.Unix(1781218500+id, 0),
+		RawRequest: json.RawMessage(`{"model":"gpt-5.5","instructions":"You are Codex.","stream":true,` +
+			`"tools":[{"type":"function","name":"exec_command"}],"input":[` + strings.Join(items, ",") + `]}`),
+		Response: json.RawMessage(fmt.Sprintf(`{"model":"gpt-5.5","message":{"role":"assistant","content":[{"type":"text","text":%q}]},`+
+			`"stop_reason":"stop","usage":{"prompt_tokens":%d,"completion_tokens":10,"cache_read_input_tokens":%d}}`, reply, promptTokens, cacheRead)),
+		Meta: json.RawMessage(`{}`),
+	}
+}
+
+var _ = Describe("span emit context-window fold", func() {
+	u := func(text string) [2]string { return [2]string{"user", text} }
+	a := func(text string) [2]string { return [2]string{"assistant", text} }
+
+	It("sizes spans against the model window and folds growth, compaction and cache figures", func() {
+		set, err := derive.BuildDerivedSet([]storage.RawTurnRecord{
+			codexUsageTurn(1, [][2]string{u("one")}, "A1", 100_000, 0),
+			codexUsageTurn(2, [][2]string{u("one"), a("A1"), u("two")}, "A2", 200_000, 100_000),
+			codexUsageTurn(3, [][2]string{u("one"), a("A1"), u("two"), a("A2"), u("Perform a CONTEXT CHECKPOINT COMPACTION.")}, "summary", 210_000, 200_000),
+			codexUsageTurn(4, [][2]string{u("summary"), u("three")}, "A3", 20_000, 0),
+		}, "p")
+		Expect(err).NotTo(HaveOccurred())
+		spans := derive.EmitSpans(set)
+		Expect(spans.Turns).To(HaveLen(3))
+		first, second, resumed := spans.Turns[0], spans.Turns[1], spans.Turns[2]
+		Expect(resumed.Synthetic).To(Equal("post-compaction"))
+
+		var opening *derive.Span
+		for _, s := range first.Spans {
+			if s.Kind == derive.SpanKindLLM {
+				opening = s
+			}
+		}
+		Expect(opening).NotTo(BeNil())
+		Expect(opening.Usage.Context).NotTo(BeNil())
+		Expect(opening.Usage.Context.Tokens).To(Equal(int64(100_000)))
+		Expect(opening.Usage.Context.Window).To(Equal(int64(400_000)))
+		Expect(opening.Usage.Context.Occupancy).To(BeNumerically("~", 0.25))
+
+		Expect(first.PeakContextTokens).To(Equal(int64(100_000)))
+		Expect(first.ContextGrowthTokens).To(Equal(int64(100_000)))
+		// the compaction call is the fullest the conversation got
+		Expect(second.PeakContextTokens).To(Equal(int64(210_000)))
+		Expect(second.PeakContextOccupancy).To(BeNumerically("~", 0.525))
+		Expect(second.ContextGrowthTokens).To(Equal(int64(100_000)))
+		Expect(resumed.ReclaimedTokens).To(Equal(int64(190_000)))
+		Expect(resumed.ContextGrowthTokens).To(Equal(int64(-180_000)))
+
+		rollup := spans.Context[first.Session]
+		Expect(rollup.PeakTokens).To(Equal(int64(210_000)))
+		Expect(rollup.PeakOccupancy).To(BeNumerically("~", 0.525))
+		Expect(rollup.Compactions).To(Equal(1))
+		Expect(rollup.ReclaimedTokens).To(Equal(int64(190_000)))
+		Expect(rollup.InputTokens).To(Equal(int64(530_000)))
+		Expect(rollup.CacheReadTokens).To(Equal(int64(300_000)))
+		Expect(rollup.CacheHitRatio).To(BeNumerically("~", 300_000.0/530_000.0))
+	})
+
+	It("leaves occupancy unset for a model of unknown window", func() {
+		turn := codexUsageTurn(1, [][2]string{u("one")}, "A1", 1_000, 0)
+		turn.RawRequest = json.RawMessage(strings.Replace(string(turn.RawRequest), "gpt-5.5", "house-model", 1))
+		turn.Response = json.RawMessage(strings.Replace(string(turn.Response), "gpt-5.5", "house-model", 1))
+		set, err := derive.BuildDerivedSet([]storage.RawTurnRecord{turn}, "p")
+		Expect(err).NotTo(HaveOccurred())
+		spans := derive.EmitSpans(set)
+		Expect(spans.Turns).To(HaveLen(1))
+
+		trace := spans.Turns[0]
+		Expect(trace.PeakContextTokens).To(Equal(int64(1_000)))
+		Expect(trace.PeakContextOccupancy).To(BeZero())
+		for _, s := range trace.Spans {
+			if s.Kind == derive.SpanKindLLM {
+				Expect(s.Usage.Context.Window).To(BeZero())
+			}
+		}
+	})
+})
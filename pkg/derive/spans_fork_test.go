@@ -0,0 +1,116 @@
+package derive_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/derive"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+// codexTextTurn builds one Codex main call whose request carries the
+// conversation so far — alternating user and assistant text, user
+// first — and whose response is reply.
+func codexTextTurn(id int64, history []string, reply string) storage.RawTurnRecord {
+	items := make([]string, 0, len(history))
+	for i, text := range history {
+		if i%2 == 0 {
+			items = append(items, fmt.Sprintf(`{"type":"message","role":"user","content":[{"type":"input_text","text":%q}]}`, text))
+		} else {
+			items = append(items, fmt.Sprintf(`{"type":"message","role":"assistant","content":[{"type":"output_text","text":%q}]}`, text))
+		}
+	}
+	return storage.RawTurnRecord{
+		ID:               id,
+		Provider:         "openai",
+		HarnessID:        "codex",
+		HarnessSessionID: "sess-fork",
+		RequestID:        fmt.Sprintf("resp_req_%d", id),
+		ReceivedAt:       time.Unix(1781218500+id, 0),
+		RawRequest: json.RawMessage(`{"model":"gpt-5.5","instructions":"You are Codex.","stream":true,` +
+			`"tools":[{"type":"function","name":"exec_command"}],"input":[` + strings.Join(items, ",") + `]}`),
+		Response: json.RawMessage(fmt.Sprintf(`{"model":"gpt-5.5","message":{"role":"assistant","content":[{"type":"text","text":%q}]},`+
+			`"stop_reason":"stop","usage":{"prompt_tokens":10,"completion_tokens":2,"total_tokens":12}}`, reply)),
+		Meta: json.RawMessage(`{}`),
+	}
+}
+
+var _ = Describe("span emit branch structure", func() {
+	emit := func(turns ...storage.RawTurnRecord) *derive.SpanSet {
+		set, err := derive.BuildDerivedSet(turns, "p")
+		Expect(err).NotTo(HaveOccurred())
+		return derive.EmitSpans(set)
+	}
+
+	It("chains a linear session's traces and emits no fork", func() {
+		spans := emit(
+			codexTextTurn(1, []string{"one"}, "A1"),
+			codexTextTurn(2, []string{"one", "A1", "two"}, "A2"),
+			codexTextTurn(3, []string{"one", "A1", "two", "A2", "three"}, "A3"),
+		)
+		Expect(spans.Turns).To(HaveLen(3))
+		Expect(spans.Turns[0].ParentTraceID).To(BeEmpty())
+		Expect(spans.Turns[1].ParentTraceID).To(Equal(spans.Turns[0].TraceID))
+		Expect(spans.Turns[2].ParentTraceID).To(Equal(spans.Turns[1].TraceID))
+		for _, turn := range spans.Turns {
+			Expect(turn.Abandoned).To(BeFalse())
+		}
+		Expect(spans.Report.LinkKinds).NotTo(HaveKey(derive.LinkFork))
+		Expect(spans.Report.Abandoned).To(BeZero())
+	})
+
+	It("forks an edited earlier message off its branch point and abandons the displaced traces", func() {
+		spans := emit(
+			codexTextTurn(1, []string{"one"}, "A1"),
+			codexTextTurn(2, []string{"one", "A1", "two"}, "A2"),
+			codexTextTurn(3, []string{"one", "A1", "two", "A2", "three"}, "A3"),
+			// the user rewinds to "two" and sends an edited message
+			codexTextTurn(4, []string{"one", "A1", "two, edited"}, "A2'"),
+			codexTextTurn(5, []string{"one", "A1", "two, edited", "A2'", "four"}, "A4"),
+		)
+		Expect(spans.Turns).To(HaveLen(5))
+		first, second, third, rewound, after := spans.Turns[0], spans.Turns[1], spans.Turns[2], spans.Turns[3], spans.Turns[4]
+
+		Expect(rewound.UserPrompt).To(Equal("two, edited"))
+		Expect(rewound.ParentTraceID).To(Equal(first.TraceID))
+		Expect(after.ParentTraceID).To(Equal(rewound.TraceID))
+		Expect(second.Abandoned).To(BeTrue())
+		Expect(third.Abandoned).To(BeTrue())
+		Expect(first.Abandoned).To(BeFalse())
+		Expect(rewound.Abandoned).To(BeFalse())
+		Expect(after.Abandoned).To(BeFalse())
+		Expect(spans.Report.Abandoned).To(Equal(2))
+
+		Expect(spans.Report.LinkKinds).To(HaveKeyWithValue(derive.LinkFork, 1))
+		Expect(spans.Links).To(HaveLen(1))
+		fork := spans.Links[0]
+		Expect(fork.Kind).To(Equal(derive.LinkFork))
+		Expect(fork.FromTraceID).To(Equal(first.TraceID))
+		Expect(fork.FromSpanID).To(Equal("llm_resp_req_1"))
+		Expect(fork.ToTraceID).To(Equal(rewound.TraceID))
+		Expect(fork.ToSpanID).To(Equal("llm_resp_req_4"))
+	})
+
+	It("revives an abandoned branch the session rewinds back onto", func() {
+		spans := emit(
+			codexTextTurn(1, []string{"one"}, "A1"),
+			codexTextTurn(2, []string{"one", "A1", "two"}, "A2"),
+			codexTextTurn(3, []string{"one", "A1", "two, edited"}, "A2'"),
+			// back onto the original branch, continuing after A2
+			codexTextTurn(4, []string{"one", "A1", "two", "A2", "three"}, "A3"),
+		)
+		Expect(spans.Turns).To(HaveLen(4))
+		original, edited, revived := spans.Turns[1], spans.Turns[2], spans.Turns[3]
+
+		Expect(edited.ParentTraceID).To(Equal(spans.Turns[0].TraceID))
+		Expect(revived.ParentTraceID).To(Equal(original.TraceID))
+		Expect(original.Abandoned).To(BeFalse())
+		Expect(edited.Abandoned).To(BeTrue())
+		Expect(spans.Report.LinkKinds).To(HaveKeyWithValue(derive.LinkFork, 2))
+	})
+})
@@ -79,3 +79,38 @@ var _ = Describe("promptText harness-scaffolding fold", func() {
 			To(Equal("How does the derive worker debounce ingest?"))
 	})
 })
+
+var _ = Describe("llm span latency", func() {
+	source := func(meta string) *SpanSource {
+		resp := &merkle.Node{
+			Hash:   strings.Repeat("ab", 32),
+			Bucket: merkle.Bucket{Model: "claude-sonnet-4"},
+			Usage:  &llm.Usage{CompletionTokens: 12, TotalDurationNs: 900},
+		}
+		return &SpanSource{
+			RequestID: "req_1",
+			Kind:      KindMain,
+			Chain:     []*DerivedNode{{Node: resp}},
+			Latency:   latencyFromMeta([]byte(meta)),
+		}
+	}
+
+	It("copies the capture-side profile from the raw meta onto the span's usage", func() {
+		src := source(`{"thread_id":"","latency":{"time_to_first_byte_ns":200,"time_to_first_token_ns":400,"output_tokens_per_sec":55.5}}`)
+		span := (&spanEmitter{}).llmSpan(src, "", nil)
+
+		Expect(span.Usage.CompletionTokens).To(Equal(12))
+		Expect(span.Usage.Latency).To(Equal(&llm.Latency{
+			TimeToFirstByteNs:  200,
+			TimeToFirstTokenNs: 400,
+			OutputTokensPerSec: 55.5,
+		}))
+		Expect(src.Chain[0].Node.Usage.Latency).To(BeNil(), "the shared node's usage must not be written through")
+	})
+
+	It("leaves usage untouched when the capture recorded no latency", func() {
+		src := source(`{"thread_id":"agent_1"}`)
+		span := (&spanEmitter{}).llmSpan(src, "", nil)
+		Expect(span.Usage).To(BeIdenticalTo(src.Chain[0].Node.Usage))
+	})
+})
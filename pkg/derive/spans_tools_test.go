@@ -0,0 +1,103 @@
+package derive_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/derive"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+// claudeToolTurn builds one Claude main call received at second id whose
+// request carries messages (JSON message objects) and whose response,
+// after a one-second generation, issues the given tool_use blocks.
+func claudeToolTurn(id int64, messages []string, toolUses ...string) storage.RawTurnRecord {
+	content := append([]string{`{"type":"text","text":"on it"}`}, toolUses...)
+	return storage.RawTurnRecord{
+		ID:               id,
+		Source:           "wire",
+		Provider:         "anthropic",
+		HarnessID:        "claude",
+		HarnessSessionID: "sess-tools",
+		RequestID:        fmt.Sprintf("r%d", id),
+		ReceivedAt:       time.Unix(1781218500+id*10, 0),
+		RawRequest: json.RawMessage(`{"model":"claude-test","max_tokens":32000,"stream":true,` +
+			`"tools":[{"name":"Bash"}],"messages":[` + strings.Join(messages, ",") + `]}`),
+		Response: json.RawMessage(`{"model":"claude-test","message":{"role":"assistant","content":[` +
+			strings.Join(content, ",") + `]},"stop_reason":"tool_use","usage":{"total_duration_ns":1000000000}}`),
+		Meta: json.RawMessage(`{}`),
+	}
+}
+
+var _ = Describe("span emit tool analytics", func() {
+	user := `{"role":"user","content":"find the flaky test"}`
+	search := `{"type":"tool_use","tool_use_id":"toolu_1","tool_name":"mcp__github__search_issues","tool_input":{"query":"flaky"}}`
+	retry := `{"type":"tool_use","tool_use_id":"toolu_2","tool_name":"mcp__github__search_issues","tool_input":{"query":"flaky"}}`
+	shell := `{"type":"tool_use","tool_use_id":"toolu_3","tool_name":"Bash","tool_input":{"command":"cd pkg && GOFLAGS=-count=1 go test ./..."}}`
+	assistant := func(toolUse string) string {
+		return `{"role":"assistant","content":[{"type":"text","text":"on it"},` + toolUse + `]}`
+	}
+	result := func(id, output string, isError bool) string {
+		return fmt.Sprintf(`{"role":"user","content":[{"type":"tool_result","tool_use_id":%q,"content":%q,"is_error":%t}]}`, id, output, isError)
+	}
+
+	It("identifies tools, times execution and marks the retry of a failed call", func() {
+		history := []string{user}
+		turns := []storage.RawTurnRecord{claudeToolTurn(1, history, search)}
+		history = append(history, assistant(search), result("toolu_1", "rate limited", true))
+		turns = append(turns, claudeToolTurn(2, history, retry))
+		history = append(history, assistant(retry), result("toolu_2", "3 issues", false))
+		turns = append(turns, claudeToolTurn(3, history, shell))
+		history = append(history, assistant(shell), result("toolu_3", "ok", false))
+		turns = append(turns, claudeToolTurn(4, history))
+
+		set, err := derive.BuildDerivedSet(turns, "p")
+		Expect(err).NotTo(HaveOccurred())
+		spans := derive.EmitSpans(set)
+
+		tools := map[string]*derive.Span{}
+		for _, turn := range spans.Turns {
+			for _, s := range turn.Spans {
+				if s.Kind == derive.SpanKindTool {
+					tools[s.SpanID] = s
+				}
+			}
+		}
+		Expect(tools).To(HaveLen(3))
+
+		first := tools["toolu_1"]
+		Expect(first.Tool).To(Equal(&derive.ToolIdentity{Name: "search_issues", Server: "github"}))
+		Expect(first.Status).To(Equal("error"))
+		Expect(first.RetryOf).To(BeEmpty())
+		// issued when the one-second response finished, answered by the
+		// next call ten seconds after the first was received
+		Expect(first.DurationNS).To(Equal(int64(9 * time.Second)))
+
+		Expect(tools["toolu_2"].RetryOf).To(Equal("toolu_1"))
+		Expect(tools["toolu_2"].Status).To(Equal("ok"))
+
+		Expect(tools["toolu_3"].Tool).To(Equal(&derive.ToolIdentity{Name: "Bash", Command: "go"}))
+		Expect(tools["toolu_3"].RetryOf).To(BeEmpty())
+	})
+
+	It("does not read a repeat of a successful call as a retry", func() {
+		again := strings.Replace(search, "toolu_1", "toolu_2", 1)
+		history := []string{user}
+		turns := []storage.RawTurnRecord{claudeToolTurn(1, history, search)}
+		history = append(history, assistant(search), result("toolu_1", "3 issues", false))
+		turns = append(turns, claudeToolTurn(2, history, again))
+
+		set, err := derive.BuildDerivedSet(turns, "p")
+		Expect(err).NotTo(HaveOccurred())
+		for _, turn := range derive.EmitSpans(set).Turns {
+			for _, s := range turn.Spans {
+				Expect(s.RetryOf).To(BeEmpty())
+			}
+		}
+	})
+})
@@ -15,6 +15,10 @@ type SessionStatus struct {
 	HasGitActivity  bool
 	ToolResultCount int
 	ToolErrorCount  int
+
+	// Outcome is how the session ended and why (FoldSessionOutcome),
+	// folded after the status it refines.
+	Outcome SessionOutcome
 }
 
 // FoldSessionStatus reproduces the ingest-time status computation from a
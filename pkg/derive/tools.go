@@ -0,0 +1,103 @@
+package derive
+
+import (
+	"path"
+	"strings"
+)
+
+// mcpToolPrefix opens the tool names harnesses give MCP server tools:
+// mcp__<server>__<tool>.
+const mcpToolPrefix = "mcp__"
+
+// ToolIdentity is a tool span's normalized identity, the key tool
+// analytics group by. Name is the tool as the harness exposes it minus
+// any MCP namespacing; Server names the MCP server that provides it
+// ("" for built-in tools); Command is the program a shell tool ran
+// ("" for every other tool), so slow or flaky commands separate out of
+// the one Bash bucket.
+type ToolIdentity struct {
+	Name    string `json:"name"`
+	Server  string `json:"server,omitempty"`
+	Command string `json:"command,omitempty"`
+}
+
+// identifyTool normalizes a tool_use block's name and input into its
+// ToolIdentity. display is the span's name (displayToolName), which has
+// already folded Codex's exec wrappers into Bash.
+func identifyTool(display string, input map[string]any) *ToolIdentity {
+	id := &ToolIdentity{Name: display}
+	if rest, ok := strings.CutPrefix(display, mcpToolPrefix); ok {
+		if server, tool, ok := strings.Cut(rest, "__"); ok && server != "" && tool != "" {
+			id.Server, id.Name = server, tool
+		}
+	}
+	if display == "Bash" {
+		id.Command = shellProgram(input)
+	}
+	return id
+}
+
+// shellProgram returns the program a shell tool call ran: the first
+// word of its command line past any VAR=value prefixes and leading cd
+// steps, without its directory.
+func shellProgram(input map[string]any) string {
+	for _, step := range strings.Split(shellCommandLine(input), "&&") {
+		fields := strings.Fields(step)
+		for len(fields) > 0 && strings.Contains(fields[0], "=") {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 || fields[0] == "cd" {
+			continue
+		}
+		return path.Base(fields[0])
+	}
+	return ""
+}
+
+// shellCommandLine returns the command line a shell tool call ran. Codex
+// passes argv arrays, usually a shell wrapper (bash -lc '<script>'),
+// whose script is returned instead.
+func shellCommandLine(input map[string]any) string {
+	for _, key := range []string{"command", "cmd"} {
+		switch v := input[key].(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case []any:
+			argv := make([]string, 0, len(v))
+			for _, a := range v {
+				if s, ok := a.(string); ok {
+					argv = append(argv, s)
+				}
+			}
+			if n := len(argv); n >= 3 && (argv[n-2] == "-c" || argv[n-2] == "-lc") {
+				return argv[n-1]
+			}
+			if len(argv) > 0 {
+				return strings.Join(argv, " ")
+			}
+		}
+	}
+	return ""
+}
+
+// foldToolRetries marks the session's tool spans that repeat an errored
+// call: the same tool identity with the same input, issued after the
+// earlier call came back as an error. spans must be the session's tool
+// spans in event order. A repeat of a call that succeeded is a fresh
+// call, not a retry, and a chain of failures points each retry at the
+// attempt just before it.
+func foldToolRetries(spans []*Span) {
+	last := map[string]*Span{}
+	for _, s := range spans {
+		key := toolCallKey(s)
+		if key == "" {
+			continue
+		}
+		if prev := last[key]; prev != nil && prev.Status == "error" {
+			s.RetryOf = prev.SpanID
+		}
+		last[key] = s
+	}
+}
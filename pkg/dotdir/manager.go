@@ -56,6 +56,26 @@ func (m *Manager) Target(overrideDir string) (string, error) {
 	return filepath.Abs(dir)
 }
 
+// Ensure returns the Target directory, creating ~/.tapes/ when none
+// exists yet, for state that must live somewhere even on a fresh install
+// (e.g. the proxy's capture spool).
+func (m *Manager) Ensure(overrideDir string) (string, error) {
+	dir, err := m.Target(overrideDir)
+	if err != nil || dir != "" {
+		return dir, err
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting home directory: %w", err)
+	}
+	dir = filepath.Join(home, dirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("error creating tapes directory %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
 // localDirExists checks whether a .tapes/ directory exists in the current
 // working directory.
 func (m *Manager) localDirExists() bool {
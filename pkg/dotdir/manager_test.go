@@ -108,4 +108,32 @@ var _ = Describe("dotdir", func() {
 			Expect(result).To(BeEmpty())
 		})
 	})
+
+	Describe("Ensure", func() {
+		It("creates ~/.tapes when no tapes dir exists", func() {
+			emptyDir := filepath.Join(tmpDir, "empty")
+			Expect(os.Mkdir(emptyDir, 0o755)).To(Succeed())
+
+			origDir, err := os.Getwd()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.Chdir(emptyDir)).To(Succeed())
+			DeferCleanup(func() { os.Chdir(origDir) })
+
+			origHome := os.Getenv("HOME")
+			Expect(os.Setenv("HOME", emptyDir)).To(Succeed())
+			DeferCleanup(func() { os.Setenv("HOME", origHome) })
+
+			result, err := m.Ensure("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(filepath.Join(emptyDir, ".tapes")))
+			Expect(result).To(BeADirectory())
+		})
+
+		It("prefers the override dir", func() {
+			override := filepath.Join(tmpDir, "override")
+			result, err := m.Ensure(override)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(override))
+		})
+	})
 })
@@ -0,0 +1,216 @@
+// Package embedding turns text into vectors through a pluggable Embedder.
+// Two backends ship: a local Ollama server (/api/embed), which is the
+// default so embeddings never leave the machine, and any server speaking
+// the OpenAI embeddings API (/v1/embeddings) — OpenAI itself, vLLM,
+// llama.cpp's server, LM Studio and the like.
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/papercomputeco/tapes/pkg/config"
+	"github.com/papercomputeco/tapes/pkg/credentials"
+)
+
+// Provider names accepted by Config.Provider.
+const (
+	ProviderOllama = "ollama"
+	ProviderOpenAI = "openai"
+)
+
+// Defaults for Config fields left empty.
+const (
+	DefaultOllamaURL   = "http://localhost:11434"
+	DefaultOllamaModel = "embeddinggemma"
+	DefaultOpenAIURL   = "https://api.openai.com/v1"
+	DefaultOpenAIModel = "text-embedding-3-small"
+
+	defaultTimeout = 60 * time.Second
+)
+
+// Embedder maps texts to vectors, one per text and in the same order.
+// Every vector one Embedder returns has the same length.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+
+	// Model names the model the vectors come from. Vectors from different
+	// models are not comparable, so it is stored beside every vector and
+	// a change of model means re-embedding.
+	Model() string
+}
+
+// Config selects and configures an Embedder.
+type Config struct {
+	// Provider is "ollama" (default) or "openai".
+	Provider string
+
+	// URL is the server's base URL. Ollama's is the host
+	// (http://localhost:11434); an OpenAI-compatible server's includes its
+	// API prefix (https://api.openai.com/v1).
+	URL string
+
+	// Model is the embedding model to request.
+	Model string
+
+	// APIKey is sent as a bearer token to OpenAI-compatible servers.
+	APIKey string
+
+	// Client is the HTTP client to call with. Nil uses one with a 60s
+	// timeout.
+	Client *http.Client
+}
+
+// New returns the Embedder Config describes.
+func New(c Config) (Embedder, error) {
+	if c.Client == nil {
+		c.Client = &http.Client{Timeout: defaultTimeout}
+	}
+	switch strings.ToLower(c.Provider) {
+	case "", ProviderOllama:
+		return &ollama{
+			url:    strings.TrimRight(withDefault(c.URL, DefaultOllamaURL), "/"),
+			model:  withDefault(c.Model, DefaultOllamaModel),
+			client: c.Client,
+		}, nil
+	case ProviderOpenAI:
+		return &openAI{
+			url:    strings.TrimRight(withDefault(c.URL, DefaultOpenAIURL), "/"),
+			model:  withDefault(c.Model, DefaultOpenAIModel),
+			apiKey: c.APIKey,
+			client: c.Client,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown embedding provider %q (want %q or %q)", c.Provider, ProviderOllama, ProviderOpenAI)
+	}
+}
+
+// FromConfig returns the Embedder the [embedding] config section selects.
+// An OpenAI-compatible provider's key comes from OPENAI_API_KEY or, when
+// that is unset, the key stored with `tapes auth openai`.
+func FromConfig(ec config.EmbeddingConfig, configDir string) (Embedder, error) {
+	c := Config{Provider: ec.Provider, URL: ec.Target, Model: ec.Model}
+	if strings.EqualFold(ec.Provider, ProviderOpenAI) {
+		c.APIKey = os.Getenv(credentials.EnvVarForProvider(ProviderOpenAI))
+		if c.APIKey == "" {
+			key, err := credentials.APIKeyForProvider(ProviderOpenAI, configDir)
+			if err != nil {
+				return nil, fmt.Errorf("loading openai key for embeddings: %w", err)
+			}
+			c.APIKey = key
+		}
+	}
+	return New(c)
+}
+
+func withDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+// ollama calls Ollama's batch endpoint, POST /api/embed.
+type ollama struct {
+	url    string
+	model  string
+	client *http.Client
+}
+
+func (o *ollama) Model() string { return o.model }
+
+func (o *ollama) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var resp struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	body := map[string]any{"model": o.model, "input": texts}
+	if err := postJSON(ctx, o.client, o.url+"/api/embed", "", body, &resp); err != nil {
+		return nil, err
+	}
+	return checkCount(resp.Embeddings, len(texts))
+}
+
+// openAI calls an OpenAI-compatible POST /embeddings.
+type openAI struct {
+	url    string
+	model  string
+	apiKey string
+	client *http.Client
+}
+
+func (o *openAI) Model() string { return o.model }
+
+func (o *openAI) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var resp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	body := map[string]any{"model": o.model, "input": texts}
+	if err := postJSON(ctx, o.client, o.url+"/embeddings", o.apiKey, body, &resp); err != nil {
+		return nil, err
+	}
+	// The API returns one item per input carrying its input's index; the
+	// order of the list itself is not promised.
+	out := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(out) {
+			return nil, fmt.Errorf("embedding index %d out of range for %d inputs", d.Index, len(texts))
+		}
+		out[d.Index] = d.Embedding
+	}
+	return checkCount(out, len(texts))
+}
+
+// checkCount rejects a response that does not carry exactly one non-empty
+// vector per input, so a caller never stores a short batch misaligned
+// against its texts.
+func checkCount(vectors [][]float32, want int) ([][]float32, error) {
+	if len(vectors) != want {
+		return nil, fmt.Errorf("embedder returned %d vectors for %d inputs", len(vectors), want)
+	}
+	for i, v := range vectors {
+		if len(v) == 0 {
+			return nil, fmt.Errorf("embedder returned no vector for input %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+func postJSON(ctx context.Context, client *http.Client, url, token string, body, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encoding embed request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("building embed request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling embedder: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("embedder returned %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding embed response: %w", err)
+	}
+	return nil
+}
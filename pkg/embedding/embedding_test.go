@@ -0,0 +1,107 @@
+package embedding_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/embedding"
+)
+
+// embedServer answers one path with the handler the spec supplies and
+// records the decoded request body and Authorization header.
+func embedServer(path string, answer func(input []string) any) (*httptest.Server, *map[string]any, *string) {
+	var body map[string]any
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Expect(r.Method).To(Equal(http.MethodPost))
+		Expect(r.URL.Path).To(Equal(path))
+		auth = r.Header.Get("Authorization")
+		Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+		var input []string
+		for _, v := range body["input"].([]any) {
+			input = append(input, v.(string))
+		}
+		Expect(json.NewEncoder(w).Encode(answer(input))).To(Succeed())
+	}))
+	DeferCleanup(srv.Close)
+	return srv, &body, &auth
+}
+
+var _ = Describe("New", func() {
+	It("defaults to a local Ollama model", func() {
+		e, err := embedding.New(embedding.Config{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(e.Model()).To(Equal(embedding.DefaultOllamaModel))
+	})
+
+	It("rejects an unknown provider", func() {
+		_, err := embedding.New(embedding.Config{Provider: "word2vec"})
+		Expect(err).To(MatchError(ContainSubstring("unknown embedding provider")))
+	})
+})
+
+var _ = Describe("Ollama", func() {
+	It("embeds a batch through /api/embed", func() {
+		srv, body, _ := embedServer("/api/embed", func(input []string) any {
+			out := [][]float32{}
+			for i := range input {
+				out = append(out, []float32{float32(i), 1})
+			}
+			return map[string]any{"embeddings": out}
+		})
+		e, err := embedding.New(embedding.Config{URL: srv.URL, Model: "all-minilm"})
+		Expect(err).NotTo(HaveOccurred())
+
+		vectors, err := e.Embed(context.Background(), []string{"a", "b"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vectors).To(Equal([][]float32{{0, 1}, {1, 1}}))
+		Expect((*body)["model"]).To(Equal("all-minilm"))
+	})
+
+	It("fails a response that is short a vector", func() {
+		srv, _, _ := embedServer("/api/embed", func([]string) any {
+			return map[string]any{"embeddings": [][]float32{{1}}}
+		})
+		e, err := embedding.New(embedding.Config{URL: srv.URL})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = e.Embed(context.Background(), []string{"a", "b"})
+		Expect(err).To(MatchError(ContainSubstring("1 vectors for 2 inputs")))
+	})
+})
+
+var _ = Describe("OpenAI-compatible", func() {
+	It("places vectors by their index and sends the key", func() {
+		srv, _, auth := embedServer("/v1/embeddings", func([]string) any {
+			return map[string]any{"data": []map[string]any{
+				{"index": 1, "embedding": []float32{2}},
+				{"index": 0, "embedding": []float32{1}},
+			}}
+		})
+		e, err := embedding.New(embedding.Config{Provider: "openai", URL: srv.URL + "/v1", APIKey: "sk-test"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(e.Model()).To(Equal(embedding.DefaultOpenAIModel))
+
+		vectors, err := e.Embed(context.Background(), []string{"a", "b"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vectors).To(Equal([][]float32{{1}, {2}}))
+		Expect(*auth).To(Equal("Bearer sk-test"))
+	})
+
+	It("surfaces a server error", func() {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, "model not loaded", http.StatusServiceUnavailable)
+		}))
+		DeferCleanup(srv.Close)
+		e, err := embedding.New(embedding.Config{Provider: "openai", URL: srv.URL})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = e.Embed(context.Background(), []string{"a"})
+		Expect(err).To(MatchError(ContainSubstring("503: model not loaded")))
+	})
+})
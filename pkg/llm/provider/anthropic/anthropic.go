@@ -227,6 +227,11 @@ func (p *Provider) ParseResponse(payload []byte) (*llm.ChatResponse, error) {
 			TotalTokens:              totalInput + resp.Usage.OutputTokens,
 			CacheCreationInputTokens: resp.Usage.CacheCreationInputTokens,
 			CacheReadInputTokens:     resp.Usage.CacheReadInputTokens,
+			ServerToolUse:            llm.ServerToolUseCounts(resp.Usage.ServerToolUse),
+			ServiceTier:              resp.Usage.ServiceTier,
+		}
+		if resp.Usage.CacheCreation != nil {
+			usage.CacheCreation1hInputTokens = resp.Usage.CacheCreation.Ephemeral1hInputTokens
 		}
 	}
 
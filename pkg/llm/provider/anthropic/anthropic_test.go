@@ -396,6 +396,30 @@ var _ = Describe("Anthropic Provider", func() {
 				Expect(resp.Usage.CompletionTokens).To(Equal(50))
 				Expect(resp.Usage.TotalTokens).To(Equal(150))
 			})
+
+			It("parses cache TTL and server tool counts", func() {
+				payload := []byte(`{
+					"id": "msg_123",
+					"type": "message",
+					"role": "assistant",
+					"content": [{"type": "text", "text": "Hi"}],
+					"model": "claude-sonnet-4-5",
+					"stop_reason": "end_turn",
+					"usage": {
+						"input_tokens": 10,
+						"output_tokens": 50,
+						"cache_creation_input_tokens": 300,
+						"cache_creation": {"ephemeral_5m_input_tokens": 100, "ephemeral_1h_input_tokens": 200},
+						"server_tool_use": {"web_search_requests": 2, "web_fetch_requests": 0, "note": "x"}
+					}
+				}`)
+
+				resp, err := p.ParseResponse(payload)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Usage.PromptTokens).To(Equal(310))
+				Expect(resp.Usage.CacheCreation1hInputTokens).To(Equal(200))
+				Expect(resp.Usage.ServerToolUse).To(Equal(map[string]int{"web_search": 2}))
+			})
 		})
 
 		Context("with tool_use response", func() {
@@ -63,4 +63,14 @@ type anthropicUsage struct {
 	OutputTokens             int `json:"output_tokens"`
 	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
 	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+
+	CacheCreation *anthropicCacheCreation `json:"cache_creation,omitempty"`
+	ServerToolUse json.RawMessage         `json:"server_tool_use,omitempty"`
+	ServiceTier   string                  `json:"service_tier,omitempty"`
+}
+
+// anthropicCacheCreation splits cache_creation_input_tokens by TTL.
+type anthropicCacheCreation struct {
+	Ephemeral5mInputTokens int `json:"ephemeral_5m_input_tokens"`
+	Ephemeral1hInputTokens int `json:"ephemeral_1h_input_tokens"`
 }
@@ -201,6 +201,10 @@ func (o *Provider) ParseResponse(payload []byte) (*llm.ChatResponse, error) {
 		if resp.Usage.PromptTokensDetails != nil {
 			usage.CacheReadInputTokens = resp.Usage.PromptTokensDetails.CachedTokens
 		}
+		if resp.Usage.CompletionTokensDetails != nil {
+			usage.ReasoningTokens = resp.Usage.CompletionTokensDetails.ReasoningTokens
+		}
+		usage.ServiceTier = resp.ServiceTier
 	}
 
 	result := &llm.ChatResponse{
@@ -300,6 +300,28 @@ var _ = Describe("OpenAI Provider", func() {
 				Expect(resp.Usage.CompletionTokens).To(Equal(50))
 				Expect(resp.Usage.TotalTokens).To(Equal(150))
 			})
+
+			It("parses cached and reasoning token details", func() {
+				payload := []byte(`{
+					"id": "chatcmpl-123",
+					"object": "chat.completion",
+					"created": 1677858242,
+					"model": "o3",
+					"choices": [{"index": 0, "message": {"role": "assistant", "content": "Hi"}, "finish_reason": "stop"}],
+					"usage": {
+						"prompt_tokens": 100,
+						"completion_tokens": 50,
+						"total_tokens": 150,
+						"prompt_tokens_details": {"cached_tokens": 64},
+						"completion_tokens_details": {"reasoning_tokens": 32}
+					}
+				}`)
+
+				resp, err := p.ParseResponse(payload)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Usage.CacheReadInputTokens).To(Equal(64))
+				Expect(resp.Usage.ReasoningTokens).To(Equal(32))
+			})
 		})
 
 		Context("with tool calls in response", func() {
@@ -47,7 +47,8 @@ type openaiResponse struct {
 		Message      openaiMessage `json:"message"`
 		FinishReason string        `json:"finish_reason"`
 	} `json:"choices"`
-	Usage *openaiUsage `json:"usage,omitempty"`
+	Usage       *openaiUsage `json:"usage,omitempty"`
+	ServiceTier string       `json:"service_tier,omitempty"`
 }
 
 type openaiUsage struct {
@@ -55,8 +56,14 @@ type openaiUsage struct {
 	CompletionTokens    int                        `json:"completion_tokens"`
 	TotalTokens         int                        `json:"total_tokens"`
 	PromptTokensDetails *openaiPromptTokensDetails `json:"prompt_tokens_details,omitempty"`
+
+	CompletionTokensDetails *openaiCompletionTokensDetails `json:"completion_tokens_details,omitempty"`
 }
 
 type openaiPromptTokensDetails struct {
 	CachedTokens int `json:"cached_tokens"`
 }
+
+type openaiCompletionTokensDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens"`
+}
@@ -2,6 +2,7 @@ package llm
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 )
 
@@ -46,6 +47,28 @@ type Usage struct {
 	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
 	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
 
+	// CacheCreation1hInputTokens is the part of CacheCreationInputTokens
+	// written with Anthropic's one-hour TTL, which bills at a higher rate
+	// than the default five-minute writes that make up the rest.
+	CacheCreation1hInputTokens int `json:"cache_creation_1h_input_tokens,omitempty"`
+
+	// ReasoningTokens is the part of CompletionTokens the model spent on
+	// hidden reasoning (OpenAI o-series and GPT-5). It is already included in
+	// CompletionTokens and bills at the output rate; it is broken out so it
+	// can be reported.
+	ReasoningTokens int `json:"reasoning_tokens,omitempty"`
+
+	// ServerToolUse counts provider-executed tool calls that bill per
+	// request rather than per token, keyed by tool name ("web_search",
+	// "web_fetch"). See ServerToolUseCounts for the key convention.
+	ServerToolUse map[string]int `json:"server_tool_use,omitempty"`
+
+	// ServiceTier is the provider's processing tier for the call as its
+	// response reports it: Anthropic "standard", "priority" or "batch";
+	// OpenAI "default", "flex", "priority" or "scale". Tiers bill at a
+	// multiple of the standard rates (see sessions.Pricing.Tiers).
+	ServiceTier string `json:"service_tier,omitempty"`
+
 	// TotalDurationNs is the proxy-measured wall-clock time, in nanoseconds,
 	// from when the proxy received the client request to when the upstream
 	// response was fully assembled. Set uniformly by the proxy across providers
@@ -58,4 +81,77 @@ type Usage struct {
 	// nanoseconds. Populated by providers that surface it (currently Ollama
 	// only); left at zero otherwise.
 	PromptDurationNs int64 `json:"prompt_duration_ns,omitempty"`
+
+	// Latency is the call's capture-side latency profile. It is never read
+	// off the provider's response: capture adapters record it in the raw
+	// turn's meta block, and the deriver copies it onto the llm span's usage.
+	Latency *Latency `json:"latency,omitempty"`
+
+	// Context is how full the model's context window was for the call. Like
+	// Latency it is never read off the provider's response: the deriver
+	// fills it on the llm span from the prompt size and the model's window.
+	Context *ContextUsage `json:"context,omitempty"`
+}
+
+// ContextUsage is a call's context-window occupancy.
+type ContextUsage struct {
+	// Tokens is the prompt the call sent. PromptTokens already counts the
+	// cached part of the prompt (reads and writes), so it is the whole
+	// context the model held.
+	Tokens int64 `json:"tokens"`
+
+	// Window is the model's context limit, zero when the model is not in
+	// the capability table.
+	Window int64 `json:"window,omitempty"`
+
+	// Occupancy is Tokens over Window, zero when Window is unknown.
+	Occupancy float64 `json:"occupancy,omitempty"`
+}
+
+// Latency separates how long a call waited from how fast it generated. The
+// durations are measured from when the capture adapter received the request.
+// Token timings come from streamed frames, so a non-streaming call carries
+// TimeToFirstByteNs alone.
+type Latency struct {
+	// TimeToFirstByteNs is the time until the upstream's response headers
+	// arrived: queueing and prompt processing, before any output.
+	TimeToFirstByteNs int64 `json:"time_to_first_byte_ns,omitempty"`
+
+	// TimeToFirstTokenNs is the time until the first frame carrying
+	// generated content (text, reasoning, or a tool call).
+	TimeToFirstTokenNs int64 `json:"time_to_first_token_ns,omitempty"`
+
+	// InterTokenP50Ns and InterTokenP95Ns are percentiles of the gaps
+	// between consecutive content frames.
+	InterTokenP50Ns int64 `json:"inter_token_p50_ns,omitempty"`
+	InterTokenP95Ns int64 `json:"inter_token_p95_ns,omitempty"`
+
+	// OutputTokensPerSec is the provider-reported output token count over
+	// the time from the first content frame to the last.
+	OutputTokensPerSec float64 `json:"output_tokens_per_sec,omitempty"`
+}
+
+// ServerToolUseCounts decodes a provider's per-request tool counters (a JSON
+// object such as Anthropic's usage.server_tool_use) into the
+// Usage.ServerToolUse convention: the "_requests" suffix is dropped from each
+// key (web_search_requests becomes web_search), and zero or non-numeric
+// values are skipped so a field the provider adds later cannot fail the
+// parse. Returns nil when nothing was used.
+func ServerToolUseCounts(raw json.RawMessage) map[string]int {
+	var fields map[string]json.RawMessage
+	if len(raw) == 0 || json.Unmarshal(raw, &fields) != nil {
+		return nil
+	}
+	var out map[string]int
+	for key, value := range fields {
+		var n int
+		if json.Unmarshal(value, &n) != nil || n <= 0 {
+			continue
+		}
+		if out == nil {
+			out = map[string]int{}
+		}
+		out[strings.TrimSuffix(key, "_requests")] += n
+	}
+	return out
 }
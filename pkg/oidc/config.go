@@ -0,0 +1,27 @@
+package oidc
+
+import (
+	"github.com/papercomputeco/tapes/pkg/config"
+)
+
+// FromConfig builds the Verifier an [oidc] config section describes, or nil
+// when the section does not enable verification. The section is
+// re-validated here so a hand-built value fails loudly.
+func FromConfig(oc config.OIDCConfig) (*Verifier, error) {
+	if err := config.ValidateOIDCConfig(oc); err != nil {
+		return nil, err
+	}
+	if !oc.Enabled() {
+		return nil, nil
+	}
+
+	return NewVerifier(Config{
+		JWKSURL:      oc.JWKSURL,
+		Issuer:       oc.Issuer,
+		Audience:     oc.Audience,
+		SubjectClaim: oc.SubjectClaim,
+		AdminClaim:   oc.AdminClaim,
+		AdminValue:   oc.AdminValue,
+		OrgClaim:     oc.OrgClaim,
+	})
+}
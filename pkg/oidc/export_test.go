@@ -0,0 +1,9 @@
+package oidc
+
+import "time"
+
+// SetNowForTest replaces the verifier's clock, which drives both the token
+// validity checks and the key set's refresh schedule.
+func SetNowForTest(v *Verifier, now func() time.Time) {
+	v.now = now
+}
@@ -0,0 +1,91 @@
+package oidc
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/papercomputeco/tapes/pkg/llm"
+)
+
+// identityLocal is the fiber.Ctx local a verified Identity is stored under.
+const identityLocal = "tapes.oidc_identity"
+
+// Middleware returns a handler that requires a valid JWT in header and stores
+// the verified Identity for FromCtx. For Authorization the Bearer scheme is
+// required; any other header may carry the token bare or Bearer-prefixed.
+//
+// A missing or rejected token is 401 with a Bearer challenge. A verifier that
+// cannot reach its key set is 503: the caller did nothing wrong, and a 401
+// would send a well-behaved client off to re-authenticate for nothing.
+func Middleware(v *Verifier, header string, log *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token, ok := tokenFromHeader(header, c.Get(header))
+		if !ok {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="tapes"`)
+
+			return c.Status(fiber.StatusUnauthorized).JSON(llm.ErrorResponse{Error: "missing identity token in " + header})
+		}
+
+		identity, err := v.Verify(c.UserContext(), token)
+		if err != nil {
+			return Reject(c, err, log)
+		}
+
+		SetIdentity(c, identity)
+
+		return c.Next()
+	}
+}
+
+// Reject writes the response for a Verify error: 401 for a token the
+// verifier refused, 503 for a verifier that could not decide.
+func Reject(c *fiber.Ctx, err error, log *slog.Logger) error {
+	if errors.Is(err, ErrInvalidToken) {
+		c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="tapes", error="invalid_token"`)
+
+		return c.Status(fiber.StatusUnauthorized).JSON(llm.ErrorResponse{Error: err.Error()})
+	}
+
+	log.Error("verify identity token", "error", err)
+
+	return c.Status(fiber.StatusServiceUnavailable).JSON(llm.ErrorResponse{Error: "identity provider unavailable"})
+}
+
+// SetIdentity records a verified identity on the request.
+func SetIdentity(c *fiber.Ctx, identity *Identity) {
+	c.Locals(identityLocal, identity)
+}
+
+// FromCtx returns the identity Middleware verified for this request, or nil
+// when the request was not verified.
+func FromCtx(c *fiber.Ctx) *Identity {
+	identity, _ := c.Locals(identityLocal).(*Identity)
+	return identity
+}
+
+// BearerToken extracts the credential from an Authorization header value.
+// The scheme is case-insensitive, as RFC 9110 specifies.
+func BearerToken(value string) (string, bool) {
+	scheme, credential, ok := strings.Cut(strings.TrimSpace(value), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	credential = strings.TrimSpace(credential)
+
+	return credential, credential != ""
+}
+
+func tokenFromHeader(header, value string) (string, bool) {
+	if strings.EqualFold(header, fiber.HeaderAuthorization) {
+		return BearerToken(value)
+	}
+	if token, ok := BearerToken(value); ok {
+		return token, true
+	}
+	value = strings.TrimSpace(value)
+
+	return value, value != ""
+}
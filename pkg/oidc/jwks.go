@@ -0,0 +1,250 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// minRefetchInterval rate-limits the refetch an unknown kid triggers. An
+// issuer that rotated its key gets picked up on the first token signed with
+// the new one; a stream of tokens naming a kid that does not exist costs one
+// fetch per interval, not one per token.
+const minRefetchInterval = 30 * time.Second
+
+// maxJWKSBytes bounds the JWKS document read. Real key sets are a few KiB.
+const maxJWKSBytes = 1 << 20
+
+// jwk is one parsed key of the set.
+type jwk struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// keySet caches an issuer's JWKS and refetches it when it goes stale or a
+// token names a key it does not hold.
+type keySet struct {
+	url     string
+	client  *http.Client
+	refresh time.Duration
+	now     func() time.Time
+
+	mu        sync.Mutex
+	keys      []jwk
+	fetchedAt time.Time
+	// attemptedAt is the last fetch attempt, successful or not; it is what
+	// rate-limits refetches, so a failing issuer is not hammered either.
+	attemptedAt time.Time
+}
+
+func newKeySet(url string, client *http.Client, refresh time.Duration, now func() time.Time) *keySet {
+	return &keySet{url: url, client: client, refresh: refresh, now: now}
+}
+
+// lookup returns the key a token with this kid and alg was signed with.
+//
+// A stale set is refreshed first; if that fetch fails the stale keys keep
+// serving, since an issuer outage should not log everyone out. A kid the set
+// does not hold triggers one rate-limited refetch for key rotation.
+func (ks *keySet) lookup(ctx context.Context, kid, alg string) (crypto.PublicKey, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	var fetchErr error
+	if ks.keys == nil || ks.now().Sub(ks.fetchedAt) >= ks.refresh {
+		fetchErr = ks.fetch(ctx)
+	}
+
+	if key, ok := ks.find(kid, alg); ok {
+		return key, nil
+	}
+
+	if fetchErr == nil && ks.now().Sub(ks.attemptedAt) >= minRefetchInterval {
+		fetchErr = ks.fetch(ctx)
+		if key, ok := ks.find(kid, alg); ok {
+			return key, nil
+		}
+	}
+
+	if ks.keys == nil && fetchErr != nil {
+		return nil, fetchErr
+	}
+
+	return nil, fmt.Errorf("%w: no %s key with kid %q in the JWKS", ErrInvalidToken, alg, kid)
+}
+
+// find picks the key for kid and alg. A token without a kid matches only
+// when exactly one key could have signed it.
+func (ks *keySet) find(kid, alg string) (crypto.PublicKey, bool) {
+	var match crypto.PublicKey
+	matches := 0
+	for _, k := range ks.keys {
+		if k.alg != "" && k.alg != alg {
+			continue
+		}
+		if kid != "" {
+			if k.kid == kid {
+				return k.key, true
+			}
+			continue
+		}
+		match = k.key
+		matches++
+	}
+
+	return match, matches == 1
+}
+
+// jwksDocument is the wire form of a JSON Web Key Set.
+type jwksDocument struct {
+	Keys []jwksKey `json:"keys"`
+}
+
+type jwksKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetch replaces the cached keys with the issuer's current set. Keys of a
+// type this package cannot verify with, or marked for encryption, are
+// skipped rather than failing the set.
+func (ks *keySet) fetch(ctx context.Context) error {
+	ks.attemptedAt = ks.now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.url, nil)
+	if err != nil {
+		return fmt.Errorf("oidc: build JWKS request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := ks.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("oidc: fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc: fetch JWKS: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSBytes))
+	if err != nil {
+		return fmt.Errorf("oidc: read JWKS: %w", err)
+	}
+
+	var doc jwksDocument
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("oidc: decode JWKS: %w", err)
+	}
+
+	keys := make([]jwk, 0, len(doc.Keys))
+	for _, raw := range doc.Keys {
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+		key, err := parseKey(raw)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, jwk{kid: raw.Kid, alg: raw.Alg, key: key})
+	}
+	if len(keys) == 0 {
+		return errors.New("oidc: JWKS holds no usable signing keys")
+	}
+
+	ks.keys = keys
+	ks.fetchedAt = ks.attemptedAt
+
+	return nil
+}
+
+func parseKey(raw jwksKey) (crypto.PublicKey, error) {
+	switch raw.Kty {
+	case "RSA":
+		n, err := decodeBigInt(raw.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(raw.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("unsupported RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch raw.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", raw.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(raw.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(raw.Y)
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, errors.New("malformed EC coordinates")
+		}
+		point := append(append([]byte{4}, x...), y...)
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+
+	case "OKP":
+		if raw.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", raw.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(raw.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("malformed Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", raw.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, errors.New("empty integer")
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
@@ -0,0 +1,379 @@
+// Package oidc verifies OIDC-issued JWTs in process, against the issuer's
+// published JWKS, for deployments with no gateway in front of tapes to do it.
+//
+// A gateway deployment verifies the caller at the edge and hands tapes the
+// result as the x-paper-auth-subject header. Without one, that header is just
+// something a client typed. The Verifier closes that gap: the services check
+// the bearer JWT themselves and take the subject from its verified claims.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidToken is wrapped by every rejection of the token itself: bad
+// shape, bad signature, wrong issuer or audience, expired, or no subject.
+// Errors that do not wrap it are the verifier's own failures (the JWKS could
+// not be fetched) and say nothing about the caller.
+var ErrInvalidToken = errors.New("invalid token")
+
+const (
+	// DefaultSubjectClaim is the claim mapped to auth_subject when the
+	// config names none.
+	DefaultSubjectClaim = "sub"
+
+	// DefaultRefreshInterval is how long a fetched JWKS is trusted before
+	// it is fetched again.
+	DefaultRefreshInterval = time.Hour
+
+	// DefaultLeeway absorbs clock skew between tapes and the issuer when
+	// checking exp and nbf.
+	DefaultLeeway = time.Minute
+)
+
+// Config configures a Verifier.
+type Config struct {
+	// JWKSURL is the issuer's JSON Web Key Set document. Required.
+	JWKSURL string
+
+	// Issuer, when set, must equal the token's iss claim.
+	Issuer string
+
+	// Audience, when set, must be one of the token's aud values.
+	Audience string
+
+	// SubjectClaim names the claim that becomes auth_subject: "sub" by
+	// default, or e.g. "email". A dotted path reaches into nested objects.
+	SubjectClaim string
+
+	// AdminClaim names the claim that marks a caller as an admin; empty
+	// means no caller is. Dotted paths work as for SubjectClaim.
+	AdminClaim string
+
+	// AdminValue is the value AdminClaim must carry: equal to it, or
+	// contained in it when the claim is an array. Empty means the claim
+	// must be boolean true.
+	AdminValue string
+
+	// OrgClaim names the claim carrying the caller's tapes org, a UUID, in
+	// a multi-tenant deployment. Empty means tokens carry no org. When set,
+	// a token without a valid org is rejected. Dotted paths work as for
+	// SubjectClaim.
+	OrgClaim string
+
+	// RefreshInterval bounds how long a fetched JWKS is used before it is
+	// fetched again. Zero selects DefaultRefreshInterval.
+	RefreshInterval time.Duration
+
+	// Leeway is the clock skew tolerated on exp and nbf. Zero selects
+	// DefaultLeeway.
+	Leeway time.Duration
+
+	// Client fetches the JWKS. Nil selects a client with a 10s timeout.
+	Client *http.Client
+}
+
+// Identity is what a verified token says about its caller.
+type Identity struct {
+	// Subject is the configured subject claim's value: what ingest stamps
+	// as auth_subject and what reads are restricted to.
+	Subject string
+
+	// Admin reports whether the token carries the configured admin claim.
+	Admin bool
+
+	// OrgID is the configured org claim's value, or empty when the
+	// verifier maps no org claim.
+	OrgID string
+
+	// Claims is the token's full verified claim set.
+	Claims map[string]any
+}
+
+// Verifier checks JWTs against one issuer's key set. It is safe for
+// concurrent use.
+type Verifier struct {
+	config Config
+	keys   *keySet
+	now    func() time.Time
+}
+
+// NewVerifier returns a Verifier for config. The JWKS is fetched lazily, on
+// the first token that needs it, so a verifier can be built while the issuer
+// is still coming up.
+func NewVerifier(config Config) (*Verifier, error) {
+	if config.JWKSURL == "" {
+		return nil, errors.New("oidc: a JWKS URL is required")
+	}
+	if !strings.HasPrefix(config.JWKSURL, "https://") && !strings.HasPrefix(config.JWKSURL, "http://") {
+		return nil, fmt.Errorf("oidc: JWKS URL %q is not an http(s) URL", config.JWKSURL)
+	}
+	if config.SubjectClaim == "" {
+		config.SubjectClaim = DefaultSubjectClaim
+	}
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = DefaultRefreshInterval
+	}
+	if config.Leeway <= 0 {
+		config.Leeway = DefaultLeeway
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	v := &Verifier{config: config, now: time.Now}
+	v.keys = newKeySet(config.JWKSURL, config.Client, config.RefreshInterval, func() time.Time { return v.now() })
+
+	return v, nil
+}
+
+// jwtHeader is the JOSE header fields verification reads.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// Verify checks token's signature, issuer, audience, and validity window and
+// returns the identity it carries.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: not a compact JWS", ErrInvalidToken)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %w", ErrInvalidToken, err)
+	}
+	verify, ok := algorithms[header.Alg]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %w", ErrInvalidToken, err)
+	}
+
+	key, err := v.keys.lookup(ctx, header.Kid, header.Alg)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %w", ErrInvalidToken, err)
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+
+	subject, _ := claimValue(claims, v.config.SubjectClaim).(string)
+	if subject == "" {
+		return nil, fmt.Errorf("%w: no %q claim", ErrInvalidToken, v.config.SubjectClaim)
+	}
+
+	var orgID string
+	if v.config.OrgClaim != "" {
+		orgID, _ = claimValue(claims, v.config.OrgClaim).(string)
+		if _, err := uuid.Parse(orgID); err != nil {
+			return nil, fmt.Errorf("%w: %q claim is not an org id", ErrInvalidToken, v.config.OrgClaim)
+		}
+	}
+
+	return &Identity{
+		Subject: subject,
+		Admin:   v.isAdmin(claims),
+		OrgID:   orgID,
+		Claims:  claims,
+	}, nil
+}
+
+// checkClaims enforces the registered claims: exp is required, nbf is
+// honored when present, and iss and aud are matched when configured.
+func (v *Verifier) checkClaims(claims map[string]any) error {
+	now := v.now()
+
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return fmt.Errorf("%w: no exp claim", ErrInvalidToken)
+	}
+	if !now.Before(exp.Add(v.config.Leeway)) {
+		return fmt.Errorf("%w: expired at %s", ErrInvalidToken, exp.Format(time.RFC3339))
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(v.config.Leeway).Before(nbf) {
+		return fmt.Errorf("%w: not valid before %s", ErrInvalidToken, nbf.Format(time.RFC3339))
+	}
+
+	if v.config.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.config.Issuer {
+			return fmt.Errorf("%w: issuer %q is not %q", ErrInvalidToken, iss, v.config.Issuer)
+		}
+	}
+
+	if v.config.Audience != "" && !containsValue(claims["aud"], v.config.Audience) {
+		return fmt.Errorf("%w: audience does not include %q", ErrInvalidToken, v.config.Audience)
+	}
+
+	return nil
+}
+
+// isAdmin reports whether claims carry the configured admin claim.
+func (v *Verifier) isAdmin(claims map[string]any) bool {
+	if v.config.AdminClaim == "" {
+		return false
+	}
+	value := claimValue(claims, v.config.AdminClaim)
+	if v.config.AdminValue == "" {
+		admin, _ := value.(bool)
+		return admin
+	}
+
+	return containsValue(value, v.config.AdminValue)
+}
+
+// claimValue resolves a dotted claim path, returning nil when any step is
+// missing or not an object.
+func claimValue(claims map[string]any, path string) any {
+	var value any = claims
+	for _, step := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[step]
+	}
+
+	return value
+}
+
+// containsValue reports whether claim is want, or an array holding want.
+func containsValue(claim any, want string) bool {
+	switch value := claim.(type) {
+	case string:
+		return value == want
+	case []any:
+		for _, item := range value {
+			if s, ok := item.(string); ok && s == want {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// numericDate reads a JWT NumericDate claim.
+func numericDate(claim any) (time.Time, bool) {
+	seconds, ok := claim.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(int64(seconds), 0), true
+}
+
+func decodeSegment(segment string, into any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, into)
+}
+
+// algorithms maps each accepted JWS alg to its signature check. "none" and
+// the HMAC family are absent on purpose: a public JWKS cannot vouch for a
+// shared secret.
+var algorithms = map[string]func(key crypto.PublicKey, signed, signature []byte) error{
+	"RS256": rsaPKCS1(crypto.SHA256, sha256.New),
+	"RS384": rsaPKCS1(crypto.SHA384, sha512.New384),
+	"RS512": rsaPKCS1(crypto.SHA512, sha512.New),
+	"PS256": rsaPSS(crypto.SHA256, sha256.New),
+	"PS384": rsaPSS(crypto.SHA384, sha512.New384),
+	"PS512": rsaPSS(crypto.SHA512, sha512.New),
+	"ES256": ecdsaP(sha256.New, 32),
+	"ES384": ecdsaP(sha512.New384, 48),
+	"ES512": ecdsaP(sha512.New, 66),
+	"EdDSA": verifyEd25519,
+}
+
+func digest(newHash func() hash.Hash, signed []byte) []byte {
+	h := newHash()
+	h.Write(signed)
+	return h.Sum(nil)
+}
+
+func rsaPKCS1(id crypto.Hash, newHash func() hash.Hash) func(crypto.PublicKey, []byte, []byte) error {
+	return func(key crypto.PublicKey, signed, signature []byte) error {
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("key is not an RSA key")
+		}
+		return rsa.VerifyPKCS1v15(pub, id, digest(newHash, signed), signature)
+	}
+}
+
+func rsaPSS(id crypto.Hash, newHash func() hash.Hash) func(crypto.PublicKey, []byte, []byte) error {
+	return func(key crypto.PublicKey, signed, signature []byte) error {
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("key is not an RSA key")
+		}
+		return rsa.VerifyPSS(pub, id, digest(newHash, signed), signature, nil)
+	}
+}
+
+// ecdsaP verifies a JWS ECDSA signature, which is the fixed-width r||s
+// concatenation rather than the ASN.1 form crypto/ecdsa produces.
+func ecdsaP(newHash func() hash.Hash, size int) func(crypto.PublicKey, []byte, []byte) error {
+	return func(key crypto.PublicKey, signed, signature []byte) error {
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("key is not an EC key")
+		}
+		if (pub.Curve.Params().BitSize+7)/8 != size {
+			return errors.New("key curve does not match alg")
+		}
+		if len(signature) != 2*size {
+			return errors.New("malformed ECDSA signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest(newHash, signed), r, s) {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	}
+}
+
+func verifyEd25519(key crypto.PublicKey, signed, signature []byte) error {
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return errors.New("key is not an Ed25519 key")
+	}
+	if !ed25519.Verify(pub, signed, signature) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/oidc"
+	"github.com/papercomputeco/tapes/pkg/oidc/oidctest"
+)
+
+var _ = Describe("Verifier", func() {
+	var (
+		ctx    context.Context
+		issuer *oidctest.Issuer
+		config oidc.Config
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		issuer = oidctest.NewIssuer()
+		DeferCleanup(issuer.Close)
+		config = oidc.Config{
+			JWKSURL:  issuer.JWKSURL(),
+			Issuer:   "https://idp.example",
+			Audience: "tapes",
+		}
+	})
+
+	newVerifier := func() *oidc.Verifier {
+		v, err := oidc.NewVerifier(config)
+		Expect(err).NotTo(HaveOccurred())
+		return v
+	}
+
+	claims := func(extra map[string]any) map[string]any {
+		c := map[string]any{"iss": "https://idp.example", "aud": "tapes", "sub": "user-1"}
+		for k, v := range extra {
+			c[k] = v
+		}
+		return c
+	}
+
+	It("requires an http(s) JWKS URL", func() {
+		_, err := oidc.NewVerifier(oidc.Config{})
+		Expect(err).To(HaveOccurred())
+		_, err = oidc.NewVerifier(oidc.Config{JWKSURL: "file:///etc/jwks.json"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("verifies a signed token and returns its subject", func() {
+		identity, err := newVerifier().Verify(ctx, issuer.Token(claims(nil)))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(identity.Subject).To(Equal("user-1"))
+		Expect(identity.Admin).To(BeFalse())
+	})
+
+	It("maps a configured, nested subject claim", func() {
+		config.SubjectClaim = "profile.email"
+		token := issuer.Token(claims(map[string]any{"profile": map[string]any{"email": "ada@example.com"}}))
+
+		identity, err := newVerifier().Verify(ctx, token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(identity.Subject).To(Equal("ada@example.com"))
+	})
+
+	It("rejects a token without the subject claim", func() {
+		config.SubjectClaim = "email"
+		_, err := newVerifier().Verify(ctx, issuer.Token(claims(nil)))
+		Expect(err).To(MatchError(oidc.ErrInvalidToken))
+	})
+
+	It("requires a UUID org claim when one is configured", func() {
+		config.OrgClaim = "org.id"
+		v := newVerifier()
+
+		identity, err := v.Verify(ctx, issuer.Token(claims(map[string]any{
+			"org": map[string]any{"id": "11111111-1111-1111-1111-111111111111"},
+		})))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(identity.OrgID).To(Equal("11111111-1111-1111-1111-111111111111"))
+
+		_, err = v.Verify(ctx, issuer.Token(claims(map[string]any{"org": map[string]any{"id": "acme"}})))
+		Expect(err).To(MatchError(oidc.ErrInvalidToken))
+		_, err = v.Verify(ctx, issuer.Token(claims(nil)))
+		Expect(err).To(MatchError(oidc.ErrInvalidToken))
+	})
+
+	Describe("admin claim", func() {
+		It("matches a value inside an array claim", func() {
+			config.AdminClaim = "groups"
+			config.AdminValue = "tapes-admins"
+			v := newVerifier()
+
+			identity, err := v.Verify(ctx, issuer.Token(claims(map[string]any{"groups": []string{"eng", "tapes-admins"}})))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(identity.Admin).To(BeTrue())
+
+			identity, err = v.Verify(ctx, issuer.Token(claims(map[string]any{"groups": []string{"eng"}})))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(identity.Admin).To(BeFalse())
+		})
+
+		It("requires boolean true when no value is configured", func() {
+			config.AdminClaim = "tapes_admin"
+			v := newVerifier()
+
+			identity, err := v.Verify(ctx, issuer.Token(claims(map[string]any{"tapes_admin": true})))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(identity.Admin).To(BeTrue())
+
+			identity, err = v.Verify(ctx, issuer.Token(claims(map[string]any{"tapes_admin": "true"})))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(identity.Admin).To(BeFalse())
+		})
+	})
+
+	Describe("rejections", func() {
+		It("rejects an expired token", func() {
+			token := issuer.Token(claims(map[string]any{"exp": time.Now().Add(-time.Hour).Unix()}))
+			_, err := newVerifier().Verify(ctx, token)
+			Expect(err).To(MatchError(oidc.ErrInvalidToken))
+			Expect(err.Error()).To(ContainSubstring("expired"))
+		})
+
+		It("tolerates clock skew within the leeway", func() {
+			token := issuer.Token(claims(map[string]any{"exp": time.Now().Add(-20 * time.Second).Unix()}))
+			_, err := newVerifier().Verify(ctx, token)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects a token not yet valid", func() {
+			token := issuer.Token(claims(map[string]any{"nbf": time.Now().Add(time.Hour).Unix()}))
+			_, err := newVerifier().Verify(ctx, token)
+			Expect(err).To(MatchError(oidc.ErrInvalidToken))
+		})
+
+		It("rejects a token without exp", func() {
+			c := claims(nil)
+			c["exp"] = nil
+			_, err := newVerifier().Verify(ctx, issuer.Token(c))
+			Expect(err).To(MatchError(oidc.ErrInvalidToken))
+		})
+
+		It("rejects the wrong issuer and audience", func() {
+			v := newVerifier()
+			_, err := v.Verify(ctx, issuer.Token(claims(map[string]any{"iss": "https://evil.example"})))
+			Expect(err).To(MatchError(oidc.ErrInvalidToken))
+
+			_, err = v.Verify(ctx, issuer.Token(claims(map[string]any{"aud": []string{"other", "another"}})))
+			Expect(err).To(MatchError(oidc.ErrInvalidToken))
+
+			_, err = v.Verify(ctx, issuer.Token(claims(map[string]any{"aud": []string{"other", "tapes"}})))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects a tampered payload", func() {
+			token := issuer.Token(claims(nil))
+			parts := strings.Split(token, ".")
+			forged, _ := json.Marshal(claims(map[string]any{"sub": "admin", "exp": time.Now().Add(time.Hour).Unix()}))
+			parts[1] = base64.RawURLEncoding.EncodeToString(forged)
+
+			_, err := newVerifier().Verify(ctx, strings.Join(parts, "."))
+			Expect(err).To(MatchError(oidc.ErrInvalidToken))
+		})
+
+		It("rejects alg none and HMAC", func() {
+			payload, _ := json.Marshal(claims(map[string]any{"exp": time.Now().Add(time.Hour).Unix()}))
+			for _, alg := range []string{"none", "HS256"} {
+				header, _ := json.Marshal(map[string]string{"alg": alg, "kid": "key-1"})
+				token := base64.RawURLEncoding.EncodeToString(header) + "." +
+					base64.RawURLEncoding.EncodeToString(payload) + "."
+
+				_, err := newVerifier().Verify(ctx, token)
+				Expect(err).To(MatchError(oidc.ErrInvalidToken), alg)
+			}
+		})
+
+		It("rejects garbage", func() {
+			_, err := newVerifier().Verify(ctx, "not-a-jwt")
+			Expect(err).To(MatchError(oidc.ErrInvalidToken))
+		})
+	})
+
+	Describe("key set caching", func() {
+		It("fetches the JWKS once and reuses it", func() {
+			v := newVerifier()
+			for range 3 {
+				_, err := v.Verify(ctx, issuer.Token(claims(nil)))
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(issuer.Fetches()).To(Equal(1))
+		})
+
+		It("picks up a rotated key on the first token that needs it", func() {
+			v := newVerifier()
+			now := time.Now()
+			oidc.SetNowForTest(v, func() time.Time { return now })
+
+			_, err := v.Verify(ctx, issuer.Token(claims(nil)))
+			Expect(err).NotTo(HaveOccurred())
+
+			issuer.Rotate()
+			now = now.Add(time.Minute)
+			_, err = v.Verify(ctx, issuer.Token(claims(nil)))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(issuer.Fetches()).To(Equal(2))
+		})
+
+		It("rate-limits refetches for unknown kids", func() {
+			v := newVerifier()
+			now := time.Now()
+			oidc.SetNowForTest(v, func() time.Time { return now })
+
+			_, err := v.Verify(ctx, issuer.Token(claims(nil)))
+			Expect(err).NotTo(HaveOccurred())
+
+			issuer.Rotate()
+			// Within the refetch interval the new kid is unknown and no
+			// fetch is spent on finding out.
+			_, err = v.Verify(ctx, issuer.Token(claims(nil)))
+			Expect(err).To(MatchError(oidc.ErrInvalidToken))
+			Expect(issuer.Fetches()).To(Equal(1))
+		})
+
+		It("keeps serving stale keys while the issuer is down", func() {
+			config.RefreshInterval = time.Minute
+			v := newVerifier()
+			now := time.Now()
+			oidc.SetNowForTest(v, func() time.Time { return now })
+
+			_, err := v.Verify(ctx, issuer.Token(claims(nil)))
+			Expect(err).NotTo(HaveOccurred())
+
+			issuer.SetDown(true)
+			now = now.Add(2 * time.Minute)
+			_, err = v.Verify(ctx, issuer.Token(claims(map[string]any{"exp": now.Add(time.Hour).Unix()})))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(issuer.Fetches()).To(Equal(2))
+		})
+
+		It("reports an unreachable issuer as a verifier failure, not a bad token", func() {
+			issuer.SetDown(true)
+			_, err := newVerifier().Verify(ctx, issuer.Token(claims(nil)))
+			Expect(err).To(HaveOccurred())
+			Expect(errors.Is(err, oidc.ErrInvalidToken)).To(BeFalse())
+		})
+	})
+
+	It("verifies ES256 tokens", func() {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		point, err := key.PublicKey.Bytes()
+		Expect(err).NotTo(HaveOccurred())
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+				"kty": "EC", "crv": "P-256", "kid": "ec-1",
+				"x": base64.RawURLEncoding.EncodeToString(point[1:33]),
+				"y": base64.RawURLEncoding.EncodeToString(point[33:]),
+			}}})
+		}))
+		DeferCleanup(server.Close)
+
+		header, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": "ec-1"})
+		payload, _ := json.Marshal(map[string]any{"sub": "user-ec", "exp": time.Now().Add(time.Hour).Unix()})
+		signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+		sum := sha256.Sum256([]byte(signed))
+		r, s, err := ecdsa.Sign(rand.Reader, key, sum[:])
+		Expect(err).NotTo(HaveOccurred())
+		signature := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+
+		v, err := oidc.NewVerifier(oidc.Config{JWKSURL: server.URL})
+		Expect(err).NotTo(HaveOccurred())
+		identity, err := v.Verify(ctx, signed+"."+base64.RawURLEncoding.EncodeToString(signature))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(identity.Subject).To(Equal("user-ec"))
+	})
+})
@@ -0,0 +1,131 @@
+// Package oidctest runs a throwaway JWT issuer for tests: an httptest server
+// publishing a JWKS, and a signer for tokens that verify against it.
+package oidctest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Issuer signs RS256 tokens and serves the key set that verifies them.
+type Issuer struct {
+	server  *httptest.Server
+	fetches atomic.Int64
+
+	mu   sync.Mutex
+	key  *rsa.PrivateKey
+	kid  string
+	gen  int
+	down bool
+}
+
+// NewIssuer starts an issuer. Close it when the test is done.
+func NewIssuer() *Issuer {
+	issuer := &Issuer{}
+	issuer.Rotate()
+	issuer.server = httptest.NewServer(http.HandlerFunc(issuer.serveJWKS))
+
+	return issuer
+}
+
+// JWKSURL is the key set's URL.
+func (i *Issuer) JWKSURL() string { return i.server.URL + "/jwks.json" }
+
+// Close stops the server.
+func (i *Issuer) Close() { i.server.Close() }
+
+// Fetches counts the JWKS requests served so far.
+func (i *Issuer) Fetches() int { return int(i.fetches.Load()) }
+
+// SetDown makes the JWKS endpoint answer 503 until it is set back.
+func (i *Issuer) SetDown(down bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.down = down
+}
+
+// Rotate replaces the signing key with a new one under a new kid. The old
+// key is no longer published.
+func (i *Issuer) Rotate() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.gen++
+	i.key = key
+	i.kid = "key-" + strconv.Itoa(i.gen)
+}
+
+// Token signs claims with the current key. An exp an hour out is added
+// when claims carry none.
+func (i *Issuer) Token(claims map[string]any) string {
+	i.mu.Lock()
+	key, kid := i.key, i.kid
+	i.mu.Unlock()
+
+	if _, ok := claims["exp"]; !ok {
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+	}
+
+	return Sign(key, kid, claims)
+}
+
+// Sign produces an RS256 compact JWS over claims.
+func Sign(key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid})
+	if err != nil {
+		panic(err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		panic(err)
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		panic(err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (i *Issuer) serveJWKS(w http.ResponseWriter, _ *http.Request) {
+	i.fetches.Add(1)
+
+	i.mu.Lock()
+	down, pub, kid := i.down, i.key.PublicKey, i.kid
+	i.mu.Unlock()
+
+	if down {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": kid,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
@@ -0,0 +1,258 @@
+// Package retention applies the configured retention rules to the raw
+// layer: sessions last seen longer ago than a rule's max age are either
+// deleted outright or stripped of their prompt/response content while
+// their usage and cost rollups are kept.
+//
+// The worker is a slow loop — one pass at startup, then one per
+// Interval — and every action is idempotent, so a pass interrupted by a
+// restart simply resumes on the next one.
+package retention
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/papercomputeco/tapes/pkg/config"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+// Defaults for Config fields left zero.
+const (
+	DefaultInterval = time.Hour
+	DefaultPageSize = 100
+)
+
+// Rule is one resolved retention rule.
+type Rule struct {
+	Name   string
+	Action string // storage.RetentionDelete or storage.RetentionStrip
+
+	// MaxAge is measured from the session's last_seen_at.
+	MaxAge time.Duration
+
+	// HarnessID and AuthSubject narrow the rule; empty matches any.
+	HarnessID   string
+	AuthSubject string
+}
+
+// Config tunes the worker. Zero fields take the package defaults.
+type Config struct {
+	// Rules are applied in order on every pass. A delete rule listed
+	// after a strip rule with a shorter age sees the stripped sessions
+	// too, so "strip at 30d, delete at 365d" composes.
+	Rules []Rule
+
+	// Interval is the pass cadence.
+	Interval time.Duration
+
+	// PageSize bounds one candidate listing.
+	PageSize int32
+
+	// MaxPerRun caps the sessions one rule acts on per pass, so a new
+	// rule over a large backlog spreads across passes instead of holding
+	// the database for one long stretch. Zero means no cap.
+	MaxPerRun int
+
+	// DryRun lists what each rule would act on without changing
+	// anything.
+	DryRun bool
+}
+
+func (c Config) withDefaults() Config {
+	if c.Interval <= 0 {
+		c.Interval = DefaultInterval
+	}
+	if c.PageSize <= 0 {
+		c.PageSize = DefaultPageSize
+	}
+	return c
+}
+
+// FromConfig resolves the [retention] config section. The section is
+// expected to have passed config.ValidateRetentionConfig; it is
+// re-validated here so a hand-built value fails loudly.
+func FromConfig(rc config.RetentionConfig) (Config, error) {
+	if err := config.ValidateRetentionConfig(rc); err != nil {
+		return Config{}, err
+	}
+	var cfg Config
+	if rc.Interval != "" {
+		d, err := config.ParseRetentionAge(rc.Interval)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.Interval = d
+	}
+	for _, r := range rc.Rules {
+		age, err := config.ParseRetentionAge(r.MaxAge)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.Rules = append(cfg.Rules, Rule{
+			Name:        r.Name,
+			Action:      r.Action,
+			MaxAge:      age,
+			HarnessID:   r.Harness,
+			AuthSubject: r.AuthSubject,
+		})
+	}
+	return cfg, nil
+}
+
+// RuleReport is what one rule did in one pass.
+type RuleReport struct {
+	Rule    string                  `json:"rule"`
+	Action  string                  `json:"action"`
+	Matched int                     `json:"matched"`
+	Applied storage.RetentionResult `json:"applied"`
+	Errors  int                     `json:"errors"`
+}
+
+// Report is one pass over every rule.
+type Report struct {
+	DryRun bool         `json:"dry_run"`
+	Rules  []RuleReport `json:"rules"`
+}
+
+// Worker applies retention rules against a storage.RetentionStore.
+type Worker struct {
+	cfg    Config
+	store  storage.RetentionStore
+	logger *slog.Logger
+}
+
+// NewWorker creates a retention worker. logger must be non-nil.
+func NewWorker(cfg Config, store storage.RetentionStore, logger *slog.Logger) *Worker {
+	return &Worker{
+		cfg:    cfg.withDefaults(),
+		store:  store,
+		logger: logger,
+	}
+}
+
+// Run blocks until ctx is canceled: one pass at startup, then one per
+// Interval. A failed pass is logged and retried on the next tick.
+func (w *Worker) Run(ctx context.Context) error {
+	w.logger.Info("retention worker starting",
+		"rules", len(w.cfg.Rules),
+		"interval", w.cfg.Interval,
+		"dry_run", w.cfg.DryRun,
+	)
+	w.runLogged(ctx)
+
+	tick := time.NewTicker(w.cfg.Interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("retention worker stopping")
+			return nil
+		case <-tick.C:
+			w.runLogged(ctx)
+		}
+	}
+}
+
+func (w *Worker) runLogged(ctx context.Context) {
+	if _, err := w.RunOnce(ctx); err != nil && ctx.Err() == nil {
+		w.logger.Warn("retention pass failed", "error", err.Error())
+	}
+}
+
+// RunOnce applies every rule once and reports what each did. A listing
+// failure aborts the pass (the store is unreachable); a failure acting
+// on one session is counted and logged, and the rule moves on.
+func (w *Worker) RunOnce(ctx context.Context) (*Report, error) {
+	report := &Report{DryRun: w.cfg.DryRun}
+	now := time.Now().UTC()
+	for _, rule := range w.cfg.Rules {
+		rr, err := w.applyRule(ctx, rule, now)
+		report.Rules = append(report.Rules, rr)
+		if err != nil {
+			return report, fmt.Errorf("retention rule %q: %w", rule.Name, err)
+		}
+		w.logger.Info("retention rule applied",
+			"rule", rr.Rule,
+			"action", rr.Action,
+			"matched", rr.Matched,
+			"sessions", rr.Applied.Sessions,
+			"raw_turns", rr.Applied.RawTurns,
+			"errors", rr.Errors,
+			"dry_run", w.cfg.DryRun,
+		)
+	}
+	return report, nil
+}
+
+// applyRule pages through one rule's candidates. Acting on a page
+// removes it from the next listing (deleted, or stripped and excluded
+// by SkipStripped), so each page re-lists from the oldest match. A page
+// on which nothing was applied ends the rule for this pass: the
+// remaining candidates are failing and would only be listed again.
+func (w *Worker) applyRule(ctx context.Context, rule Rule, now time.Time) (RuleReport, error) {
+	rr := RuleReport{Rule: rule.Name, Action: rule.Action}
+	opts := storage.RetentionCandidateOpts{
+		LastSeenBefore: now.Add(-rule.MaxAge),
+		HarnessID:      rule.HarnessID,
+		AuthSubject:    rule.AuthSubject,
+		SkipStripped:   rule.Action == storage.RetentionStrip,
+		Limit:          w.cfg.PageSize,
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return rr, nil
+		}
+		if w.cfg.MaxPerRun > 0 {
+			remaining := w.cfg.MaxPerRun - rr.Matched
+			if remaining <= 0 {
+				return rr, nil
+			}
+			opts.Limit = int32(min(remaining, int(w.cfg.PageSize))) //nolint:gosec // bounded by PageSize
+		}
+		candidates, err := w.store.ListRetentionCandidates(ctx, opts)
+		if err != nil {
+			return rr, err
+		}
+		rr.Matched += len(candidates)
+		if w.cfg.DryRun {
+			// Nothing changes, so a second page would list the same rows.
+			return rr, nil
+		}
+
+		progressed := false
+		for _, c := range candidates {
+			res, err := w.apply(ctx, rule.Action, c)
+			if err != nil {
+				rr.Errors++
+				w.logger.Warn("retention action failed",
+					"rule", rule.Name,
+					"session_id", c.ID,
+					"error", err.Error(),
+				)
+				continue
+			}
+			progressed = true
+			rr.Applied.Sessions += res.Sessions
+			rr.Applied.RawTurns += res.RawTurns
+			rr.Applied.Spans += res.Spans
+			rr.Applied.Traces += res.Traces
+		}
+		if len(candidates) < int(opts.Limit) || !progressed {
+			return rr, nil
+		}
+	}
+}
+
+func (w *Worker) apply(ctx context.Context, action string, c storage.RetentionCandidate) (storage.RetentionResult, error) {
+	switch action {
+	case storage.RetentionDelete:
+		return w.store.PurgeSession(ctx, c.OrgID, c.ID)
+	case storage.RetentionStrip:
+		return w.store.StripSessionPayloads(ctx, c.OrgID, c.ID)
+	default:
+		return storage.RetentionResult{}, fmt.Errorf("unknown retention action %q", action)
+	}
+}
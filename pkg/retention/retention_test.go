@@ -0,0 +1,256 @@
+package retention_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/config"
+	"github.com/papercomputeco/tapes/pkg/retention"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+// fakeStore is an in-memory storage.RetentionStore. It models the two
+// properties the worker's paging relies on: a purged session disappears
+// from the listing, and a stripped one is excluded when SkipStripped is
+// set. The Postgres implementation is exercised against a live database.
+type fakeStore struct {
+	mu       sync.Mutex
+	sessions map[string]storage.RetentionCandidate
+
+	listErr   error
+	listCalls int
+	failIDs   map[string]bool
+
+	purged   []string
+	stripped []string
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{sessions: map[string]storage.RetentionCandidate{}, failIDs: map[string]bool{}}
+}
+
+func (f *fakeStore) add(id, harness string, lastSeen time.Time) {
+	f.sessions[id] = storage.RetentionCandidate{
+		ID:         id,
+		OrgID:      "org",
+		HarnessID:  harness,
+		LastSeenAt: lastSeen,
+	}
+}
+
+func (f *fakeStore) ListRetentionCandidates(_ context.Context, opts storage.RetentionCandidateOpts) ([]storage.RetentionCandidate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.listCalls++
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	var out []storage.RetentionCandidate
+	for _, s := range f.sessions {
+		if !s.LastSeenAt.Before(opts.LastSeenBefore) {
+			continue
+		}
+		if opts.HarnessID != "" && s.HarnessID != opts.HarnessID {
+			continue
+		}
+		if opts.SkipStripped && s.RetentionTier == storage.RetentionTierStripped {
+			continue
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].LastSeenAt.Before(out[j].LastSeenAt) })
+	if len(out) > int(opts.Limit) {
+		out = out[:opts.Limit]
+	}
+	return out, nil
+}
+
+func (f *fakeStore) StripSessionPayloads(_ context.Context, _, id string) (storage.RetentionResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failIDs[id] {
+		return storage.RetentionResult{}, errors.New("boom")
+	}
+	s, ok := f.sessions[id]
+	if !ok || s.RetentionTier == storage.RetentionTierStripped {
+		return storage.RetentionResult{}, nil
+	}
+	s.RetentionTier = storage.RetentionTierStripped
+	f.sessions[id] = s
+	f.stripped = append(f.stripped, id)
+	return storage.RetentionResult{Sessions: 1, RawTurns: 2}, nil
+}
+
+func (f *fakeStore) PurgeSession(_ context.Context, _, id string) (storage.RetentionResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failIDs[id] {
+		return storage.RetentionResult{}, errors.New("boom")
+	}
+	if _, ok := f.sessions[id]; !ok {
+		return storage.RetentionResult{}, nil
+	}
+	delete(f.sessions, id)
+	f.purged = append(f.purged, id)
+	return storage.RetentionResult{Sessions: 1, RawTurns: 3}, nil
+}
+
+var _ = Describe("Worker", func() {
+	var (
+		store  *fakeStore
+		logger *slog.Logger
+		now    time.Time
+	)
+
+	BeforeEach(func() {
+		store = newFakeStore()
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+		now = time.Now().UTC()
+	})
+
+	days := func(n int) time.Duration { return time.Duration(n) * 24 * time.Hour }
+
+	It("deletes only sessions older than the rule's max age", func() {
+		store.add("old", "claude-code", now.Add(-days(100)))
+		store.add("new", "claude-code", now.Add(-days(10)))
+
+		w := retention.NewWorker(retention.Config{Rules: []retention.Rule{
+			{Name: "purge", Action: storage.RetentionDelete, MaxAge: days(90)},
+		}}, store, logger)
+		report, err := w.RunOnce(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(store.purged).To(ConsistOf("old"))
+		Expect(store.sessions).To(HaveKey("new"))
+		Expect(report.Rules).To(HaveLen(1))
+		Expect(report.Rules[0].Matched).To(Equal(1))
+		Expect(report.Rules[0].Applied.RawTurns).To(Equal(int64(3)))
+	})
+
+	It("pages through every candidate", func() {
+		for _, id := range []string{"a", "b", "c", "d", "e"} {
+			store.add(id, "h", now.Add(-days(40)))
+		}
+		w := retention.NewWorker(retention.Config{
+			PageSize: 2,
+			Rules:    []retention.Rule{{Name: "strip", Action: storage.RetentionStrip, MaxAge: days(30)}},
+		}, store, logger)
+		report, err := w.RunOnce(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store.stripped).To(ConsistOf("a", "b", "c", "d", "e"))
+		Expect(report.Rules[0].Applied.Sessions).To(Equal(int64(5)))
+
+		// A second pass finds nothing left to strip.
+		store.stripped = nil
+		_, err = w.RunOnce(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store.stripped).To(BeEmpty())
+	})
+
+	It("lets a later delete rule act on stripped sessions", func() {
+		store.add("ancient", "h", now.Add(-days(400)))
+		store.add("old", "h", now.Add(-days(40)))
+		w := retention.NewWorker(retention.Config{Rules: []retention.Rule{
+			{Name: "strip", Action: storage.RetentionStrip, MaxAge: days(30)},
+			{Name: "purge", Action: storage.RetentionDelete, MaxAge: days(365)},
+		}}, store, logger)
+		_, err := w.RunOnce(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store.stripped).To(ConsistOf("ancient", "old"))
+		Expect(store.purged).To(ConsistOf("ancient"))
+	})
+
+	It("narrows by harness", func() {
+		store.add("cc", "claude-code", now.Add(-days(40)))
+		store.add("cx", "codex", now.Add(-days(40)))
+		w := retention.NewWorker(retention.Config{Rules: []retention.Rule{
+			{Name: "codex", Action: storage.RetentionDelete, MaxAge: days(30), HarnessID: "codex"},
+		}}, store, logger)
+		_, err := w.RunOnce(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store.purged).To(ConsistOf("cx"))
+	})
+
+	It("changes nothing in dry-run mode", func() {
+		store.add("old", "h", now.Add(-days(100)))
+		w := retention.NewWorker(retention.Config{
+			DryRun: true,
+			Rules:  []retention.Rule{{Name: "purge", Action: storage.RetentionDelete, MaxAge: days(90)}},
+		}, store, logger)
+		report, err := w.RunOnce(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.DryRun).To(BeTrue())
+		Expect(report.Rules[0].Matched).To(Equal(1))
+		Expect(store.purged).To(BeEmpty())
+		Expect(store.sessions).To(HaveKey("old"))
+	})
+
+	It("caps the sessions one pass acts on", func() {
+		for _, id := range []string{"a", "b", "c"} {
+			store.add(id, "h", now.Add(-days(100)))
+		}
+		w := retention.NewWorker(retention.Config{
+			MaxPerRun: 2,
+			Rules:     []retention.Rule{{Name: "purge", Action: storage.RetentionDelete, MaxAge: days(90)}},
+		}, store, logger)
+		_, err := w.RunOnce(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store.purged).To(HaveLen(2))
+	})
+
+	It("counts per-session failures without looping on them", func() {
+		store.add("bad", "h", now.Add(-days(100)))
+		store.add("good", "h", now.Add(-days(99)))
+		store.failIDs["bad"] = true
+		w := retention.NewWorker(retention.Config{
+			PageSize: 1,
+			Rules:    []retention.Rule{{Name: "purge", Action: storage.RetentionDelete, MaxAge: days(90)}},
+		}, store, logger)
+		report, err := w.RunOnce(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Rules[0].Errors).To(Equal(1))
+		Expect(store.listCalls).To(Equal(1))
+	})
+
+	It("aborts the pass when the store cannot list", func() {
+		store.listErr = errors.New("connection refused")
+		w := retention.NewWorker(retention.Config{Rules: []retention.Rule{
+			{Name: "purge", Action: storage.RetentionDelete, MaxAge: days(90)},
+		}}, store, logger)
+		_, err := w.RunOnce(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("connection refused")))
+	})
+})
+
+var _ = Describe("FromConfig", func() {
+	It("resolves day and duration ages", func() {
+		cfg, err := retention.FromConfig(config.RetentionConfig{
+			Interval: "30m",
+			Rules: []config.RetentionRule{
+				{Name: "strip", Action: "strip", MaxAge: "30d", Harness: "codex"},
+				{Name: "purge", Action: "delete", MaxAge: "8760h"},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.Interval).To(Equal(30 * time.Minute))
+		Expect(cfg.Rules).To(HaveLen(2))
+		Expect(cfg.Rules[0].MaxAge).To(Equal(30 * 24 * time.Hour))
+		Expect(cfg.Rules[0].HarnessID).To(Equal("codex"))
+		Expect(cfg.Rules[1].MaxAge).To(Equal(8760 * time.Hour))
+	})
+
+	It("rejects an unknown action", func() {
+		_, err := retention.FromConfig(config.RetentionConfig{
+			Rules: []config.RetentionRule{{Name: "x", Action: "archive", MaxAge: "1d"}},
+		})
+		Expect(err).To(MatchError(ContainSubstring("action must be")))
+	})
+})
@@ -0,0 +1,67 @@
+package sessions
+
+import "strings"
+
+// ModelCapability describes what a model can hold, as opposed to what it
+// costs (Pricing).
+type ModelCapability struct {
+	// ContextWindow is the most prompt the model accepts, in tokens.
+	ContextWindow int64 `json:"context_window"`
+}
+
+// CapabilityTable maps normalized model names (see NormalizeModel) to
+// their capabilities.
+type CapabilityTable map[string]ModelCapability
+
+// oneMillionContext is the window Anthropic's [1m] model marker opts into.
+const oneMillionContext = 1_000_000
+
+// DefaultCapabilities returns the standard context windows for the models
+// DefaultPricing covers.
+//
+// Last verified: 2026-07-24, against the same provider pages as
+// DefaultPricing. Claude models that offer a 1M window only use it when
+// the harness asks with the [1m] marker; CapabilityForModel honors that.
+func DefaultCapabilities() CapabilityTable {
+	table := CapabilityTable{
+		// OpenAI
+		"gpt-4o":            {ContextWindow: 128_000},
+		"gpt-4o-mini":       {ContextWindow: 128_000},
+		"gpt-4.1":           {ContextWindow: 1_047_576},
+		"gpt-4.1-mini":      {ContextWindow: 1_047_576},
+		"gpt-4.1-nano":      {ContextWindow: 1_047_576},
+		"o1":                {ContextWindow: 200_000},
+		"o3":                {ContextWindow: 200_000},
+		"o3-mini":           {ContextWindow: 200_000},
+		"o4-mini":           {ContextWindow: 200_000},
+		"codex-mini-latest": {ContextWindow: 200_000},
+
+		// DeepSeek
+		"deepseek-r1": {ContextWindow: 128_000},
+	}
+	for model := range DefaultPricing() {
+		switch {
+		case strings.HasPrefix(model, "claude-"):
+			table[model] = ModelCapability{ContextWindow: 200_000}
+		case strings.HasPrefix(model, "gpt-5"):
+			table[model] = ModelCapability{ContextWindow: 400_000}
+		}
+	}
+	return table
+}
+
+// CapabilityForModel looks up a model's capabilities the way
+// PricingForModel looks up its price. A model name carrying the [1m]
+// marker reports the one-million-token window whatever the table says.
+func CapabilityForModel(table CapabilityTable, model string) (ModelCapability, bool) {
+	normalized := NormalizeModel(model)
+	capability, ok := table[normalized]
+	if !ok {
+		capability, ok = table[model]
+	}
+	if strings.HasSuffix(strings.ToLower(strings.TrimSpace(model)), "[1m]") {
+		capability.ContextWindow = oneMillionContext
+		ok = true
+	}
+	return capability, ok
+}
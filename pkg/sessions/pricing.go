@@ -20,54 +20,97 @@ import (
 // OpenAI cache: CacheWrite = 1x input and CacheRead = 0.50x input for older models;
 // GPT-5.6+ uses CacheWrite = 1.25x input and CacheRead = 0.10x input.
 //
+// Batch calls bill at half the standard rates on both providers, as do
+// OpenAI flex calls; OpenAI priority processing bills GPT-5 models at 2x.
+// Anthropic one-hour cache writes are 2x input. Claude Sonnet 4 and 4.5
+// bill prompts over 200K tokens at the long-context tier ($6 input, $22.50
+// output). Web search is $10 per 1,000 requests on Anthropic and on OpenAI
+// reasoning models, $25 per 1,000 on GPT-4o and GPT-4.1.
+//
 // To override at runtime, pass a JSON file path to LoadPricing.
 func DefaultPricing() PricingTable {
-	return PricingTable{
+	// These are shared between entries, so they are built fresh for every
+	// table: a caller editing one table cannot reach another.
+	anthropicServerTools := map[string]float64{"web_search": 0.01}
+	openAIServerTools := map[string]float64{"web_search": 0.01, "file_search": 0.0025}
+	openAIChatModelTools := map[string]float64{"web_search": 0.025, "file_search": 0.0025}
+	sonnetLongContext := &LongContextPricing{
+		Threshold: 200_000, Input: 6.00, Output: 22.50, CacheRead: 0.60, CacheWrite: 7.50, CacheWrite1h: 12.00,
+	}
+
+	table := PricingTable{
 		// Anthropic
-		"claude-opus-5":     {Input: 5.00, Output: 25.00, CacheRead: 0.50, CacheWrite: 6.25},
-		"claude-fable-5":    {Input: 10.00, Output: 50.00, CacheRead: 1.00, CacheWrite: 12.50},
-		"claude-opus-4.8":   {Input: 5.00, Output: 25.00, CacheRead: 0.50, CacheWrite: 6.25},
-		"claude-opus-4.7":   {Input: 5.00, Output: 25.00, CacheRead: 0.50, CacheWrite: 6.25},
-		"claude-opus-4.6":   {Input: 5.00, Output: 25.00, CacheRead: 0.50, CacheWrite: 6.25},
-		"claude-opus-4.5":   {Input: 5.00, Output: 25.00, CacheRead: 0.50, CacheWrite: 6.25},
-		"claude-opus-4.1":   {Input: 15.00, Output: 75.00, CacheRead: 1.50, CacheWrite: 18.75},
-		"claude-opus-4":     {Input: 15.00, Output: 75.00, CacheRead: 1.50, CacheWrite: 18.75},
-		"claude-sonnet-5":   {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75},
-		"claude-sonnet-4.6": {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75},
-		"claude-sonnet-4.5": {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75},
-		"claude-sonnet-4":   {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75},
-		"claude-sonnet-3.7": {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75},
-		"claude-haiku-4.5":  {Input: 1.00, Output: 5.00, CacheRead: 0.10, CacheWrite: 1.25},
-		"claude-haiku-4.6":  {Input: 1.00, Output: 5.00, CacheRead: 0.10, CacheWrite: 1.25},
-		"claude-3.5-sonnet": {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75},
-		"claude-3.5-haiku":  {Input: 0.80, Output: 4.00, CacheRead: 0.08, CacheWrite: 1.00},
-		"claude-3-opus":     {Input: 15.00, Output: 75.00, CacheRead: 1.50, CacheWrite: 18.75},
-		"claude-3-haiku":    {Input: 0.25, Output: 1.25, CacheRead: 0.03, CacheWrite: 0.30},
+		"claude-opus-5":     {Input: 5.00, Output: 25.00, CacheRead: 0.50, CacheWrite: 6.25, CacheWrite1h: 10.00, ServerTools: anthropicServerTools},
+		"claude-fable-5":    {Input: 10.00, Output: 50.00, CacheRead: 1.00, CacheWrite: 12.50, CacheWrite1h: 20.00, ServerTools: anthropicServerTools},
+		"claude-opus-4.8":   {Input: 5.00, Output: 25.00, CacheRead: 0.50, CacheWrite: 6.25, CacheWrite1h: 10.00, ServerTools: anthropicServerTools},
+		"claude-opus-4.7":   {Input: 5.00, Output: 25.00, CacheRead: 0.50, CacheWrite: 6.25, CacheWrite1h: 10.00, ServerTools: anthropicServerTools},
+		"claude-opus-4.6":   {Input: 5.00, Output: 25.00, CacheRead: 0.50, CacheWrite: 6.25, CacheWrite1h: 10.00, ServerTools: anthropicServerTools},
+		"claude-opus-4.5":   {Input: 5.00, Output: 25.00, CacheRead: 0.50, CacheWrite: 6.25, CacheWrite1h: 10.00, ServerTools: anthropicServerTools},
+		"claude-opus-4.1":   {Input: 15.00, Output: 75.00, CacheRead: 1.50, CacheWrite: 18.75, CacheWrite1h: 30.00, ServerTools: anthropicServerTools},
+		"claude-opus-4":     {Input: 15.00, Output: 75.00, CacheRead: 1.50, CacheWrite: 18.75, CacheWrite1h: 30.00, ServerTools: anthropicServerTools},
+		"claude-sonnet-5":   {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75, CacheWrite1h: 6.00, ServerTools: anthropicServerTools},
+		"claude-sonnet-4.6": {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75, CacheWrite1h: 6.00, ServerTools: anthropicServerTools},
+		"claude-sonnet-4.5": {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75, CacheWrite1h: 6.00, ServerTools: anthropicServerTools, LongContext: sonnetLongContext},
+		"claude-sonnet-4":   {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75, CacheWrite1h: 6.00, ServerTools: anthropicServerTools, LongContext: sonnetLongContext},
+		"claude-sonnet-3.7": {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75, CacheWrite1h: 6.00, ServerTools: anthropicServerTools},
+		"claude-haiku-4.5":  {Input: 1.00, Output: 5.00, CacheRead: 0.10, CacheWrite: 1.25, CacheWrite1h: 2.00, ServerTools: anthropicServerTools},
+		"claude-haiku-4.6":  {Input: 1.00, Output: 5.00, CacheRead: 0.10, CacheWrite: 1.25, CacheWrite1h: 2.00, ServerTools: anthropicServerTools},
+		"claude-3.5-sonnet": {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75, CacheWrite1h: 6.00, ServerTools: anthropicServerTools},
+		"claude-3.5-haiku":  {Input: 0.80, Output: 4.00, CacheRead: 0.08, CacheWrite: 1.00, CacheWrite1h: 1.60, ServerTools: anthropicServerTools},
+		"claude-3-opus":     {Input: 15.00, Output: 75.00, CacheRead: 1.50, CacheWrite: 18.75, CacheWrite1h: 30.00, ServerTools: anthropicServerTools},
+		"claude-3-haiku":    {Input: 0.25, Output: 1.25, CacheRead: 0.03, CacheWrite: 0.30, CacheWrite1h: 0.50, ServerTools: anthropicServerTools},
 
 		// OpenAI
-		"gpt-4o":            {Input: 2.50, Output: 10.00, CacheRead: 1.25, CacheWrite: 2.50},
-		"gpt-4o-mini":       {Input: 0.15, Output: 0.60, CacheRead: 0.075, CacheWrite: 0.15},
-		"gpt-4.1":           {Input: 2.00, Output: 8.00, CacheRead: 0.50, CacheWrite: 2.00},
-		"gpt-4.1-mini":      {Input: 0.40, Output: 1.60, CacheRead: 0.10, CacheWrite: 0.40},
-		"gpt-4.1-nano":      {Input: 0.10, Output: 0.40, CacheRead: 0.025, CacheWrite: 0.10},
-		"o3":                {Input: 2.00, Output: 8.00, CacheRead: 0.50, CacheWrite: 2.00},
-		"o3-mini":           {Input: 1.10, Output: 4.40, CacheRead: 0.55, CacheWrite: 1.10},
-		"o4-mini":           {Input: 1.10, Output: 4.40, CacheRead: 0.275, CacheWrite: 1.10},
-		"gpt-5.6-sol":       {Input: 5.00, Output: 30.00, CacheRead: 0.50, CacheWrite: 6.25},
-		"gpt-5.6-terra":     {Input: 2.50, Output: 15.00, CacheRead: 0.25, CacheWrite: 3.125},
-		"gpt-5.6-luna":      {Input: 1.00, Output: 6.00, CacheRead: 0.10, CacheWrite: 1.25},
-		"gpt-5.5":           {Input: 5.00, Output: 30.00, CacheRead: 0.50, CacheWrite: 5.00},
-		"gpt-5.4":           {Input: 2.50, Output: 15.00, CacheRead: 0.25, CacheWrite: 2.50},
-		"gpt-5.3-codex":     {Input: 1.75, Output: 14.00, CacheRead: 0.175, CacheWrite: 1.75},
-		"gpt-5.2-codex":     {Input: 1.75, Output: 14.00, CacheRead: 0.175, CacheWrite: 1.75},
-		"gpt-5.1-codex":     {Input: 1.25, Output: 10.00, CacheRead: 0.125, CacheWrite: 1.25},
-		"gpt-5-codex":       {Input: 1.25, Output: 10.00, CacheRead: 0.125, CacheWrite: 1.25},
-		"codex-mini-latest": {Input: 1.50, Output: 6.00, CacheRead: 0.375, CacheWrite: 1.50},
-		"o1":                {Input: 15.00, Output: 60.00, CacheRead: 7.50, CacheWrite: 15.00},
+		"gpt-4o":            {Input: 2.50, Output: 10.00, CacheRead: 1.25, CacheWrite: 2.50, ServerTools: openAIChatModelTools},
+		"gpt-4o-mini":       {Input: 0.15, Output: 0.60, CacheRead: 0.075, CacheWrite: 0.15, ServerTools: openAIChatModelTools},
+		"gpt-4.1":           {Input: 2.00, Output: 8.00, CacheRead: 0.50, CacheWrite: 2.00, ServerTools: openAIChatModelTools},
+		"gpt-4.1-mini":      {Input: 0.40, Output: 1.60, CacheRead: 0.10, CacheWrite: 0.40, ServerTools: openAIChatModelTools},
+		"gpt-4.1-nano":      {Input: 0.10, Output: 0.40, CacheRead: 0.025, CacheWrite: 0.10, ServerTools: openAIChatModelTools},
+		"o3":                {Input: 2.00, Output: 8.00, CacheRead: 0.50, CacheWrite: 2.00, ServerTools: openAIServerTools},
+		"o3-mini":           {Input: 1.10, Output: 4.40, CacheRead: 0.55, CacheWrite: 1.10, ServerTools: openAIServerTools},
+		"o4-mini":           {Input: 1.10, Output: 4.40, CacheRead: 0.275, CacheWrite: 1.10, ServerTools: openAIServerTools},
+		"gpt-5.6-sol":       {Input: 5.00, Output: 30.00, CacheRead: 0.50, CacheWrite: 6.25, ServerTools: openAIServerTools},
+		"gpt-5.6-terra":     {Input: 2.50, Output: 15.00, CacheRead: 0.25, CacheWrite: 3.125, ServerTools: openAIServerTools},
+		"gpt-5.6-luna":      {Input: 1.00, Output: 6.00, CacheRead: 0.10, CacheWrite: 1.25, ServerTools: openAIServerTools},
+		"gpt-5.5":           {Input: 5.00, Output: 30.00, CacheRead: 0.50, CacheWrite: 5.00, ServerTools: openAIServerTools},
+		"gpt-5.4":           {Input: 2.50, Output: 15.00, CacheRead: 0.25, CacheWrite: 2.50, ServerTools: openAIServerTools},
+		"gpt-5.3-codex":     {Input: 1.75, Output: 14.00, CacheRead: 0.175, CacheWrite: 1.75, ServerTools: openAIServerTools},
+		"gpt-5.2-codex":     {Input: 1.75, Output: 14.00, CacheRead: 0.175, CacheWrite: 1.75, ServerTools: openAIServerTools},
+		"gpt-5.1-codex":     {Input: 1.25, Output: 10.00, CacheRead: 0.125, CacheWrite: 1.25, ServerTools: openAIServerTools},
+		"gpt-5-codex":       {Input: 1.25, Output: 10.00, CacheRead: 0.125, CacheWrite: 1.25, ServerTools: openAIServerTools},
+		"codex-mini-latest": {Input: 1.50, Output: 6.00, CacheRead: 0.375, CacheWrite: 1.50, ServerTools: openAIServerTools},
+		"o1":                {Input: 15.00, Output: 60.00, CacheRead: 7.50, CacheWrite: 15.00, ServerTools: openAIServerTools},
 
 		// DeepSeek
 		"deepseek-r1": {Input: 0.55, Output: 2.19, CacheRead: 0.14},
 	}
+
+	for model, price := range table {
+		switch {
+		case strings.HasPrefix(model, "claude-"):
+			price.Tiers = map[string]float64{"batch": 0.5}
+		case strings.HasPrefix(model, "gpt-5"):
+			price.Tiers = map[string]float64{"batch": 0.5, "flex": 0.5, "priority": 2.0}
+		case strings.HasPrefix(model, "gpt-") || isOpenAIReasoningModel(model) || strings.HasPrefix(model, "codex-"):
+			price.Tiers = map[string]float64{"batch": 0.5, "flex": 0.5}
+		default:
+			continue
+		}
+		table[model] = price
+	}
+	return table
+}
+
+// isOpenAIReasoningModel reports whether model is one of OpenAI's o-series
+// reasoning models (o1, o3, o4 and their -mini and dated variants). A bare
+// "o" prefix would also claim any other vendor's model that starts with it.
+func isOpenAIReasoningModel(model string) bool {
+	for _, family := range []string{"o1", "o3", "o4"} {
+		if model == family || strings.HasPrefix(model, family+"-") {
+			return true
+		}
+	}
+	return false
 }
 
 // LoadPricing loads a pricing table, applying JSON overrides from path if set.
@@ -116,19 +159,75 @@ func CostForTokens(pricing Pricing, inputTokens, outputTokens int64) (float64, f
 //
 //	baseInput = totalInput - cacheCreation - cacheRead
 //
-// Each token type is priced at its respective rate.
+// Each token type is priced at its respective rate. It is CostForUsage
+// without the TTL, reasoning and server-tool breakdown.
 func CostForTokensWithCache(pricing Pricing, inputTokens, outputTokens, cacheCreation, cacheRead int64) (float64, float64, float64) {
-	if cacheCreation == 0 && cacheRead == 0 {
-		return CostForTokens(pricing, inputTokens, outputTokens)
+	return CostForUsage(pricing, NodeTokens{
+		Input:         inputTokens,
+		Output:        outputTokens,
+		CacheCreation: cacheCreation,
+		CacheRead:     cacheRead,
+	})
+}
+
+// CostForUsage calculates the cost of one call from its full token
+// breakdown. On top of CostForTokensWithCache it:
+//
+//   - switches every rate to the LongContext tier when the prompt exceeds
+//     the tier's threshold;
+//   - prices one-hour cache writes at CacheWrite1h and the rest of the
+//     cache writes at CacheWrite;
+//   - multiplies the token cost by the call's service-tier multiplier;
+//   - adds the per-request ServerTools fee for each server tool call.
+//
+// Reasoning tokens are part of Output and need no separate rate. The
+// server-tool fees are in the total only, since they are neither input nor
+// output, so the total can exceed input plus output.
+func CostForUsage(pricing Pricing, tokens NodeTokens) (float64, float64, float64) {
+	rates := pricing.ratesFor(tokens.Input)
+
+	cacheWrite1h := min(tokens.CacheCreation1h, tokens.CacheCreation)
+	cacheWrite5m := tokens.CacheCreation - cacheWrite1h
+	baseInput := max(tokens.Input-tokens.CacheCreation-tokens.CacheRead, 0)
+
+	inputCost := perMillion(baseInput, rates.Input)
+	inputCost += perMillion(cacheWrite5m, rates.CacheWrite)
+	inputCost += perMillion(cacheWrite1h, rates.CacheWrite1h)
+	inputCost += perMillion(tokens.CacheRead, rates.CacheRead)
+	outputCost := perMillion(tokens.Output, rates.Output)
+	if multiplier, ok := pricing.Tiers[tokens.ServiceTier]; ok && multiplier > 0 {
+		inputCost *= multiplier
+		outputCost *= multiplier
 	}
 
-	baseInput := max(inputTokens-cacheCreation-cacheRead, 0)
+	var toolCost float64
+	for tool, requests := range tokens.ServerToolUse {
+		toolCost += float64(requests) * pricing.ServerTools[tool]
+	}
+	return inputCost, outputCost, inputCost + outputCost + toolCost
+}
 
-	inputCost := float64(baseInput) / 1_000_000.0 * pricing.Input
-	inputCost += float64(cacheCreation) / 1_000_000.0 * pricing.CacheWrite
-	inputCost += float64(cacheRead) / 1_000_000.0 * pricing.CacheRead
-	outputCost := float64(outputTokens) / 1_000_000.0 * pricing.Output
-	return inputCost, outputCost, inputCost + outputCost
+// ratesFor returns the per-million rates that apply to a call with
+// promptTokens of input, with CacheWrite1h defaulted to CacheWrite.
+func (p Pricing) ratesFor(promptTokens int64) LongContextPricing {
+	rates := LongContextPricing{
+		Input:        p.Input,
+		Output:       p.Output,
+		CacheRead:    p.CacheRead,
+		CacheWrite:   p.CacheWrite,
+		CacheWrite1h: p.CacheWrite1h,
+	}
+	if lc := p.LongContext; lc != nil && lc.Threshold > 0 && promptTokens > lc.Threshold {
+		rates = *lc
+	}
+	if rates.CacheWrite1h == 0 {
+		rates.CacheWrite1h = rates.CacheWrite
+	}
+	return rates
+}
+
+func perMillion(tokens int64, rate float64) float64 {
+	return float64(tokens) / 1_000_000.0 * rate
 }
 
 // NormalizeModel canonicalizes a model name: lowercased, trimmed, date
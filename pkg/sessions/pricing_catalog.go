@@ -0,0 +1,162 @@
+package sessions
+
+import (
+	"maps"
+	"sort"
+	"time"
+)
+
+// PriceLookup resolves the price a model billed at on a given instant.
+// Derive-time costing asks it once per llm span, at the span's start, so a
+// re-derive reproduces what each call cost when it was made.
+type PriceLookup interface {
+	PricingAt(model string, at time.Time) (Pricing, bool)
+}
+
+// PricingAt implements PriceLookup for a flat table: every instant gets the
+// table's current price.
+func (t PricingTable) PricingAt(model string, _ time.Time) (Pricing, bool) {
+	return PricingForModel(t, model)
+}
+
+// CatalogPrice is one model's price from an instant onward, until the next
+// entry for the same model takes effect.
+type CatalogPrice struct {
+	Model         string    `json:"model"`
+	EffectiveFrom time.Time `json:"effective_from"`
+	Pricing       Pricing   `json:"pricing"`
+}
+
+// PriceDiscount is a negotiated discount off the catalog price for one org.
+// An empty Model applies it to every model; EffectiveUntil, when set, ends
+// it (exclusive).
+type PriceDiscount struct {
+	Model          string     `json:"model,omitempty"`
+	Percent        float64    `json:"percent"`
+	EffectiveFrom  time.Time  `json:"effective_from"`
+	EffectiveUntil *time.Time `json:"effective_until,omitempty"`
+}
+
+// PricingCatalog is an effective-dated price book for one org: catalog
+// prices by model and instant, that org's discounts, and a fallback table
+// for models or instants the catalog does not cover.
+type PricingCatalog struct {
+	prices    map[string][]CatalogPrice
+	discounts []PriceDiscount
+	fallback  PricingTable
+}
+
+// NewPricingCatalog builds a catalog from prices and discounts in any order.
+// Model names are normalized (NormalizeModel) so catalog keys match the
+// table's. A nil fallback prices uncovered lookups at DefaultPricing.
+func NewPricingCatalog(fallback PricingTable, prices []CatalogPrice, discounts []PriceDiscount) *PricingCatalog {
+	if fallback == nil {
+		fallback = DefaultPricing()
+	}
+	c := &PricingCatalog{
+		prices:    map[string][]CatalogPrice{},
+		discounts: discounts,
+		fallback:  fallback,
+	}
+	for _, price := range prices {
+		key := NormalizeModel(price.Model)
+		c.prices[key] = append(c.prices[key], price)
+	}
+	for _, entries := range c.prices {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].EffectiveFrom.Before(entries[j].EffectiveFrom)
+		})
+	}
+	return c
+}
+
+// PricingAt implements PriceLookup. The price is the latest catalog entry
+// for the model that took effect at or before at; a model with no such entry
+// falls back to the table. The org's discount in effect at that instant, if
+// any, is then applied — a model-specific discount outranks an every-model
+// one.
+func (c *PricingCatalog) PricingAt(model string, at time.Time) (Pricing, bool) {
+	price, ok := c.catalogPrice(model, at)
+	if !ok {
+		price, ok = PricingForModel(c.fallback, model)
+	}
+	if !ok {
+		return Pricing{}, false
+	}
+	if percent := c.discountAt(model, at); percent > 0 {
+		price = price.Discounted(percent)
+	}
+	return price, true
+}
+
+func (c *PricingCatalog) catalogPrice(model string, at time.Time) (Pricing, bool) {
+	entries, ok := c.prices[NormalizeModel(model)]
+	if !ok {
+		return Pricing{}, false
+	}
+	// First entry that takes effect after at; the one before it applies.
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].EffectiveFrom.After(at) })
+	if i == 0 {
+		return Pricing{}, false
+	}
+	return entries[i-1].Pricing, true
+}
+
+func (c *PricingCatalog) discountAt(model string, at time.Time) float64 {
+	normalized := NormalizeModel(model)
+	var best *PriceDiscount
+	for i := range c.discounts {
+		d := &c.discounts[i]
+		if d.EffectiveFrom.After(at) || (d.EffectiveUntil != nil && !at.Before(*d.EffectiveUntil)) {
+			continue
+		}
+		if d.Model != "" && NormalizeModel(d.Model) != normalized {
+			continue
+		}
+		if best == nil || d.outranks(best) {
+			best = d
+		}
+	}
+	if best == nil {
+		return 0
+	}
+	return best.Percent
+}
+
+// outranks reports whether d takes precedence over other when both are in
+// effect: a model-specific discount beats an every-model one, and between
+// two of the same kind the later one wins.
+func (d *PriceDiscount) outranks(other *PriceDiscount) bool {
+	if (d.Model != "") != (other.Model != "") {
+		return d.Model != ""
+	}
+	return d.EffectiveFrom.After(other.EffectiveFrom)
+}
+
+// Discounted returns the price with percent taken off every rate and fee.
+// Tier multipliers are ratios, so they are left as they are.
+func (p Pricing) Discounted(percent float64) Pricing {
+	factor := 1 - percent/100
+	out := p
+	out.Input *= factor
+	out.Output *= factor
+	out.CacheRead *= factor
+	out.CacheWrite *= factor
+	out.CacheWrite1h *= factor
+	if p.ServerTools != nil {
+		out.ServerTools = maps.Clone(p.ServerTools)
+		for tool, fee := range out.ServerTools {
+			out.ServerTools[tool] = fee * factor
+		}
+	}
+	if p.LongContext != nil {
+		lc := *p.LongContext
+		lc.Input *= factor
+		lc.Output *= factor
+		lc.CacheRead *= factor
+		lc.CacheWrite *= factor
+		lc.CacheWrite1h *= factor
+		out.LongContext = &lc
+	}
+	return out
+}
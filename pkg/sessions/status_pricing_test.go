@@ -2,6 +2,7 @@ package sessions_test
 
 import (
 	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -183,3 +184,163 @@ var _ = Describe("CostForTokensWithCache", func() {
 		Expect(total).To(BeNumerically("~", 12.20, 0.0001))
 	})
 })
+
+var _ = Describe("CostForUsage", func() {
+	pricing := sessions.Pricing{
+		Input: 10.0, Output: 30.0, CacheRead: 1.0, CacheWrite: 12.5, CacheWrite1h: 20.0,
+		ServerTools: map[string]float64{"web_search": 0.01},
+		LongContext: &sessions.LongContextPricing{
+			Threshold: 200_000, Input: 20.0, Output: 45.0, CacheRead: 2.0, CacheWrite: 25.0, CacheWrite1h: 40.0,
+		},
+	}
+
+	It("prices one-hour cache writes at their own rate", func() {
+		// 100k input: 40k cache writes (10k of them one-hour), 50k base.
+		//   Base:        50k * $10/M   = $0.50
+		//   5m writes:   30k * $12.5/M = $0.375
+		//   1h writes:   10k * $20/M   = $0.20
+		//   Cache reads: 10k * $1/M    = $0.01
+		inCost, _, _ := sessions.CostForUsage(pricing, sessions.NodeTokens{
+			Input: 100_000, CacheCreation: 40_000, CacheCreation1h: 10_000, CacheRead: 10_000,
+		})
+		Expect(inCost).To(BeNumerically("~", 1.085, 0.0001))
+	})
+
+	It("falls back to the five-minute rate when no one-hour rate is set", func() {
+		base := sessions.Pricing{Input: 10.0, CacheWrite: 12.5}
+		inCost, _, _ := sessions.CostForUsage(base, sessions.NodeTokens{
+			Input: 100_000, CacheCreation: 100_000, CacheCreation1h: 100_000,
+		})
+		Expect(inCost).To(BeNumerically("~", 1.25, 0.0001))
+	})
+
+	It("reprices the whole call past the long-context threshold", func() {
+		_, outCost, total := sessions.CostForUsage(pricing, sessions.NodeTokens{Input: 200_000, Output: 100_000})
+		Expect(outCost).To(BeNumerically("~", 3.0, 0.0001))
+		Expect(total).To(BeNumerically("~", 5.0, 0.0001))
+
+		inCost, outCost, _ := sessions.CostForUsage(pricing, sessions.NodeTokens{Input: 200_001, Output: 100_000})
+		Expect(inCost).To(BeNumerically("~", 4.00002, 0.0001))
+		Expect(outCost).To(BeNumerically("~", 4.5, 0.0001))
+	})
+
+	It("adds per-request server tool fees to the total only", func() {
+		inCost, outCost, total := sessions.CostForUsage(pricing, sessions.NodeTokens{
+			Input: 100_000, Output: 100_000, Reasoning: 80_000,
+			ServerToolUse: map[string]int{"web_search": 3, "web_fetch": 2},
+		})
+		Expect(inCost).To(BeNumerically("~", 1.0, 0.0001))
+		Expect(outCost).To(BeNumerically("~", 3.0, 0.0001))
+		Expect(total).To(BeNumerically("~", 4.03, 0.0001))
+	})
+
+	It("gives the OpenAI o-series the OpenAI service tiers", func() {
+		table := sessions.DefaultPricing()
+		for _, model := range []string{"o1", "o3", "o3-mini", "o4-mini"} {
+			Expect(table[model].Tiers).To(Equal(map[string]float64{"batch": 0.5, "flex": 0.5}), model)
+		}
+		Expect(table["deepseek-r1"].Tiers).To(BeNil())
+	})
+
+	It("scales token cost by the service tier's multiplier", func() {
+		tiered := sessions.Pricing{Input: 10.0, Output: 30.0, Tiers: map[string]float64{"batch": 0.5, "priority": 2.0}}
+		tokens := sessions.NodeTokens{Input: 100_000, Output: 100_000, ServiceTier: "batch"}
+		_, _, total := sessions.CostForUsage(tiered, tokens)
+		Expect(total).To(BeNumerically("~", 2.0, 0.0001))
+
+		tokens.ServiceTier = "default"
+		_, _, total = sessions.CostForUsage(tiered, tokens)
+		Expect(total).To(BeNumerically("~", 4.0, 0.0001))
+	})
+})
+
+var _ = Describe("PricingCatalog", func() {
+	day := func(s string) time.Time {
+		t, err := time.Parse(time.DateOnly, s)
+		Expect(err).NotTo(HaveOccurred())
+		return t
+	}
+	fallback := sessions.PricingTable{"claude-sonnet-4-5": {Input: 3.0, Output: 15.0}}
+	prices := []sessions.CatalogPrice{
+		{Model: "claude-sonnet-4-5", EffectiveFrom: day("2026-03-01"), Pricing: sessions.Pricing{Input: 2.0, Output: 10.0}},
+		{Model: "claude-sonnet-4-5", EffectiveFrom: day("2026-01-01"), Pricing: sessions.Pricing{Input: 4.0, Output: 20.0}},
+	}
+
+	It("prices each instant at the entry in effect then", func() {
+		catalog := sessions.NewPricingCatalog(fallback, prices, nil)
+
+		p, ok := catalog.PricingAt("claude-sonnet-4-5-20250929", day("2026-02-15"))
+		Expect(ok).To(BeTrue())
+		Expect(p.Input).To(Equal(4.0))
+
+		p, _ = catalog.PricingAt("claude-sonnet-4-5", day("2026-03-01"))
+		Expect(p.Input).To(Equal(2.0))
+	})
+
+	It("falls back to the table before the first entry and for uncovered models", func() {
+		catalog := sessions.NewPricingCatalog(fallback, prices, nil)
+
+		p, ok := catalog.PricingAt("claude-sonnet-4-5", day("2025-06-01"))
+		Expect(ok).To(BeTrue())
+		Expect(p.Input).To(Equal(3.0))
+
+		_, ok = catalog.PricingAt("unknown-model", day("2026-06-01"))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("applies the discount in effect, a model-specific one outranking an every-model one", func() {
+		until := day("2026-05-01")
+		catalog := sessions.NewPricingCatalog(fallback, prices, []sessions.PriceDiscount{
+			{Percent: 10, EffectiveFrom: day("2026-01-01")},
+			{Model: "claude-sonnet-4-5", Percent: 50, EffectiveFrom: day("2026-04-01"), EffectiveUntil: &until},
+		})
+
+		p, _ := catalog.PricingAt("claude-sonnet-4-5", day("2026-03-15"))
+		Expect(p.Input).To(BeNumerically("~", 1.8, 0.0001))
+
+		p, _ = catalog.PricingAt("claude-sonnet-4-5", day("2026-04-15"))
+		Expect(p.Input).To(BeNumerically("~", 1.0, 0.0001))
+		Expect(p.Output).To(BeNumerically("~", 5.0, 0.0001))
+
+		p, _ = catalog.PricingAt("claude-sonnet-4-5", day("2026-05-01"))
+		Expect(p.Input).To(BeNumerically("~", 1.8, 0.0001))
+	})
+
+	It("leaves tier multipliers alone when discounting", func() {
+		p := sessions.Pricing{Input: 10.0, Tiers: map[string]float64{"batch": 0.5}, ServerTools: map[string]float64{"web_search": 0.01}}
+		discounted := p.Discounted(20)
+		Expect(discounted.Input).To(BeNumerically("~", 8.0, 0.0001))
+		Expect(discounted.Tiers["batch"]).To(Equal(0.5))
+		Expect(discounted.ServerTools["web_search"]).To(BeNumerically("~", 0.008, 0.00001))
+		Expect(p.ServerTools["web_search"]).To(Equal(0.01))
+	})
+})
+
+var _ = Describe("CapabilityForModel", func() {
+	table := sessions.DefaultCapabilities()
+
+	It("covers every priced model", func() {
+		for model := range sessions.DefaultPricing() {
+			capability, ok := table[model]
+			Expect(ok).To(BeTrue(), model)
+			Expect(capability.ContextWindow).To(BeNumerically(">", 0), model)
+		}
+	})
+
+	It("resolves dated model names through NormalizeModel", func() {
+		capability, ok := sessions.CapabilityForModel(table, "claude-sonnet-4-5-20250929")
+		Expect(ok).To(BeTrue())
+		Expect(capability.ContextWindow).To(Equal(int64(200_000)))
+	})
+
+	It("reports the one-million-token window for the [1m] marker", func() {
+		capability, ok := sessions.CapabilityForModel(table, "claude-opus-4-6[1m]")
+		Expect(ok).To(BeTrue())
+		Expect(capability.ContextWindow).To(Equal(int64(1_000_000)))
+	})
+
+	It("misses unknown models", func() {
+		_, ok := sessions.CapabilityForModel(table, "house-model")
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -1,6 +1,9 @@
 package sessions
 
-import "github.com/papercomputeco/tapes/pkg/merkle"
+import (
+	"github.com/papercomputeco/tapes/pkg/llm"
+	"github.com/papercomputeco/tapes/pkg/merkle"
+)
 
 // NodeTokens holds all token counts for a node, including cache breakdown.
 type NodeTokens struct {
@@ -9,23 +12,48 @@ type NodeTokens struct {
 	Total         int64
 	CacheCreation int64
 	CacheRead     int64
+
+	// CacheCreation1h is the one-hour-TTL part of CacheCreation.
+	CacheCreation1h int64
+
+	// Reasoning is the hidden-reasoning part of Output.
+	Reasoning int64
+
+	// ServerToolUse counts per-request billed tool calls by tool name.
+	ServerToolUse map[string]int
+
+	// ServiceTier is the provider processing tier the call ran on.
+	ServiceTier string
 }
 
 // TokensForNode extracts token counts from a merkle node's Usage metadata.
 // Returns a zero-valued NodeTokens when Usage is nil.
 func TokensForNode(n *merkle.Node) NodeTokens {
+	if n == nil {
+		return NodeTokens{}
+	}
+	return TokensForUsage(n.Usage)
+}
+
+// TokensForUsage extracts token counts from a call's usage. Returns a
+// zero-valued NodeTokens when usage is nil.
+func TokensForUsage(usage *llm.Usage) NodeTokens {
 	var t NodeTokens
-	if n == nil || n.Usage == nil {
+	if usage == nil {
 		return t
 	}
-	t.Input = int64(n.Usage.PromptTokens)
-	t.Output = int64(n.Usage.CompletionTokens)
-	t.CacheCreation = int64(n.Usage.CacheCreationInputTokens)
-	t.CacheRead = int64(n.Usage.CacheReadInputTokens)
+	t.Input = int64(usage.PromptTokens)
+	t.Output = int64(usage.CompletionTokens)
+	t.CacheCreation = int64(usage.CacheCreationInputTokens)
+	t.CacheRead = int64(usage.CacheReadInputTokens)
+	t.CacheCreation1h = int64(usage.CacheCreation1hInputTokens)
+	t.Reasoning = int64(usage.ReasoningTokens)
+	t.ServerToolUse = usage.ServerToolUse
+	t.ServiceTier = usage.ServiceTier
 
 	t.Total = t.Input + t.Output
-	if n.Usage.TotalTokens > 0 {
-		t.Total = int64(n.Usage.TotalTokens)
+	if usage.TotalTokens > 0 {
+		t.Total = int64(usage.TotalTokens)
 	}
 	return t
 }
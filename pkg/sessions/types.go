@@ -15,6 +15,39 @@ type Pricing struct {
 	Output     float64 `json:"output"`
 	CacheRead  float64 `json:"cache_read"`
 	CacheWrite float64 `json:"cache_write"`
+
+	// CacheWrite1h prices cache writes made with a one-hour TTL (Anthropic
+	// bills them at 2x input, against 1.25x for five-minute writes). Zero
+	// prices them at CacheWrite.
+	CacheWrite1h float64 `json:"cache_write_1h,omitempty"`
+
+	// ServerTools is the flat USD fee per request for provider-executed
+	// tools, keyed like llm.Usage.ServerToolUse ("web_search"). Tools
+	// without an entry are free beyond the tokens they add.
+	ServerTools map[string]float64 `json:"server_tools,omitempty"`
+
+	// LongContext, when set, reprices every token of a call whose prompt
+	// exceeds its threshold.
+	LongContext *LongContextPricing `json:"long_context,omitempty"`
+
+	// Tiers multiplies the token cost of a call made on a non-standard
+	// service tier, keyed like llm.Usage.ServiceTier ("batch": 0.5,
+	// "priority": 2). Tiers without an entry bill at the standard rates.
+	Tiers map[string]float64 `json:"tiers,omitempty"`
+}
+
+// LongContextPricing is a model's premium tier for large prompts. Providers
+// apply it to the whole call, not just the tokens past the threshold, so
+// every rate here replaces its base counterpart.
+type LongContextPricing struct {
+	// Threshold is the prompt size, in input tokens including cache reads
+	// and writes, above which the tier applies.
+	Threshold    int64   `json:"threshold"`
+	Input        float64 `json:"input"`
+	Output       float64 `json:"output"`
+	CacheRead    float64 `json:"cache_read"`
+	CacheWrite   float64 `json:"cache_write"`
+	CacheWrite1h float64 `json:"cache_write_1h,omitempty"`
 }
 
 // PricingTable maps model names (after normalization) to their Pricing.
@@ -0,0 +1,73 @@
+package spanembed
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Span result labels.
+const (
+	resultEmbedded = "embedded"
+	resultCurrent  = "current"
+	resultEmpty    = "empty"
+	resultFailed   = "failed"
+)
+
+// Metrics is the Prometheus surface for the embed worker, on its own
+// registry like the derive worker's.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	// Spans counts feed rows by what became of them: embedded, current
+	// (already embedded from the same content), empty (no text to
+	// embed), or failed (the embedder rejected the span alone).
+	Spans *prometheus.CounterVec
+
+	EmbedDuration prometheus.Histogram
+
+	// Errors counts failed pages. ConsecutiveFailures is non-zero while
+	// the store or the embedder is down and the worker is backing off.
+	Errors              prometheus.Counter
+	ConsecutiveFailures prometheus.Gauge
+}
+
+// NewMetrics constructs the embed worker's metrics on a fresh registry.
+func NewMetrics() *Metrics {
+	reg := prometheus.NewRegistry()
+	m := &Metrics{
+		registry: reg,
+		Spans: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "tapes_embed_worker_spans_total",
+				Help: "Change-feed spans handled by the embed worker, by result.",
+			},
+			[]string{"result"},
+		),
+		EmbedDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "tapes_embed_worker_embed_duration_seconds",
+			Help:    "Wall time of one embedder call.",
+			Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+		}),
+		Errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "tapes_embed_worker_errors_total",
+			Help: "Feed pages that failed on the store or the embedder and will be retried.",
+		}),
+		ConsecutiveFailures: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "tapes_embed_worker_consecutive_failures",
+			Help: "Consecutive failed pages; non-zero means the store or the embedder is down and the worker is backing off.",
+		}),
+	}
+	reg.MustRegister(m.Spans, m.EmbedDuration, m.Errors, m.ConsecutiveFailures)
+	return m
+}
+
+// Registry exposes the registry so tests can scrape it.
+func (m *Metrics) Registry() *prometheus.Registry { return m.registry }
+
+// Handler returns the /metrics scrape handler for this worker's
+// registry.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
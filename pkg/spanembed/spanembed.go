@@ -0,0 +1,357 @@
+// Package spanembed is the embed worker: it follows the span change feed
+// and embeds every main-conversation llm span through an
+// embedding.Embedder, storing one vector per span per model for semantic
+// search. It is the single writer of embeddings; capture and derivation
+// never embed inline, so a slow or absent embedder costs search freshness
+// and nothing else.
+//
+// The feed cursor is kept per model. A model the store has never seen
+// starts at the beginning of the feed, so the first run backfills every
+// span already derived, and switching models re-embeds the projection
+// under the new one. Search queries with the configured model only, so
+// during a switch it answers from the spans the new model has reached,
+// and from nothing before its first page lands. Once the new model has
+// caught up, the old one's vectors are pruned.
+package spanembed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/papercomputeco/tapes/pkg/embedding"
+	"github.com/papercomputeco/tapes/pkg/llm"
+	"github.com/papercomputeco/tapes/pkg/sessions"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+// Defaults for Config fields left zero.
+const (
+	DefaultPollInterval   = 5 * time.Second
+	DefaultPageSize       = 256
+	DefaultBatchSize      = 32
+	DefaultMaxTextRunes   = 8000
+	DefaultMaxPollBackoff = 30 * time.Second
+)
+
+// Config tunes the worker loop. Zero fields take the package defaults.
+type Config struct {
+	// PollInterval is how often the feed is read once the worker has
+	// caught up. While a backlog remains, pages are read back to back.
+	PollInterval time.Duration
+
+	// PageSize bounds one read of the feed, and so one cursor step.
+	PageSize int32
+
+	// BatchSize bounds one call to the embedder.
+	BatchSize int
+
+	// MaxTextRunes truncates a span's text before embedding. Embedding
+	// models have a context limit of their own; the head of a turn is
+	// what a search for it is most likely to match.
+	MaxTextRunes int
+
+	// MaxPollBackoff caps the jittered exponential backoff applied while
+	// the store or the embedder is failing.
+	MaxPollBackoff time.Duration
+
+	// Metrics optionally injects a pre-built metrics surface, so the
+	// hosting command can mount /metrics before the store is reachable.
+	Metrics *Metrics
+}
+
+func (c Config) withDefaults() Config {
+	if c.PollInterval <= 0 {
+		c.PollInterval = DefaultPollInterval
+	}
+	if c.PageSize <= 0 {
+		c.PageSize = DefaultPageSize
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = DefaultBatchSize
+	}
+	if c.MaxTextRunes <= 0 {
+		c.MaxTextRunes = DefaultMaxTextRunes
+	}
+	if c.MaxPollBackoff <= 0 {
+		c.MaxPollBackoff = DefaultMaxPollBackoff
+	}
+	return c
+}
+
+// Worker owns the read/embed/write/advance loop for one model.
+type Worker struct {
+	cfg      Config
+	store    storage.SpanEmbeddingStore
+	embedder embedding.Embedder
+	logger   *slog.Logger
+	metrics  *Metrics
+
+	// pruned records that this run has already dropped other models'
+	// vectors; it happens once, the first time the feed is caught up.
+	pruned              bool
+	consecutiveFailures int
+}
+
+// NewWorker creates an embed worker. logger must be non-nil.
+func NewWorker(cfg Config, store storage.SpanEmbeddingStore, embedder embedding.Embedder, logger *slog.Logger) *Worker {
+	metrics := cfg.Metrics
+	if metrics == nil {
+		metrics = NewMetrics()
+	}
+	return &Worker{
+		cfg:      cfg.withDefaults(),
+		store:    store,
+		embedder: embedder,
+		logger:   logger,
+		metrics:  metrics,
+	}
+}
+
+// Ready reports whether the store answers the cursor read the loop
+// starts every page with. It is the /readyz signal.
+func (w *Worker) Ready(ctx context.Context) error {
+	_, err := w.store.SpanEmbedCursor(ctx, w.embedder.Model())
+	return err
+}
+
+// Metrics exposes the worker's Prometheus surface.
+func (w *Worker) Metrics() *Metrics { return w.metrics }
+
+// Run blocks until ctx is canceled. It waits for the embedding tables
+// first, retrying with backoff, then reads the feed a page at a time.
+// Every page's vectors and the cursor past it commit together, so a
+// crash or shutdown mid-page re-embeds at most that page.
+func (w *Worker) Run(ctx context.Context) error {
+	model := w.embedder.Model()
+	w.logger.Info("embed worker starting",
+		"model", model,
+		"poll_interval", w.cfg.PollInterval,
+		"page_size", w.cfg.PageSize,
+		"batch_size", w.cfg.BatchSize,
+	)
+
+	for {
+		err := w.store.CheckSpanEmbeddings(ctx)
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return nil //nolint:nilerr // shutdown is not a failure
+		}
+		if !w.wait(ctx, w.failed("check embedding tables", err)) {
+			return nil
+		}
+	}
+
+	for {
+		n, err := w.runPage(ctx)
+		var delay time.Duration
+		switch {
+		case ctx.Err() != nil:
+			w.logger.Info("embed worker stopping")
+			return nil
+		case err != nil:
+			delay = w.failed("embed page", err)
+		case n == int(w.cfg.PageSize):
+			// A full page means more is waiting: keep going.
+			w.recovered()
+		default:
+			w.recovered()
+			w.caughtUp(ctx, model)
+			delay = w.cfg.PollInterval
+		}
+		if !w.wait(ctx, delay) {
+			w.logger.Info("embed worker stopping")
+			return nil
+		}
+	}
+}
+
+// runPage embeds one page of the feed and advances the cursor past it,
+// returning the page's length.
+func (w *Worker) runPage(ctx context.Context) (int, error) {
+	model := w.embedder.Model()
+	cursor, err := w.store.SpanEmbedCursor(ctx, model)
+	if err != nil {
+		return 0, err
+	}
+	page, err := w.store.ListSpansToEmbed(ctx, model, cursor, w.cfg.PageSize)
+	if err != nil || len(page) == 0 {
+		return 0, err
+	}
+
+	var (
+		todo  []storage.SpanEmbedding
+		texts []string
+	)
+	for _, c := range page {
+		if c.Current {
+			w.metrics.Spans.WithLabelValues(resultCurrent).Inc()
+			continue
+		}
+		text := Text(c.Input, c.Output, w.cfg.MaxTextRunes)
+		if text == "" {
+			w.metrics.Spans.WithLabelValues(resultEmpty).Inc()
+			continue
+		}
+		todo = append(todo, storage.SpanEmbedding{
+			OrgID: c.Cursor.OrgID, TraceID: c.Cursor.TraceID, SpanID: c.Cursor.SpanID, ContentHash: c.ContentHash,
+		})
+		texts = append(texts, text)
+	}
+
+	rows := make([]storage.SpanEmbedding, 0, len(todo))
+	for start := 0; start < len(todo); start += w.cfg.BatchSize {
+		end := min(start+w.cfg.BatchSize, len(todo))
+		embedded, err := w.embedBatch(ctx, todo[start:end], texts[start:end])
+		if err != nil {
+			return 0, err
+		}
+		rows = append(rows, embedded...)
+	}
+
+	if err := w.store.PutSpanEmbeddings(ctx, model, rows, page[len(page)-1].Cursor); err != nil {
+		return 0, err
+	}
+	w.metrics.Spans.WithLabelValues(resultEmbedded).Add(float64(len(rows)))
+	return len(page), nil
+}
+
+// embedBatch embeds one batch. When the batch fails it retries the spans
+// one at a time, to tell a span the embedder cannot take from an
+// embedder that is down: if every span fails alone the batch's error is
+// returned and the page retried later; otherwise the spans that failed
+// alone are logged and left unembedded, so one bad span cannot hold the
+// feed back forever.
+func (w *Worker) embedBatch(ctx context.Context, rows []storage.SpanEmbedding, texts []string) ([]storage.SpanEmbedding, error) {
+	start := time.Now()
+	vectors, err := w.embedder.Embed(ctx, texts)
+	w.metrics.EmbedDuration.Observe(time.Since(start).Seconds())
+	if err == nil {
+		for i := range rows {
+			rows[i].Vector = vectors[i]
+		}
+		return rows, nil
+	}
+	if len(rows) == 1 || ctx.Err() != nil {
+		return nil, err
+	}
+
+	var (
+		out    []storage.SpanEmbedding
+		failed []error
+	)
+	for i, r := range rows {
+		vector, err := w.embedder.Embed(ctx, texts[i:i+1])
+		if err != nil {
+			failed = append(failed, fmt.Errorf("span %s/%s: %w", r.TraceID, r.SpanID, err))
+			continue
+		}
+		r.Vector = vector[0]
+		out = append(out, r)
+	}
+	if len(out) == 0 {
+		return nil, err
+	}
+	for _, f := range failed {
+		w.metrics.Spans.WithLabelValues(resultFailed).Inc()
+		w.logger.Warn("embed worker skipped a span the embedder rejected", "error", f.Error())
+	}
+	return out, nil
+}
+
+// caughtUp prunes the vectors of every other model the first time this
+// run reaches the head of the feed: from then on the current model
+// covers every span the old one did.
+func (w *Worker) caughtUp(ctx context.Context, model string) {
+	if w.pruned {
+		return
+	}
+	removed, err := w.store.PruneSpanEmbeddings(ctx, model)
+	if err != nil {
+		w.logger.Warn("embed worker could not prune other models", "error", err.Error())
+		return
+	}
+	w.pruned = true
+	if removed > 0 {
+		w.logger.Info("embed worker pruned vectors of other models", "model", model, "removed", removed)
+	}
+}
+
+// failed records one consecutive failure and returns the delay before
+// the next attempt: PollInterval doubled per failure, capped, and
+// jittered to 50–100% so replicas do not retry in lockstep.
+func (w *Worker) failed(what string, err error) time.Duration {
+	w.consecutiveFailures++
+	w.metrics.Errors.Inc()
+	w.metrics.ConsecutiveFailures.Set(float64(w.consecutiveFailures))
+
+	delay := w.cfg.PollInterval
+	for i := 1; i < w.consecutiveFailures && delay < w.cfg.MaxPollBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, w.cfg.MaxPollBackoff)
+	half := delay / 2
+	delay = half + rand.N(half+1) //nolint:gosec // retry jitter, not cryptographic material
+
+	w.logger.Warn("embed worker "+what+" failed",
+		"consecutive_failures", w.consecutiveFailures,
+		"retry_in", delay.Round(time.Millisecond),
+		"error", err.Error(),
+	)
+	return delay
+}
+
+func (w *Worker) recovered() {
+	if w.consecutiveFailures == 0 {
+		return
+	}
+	w.logger.Info("embed worker recovered", "failures", w.consecutiveFailures)
+	w.consecutiveFailures = 0
+	w.metrics.ConsecutiveFailures.Set(0)
+}
+
+// wait sleeps for d, reporting false if ctx ends first.
+func (w *Worker) wait(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// Text is what a span is embedded from: its new request content and its
+// response, as the session views render them, truncated to maxRunes.
+// Input and output are the span row's JSON content blocks; a payload
+// that no longer decodes contributes nothing.
+func Text(input, output json.RawMessage, maxRunes int) string {
+	var parts []string
+	for _, raw := range []json.RawMessage{input, output} {
+		if len(raw) == 0 {
+			continue
+		}
+		var blocks []llm.ContentBlock
+		if err := json.Unmarshal(raw, &blocks); err != nil {
+			continue
+		}
+		if text := strings.TrimSpace(sessions.ExtractText(blocks)); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	text := strings.Join(parts, "\n\n")
+	if runes := []rune(text); len(runes) > maxRunes {
+		text = string(runes[:maxRunes])
+	}
+	return text
+}
@@ -0,0 +1,13 @@
+package spanembed_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSpanembed(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Embed Worker Suite")
+}
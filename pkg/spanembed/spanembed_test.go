@@ -0,0 +1,312 @@
+package spanembed_test
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/llm"
+	"github.com/papercomputeco/tapes/pkg/spanembed"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+// feedSpan is one span on the fake change feed.
+type feedSpan struct {
+	cursor      storage.SpanEmbedCursor
+	contentHash string
+	input       json.RawMessage
+	output      json.RawMessage
+}
+
+type vectorKey struct{ org, trace, span, model string }
+
+type storedVector struct {
+	contentHash string
+	vector      []float32
+}
+
+// fakeStore is an in-memory storage.SpanEmbeddingStore with the same
+// feed ordering and current-vector semantics as the Postgres one.
+type fakeStore struct {
+	mu      sync.Mutex
+	feed    []feedSpan
+	vectors map[vectorKey]storedVector
+	cursors map[string]storage.SpanEmbedCursor
+}
+
+func newFakeStore(spans ...feedSpan) *fakeStore {
+	s := &fakeStore{vectors: map[vectorKey]storedVector{}, cursors: map[string]storage.SpanEmbedCursor{}}
+	s.add(spans...)
+	return s
+}
+
+func cursorCompare(a, b storage.SpanEmbedCursor) int {
+	return cmp.Or(cmp.Compare(a.Seq, b.Seq), cmp.Compare(a.OrgID, b.OrgID),
+		cmp.Compare(a.TraceID, b.TraceID), cmp.Compare(a.SpanID, b.SpanID))
+}
+
+func (s *fakeStore) add(spans ...feedSpan) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.feed = append(s.feed, spans...)
+	slices.SortFunc(s.feed, func(a, b feedSpan) int { return cursorCompare(a.cursor, b.cursor) })
+}
+
+func (s *fakeStore) CheckSpanEmbeddings(context.Context) error { return nil }
+
+func (s *fakeStore) SpanEmbedCursor(_ context.Context, model string) (storage.SpanEmbedCursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.cursors[model]; ok {
+		return c, nil
+	}
+	return storage.StartOfSpanFeed, nil
+}
+
+func (s *fakeStore) ListSpansToEmbed(_ context.Context, model string, after storage.SpanEmbedCursor, limit int32) ([]storage.SpanEmbedCandidate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []storage.SpanEmbedCandidate
+	for _, f := range s.feed {
+		if cursorCompare(f.cursor, after) <= 0 {
+			continue
+		}
+		if len(out) == int(limit) {
+			break
+		}
+		stored, ok := s.vectors[vectorKey{f.cursor.OrgID, f.cursor.TraceID, f.cursor.SpanID, model}]
+		c := storage.SpanEmbedCandidate{Cursor: f.cursor, ContentHash: f.contentHash}
+		if ok && stored.contentHash == f.contentHash {
+			c.Current = true
+		} else {
+			c.Input, c.Output = f.input, f.output
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func (s *fakeStore) PutSpanEmbeddings(_ context.Context, model string, rows []storage.SpanEmbedding, next storage.SpanEmbedCursor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range rows {
+		s.vectors[vectorKey{r.OrgID, r.TraceID, r.SpanID, model}] = storedVector{r.ContentHash, r.Vector}
+	}
+	s.cursors[model] = next
+	return nil
+}
+
+func (s *fakeStore) PruneSpanEmbeddings(_ context.Context, keep string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var removed int64
+	for k := range s.vectors {
+		if k.model != keep {
+			delete(s.vectors, k)
+			removed++
+		}
+	}
+	for m := range s.cursors {
+		if m != keep {
+			delete(s.cursors, m)
+		}
+	}
+	return removed, nil
+}
+
+// models returns, per model, the span ids holding a vector.
+func (s *fakeStore) models() map[string][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := map[string][]string{}
+	for k := range s.vectors {
+		out[k.model] = append(out[k.model], k.span)
+	}
+	for _, ids := range out {
+		slices.Sort(ids)
+	}
+	return out
+}
+
+func (s *fakeStore) vector(span, model string) storedVector {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range s.vectors {
+		if k.span == span && k.model == model {
+			return v
+		}
+	}
+	return storedVector{}
+}
+
+// fakeEmbedder embeds a text as its length. reject makes any batch
+// containing a text with that substring fail; down fails every call.
+type fakeEmbedder struct {
+	mu     sync.Mutex
+	model  string
+	reject string
+	down   bool
+	calls  int
+}
+
+func (e *fakeEmbedder) Model() string { return e.model }
+
+func (e *fakeEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls++
+	if e.down {
+		return nil, errors.New("connection refused")
+	}
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		if e.reject != "" && strings.Contains(t, e.reject) {
+			return nil, errors.New("input too long")
+		}
+		out[i] = []float32{float32(len(t)), 1}
+	}
+	return out, nil
+}
+
+func (e *fakeEmbedder) setDown(down bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.down = down
+}
+
+func textBlocks(text string) json.RawMessage {
+	raw, err := json.Marshal([]llm.ContentBlock{{Type: "text", Text: text}})
+	Expect(err).NotTo(HaveOccurred())
+	return raw
+}
+
+func span(seq int64, id, hash, prompt, answer string) feedSpan {
+	return feedSpan{
+		cursor:      storage.SpanEmbedCursor{Seq: seq, OrgID: "00000000-0000-0000-0000-000000000000", TraceID: "t1", SpanID: id},
+		contentHash: hash,
+		input:       textBlocks(prompt),
+		output:      textBlocks(answer),
+	}
+}
+
+var _ = Describe("Worker", func() {
+	var (
+		store    *fakeStore
+		embedder *fakeEmbedder
+		cfg      spanembed.Config
+		logger   = slog.New(slog.NewTextHandler(io.Discard, nil))
+	)
+
+	BeforeEach(func() {
+		embedder = &fakeEmbedder{model: "nomic-embed-text"}
+		cfg = spanembed.Config{PollInterval: 10 * time.Millisecond, MaxPollBackoff: 20 * time.Millisecond, PageSize: 2, BatchSize: 2}
+	})
+
+	run := func(w *spanembed.Worker) {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- w.Run(ctx) }()
+		DeferCleanup(func() {
+			cancel()
+			Eventually(done).Should(Receive(BeNil()))
+		})
+	}
+
+	It("backfills every span on the feed, across pages, skipping empty ones", func() {
+		store = newFakeStore(
+			span(0, "s1", "h1", "fix the build", "done"),
+			span(0, "s2", "h2", "", ""),
+			span(7, "s3", "h3", "add a test", "added"),
+		)
+		run(spanembed.NewWorker(cfg, store, embedder, logger))
+
+		Eventually(store.models).Should(Equal(map[string][]string{"nomic-embed-text": {"s1", "s3"}}))
+		Expect(store.vector("s1", "nomic-embed-text").vector).To(Equal([]float32{float32(len("fix the build\n\ndone")), 1}))
+		Eventually(func() int64 {
+			c, _ := store.SpanEmbedCursor(context.Background(), "nomic-embed-text")
+			return c.Seq
+		}).Should(Equal(int64(7)))
+	})
+
+	It("re-embeds a span whose content changed and leaves unchanged ones alone", func() {
+		store = newFakeStore(span(1, "s1", "h1", "first", "answer"))
+		run(spanembed.NewWorker(cfg, store, embedder, logger))
+		Eventually(store.models).Should(HaveKey("nomic-embed-text"))
+
+		// A re-derive rewrites both rows at a new seq; only s1 changed.
+		store.add(span(9, "s1", "h1b", "first, edited", "answer"), span(9, "s2", "h2", "second", "ok"))
+		Eventually(func() string { return store.vector("s1", "nomic-embed-text").contentHash }).Should(Equal("h1b"))
+		Eventually(store.models).Should(Equal(map[string][]string{"nomic-embed-text": {"s1", "s2"}}))
+	})
+
+	It("re-embeds everything under a new model and then prunes the old one", func() {
+		store = newFakeStore(span(1, "s1", "h1", "a", "b"), span(2, "s2", "h2", "c", "d"))
+		Expect(store.PutSpanEmbeddings(context.Background(), "all-minilm",
+			[]storage.SpanEmbedding{{OrgID: "00000000-0000-0000-0000-000000000000", TraceID: "t1", SpanID: "s1", ContentHash: "h1", Vector: []float32{1}}},
+			storage.SpanEmbedCursor{Seq: 2})).To(Succeed())
+
+		run(spanembed.NewWorker(cfg, store, embedder, logger))
+		Eventually(store.models).Should(Equal(map[string][]string{"nomic-embed-text": {"s1", "s2"}}))
+		Eventually(func() storage.SpanEmbedCursor {
+			c, _ := store.SpanEmbedCursor(context.Background(), "all-minilm")
+			return c
+		}).Should(Equal(storage.StartOfSpanFeed))
+	})
+
+	It("holds the cursor while the embedder is down and resumes when it returns", func() {
+		store = newFakeStore(span(1, "s1", "h1", "a", "b"), span(2, "s2", "h2", "c", "d"))
+		embedder.setDown(true)
+		w := spanembed.NewWorker(cfg, store, embedder, logger)
+		run(w)
+
+		Eventually(func() float64 {
+			return gaugeValue(w.Metrics(), "tapes_embed_worker_consecutive_failures")
+		}).Should(BeNumerically(">=", 2))
+		c, err := store.SpanEmbedCursor(context.Background(), "nomic-embed-text")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c).To(Equal(storage.StartOfSpanFeed))
+
+		embedder.setDown(false)
+		Eventually(store.models).Should(Equal(map[string][]string{"nomic-embed-text": {"s1", "s2"}}))
+		Eventually(func() float64 {
+			return gaugeValue(w.Metrics(), "tapes_embed_worker_consecutive_failures")
+		}).Should(BeZero())
+	})
+
+	It("skips a span the embedder rejects alone instead of stalling the feed", func() {
+		store = newFakeStore(span(1, "s1", "h1", "poison", "x"), span(1, "s2", "h2", "fine", "y"), span(3, "s3", "h3", "also fine", "z"))
+		embedder.reject = "poison"
+		run(spanembed.NewWorker(cfg, store, embedder, logger))
+
+		Eventually(store.models).Should(Equal(map[string][]string{"nomic-embed-text": {"s2", "s3"}}))
+	})
+
+	It("is ready once the store answers its cursor read", func() {
+		store = newFakeStore()
+		w := spanembed.NewWorker(cfg, store, embedder, logger)
+		Expect(w.Ready(context.Background())).To(Succeed())
+	})
+})
+
+func gaugeValue(m *spanembed.Metrics, name string) float64 {
+	mfs, err := m.Registry().Gather()
+	Expect(err).NotTo(HaveOccurred())
+	for _, mf := range mfs {
+		if mf.GetName() == name {
+			for _, metric := range mf.Metric {
+				return metric.GetGauge().GetValue()
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrAnnotationNotFound means no annotation in the org has the given id.
+var ErrAnnotationNotFound = errors.New("annotation not found")
+
+// ErrAnnotationTarget means an annotation names a session, trace or span
+// that does not exist, or a trace and session that do not belong
+// together. Stores wrap it with the offending target.
+var ErrAnnotationTarget = errors.New("annotation target not found")
+
+// Annotation is one judgment on captured work: a label, a numeric score
+// and/or a free-text note under a name, attached to a session, one of
+// its traces, or one span of a trace. TraceID and SpanID are the
+// deterministic ids the deriver writes, so an annotation stays attached
+// across a re-derive; empty TraceID targets the whole session, empty
+// SpanID the whole trace.
+//
+// (SessionID, TraceID, SpanID, Name, Author) is the annotation's key:
+// writing the same key again replaces Label, Score and Note.
+type Annotation struct {
+	ID        string   `json:"id"`
+	SessionID string   `json:"session_id"`
+	TraceID   string   `json:"trace_id,omitempty"`
+	SpanID    string   `json:"span_id,omitempty"`
+	Name      string   `json:"name"`
+	Label     string   `json:"label,omitempty"`
+	Score     *float64 `json:"score,omitempty"`
+	Note      string   `json:"note,omitempty"`
+	// Author is who or what made the judgment: a reviewer, or the
+	// evaluator that scored it.
+	Author    string    `json:"author,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AnnotationFilter narrows ListAnnotations. Every non-empty field is an
+// exact match; AuthSubject keeps annotations on sessions captured for
+// that subject, the way it narrows ListSessionRecords.
+type AnnotationFilter struct {
+	SessionID   string
+	TraceID     string
+	SpanID      string
+	Name        string
+	Label       string
+	Author      string
+	AuthSubject string
+	Limit       int
+}
+
+// AnnotationStats folds one annotation name's judgments: how many there
+// are, the spread of the scored ones, and how often each label was
+// given. Mean, Min and Max are zero when Scored is.
+type AnnotationStats struct {
+	Name   string
+	Count  int
+	Scored int
+	Mean   float64
+	Min    float64
+	Max    float64
+	Labels []LabelCount
+}
+
+// LabelCount is how many of a name's annotations carry one label.
+type LabelCount struct {
+	Label string
+	Count int
+}
+
+// AnnotationStore is an optional capability for a Driver: the annotation
+// table behind /v1/annotations.
+//
+// Only drivers with durable storage implement this (Postgres does;
+// in-memory intentionally does not). Callers MUST type-assert.
+type AnnotationStore interface {
+	// PutAnnotations writes annotations in one transaction, so a bulk
+	// import applies whole or not at all. An annotation whose key already
+	// exists replaces that row's label, score and note. A trace target
+	// needs no SessionID: the store fills it from the trace. A target that
+	// does not exist, or that lies in a session not captured for a
+	// non-empty authSubject, fails with ErrAnnotationTarget. Returns the
+	// stored rows in input order.
+	PutAnnotations(ctx context.Context, orgID, authSubject string, annotations []Annotation) ([]Annotation, error)
+
+	// GetAnnotation returns one annotation, or ErrAnnotationNotFound.
+	GetAnnotation(ctx context.Context, orgID, id string) (*Annotation, error)
+
+	// ListAnnotations returns the filter's annotations, oldest first.
+	ListAnnotations(ctx context.Context, orgID string, filter AnnotationFilter) ([]Annotation, error)
+
+	// UpdateAnnotation replaces the label, score and note of the
+	// annotation with annotation.ID; its target, name and author never
+	// change. Returns ErrAnnotationNotFound when no row has that id.
+	UpdateAnnotation(ctx context.Context, orgID string, annotation Annotation) (*Annotation, error)
+
+	// DeleteAnnotation removes one annotation. Returns
+	// ErrAnnotationNotFound when no row has that id.
+	DeleteAnnotation(ctx context.Context, orgID, id string) error
+
+	// AggregateAnnotations folds the annotations on sessions with a trace
+	// started in [since, until) per name, most annotated first. The window
+	// and authSubject narrow it the way they narrow AggregateSpanStats.
+	AggregateAnnotations(ctx context.Context, orgID string, since, until *time.Time, authSubject string) ([]AnnotationStats, error)
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrAPITokenNotFound means no active API token matches: it never existed,
+// was revoked, or has expired.
+var ErrAPITokenNotFound = errors.New("api token not found")
+
+// APIToken is one row of the API token table. The secret itself is never
+// stored or returned — only Prefix, its first characters, for telling
+// tokens apart.
+type APIToken struct {
+	ID         string     `json:"id"`
+	OrgID      string     `json:"org_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// APITokenCreate is a new token as the store records it: the caller mints
+// the secret (see pkg/apitoken) and hands over only its digest.
+type APITokenCreate struct {
+	// OrgID is the org the token reads and writes in a multi-tenant
+	// deployment. Empty selects the single-tenant sentinel.
+	OrgID     string
+	Name      string
+	Prefix    string
+	Hash      []byte
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+// APITokenStore is an optional capability for a Driver: the token table
+// behind the read API's built-in authentication.
+//
+// Only drivers with durable storage implement this (Postgres does;
+// in-memory intentionally does not). Callers MUST type-assert.
+type APITokenStore interface {
+	// CreateAPIToken records a new token.
+	CreateAPIToken(ctx context.Context, create APITokenCreate) (*APIToken, error)
+
+	// ListAPITokens returns every org's tokens, oldest first. Revoked tokens are
+	// included only when includeRevoked is set.
+	ListAPITokens(ctx context.Context, includeRevoked bool) ([]APIToken, error)
+
+	// LookupAPIToken returns the active token with this digest, or
+	// ErrAPITokenNotFound.
+	LookupAPIToken(ctx context.Context, hash []byte) (*APIToken, error)
+
+	// TouchAPIToken stamps the token's last use.
+	TouchAPIToken(ctx context.Context, id string) error
+
+	// RevokeAPIToken revokes a token by id. Returns ErrAPITokenNotFound
+	// when no unrevoked token has that id.
+	RevokeAPIToken(ctx context.Context, id string) error
+}
@@ -0,0 +1,313 @@
+// Package corpusfile provides a storage.Driver that keeps no database: the
+// raw turns it is handed are appended to a local corpus file, the gzipped
+// JSONL raw_turns dump derive.CorpusWriter writes and derive.ReadCorpus
+// reads. A proxy capturing this way needs nothing but a path, and the file
+// can be derived offline (`tapes derive`), shared, or replayed into a
+// deployment later.
+package corpusfile
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/papercomputeco/tapes/pkg/derive"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+// Config configures a corpus file Driver.
+type Config struct {
+	// Path is the corpus file to append to. It is created, along with
+	// its directory, when missing.
+	Path string
+
+	Logger *slog.Logger
+}
+
+// Driver appends raw turns to a corpus file. It implements
+// storage.RawTurnStore and deliberately not storage.SessionIngester: a
+// corpus carries each turn's session envelope, and the deriver folds the
+// session from it when the file is read.
+//
+// Every turn is written as its own gzip member and synced before
+// PutRawTurn returns, so the file is a complete corpus after every write:
+// a crash loses at most the turn being written, and Open cuts that torn
+// member off before appending again.
+type Driver struct {
+	path   string
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	nextID int64
+	count  int64
+	seen   map[dedupeKey]struct{}
+}
+
+// dedupeKey mirrors the raw layer's unique (org, request id) constraint.
+type dedupeKey struct {
+	orgID     string
+	requestID string
+}
+
+// NewDriver validates cfg and returns a Driver. Open reads the file.
+func NewDriver(cfg Config) (*Driver, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("corpus file path is required")
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Driver{
+		path:   cfg.Path,
+		logger: logger,
+		nextID: 1,
+		seen:   map[dedupeKey]struct{}{},
+	}, nil
+}
+
+// Open opens the corpus file for appending, creating it when missing. An
+// existing file is scanned for the ids and request ids it already holds,
+// so numbering continues and a replayed turn dedupes across restarts.
+func (d *Driver) Open(context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(d.path), 0o750); err != nil {
+		return fmt.Errorf("creating corpus directory: %w", err)
+	}
+	f, err := os.OpenFile(d.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening corpus file: %w", err)
+	}
+
+	good, err := d.scan(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("stat corpus file: %w", err)
+	}
+	if info.Size() > good {
+		d.logger.Warn("corpus file ends in a torn turn, truncating it",
+			"path", d.path, "size", info.Size(), "truncate_to", good)
+		if err := f.Truncate(good); err != nil {
+			f.Close()
+			return fmt.Errorf("truncating torn corpus tail: %w", err)
+		}
+	}
+
+	d.file = f
+	d.size = good
+	return nil
+}
+
+// Close closes the corpus file. Every stored turn is already synced.
+func (d *Driver) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.file == nil {
+		return nil
+	}
+	err := d.file.Close()
+	d.file = nil
+	return err
+}
+
+// PutRawTurn appends one turn, stamping its id and receipt time as the
+// database would. Returns false when a turn with the same org and request
+// id is already in the file.
+func (d *Driver) PutRawTurn(ctx context.Context, rec storage.RawTurnRecord) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.file == nil {
+		return false, errors.New("corpus file driver is not open")
+	}
+	key := dedupeKey{orgID: rec.OrgID, requestID: rec.RequestID}
+	if rec.RequestID != "" {
+		if _, ok := d.seen[key]; ok {
+			return false, nil
+		}
+	}
+
+	rec.ID = d.nextID
+	rec.ReceivedAt = time.Now().UTC()
+
+	var buf bytes.Buffer
+	w := derive.NewCorpusWriter(&buf)
+	if err := w.Write(&rec); err != nil {
+		return false, err
+	}
+	if err := w.Close(); err != nil {
+		return false, err
+	}
+
+	if _, err := d.file.Write(buf.Bytes()); err != nil {
+		// Cut a partial write off so the next turn does not land behind
+		// a torn member.
+		_ = d.file.Truncate(d.size)
+		return false, fmt.Errorf("appending to corpus file: %w", err)
+	}
+	if err := d.file.Sync(); err != nil {
+		return false, fmt.Errorf("syncing corpus file: %w", err)
+	}
+
+	d.size += int64(buf.Len())
+	d.nextID++
+	d.count++
+	if rec.RequestID != "" {
+		d.seen[key] = struct{}{}
+	}
+	return true, nil
+}
+
+// errPageFull stops a ListRawTurns scan once its page is filled.
+var errPageFull = errors.New("page full")
+
+// ListRawTurns reads the file in insertion order, returning up to
+// pageSize turns with id greater than afterID.
+func (d *Driver) ListRawTurns(_ context.Context, afterID int64, pageSize int32) ([]storage.RawTurnRecord, error) {
+	if pageSize <= 0 {
+		return nil, nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	f, err := os.Open(d.path)
+	if err != nil {
+		return nil, fmt.Errorf("opening corpus file: %w", err)
+	}
+	defer f.Close()
+
+	var out []storage.RawTurnRecord
+	err = derive.ReadCorpus(io.LimitReader(f, d.size), func(rec *storage.RawTurnRecord) error {
+		if rec.ID <= afterID {
+			return nil
+		}
+		out = append(out, *rec)
+		if len(out) >= int(pageSize) {
+			return errPageFull
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errPageFull) {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CountRawTurns reports the number of turns in the file.
+func (d *Driver) CountRawTurns(context.Context) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.count, nil
+}
+
+// corpusKeys are the columns Open needs from each existing row.
+type corpusKeys struct {
+	ID        int64  `json:"id"`
+	OrgID     string `json:"org_id"`
+	RequestID string `json:"request_id"`
+}
+
+// scan reads f member by member, recording the rows it holds, and returns
+// the offset just past the last complete member. A file that does not
+// start as a gzip stream is not a corpus and is refused rather than
+// truncated.
+func (d *Driver) scan(f *os.File) (int64, error) {
+	counter := &countingReader{r: f}
+	br := bufio.NewReader(counter)
+	offset := func() int64 { return counter.n - int64(br.Buffered()) }
+
+	zr, err := gzip.NewReader(br)
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return 0, nil
+	case err != nil:
+		return 0, fmt.Errorf("%s is not a corpus file: %w", d.path, err)
+	}
+
+	var good int64
+	for {
+		zr.Multistream(false)
+		if !d.scanMember(zr) {
+			return good, nil
+		}
+		good = offset()
+
+		// io.EOF is the clean end of the file; any other error is a torn
+		// member header, which ends the readable corpus just the same.
+		if zr.Reset(br) != nil {
+			return good, nil
+		}
+	}
+}
+
+// scanMember records the rows of one gzip member, reporting false when
+// the member is torn. Only a complete member counts, so its rows are
+// recorded together.
+func (d *Driver) scanMember(r io.Reader) bool {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 1<<20), 64<<20)
+	var rows []corpusKeys
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var row corpusKeys
+		if json.Unmarshal(line, &row) != nil {
+			return false
+		}
+		rows = append(rows, row)
+	}
+	if scanner.Err() != nil {
+		return false
+	}
+
+	for _, row := range rows {
+		if row.ID >= d.nextID {
+			d.nextID = row.ID + 1
+		}
+		if row.RequestID != "" {
+			d.seen[dedupeKey{orgID: row.OrgID, requestID: row.RequestID}] = struct{}{}
+		}
+		d.count++
+	}
+	return true
+}
+
+// countingReader counts the bytes read through it, so scan can tell where
+// each gzip member ends.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,13 @@
+package corpusfile_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCorpusFile(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Corpus File Driver Suite")
+}
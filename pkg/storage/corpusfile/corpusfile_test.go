@@ -0,0 +1,129 @@
+package corpusfile_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/derive"
+	"github.com/papercomputeco/tapes/pkg/storage"
+	"github.com/papercomputeco/tapes/pkg/storage/corpusfile"
+)
+
+func sampleRecord(requestID string) storage.RawTurnRecord {
+	return storage.RawTurnRecord{
+		Source:           storage.RawTurnSourceWire,
+		Provider:         "anthropic",
+		HarnessID:        "claude",
+		HarnessSessionID: "harness-1",
+		RequestID:        requestID,
+		RawRequest:       json.RawMessage(`{"model":"m","messages":[]}`),
+		Response:         json.RawMessage(`{"model":"m","message":{"role":"assistant","content":[]}}`),
+		Meta:             json.RawMessage(`{"thread_id":"t-1"}`),
+		SessionEnvelope:  json.RawMessage(`{"harness_id":"claude","harness_session_id":"harness-1"}`),
+	}
+}
+
+var _ = Describe("Corpus file driver", func() {
+	var (
+		ctx  context.Context
+		path string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		path = filepath.Join(GinkgoT().TempDir(), "captures", "corpus.jsonl.gz")
+	})
+
+	open := func() *corpusfile.Driver {
+		driver, err := corpusfile.NewDriver(corpusfile.Config{Path: path})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(driver.Open(ctx)).To(Succeed())
+		DeferCleanup(driver.Close)
+		return driver
+	}
+
+	It("appends turns the corpus loader reads back with ids and receipt times", func() {
+		driver := open()
+		for _, id := range []string{"req-1", "req-2"} {
+			stored, err := driver.PutRawTurn(ctx, sampleRecord(id))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stored).To(BeTrue())
+		}
+
+		wire, transcripts, err := derive.LoadCorpusFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(transcripts).To(BeEmpty())
+		Expect(wire).To(HaveLen(2))
+		Expect(wire[0].ID).To(Equal(int64(1)))
+		Expect(wire[1].ID).To(Equal(int64(2)))
+		Expect(wire[1].RequestID).To(Equal("req-2"))
+		Expect(wire[0].ReceivedAt).NotTo(BeZero())
+		Expect(string(wire[0].RawRequest)).To(Equal(`{"model":"m","messages":[]}`))
+	})
+
+	It("dedupes a request id across a reopen and continues numbering", func() {
+		driver := open()
+		_, err := driver.PutRawTurn(ctx, sampleRecord("req-1"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(driver.Close()).To(Succeed())
+
+		driver = open()
+		stored, err := driver.PutRawTurn(ctx, sampleRecord("req-1"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored).To(BeFalse())
+
+		stored, err = driver.PutRawTurn(ctx, sampleRecord("req-2"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored).To(BeTrue())
+
+		count, err := driver.CountRawTurns(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count).To(Equal(int64(2)))
+
+		page, err := driver.ListRawTurns(ctx, 1, 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(page).To(HaveLen(1))
+		Expect(page[0].ID).To(Equal(int64(2)))
+		Expect(page[0].RequestID).To(Equal("req-2"))
+	})
+
+	It("cuts a torn trailing turn off before appending again", func() {
+		driver := open()
+		_, err := driver.PutRawTurn(ctx, sampleRecord("req-1"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(driver.Close()).To(Succeed())
+
+		// A crash mid-write leaves the head of a second member behind.
+		intact, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(path, append(intact, intact[:len(intact)/2]...), 0o600)).To(Succeed())
+
+		driver = open()
+		stored, err := driver.PutRawTurn(ctx, sampleRecord("req-2"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored).To(BeTrue())
+
+		wire, _, err := derive.LoadCorpusFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wire).To(HaveLen(2))
+		Expect(wire[1].ID).To(Equal(int64(2)))
+	})
+
+	It("refuses a file that is not a corpus instead of truncating it", func() {
+		Expect(os.MkdirAll(filepath.Dir(path), 0o750)).To(Succeed())
+		Expect(os.WriteFile(path, []byte("{\"not\":\"gzip\"}\n"), 0o600)).To(Succeed())
+
+		driver, err := corpusfile.NewDriver(corpusfile.Config{Path: path})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(driver.Open(ctx)).To(MatchError(ContainSubstring("not a corpus file")))
+
+		content, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal("{\"not\":\"gzip\"}\n"))
+	})
+})
@@ -0,0 +1,357 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/papercomputeco/tapes/pkg/storage"
+	"github.com/papercomputeco/tapes/pkg/storage/postgres/gensqlc"
+)
+
+// Compile-time guarantee that the Postgres driver hosts the annotation
+// table the API server type-asserts for.
+var _ storage.AnnotationStore = (*Driver)(nil)
+
+// PutAnnotations implements storage.AnnotationStore. Targets are checked
+// and written in one transaction, so a bulk import applies whole or not
+// at all.
+func (d *Driver) PutAnnotations(ctx context.Context, orgID, authSubject string, annotations []storage.Annotation) ([]storage.Annotation, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	org, err := orgIDFromString(orgKeyForLookup(orgID))
+	if err != nil {
+		return nil, fmt.Errorf("decode org_id: %w", err)
+	}
+	tx, err := d.conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // commit shadows on success
+	qtx := d.q.WithTx(tx)
+
+	out := make([]storage.Annotation, 0, len(annotations))
+	for _, ann := range annotations {
+		sessionID, err := resolveAnnotationTarget(ctx, qtx, org, authSubject, ann)
+		if err != nil {
+			return nil, err
+		}
+		id, err := newAppUUID()
+		if err != nil {
+			return nil, fmt.Errorf("mint annotation id: %w", err)
+		}
+		row, err := qtx.UpsertAnnotation(ctx, gensqlc.UpsertAnnotationParams{
+			ID:        id,
+			OrgID:     org,
+			SessionID: sessionID,
+			TraceID:   ann.TraceID,
+			SpanID:    ann.SpanID,
+			Name:      ann.Name,
+			Label:     ann.Label,
+			Score:     scoreParam(ann.Score),
+			Note:      ann.Note,
+			Author:    ann.Author,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("upsert annotation %s: %w", ann.Name, err)
+		}
+		out = append(out, annotationFromRow(row))
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	return out, nil
+}
+
+// resolveAnnotationTarget checks that an annotation's target exists and
+// is visible under authSubject, and returns the session it belongs to. A
+// trace target's session comes from the trace; a session id given
+// alongside it must agree.
+func resolveAnnotationTarget(ctx context.Context, qtx *gensqlc.Queries, org pgtype.UUID, authSubject string, ann storage.Annotation) (pgtype.UUID, error) {
+	var (
+		sessionID pgtype.UUID
+		subject   string
+	)
+	if ann.TraceID == "" {
+		parsed, err := uuid.Parse(ann.SessionID)
+		if err != nil {
+			return pgtype.UUID{}, fmt.Errorf("%w: session %q", storage.ErrAnnotationTarget, ann.SessionID)
+		}
+		sessionID = pgtype.UUID{Bytes: parsed, Valid: true}
+		subject, err = qtx.GetAnnotationSessionSubject(ctx, gensqlc.GetAnnotationSessionSubjectParams{
+			OrgID: org,
+			ID:    sessionID,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pgtype.UUID{}, fmt.Errorf("%w: session %s", storage.ErrAnnotationTarget, ann.SessionID)
+		}
+		if err != nil {
+			return pgtype.UUID{}, fmt.Errorf("look up session %s: %w", ann.SessionID, err)
+		}
+	} else {
+		row, err := qtx.GetAnnotationTraceSession(ctx, gensqlc.GetAnnotationTraceSessionParams{
+			OrgID:   org,
+			TraceID: ann.TraceID,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pgtype.UUID{}, fmt.Errorf("%w: trace %s", storage.ErrAnnotationTarget, ann.TraceID)
+		}
+		if err != nil {
+			return pgtype.UUID{}, fmt.Errorf("look up trace %s: %w", ann.TraceID, err)
+		}
+		if ann.SessionID != "" && ann.SessionID != uuidToString(row.SessionID) {
+			return pgtype.UUID{}, fmt.Errorf("%w: trace %s is not in session %s",
+				storage.ErrAnnotationTarget, ann.TraceID, ann.SessionID)
+		}
+		sessionID, subject = row.SessionID, row.AuthSubject
+	}
+	// A session the caller may not see is answered like a missing one.
+	if authSubject != "" && subject != authSubject {
+		if ann.TraceID != "" {
+			return pgtype.UUID{}, fmt.Errorf("%w: trace %s", storage.ErrAnnotationTarget, ann.TraceID)
+		}
+		return pgtype.UUID{}, fmt.Errorf("%w: session %s", storage.ErrAnnotationTarget, ann.SessionID)
+	}
+	if ann.SpanID != "" {
+		ok, err := qtx.AnnotationSpanExists(ctx, gensqlc.AnnotationSpanExistsParams{
+			OrgID:   org,
+			TraceID: ann.TraceID,
+			SpanID:  ann.SpanID,
+		})
+		if err != nil {
+			return pgtype.UUID{}, fmt.Errorf("look up span %s/%s: %w", ann.TraceID, ann.SpanID, err)
+		}
+		if !ok {
+			return pgtype.UUID{}, fmt.Errorf("%w: span %s in trace %s", storage.ErrAnnotationTarget, ann.SpanID, ann.TraceID)
+		}
+	}
+	return sessionID, nil
+}
+
+// GetAnnotation implements storage.AnnotationStore.
+func (d *Driver) GetAnnotation(ctx context.Context, orgID, id string) (*storage.Annotation, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		// Not an annotation id, so certainly not one that exists.
+		return nil, storage.ErrAnnotationNotFound
+	}
+	org, err := orgIDFromString(orgKeyForLookup(orgID))
+	if err != nil {
+		return nil, fmt.Errorf("decode org_id: %w", err)
+	}
+	row, err := d.q.GetAnnotation(ctx, gensqlc.GetAnnotationParams{
+		OrgID: org,
+		ID:    pgtype.UUID{Bytes: parsed, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, storage.ErrAnnotationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get annotation: %w", err)
+	}
+	ann := annotationFromRow(row)
+	return &ann, nil
+}
+
+// ListAnnotations implements storage.AnnotationStore.
+func (d *Driver) ListAnnotations(ctx context.Context, orgID string, filter storage.AnnotationFilter) ([]storage.Annotation, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	org, err := orgIDFromString(orgKeyForLookup(orgID))
+	if err != nil {
+		return nil, fmt.Errorf("decode org_id: %w", err)
+	}
+	var sessionID pgtype.UUID
+	if filter.SessionID != "" {
+		parsed, err := uuid.Parse(filter.SessionID)
+		if err != nil {
+			return nil, fmt.Errorf("list annotations: invalid session id %q: %w", filter.SessionID, err)
+		}
+		sessionID = pgtype.UUID{Bytes: parsed, Valid: true}
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = storage.DefaultListLimit
+	}
+	text := func(s string) pgtype.Text { return pgtype.Text{String: s, Valid: s != ""} }
+	rows, err := d.q.ListAnnotations(ctx, gensqlc.ListAnnotationsParams{
+		OrgID:             org,
+		SessionID:         sessionID,
+		TraceID:           text(filter.TraceID),
+		SpanID:            text(filter.SpanID),
+		Name:              text(filter.Name),
+		Label:             text(filter.Label),
+		Author:            text(filter.Author),
+		AuthSubjectFilter: text(filter.AuthSubject),
+		RowLimit:          int32(limit), //nolint:gosec // limit bounded by the API handler
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list annotations: %w", err)
+	}
+	out := make([]storage.Annotation, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, annotationFromRow(row))
+	}
+	return out, nil
+}
+
+// UpdateAnnotation implements storage.AnnotationStore.
+func (d *Driver) UpdateAnnotation(ctx context.Context, orgID string, annotation storage.Annotation) (*storage.Annotation, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	parsed, err := uuid.Parse(annotation.ID)
+	if err != nil {
+		return nil, storage.ErrAnnotationNotFound
+	}
+	org, err := orgIDFromString(orgKeyForLookup(orgID))
+	if err != nil {
+		return nil, fmt.Errorf("decode org_id: %w", err)
+	}
+	row, err := d.q.UpdateAnnotation(ctx, gensqlc.UpdateAnnotationParams{
+		Label: annotation.Label,
+		Score: scoreParam(annotation.Score),
+		Note:  annotation.Note,
+		OrgID: org,
+		ID:    pgtype.UUID{Bytes: parsed, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, storage.ErrAnnotationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("update annotation: %w", err)
+	}
+	ann := annotationFromRow(row)
+	return &ann, nil
+}
+
+// DeleteAnnotation implements storage.AnnotationStore.
+func (d *Driver) DeleteAnnotation(ctx context.Context, orgID, id string) error {
+	if d == nil || d.conn == nil {
+		return errors.New("postgres driver not open")
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return storage.ErrAnnotationNotFound
+	}
+	org, err := orgIDFromString(orgKeyForLookup(orgID))
+	if err != nil {
+		return fmt.Errorf("decode org_id: %w", err)
+	}
+	n, err := d.q.DeleteAnnotation(ctx, gensqlc.DeleteAnnotationParams{
+		OrgID: org,
+		ID:    pgtype.UUID{Bytes: parsed, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("delete annotation: %w", err)
+	}
+	if n == 0 {
+		return storage.ErrAnnotationNotFound
+	}
+	return nil
+}
+
+// AggregateAnnotations implements storage.AnnotationStore. The query
+// groups by name and label; the labels fold into their name here, most
+// annotated name first.
+func (d *Driver) AggregateAnnotations(ctx context.Context, orgID string, since, until *time.Time, authSubject string) ([]storage.AnnotationStats, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	org, err := orgIDFromString(orgKeyForLookup(orgID))
+	if err != nil {
+		return nil, fmt.Errorf("decode org_id: %w", err)
+	}
+	rows, err := d.q.AggregateAnnotations(ctx, gensqlc.AggregateAnnotationsParams{
+		OrgID:             org,
+		AuthSubjectFilter: pgtype.Text{String: authSubject, Valid: authSubject != ""},
+		SinceFilter:       nullTimePtr(since),
+		UntilFilter:       nullTimePtr(until),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("aggregate annotations: %w", err)
+	}
+	return foldAnnotationStats(rows), nil
+}
+
+// foldAnnotationStats folds per-(name, label) rows, ordered by name, into
+// per-name stats ordered by count.
+func foldAnnotationStats(rows []gensqlc.AggregateAnnotationsRow) []storage.AnnotationStats {
+	out := []storage.AnnotationStats{}
+	var sum float64
+	for _, r := range rows {
+		if len(out) == 0 || out[len(out)-1].Name != r.Name {
+			finishAnnotationMean(out, sum)
+			out = append(out, storage.AnnotationStats{Name: r.Name, Min: math.Inf(1), Max: math.Inf(-1)})
+			sum = 0
+		}
+		st := &out[len(out)-1]
+		st.Count += int(r.Annotations)
+		if r.Scored > 0 {
+			st.Scored += int(r.Scored)
+			sum += r.ScoreSum
+			st.Min = min(st.Min, r.ScoreMin)
+			st.Max = max(st.Max, r.ScoreMax)
+		}
+		if r.Label != "" {
+			st.Labels = append(st.Labels, storage.LabelCount{Label: r.Label, Count: int(r.Annotations)})
+		}
+	}
+	finishAnnotationMean(out, sum)
+	slices.SortStableFunc(out, func(a, b storage.AnnotationStats) int { return b.Count - a.Count })
+	return out
+}
+
+// finishAnnotationMean closes the last name in out: its mean from the
+// score sum, and zeroed bounds when nothing was scored.
+func finishAnnotationMean(out []storage.AnnotationStats, sum float64) {
+	if len(out) == 0 {
+		return
+	}
+	st := &out[len(out)-1]
+	if st.Scored == 0 {
+		st.Min, st.Max = 0, 0
+		return
+	}
+	st.Mean = sum / float64(st.Scored)
+}
+
+func annotationFromRow(row gensqlc.Annotation) storage.Annotation {
+	ann := storage.Annotation{
+		ID:        uuidToString(row.ID),
+		SessionID: uuidToString(row.SessionID),
+		TraceID:   row.TraceID,
+		SpanID:    row.SpanID,
+		Name:      row.Name,
+		Label:     row.Label,
+		Note:      row.Note,
+		Author:    row.Author,
+		CreatedAt: row.CreatedAt.Time,
+		UpdatedAt: row.UpdatedAt.Time,
+	}
+	if row.Score.Valid {
+		score := row.Score.Float64
+		ann.Score = &score
+	}
+	return ann
+}
+
+func scoreParam(score *float64) pgtype.Float8 {
+	if score == nil {
+		return pgtype.Float8{}
+	}
+	return pgtype.Float8{Float64: *score, Valid: true}
+}
@@ -0,0 +1,222 @@
+package postgres_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/storage"
+	"github.com/papercomputeco/tapes/pkg/storage/postgres"
+)
+
+var _ = Describe("annotations [postgres]", func() {
+	var (
+		ctx    context.Context
+		driver *postgres.Driver
+		orgID  string
+		at     time.Time
+	)
+
+	exec := func(sql string, args ...any) {
+		_, err := driver.DB().Exec(ctx, sql, args...)
+		Expect(err).NotTo(HaveOccurred(), sql)
+	}
+
+	// seedSession writes a session captured for subject with one turn,
+	// traceID, holding one tool span, span-1.
+	seedSession := func(subject, traceID string) string {
+		sessionID := uuid.NewString()
+		exec(`INSERT INTO sessions (id, org_id, auth_subject, harness_id, harness_session_id, started_at, last_seen_at)
+			VALUES ($1, $2, $3, 'claude', $4, $5, $5)`, sessionID, orgID, subject, "ann-"+sessionID, at)
+		exec(`INSERT INTO span_turns_20260615 (org_id, trace_id, session_id, started_at)
+			VALUES ($1, $2, $3, $4)`, orgID, traceID, sessionID, at)
+		exec(`INSERT INTO spans_20260615 (org_id, trace_id, span_id, session_id, kind, started_at)
+			VALUES ($1, $2, 'span-1', $3, 'tool', $4)`, orgID, traceID, sessionID, at)
+		return sessionID
+	}
+
+	score := func(v float64) *float64 { return &v }
+
+	put := func(anns ...storage.Annotation) []storage.Annotation {
+		out, err := driver.PutAnnotations(ctx, orgID, "", anns)
+		Expect(err).NotTo(HaveOccurred())
+		return out
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var err error
+		driver, err = postgres.NewDriver(ctx, testPostgresDSN)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(driver.Close)
+		orgID = newTestOrgID()
+		at = time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	})
+
+	Describe("PutAnnotations", func() {
+		It("replaces label, score and note on the same (target, name, author)", func() {
+			sessionID := seedSession("alice", "trace-a")
+
+			first := put(storage.Annotation{SessionID: sessionID, Name: "correctness", Label: "good", Score: score(1), Author: "alice"})
+			second := put(storage.Annotation{SessionID: sessionID, Name: "correctness", Label: "bad", Score: score(0), Note: "wrong file", Author: "alice"})
+			Expect(second[0].ID).To(Equal(first[0].ID))
+			Expect(second[0].Label).To(Equal("bad"))
+			Expect(*second[0].Score).To(Equal(0.0))
+			Expect(second[0].Note).To(Equal("wrong file"))
+
+			// Another author, or the same name on a span, is a row of its own.
+			put(
+				storage.Annotation{SessionID: sessionID, Name: "correctness", Label: "good", Author: "bob"},
+				storage.Annotation{TraceID: "trace-a", SpanID: "span-1", Name: "correctness", Label: "good", Author: "alice"},
+			)
+			got, err := driver.ListAnnotations(ctx, orgID, storage.AnnotationFilter{SessionID: sessionID})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got).To(HaveLen(3))
+			Expect(got[0].ID).To(Equal(first[0].ID))
+			Expect(got[0].Label).To(Equal("bad"))
+		})
+
+		It("fills a trace target's session from the trace", func() {
+			sessionID := seedSession("alice", "trace-a")
+
+			out := put(storage.Annotation{TraceID: "trace-a", Name: "helpful", Score: score(0.5)})
+			Expect(out[0].SessionID).To(Equal(sessionID))
+		})
+
+		It("writes nothing when one target in the batch is missing", func() {
+			sessionID := seedSession("alice", "trace-a")
+
+			_, err := driver.PutAnnotations(ctx, orgID, "", []storage.Annotation{
+				{SessionID: sessionID, Name: "correctness", Label: "good"},
+				{TraceID: "trace-a", SpanID: "span-404", Name: "correctness", Label: "bad"},
+			})
+			Expect(err).To(MatchError(storage.ErrAnnotationTarget))
+
+			got, err := driver.ListAnnotations(ctx, orgID, storage.AnnotationFilter{SessionID: sessionID})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got).To(BeEmpty())
+		})
+
+		It("rejects a missing span, trace or session with ErrAnnotationTarget", func() {
+			sessionID := seedSession("alice", "trace-a")
+			seedSession("alice", "trace-b")
+
+			for _, ann := range []storage.Annotation{
+				{TraceID: "trace-a", SpanID: "span-404", Name: "n", Label: "l"},
+				{TraceID: "trace-404", Name: "n", Label: "l"},
+				{SessionID: uuid.NewString(), Name: "n", Label: "l"},
+				{SessionID: sessionID, TraceID: "trace-b", Name: "n", Label: "l"},
+			} {
+				_, err := driver.PutAnnotations(ctx, orgID, "", []storage.Annotation{ann})
+				Expect(err).To(MatchError(storage.ErrAnnotationTarget), "%+v", ann)
+			}
+		})
+
+		It("answers a session captured for another subject like a missing one", func() {
+			sessionID := seedSession("alice", "trace-a")
+
+			_, err := driver.PutAnnotations(ctx, orgID, "bob", []storage.Annotation{
+				{SessionID: sessionID, Name: "correctness", Label: "good"},
+			})
+			Expect(err).To(MatchError(storage.ErrAnnotationTarget))
+		})
+	})
+
+	Describe("AggregateAnnotations", func() {
+		var sessionID string
+
+		BeforeEach(func() {
+			sessionID = seedSession("alice", "trace-a")
+			other := seedSession("bob", "trace-b")
+			put(
+				storage.Annotation{SessionID: sessionID, Name: "correctness", Label: "good", Score: score(1), Author: "r1"},
+				storage.Annotation{SessionID: sessionID, Name: "correctness", Label: "good", Score: score(0.5), Author: "r2"},
+				storage.Annotation{SessionID: other, Name: "correctness", Label: "bad", Score: score(0), Author: "r1"},
+				storage.Annotation{SessionID: sessionID, Name: "flagged", Note: "look again"},
+			)
+		})
+
+		It("counts, labels and scores each name, most annotated first", func() {
+			stats, err := driver.AggregateAnnotations(ctx, orgID, nil, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats).To(HaveLen(2))
+
+			Expect(stats[0].Name).To(Equal("correctness"))
+			Expect(stats[0].Count).To(Equal(3))
+			Expect(stats[0].Scored).To(Equal(3))
+			Expect(stats[0].Mean).To(BeNumerically("~", 0.5, 1e-9))
+			Expect(stats[0].Min).To(Equal(0.0))
+			Expect(stats[0].Max).To(Equal(1.0))
+			Expect(stats[0].Labels).To(ConsistOf(
+				storage.LabelCount{Label: "good", Count: 2},
+				storage.LabelCount{Label: "bad", Count: 1},
+			))
+
+			// Nothing scored: zero bounds, not infinities; no label, no entry.
+			Expect(stats[1]).To(Equal(storage.AnnotationStats{Name: "flagged", Count: 1}))
+		})
+
+		It("narrows to a subject's sessions", func() {
+			stats, err := driver.AggregateAnnotations(ctx, orgID, nil, nil, "bob")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats).To(HaveLen(1))
+			Expect(stats[0].Count).To(Equal(1))
+			Expect(stats[0].Labels).To(ConsistOf(storage.LabelCount{Label: "bad", Count: 1}))
+		})
+
+		It("keeps sessions with a turn started in [since, until)", func() {
+			since, until := at, at.Add(time.Hour)
+			stats, err := driver.AggregateAnnotations(ctx, orgID, &since, &until, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats).To(HaveLen(2))
+
+			since, until = at.Add(time.Hour), at.Add(2*time.Hour)
+			stats, err = driver.AggregateAnnotations(ctx, orgID, &since, &until, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats).To(BeEmpty())
+		})
+	})
+
+	Describe("ListSessionRecords by annotation", func() {
+		var good, bad string
+
+		list := func(m storage.AnnotationMatch) []string {
+			recs, err := driver.ListSessionRecords(ctx, orgID, storage.SessionListOpts{Annotation: m})
+			Expect(err).NotTo(HaveOccurred())
+			ids := make([]string, 0, len(recs))
+			for _, r := range recs {
+				ids = append(ids, r.ID)
+			}
+			return ids
+		}
+
+		BeforeEach(func() {
+			good = seedSession("alice", "trace-a")
+			bad = seedSession("alice", "trace-b")
+			seedSession("alice", "trace-c") // never annotated
+			put(
+				storage.Annotation{TraceID: "trace-a", Name: "eval:task", Label: "pass", Score: score(0.9)},
+				storage.Annotation{TraceID: "trace-b", Name: "eval:task", Label: "fail", Score: score(0.2)},
+				// A high score under another name must not lift bad into
+				// an eval:task score range.
+				storage.Annotation{SessionID: bad, Name: "style", Score: score(0.95)},
+			)
+		})
+
+		It("matches on name and label", func() {
+			Expect(list(storage.AnnotationMatch{Name: "eval:task"})).To(ConsistOf(good, bad))
+			Expect(list(storage.AnnotationMatch{Label: "fail"})).To(ConsistOf(bad))
+			Expect(list(storage.AnnotationMatch{Name: "style", Label: "pass"})).To(BeEmpty())
+		})
+
+		It("requires one annotation to satisfy the name and the score range", func() {
+			Expect(list(storage.AnnotationMatch{Name: "eval:task", MinScore: score(0.5)})).To(ConsistOf(good))
+			Expect(list(storage.AnnotationMatch{Name: "eval:task", MaxScore: score(0.5)})).To(ConsistOf(bad))
+			Expect(list(storage.AnnotationMatch{MinScore: score(0.5), MaxScore: score(0.92)})).To(ConsistOf(good))
+			Expect(list(storage.AnnotationMatch{MinScore: score(0.93)})).To(ConsistOf(bad))
+		})
+	})
+})
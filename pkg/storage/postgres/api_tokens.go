@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/papercomputeco/tapes/pkg/storage"
+	"github.com/papercomputeco/tapes/pkg/storage/postgres/gensqlc"
+)
+
+// Compile-time guarantee that the Postgres driver hosts the token table
+// the API server's auth middleware type-asserts for.
+var _ storage.APITokenStore = (*Driver)(nil)
+
+// CreateAPIToken implements storage.APITokenStore. Tokens belong to the
+// deployment's single tenant, like every other row.
+func (d *Driver) CreateAPIToken(ctx context.Context, create storage.APITokenCreate) (*storage.APIToken, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	id, err := newAppUUID()
+	if err != nil {
+		return nil, fmt.Errorf("mint api token id: %w", err)
+	}
+	orgID, err := orgIDFromString(create.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	var expires pgtype.Timestamptz
+	if create.ExpiresAt != nil {
+		expires = pgtype.Timestamptz{Time: *create.ExpiresAt, Valid: true}
+	}
+	row, err := d.q.InsertAPIToken(ctx, gensqlc.InsertAPITokenParams{
+		ID:          id,
+		OrgID:       orgID,
+		Name:        create.Name,
+		TokenPrefix: create.Prefix,
+		TokenHash:   create.Hash,
+		Scopes:      create.Scopes,
+		ExpiresAt:   expires,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("insert api token: %w", err)
+	}
+	tok := apiTokenFromRow(gensqlc.ListAPITokensRow(row))
+	return &tok, nil
+}
+
+// ListAPITokens implements storage.APITokenStore.
+func (d *Driver) ListAPITokens(ctx context.Context, includeRevoked bool) ([]storage.APIToken, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	rows, err := d.q.ListAPITokens(ctx, includeRevoked)
+	if err != nil {
+		return nil, fmt.Errorf("list api tokens: %w", err)
+	}
+	out := make([]storage.APIToken, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, apiTokenFromRow(row))
+	}
+	return out, nil
+}
+
+// LookupAPIToken implements storage.APITokenStore.
+func (d *Driver) LookupAPIToken(ctx context.Context, hash []byte) (*storage.APIToken, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	row, err := d.q.FindActiveAPITokenByHash(ctx, hash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, storage.ErrAPITokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("look up api token: %w", err)
+	}
+	tok := apiTokenFromRow(gensqlc.ListAPITokensRow(row))
+	return &tok, nil
+}
+
+// TouchAPIToken implements storage.APITokenStore.
+func (d *Driver) TouchAPIToken(ctx context.Context, id string) error {
+	if d == nil || d.conn == nil {
+		return errors.New("postgres driver not open")
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid api token id %q: %w", id, err)
+	}
+	if err := d.q.TouchAPIToken(ctx, pgtype.UUID{Bytes: parsed, Valid: true}); err != nil {
+		return fmt.Errorf("touch api token: %w", err)
+	}
+	return nil
+}
+
+// RevokeAPIToken implements storage.APITokenStore.
+func (d *Driver) RevokeAPIToken(ctx context.Context, id string) error {
+	if d == nil || d.conn == nil {
+		return errors.New("postgres driver not open")
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		// Not a token id, so certainly not one that exists.
+		return storage.ErrAPITokenNotFound
+	}
+	n, err := d.q.RevokeAPIToken(ctx, pgtype.UUID{Bytes: parsed, Valid: true})
+	if err != nil {
+		return fmt.Errorf("revoke api token: %w", err)
+	}
+	if n == 0 {
+		return storage.ErrAPITokenNotFound
+	}
+	return nil
+}
+
+func apiTokenFromRow(row gensqlc.ListAPITokensRow) storage.APIToken {
+	return storage.APIToken{
+		ID:         uuidString(row.ID),
+		OrgID:      uuidString(row.OrgID),
+		Name:       row.Name,
+		Prefix:     row.TokenPrefix,
+		Scopes:     row.Scopes,
+		CreatedAt:  row.CreatedAt.Time,
+		ExpiresAt:  optionalTime(row.ExpiresAt),
+		LastUsedAt: optionalTime(row.LastUsedAt),
+		RevokedAt:  optionalTime(row.RevokedAt),
+	}
+}
+
+func optionalTime(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	t := ts.Time
+	return &t
+}
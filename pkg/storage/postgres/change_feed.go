@@ -20,6 +20,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"hash"
+	"math"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
@@ -58,15 +59,23 @@ const (
 	// every rollup, and rollups take the worst tier, so the split would be
 	// lost at the trace level anyway.
 	FidelityDegraded = "degraded"
+
+	// FidelityStripped marks a row whose content a retention rule removed
+	// after capture (see 1781540000_retention). The usage and cost it was
+	// folded from survive; the prompt and response do not, anywhere. It is
+	// the worst tier: degraded lost the verbatim bytes but kept the
+	// reduction, stripped kept neither.
+	FidelityStripped = "stripped"
 )
 
 // fidelityRank orders the tiers worst-first for the trace rollup. A trace is
 // only as trustworthy as its least-recoverable span.
 var fidelityRank = map[string]int{
-	FidelityDegraded: 0,
-	FidelityReduced:  1,
-	FidelityRaw:      2,
-	FidelityUnbacked: 3,
+	FidelityStripped: 0,
+	FidelityDegraded: 1,
+	FidelityReduced:  2,
+	FidelityRaw:      3,
+	FidelityUnbacked: 4,
 }
 
 // resolveFidelity maps each raw turn referenced by the set to its provenance
@@ -102,6 +111,8 @@ func resolveFidelity(ctx context.Context, qtx *gensqlc.Queries, spans *derive.Sp
 	out := make(map[int64]string, len(rows))
 	for _, r := range rows {
 		switch {
+		case r.PayloadStripped:
+			out[r.ID] = FidelityStripped
 		case r.RawResponseDropped:
 			out[r.ID] = FidelityDegraded
 		case r.HasRawResponse:
@@ -169,6 +180,13 @@ func (c *contentHasher) i64(v int64) *contentHasher {
 
 func (c *contentHasher) i32(v int32) *contentHasher { return c.i64(int64(v)) }
 
+// f64 hashes a float by its IEEE-754 bits.
+func (c *contentHasher) f64(v float64) *contentHasher {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], math.Float64bits(v))
+	return c.bytes(b[:])
+}
+
 func (c *contentHasher) boolean(v bool) *contentHasher {
 	if v {
 		return c.bytes([]byte{1})
@@ -238,6 +256,8 @@ func spanContentHash(p gensqlc.UpsertSpanParams) string {
 		boolean(p.RawTurnID.Valid).
 		str(p.NodeHash).
 		str(p.Fidelity).
+		bytes(p.Tool).
+		str(p.RetryOf).
 		sum()
 }
 
@@ -262,5 +282,11 @@ func spanTurnContentHash(p gensqlc.UpsertSpanTurnParams) string {
 		i64(p.ToolCalls).
 		str(p.Source).
 		str(p.Fidelity).
+		str(p.ParentTraceID).
+		boolean(p.Abandoned).
+		i64(p.PeakContextTokens).
+		f64(p.PeakContextOccupancy).
+		i64(p.ContextGrowthTokens).
+		i64(p.ReclaimedTokens).
 		sum()
 }
@@ -37,6 +37,22 @@ type rawTurnIndexEntry struct {
 // acquisition. The pass is atomic per session, not per org; reports retain the
 // existing per-org shape by aggregating session reports.
 func (d *Driver) RederiveFromRaw(ctx context.Context, project string) (map[string]*derive.RederiveReport, error) {
+	return d.rederiveFromRaw(ctx, project, nil)
+}
+
+// RederiveOrgFromRaw is RederiveFromRaw for one org's sessions: the admin
+// derive run of a multi-tenant deployment, where one tenant's admin must not
+// rebuild another's projection. The report keeps the per-org shape.
+func (d *Driver) RederiveOrgFromRaw(ctx context.Context, project, orgID string) (map[string]*derive.RederiveReport, error) {
+	org, err := orgIDFromString(orgID)
+	if err != nil {
+		return nil, err
+	}
+	return d.rederiveFromRaw(ctx, project, &org)
+}
+
+// rederiveFromRaw rebuilds every session, or only org's when it is non-nil.
+func (d *Driver) rederiveFromRaw(ctx context.Context, project string, org *pgtype.UUID) (map[string]*derive.RederiveReport, error) {
 	if d == nil || d.conn == nil {
 		return nil, errors.New("postgres driver not open")
 	}
@@ -46,6 +62,9 @@ func (d *Driver) RederiveFromRaw(ctx context.Context, project string) (map[strin
 	}
 	reports := make(map[string]*derive.RederiveReport)
 	for _, key := range keys {
+		if org != nil && key.OrgID != *org {
+			continue
+		}
 		orgID := uuidString(key.OrgID)
 		report, err := d.RederiveSessionLocked(ctx, project, orgID, key.HarnessID, key.HarnessSessionID)
 		if err != nil {
@@ -123,6 +142,20 @@ func (d *Driver) RederiveSession(ctx context.Context, project, orgID, harnessID,
 		return nil, fmt.Errorf("decode org_id: %w", err)
 	}
 
+	// A session a retention rule stripped has no content left to derive
+	// from; writeDerivedSet would skip it anyway, so don't read its rows.
+	identity, err := d.q.SessionIDByHarnessKey(ctx, gensqlc.SessionIDByHarnessKeyParams{
+		OrgID:            org,
+		HarnessID:        harnessID,
+		HarnessSessionID: harnessSessionID,
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("resolve session %s/%s: %w", harnessID, harnessSessionID, err)
+	}
+	if err == nil && identity.RetentionTier == storage.RetentionTierStripped {
+		return newRederiveReport(), nil
+	}
+
 	// Index scan: identity + timing only, no payloads. Transcript rows
 	// keep only the LATEST version per (agent, lifecycle kind) —
 	// transcript ingest appends a new row each time a file grows, and a
@@ -292,7 +325,7 @@ func (d *Driver) writeDerivedSet(ctx context.Context, orgKey string, set *derive
 	sessionIDs := map[derive.SessionKey]pgtype.UUID{}
 	var coveredSessions []pgtype.UUID
 	for _, key := range set.Sessions {
-		id, err := qtx.SessionIDByHarnessKey(ctx, gensqlc.SessionIDByHarnessKeyParams{
+		row, err := qtx.SessionIDByHarnessKey(ctx, gensqlc.SessionIDByHarnessKeyParams{
 			OrgID:            orgID,
 			HarnessID:        key.HarnessID,
 			HarnessSessionID: key.HarnessSessionID,
@@ -303,6 +336,14 @@ func (d *Driver) writeDerivedSet(ctx context.Context, orgKey string, set *derive
 		if err != nil {
 			return fmt.Errorf("resolve session %s/%s: %w", key.HarnessID, key.HarnessSessionID, err)
 		}
+		if row.RetentionTier == storage.RetentionTierStripped {
+			// A retention rule stripped this session's payloads: its raw
+			// rows no longer carry the content, so re-deriving would fold
+			// an empty conversation over the rollups the rule kept. Leave
+			// the frozen projection alone, exactly like an unresolved key.
+			continue
+		}
+		id := row.ID
 		sessionIDs[key] = id
 		coveredSessions = append(coveredSessions, id)
 	}
@@ -317,7 +358,15 @@ func (d *Driver) writeDerivedSet(ctx context.Context, orgKey string, set *derive
 	// The span projection rides the same transaction: traces, spans,
 	// and links are as derived as the nodes are, and a derive pass
 	// either lands both layers or neither.
-	if err := writeSpanSet(ctx, qtx, orgID, sessionIDs, coveredSessions, derive.EmitSpans(set)); err != nil {
+	//
+	// Costs come from the pricing catalog as it stands now, applied at each
+	// span's start: a re-derive after a price import re-costs history with
+	// the rate each call was actually billed at.
+	pricing, err := d.pricingCatalog(ctx, qtx, orgKeyForLookup(orgKey))
+	if err != nil {
+		return fmt.Errorf("load pricing catalog: %w", err)
+	}
+	if err := writeSpanSet(ctx, qtx, orgID, sessionIDs, coveredSessions, derive.EmitSpans(set, derive.WithPricing(pricing))); err != nil {
 		return fmt.Errorf("write span set: %w", err)
 	}
 
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/papercomputeco/tapes/pkg/derive"
+	"github.com/papercomputeco/tapes/pkg/storage"
+	"github.com/papercomputeco/tapes/pkg/storage/postgres/gensqlc"
+)
+
+// writeFileTouches replaces one trace's file rows with its current fold.
+// It runs after the trace row is upserted, which the rows reference; a
+// trace the projection stops producing takes its rows with it when
+// PruneSpanTurns deletes it.
+func writeFileTouches(ctx context.Context, qtx *gensqlc.Queries, orgID, sessionID pgtype.UUID, turn *derive.SpanTurn) error {
+	if err := qtx.DeleteFileTouchesForTrace(ctx, gensqlc.DeleteFileTouchesForTraceParams{
+		OrgID:   orgID,
+		TraceID: turn.TraceID,
+	}); err != nil {
+		return fmt.Errorf("clear file touches %s: %w", turn.TraceID, err)
+	}
+	for _, f := range turn.Files {
+		if err := qtx.InsertFileTouch(ctx, gensqlc.InsertFileTouchParams{
+			OrgID:        orgID,
+			TraceID:      turn.TraceID,
+			Path:         f.Path,
+			SessionID:    sessionID,
+			Name:         path.Base(f.Path),
+			Reads:        int32(f.Reads),
+			Creates:      int32(f.Creates),
+			Edits:        int32(f.Edits),
+			Deletes:      int32(f.Deletes),
+			LinesAdded:   int32(f.LinesAdded),
+			LinesRemoved: int32(f.LinesRemoved),
+			FirstAt:      pgtype.Timestamptz{Time: f.FirstAt, Valid: true},
+			LastAt:       pgtype.Timestamptz{Time: f.LastAt, Valid: true},
+		}); err != nil {
+			return fmt.Errorf("insert file touch %s %s: %w", turn.TraceID, f.Path, err)
+		}
+	}
+	return nil
+}
+
+// ListFileTouches returns every trace that touched the file at filePath,
+// or at any path ending with it, newest activity first. A non-empty
+// authSubject narrows the rows to that subject's sessions. Implements
+// storage.FileTouchReader.
+func (d *Driver) ListFileTouches(ctx context.Context, orgID, filePath, authSubject string, limit int) ([]storage.FileTouchRecord, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	org, err := orgIDFromString(orgKeyForLookup(orgID))
+	if err != nil {
+		return nil, fmt.Errorf("decode org_id: %w", err)
+	}
+	rows, err := d.q.ListFileTouchesByPath(ctx, gensqlc.ListFileTouchesByPathParams{
+		OrgID:             org,
+		Name:              path.Base(filePath),
+		Path:              filePath,
+		AuthSubjectFilter: pgtype.Text{String: authSubject, Valid: authSubject != ""},
+		RowLimit:          int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list file touches: %w", err)
+	}
+	out := make([]storage.FileTouchRecord, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, storage.FileTouchRecord{
+			SessionID:    uuidString(r.SessionID),
+			TraceID:      r.TraceID,
+			Path:         r.Path,
+			Reads:        int(r.Reads),
+			Creates:      int(r.Creates),
+			Edits:        int(r.Edits),
+			Deletes:      int(r.Deletes),
+			LinesAdded:   int(r.LinesAdded),
+			LinesRemoved: int(r.LinesRemoved),
+			FirstAt:      r.FirstAt.Time,
+			LastAt:       r.LastAt.Time,
+		})
+	}
+	return out, nil
+}
@@ -208,7 +208,7 @@ func (q *Queries) ListSessionsForRederive(ctx context.Context) ([]ListSessionsFo
 }
 
 const sessionIDByHarnessKey = `-- name: SessionIDByHarnessKey :one
-SELECT id FROM sessions
+SELECT id, retention_tier FROM sessions
 WHERE org_id = $1
   AND harness_id = $2
   AND harness_session_id = $3
@@ -220,11 +220,18 @@ type SessionIDByHarnessKeyParams struct {
 	HarnessSessionID string
 }
 
+type SessionIDByHarnessKeyRow struct {
+	ID            pgtype.UUID
+	RetentionTier string
+}
+
 // Resolve the sessions row for one raw turn's natural key. The deriver
 // only attributes to existing sessions; it never creates them.
-func (q *Queries) SessionIDByHarnessKey(ctx context.Context, arg SessionIDByHarnessKeyParams) (pgtype.UUID, error) {
+// retention_tier rides along so the write path can leave a session whose
+// payloads a retention rule stripped untouched (see 1781540000_retention).
+func (q *Queries) SessionIDByHarnessKey(ctx context.Context, arg SessionIDByHarnessKeyParams) (SessionIDByHarnessKeyRow, error) {
 	row := q.db.QueryRow(ctx, sessionIDByHarnessKey, arg.OrgID, arg.HarnessID, arg.HarnessSessionID)
-	var id pgtype.UUID
-	err := row.Scan(&id)
-	return id, err
+	var i SessionIDByHarnessKeyRow
+	err := row.Scan(&i.ID, &i.RetentionTier)
+	return i, err
 }
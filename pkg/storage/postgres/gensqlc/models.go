@@ -8,6 +8,34 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type Annotation struct {
+	ID        pgtype.UUID
+	OrgID     pgtype.UUID
+	SessionID pgtype.UUID
+	TraceID   string
+	SpanID    string
+	Name      string
+	Label     string
+	Score     pgtype.Float8
+	Note      string
+	Author    string
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+}
+
+type ApiToken struct {
+	ID          pgtype.UUID
+	OrgID       pgtype.UUID
+	Name        string
+	TokenPrefix string
+	TokenHash   []byte
+	Scopes      []string
+	CreatedAt   pgtype.Timestamptz
+	ExpiresAt   pgtype.Timestamptz
+	LastUsedAt  pgtype.Timestamptz
+	RevokedAt   pgtype.Timestamptz
+}
+
 type DeriveQueue struct {
 	OrgID            pgtype.UUID
 	HarnessID        string
@@ -24,6 +52,56 @@ type DerivedProjectionSchema struct {
 	CreatedAt         pgtype.Timestamptz
 }
 
+type FileTouches20260615 struct {
+	OrgID        pgtype.UUID
+	TraceID      string
+	Path         string
+	SessionID    pgtype.UUID
+	Name         string
+	Reads        int32
+	Creates      int32
+	Edits        int32
+	Deletes      int32
+	LinesAdded   int32
+	LinesRemoved int32
+	FirstAt      pgtype.Timestamptz
+	LastAt       pgtype.Timestamptz
+}
+
+type GitArtifacts20260615 struct {
+	OrgID     pgtype.UUID
+	TraceID   string
+	Ordinal   int32
+	SessionID pgtype.UUID
+	SpanID    string
+	Kind      string
+	Sha       string
+	Branch    string
+	Url       string
+	Subject   string
+	At        pgtype.Timestamptz
+}
+
+type PricingCatalog struct {
+	ID            pgtype.UUID
+	Model         string
+	EffectiveFrom pgtype.Timestamptz
+	Pricing       []byte
+	Source        string
+	CreatedAt     pgtype.Timestamptz
+}
+
+type PricingDiscount struct {
+	ID             pgtype.UUID
+	OrgID          pgtype.UUID
+	Model          string
+	Percent        float64
+	EffectiveFrom  pgtype.Timestamptz
+	EffectiveUntil pgtype.Timestamptz
+	Note           string
+	CreatedAt      pgtype.Timestamptz
+}
+
 type RawTurn struct {
 	ID                  int64
 	OrgID               pgtype.UUID
@@ -41,6 +119,7 @@ type RawTurn struct {
 	RawResponse         []byte
 	RawResponseEncoding string
 	RawResponseDropped  bool
+	PayloadStrippedAt   pgtype.Timestamptz
 }
 
 type RawTurnAttributionCorrection struct {
@@ -56,35 +135,59 @@ type RawTurnAttributionCorrection struct {
 }
 
 type Session struct {
-	ID                pgtype.UUID
-	OrgID             pgtype.UUID
-	AuthSubject       string
-	HarnessID         string
-	HarnessSessionID  string
-	Name              pgtype.Text
-	Cwd               pgtype.Text
-	HarnessVersion    pgtype.Text
-	ParentSessionID   pgtype.UUID
-	StartedAt         pgtype.Timestamptz
-	LastSeenAt        pgtype.Timestamptz
-	EndedAt           pgtype.Timestamptz
-	HarnessMetadata   []byte
-	TotalInputTokens  int64
-	TotalOutputTokens int64
-	TotalCostUsd      pgtype.Numeric
-	TurnCount         int32
-	DerivedStatus     string
-	HasGitActivity    bool
-	ToolResultCount   int32
-	ToolErrorCount    int32
-	DerivedTitle      pgtype.Text
-	DerivedModel      string
-	ModelUsage        []byte
-	TotalTokens       pgtype.Int8
-	DurationNs        pgtype.Int8
-	Tasks             []byte
-	KindCounts        []byte
-	DisplayName       pgtype.Text
+	ID                 pgtype.UUID
+	OrgID              pgtype.UUID
+	AuthSubject        string
+	HarnessID          string
+	HarnessSessionID   string
+	Name               pgtype.Text
+	Cwd                pgtype.Text
+	HarnessVersion     pgtype.Text
+	ParentSessionID    pgtype.UUID
+	StartedAt          pgtype.Timestamptz
+	LastSeenAt         pgtype.Timestamptz
+	EndedAt            pgtype.Timestamptz
+	HarnessMetadata    []byte
+	TotalInputTokens   int64
+	TotalOutputTokens  int64
+	TotalCostUsd       pgtype.Numeric
+	TurnCount          int32
+	DerivedStatus      string
+	HasGitActivity     bool
+	ToolResultCount    int32
+	ToolErrorCount     int32
+	DerivedTitle       pgtype.Text
+	DerivedModel       string
+	ModelUsage         []byte
+	TotalTokens        pgtype.Int8
+	DurationNs         pgtype.Int8
+	Tasks              []byte
+	KindCounts         []byte
+	DisplayName        pgtype.Text
+	RetentionTier      string
+	RetentionAppliedAt pgtype.Timestamptz
+	ContextUsage       []byte
+	Outcome            string
+	OutcomeReasons     []byte
+}
+
+type SpanEmbedCursor struct {
+	Model     string
+	DeriveSeq int64
+	OrgID     pgtype.UUID
+	TraceID   string
+	SpanID    string
+	UpdatedAt pgtype.Timestamptz
+}
+
+type SpanEmbeddings20260615 struct {
+	OrgID       pgtype.UUID
+	TraceID     string
+	SpanID      string
+	Model       string
+	ContentHash string
+	Embedding   string
+	EmbeddedAt  pgtype.Timestamptz
 }
 
 // Derived span-link projection schema version 2026-06-15.
@@ -102,28 +205,34 @@ type SpanLinks20260615 struct {
 
 // Derived span-turn projection schema version 2026-06-15.
 type SpanTurns20260615 struct {
-	OrgID               pgtype.UUID
-	TraceID             string
-	SessionID           pgtype.UUID
-	UserPrompt          string
-	Synthetic           string
-	Status              string
-	StartedAt           pgtype.Timestamptz
-	EndedAt             pgtype.Timestamptz
-	DurationNs          int64
-	TotalInputTokens    int64
-	TotalOutputTokens   int64
-	TotalCostUsd        pgtype.Numeric
-	MainInputTokens     int64
-	MainOutputTokens    int64
-	CacheReadTokens     int64
-	CacheCreationTokens int64
-	ResponsePreview     string
-	Source              string
-	ContentHash         string
-	DeriveSeq           int64
-	Fidelity            string
-	ToolCalls           int64
+	OrgID                pgtype.UUID
+	TraceID              string
+	SessionID            pgtype.UUID
+	UserPrompt           string
+	Synthetic            string
+	Status               string
+	StartedAt            pgtype.Timestamptz
+	EndedAt              pgtype.Timestamptz
+	DurationNs           int64
+	TotalInputTokens     int64
+	TotalOutputTokens    int64
+	TotalCostUsd         pgtype.Numeric
+	MainInputTokens      int64
+	MainOutputTokens     int64
+	CacheReadTokens      int64
+	CacheCreationTokens  int64
+	ResponsePreview      string
+	Source               string
+	ContentHash          string
+	DeriveSeq            int64
+	Fidelity             string
+	ToolCalls            int64
+	ParentTraceID        string
+	Abandoned            bool
+	PeakContextTokens    int64
+	PeakContextOccupancy float64
+	ContextGrowthTokens  int64
+	ReclaimedTokens      int64
 }
 
 // Derived span projection schema version 2026-06-15.
@@ -152,6 +261,8 @@ type Spans20260615 struct {
 	ContentHash  string
 	DeriveSeq    int64
 	Fidelity     string
+	Tool         []byte
+	RetryOf      string
 }
 
 // v1 contract view over the sessions table.
@@ -252,3 +363,9 @@ type TapesV1SpanTurn struct {
 	DeriveSeq           int64
 	Fidelity            string
 }
+
+// Maps a database role to the org it may read through tapes_v1. Roles without a row are unrestricted.
+type TenantRole struct {
+	RoleName string
+	OrgID    pgtype.UUID
+}
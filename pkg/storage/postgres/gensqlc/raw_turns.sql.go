@@ -496,7 +496,8 @@ func (q *Queries) ListRawTurnsBySession(ctx context.Context, arg ListRawTurnsByS
 const rawTurnFidelityByIDs = `-- name: RawTurnFidelityByIDs :many
 SELECT id,
        COALESCE(octet_length(raw_response) > 0, false)::boolean AS has_raw_response,
-       raw_response_dropped
+       raw_response_dropped,
+       (payload_stripped_at IS NOT NULL)::boolean AS payload_stripped
 FROM raw_turns
 WHERE id = ANY($1::bigint[])
 `
@@ -505,6 +506,7 @@ type RawTurnFidelityByIDsRow struct {
 	ID                 int64
 	HasRawResponse     bool
 	RawResponseDropped bool
+	PayloadStripped    bool
 }
 
 // Provenance tier for a set of raw turns, for stamping the span projection.
@@ -526,7 +528,12 @@ func (q *Queries) RawTurnFidelityByIDs(ctx context.Context, ids []int64) ([]RawT
 	var items []RawTurnFidelityByIDsRow
 	for rows.Next() {
 		var i RawTurnFidelityByIDsRow
-		if err := rows.Scan(&i.ID, &i.HasRawResponse, &i.RawResponseDropped); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.HasRawResponse,
+			&i.RawResponseDropped,
+			&i.PayloadStripped,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
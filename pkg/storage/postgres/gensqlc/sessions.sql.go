@@ -82,7 +82,7 @@ func (q *Queries) DeleteSession(ctx context.Context, arg DeleteSessionParams) (i
 }
 
 const getSessionByNaturalKey = `-- name: GetSessionByNaturalKey :one
-SELECT id, org_id, auth_subject, harness_id, harness_session_id, name, cwd, harness_version, parent_session_id, started_at, last_seen_at, ended_at, harness_metadata, total_input_tokens, total_output_tokens, total_cost_usd, turn_count, derived_status, has_git_activity, tool_result_count, tool_error_count, derived_title, derived_model, model_usage, total_tokens, duration_ns, tasks, kind_counts, display_name FROM sessions
+SELECT id, org_id, auth_subject, harness_id, harness_session_id, name, cwd, harness_version, parent_session_id, started_at, last_seen_at, ended_at, harness_metadata, total_input_tokens, total_output_tokens, total_cost_usd, turn_count, derived_status, has_git_activity, tool_result_count, tool_error_count, derived_title, derived_model, model_usage, total_tokens, duration_ns, tasks, kind_counts, display_name, retention_tier, retention_applied_at, context_usage, outcome, outcome_reasons FROM sessions
 WHERE org_id = $1
   AND harness_id = $2
   AND harness_session_id = $3
@@ -131,12 +131,17 @@ func (q *Queries) GetSessionByNaturalKey(ctx context.Context, arg GetSessionByNa
 		&i.Tasks,
 		&i.KindCounts,
 		&i.DisplayName,
+		&i.RetentionTier,
+		&i.RetentionAppliedAt,
+		&i.ContextUsage,
+		&i.Outcome,
+		&i.OutcomeReasons,
 	)
 	return i, err
 }
 
 const getSessionRecord = `-- name: GetSessionRecord :one
-SELECT id, org_id, auth_subject, harness_id, harness_session_id, name, cwd, harness_version, parent_session_id, started_at, last_seen_at, ended_at, harness_metadata, total_input_tokens, total_output_tokens, total_cost_usd, turn_count, derived_status, has_git_activity, tool_result_count, tool_error_count, derived_title, derived_model, model_usage, total_tokens, duration_ns, tasks, kind_counts, display_name FROM sessions
+SELECT id, org_id, auth_subject, harness_id, harness_session_id, name, cwd, harness_version, parent_session_id, started_at, last_seen_at, ended_at, harness_metadata, total_input_tokens, total_output_tokens, total_cost_usd, turn_count, derived_status, has_git_activity, tool_result_count, tool_error_count, derived_title, derived_model, model_usage, total_tokens, duration_ns, tasks, kind_counts, display_name, retention_tier, retention_applied_at, context_usage, outcome, outcome_reasons FROM sessions
 WHERE org_id = $1 AND id = $2
 `
 
@@ -178,6 +183,11 @@ func (q *Queries) GetSessionRecord(ctx context.Context, arg GetSessionRecordPara
 		&i.Tasks,
 		&i.KindCounts,
 		&i.DisplayName,
+		&i.RetentionTier,
+		&i.RetentionAppliedAt,
+		&i.ContextUsage,
+		&i.Outcome,
+		&i.OutcomeReasons,
 	)
 	return i, err
 }
@@ -239,7 +249,7 @@ func (q *Queries) InsertSessionPlaceholder(ctx context.Context, arg InsertSessio
 }
 
 const listSessionsByHarnessSessionID = `-- name: ListSessionsByHarnessSessionID :many
-SELECT id, org_id, auth_subject, harness_id, harness_session_id, name, cwd, harness_version, parent_session_id, started_at, last_seen_at, ended_at, harness_metadata, total_input_tokens, total_output_tokens, total_cost_usd, turn_count, derived_status, has_git_activity, tool_result_count, tool_error_count, derived_title, derived_model, model_usage, total_tokens, duration_ns, tasks, kind_counts, display_name FROM sessions
+SELECT id, org_id, auth_subject, harness_id, harness_session_id, name, cwd, harness_version, parent_session_id, started_at, last_seen_at, ended_at, harness_metadata, total_input_tokens, total_output_tokens, total_cost_usd, turn_count, derived_status, has_git_activity, tool_result_count, tool_error_count, derived_title, derived_model, model_usage, total_tokens, duration_ns, tasks, kind_counts, display_name, retention_tier, retention_applied_at, context_usage, outcome, outcome_reasons FROM sessions
 WHERE org_id = $1
   AND harness_session_id = $2
 ORDER BY harness_id
@@ -294,6 +304,11 @@ func (q *Queries) ListSessionsByHarnessSessionID(ctx context.Context, arg ListSe
 			&i.Tasks,
 			&i.KindCounts,
 			&i.DisplayName,
+			&i.RetentionTier,
+			&i.RetentionAppliedAt,
+			&i.ContextUsage,
+			&i.Outcome,
+			&i.OutcomeReasons,
 		); err != nil {
 			return nil, err
 		}
@@ -322,6 +337,23 @@ func (q *Queries) SetSessionParent(ctx context.Context, arg SetSessionParentPara
 	return err
 }
 
+const updateSessionContextUsage = `-- name: UpdateSessionContextUsage :exec
+UPDATE sessions SET context_usage = $1 WHERE id = $2
+`
+
+type UpdateSessionContextUsageParams struct {
+	ContextUsage []byte
+	ID           pgtype.UUID
+}
+
+// Write the context-window fold (peak occupancy, compactions, cache hit
+// ratio) onto the session as a JSONB object. Re-derive overwrites it
+// idempotently.
+func (q *Queries) UpdateSessionContextUsage(ctx context.Context, arg UpdateSessionContextUsageParams) error {
+	_, err := q.db.Exec(ctx, updateSessionContextUsage, arg.ContextUsage, arg.ID)
+	return err
+}
+
 const updateSessionDerivedTitle = `-- name: UpdateSessionDerivedTitle :exec
 UPDATE sessions SET derived_title = $1 WHERE id = $2
 `
@@ -409,8 +441,10 @@ UPDATE sessions
    SET has_git_activity  = $1,
        tool_result_count = $2,
        tool_error_count  = $3,
-       derived_status    = $4
- WHERE id = $5
+       derived_status    = $4,
+       outcome           = $5,
+       outcome_reasons   = $6
+ WHERE id = $7
 `
 
 type UpdateSessionStatusParams struct {
@@ -418,6 +452,8 @@ type UpdateSessionStatusParams struct {
 	ToolResultCount int32
 	ToolErrorCount  int32
 	DerivedStatus   string
+	Outcome         string
+	OutcomeReasons  []byte
 	ID              pgtype.UUID
 }
 
@@ -428,13 +464,17 @@ type UpdateSessionStatusParams struct {
 // just writes them. derived_status mirrors pkg/sessions.DetermineStatus over
 // those signals and the session's terminal main-spine span. Called only by
 // the deriver (writeSpanSet) during the derive pass — the ingest path no
-// longer writes status.
+// longer writes status. outcome and outcome_reasons are
+// pkg/derive.FoldSessionOutcome's refinement of that status, written with
+// it.
 func (q *Queries) UpdateSessionStatus(ctx context.Context, arg UpdateSessionStatusParams) error {
 	_, err := q.db.Exec(ctx, updateSessionStatus,
 		arg.HasGitActivity,
 		arg.ToolResultCount,
 		arg.ToolErrorCount,
 		arg.DerivedStatus,
+		arg.Outcome,
+		arg.OutcomeReasons,
 		arg.ID,
 	)
 	return err
@@ -493,7 +533,7 @@ SET last_seen_at     = $10,
     cwd              = COALESCE($7, sessions.cwd),
     harness_version  = COALESCE($8, sessions.harness_version),
     parent_session_id = COALESCE($9, sessions.parent_session_id)
-RETURNING id, org_id, auth_subject, harness_id, harness_session_id, name, cwd, harness_version, parent_session_id, started_at, last_seen_at, ended_at, harness_metadata, total_input_tokens, total_output_tokens, total_cost_usd, turn_count, derived_status, has_git_activity, tool_result_count, tool_error_count, derived_title, derived_model, model_usage, total_tokens, duration_ns, tasks, kind_counts, display_name
+RETURNING id, org_id, auth_subject, harness_id, harness_session_id, name, cwd, harness_version, parent_session_id, started_at, last_seen_at, ended_at, harness_metadata, total_input_tokens, total_output_tokens, total_cost_usd, turn_count, derived_status, has_git_activity, tool_result_count, tool_error_count, derived_title, derived_model, model_usage, total_tokens, duration_ns, tasks, kind_counts, display_name, retention_tier, retention_applied_at, context_usage, outcome, outcome_reasons
 `
 
 type UpsertSessionParams struct {
@@ -571,6 +611,11 @@ func (q *Queries) UpsertSession(ctx context.Context, arg UpsertSessionParams) (S
 		&i.Tasks,
 		&i.KindCounts,
 		&i.DisplayName,
+		&i.RetentionTier,
+		&i.RetentionAppliedAt,
+		&i.ContextUsage,
+		&i.Outcome,
+		&i.OutcomeReasons,
 	)
 	return i, err
 }
@@ -596,7 +641,7 @@ SET auth_subject      = COALESCE(NULLIF(EXCLUDED.auth_subject, ''), sessions.aut
     cwd               = COALESCE($7, sessions.cwd),
     harness_version   = COALESCE($8, sessions.harness_version),
     parent_session_id = COALESCE($9, sessions.parent_session_id)
-RETURNING id, org_id, auth_subject, harness_id, harness_session_id, name, cwd, harness_version, parent_session_id, started_at, last_seen_at, ended_at, harness_metadata, total_input_tokens, total_output_tokens, total_cost_usd, turn_count, derived_status, has_git_activity, tool_result_count, tool_error_count, derived_title, derived_model, model_usage, total_tokens, duration_ns, tasks, kind_counts, display_name
+RETURNING id, org_id, auth_subject, harness_id, harness_session_id, name, cwd, harness_version, parent_session_id, started_at, last_seen_at, ended_at, harness_metadata, total_input_tokens, total_output_tokens, total_cost_usd, turn_count, derived_status, has_git_activity, tool_result_count, tool_error_count, derived_title, derived_model, model_usage, total_tokens, duration_ns, tasks, kind_counts, display_name, retention_tier, retention_applied_at, context_usage, outcome, outcome_reasons
 `
 
 type UpsertSessionForAttributionRepairParams struct {
@@ -662,6 +707,11 @@ func (q *Queries) UpsertSessionForAttributionRepair(ctx context.Context, arg Ups
 		&i.Tasks,
 		&i.KindCounts,
 		&i.DisplayName,
+		&i.RetentionTier,
+		&i.RetentionAppliedAt,
+		&i.ContextUsage,
+		&i.Outcome,
+		&i.OutcomeReasons,
 	)
 	return i, err
 }
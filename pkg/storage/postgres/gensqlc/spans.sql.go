@@ -11,6 +11,100 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const aggregateModelLatency = `-- name: AggregateModelLatency :many
+SELECT
+    sp.model::text                                                              AS model,
+    COUNT(*)::bigint                                                            AS calls,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (
+        ORDER BY (sp.usage->'latency'->>'time_to_first_byte_ns')::float8), 0)::bigint  AS ttfb_p50_ns,
+    COALESCE(percentile_cont(0.95) WITHIN GROUP (
+        ORDER BY (sp.usage->'latency'->>'time_to_first_byte_ns')::float8), 0)::bigint  AS ttfb_p95_ns,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (
+        ORDER BY (sp.usage->'latency'->>'time_to_first_token_ns')::float8), 0)::bigint AS ttft_p50_ns,
+    COALESCE(percentile_cont(0.95) WITHIN GROUP (
+        ORDER BY (sp.usage->'latency'->>'time_to_first_token_ns')::float8), 0)::bigint AS ttft_p95_ns,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (
+        ORDER BY (sp.usage->'latency'->>'inter_token_p50_ns')::float8), 0)::bigint     AS inter_token_p50_ns,
+    COALESCE(percentile_cont(0.95) WITHIN GROUP (
+        ORDER BY (sp.usage->'latency'->>'inter_token_p95_ns')::float8), 0)::bigint     AS inter_token_p95_ns,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (
+        ORDER BY (sp.usage->'latency'->>'output_tokens_per_sec')::float8), 0)::float8  AS output_tokens_per_sec_p50
+FROM spans_20260615 sp
+LEFT JOIN sessions s ON s.id = sp.session_id
+WHERE sp.org_id = $1
+  AND sp.kind = 'llm'
+  AND sp.usage->'latency' IS NOT NULL
+  AND ($2::timestamptz IS NULL OR sp.started_at >= $2::timestamptz)
+  AND ($3::timestamptz IS NULL OR sp.started_at < $3::timestamptz)
+  AND ($4::text IS NULL OR s.auth_subject = $4::text)
+GROUP BY sp.model
+ORDER BY calls DESC, model
+`
+
+type AggregateModelLatencyParams struct {
+	OrgID             pgtype.UUID
+	SinceFilter       pgtype.Timestamptz
+	UntilFilter       pgtype.Timestamptz
+	AuthSubjectFilter pgtype.Text
+}
+
+type AggregateModelLatencyRow struct {
+	Model                 string
+	Calls                 int64
+	TtfbP50Ns             int64
+	TtfbP95Ns             int64
+	TtftP50Ns             int64
+	TtftP95Ns             int64
+	InterTokenP50Ns       int64
+	InterTokenP95Ns       int64
+	OutputTokensPerSecP50 float64
+}
+
+// Latency percentiles per model over the llm spans started in the window,
+// for /v1/stats. Only spans whose capture adapter recorded a latency
+// profile count; a call the proxy or extproc did not time contributes
+// nothing rather than a zero. Each percentile is taken over per-call
+// figures, so inter_token_p95_ns is the 95th percentile of the calls'
+// own p95 gaps, not of every gap in the window.
+//
+// kind = 'llm' with the window on started_at rides
+// spans_20260615_org_kind_started_idx, and the auth_subject filter reaches
+// sessions the same way AggregateSpanStats does.
+func (q *Queries) AggregateModelLatency(ctx context.Context, arg AggregateModelLatencyParams) ([]AggregateModelLatencyRow, error) {
+	rows, err := q.db.Query(ctx, aggregateModelLatency,
+		arg.OrgID,
+		arg.SinceFilter,
+		arg.UntilFilter,
+		arg.AuthSubjectFilter,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AggregateModelLatencyRow
+	for rows.Next() {
+		var i AggregateModelLatencyRow
+		if err := rows.Scan(
+			&i.Model,
+			&i.Calls,
+			&i.TtfbP50Ns,
+			&i.TtfbP95Ns,
+			&i.TtftP50Ns,
+			&i.TtftP95Ns,
+			&i.InterTokenP50Ns,
+			&i.InterTokenP95Ns,
+			&i.OutputTokensPerSecP50,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const aggregateSpanStats = `-- name: AggregateSpanStats :one
 WITH matched AS (
     SELECT t.org_id, t.trace_id, t.session_id, t.duration_ns,
@@ -116,6 +210,90 @@ func (q *Queries) AggregateSpanStats(ctx context.Context, arg AggregateSpanStats
 	return i, err
 }
 
+const aggregateToolStats = `-- name: AggregateToolStats :many
+SELECT
+    COALESCE(sp.tool->>'name', sp.name)::text                                   AS tool_name,
+    COALESCE(sp.tool->>'server', '')::text                                      AS server,
+    COALESCE(sp.tool->>'command', '')::text                                     AS command,
+    COUNT(*)::bigint                                                            AS calls,
+    COUNT(*) FILTER (WHERE sp.status = 'error')::bigint                         AS errors,
+    COUNT(*) FILTER (WHERE sp.retry_of <> '')::bigint                           AS retries,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY sp.duration_ns::float8)
+        FILTER (WHERE sp.duration_ns > 0), 0)::bigint                           AS duration_p50_ns,
+    COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY sp.duration_ns::float8)
+        FILTER (WHERE sp.duration_ns > 0), 0)::bigint                           AS duration_p95_ns
+FROM spans_20260615 sp
+LEFT JOIN sessions s ON s.id = sp.session_id
+WHERE sp.org_id = $1
+  AND sp.kind = 'tool'
+  AND ($2::timestamptz IS NULL OR sp.started_at >= $2::timestamptz)
+  AND ($3::timestamptz IS NULL OR sp.started_at < $3::timestamptz)
+  AND ($4::text IS NULL OR s.auth_subject = $4::text)
+GROUP BY 1, 2, 3
+ORDER BY calls DESC, tool_name, server, command
+`
+
+type AggregateToolStatsParams struct {
+	OrgID             pgtype.UUID
+	SinceFilter       pgtype.Timestamptz
+	UntilFilter       pgtype.Timestamptz
+	AuthSubjectFilter pgtype.Text
+}
+
+type AggregateToolStatsRow struct {
+	ToolName      string
+	Server        string
+	Command       string
+	Calls         int64
+	Errors        int64
+	Retries       int64
+	DurationP50Ns int64
+	DurationP95Ns int64
+}
+
+// Execution figures per tool identity over the tool spans started in the
+// window, for /v1/tools. Duration percentiles count only spans whose
+// tool_result arrived (duration_ns > 0); a call still pending, or one the
+// session never answered, adds to calls but not to latency. retries counts
+// calls that repeat an errored call with the same input.
+//
+// kind = 'tool' with the window on started_at rides
+// spans_20260615_org_kind_started_idx, and the auth_subject filter reaches
+// sessions the same way AggregateSpanStats does.
+func (q *Queries) AggregateToolStats(ctx context.Context, arg AggregateToolStatsParams) ([]AggregateToolStatsRow, error) {
+	rows, err := q.db.Query(ctx, aggregateToolStats,
+		arg.OrgID,
+		arg.SinceFilter,
+		arg.UntilFilter,
+		arg.AuthSubjectFilter,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AggregateToolStatsRow
+	for rows.Next() {
+		var i AggregateToolStatsRow
+		if err := rows.Scan(
+			&i.ToolName,
+			&i.Server,
+			&i.Command,
+			&i.Calls,
+			&i.Errors,
+			&i.Retries,
+			&i.DurationP50Ns,
+			&i.DurationP95Ns,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const foldSessionRollupsFromSpans = `-- name: FoldSessionRollupsFromSpans :exec
 UPDATE sessions SET
     total_cost_usd = COALESCE(f.cost, 0),
@@ -126,10 +304,13 @@ UPDATE sessions SET
     derived_title = NULL,
     tasks = NULL,
     kind_counts = NULL,
+    context_usage = NULL,
     has_git_activity = false,
     tool_result_count = 0,
     tool_error_count = 0,
     derived_status = 'unknown',
+    outcome = 'unknown',
+    outcome_reasons = NULL,
     derived_model = COALESCE((
         SELECT sp.model FROM spans_20260615 sp
         WHERE sp.session_id = sessions.id
@@ -177,7 +358,7 @@ func (q *Queries) FoldSessionRollupsFromSpans(ctx context.Context, sessionIds []
 }
 
 const getSpan = `-- name: GetSpan :one
-SELECT org_id, trace_id, span_id, parent_span_id, session_id, kind, name, status, call_kind, thread_id, model, stop_reason, started_at, duration_ns, input, output, usage, raw_turn_id, node_hash, seq, verdict, content_hash, derive_seq, fidelity FROM spans_20260615
+SELECT org_id, trace_id, span_id, parent_span_id, session_id, kind, name, status, call_kind, thread_id, model, stop_reason, started_at, duration_ns, input, output, usage, raw_turn_id, node_hash, seq, verdict, content_hash, derive_seq, fidelity, tool, retry_of FROM spans_20260615
 WHERE org_id = $1 AND trace_id = $2 AND span_id = $3
 `
 
@@ -215,12 +396,14 @@ func (q *Queries) GetSpan(ctx context.Context, arg GetSpanParams) (Spans20260615
 		&i.ContentHash,
 		&i.DeriveSeq,
 		&i.Fidelity,
+		&i.Tool,
+		&i.RetryOf,
 	)
 	return i, err
 }
 
 const getSpanTurn = `-- name: GetSpanTurn :one
-SELECT org_id, trace_id, session_id, user_prompt, synthetic, status, started_at, ended_at, duration_ns, total_input_tokens, total_output_tokens, total_cost_usd, main_input_tokens, main_output_tokens, cache_read_tokens, cache_creation_tokens, response_preview, source, content_hash, derive_seq, fidelity, tool_calls FROM span_turns_20260615
+SELECT org_id, trace_id, session_id, user_prompt, synthetic, status, started_at, ended_at, duration_ns, total_input_tokens, total_output_tokens, total_cost_usd, main_input_tokens, main_output_tokens, cache_read_tokens, cache_creation_tokens, response_preview, source, content_hash, derive_seq, fidelity, tool_calls, parent_trace_id, abandoned, peak_context_tokens, peak_context_occupancy, context_growth_tokens, reclaimed_tokens FROM span_turns_20260615
 WHERE org_id = $1 AND trace_id = $2
 `
 
@@ -255,6 +438,12 @@ func (q *Queries) GetSpanTurn(ctx context.Context, arg GetSpanTurnParams) (SpanT
 		&i.DeriveSeq,
 		&i.Fidelity,
 		&i.ToolCalls,
+		&i.ParentTraceID,
+		&i.Abandoned,
+		&i.PeakContextTokens,
+		&i.PeakContextOccupancy,
+		&i.ContextGrowthTokens,
+		&i.ReclaimedTokens,
 	)
 	return i, err
 }
@@ -466,7 +655,7 @@ func (q *Queries) ListSpanLinksByTrace(ctx context.Context, arg ListSpanLinksByT
 }
 
 const listSpanTurns = `-- name: ListSpanTurns :many
-SELECT org_id, trace_id, session_id, user_prompt, synthetic, status, started_at, ended_at, duration_ns, total_input_tokens, total_output_tokens, total_cost_usd, main_input_tokens, main_output_tokens, cache_read_tokens, cache_creation_tokens, response_preview, source, content_hash, derive_seq, fidelity, tool_calls FROM span_turns_20260615
+SELECT org_id, trace_id, session_id, user_prompt, synthetic, status, started_at, ended_at, duration_ns, total_input_tokens, total_output_tokens, total_cost_usd, main_input_tokens, main_output_tokens, cache_read_tokens, cache_creation_tokens, response_preview, source, content_hash, derive_seq, fidelity, tool_calls, parent_trace_id, abandoned, peak_context_tokens, peak_context_occupancy, context_growth_tokens, reclaimed_tokens FROM span_turns_20260615
 WHERE org_id = $1
   AND ($3::timestamptz IS NULL
        OR (started_at, trace_id) < ($3::timestamptz, $4::text))
@@ -518,6 +707,12 @@ func (q *Queries) ListSpanTurns(ctx context.Context, arg ListSpanTurnsParams) ([
 			&i.DeriveSeq,
 			&i.Fidelity,
 			&i.ToolCalls,
+			&i.ParentTraceID,
+			&i.Abandoned,
+			&i.PeakContextTokens,
+			&i.PeakContextOccupancy,
+			&i.ContextGrowthTokens,
+			&i.ReclaimedTokens,
 		); err != nil {
 			return nil, err
 		}
@@ -531,7 +726,7 @@ func (q *Queries) ListSpanTurns(ctx context.Context, arg ListSpanTurnsParams) ([
 
 const listSpanTurnsBySession = `-- name: ListSpanTurnsBySession :many
 
-SELECT org_id, trace_id, session_id, user_prompt, synthetic, status, started_at, ended_at, duration_ns, total_input_tokens, total_output_tokens, total_cost_usd, main_input_tokens, main_output_tokens, cache_read_tokens, cache_creation_tokens, response_preview, source, content_hash, derive_seq, fidelity, tool_calls FROM span_turns_20260615
+SELECT org_id, trace_id, session_id, user_prompt, synthetic, status, started_at, ended_at, duration_ns, total_input_tokens, total_output_tokens, total_cost_usd, main_input_tokens, main_output_tokens, cache_read_tokens, cache_creation_tokens, response_preview, source, content_hash, derive_seq, fidelity, tool_calls, parent_trace_id, abandoned, peak_context_tokens, peak_context_occupancy, context_growth_tokens, reclaimed_tokens FROM span_turns_20260615
 WHERE session_id = $1
 ORDER BY started_at ASC, trace_id ASC
 `
@@ -569,6 +764,12 @@ func (q *Queries) ListSpanTurnsBySession(ctx context.Context, sessionID pgtype.U
 			&i.DeriveSeq,
 			&i.Fidelity,
 			&i.ToolCalls,
+			&i.ParentTraceID,
+			&i.Abandoned,
+			&i.PeakContextTokens,
+			&i.PeakContextOccupancy,
+			&i.ContextGrowthTokens,
+			&i.ReclaimedTokens,
 		); err != nil {
 			return nil, err
 		}
@@ -581,7 +782,7 @@ func (q *Queries) ListSpanTurnsBySession(ctx context.Context, sessionID pgtype.U
 }
 
 const listSpansBySession = `-- name: ListSpansBySession :many
-SELECT org_id, trace_id, span_id, parent_span_id, session_id, kind, name, status, call_kind, thread_id, model, stop_reason, started_at, duration_ns, input, output, usage, raw_turn_id, node_hash, seq, verdict, content_hash, derive_seq, fidelity FROM spans_20260615
+SELECT org_id, trace_id, span_id, parent_span_id, session_id, kind, name, status, call_kind, thread_id, model, stop_reason, started_at, duration_ns, input, output, usage, raw_turn_id, node_hash, seq, verdict, content_hash, derive_seq, fidelity, tool, retry_of FROM spans_20260615
 WHERE session_id = $1
 ORDER BY trace_id ASC, seq ASC, started_at ASC, span_id ASC
 `
@@ -622,6 +823,8 @@ func (q *Queries) ListSpansBySession(ctx context.Context, sessionID pgtype.UUID)
 			&i.ContentHash,
 			&i.DeriveSeq,
 			&i.Fidelity,
+			&i.Tool,
+			&i.RetryOf,
 		); err != nil {
 			return nil, err
 		}
@@ -634,7 +837,7 @@ func (q *Queries) ListSpansBySession(ctx context.Context, sessionID pgtype.UUID)
 }
 
 const listSpansByTrace = `-- name: ListSpansByTrace :many
-SELECT org_id, trace_id, span_id, parent_span_id, session_id, kind, name, status, call_kind, thread_id, model, stop_reason, started_at, duration_ns, input, output, usage, raw_turn_id, node_hash, seq, verdict, content_hash, derive_seq, fidelity FROM spans_20260615
+SELECT org_id, trace_id, span_id, parent_span_id, session_id, kind, name, status, call_kind, thread_id, model, stop_reason, started_at, duration_ns, input, output, usage, raw_turn_id, node_hash, seq, verdict, content_hash, derive_seq, fidelity, tool, retry_of FROM spans_20260615
 WHERE org_id = $1 AND trace_id = $2
 ORDER BY seq ASC, started_at ASC, span_id ASC
 `
@@ -678,6 +881,8 @@ func (q *Queries) ListSpansByTrace(ctx context.Context, arg ListSpansByTracePara
 			&i.ContentHash,
 			&i.DeriveSeq,
 			&i.Fidelity,
+			&i.Tool,
+			&i.RetryOf,
 		); err != nil {
 			return nil, err
 		}
@@ -691,10 +896,12 @@ func (q *Queries) ListSpansByTrace(ctx context.Context, arg ListSpansByTracePara
 
 const listTraceSummariesBySession = `-- name: ListTraceSummariesBySession :many
 SELECT t.org_id, t.trace_id, t.session_id, t.user_prompt, t.response_preview, t.synthetic,
-       t.status, t.source, t.started_at, t.ended_at, t.duration_ns,
+       t.status, t.source, t.parent_trace_id, t.abandoned, t.started_at, t.ended_at, t.duration_ns,
        t.total_input_tokens, t.total_output_tokens,
        t.main_input_tokens, t.main_output_tokens,
        t.cache_read_tokens, t.cache_creation_tokens, t.total_cost_usd,
+       t.peak_context_tokens, t.peak_context_occupancy,
+       t.context_growth_tokens, t.reclaimed_tokens,
        count(s.span_id) AS span_count
 FROM span_turns_20260615 t
 LEFT JOIN spans_20260615 s ON s.org_id = t.org_id AND s.trace_id = t.trace_id
@@ -704,25 +911,31 @@ ORDER BY t.started_at ASC, t.trace_id ASC
 `
 
 type ListTraceSummariesBySessionRow struct {
-	OrgID               pgtype.UUID
-	TraceID             string
-	SessionID           pgtype.UUID
-	UserPrompt          string
-	ResponsePreview     string
-	Synthetic           string
-	Status              string
-	Source              string
-	StartedAt           pgtype.Timestamptz
-	EndedAt             pgtype.Timestamptz
-	DurationNs          int64
-	TotalInputTokens    int64
-	TotalOutputTokens   int64
-	MainInputTokens     int64
-	MainOutputTokens    int64
-	CacheReadTokens     int64
-	CacheCreationTokens int64
-	TotalCostUsd        pgtype.Numeric
-	SpanCount           int64
+	OrgID                pgtype.UUID
+	TraceID              string
+	SessionID            pgtype.UUID
+	UserPrompt           string
+	ResponsePreview      string
+	Synthetic            string
+	Status               string
+	Source               string
+	ParentTraceID        string
+	Abandoned            bool
+	StartedAt            pgtype.Timestamptz
+	EndedAt              pgtype.Timestamptz
+	DurationNs           int64
+	TotalInputTokens     int64
+	TotalOutputTokens    int64
+	MainInputTokens      int64
+	MainOutputTokens     int64
+	CacheReadTokens      int64
+	CacheCreationTokens  int64
+	TotalCostUsd         pgtype.Numeric
+	PeakContextTokens    int64
+	PeakContextOccupancy float64
+	ContextGrowthTokens  int64
+	ReclaimedTokens      int64
+	SpanCount            int64
 }
 
 // Session detail's lazy view: turn headers only, no span payloads.
@@ -744,6 +957,8 @@ func (q *Queries) ListTraceSummariesBySession(ctx context.Context, sessionID pgt
 			&i.Synthetic,
 			&i.Status,
 			&i.Source,
+			&i.ParentTraceID,
+			&i.Abandoned,
 			&i.StartedAt,
 			&i.EndedAt,
 			&i.DurationNs,
@@ -754,6 +969,10 @@ func (q *Queries) ListTraceSummariesBySession(ctx context.Context, sessionID pgt
 			&i.CacheReadTokens,
 			&i.CacheCreationTokens,
 			&i.TotalCostUsd,
+			&i.PeakContextTokens,
+			&i.PeakContextOccupancy,
+			&i.ContextGrowthTokens,
+			&i.ReclaimedTokens,
 			&i.SpanCount,
 		); err != nil {
 			return nil, err
@@ -877,12 +1096,12 @@ INSERT INTO spans_20260615 (
     org_id, trace_id, span_id, parent_span_id, session_id,
     kind, name, status, call_kind, thread_id, model, stop_reason,
     started_at, duration_ns, seq, input, output, usage, raw_turn_id, node_hash,
-    verdict, content_hash, derive_seq, fidelity
+    verdict, content_hash, derive_seq, fidelity, tool, retry_of
 ) VALUES (
     $1, $2, $3, $4, $5,
     $6, $7, $8, $9, $10, $11, $12,
     $13, $14, $15, $16, $17, $18, $19, $20,
-    $21, $22, $23, $24
+    $21, $22, $23, $24, $25, $26
 )
 ON CONFLICT (org_id, trace_id, span_id) DO UPDATE SET
     parent_span_id = EXCLUDED.parent_span_id,
@@ -905,6 +1124,8 @@ ON CONFLICT (org_id, trace_id, span_id) DO UPDATE SET
     verdict        = EXCLUDED.verdict,
     content_hash   = EXCLUDED.content_hash,
     fidelity       = EXCLUDED.fidelity,
+    tool           = EXCLUDED.tool,
+    retry_of       = EXCLUDED.retry_of,
     -- See UpsertSpanTurn: the cursor advances only on a real content change,
     -- so a consumer polling derive_seq sees changes rather than every row a
     -- re-derive happened to touch.
@@ -940,6 +1161,8 @@ type UpsertSpanParams struct {
 	ContentHash  string
 	DeriveSeq    int64
 	Fidelity     string
+	Tool         []byte
+	RetryOf      string
 }
 
 func (q *Queries) UpsertSpan(ctx context.Context, arg UpsertSpanParams) error {
@@ -968,6 +1191,8 @@ func (q *Queries) UpsertSpan(ctx context.Context, arg UpsertSpanParams) error {
 		arg.ContentHash,
 		arg.DeriveSeq,
 		arg.Fidelity,
+		arg.Tool,
+		arg.RetryOf,
 	)
 	return err
 }
@@ -1022,7 +1247,10 @@ INSERT INTO span_turns_20260615 (
     main_input_tokens, main_output_tokens,
     cache_read_tokens, cache_creation_tokens,
     total_cost_usd, source, tool_calls,
-    content_hash, derive_seq, fidelity
+    content_hash, derive_seq, fidelity,
+    parent_trace_id, abandoned,
+    peak_context_tokens, peak_context_occupancy,
+    context_growth_tokens, reclaimed_tokens
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7,
     $8, $9, $10,
@@ -1030,7 +1258,10 @@ INSERT INTO span_turns_20260615 (
     $13, $14,
     $15, $16,
     $17, $18, $19,
-    $20, $21, $22
+    $20, $21, $22,
+    $23, $24,
+    $25, $26,
+    $27, $28
 )
 ON CONFLICT (org_id, trace_id) DO UPDATE SET
     session_id            = COALESCE(span_turns_20260615.session_id, EXCLUDED.session_id),
@@ -1052,6 +1283,12 @@ ON CONFLICT (org_id, trace_id) DO UPDATE SET
     tool_calls            = EXCLUDED.tool_calls,
     content_hash          = EXCLUDED.content_hash,
     fidelity              = EXCLUDED.fidelity,
+    parent_trace_id       = EXCLUDED.parent_trace_id,
+    abandoned             = EXCLUDED.abandoned,
+    peak_context_tokens   = EXCLUDED.peak_context_tokens,
+    peak_context_occupancy = EXCLUDED.peak_context_occupancy,
+    context_growth_tokens = EXCLUDED.context_growth_tokens,
+    reclaimed_tokens      = EXCLUDED.reclaimed_tokens,
     -- Advance the cursor ONLY when the content actually changed. A derive
     -- pass rewrites every row of a covered session in place; bumping the
     -- sequence unconditionally would make every consumer re-read the whole
@@ -1065,28 +1302,34 @@ ON CONFLICT (org_id, trace_id) DO UPDATE SET
 `
 
 type UpsertSpanTurnParams struct {
-	OrgID               pgtype.UUID
-	TraceID             string
-	SessionID           pgtype.UUID
-	UserPrompt          string
-	ResponsePreview     string
-	Synthetic           string
-	Status              string
-	StartedAt           pgtype.Timestamptz
-	EndedAt             pgtype.Timestamptz
-	DurationNs          int64
-	TotalInputTokens    int64
-	TotalOutputTokens   int64
-	MainInputTokens     int64
-	MainOutputTokens    int64
-	CacheReadTokens     int64
-	CacheCreationTokens int64
-	TotalCostUsd        pgtype.Numeric
-	Source              string
-	ToolCalls           int64
-	ContentHash         string
-	DeriveSeq           int64
-	Fidelity            string
+	OrgID                pgtype.UUID
+	TraceID              string
+	SessionID            pgtype.UUID
+	UserPrompt           string
+	ResponsePreview      string
+	Synthetic            string
+	Status               string
+	StartedAt            pgtype.Timestamptz
+	EndedAt              pgtype.Timestamptz
+	DurationNs           int64
+	TotalInputTokens     int64
+	TotalOutputTokens    int64
+	MainInputTokens      int64
+	MainOutputTokens     int64
+	CacheReadTokens      int64
+	CacheCreationTokens  int64
+	TotalCostUsd         pgtype.Numeric
+	Source               string
+	ToolCalls            int64
+	ContentHash          string
+	DeriveSeq            int64
+	Fidelity             string
+	ParentTraceID        string
+	Abandoned            bool
+	PeakContextTokens    int64
+	PeakContextOccupancy float64
+	ContextGrowthTokens  int64
+	ReclaimedTokens      int64
 }
 
 // Span model writes. Span identity is deterministic (minted from wire
@@ -1116,6 +1359,12 @@ func (q *Queries) UpsertSpanTurn(ctx context.Context, arg UpsertSpanTurnParams)
 		arg.ContentHash,
 		arg.DeriveSeq,
 		arg.Fidelity,
+		arg.ParentTraceID,
+		arg.Abandoned,
+		arg.PeakContextTokens,
+		arg.PeakContextOccupancy,
+		arg.ContextGrowthTokens,
+		arg.ReclaimedTokens,
 	)
 	return err
 }
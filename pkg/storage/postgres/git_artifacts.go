@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/papercomputeco/tapes/pkg/derive"
+	"github.com/papercomputeco/tapes/pkg/storage"
+	"github.com/papercomputeco/tapes/pkg/storage/postgres/gensqlc"
+)
+
+// minSHAPrefix is the shortest sha git abbreviates to, and so the
+// shortest prefix a commit lookup tries.
+const minSHAPrefix = 7
+
+// writeGitArtifacts replaces one trace's git artifacts with its current
+// fold, the same way writeFileTouches replaces its file rows.
+func writeGitArtifacts(ctx context.Context, qtx *gensqlc.Queries, orgID, sessionID pgtype.UUID, turn *derive.SpanTurn) error {
+	if err := qtx.DeleteGitArtifactsForTrace(ctx, gensqlc.DeleteGitArtifactsForTraceParams{
+		OrgID:   orgID,
+		TraceID: turn.TraceID,
+	}); err != nil {
+		return fmt.Errorf("clear git artifacts %s: %w", turn.TraceID, err)
+	}
+	for i, a := range turn.Artifacts {
+		if err := qtx.InsertGitArtifact(ctx, gensqlc.InsertGitArtifactParams{
+			OrgID:     orgID,
+			TraceID:   turn.TraceID,
+			Ordinal:   int32(i),
+			SessionID: sessionID,
+			SpanID:    a.SpanID,
+			Kind:      a.Kind,
+			Sha:       a.SHA,
+			Branch:    a.Branch,
+			Url:       a.URL,
+			Subject:   a.Subject,
+			At:        pgtype.Timestamptz{Time: a.At, Valid: true},
+		}); err != nil {
+			return fmt.Errorf("insert git artifact %s/%d: %w", turn.TraceID, i, err)
+		}
+	}
+	return nil
+}
+
+// ListCommitSessions returns every artifact of the sessions that
+// committed or pushed sha, most recently active session first. Implements
+// storage.CommitSessionReader.
+func (d *Driver) ListCommitSessions(ctx context.Context, orgID, sha, authSubject string, limit int) ([]storage.GitArtifactRecord, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	org, err := orgIDFromString(orgKeyForLookup(orgID))
+	if err != nil {
+		return nil, fmt.Errorf("decode org_id: %w", err)
+	}
+	prefixes := make([]string, 0, max(len(sha)-minSHAPrefix+1, 0))
+	for n := minSHAPrefix; n <= len(sha); n++ {
+		prefixes = append(prefixes, sha[:n])
+	}
+	sessionRows, err := d.q.ListSessionIDsByCommit(ctx, gensqlc.ListSessionIDsByCommitParams{
+		OrgID:             org,
+		ShaPrefixes:       prefixes,
+		Sha:               sha,
+		AuthSubjectFilter: pgtype.Text{String: authSubject, Valid: authSubject != ""},
+		RowLimit:          int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list commit sessions: %w", err)
+	}
+	if len(sessionRows) == 0 {
+		return nil, nil
+	}
+	sessionIDs := make([]pgtype.UUID, 0, len(sessionRows))
+	rank := make(map[pgtype.UUID]int, len(sessionRows))
+	for i, r := range sessionRows {
+		sessionIDs = append(sessionIDs, r.SessionID)
+		rank[r.SessionID] = i
+	}
+	rows, err := d.q.ListGitArtifactsBySessions(ctx, gensqlc.ListGitArtifactsBySessionsParams{
+		OrgID:      org,
+		SessionIds: sessionIDs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list git artifacts: %w", err)
+	}
+	// Sessions in the order the lookup ranked them, each one's artifacts
+	// in the order they were produced.
+	bySession := make([][]storage.GitArtifactRecord, len(sessionRows))
+	for _, r := range rows {
+		i := rank[r.SessionID]
+		bySession[i] = append(bySession[i], storage.GitArtifactRecord{
+			SessionID: uuidString(r.SessionID),
+			TraceID:   r.TraceID,
+			SpanID:    r.SpanID,
+			Kind:      r.Kind,
+			SHA:       r.Sha,
+			Branch:    r.Branch,
+			URL:       r.Url,
+			Subject:   r.Subject,
+			At:        r.At.Time,
+		})
+	}
+	var out []storage.GitArtifactRecord
+	for _, artifacts := range bySession {
+		out = append(out, artifacts...)
+	}
+	return out, nil
+}
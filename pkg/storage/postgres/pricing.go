@@ -0,0 +1,287 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/papercomputeco/tapes/pkg/sessions"
+	"github.com/papercomputeco/tapes/pkg/storage"
+	"github.com/papercomputeco/tapes/pkg/storage/postgres/gensqlc"
+)
+
+// Compile-time guarantee that the Postgres driver hosts the pricing
+// catalog derive and the admin API type-assert for.
+var _ storage.PricingStore = (*Driver)(nil)
+
+// UpsertPrices implements storage.PricingStore. The rows land in one
+// transaction, so an import applies whole or not at all.
+func (d *Driver) UpsertPrices(ctx context.Context, prices []storage.Price) ([]storage.Price, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	tx, err := d.conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // commit shadows on success
+	qtx := d.q.WithTx(tx)
+
+	out := make([]storage.Price, 0, len(prices))
+	for _, price := range prices {
+		if price.Model == "" {
+			return nil, errors.New("price has no model")
+		}
+		if price.EffectiveFrom.IsZero() {
+			return nil, fmt.Errorf("price for %s has no effective_from", price.Model)
+		}
+		id, err := newAppUUID()
+		if err != nil {
+			return nil, fmt.Errorf("mint price id: %w", err)
+		}
+		doc, err := json.Marshal(price.Pricing)
+		if err != nil {
+			return nil, fmt.Errorf("encode pricing for %s: %w", price.Model, err)
+		}
+		row, err := qtx.UpsertPrice(ctx, gensqlc.UpsertPriceParams{
+			ID:            id,
+			Model:         sessions.NormalizeModel(price.Model),
+			EffectiveFrom: pgtype.Timestamptz{Time: price.EffectiveFrom, Valid: true},
+			Pricing:       doc,
+			Source:        price.Source,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("upsert price for %s: %w", price.Model, err)
+		}
+		stored, err := priceFromRow(row)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, stored)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	return out, nil
+}
+
+// ListPrices implements storage.PricingStore.
+func (d *Driver) ListPrices(ctx context.Context, model string) ([]storage.Price, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	if model != "" {
+		model = sessions.NormalizeModel(model)
+	}
+	rows, err := d.q.ListPrices(ctx, model)
+	if err != nil {
+		return nil, fmt.Errorf("list prices: %w", err)
+	}
+	out := make([]storage.Price, 0, len(rows))
+	for _, row := range rows {
+		price, err := priceFromRow(row)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, price)
+	}
+	return out, nil
+}
+
+// DeletePrice implements storage.PricingStore.
+func (d *Driver) DeletePrice(ctx context.Context, id string) error {
+	if d == nil || d.conn == nil {
+		return errors.New("postgres driver not open")
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return storage.ErrPriceNotFound
+	}
+	n, err := d.q.DeletePrice(ctx, pgtype.UUID{Bytes: parsed, Valid: true})
+	if err != nil {
+		return fmt.Errorf("delete price: %w", err)
+	}
+	if n == 0 {
+		return storage.ErrPriceNotFound
+	}
+	return nil
+}
+
+// CreatePriceDiscount implements storage.PricingStore.
+func (d *Driver) CreatePriceDiscount(ctx context.Context, discount storage.PriceDiscount) (*storage.PriceDiscount, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	if discount.Percent <= 0 || discount.Percent > 100 {
+		return nil, fmt.Errorf("discount percent %v is not in (0, 100]", discount.Percent)
+	}
+	if discount.EffectiveFrom.IsZero() {
+		return nil, errors.New("discount has no effective_from")
+	}
+	if discount.EffectiveUntil != nil && !discount.EffectiveUntil.After(discount.EffectiveFrom) {
+		return nil, errors.New("discount effective_until must be after effective_from")
+	}
+	id, err := newAppUUID()
+	if err != nil {
+		return nil, fmt.Errorf("mint discount id: %w", err)
+	}
+	orgID, err := orgIDFromString(discount.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	model := discount.Model
+	if model != "" {
+		model = sessions.NormalizeModel(model)
+	}
+	var until pgtype.Timestamptz
+	if discount.EffectiveUntil != nil {
+		until = pgtype.Timestamptz{Time: *discount.EffectiveUntil, Valid: true}
+	}
+	row, err := d.q.InsertPriceDiscount(ctx, gensqlc.InsertPriceDiscountParams{
+		ID:             id,
+		OrgID:          orgID,
+		Model:          model,
+		Percent:        discount.Percent,
+		EffectiveFrom:  pgtype.Timestamptz{Time: discount.EffectiveFrom, Valid: true},
+		EffectiveUntil: until,
+		Note:           discount.Note,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("insert price discount: %w", err)
+	}
+	stored := priceDiscountFromRow(row)
+	return &stored, nil
+}
+
+// ListPriceDiscounts implements storage.PricingStore.
+func (d *Driver) ListPriceDiscounts(ctx context.Context, orgID string) ([]storage.PriceDiscount, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	org, err := orgIDFromString(orgID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := d.q.ListPriceDiscounts(ctx, gensqlc.ListPriceDiscountsParams{
+		FilterOrg: orgID != "",
+		OrgID:     org,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list price discounts: %w", err)
+	}
+	out := make([]storage.PriceDiscount, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, priceDiscountFromRow(row))
+	}
+	return out, nil
+}
+
+// DeletePriceDiscount implements storage.PricingStore.
+func (d *Driver) DeletePriceDiscount(ctx context.Context, orgID, id string) error {
+	if d == nil || d.conn == nil {
+		return errors.New("postgres driver not open")
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return storage.ErrPriceNotFound
+	}
+	org, err := orgIDFromString(orgID)
+	if err != nil {
+		return err
+	}
+	n, err := d.q.DeletePriceDiscount(ctx, gensqlc.DeletePriceDiscountParams{
+		ID:        pgtype.UUID{Bytes: parsed, Valid: true},
+		FilterOrg: orgID != "",
+		OrgID:     org,
+	})
+	if err != nil {
+		return fmt.Errorf("delete price discount: %w", err)
+	}
+	if n == 0 {
+		return storage.ErrPriceNotFound
+	}
+	return nil
+}
+
+// PricingCatalog implements storage.PricingStore. An empty orgID reads the
+// single-tenant org's discounts, matching how every other org-scoped row is
+// keyed.
+func (d *Driver) PricingCatalog(ctx context.Context, orgID string) (*sessions.PricingCatalog, error) {
+	return d.pricingCatalog(ctx, d.q, orgID)
+}
+
+// pricingCatalog loads the catalog through q, so derive can read it inside
+// its own transaction.
+func (d *Driver) pricingCatalog(ctx context.Context, q *gensqlc.Queries, orgID string) (*sessions.PricingCatalog, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	org, err := orgIDFromString(orgID)
+	if err != nil {
+		return nil, err
+	}
+	priceRows, err := q.ListPrices(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("list prices: %w", err)
+	}
+	prices := make([]sessions.CatalogPrice, 0, len(priceRows))
+	for _, row := range priceRows {
+		price, err := priceFromRow(row)
+		if err != nil {
+			return nil, err
+		}
+		prices = append(prices, sessions.CatalogPrice{
+			Model:         price.Model,
+			EffectiveFrom: price.EffectiveFrom,
+			Pricing:       price.Pricing,
+		})
+	}
+	discountRows, err := q.ListPriceDiscounts(ctx, gensqlc.ListPriceDiscountsParams{FilterOrg: true, OrgID: org})
+	if err != nil {
+		return nil, fmt.Errorf("list price discounts: %w", err)
+	}
+	discounts := make([]sessions.PriceDiscount, 0, len(discountRows))
+	for _, row := range discountRows {
+		discount := priceDiscountFromRow(row)
+		discounts = append(discounts, sessions.PriceDiscount{
+			Model:          discount.Model,
+			Percent:        discount.Percent,
+			EffectiveFrom:  discount.EffectiveFrom,
+			EffectiveUntil: discount.EffectiveUntil,
+		})
+	}
+	return sessions.NewPricingCatalog(nil, prices, discounts), nil
+}
+
+func priceFromRow(row gensqlc.PricingCatalog) (storage.Price, error) {
+	var pricing sessions.Pricing
+	if err := json.Unmarshal(row.Pricing, &pricing); err != nil {
+		return storage.Price{}, fmt.Errorf("decode pricing for %s: %w", row.Model, err)
+	}
+	return storage.Price{
+		ID:            uuidString(row.ID),
+		Model:         row.Model,
+		EffectiveFrom: row.EffectiveFrom.Time,
+		Pricing:       pricing,
+		Source:        row.Source,
+		CreatedAt:     row.CreatedAt.Time,
+	}, nil
+}
+
+func priceDiscountFromRow(row gensqlc.PricingDiscount) storage.PriceDiscount {
+	return storage.PriceDiscount{
+		ID:             uuidString(row.ID),
+		OrgID:          uuidString(row.OrgID),
+		Model:          row.Model,
+		Percent:        row.Percent,
+		EffectiveFrom:  row.EffectiveFrom.Time,
+		EffectiveUntil: optionalTime(row.EffectiveUntil),
+		Note:           row.Note,
+		CreatedAt:      row.CreatedAt.Time,
+	}
+}
@@ -0,0 +1,105 @@
+-- Annotations: judgments attached to sessions, traces and spans, read and
+-- written by /v1/annotations and folded into /v1/stats.
+
+-- name: GetAnnotationSessionSubject :one
+-- The subject a session target was captured for, which also proves the
+-- session exists in the org.
+SELECT auth_subject
+FROM sessions
+WHERE org_id = sqlc.arg(org_id) AND id = sqlc.arg(id);
+
+-- name: GetAnnotationTraceSession :one
+-- The session a trace target belongs to, and its subject. A trace with
+-- no session cannot be annotated, so the join drops it.
+SELECT t.session_id, s.auth_subject
+FROM span_turns_20260615 t
+JOIN sessions s ON s.id = t.session_id
+WHERE t.org_id = sqlc.arg(org_id) AND t.trace_id = sqlc.arg(trace_id);
+
+-- name: AnnotationSpanExists :one
+SELECT EXISTS (
+    SELECT 1 FROM spans_20260615
+    WHERE org_id = sqlc.arg(org_id)
+      AND trace_id = sqlc.arg(trace_id)
+      AND span_id = sqlc.arg(span_id)
+)::boolean;
+
+-- name: UpsertAnnotation :one
+-- The same judgment written again replaces its value and keeps its id.
+INSERT INTO annotations (
+    id, org_id, session_id, trace_id, span_id,
+    name, label, score, note, author
+) VALUES (
+    sqlc.arg(id), sqlc.arg(org_id), sqlc.arg(session_id), sqlc.arg(trace_id), sqlc.arg(span_id),
+    sqlc.arg(name), sqlc.arg(label), sqlc.narg(score), sqlc.arg(note), sqlc.arg(author)
+)
+ON CONFLICT (org_id, session_id, trace_id, span_id, name, author) DO UPDATE
+SET label = EXCLUDED.label,
+    score = EXCLUDED.score,
+    note = EXCLUDED.note,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING id, org_id, session_id, trace_id, span_id, name, label, score, note, author, created_at, updated_at;
+
+-- name: GetAnnotation :one
+SELECT id, org_id, session_id, trace_id, span_id, name, label, score, note, author, created_at, updated_at
+FROM annotations
+WHERE org_id = sqlc.arg(org_id) AND id = sqlc.arg(id);
+
+-- name: ListAnnotations :many
+-- Every filter is optional; the auth_subject filter reaches sessions the
+-- same way AggregateSpanStats does.
+SELECT id, org_id, session_id, trace_id, span_id, name, label, score, note, author, created_at, updated_at
+FROM annotations
+WHERE org_id = sqlc.arg(org_id)
+  AND (sqlc.narg(session_id)::uuid IS NULL OR session_id = sqlc.narg(session_id)::uuid)
+  AND (sqlc.narg(trace_id)::text IS NULL OR trace_id = sqlc.narg(trace_id)::text)
+  AND (sqlc.narg(span_id)::text IS NULL OR span_id = sqlc.narg(span_id)::text)
+  AND (sqlc.narg(name)::text IS NULL OR name = sqlc.narg(name)::text)
+  AND (sqlc.narg(label)::text IS NULL OR label = sqlc.narg(label)::text)
+  AND (sqlc.narg(author)::text IS NULL OR author = sqlc.narg(author)::text)
+  AND (sqlc.narg(auth_subject_filter)::text IS NULL OR session_id IN (
+      SELECT s.id FROM sessions s
+      WHERE s.org_id = sqlc.arg(org_id) AND s.auth_subject = sqlc.narg(auth_subject_filter)::text))
+ORDER BY created_at, id
+LIMIT sqlc.arg(row_limit);
+
+-- name: UpdateAnnotation :one
+UPDATE annotations
+SET label = sqlc.arg(label),
+    score = sqlc.narg(score),
+    note = sqlc.arg(note),
+    updated_at = CURRENT_TIMESTAMP
+WHERE org_id = sqlc.arg(org_id) AND id = sqlc.arg(id)
+RETURNING id, org_id, session_id, trace_id, span_id, name, label, score, note, author, created_at, updated_at;
+
+-- name: DeleteAnnotation :execrows
+DELETE FROM annotations
+WHERE org_id = sqlc.arg(org_id) AND id = sqlc.arg(id);
+
+-- name: AggregateAnnotations :many
+-- Annotation counts and score sums per name and label, for /v1/stats;
+-- the caller folds the labels into per-name figures. An annotation is in
+-- the window when its session has a trace started in [since, until) —
+-- the predicate session_count counts sessions by — and the auth_subject
+-- filter reaches sessions the same way AggregateSpanStats does.
+SELECT
+    a.name,
+    a.label,
+    COUNT(*)::bigint                    AS annotations,
+    COUNT(a.score)::bigint              AS scored,
+    COALESCE(SUM(a.score), 0)::float8   AS score_sum,
+    COALESCE(MIN(a.score), 0)::float8   AS score_min,
+    COALESCE(MAX(a.score), 0)::float8   AS score_max
+FROM annotations a
+JOIN sessions s ON s.id = a.session_id
+WHERE a.org_id = sqlc.arg(org_id)
+  AND (sqlc.narg(auth_subject_filter)::text IS NULL OR s.auth_subject = sqlc.narg(auth_subject_filter)::text)
+  AND ((sqlc.narg(since_filter)::timestamptz IS NULL AND sqlc.narg(until_filter)::timestamptz IS NULL)
+       OR EXISTS (
+           SELECT 1 FROM span_turns_20260615 t
+           WHERE t.org_id = a.org_id
+             AND t.session_id = a.session_id
+             AND (sqlc.narg(since_filter)::timestamptz IS NULL OR t.started_at >= sqlc.narg(since_filter)::timestamptz)
+             AND (sqlc.narg(until_filter)::timestamptz IS NULL OR t.started_at < sqlc.narg(until_filter)::timestamptz)))
+GROUP BY a.name, a.label
+ORDER BY a.name, a.label;
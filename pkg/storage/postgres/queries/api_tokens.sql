@@ -0,0 +1,40 @@
+-- name: InsertAPIToken :one
+INSERT INTO api_tokens (
+    id, org_id, name, token_prefix, token_hash, scopes, expires_at
+) VALUES (
+    sqlc.arg(id), sqlc.arg(org_id), sqlc.arg(name), sqlc.arg(token_prefix),
+    sqlc.arg(token_hash), sqlc.arg(scopes)::text[], sqlc.narg(expires_at)
+)
+RETURNING id, org_id, name, token_prefix, scopes, created_at,
+          expires_at, last_used_at, revoked_at;
+
+-- name: ListAPITokens :many
+-- Every org's tokens: the CLI is the operator's view of the whole table.
+SELECT id, org_id, name, token_prefix, scopes, created_at,
+       expires_at, last_used_at, revoked_at
+FROM api_tokens
+WHERE sqlc.arg(include_revoked)::boolean OR revoked_at IS NULL
+ORDER BY created_at, id;
+
+-- name: FindActiveAPITokenByHash :one
+-- The per-request lookup. Revoked and expired tokens simply do not match, so
+-- the caller cannot distinguish "never existed" from "no longer valid" — nor
+-- should a client probing the API be able to.
+SELECT id, org_id, name, token_prefix, scopes, created_at,
+       expires_at, last_used_at, revoked_at
+FROM api_tokens
+WHERE token_hash = sqlc.arg(token_hash)
+  AND revoked_at IS NULL
+  AND (expires_at IS NULL OR expires_at > now());
+
+-- name: TouchAPIToken :exec
+UPDATE api_tokens
+SET last_used_at = now()
+WHERE id = sqlc.arg(id);
+
+-- name: RevokeAPIToken :execrows
+-- Token ids are unique across orgs, so the operator revokes by id alone.
+UPDATE api_tokens
+SET revoked_at = now()
+WHERE id = sqlc.arg(id)
+  AND revoked_at IS NULL;
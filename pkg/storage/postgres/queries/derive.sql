@@ -1,7 +1,9 @@
 -- name: SessionIDByHarnessKey :one
 -- Resolve the sessions row for one raw turn's natural key. The deriver
 -- only attributes to existing sessions; it never creates them.
-SELECT id FROM sessions
+-- retention_tier rides along so the write path can leave a session whose
+-- payloads a retention rule stripped untouched (see 1781540000_retention).
+SELECT id, retention_tier FROM sessions
 WHERE org_id = $1
   AND harness_id = $2
   AND harness_session_id = $3;
@@ -0,0 +1,47 @@
+-- File activity per trace, written by the deriver and read by /v1/files.
+
+-- name: DeleteFileTouchesForTrace :exec
+-- A derive rewrites a trace's file rows whole: the fold is a function of
+-- the trace's spans, so the previous rows carry nothing to keep.
+DELETE FROM file_touches_20260615
+WHERE org_id = $1 AND trace_id = $2;
+
+-- name: InsertFileTouch :exec
+INSERT INTO file_touches_20260615 (
+    org_id, trace_id, path, session_id, name,
+    reads, creates, edits, deletes, lines_added, lines_removed,
+    first_at, last_at
+) VALUES (
+    $1, $2, $3, $4, $5,
+    $6, $7, $8, $9, $10, $11,
+    $12, $13
+);
+
+-- name: ListFileTouchesByPath :many
+-- Every trace that touched a file, newest activity first. The name filter
+-- rides file_touches_20260615_org_name_idx; the path check then accepts
+-- the path itself or any path that ends with it at a directory boundary,
+-- so pkg/api/server.go finds /home/dev/repo/pkg/api/server.go. The
+-- auth_subject filter reaches sessions the same way AggregateSpanStats
+-- does.
+SELECT
+    ft.session_id,
+    ft.trace_id,
+    ft.path,
+    ft.reads,
+    ft.creates,
+    ft.edits,
+    ft.deletes,
+    ft.lines_added,
+    ft.lines_removed,
+    ft.first_at,
+    ft.last_at
+FROM file_touches_20260615 ft
+JOIN sessions s ON s.id = ft.session_id
+WHERE ft.org_id = sqlc.arg(org_id)
+  AND ft.name = sqlc.arg(name)
+  AND (ft.path = sqlc.arg(path)::text
+       OR right(ft.path, length(sqlc.arg(path)::text) + 1) = '/' || sqlc.arg(path)::text)
+  AND (sqlc.narg(auth_subject_filter)::text IS NULL OR s.auth_subject = sqlc.narg(auth_subject_filter)::text)
+ORDER BY ft.last_at DESC, ft.trace_id, ft.path
+LIMIT sqlc.arg(row_limit);
@@ -0,0 +1,42 @@
+-- Git artifacts per trace, written by the deriver and read by
+-- /v1/commits/{sha}/sessions.
+
+-- name: DeleteGitArtifactsForTrace :exec
+-- A derive rewrites a trace's artifacts whole, like its file touches.
+DELETE FROM git_artifacts_20260615
+WHERE org_id = $1 AND trace_id = $2;
+
+-- name: InsertGitArtifact :exec
+INSERT INTO git_artifacts_20260615 (
+    org_id, trace_id, ordinal, session_id, span_id,
+    kind, sha, branch, url, subject, at
+) VALUES (
+    $1, $2, $3, $4, $5,
+    $6, $7, $8, $9, $10, $11
+);
+
+-- name: ListSessionIDsByCommit :many
+-- Sessions with a commit or push whose sha matches the requested one,
+-- most recent first. A stored sha is usually abbreviated, so it matches
+-- when it is one of the request's prefixes (an equality per prefix on
+-- git_artifacts_20260615_org_sha_idx); a request shorter than the stored
+-- sha matches it by prefix as well. The auth_subject filter reaches
+-- sessions the same way AggregateSpanStats does.
+SELECT ga.session_id, MAX(ga.at)::timestamptz AS last_at
+FROM git_artifacts_20260615 ga
+JOIN sessions s ON s.id = ga.session_id
+WHERE ga.org_id = sqlc.arg(org_id)
+  AND ga.sha <> ''
+  AND (ga.sha = ANY(sqlc.arg(sha_prefixes)::text[]) OR starts_with(ga.sha, sqlc.arg(sha)::text))
+  AND (sqlc.narg(auth_subject_filter)::text IS NULL OR s.auth_subject = sqlc.narg(auth_subject_filter)::text)
+GROUP BY ga.session_id
+ORDER BY last_at DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListGitArtifactsBySessions :many
+-- Every artifact of the given sessions, in the order they were produced.
+SELECT session_id, trace_id, span_id, kind, sha, branch, url, subject, at
+FROM git_artifacts_20260615
+WHERE org_id = sqlc.arg(org_id)
+  AND session_id = ANY(sqlc.arg(session_ids)::uuid[])
+ORDER BY at, trace_id, ordinal;
@@ -0,0 +1,43 @@
+-- name: UpsertPrice :one
+-- A re-imported sheet replaces the rate for the same model and instant;
+-- the row keeps its id so references to it stay stable.
+INSERT INTO pricing_catalog (
+    id, model, effective_from, pricing, source
+) VALUES (
+    sqlc.arg(id), sqlc.arg(model), sqlc.arg(effective_from),
+    sqlc.arg(pricing), sqlc.arg(source)
+)
+ON CONFLICT (model, effective_from) DO UPDATE
+SET pricing = EXCLUDED.pricing,
+    source = EXCLUDED.source
+RETURNING id, model, effective_from, pricing, source, created_at;
+
+-- name: ListPrices :many
+SELECT id, model, effective_from, pricing, source, created_at
+FROM pricing_catalog
+WHERE sqlc.arg(model)::text = '' OR model = sqlc.arg(model)::text
+ORDER BY model, effective_from;
+
+-- name: DeletePrice :execrows
+DELETE FROM pricing_catalog
+WHERE id = sqlc.arg(id);
+
+-- name: InsertPriceDiscount :one
+INSERT INTO pricing_discounts (
+    id, org_id, model, percent, effective_from, effective_until, note
+) VALUES (
+    sqlc.arg(id), sqlc.arg(org_id), sqlc.arg(model), sqlc.arg(percent),
+    sqlc.arg(effective_from), sqlc.narg(effective_until), sqlc.arg(note)
+)
+RETURNING id, org_id, model, percent, effective_from, effective_until, note, created_at;
+
+-- name: ListPriceDiscounts :many
+SELECT id, org_id, model, percent, effective_from, effective_until, note, created_at
+FROM pricing_discounts
+WHERE NOT sqlc.arg(filter_org)::boolean OR org_id = sqlc.arg(org_id)
+ORDER BY created_at, id;
+
+-- name: DeletePriceDiscount :execrows
+DELETE FROM pricing_discounts
+WHERE id = sqlc.arg(id)
+  AND (NOT sqlc.arg(filter_org)::boolean OR org_id = sqlc.arg(org_id));
@@ -0,0 +1,124 @@
+-- name: ListRawTurnsForExport :many
+-- One page of verbatim raw turns for an export bundle, in insertion order.
+-- Unlike ListRawTurns this applies NO correction overlay: the bundle carries
+-- the corrections as their own rows, so the destination rebuilds the same
+-- audit log instead of receiving the corrected view baked into the turn.
+--
+-- The session filter matches effective attribution (the latest correction
+-- wins) and expands through sessions.parent_session_id, so naming a session
+-- brings its subagent sessions along. active_since selects sessions by
+-- last_seen_at and exports their whole history: a partial session would
+-- derive to a different projection at the destination.
+--
+-- Stripped turns are excluded. Their content is gone, and importing them
+-- would give the destination a session it can re-derive only to zero.
+WITH RECURSIVE wanted AS (
+    SELECT s.id, s.harness_session_id
+    FROM sessions s
+    WHERE s.harness_session_id = ANY(sqlc.arg(harness_session_ids)::text[])
+    UNION
+    SELECT c.id, c.harness_session_id
+    FROM sessions c
+    JOIN wanted w ON c.parent_session_id = w.id
+)
+SELECT r.id, r.org_id, r.source, r.provider, r.agent_name,
+       r.harness_id, r.harness_session_id, r.request_id,
+       r.raw_request, r.response, r.meta, r.session_envelope,
+       r.received_at,
+       r.raw_response, r.raw_response_encoding, r.raw_response_dropped
+FROM raw_turns r
+LEFT JOIN LATERAL (
+    SELECT harness_session_id
+    FROM raw_turn_attribution_corrections
+    WHERE org_id = r.org_id AND raw_turn_id = r.id
+    ORDER BY id DESC LIMIT 1
+) c ON TRUE
+WHERE r.id > sqlc.arg(after_id)
+  AND r.payload_stripped_at IS NULL
+  AND (cardinality(sqlc.arg(harness_session_ids)::text[]) = 0
+       OR COALESCE(c.harness_session_id, r.harness_session_id) = ANY(sqlc.arg(harness_session_ids)::text[])
+       OR COALESCE(c.harness_session_id, r.harness_session_id) IN (SELECT harness_session_id FROM wanted))
+  AND (sqlc.narg(active_since)::timestamptz IS NULL
+       OR COALESCE(c.harness_session_id, r.harness_session_id) IN (
+           SELECT harness_session_id FROM sessions
+           WHERE last_seen_at >= sqlc.narg(active_since)::timestamptz))
+ORDER BY r.id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListRawTurnCorrectionsByRawTurnIDs :many
+SELECT id, org_id, raw_turn_id, harness_id, harness_session_id, thread_id,
+       parent_harness_session_id, reason, created_at
+FROM raw_turn_attribution_corrections
+WHERE raw_turn_id = ANY(sqlc.arg(raw_turn_ids)::bigint[])
+ORDER BY id;
+
+-- name: FindRawTurnIDByRequestID :one
+-- The import-side half of the capture-identity dedupe: the same key
+-- raw_turns_org_request_uq enforces on insert.
+SELECT id
+FROM raw_turns
+WHERE org_id = sqlc.arg(org_id)
+  AND request_id = sqlc.arg(request_id)
+  AND request_id <> '';
+
+-- name: FindRawTurnIDByContent :one
+-- Capture identity for a turn that carried no request id: nothing else
+-- distinguishes a re-import from a genuine second capture, so the whole
+-- identity is matched, received_at included. Only an imported row keeps a
+-- received_at from another deployment, so a live capture never matches.
+SELECT id
+FROM raw_turns
+WHERE org_id = sqlc.arg(org_id)
+  AND request_id = ''
+  AND source = sqlc.arg(source)
+  AND harness_id = sqlc.arg(harness_id)
+  AND harness_session_id = sqlc.arg(harness_session_id)
+  AND received_at = sqlc.arg(received_at)
+  AND raw_request IS NOT DISTINCT FROM sqlc.narg(raw_request)::jsonb
+ORDER BY id
+LIMIT 1;
+
+-- name: InsertImportedRawTurn :one
+-- InsertRawTurn with the exporting deployment's received_at kept, so the
+-- imported turn orders and times exactly as it did at capture. The ON
+-- CONFLICT arm covers a concurrent capture of the same request id landing
+-- between the caller's lookup and this insert; the caller re-reads the id.
+INSERT INTO raw_turns (
+    org_id, source, provider, agent_name,
+    harness_id, harness_session_id, request_id,
+    raw_request, response, meta, session_envelope,
+    raw_response, raw_response_encoding, raw_response_dropped,
+    received_at
+) VALUES (
+    sqlc.arg(org_id), sqlc.arg(source), sqlc.arg(provider), sqlc.arg(agent_name),
+    sqlc.arg(harness_id), sqlc.arg(harness_session_id), sqlc.arg(request_id),
+    sqlc.narg(raw_request), sqlc.narg(response), sqlc.narg(meta), sqlc.narg(session_envelope),
+    sqlc.narg(raw_response), sqlc.arg(raw_response_encoding), sqlc.arg(raw_response_dropped),
+    sqlc.arg(received_at)
+)
+ON CONFLICT (org_id, request_id) WHERE request_id <> '' DO NOTHING
+RETURNING id;
+
+-- name: RawTurnCorrectionExists :one
+SELECT EXISTS (
+    SELECT 1
+    FROM raw_turn_attribution_corrections
+    WHERE org_id = sqlc.arg(org_id)
+      AND raw_turn_id = sqlc.arg(raw_turn_id)
+      AND harness_id = sqlc.arg(harness_id)
+      AND harness_session_id = sqlc.arg(harness_session_id)
+      AND thread_id = sqlc.arg(thread_id)
+      AND parent_harness_session_id IS NOT DISTINCT FROM sqlc.narg(parent_harness_session_id)
+      AND reason = sqlc.arg(reason)
+      AND created_at = sqlc.arg(created_at)
+)::boolean;
+
+-- name: InsertImportedRawTurnCorrection :exec
+INSERT INTO raw_turn_attribution_corrections (
+    org_id, raw_turn_id, harness_id, harness_session_id, thread_id,
+    parent_harness_session_id, reason, created_at
+) VALUES (
+    sqlc.arg(org_id), sqlc.arg(raw_turn_id), sqlc.arg(harness_id),
+    sqlc.arg(harness_session_id), sqlc.arg(thread_id),
+    sqlc.narg(parent_harness_session_id), sqlc.arg(reason), sqlc.arg(created_at)
+);
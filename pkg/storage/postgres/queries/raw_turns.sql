@@ -141,7 +141,8 @@ ORDER BY r.id ASC;
 -- an "unknown" case into the caller that has no meaning here.
 SELECT id,
        COALESCE(octet_length(raw_response) > 0, false)::boolean AS has_raw_response,
-       raw_response_dropped
+       raw_response_dropped,
+       (payload_stripped_at IS NOT NULL)::boolean AS payload_stripped
 FROM raw_turns
 WHERE id = ANY(sqlc.arg(ids)::bigint[]);
 
@@ -0,0 +1,135 @@
+-- name: ListRetentionCandidates :many
+-- Sessions a retention rule matches: last seen before the cutoff, optionally
+-- narrowed to one harness and/or one auth subject ('' matches any). Oldest
+-- first so a bounded page always makes progress on the backlog. Sessions a
+-- strip rule already stripped are excluded when skip_stripped is set — a
+-- re-strip is a no-op, and listing them would starve the page.
+SELECT id, org_id, harness_id, harness_session_id, auth_subject,
+       last_seen_at, retention_tier
+FROM sessions
+WHERE last_seen_at < sqlc.arg(cutoff)
+  AND (sqlc.arg(harness_id)::text = '' OR harness_id = sqlc.arg(harness_id)::text)
+  AND (sqlc.arg(auth_subject)::text = '' OR auth_subject = sqlc.arg(auth_subject)::text)
+  AND (NOT sqlc.arg(skip_stripped)::boolean OR retention_tier <> 'stripped')
+ORDER BY last_seen_at, id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListSessionSubtree :many
+-- A session plus every subagent session under it (parent_session_id,
+-- recursively). Retention acts on the subtree as one unit: a subagent's
+-- prompts are the parent conversation's content, and DELETE cascades through
+-- parent_session_id anyway, so a child's raw turns must go in the same pass
+-- or they outlive the session they belonged to.
+WITH RECURSIVE subtree AS (
+    SELECT s.id, s.harness_id, s.harness_session_id
+    FROM sessions s
+    WHERE s.org_id = sqlc.arg(org_id) AND s.id = sqlc.arg(id)
+    UNION
+    SELECT c.id, c.harness_id, c.harness_session_id
+    FROM sessions c
+    JOIN subtree p ON c.parent_session_id = p.id
+    WHERE c.org_id = sqlc.arg(org_id)
+)
+SELECT id, harness_id, harness_session_id FROM subtree;
+
+-- name: ListRawTurnIDsByEffectiveSession :many
+-- Raw turn ids whose EFFECTIVE attribution (latest correction, else the
+-- captured identity) is the given harness session — the same resolution the
+-- deriver reads through, so retention removes exactly the rows that project
+-- into the session and never a row a repair moved elsewhere.
+SELECT r.id
+FROM raw_turns r
+WHERE r.org_id = sqlc.arg(org_id)
+  AND COALESCE((
+      SELECT c.harness_id FROM raw_turn_attribution_corrections c
+      WHERE c.org_id = r.org_id AND c.raw_turn_id = r.id
+      ORDER BY c.id DESC LIMIT 1
+  ), r.harness_id) = sqlc.arg(harness_id)
+  AND COALESCE((
+      SELECT c.harness_session_id FROM raw_turn_attribution_corrections c
+      WHERE c.org_id = r.org_id AND c.raw_turn_id = r.id
+      ORDER BY c.id DESC LIMIT 1
+  ), r.harness_session_id) = sqlc.arg(harness_session_id)
+ORDER BY r.id;
+
+-- name: StripRawTurnPayloads :execrows
+-- Remove a raw turn's prompt and response content, keeping the identity,
+-- meta, and the reduced response's model / stop_reason / usage skeleton the
+-- cost rollups were folded from. Guarded on payload_stripped_at so a retry
+-- keeps the original strip time.
+UPDATE raw_turns
+SET raw_request         = NULL,
+    raw_response        = NULL,
+    response            = CASE
+                              WHEN response IS NULL THEN NULL
+                              ELSE jsonb_strip_nulls(jsonb_build_object(
+                                  'model', response->'model',
+                                  'done', response->'done',
+                                  'stop_reason', response->'stop_reason',
+                                  'usage', response->'usage'
+                              ))
+                          END,
+    payload_stripped_at = sqlc.arg(stripped_at)
+WHERE org_id = sqlc.arg(org_id)
+  AND id = ANY(sqlc.arg(ids)::bigint[])
+  AND payload_stripped_at IS NULL;
+
+-- name: StripSessionSpanPayloads :execrows
+-- Drop span input/output for the given sessions and stamp the 'stripped'
+-- fidelity tier. content_hash is re-keyed and derive_seq re-stamped so a
+-- change-feed consumer sees the rows change instead of holding stale copies
+-- of content that no longer exists anywhere else.
+UPDATE spans_20260615
+SET input        = NULL,
+    output       = NULL,
+    fidelity     = 'stripped',
+    content_hash = 'stripped:' || content_hash,
+    derive_seq   = pg_current_xact_id()::text::bigint
+WHERE org_id = sqlc.arg(org_id)
+  AND session_id = ANY(sqlc.arg(session_ids)::uuid[])
+  AND fidelity <> 'stripped';
+
+-- name: StripSessionSpanTurnPayloads :execrows
+-- The trace-level counterpart: prompt and response previews are content.
+-- Token, cost, and tool-call rollups stay.
+UPDATE span_turns_20260615
+SET user_prompt      = '',
+    response_preview = '',
+    fidelity         = 'stripped',
+    content_hash     = 'stripped:' || content_hash,
+    derive_seq       = pg_current_xact_id()::text::bigint
+WHERE org_id = sqlc.arg(org_id)
+  AND session_id = ANY(sqlc.arg(session_ids)::uuid[])
+  AND fidelity <> 'stripped';
+
+-- name: MarkSessionsStripped :execrows
+UPDATE sessions
+SET retention_tier       = 'stripped',
+    retention_applied_at = sqlc.arg(applied_at)
+WHERE org_id = sqlc.arg(org_id)
+  AND id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: DeleteRawTurnCorrectionsByIDs :execrows
+DELETE FROM raw_turn_attribution_corrections
+WHERE org_id = sqlc.arg(org_id)
+  AND raw_turn_id = ANY(sqlc.arg(ids)::bigint[]);
+
+-- name: DeleteRawTurnsByIDs :execrows
+-- The retention delete: the only DELETE raw_turns ever sees.
+DELETE FROM raw_turns
+WHERE org_id = sqlc.arg(org_id)
+  AND id = ANY(sqlc.arg(ids)::bigint[]);
+
+-- name: DeleteDeriveQueueEntry :execrows
+-- Unconditional, unlike ClearDeriveDirty: the session is going away, so a
+-- mark that raced in is as dead as the rows it points at.
+DELETE FROM derive_queue
+WHERE org_id = sqlc.arg(org_id)
+  AND harness_id = sqlc.arg(harness_id)
+  AND harness_session_id = sqlc.arg(harness_session_id);
+
+-- name: DeleteSessionsByIDs :execrows
+-- ON DELETE CASCADE tears down the span projection with the rows.
+DELETE FROM sessions
+WHERE org_id = sqlc.arg(org_id)
+  AND id = ANY(sqlc.arg(ids)::uuid[]);
@@ -172,12 +172,16 @@ RETURNING id;
 -- just writes them. derived_status mirrors pkg/sessions.DetermineStatus over
 -- those signals and the session's terminal main-spine span. Called only by
 -- the deriver (writeSpanSet) during the derive pass — the ingest path no
--- longer writes status.
+-- longer writes status. outcome and outcome_reasons are
+-- pkg/derive.FoldSessionOutcome's refinement of that status, written with
+-- it.
 UPDATE sessions
    SET has_git_activity  = sqlc.arg(has_git_activity),
        tool_result_count = sqlc.arg(tool_result_count),
        tool_error_count  = sqlc.arg(tool_error_count),
-       derived_status    = sqlc.arg(derived_status)
+       derived_status    = sqlc.arg(derived_status),
+       outcome           = sqlc.arg(outcome),
+       outcome_reasons   = sqlc.arg(outcome_reasons)
  WHERE id = sqlc.arg(id);
 
 -- name: GetSessionRecord :one
@@ -218,6 +222,12 @@ UPDATE sessions SET tasks = sqlc.arg(tasks) WHERE id = sqlc.arg(id);
 -- Re-derive overwrites it idempotently.
 UPDATE sessions SET kind_counts = sqlc.arg(kind_counts) WHERE id = sqlc.arg(id);
 
+-- name: UpdateSessionContextUsage :exec
+-- Write the context-window fold (peak occupancy, compactions, cache hit
+-- ratio) onto the session as a JSONB object. Re-derive overwrites it
+-- idempotently.
+UPDATE sessions SET context_usage = sqlc.arg(context_usage) WHERE id = sqlc.arg(id);
+
 -- name: UpdateSessionDisplayName :execrows
 -- User-driven rename of a session's display title (Console edit affordance).
 -- Writes the dedicated `display_name` column — NOT `name`. `name` carries
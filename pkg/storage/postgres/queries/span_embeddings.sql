@@ -0,0 +1,81 @@
+-- Span embeddings: the embed worker's vectors and feed cursors, and the
+-- semantic span search over them. The tables exist only where
+-- 1781635000 found pgvector installed.
+
+-- name: SpanEmbeddingsExist :one
+SELECT (to_regclass('span_embeddings_20260615') IS NOT NULL
+        AND to_regclass('span_embed_cursors') IS NOT NULL)::boolean AS present;
+
+-- name: GetSpanEmbedCursor :one
+SELECT derive_seq, org_id, trace_id, span_id
+FROM span_embed_cursors
+WHERE model = $1;
+
+-- name: ListSpansToEmbed :many
+-- The span change feed across every org, which is why it runs as the
+-- owner role: one worker embeds for the deployment. The upper bound is
+-- ListChangedSpans', for the same reason. A span whose stored vector was
+-- embedded from the same content is reported current, without its
+-- payloads.
+SELECT sp.derive_seq, sp.org_id, sp.trace_id, sp.span_id, sp.content_hash,
+       COALESCE(e.content_hash = sp.content_hash, false)::boolean AS current,
+       CASE WHEN e.content_hash = sp.content_hash THEN NULL ELSE sp.input END AS input,
+       CASE WHEN e.content_hash = sp.content_hash THEN NULL ELSE sp.output END AS output
+FROM spans_20260615 sp
+LEFT JOIN span_embeddings_20260615 e
+       ON e.org_id = sp.org_id AND e.trace_id = sp.trace_id AND e.span_id = sp.span_id
+      AND e.model = sqlc.arg(model)
+WHERE sp.kind = 'llm' AND sp.call_kind = 'main'
+  AND (sp.derive_seq, sp.org_id, sp.trace_id, sp.span_id)
+      > (sqlc.arg(after_seq)::bigint, sqlc.arg(after_org_id)::uuid,
+         sqlc.arg(after_trace_id)::text, sqlc.arg(after_span_id)::text)
+  AND sp.derive_seq < pg_snapshot_xmin(pg_current_snapshot())::text::bigint
+ORDER BY sp.derive_seq, sp.org_id, sp.trace_id, sp.span_id
+LIMIT sqlc.arg(page_size);
+
+-- name: UpsertSpanEmbedding :exec
+-- The span may have been pruned since it was listed; inserting only
+-- while it exists keeps the foreign key from failing the whole page over
+-- it.
+INSERT INTO span_embeddings_20260615 (org_id, trace_id, span_id, model, content_hash, embedding)
+SELECT sqlc.arg(org_id)::uuid, sqlc.arg(trace_id)::text, sqlc.arg(span_id)::text,
+       sqlc.arg(model)::text, sqlc.arg(content_hash)::text, sqlc.arg(embedding)::vector
+WHERE EXISTS (
+    SELECT 1 FROM spans_20260615
+    WHERE org_id = sqlc.arg(org_id)::uuid
+      AND trace_id = sqlc.arg(trace_id)::text
+      AND span_id = sqlc.arg(span_id)::text
+)
+ON CONFLICT (org_id, trace_id, span_id, model) DO UPDATE
+SET content_hash = EXCLUDED.content_hash, embedding = EXCLUDED.embedding, embedded_at = now();
+
+-- name: UpsertSpanEmbedCursor :exec
+INSERT INTO span_embed_cursors (model, derive_seq, org_id, trace_id, span_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (model) DO UPDATE
+SET derive_seq = EXCLUDED.derive_seq, org_id = EXCLUDED.org_id, trace_id = EXCLUDED.trace_id,
+    span_id = EXCLUDED.span_id, updated_at = now();
+
+-- name: DeleteOtherSpanEmbeddings :execrows
+DELETE FROM span_embeddings_20260615 WHERE model <> $1;
+
+-- name: DeleteOtherSpanEmbedCursors :exec
+DELETE FROM span_embed_cursors WHERE model <> $1;
+
+-- name: SearchSpanEmbeddings :many
+-- The org's spans embedded under the model, nearest the query vector by
+-- cosine distance. There is no approximate index — the column is unsized,
+-- which pgvector's indexes do not accept — so this is an exact scan of
+-- the org's vectors for the model.
+SELECT COALESCE(sp.session_id::text, '')::text AS session_id, e.trace_id, e.span_id, sp.model,
+       (1 - (e.embedding <=> sqlc.arg(query)::vector))::float8 AS score,
+       sp.started_at, COALESCE(t.user_prompt, '')::text AS user_prompt, sp.input, sp.output
+FROM span_embeddings_20260615 e
+JOIN spans_20260615 sp
+  ON sp.org_id = e.org_id AND sp.trace_id = e.trace_id AND sp.span_id = e.span_id
+LEFT JOIN span_turns_20260615 t ON t.org_id = e.org_id AND t.trace_id = e.trace_id
+LEFT JOIN sessions s ON s.id = sp.session_id
+WHERE e.org_id = sqlc.arg(org_id) AND e.model = sqlc.arg(model)
+  AND (sqlc.arg(auth_subject)::text = '' OR s.auth_subject = sqlc.arg(auth_subject)::text)
+ORDER BY e.embedding <=> sqlc.arg(query)::vector
+LIMIT sqlc.arg(result_limit);
@@ -10,7 +10,10 @@ INSERT INTO span_turns_20260615 (
     main_input_tokens, main_output_tokens,
     cache_read_tokens, cache_creation_tokens,
     total_cost_usd, source, tool_calls,
-    content_hash, derive_seq, fidelity
+    content_hash, derive_seq, fidelity,
+    parent_trace_id, abandoned,
+    peak_context_tokens, peak_context_occupancy,
+    context_growth_tokens, reclaimed_tokens
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7,
     $8, $9, $10,
@@ -18,7 +21,10 @@ INSERT INTO span_turns_20260615 (
     $13, $14,
     $15, $16,
     $17, $18, $19,
-    $20, $21, $22
+    $20, $21, $22,
+    $23, $24,
+    $25, $26,
+    $27, $28
 )
 ON CONFLICT (org_id, trace_id) DO UPDATE SET
     session_id            = COALESCE(span_turns_20260615.session_id, EXCLUDED.session_id),
@@ -40,6 +46,12 @@ ON CONFLICT (org_id, trace_id) DO UPDATE SET
     tool_calls            = EXCLUDED.tool_calls,
     content_hash          = EXCLUDED.content_hash,
     fidelity              = EXCLUDED.fidelity,
+    parent_trace_id       = EXCLUDED.parent_trace_id,
+    abandoned             = EXCLUDED.abandoned,
+    peak_context_tokens   = EXCLUDED.peak_context_tokens,
+    peak_context_occupancy = EXCLUDED.peak_context_occupancy,
+    context_growth_tokens = EXCLUDED.context_growth_tokens,
+    reclaimed_tokens      = EXCLUDED.reclaimed_tokens,
     -- Advance the cursor ONLY when the content actually changed. A derive
     -- pass rewrites every row of a covered session in place; bumping the
     -- sequence unconditionally would make every consumer re-read the whole
@@ -56,12 +68,12 @@ INSERT INTO spans_20260615 (
     org_id, trace_id, span_id, parent_span_id, session_id,
     kind, name, status, call_kind, thread_id, model, stop_reason,
     started_at, duration_ns, seq, input, output, usage, raw_turn_id, node_hash,
-    verdict, content_hash, derive_seq, fidelity
+    verdict, content_hash, derive_seq, fidelity, tool, retry_of
 ) VALUES (
     $1, $2, $3, $4, $5,
     $6, $7, $8, $9, $10, $11, $12,
     $13, $14, $15, $16, $17, $18, $19, $20,
-    $21, $22, $23, $24
+    $21, $22, $23, $24, $25, $26
 )
 ON CONFLICT (org_id, trace_id, span_id) DO UPDATE SET
     parent_span_id = EXCLUDED.parent_span_id,
@@ -84,6 +96,8 @@ ON CONFLICT (org_id, trace_id, span_id) DO UPDATE SET
     verdict        = EXCLUDED.verdict,
     content_hash   = EXCLUDED.content_hash,
     fidelity       = EXCLUDED.fidelity,
+    tool           = EXCLUDED.tool,
+    retry_of       = EXCLUDED.retry_of,
     -- See UpsertSpanTurn: the cursor advances only on a real content change,
     -- so a consumer polling derive_seq sees changes rather than every row a
     -- re-derive happened to touch.
@@ -193,10 +207,12 @@ WHERE org_id = $1 AND (from_trace_id = $2 OR to_trace_id = $2);
 -- name: ListTraceSummariesBySession :many
 -- Session detail's lazy view: turn headers only, no span payloads.
 SELECT t.org_id, t.trace_id, t.session_id, t.user_prompt, t.response_preview, t.synthetic,
-       t.status, t.source, t.started_at, t.ended_at, t.duration_ns,
+       t.status, t.source, t.parent_trace_id, t.abandoned, t.started_at, t.ended_at, t.duration_ns,
        t.total_input_tokens, t.total_output_tokens,
        t.main_input_tokens, t.main_output_tokens,
        t.cache_read_tokens, t.cache_creation_tokens, t.total_cost_usd,
+       t.peak_context_tokens, t.peak_context_occupancy,
+       t.context_growth_tokens, t.reclaimed_tokens,
        count(s.span_id) AS span_count
 FROM span_turns_20260615 t
 LEFT JOIN spans_20260615 s ON s.org_id = t.org_id AND s.trace_id = t.trace_id
@@ -233,10 +249,13 @@ UPDATE sessions SET
     derived_title = NULL,
     tasks = NULL,
     kind_counts = NULL,
+    context_usage = NULL,
     has_git_activity = false,
     tool_result_count = 0,
     tool_error_count = 0,
     derived_status = 'unknown',
+    outcome = 'unknown',
+    outcome_reasons = NULL,
     derived_model = COALESCE((
         SELECT sp.model FROM spans_20260615 sp
         WHERE sp.session_id = sessions.id
@@ -322,6 +341,76 @@ SELECT
     COALESCE(SUM(tool_calls), 0)::bigint                    AS tool_calls
 FROM matched;
 
+-- name: AggregateModelLatency :many
+-- Latency percentiles per model over the llm spans started in the window,
+-- for /v1/stats. Only spans whose capture adapter recorded a latency
+-- profile count; a call the proxy or extproc did not time contributes
+-- nothing rather than a zero. Each percentile is taken over per-call
+-- figures, so inter_token_p95_ns is the 95th percentile of the calls'
+-- own p95 gaps, not of every gap in the window.
+--
+-- kind = 'llm' with the window on started_at rides
+-- spans_20260615_org_kind_started_idx, and the auth_subject filter reaches
+-- sessions the same way AggregateSpanStats does.
+SELECT
+    sp.model::text                                                              AS model,
+    COUNT(*)::bigint                                                            AS calls,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (
+        ORDER BY (sp.usage->'latency'->>'time_to_first_byte_ns')::float8), 0)::bigint  AS ttfb_p50_ns,
+    COALESCE(percentile_cont(0.95) WITHIN GROUP (
+        ORDER BY (sp.usage->'latency'->>'time_to_first_byte_ns')::float8), 0)::bigint  AS ttfb_p95_ns,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (
+        ORDER BY (sp.usage->'latency'->>'time_to_first_token_ns')::float8), 0)::bigint AS ttft_p50_ns,
+    COALESCE(percentile_cont(0.95) WITHIN GROUP (
+        ORDER BY (sp.usage->'latency'->>'time_to_first_token_ns')::float8), 0)::bigint AS ttft_p95_ns,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (
+        ORDER BY (sp.usage->'latency'->>'inter_token_p50_ns')::float8), 0)::bigint     AS inter_token_p50_ns,
+    COALESCE(percentile_cont(0.95) WITHIN GROUP (
+        ORDER BY (sp.usage->'latency'->>'inter_token_p95_ns')::float8), 0)::bigint     AS inter_token_p95_ns,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (
+        ORDER BY (sp.usage->'latency'->>'output_tokens_per_sec')::float8), 0)::float8  AS output_tokens_per_sec_p50
+FROM spans_20260615 sp
+LEFT JOIN sessions s ON s.id = sp.session_id
+WHERE sp.org_id = sqlc.arg(org_id)
+  AND sp.kind = 'llm'
+  AND sp.usage->'latency' IS NOT NULL
+  AND (sqlc.narg(since_filter)::timestamptz IS NULL OR sp.started_at >= sqlc.narg(since_filter)::timestamptz)
+  AND (sqlc.narg(until_filter)::timestamptz IS NULL OR sp.started_at < sqlc.narg(until_filter)::timestamptz)
+  AND (sqlc.narg(auth_subject_filter)::text IS NULL OR s.auth_subject = sqlc.narg(auth_subject_filter)::text)
+GROUP BY sp.model
+ORDER BY calls DESC, model;
+
+-- name: AggregateToolStats :many
+-- Execution figures per tool identity over the tool spans started in the
+-- window, for /v1/tools. Duration percentiles count only spans whose
+-- tool_result arrived (duration_ns > 0); a call still pending, or one the
+-- session never answered, adds to calls but not to latency. retries counts
+-- calls that repeat an errored call with the same input.
+--
+-- kind = 'tool' with the window on started_at rides
+-- spans_20260615_org_kind_started_idx, and the auth_subject filter reaches
+-- sessions the same way AggregateSpanStats does.
+SELECT
+    COALESCE(sp.tool->>'name', sp.name)::text                                   AS tool_name,
+    COALESCE(sp.tool->>'server', '')::text                                      AS server,
+    COALESCE(sp.tool->>'command', '')::text                                     AS command,
+    COUNT(*)::bigint                                                            AS calls,
+    COUNT(*) FILTER (WHERE sp.status = 'error')::bigint                         AS errors,
+    COUNT(*) FILTER (WHERE sp.retry_of <> '')::bigint                           AS retries,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY sp.duration_ns::float8)
+        FILTER (WHERE sp.duration_ns > 0), 0)::bigint                           AS duration_p50_ns,
+    COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY sp.duration_ns::float8)
+        FILTER (WHERE sp.duration_ns > 0), 0)::bigint                           AS duration_p95_ns
+FROM spans_20260615 sp
+LEFT JOIN sessions s ON s.id = sp.session_id
+WHERE sp.org_id = sqlc.arg(org_id)
+  AND sp.kind = 'tool'
+  AND (sqlc.narg(since_filter)::timestamptz IS NULL OR sp.started_at >= sqlc.narg(since_filter)::timestamptz)
+  AND (sqlc.narg(until_filter)::timestamptz IS NULL OR sp.started_at < sqlc.narg(until_filter)::timestamptz)
+  AND (sqlc.narg(auth_subject_filter)::text IS NULL OR s.auth_subject = sqlc.narg(auth_subject_filter)::text)
+GROUP BY 1, 2, 3
+ORDER BY calls DESC, tool_name, server, command;
+
 -- name: ListChangedSpanTurns :many
 -- Change feed over the turn projection: rows whose content changed after
 -- `after_cursor`, in cursor order.
@@ -0,0 +1,340 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/papercomputeco/tapes/pkg/sessions"
+	"github.com/papercomputeco/tapes/pkg/storage"
+	"github.com/papercomputeco/tapes/pkg/storage/postgres/gensqlc"
+)
+
+var _ storage.RawTurnTransferStore = (*Driver)(nil)
+
+// ListRawTurnsForExport implements storage.RawTurnTransferStore.
+func (d *Driver) ListRawTurnsForExport(ctx context.Context, opts storage.RawTurnExportOpts) ([]storage.RawTurnRecord, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	params := gensqlc.ListRawTurnsForExportParams{
+		AfterID:           opts.AfterID,
+		HarnessSessionIds: opts.HarnessSessionIDs,
+		PageSize:          opts.Limit,
+	}
+	if params.HarnessSessionIds == nil {
+		params.HarnessSessionIds = []string{}
+	}
+	if !opts.ActiveSince.IsZero() {
+		params.ActiveSince = pgtype.Timestamptz{Time: opts.ActiveSince, Valid: true}
+	}
+	rows, err := d.q.ListRawTurnsForExport(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("list raw turns for export: %w", err)
+	}
+	out := make([]storage.RawTurnRecord, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, storage.RawTurnRecord{
+			ID:               row.ID,
+			OrgID:            uuidString(row.OrgID),
+			Source:           row.Source,
+			Provider:         row.Provider,
+			AgentName:        row.AgentName,
+			HarnessID:        row.HarnessID,
+			HarnessSessionID: row.HarnessSessionID,
+			RequestID:        row.RequestID,
+			RawRequest:       row.RawRequest,
+			Response:         row.Response,
+			Meta:             row.Meta,
+			SessionEnvelope:  row.SessionEnvelope,
+			ReceivedAt:       row.ReceivedAt.Time,
+
+			RawResponse:         row.RawResponse,
+			RawResponseEncoding: row.RawResponseEncoding,
+			RawResponseDropped:  row.RawResponseDropped,
+		})
+	}
+	return out, nil
+}
+
+// ListRawTurnCorrections implements storage.RawTurnTransferStore.
+func (d *Driver) ListRawTurnCorrections(ctx context.Context, rawTurnIDs []int64) ([]storage.RawTurnCorrection, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	if len(rawTurnIDs) == 0 {
+		return nil, nil
+	}
+	rows, err := d.q.ListRawTurnCorrectionsByRawTurnIDs(ctx, rawTurnIDs)
+	if err != nil {
+		return nil, fmt.Errorf("list raw turn corrections: %w", err)
+	}
+	out := make([]storage.RawTurnCorrection, 0, len(rows))
+	for _, row := range rows {
+		c := storage.RawTurnCorrection{
+			ID:               row.ID,
+			OrgID:            uuidString(row.OrgID),
+			RawTurnID:        row.RawTurnID,
+			HarnessID:        row.HarnessID,
+			HarnessSessionID: row.HarnessSessionID,
+			ThreadID:         row.ThreadID,
+			Reason:           row.Reason,
+			CreatedAt:        row.CreatedAt.Time,
+		}
+		if row.ParentHarnessSessionID.Valid {
+			c.ParentHarnessSessionID = cloneStringPointer(&row.ParentHarnessSessionID.String)
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// ImportRawTurn implements storage.RawTurnTransferStore. The raw row, its
+// session identity, and the derive-queue mark land in one transaction,
+// exactly as PutRawTurn and the ingest session upsert would have left
+// them at capture — except that received_at, and with it the session's
+// started/last-seen range, is the exporting deployment's.
+func (d *Driver) ImportRawTurn(ctx context.Context, rec storage.RawTurnRecord) (int64, bool, error) {
+	if d == nil || d.conn == nil {
+		return 0, false, errors.New("postgres driver not open")
+	}
+	orgID, err := orgIDFromString(rec.OrgID)
+	if err != nil {
+		return 0, false, fmt.Errorf("decode org_id: %w", err)
+	}
+	source := rec.Source
+	if source == "" {
+		source = storage.RawTurnSourceWire
+	}
+	receivedAt := pgtype.Timestamptz{Time: rec.ReceivedAt, Valid: true}
+	rawRequest := sanitizeJSONB(rec.RawRequest)
+
+	tx, err := d.conn.Begin(ctx)
+	if err != nil {
+		return 0, false, fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // commit shadows on success
+	qtx := d.q.WithTx(tx)
+
+	findExisting := func() (int64, error) {
+		if rec.RequestID != "" {
+			return qtx.FindRawTurnIDByRequestID(ctx, gensqlc.FindRawTurnIDByRequestIDParams{
+				OrgID: orgID, RequestID: rec.RequestID,
+			})
+		}
+		return qtx.FindRawTurnIDByContent(ctx, gensqlc.FindRawTurnIDByContentParams{
+			OrgID:            orgID,
+			Source:           source,
+			HarnessID:        rec.HarnessID,
+			HarnessSessionID: rec.HarnessSessionID,
+			ReceivedAt:       receivedAt,
+			RawRequest:       rawRequest,
+		})
+	}
+
+	existing, err := findExisting()
+	if err == nil {
+		return existing, false, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, fmt.Errorf("look up imported raw turn: %w", err)
+	}
+
+	id, err := qtx.InsertImportedRawTurn(ctx, gensqlc.InsertImportedRawTurnParams{
+		OrgID:            orgID,
+		Source:           source,
+		Provider:         rec.Provider,
+		AgentName:        rec.AgentName,
+		HarnessID:        rec.HarnessID,
+		HarnessSessionID: rec.HarnessSessionID,
+		RequestID:        rec.RequestID,
+		RawRequest:       rawRequest,
+		Response:         sanitizeJSONB(rec.Response),
+		Meta:             sanitizeJSONB(metaOrEmptyObject(rec.Meta)),
+		SessionEnvelope:  sanitizeJSONB(rec.SessionEnvelope),
+
+		// Not scrubbed, for the reason PutRawTurn gives.
+		RawResponse:         rec.RawResponse,
+		RawResponseEncoding: rec.RawResponseEncoding,
+		RawResponseDropped:  rec.RawResponseDropped,
+		ReceivedAt:          receivedAt,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		// A capture of the same request id committed between the lookup
+		// and the insert; that row is the turn.
+		if existing, err = findExisting(); err != nil {
+			return 0, false, fmt.Errorf("re-read raw turn after insert conflict: %w", err)
+		}
+		return existing, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("insert imported raw turn: %w", asContentError(err))
+	}
+
+	if rec.HarnessSessionID != "" {
+		var envelope sessions.IngestEnvelope
+		if len(rec.SessionEnvelope) > 0 {
+			if err := json.Unmarshal(rec.SessionEnvelope, &envelope); err != nil {
+				return 0, false, fmt.Errorf("decode session envelope of raw turn %d: %w", rec.ID, err)
+			}
+		}
+		envelope.HarnessID = rec.HarnessID
+		envelope.HarnessSessionID = rec.HarnessSessionID
+		if _, _, err := upsertImportedSession(ctx, qtx, orgID, &envelope, receivedAt); err != nil {
+			return 0, false, err
+		}
+		if err := qtx.MarkDeriveDirty(ctx, gensqlc.MarkDeriveDirtyParams{
+			OrgID:            orgID,
+			HarnessID:        rec.HarnessID,
+			HarnessSessionID: rec.HarnessSessionID,
+		}); err != nil {
+			return 0, false, fmt.Errorf("mark derive dirty: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, false, fmt.Errorf("commit imported raw turn: %w", err)
+	}
+	return id, true, nil
+}
+
+// ImportRawTurnCorrection implements storage.RawTurnTransferStore. It is
+// recordAttributionRepair without the synchronous rebuild: the corrected
+// session is materialized, the correction appended with its original
+// timestamp, and both the previous and the corrected session marked
+// derive-dirty. The importer re-derives what it touched.
+func (d *Driver) ImportRawTurnCorrection(ctx context.Context, c storage.RawTurnCorrection) (bool, error) {
+	if d == nil || d.conn == nil {
+		return false, errors.New("postgres driver not open")
+	}
+	orgID, err := orgIDFromString(c.OrgID)
+	if err != nil {
+		return false, fmt.Errorf("decode org_id: %w", err)
+	}
+	createdAt := pgtype.Timestamptz{Time: c.CreatedAt, Valid: true}
+	parent := textFromPointer(c.ParentHarnessSessionID)
+
+	tx, err := d.conn.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // commit shadows on success
+	qtx := d.q.WithTx(tx)
+
+	current, err := qtx.GetRawTurnAttributionForUpdate(ctx, gensqlc.GetRawTurnAttributionForUpdateParams{
+		OrgID: orgID, RawTurnID: c.RawTurnID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, storage.ErrRawTurnNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("lock raw turn for imported correction: %w", err)
+	}
+
+	exists, err := qtx.RawTurnCorrectionExists(ctx, gensqlc.RawTurnCorrectionExistsParams{
+		OrgID:                  orgID,
+		RawTurnID:              c.RawTurnID,
+		HarnessID:              c.HarnessID,
+		HarnessSessionID:       c.HarnessSessionID,
+		ThreadID:               c.ThreadID,
+		ParentHarnessSessionID: parent,
+		Reason:                 c.Reason,
+		CreatedAt:              createdAt,
+	})
+	if err != nil {
+		return false, fmt.Errorf("look up imported correction: %w", err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	var envelope sessions.IngestEnvelope
+	if len(current.SessionEnvelope) > 0 {
+		if err := json.Unmarshal(current.SessionEnvelope, &envelope); err != nil {
+			return false, fmt.Errorf("decode raw session envelope: %w", err)
+		}
+	}
+	envelope.HarnessID = c.HarnessID
+	envelope.HarnessSessionID = c.HarnessSessionID
+	envelope.ParentHarnessSessionID = cloneStringPointer(c.ParentHarnessSessionID)
+	sessionID, parentID, err := upsertImportedSession(ctx, qtx, orgID, &envelope, current.ReceivedAt)
+	if err != nil {
+		return false, err
+	}
+	// The upsert only ever fills a missing parent; a correction that
+	// moves or clears one must overwrite it, as the repair does.
+	if err := qtx.SetSessionParent(ctx, gensqlc.SetSessionParentParams{ParentSessionID: parentID, ID: sessionID}); err != nil {
+		return false, fmt.Errorf("set corrected session parent: %w", err)
+	}
+
+	if err := qtx.InsertImportedRawTurnCorrection(ctx, gensqlc.InsertImportedRawTurnCorrectionParams{
+		OrgID:                  orgID,
+		RawTurnID:              c.RawTurnID,
+		HarnessID:              c.HarnessID,
+		HarnessSessionID:       c.HarnessSessionID,
+		ThreadID:               c.ThreadID,
+		ParentHarnessSessionID: parent,
+		Reason:                 c.Reason,
+		CreatedAt:              createdAt,
+	}); err != nil {
+		return false, fmt.Errorf("append imported correction: %w", err)
+	}
+	for _, key := range []repairSessionKey{
+		{current.HarnessID, current.HarnessSessionID},
+		{c.HarnessID, c.HarnessSessionID},
+	} {
+		if err := qtx.MarkDeriveDirty(ctx, gensqlc.MarkDeriveDirtyParams{
+			OrgID: orgID, HarnessID: key.harnessID, HarnessSessionID: key.harnessSessionID,
+		}); err != nil {
+			return false, fmt.Errorf("mark corrected session dirty: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit imported correction: %w", err)
+	}
+	return true, nil
+}
+
+// upsertImportedSession materializes the session row an imported turn
+// belongs to. It uses the attribution-repair upsert because that one
+// widens started_at/last_seen_at with LEAST/GREATEST: imported turns
+// arrive carrying old capture times, in bundle order, possibly into a
+// session the destination already saw live, and must never pull its
+// liveness range backwards. It returns the session's id and the parent
+// id the envelope resolved to.
+func upsertImportedSession(
+	ctx context.Context,
+	qtx *gensqlc.Queries,
+	orgID pgtype.UUID,
+	envelope *sessions.IngestEnvelope,
+	capturedAt pgtype.Timestamptz,
+) (sessionID, parentID pgtype.UUID, err error) {
+	parentID, err = resolveParentSessionID(ctx, qtx, envelope, orgID, capturedAt)
+	if err != nil {
+		return pgtype.UUID{}, pgtype.UUID{}, fmt.Errorf("resolve imported parent session: %w", err)
+	}
+	sessionID, err = newAppUUID()
+	if err != nil {
+		return pgtype.UUID{}, pgtype.UUID{}, fmt.Errorf("mint imported session uuid: %w", err)
+	}
+	metadata := []byte(envelope.HarnessMetadata)
+	if len(metadata) == 0 {
+		metadata = []byte("{}")
+	}
+	row, err := qtx.UpsertSessionForAttributionRepair(ctx, gensqlc.UpsertSessionForAttributionRepairParams{
+		ID: sessionID, OrgID: orgID, AuthSubject: envelope.AuthSubject,
+		HarnessID: envelope.HarnessIDOrUnknown(), HarnessSessionID: envelope.HarnessSessionID,
+		Name: nullStringValue(envelope.Name), Cwd: nullStringValue(envelope.Cwd),
+		HarnessVersion: nullStringValue(envelope.HarnessVersion), ParentSessionID: parentID,
+		CapturedAt: capturedAt, HarnessMetadata: metadata,
+	})
+	if err != nil {
+		return pgtype.UUID{}, pgtype.UUID{}, fmt.Errorf("upsert imported session: %w", err)
+	}
+	return row.ID, parentID, nil
+}
@@ -0,0 +1,226 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/papercomputeco/tapes/pkg/storage"
+	"github.com/papercomputeco/tapes/pkg/storage/postgres/gensqlc"
+)
+
+// Compile-time guarantee that the Postgres driver hosts the retention
+// capability the retention worker type-asserts for.
+var _ storage.RetentionStore = (*Driver)(nil)
+
+// ListRetentionCandidates implements storage.RetentionStore.
+func (d *Driver) ListRetentionCandidates(ctx context.Context, opts storage.RetentionCandidateOpts) ([]storage.RetentionCandidate, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	rows, err := d.q.ListRetentionCandidates(ctx, gensqlc.ListRetentionCandidatesParams{
+		Cutoff:       pgtype.Timestamptz{Time: opts.LastSeenBefore, Valid: true},
+		HarnessID:    opts.HarnessID,
+		AuthSubject:  opts.AuthSubject,
+		SkipStripped: opts.SkipStripped,
+		PageSize:     opts.Limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list retention candidates: %w", err)
+	}
+	out := make([]storage.RetentionCandidate, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, storage.RetentionCandidate{
+			ID:               uuidString(row.ID),
+			OrgID:            uuidString(row.OrgID),
+			HarnessID:        row.HarnessID,
+			HarnessSessionID: row.HarnessSessionID,
+			AuthSubject:      row.AuthSubject,
+			LastSeenAt:       row.LastSeenAt.Time,
+			RetentionTier:    row.RetentionTier,
+		})
+	}
+	return out, nil
+}
+
+// StripSessionPayloads implements storage.RetentionStore. The raw rows,
+// the span projection, and the retention tier change in one transaction,
+// so a session is never observed stripped in one layer and intact in
+// another.
+func (d *Driver) StripSessionPayloads(ctx context.Context, orgID, id string) (storage.RetentionResult, error) {
+	var res storage.RetentionResult
+	err := d.withRetentionSubtree(ctx, orgID, id, func(qtx *gensqlc.Queries, org pgtype.UUID, subtree []gensqlc.ListSessionSubtreeRow, rawIDs []int64) error {
+		now := pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true}
+		sessionIDs := subtreeIDs(subtree)
+
+		n, err := qtx.StripRawTurnPayloads(ctx, gensqlc.StripRawTurnPayloadsParams{
+			StrippedAt: now,
+			OrgID:      org,
+			Ids:        rawIDs,
+		})
+		if err != nil {
+			return fmt.Errorf("strip raw turns: %w", err)
+		}
+		res.RawTurns = n
+
+		if res.Spans, err = qtx.StripSessionSpanPayloads(ctx, gensqlc.StripSessionSpanPayloadsParams{
+			OrgID:      org,
+			SessionIds: sessionIDs,
+		}); err != nil {
+			return fmt.Errorf("strip spans: %w", err)
+		}
+		if res.Traces, err = qtx.StripSessionSpanTurnPayloads(ctx, gensqlc.StripSessionSpanTurnPayloadsParams{
+			OrgID:      org,
+			SessionIds: sessionIDs,
+		}); err != nil {
+			return fmt.Errorf("strip traces: %w", err)
+		}
+		if res.Sessions, err = qtx.MarkSessionsStripped(ctx, gensqlc.MarkSessionsStrippedParams{
+			AppliedAt: now,
+			OrgID:     org,
+			Ids:       sessionIDs,
+		}); err != nil {
+			return fmt.Errorf("mark sessions stripped: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return storage.RetentionResult{}, fmt.Errorf("strip session %s: %w", id, err)
+	}
+	return res, nil
+}
+
+// PurgeSession implements storage.RetentionStore. Raw turns go first
+// (with the corrections that attributed them), then the queue entries,
+// then the session rows, whose ON DELETE CASCADE removes the projection.
+func (d *Driver) PurgeSession(ctx context.Context, orgID, id string) (storage.RetentionResult, error) {
+	var res storage.RetentionResult
+	err := d.withRetentionSubtree(ctx, orgID, id, func(qtx *gensqlc.Queries, org pgtype.UUID, subtree []gensqlc.ListSessionSubtreeRow, rawIDs []int64) error {
+		if _, err := qtx.DeleteRawTurnCorrectionsByIDs(ctx, gensqlc.DeleteRawTurnCorrectionsByIDsParams{
+			OrgID: org,
+			Ids:   rawIDs,
+		}); err != nil {
+			return fmt.Errorf("delete raw turn corrections: %w", err)
+		}
+		n, err := qtx.DeleteRawTurnsByIDs(ctx, gensqlc.DeleteRawTurnsByIDsParams{OrgID: org, Ids: rawIDs})
+		if err != nil {
+			return fmt.Errorf("delete raw turns: %w", err)
+		}
+		res.RawTurns = n
+
+		for _, s := range subtree {
+			if _, err := qtx.DeleteDeriveQueueEntry(ctx, gensqlc.DeleteDeriveQueueEntryParams{
+				OrgID:            org,
+				HarnessID:        s.HarnessID,
+				HarnessSessionID: s.HarnessSessionID,
+			}); err != nil {
+				return fmt.Errorf("delete derive queue entry: %w", err)
+			}
+		}
+
+		if res.Sessions, err = qtx.DeleteSessionsByIDs(ctx, gensqlc.DeleteSessionsByIDsParams{
+			OrgID: org,
+			Ids:   subtreeIDs(subtree),
+		}); err != nil {
+			return fmt.Errorf("delete sessions: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return storage.RetentionResult{}, fmt.Errorf("purge session %s: %w", id, err)
+	}
+	return res, nil
+}
+
+// withRetentionSubtree resolves a session's subtree and its effective raw
+// turns inside one transaction and hands them to apply.
+//
+// Every member's derive lock is taken as a transaction-scoped advisory lock
+// first, in key order: a derive pass holding a session's lock finishes
+// before retention touches it, and one starting after commit sees the
+// result (a stripped tier it skips, or no rows at all). Transaction-scoped
+// locks share the keyspace with the worker's session-scoped ones and need
+// no extra pooled connection per member.
+//
+// A missing or malformed session id is a no-op, not an error: the
+// candidate may have been deleted between the worker's list and this call.
+func (d *Driver) withRetentionSubtree(
+	ctx context.Context,
+	orgID, id string,
+	apply func(qtx *gensqlc.Queries, org pgtype.UUID, subtree []gensqlc.ListSessionSubtreeRow, rawIDs []int64) error,
+) error {
+	if d == nil || d.conn == nil {
+		return errors.New("postgres driver not open")
+	}
+	org, err := orgIDFromString(orgKeyForLookup(orgID))
+	if err != nil {
+		return fmt.Errorf("decode org_id: %w", err)
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return nil //nolint:nilerr // invalid id == nothing to act on
+	}
+
+	tx, err := d.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // commit shadows on success
+	qtx := d.q.WithTx(tx)
+
+	subtree, err := qtx.ListSessionSubtree(ctx, gensqlc.ListSessionSubtreeParams{
+		OrgID: org,
+		ID:    pgtype.UUID{Bytes: parsed, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("list session subtree: %w", err)
+	}
+	if len(subtree) == 0 {
+		return nil
+	}
+
+	lockKeys := make([]int64, 0, len(subtree))
+	for _, s := range subtree {
+		lockKeys = append(lockKeys, deriveLockKey(uuidString(org), s.HarnessID, s.HarnessSessionID))
+	}
+	slices.Sort(lockKeys)
+	for _, key := range slices.Compact(lockKeys) {
+		if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", key); err != nil {
+			return fmt.Errorf("pg_advisory_xact_lock: %w", err)
+		}
+	}
+
+	var rawIDs []int64
+	for _, s := range subtree {
+		ids, err := qtx.ListRawTurnIDsByEffectiveSession(ctx, gensqlc.ListRawTurnIDsByEffectiveSessionParams{
+			OrgID:            org,
+			HarnessID:        s.HarnessID,
+			HarnessSessionID: s.HarnessSessionID,
+		})
+		if err != nil {
+			return fmt.Errorf("list raw turns for %s/%s: %w", s.HarnessID, s.HarnessSessionID, err)
+		}
+		rawIDs = append(rawIDs, ids...)
+	}
+	if rawIDs == nil {
+		rawIDs = []int64{}
+	}
+
+	if err := apply(qtx, org, subtree, rawIDs); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func subtreeIDs(subtree []gensqlc.ListSessionSubtreeRow) []pgtype.UUID {
+	ids := make([]pgtype.UUID, 0, len(subtree))
+	for _, s := range subtree {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
@@ -0,0 +1,27 @@
+-- Code generation only: never applied to a database.
+--
+-- migrations/1781635000_span_embeddings.up.sql creates these tables inside
+-- a plpgsql block, guarded on the pgvector extension, and sqlc does not
+-- read plpgsql. This file restates them so the span embedding queries
+-- type-check. Keep it in step with that migration.
+CREATE TABLE span_embeddings_20260615 (
+    org_id       UUID NOT NULL,
+    trace_id     TEXT NOT NULL,
+    span_id      TEXT NOT NULL,
+    model        TEXT NOT NULL,
+    content_hash TEXT NOT NULL,
+    embedding    vector NOT NULL,
+    embedded_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (org_id, trace_id, span_id, model),
+    FOREIGN KEY (org_id, trace_id, span_id)
+        REFERENCES spans_20260615(org_id, trace_id, span_id) ON DELETE CASCADE
+);
+
+CREATE TABLE span_embed_cursors (
+    model      TEXT PRIMARY KEY,
+    derive_seq BIGINT NOT NULL,
+    org_id     UUID NOT NULL,
+    trace_id   TEXT NOT NULL,
+    span_id    TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 
+	"github.com/papercomputeco/tapes/pkg/derive"
 	"github.com/papercomputeco/tapes/pkg/storage"
 	"github.com/papercomputeco/tapes/pkg/storage/postgres/gensqlc"
 )
@@ -56,7 +58,7 @@ func (d *Driver) ListSessionRecords(
 		cmp = ">"
 	}
 
-	// Explicit column list = the 24 columns the previous generated query
+	// Explicit column list = the columns the previous generated query
 	// selected, plus the sort column rendered to canonical text so the cursor
 	// round-trips exactly. col.Col()/col.Cast() and order/cmp come from the
 	// allowlist only.
@@ -71,7 +73,8 @@ func (d *Driver) ListSessionRecords(
 		`harness_version, parent_session_id, started_at, last_seen_at, ended_at, harness_metadata, ` +
 		`total_input_tokens, total_output_tokens, total_cost_usd, turn_count, derived_status, ` +
 		`has_git_activity, tool_result_count, tool_error_count, derived_title, derived_model, model_usage, ` +
-		`tasks, kind_counts, display_name`
+		`tasks, kind_counts, display_name, retention_tier, retention_applied_at, context_usage, ` +
+		`outcome, outcome_reasons`
 
 	// Values bind as pgx named args (@name). The dynamic ORDER BY forces a
 	// hand-built query, but every caller value is still a named, bound parameter
@@ -102,6 +105,66 @@ func (d *Driver) ListSessionRecords(
 		named["auth_subject"] = opts.AuthSubject
 		where = append(where, "auth_subject = @auth_subject::text")
 	}
+	// A stored awaiting_user reads as abandoned once its session has been
+	// idle for derive.AbandonAfter (derive.OutcomeAt), so those two filters
+	// split the awaiting_user rows on last_seen_at the same way.
+	switch opts.Outcome {
+	case "":
+	case derive.OutcomeAbandoned, derive.OutcomeAwaitingUser:
+		named["outcome_abandoned"] = derive.OutcomeAbandoned
+		named["outcome_awaiting"] = derive.OutcomeAwaitingUser
+		named["idle_before"] = time.Now().Add(-derive.AbandonAfter)
+		if opts.Outcome == derive.OutcomeAbandoned {
+			where = append(where, "(outcome = @outcome_abandoned::text OR "+
+				"(outcome = @outcome_awaiting::text AND last_seen_at <= @idle_before::timestamptz))")
+		} else {
+			where = append(where,
+				"outcome = @outcome_awaiting::text AND last_seen_at > @idle_before::timestamptz")
+		}
+	default:
+		named["outcome"] = opts.Outcome
+		where = append(where, "outcome = @outcome::text")
+	}
+	// A reason filter matches the code or a terminal error's category by
+	// containment, which sessions_outcome_reasons_idx serves.
+	if opts.OutcomeReason != "" {
+		code, err := json.Marshal([]map[string]string{{"code": opts.OutcomeReason}})
+		if err != nil {
+			return nil, fmt.Errorf("list session records: %w", err)
+		}
+		category, err := json.Marshal([]map[string]string{{"category": opts.OutcomeReason}})
+		if err != nil {
+			return nil, fmt.Errorf("list session records: %w", err)
+		}
+		named["outcome_reason_code"] = string(code)
+		named["outcome_reason_category"] = string(category)
+		where = append(where, "(outcome_reasons @> @outcome_reason_code::jsonb OR "+
+			"outcome_reasons @> @outcome_reason_category::jsonb)")
+	}
+	// One annotation row must satisfy every set condition: a name with a
+	// score range means that name scored in range, not any name plus any
+	// score. annotations_org_name_idx serves the name and label probes.
+	if m := opts.Annotation; !m.IsZero() {
+		conds := []string{"a.session_id = sessions.id", "a.org_id = @org_id"}
+		if m.Name != "" {
+			named["annotation_name"] = m.Name
+			conds = append(conds, "a.name = @annotation_name::text")
+		}
+		if m.Label != "" {
+			named["annotation_label"] = m.Label
+			conds = append(conds, "a.label = @annotation_label::text")
+		}
+		if m.MinScore != nil {
+			named["annotation_min_score"] = *m.MinScore
+			conds = append(conds, "a.score >= @annotation_min_score::float8")
+		}
+		if m.MaxScore != nil {
+			named["annotation_max_score"] = *m.MaxScore
+			conds = append(conds, "a.score <= @annotation_max_score::float8")
+		}
+		where = append(where,
+			"EXISTS (SELECT 1 FROM annotations a WHERE "+strings.Join(conds, " AND ")+")")
+	}
 	if opts.CursorVal != nil && opts.CursorID != nil {
 		named["cursor_val"] = *opts.CursorVal
 		named["cursor_id"] = *opts.CursorID
@@ -142,7 +205,8 @@ func (d *Driver) ListSessionRecords(
 			&g.HarnessVersion, &g.ParentSessionID, &g.StartedAt, &g.LastSeenAt, &g.EndedAt, &g.HarnessMetadata,
 			&g.TotalInputTokens, &g.TotalOutputTokens, &g.TotalCostUsd, &g.TurnCount, &g.DerivedStatus,
 			&g.HasGitActivity, &g.ToolResultCount, &g.ToolErrorCount, &g.DerivedTitle, &g.DerivedModel, &g.ModelUsage,
-			&g.Tasks, &g.KindCounts, &g.DisplayName,
+			&g.Tasks, &g.KindCounts, &g.DisplayName, &g.RetentionTier, &g.RetentionAppliedAt, &g.ContextUsage,
+			&g.Outcome, &g.OutcomeReasons,
 			&sortVal,
 		); err != nil {
 			return nil, fmt.Errorf("list session records: scan: %w", err)
@@ -400,6 +464,7 @@ func sessionRecordFromRow(row gensqlc.Session) storage.SessionRecord {
 		DerivedStatus:     row.DerivedStatus,
 		Model:             row.DerivedModel,
 		AuthSubject:       row.AuthSubject,
+		RetentionTier:     row.RetentionTier,
 	}
 	// DisplayName (the user's Console rename) is exposed raw. It is the
 	// top of the display-title resolution the API performs; empty means
@@ -456,10 +521,14 @@ func sessionRecordFromRow(row gensqlc.Session) storage.SessionRecord {
 			s.ModelUsage = mu
 		}
 	}
-	// Tasks and KindCounts are deriver-written JSONB rollups served
-	// verbatim on the composite traces response; carry the raw bytes.
+	// Tasks, KindCounts, ContextUsage and OutcomeReasons are deriver-written
+	// JSONB rollups
+	// served verbatim on the composite traces response; carry the raw bytes.
 	s.Tasks = json.RawMessage(row.Tasks)
 	s.KindCounts = json.RawMessage(row.KindCounts)
+	s.ContextUsage = json.RawMessage(row.ContextUsage)
+	s.Outcome = row.Outcome
+	s.OutcomeReasons = json.RawMessage(row.OutcomeReasons)
 	if row.TotalCostUsd.Valid {
 		if f, err := row.TotalCostUsd.Float64Value(); err == nil && f.Valid {
 			s.TotalCostUsd = f.Float64
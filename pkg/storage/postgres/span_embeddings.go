@@ -0,0 +1,236 @@
+package postgres
+
+// Span embeddings for semantic search.
+//
+// The tables come from 1781635000, which creates them only on a database
+// with pgvector installed, so the schema keeps migrating on a Postgres
+// without it. Vectors are derivable data keyed by the deterministic span
+// id, never capture; see that migration for how they follow re-derives.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/papercomputeco/tapes/pkg/storage"
+	"github.com/papercomputeco/tapes/pkg/storage/postgres/gensqlc"
+)
+
+// Compile-time guarantee that the Postgres driver hosts the capabilities
+// the embed worker and the search route type-assert for.
+var (
+	_ storage.SpanEmbeddingStore = (*Driver)(nil)
+	_ storage.SpanSearcher       = (*Driver)(nil)
+)
+
+// CheckSpanEmbeddings reports storage.ErrSpanEmbeddingsUnavailable when
+// the migration found no pgvector and left the tables out. Implements
+// storage.SpanEmbeddingStore.
+func (d *Driver) CheckSpanEmbeddings(ctx context.Context) error {
+	if d == nil || d.conn == nil {
+		return errors.New("postgres driver not open")
+	}
+	present, err := d.q.SpanEmbeddingsExist(ctx)
+	if err != nil {
+		return fmt.Errorf("check span embedding tables: %w", err)
+	}
+	if !present {
+		return storage.ErrSpanEmbeddingsUnavailable
+	}
+	return nil
+}
+
+// SpanEmbedCursor returns the model's feed checkpoint. Implements
+// storage.SpanEmbeddingStore.
+func (d *Driver) SpanEmbedCursor(ctx context.Context, model string) (storage.SpanEmbedCursor, error) {
+	if d == nil || d.conn == nil {
+		return storage.SpanEmbedCursor{}, errors.New("postgres driver not open")
+	}
+	row, err := d.q.GetSpanEmbedCursor(ctx, model)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return storage.StartOfSpanFeed, nil
+	}
+	if err != nil {
+		return storage.SpanEmbedCursor{}, fmt.Errorf("read span embed cursor: %w", err)
+	}
+	return storage.SpanEmbedCursor{
+		Seq:     row.DeriveSeq,
+		OrgID:   uuidString(row.OrgID),
+		TraceID: row.TraceID,
+		SpanID:  row.SpanID,
+	}, nil
+}
+
+// ListSpansToEmbed reads the span change feed across every org.
+// Implements storage.SpanEmbeddingStore.
+func (d *Driver) ListSpansToEmbed(ctx context.Context, model string, after storage.SpanEmbedCursor, limit int32) ([]storage.SpanEmbedCandidate, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	org, err := orgIDFromString(after.OrgID)
+	if err != nil {
+		return nil, fmt.Errorf("decode cursor org_id: %w", err)
+	}
+	rows, err := d.q.ListSpansToEmbed(ctx, gensqlc.ListSpansToEmbedParams{
+		Model:        model,
+		AfterSeq:     after.Seq,
+		AfterOrgID:   org,
+		AfterTraceID: after.TraceID,
+		AfterSpanID:  after.SpanID,
+		PageSize:     limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list spans to embed: %w", err)
+	}
+
+	out := make([]storage.SpanEmbedCandidate, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, storage.SpanEmbedCandidate{
+			Cursor: storage.SpanEmbedCursor{
+				Seq:     r.DeriveSeq,
+				OrgID:   uuidString(r.OrgID),
+				TraceID: r.TraceID,
+				SpanID:  r.SpanID,
+			},
+			ContentHash: r.ContentHash,
+			Current:     r.Current,
+			Input:       r.Input,
+			Output:      r.Output,
+		})
+	}
+	return out, nil
+}
+
+// PutSpanEmbeddings upserts a page's vectors and advances the model's
+// cursor past the page together, so a crash between the two can only
+// re-embed a page, never skip one. Implements storage.SpanEmbeddingStore.
+func (d *Driver) PutSpanEmbeddings(ctx context.Context, model string, rows []storage.SpanEmbedding, next storage.SpanEmbedCursor) error {
+	if d == nil || d.conn == nil {
+		return errors.New("postgres driver not open")
+	}
+	nextOrg, err := orgIDFromString(next.OrgID)
+	if err != nil {
+		return fmt.Errorf("decode cursor org_id: %w", err)
+	}
+
+	tx, err := d.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // commit shadows on success
+	qtx := d.q.WithTx(tx)
+
+	for _, r := range rows {
+		org, err := orgIDFromString(r.OrgID)
+		if err != nil {
+			return fmt.Errorf("decode org_id: %w", err)
+		}
+		if err := qtx.UpsertSpanEmbedding(ctx, gensqlc.UpsertSpanEmbeddingParams{
+			OrgID:       org,
+			TraceID:     r.TraceID,
+			SpanID:      r.SpanID,
+			Model:       model,
+			ContentHash: r.ContentHash,
+			Embedding:   vectorLiteral(r.Vector),
+		}); err != nil {
+			return fmt.Errorf("upsert span embedding %s/%s: %w", r.TraceID, r.SpanID, err)
+		}
+	}
+
+	if err := qtx.UpsertSpanEmbedCursor(ctx, gensqlc.UpsertSpanEmbedCursorParams{
+		Model:     model,
+		DeriveSeq: next.Seq,
+		OrgID:     nextOrg,
+		TraceID:   next.TraceID,
+		SpanID:    next.SpanID,
+	}); err != nil {
+		return fmt.Errorf("advance span embed cursor: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// PruneSpanEmbeddings drops every other model's vectors and cursor.
+// Implements storage.SpanEmbeddingStore.
+func (d *Driver) PruneSpanEmbeddings(ctx context.Context, keep string) (int64, error) {
+	if d == nil || d.conn == nil {
+		return 0, errors.New("postgres driver not open")
+	}
+	tx, err := d.conn.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // commit shadows on success
+	qtx := d.q.WithTx(tx)
+
+	removed, err := qtx.DeleteOtherSpanEmbeddings(ctx, keep)
+	if err != nil {
+		return 0, fmt.Errorf("prune span embeddings: %w", err)
+	}
+	if err := qtx.DeleteOtherSpanEmbedCursors(ctx, keep); err != nil {
+		return 0, fmt.Errorf("prune span embed cursors: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	return removed, nil
+}
+
+// SearchSpans ranks the org's spans embedded under model by cosine
+// distance to vector, with an exact scan. Implements
+// storage.SpanSearcher.
+func (d *Driver) SearchSpans(ctx context.Context, orgID, model string, vector []float32, authSubject string, limit int) ([]storage.SpanSearchHit, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	org, err := orgIDFromString(orgKeyForLookup(orgID))
+	if err != nil {
+		return nil, fmt.Errorf("decode org_id: %w", err)
+	}
+	rows, err := d.q.SearchSpanEmbeddings(ctx, gensqlc.SearchSpanEmbeddingsParams{
+		Query:       vectorLiteral(vector),
+		OrgID:       org,
+		Model:       model,
+		AuthSubject: authSubject,
+		ResultLimit: int32(limit), //nolint:gosec // limit bounded by the API handler
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search spans: %w", err)
+	}
+
+	out := make([]storage.SpanSearchHit, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, storage.SpanSearchHit{
+			SessionID:  r.SessionID,
+			TraceID:    r.TraceID,
+			SpanID:     r.SpanID,
+			Model:      r.Model,
+			Score:      r.Score,
+			StartedAt:  r.StartedAt.Time,
+			UserPrompt: r.UserPrompt,
+			Input:      r.Input,
+			Output:     r.Output,
+		})
+	}
+	return out, nil
+}
+
+// vectorLiteral renders a vector in pgvector's text form, '[1,2.5,-3]',
+// which the queries cast with ::vector. Going through text keeps the
+// driver free of a pgvector codec dependency.
+func vectorLiteral(v []float32) string {
+	var b strings.Builder
+	b.Grow(len(v)*10 + 2)
+	b.WriteByte('[')
+	for i, f := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(f), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
@@ -85,32 +85,44 @@ func writeSpanSet(
 		}
 
 		turnParams := gensqlc.UpsertSpanTurnParams{
-			OrgID:               orgID,
-			TraceID:             turn.TraceID,
-			SessionID:           sid,
-			UserPrompt:          turn.UserPrompt,
-			ResponsePreview:     turn.ResponsePreview,
-			Synthetic:           turn.Synthetic,
-			Status:              "ok",
-			StartedAt:           pgtype.Timestamptz{Time: turn.StartedAt, Valid: true},
-			EndedAt:             pgtype.Timestamptz{Time: turn.EndedAt, Valid: !turn.EndedAt.IsZero()},
-			DurationNs:          turn.EndedAt.Sub(turn.StartedAt).Nanoseconds(),
-			TotalInputTokens:    turn.TotalInputTokens,
-			TotalOutputTokens:   turn.TotalOutputTokens,
-			MainInputTokens:     turn.MainInputTokens,
-			MainOutputTokens:    turn.MainOutputTokens,
-			CacheReadTokens:     turn.CacheReadTokens,
-			CacheCreationTokens: turn.CacheCreationTokens,
-			TotalCostUsd:        costNumeric,
-			Source:              turn.Source,
-			ToolCalls:           int64(turn.ToolCalls),
-			DeriveSeq:           deriveSeq,
-			Fidelity:            rollupFidelity(spanTiers),
+			OrgID:                orgID,
+			TraceID:              turn.TraceID,
+			SessionID:            sid,
+			UserPrompt:           turn.UserPrompt,
+			ResponsePreview:      turn.ResponsePreview,
+			Synthetic:            turn.Synthetic,
+			Status:               "ok",
+			StartedAt:            pgtype.Timestamptz{Time: turn.StartedAt, Valid: true},
+			EndedAt:              pgtype.Timestamptz{Time: turn.EndedAt, Valid: !turn.EndedAt.IsZero()},
+			DurationNs:           turn.EndedAt.Sub(turn.StartedAt).Nanoseconds(),
+			TotalInputTokens:     turn.TotalInputTokens,
+			TotalOutputTokens:    turn.TotalOutputTokens,
+			MainInputTokens:      turn.MainInputTokens,
+			MainOutputTokens:     turn.MainOutputTokens,
+			CacheReadTokens:      turn.CacheReadTokens,
+			CacheCreationTokens:  turn.CacheCreationTokens,
+			TotalCostUsd:         costNumeric,
+			Source:               turn.Source,
+			ToolCalls:            int64(turn.ToolCalls),
+			DeriveSeq:            deriveSeq,
+			Fidelity:             rollupFidelity(spanTiers),
+			ParentTraceID:        turn.ParentTraceID,
+			Abandoned:            turn.Abandoned,
+			PeakContextTokens:    turn.PeakContextTokens,
+			PeakContextOccupancy: turn.PeakContextOccupancy,
+			ContextGrowthTokens:  turn.ContextGrowthTokens,
+			ReclaimedTokens:      turn.ReclaimedTokens,
 		}
 		turnParams.ContentHash = spanTurnContentHash(turnParams)
 		if err := qtx.UpsertSpanTurn(ctx, turnParams); err != nil {
 			return fmt.Errorf("upsert span turn %s: %w", turn.TraceID, err)
 		}
+		if err := writeFileTouches(ctx, qtx, orgID, sid, turn); err != nil {
+			return err
+		}
+		if err := writeGitArtifacts(ctx, qtx, orgID, sid, turn); err != nil {
+			return err
+		}
 
 		for i, s := range turn.Spans {
 			keepSpanTraceIDs = append(keepSpanTraceIDs, turn.TraceID)
@@ -135,6 +147,12 @@ func writeSpanSet(
 					return fmt.Errorf("marshal span %s verdict: %w", s.SpanID, err)
 				}
 			}
+			var tool []byte
+			if s.Tool != nil {
+				if tool, err = json.Marshal(s.Tool); err != nil {
+					return fmt.Errorf("marshal span %s tool: %w", s.SpanID, err)
+				}
+			}
 			rawTurn := pgtype.Int8{}
 			if s.RawTurnID != 0 {
 				rawTurn = pgtype.Int8{Int64: s.RawTurnID, Valid: true}
@@ -163,6 +181,8 @@ func writeSpanSet(
 				Verdict:      verdict,
 				DeriveSeq:    deriveSeq,
 				Fidelity:     spanTiers[i],
+				Tool:         tool,
+				RetryOf:      s.RetryOf,
 			}
 			spanParams.ContentHash = spanContentHash(spanParams)
 			if err := qtx.UpsertSpan(ctx, spanParams); err != nil {
@@ -280,6 +300,19 @@ func writeSpanSet(
 			return fmt.Errorf("update session tasks: %w", err)
 		}
 	}
+	for key, rollup := range spans.Context {
+		sid, ok := sessionIDs[key]
+		if !ok || !sid.Valid {
+			continue
+		}
+		payload, err := json.Marshal(rollup)
+		if err != nil {
+			return fmt.Errorf("marshal session context_usage: %w", err)
+		}
+		if err := qtx.UpdateSessionContextUsage(ctx, gensqlc.UpdateSessionContextUsageParams{ID: sid, ContextUsage: payload}); err != nil {
+			return fmt.Errorf("update session context_usage: %w", err)
+		}
+	}
 	for key, counts := range spans.KindCounts {
 		sid, ok := sessionIDs[key]
 		if !ok || !sid.Valid {
@@ -296,17 +329,28 @@ func writeSpanSet(
 
 	// Chain-aware status is derived data too: the deriver folds it (moved
 	// off the ingest hot path) and is the sole writer of derived_status /
-	// has_git_activity / tool_result_count / tool_error_count.
+	// has_git_activity / tool_result_count / tool_error_count, and of the
+	// outcome that refines them.
 	for key, status := range spans.Status {
 		sid, ok := sessionIDs[key]
 		if !ok || !sid.Valid {
 			continue
 		}
+		outcome := status.Outcome.Outcome
+		if outcome == "" {
+			outcome = derive.OutcomeUnknown
+		}
+		reasons, err := json.Marshal(status.Outcome.Reasons)
+		if err != nil {
+			return fmt.Errorf("marshal session outcome_reasons: %w", err)
+		}
 		if err := qtx.UpdateSessionStatus(ctx, gensqlc.UpdateSessionStatusParams{
 			HasGitActivity:  status.HasGitActivity,
 			ToolResultCount: int32Count(status.ToolResultCount),
 			ToolErrorCount:  int32Count(status.ToolErrorCount),
 			DerivedStatus:   status.DerivedStatus,
+			Outcome:         outcome,
+			OutcomeReasons:  reasons,
 			ID:              sid,
 		}); err != nil {
 			return fmt.Errorf("update session status: %w", err)
@@ -367,12 +411,15 @@ func (d *Driver) ListSessionSpanModel(ctx context.Context, sessionID string) ([]
 			traceID: row.TraceID, userPrompt: row.UserPrompt,
 			responsePreview: row.ResponsePreview,
 			synthetic:       row.Synthetic, status: row.Status, source: row.Source,
+			parentTraceID: row.ParentTraceID, abandoned: row.Abandoned,
 			sessionID: row.SessionID, startedAt: row.StartedAt,
 			endedAt: row.EndedAt, durationNs: row.DurationNs,
 			totalIn: row.TotalInputTokens, totalOut: row.TotalOutputTokens,
 			mainIn: row.MainInputTokens, mainOut: row.MainOutputTokens,
 			cacheRead: row.CacheReadTokens, cacheCreation: row.CacheCreationTokens,
-			cost: row.TotalCostUsd,
+			cost:        row.TotalCostUsd,
+			peakContext: row.PeakContextTokens, peakOccupancy: row.PeakContextOccupancy,
+			contextGrowth: row.ContextGrowthTokens, reclaimed: row.ReclaimedTokens,
 		})
 		turns = append(turns, rec)
 	}
@@ -402,29 +449,39 @@ func (d *Driver) ListSessionSpanModel(ctx context.Context, sessionID string) ([]
 type spanTurnColumns struct {
 	traceID, userPrompt, responsePreview      string
 	synthetic, status, source                 string
+	parentTraceID                             string
+	abandoned                                 bool
 	sessionID                                 pgtype.UUID
 	startedAt, endedAt                        pgtype.Timestamptz
 	durationNs, totalIn, totalOut             int64
 	mainIn, mainOut, cacheRead, cacheCreation int64
 	cost                                      pgtype.Numeric
+	peakContext, contextGrowth, reclaimed     int64
+	peakOccupancy                             float64
 }
 
 func spanTurnRecordFromColumns(c spanTurnColumns) storage.SpanTurnRecord {
 	rec := storage.SpanTurnRecord{
-		TraceID:             c.traceID,
-		UserPrompt:          c.userPrompt,
-		ResponsePreview:     c.responsePreview,
-		Synthetic:           c.synthetic,
-		Status:              c.status,
-		Source:              c.source,
-		StartedAt:           c.startedAt.Time,
-		DurationNS:          c.durationNs,
-		TotalInputTokens:    c.totalIn,
-		TotalOutputTokens:   c.totalOut,
-		MainInputTokens:     c.mainIn,
-		MainOutputTokens:    c.mainOut,
-		CacheReadTokens:     c.cacheRead,
-		CacheCreationTokens: c.cacheCreation,
+		TraceID:              c.traceID,
+		UserPrompt:           c.userPrompt,
+		ResponsePreview:      c.responsePreview,
+		Synthetic:            c.synthetic,
+		Status:               c.status,
+		Source:               c.source,
+		ParentTraceID:        c.parentTraceID,
+		Abandoned:            c.abandoned,
+		StartedAt:            c.startedAt.Time,
+		DurationNS:           c.durationNs,
+		TotalInputTokens:     c.totalIn,
+		TotalOutputTokens:    c.totalOut,
+		MainInputTokens:      c.mainIn,
+		MainOutputTokens:     c.mainOut,
+		CacheReadTokens:      c.cacheRead,
+		CacheCreationTokens:  c.cacheCreation,
+		PeakContextTokens:    c.peakContext,
+		PeakContextOccupancy: c.peakOccupancy,
+		ContextGrowthTokens:  c.contextGrowth,
+		ReclaimedTokens:      c.reclaimed,
 	}
 	sessionID, endedAt, cost := c.sessionID, c.endedAt, c.cost
 	if sessionID.Valid {
@@ -464,6 +521,8 @@ func spanRecordFromRow(row gensqlc.Spans20260615) storage.SpanRecord {
 		RawTurnID:    row.RawTurnID.Int64,
 		NodeHash:     row.NodeHash,
 		Verdict:      row.Verdict,
+		Tool:         row.Tool,
+		RetryOf:      row.RetryOf,
 	}
 }
 
@@ -488,12 +547,15 @@ func (d *Driver) ListTraceSummaries(ctx context.Context, sessionID string) ([]st
 				traceID: row.TraceID, userPrompt: row.UserPrompt,
 				responsePreview: row.ResponsePreview,
 				synthetic:       row.Synthetic, status: row.Status, source: row.Source,
+				parentTraceID: row.ParentTraceID, abandoned: row.Abandoned,
 				sessionID: row.SessionID, startedAt: row.StartedAt,
 				endedAt: row.EndedAt, durationNs: row.DurationNs,
 				totalIn: row.TotalInputTokens, totalOut: row.TotalOutputTokens,
 				mainIn: row.MainInputTokens, mainOut: row.MainOutputTokens,
 				cacheRead: row.CacheReadTokens, cacheCreation: row.CacheCreationTokens,
-				cost: row.TotalCostUsd,
+				cost:        row.TotalCostUsd,
+				peakContext: row.PeakContextTokens, peakOccupancy: row.PeakContextOccupancy,
+				contextGrowth: row.ContextGrowthTokens, reclaimed: row.ReclaimedTokens,
 			}),
 			SpanCount: int(row.SpanCount),
 		})
@@ -576,12 +638,15 @@ func (d *Driver) GetTraceDetail(ctx context.Context, orgID, traceID string) (*st
 		traceID: row.TraceID, userPrompt: row.UserPrompt,
 		responsePreview: row.ResponsePreview,
 		synthetic:       row.Synthetic, status: row.Status, source: row.Source,
+		parentTraceID: row.ParentTraceID, abandoned: row.Abandoned,
 		sessionID: row.SessionID, startedAt: row.StartedAt,
 		endedAt: row.EndedAt, durationNs: row.DurationNs,
 		totalIn: row.TotalInputTokens, totalOut: row.TotalOutputTokens,
 		mainIn: row.MainInputTokens, mainOut: row.MainOutputTokens,
 		cacheRead: row.CacheReadTokens, cacheCreation: row.CacheCreationTokens,
-		cost: row.TotalCostUsd,
+		cost:        row.TotalCostUsd,
+		peakContext: row.PeakContextTokens, peakOccupancy: row.PeakContextOccupancy,
+		contextGrowth: row.ContextGrowthTokens, reclaimed: row.ReclaimedTokens,
 	})
 
 	spanRows, err := d.q.ListSpansByTrace(ctx, gensqlc.ListSpansByTraceParams{OrgID: org, TraceID: traceID})
@@ -630,8 +695,10 @@ func (d *Driver) GetSpanRecord(ctx context.Context, orgID, traceID, spanID strin
 }
 
 // AggregateSpanStats sums the trace-grain rollups over a time window —
-// the span-layer aggregate behind /v1/stats. A non-empty authSubject narrows
-// every total to that subject's sessions. Implements storage.SpanStatsReader.
+// the span-layer aggregate behind /v1/stats — plus per-model latency
+// percentiles over the window's timed llm spans. A non-empty authSubject
+// narrows every figure to that subject's sessions. Implements
+// storage.SpanStatsReader.
 func (d *Driver) AggregateSpanStats(ctx context.Context, orgID string, since, until *time.Time, authSubject string) (storage.SpanStats, error) {
 	if d == nil || d.conn == nil {
 		return storage.SpanStats{}, errors.New("postgres driver not open")
@@ -640,20 +707,30 @@ func (d *Driver) AggregateSpanStats(ctx context.Context, orgID string, since, un
 	if err != nil {
 		return storage.SpanStats{}, fmt.Errorf("decode org_id: %w", err)
 	}
+	// Deliberately not nullStringValue: that treats whitespace as absent,
+	// and absent here means org-wide. A caller who sends a blank-looking
+	// subject should get zeros — "nobody by that name" — rather than
+	// silently widening to every user's totals on a personal surface.
+	// `!= ""` is also exactly how the /v1/sessions filter decides.
+	subjectFilter := pgtype.Text{String: authSubject, Valid: authSubject != ""}
 	row, err := d.q.AggregateSpanStats(ctx, gensqlc.AggregateSpanStatsParams{
-		OrgID:       org,
-		SinceFilter: nullTimePtr(since),
-		UntilFilter: nullTimePtr(until),
-		// Deliberately not nullStringValue: that treats whitespace as absent,
-		// and absent here means org-wide. A caller who sends a blank-looking
-		// subject should get zeros — "nobody by that name" — rather than
-		// silently widening to every user's totals on a personal surface.
-		// `!= ""` is also exactly how the /v1/sessions filter decides.
-		AuthSubjectFilter: pgtype.Text{String: authSubject, Valid: authSubject != ""},
+		OrgID:             org,
+		SinceFilter:       nullTimePtr(since),
+		UntilFilter:       nullTimePtr(until),
+		AuthSubjectFilter: subjectFilter,
 	})
 	if err != nil {
 		return storage.SpanStats{}, fmt.Errorf("aggregate span stats: %w", err)
 	}
+	latencyRows, err := d.q.AggregateModelLatency(ctx, gensqlc.AggregateModelLatencyParams{
+		OrgID:             org,
+		SinceFilter:       nullTimePtr(since),
+		UntilFilter:       nullTimePtr(until),
+		AuthSubjectFilter: subjectFilter,
+	})
+	if err != nil {
+		return storage.SpanStats{}, fmt.Errorf("aggregate model latency: %w", err)
+	}
 	stats := storage.SpanStats{
 		TurnCount:           int(row.TurnCount),
 		SessionCount:        int(row.SessionCount),
@@ -665,6 +742,19 @@ func (d *Driver) AggregateSpanStats(ctx context.Context, orgID string, since, un
 		TotalDurationNS:     row.TotalDurationNs,
 		ToolCalls:           int(row.ToolCalls),
 	}
+	for _, l := range latencyRows {
+		stats.ModelLatency = append(stats.ModelLatency, storage.ModelLatency{
+			Model:                 l.Model,
+			Calls:                 int(l.Calls),
+			TTFBP50NS:             l.TtfbP50Ns,
+			TTFBP95NS:             l.TtfbP95Ns,
+			TTFTP50NS:             l.TtftP50Ns,
+			TTFTP95NS:             l.TtftP95Ns,
+			InterTokenP50NS:       l.InterTokenP50Ns,
+			InterTokenP95NS:       l.InterTokenP95Ns,
+			OutputTokensPerSecP50: l.OutputTokensPerSecP50,
+		})
+	}
 	if row.TotalCostUsd.Valid {
 		if f, err := row.TotalCostUsd.Float64Value(); err == nil && f.Valid {
 			stats.TotalCostUSD = f.Float64
@@ -673,6 +763,44 @@ func (d *Driver) AggregateSpanStats(ctx context.Context, orgID string, since, un
 	return stats, nil
 }
 
+// AggregateToolStats folds the window's tool spans per tool identity —
+// call, error and retry counts plus execution-time percentiles — behind
+// /v1/tools. A non-empty authSubject narrows the rows to that subject's
+// sessions. Implements storage.ToolStatsReader.
+func (d *Driver) AggregateToolStats(ctx context.Context, orgID string, since, until *time.Time, authSubject string) ([]storage.ToolStats, error) {
+	if d == nil || d.conn == nil {
+		return nil, errors.New("postgres driver not open")
+	}
+	org, err := orgIDFromString(orgKeyForLookup(orgID))
+	if err != nil {
+		return nil, fmt.Errorf("decode org_id: %w", err)
+	}
+	// Blank is not absent here either; see AggregateSpanStats.
+	rows, err := d.q.AggregateToolStats(ctx, gensqlc.AggregateToolStatsParams{
+		OrgID:             org,
+		SinceFilter:       nullTimePtr(since),
+		UntilFilter:       nullTimePtr(until),
+		AuthSubjectFilter: pgtype.Text{String: authSubject, Valid: authSubject != ""},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("aggregate tool stats: %w", err)
+	}
+	out := make([]storage.ToolStats, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, storage.ToolStats{
+			Tool:          r.ToolName,
+			Server:        r.Server,
+			Command:       r.Command,
+			Calls:         int(r.Calls),
+			Errors:        int(r.Errors),
+			Retries:       int(r.Retries),
+			DurationP50NS: r.DurationP50Ns,
+			DurationP95NS: r.DurationP95Ns,
+		})
+	}
+	return out, nil
+}
+
 // ListRawTurnHeaders returns the wire log for one session: capture
 // identity and payload sizes, no blobs. Implements
 // storage.SpanModelReader.
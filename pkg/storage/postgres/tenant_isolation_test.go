@@ -0,0 +1,167 @@
+package postgres_test
+
+// Tenant isolation in the database: a cassette connecting as a role mapped
+// in tenant_roles sees only its org's rows, through the tapes_v1 views and
+// in every org-scoped table it could be granted directly. Application code
+// scopes its own queries; these specs are about the half that must hold
+// when a tenant's SQL does not.
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/papercomputeco/tapes/pkg/storage/postgres"
+)
+
+var _ = Describe("tenant isolation [postgres]", func() {
+	var (
+		ctx    context.Context
+		driver *postgres.Driver
+		orgA   string
+		orgB   string
+	)
+
+	// The org-scoped tables a per-tenant role might be granted. Each gets a
+	// row for both orgs below.
+	tables := []string{
+		"sessions",
+		"spans_20260615",
+		"span_turns_20260615",
+		"span_links_20260615",
+		"file_touches_20260615",
+		"git_artifacts_20260615",
+		"annotations",
+	}
+	views := []string{"tapes_v1.sessions", "tapes_v1.spans", "tapes_v1.span_turns", "tapes_v1.span_links"}
+
+	exec := func(sql string, args ...any) {
+		_, err := driver.DB().Exec(ctx, sql, args...)
+		Expect(err).NotTo(HaveOccurred(), sql)
+	}
+
+	// seedOrg writes one row per table for orgID, as the ingest and derive
+	// paths would leave them.
+	seedOrg := func(orgID string) {
+		sessionID := uuid.NewString()
+		at := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+		exec(`INSERT INTO sessions (id, org_id, auth_subject, harness_id, harness_session_id, started_at, last_seen_at)
+			VALUES ($1, $2, 'subj', 'claude', $3, $4, $4)`, sessionID, orgID, "rls-"+sessionID, at)
+		exec(`INSERT INTO span_turns_20260615 (org_id, trace_id, session_id, started_at)
+			VALUES ($1, 'trace-1', $2, $3)`, orgID, sessionID, at)
+		exec(`INSERT INTO spans_20260615 (org_id, trace_id, span_id, session_id, kind, started_at)
+			VALUES ($1, 'trace-1', 'span-1', $2, 'tool', $3)`, orgID, sessionID, at)
+		exec(`INSERT INTO span_links_20260615 (org_id, from_trace_id, from_span_id, to_trace_id, to_span_id, kind, session_id)
+			VALUES ($1, 'trace-1', 'span-1', 'trace-1', 'span-1', 'self', $2)`, orgID, sessionID)
+		exec(`INSERT INTO file_touches_20260615 (org_id, trace_id, path, session_id, name, reads, first_at, last_at)
+			VALUES ($1, 'trace-1', '/repo/main.go', $2, 'main.go', 1, $3, $3)`, orgID, sessionID, at)
+		exec(`INSERT INTO git_artifacts_20260615 (org_id, trace_id, ordinal, session_id, span_id, kind, sha, at)
+			VALUES ($1, 'trace-1', 0, $2, 'span-1', 'commit', 'abc1234', $3)`, orgID, sessionID, at)
+		exec(`INSERT INTO annotations (id, org_id, session_id, name, label)
+			VALUES ($1, $2, $3, 'correctness', 'good')`, uuid.NewString(), orgID, sessionID)
+	}
+
+	// orgsIn lists which of the two orgs a relation shows conn's role.
+	orgsIn := func(conn *pgx.Conn, relation string) []string {
+		rows, err := conn.Query(ctx,
+			"SELECT DISTINCT org_id::text FROM "+relation+" WHERE org_id = ANY($1::uuid[])",
+			[]string{orgA, orgB})
+		Expect(err).NotTo(HaveOccurred(), relation)
+		orgs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		Expect(err).NotTo(HaveOccurred(), relation)
+		return orgs
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var err error
+		driver, err = postgres.NewDriver(ctx, testPostgresDSN)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(driver.Close)
+
+		orgA, orgB = newTestOrgID(), newTestOrgID()
+		seedOrg(orgA)
+		seedOrg(orgB)
+	})
+
+	// connectAs provisions a login role the way a deployment provisions a
+	// cassette's — granted the views and, here, the tables too — maps it to
+	// orgID when one is given, and connects as it. The role is dropped when
+	// the spec ends.
+	connectAs := func(orgID string) *pgx.Conn {
+		role := "tapes_test_tenant_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+		password := uuid.NewString()
+
+		exec("CREATE ROLE " + role + " LOGIN PASSWORD '" + password + "'")
+		DeferCleanup(func() {
+			exec("DELETE FROM tenant_roles WHERE role_name = $1", role)
+			exec("DROP OWNED BY " + role)
+			exec("DROP ROLE " + role)
+		})
+		exec("GRANT USAGE ON SCHEMA tapes_v1 TO " + role)
+		exec("GRANT SELECT ON ALL TABLES IN SCHEMA tapes_v1 TO " + role)
+		exec("GRANT SELECT ON " + strings.Join(tables, ", ") + " TO " + role)
+		if orgID != "" {
+			exec("INSERT INTO tenant_roles (role_name, org_id) VALUES ($1, $2)", role, orgID)
+		}
+
+		cfg, err := pgx.ParseConfig(testPostgresDSN)
+		Expect(err).NotTo(HaveOccurred())
+		cfg.User = role
+		cfg.Password = password
+		conn, err := pgx.ConnectConfig(ctx, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		// Registered after the role's cleanup, so it runs first: a role
+		// with an open session cannot be dropped.
+		DeferCleanup(func() { Expect(conn.Close(ctx)).To(Succeed()) })
+		return conn
+	}
+
+	It("resolves a mapped role to its org and leaves any other role unrestricted", func() {
+		conn := connectAs(orgA)
+
+		var mapped string
+		Expect(conn.QueryRow(ctx, "SELECT tenant_org_id(current_user)::text").Scan(&mapped)).To(Succeed())
+		Expect(mapped).To(Equal(orgA))
+
+		var owner *string
+		Expect(driver.DB().QueryRow(ctx, "SELECT tenant_org_id(current_user)::text").Scan(&owner)).To(Succeed())
+		Expect(owner).To(BeNil())
+	})
+
+	It("shows a mapped role only its org's rows in every org-scoped table", func() {
+		conn := connectAs(orgA)
+		for _, table := range tables {
+			Expect(orgsIn(conn, table)).To(ConsistOf(orgA), "%s must hide org B from org A's role", table)
+		}
+	})
+
+	It("shows a mapped role only its org's rows through tapes_v1", func() {
+		conn := connectAs(orgB)
+		for _, view := range views {
+			Expect(orgsIn(conn, view)).To(ConsistOf(orgB), "%s must hide org A from org B's role", view)
+		}
+	})
+
+	It("keeps the filter when a tenant's own predicate asks for another org", func() {
+		conn := connectAs(orgA)
+		for _, relation := range append(append([]string{}, tables...), views...) {
+			var n int
+			err := conn.QueryRow(ctx, "SELECT count(*) FROM "+relation+" WHERE org_id = $1", orgB).Scan(&n)
+			Expect(err).NotTo(HaveOccurred(), relation)
+			Expect(n).To(BeZero(), "%s leaked org B's rows", relation)
+		}
+	})
+
+	It("leaves a role with no tenant_roles row unrestricted", func() {
+		conn := connectAs("")
+		for _, relation := range append(append([]string{}, tables...), views...) {
+			Expect(orgsIn(conn, relation)).To(ConsistOf(orgA, orgB), relation)
+		}
+	})
+})
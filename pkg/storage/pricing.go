@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/papercomputeco/tapes/pkg/sessions"
+)
+
+// ErrPriceNotFound means no catalog price or discount has the given id.
+var ErrPriceNotFound = errors.New("price not found")
+
+// Price is one row of the pricing catalog: a model's rates from
+// EffectiveFrom until the model's next row takes effect.
+type Price struct {
+	ID            string           `json:"id"`
+	Model         string           `json:"model"`
+	EffectiveFrom time.Time        `json:"effective_from"`
+	Pricing       sessions.Pricing `json:"pricing"`
+	// Source notes where the rates came from (a price-sheet URL, an
+	// invoice reference, "defaults").
+	Source    string    `json:"source,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PriceDiscount is one org's negotiated discount off the catalog.
+type PriceDiscount struct {
+	ID    string `json:"id"`
+	OrgID string `json:"org_id"`
+	// Model is the model the discount applies to; empty means every
+	// model.
+	Model          string     `json:"model,omitempty"`
+	Percent        float64    `json:"percent"`
+	EffectiveFrom  time.Time  `json:"effective_from"`
+	EffectiveUntil *time.Time `json:"effective_until,omitempty"`
+	Note           string     `json:"note,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// PricingStore is an optional capability for a Driver: the effective-dated
+// pricing catalog that derive-time costing reads.
+//
+// Only drivers with durable storage implement this (Postgres does;
+// in-memory intentionally does not). Callers MUST type-assert.
+type PricingStore interface {
+	// UpsertPrices records prices, replacing any row with the same model
+	// and effective_from. Returns the stored rows.
+	UpsertPrices(ctx context.Context, prices []Price) ([]Price, error)
+
+	// ListPrices returns the catalog ordered by model, then effective_from.
+	// A non-empty model narrows it to that model.
+	ListPrices(ctx context.Context, model string) ([]Price, error)
+
+	// DeletePrice removes a catalog row by id. Returns ErrPriceNotFound
+	// when no row has that id.
+	DeletePrice(ctx context.Context, id string) error
+
+	// CreatePriceDiscount records a discount.
+	CreatePriceDiscount(ctx context.Context, discount PriceDiscount) (*PriceDiscount, error)
+
+	// ListPriceDiscounts returns one org's discounts, or every org's when
+	// orgID is empty, oldest first.
+	ListPriceDiscounts(ctx context.Context, orgID string) ([]PriceDiscount, error)
+
+	// DeletePriceDiscount removes a discount by id. A non-empty orgID
+	// restricts the delete to that org's discounts. Returns
+	// ErrPriceNotFound when no matching row exists.
+	DeletePriceDiscount(ctx context.Context, orgID, id string) error
+
+	// PricingCatalog assembles the catalog and one org's discounts into the
+	// lookup derive prices spans with.
+	PricingCatalog(ctx context.Context, orgID string) (*sessions.PricingCatalog, error)
+}
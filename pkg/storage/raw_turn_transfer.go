@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// RawTurnExportOpts selects raw turns for an export bundle. The zero
+// value selects every raw turn in the store.
+type RawTurnExportOpts struct {
+	// HarnessSessionIDs limits the export to these sessions (matched on
+	// effective attribution) and their subagent sessions. Empty means no
+	// session filter.
+	HarnessSessionIDs []string
+
+	// ActiveSince limits the export to sessions last seen at or after
+	// it. A matching session exports its whole raw history, not just
+	// the turns after the cutoff. Zero means no time filter.
+	ActiveSince time.Time
+
+	// AfterID and Limit page the scan in insertion order.
+	AfterID int64
+	Limit   int32
+}
+
+// RawTurnCorrection is one row of the append-only attribution
+// corrections log, as an export bundle carries it.
+type RawTurnCorrection struct {
+	ID                     int64     `json:"id"`
+	OrgID                  string    `json:"org_id"`
+	RawTurnID              int64     `json:"raw_turn_id"`
+	HarnessID              string    `json:"harness_id"`
+	HarnessSessionID       string    `json:"harness_session_id"`
+	ThreadID               string    `json:"thread_id"`
+	ParentHarnessSessionID *string   `json:"parent_harness_session_id,omitempty"`
+	Reason                 string    `json:"reason"`
+	CreatedAt              time.Time `json:"created_at"`
+}
+
+// RawTurnTransferStore is an optional capability for a Driver: moving
+// the raw layer between deployments. Export reads raw turns verbatim,
+// without the correction overlay ListRawTurns applies, so the
+// corrections travel as their own rows and the destination's audit log
+// matches the source's.
+//
+// Only drivers that host the raw layer implement this (Postgres does;
+// in-memory intentionally does not). Callers MUST type-assert.
+type RawTurnTransferStore interface {
+	// ListRawTurnsForExport returns one page of verbatim raw turns
+	// matching opts, in insertion order. Turns whose payloads a
+	// retention strip removed are never exported.
+	ListRawTurnsForExport(ctx context.Context, opts RawTurnExportOpts) ([]RawTurnRecord, error)
+
+	// ListRawTurnCorrections returns every correction recorded against
+	// the given raw turns, oldest first.
+	ListRawTurnCorrections(ctx context.Context, rawTurnIDs []int64) ([]RawTurnCorrection, error)
+
+	// ImportRawTurn appends one exported raw turn, keeping its original
+	// ReceivedAt, and materializes its session identity. A turn already
+	// present — same request id, or for a turn without one, the same
+	// source, session, receipt time and request bytes — is not written
+	// again. Either way the returned id is the destination row's, for
+	// re-pointing the turn's corrections.
+	ImportRawTurn(ctx context.Context, rec RawTurnRecord) (id int64, inserted bool, err error)
+
+	// ImportRawTurnCorrection appends one exported correction against
+	// the destination raw turn c.RawTurnID, keeping its CreatedAt. An
+	// identical correction already recorded is not written again.
+	// Returns ErrRawTurnNotFound when the raw turn does not exist.
+	ImportRawTurnCorrection(ctx context.Context, c RawTurnCorrection) (inserted bool, err error)
+}
@@ -0,0 +1,392 @@
+// Package remote provides a storage.Driver that keeps no local database:
+// the raw turns it is handed are forwarded to a remote tapes ingest server
+// in POST /v1/ingest/batch requests, one /v1/ingest envelope per line, so a
+// capture proxy on a developer machine needs an ingest URL rather than
+// credentials for the team's Postgres.
+package remote
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+var (
+	// DefaultBatchSize is the most turns one flush forwards in a single
+	// batch request. The capture
+	// pool sizes its workers to it so a flush can fill.
+	DefaultBatchSize = 16
+
+	// defaultFlushInterval is how long a turn waits for company before its
+	// batch is flushed anyway. Capture is asynchronous, so this only delays
+	// when the turn lands centrally, never the proxied call.
+	defaultFlushInterval = 100 * time.Millisecond
+
+	defaultHTTPTimeout = 30 * time.Second
+)
+
+// ErrUnsupported is returned by the read side of storage.RawTurnStore: the
+// remote driver writes to the ingest server and has nothing local to read.
+var ErrUnsupported = errors.New("not supported by the remote driver")
+
+// Config configures a remote Driver.
+type Config struct {
+	// IngestURL is the base URL of the tapes ingest server
+	// (e.g. https://tapes.internal); /v1/ingest/batch is appended.
+	IngestURL string
+
+	// Token, when set, is sent as a bearer token on every POST, for ingest
+	// servers that verify callers in process.
+	Token string
+
+	// BatchSize and FlushInterval bound a batch. Zero values default.
+	BatchSize     int
+	FlushInterval time.Duration
+
+	// Client is the HTTP client to forward with. Nil uses one with a 30s
+	// timeout.
+	Client *http.Client
+
+	Logger *slog.Logger
+}
+
+// Driver forwards raw turns to a remote ingest server. It implements
+// storage.RawTurnStore's write side and deliberately not
+// storage.SessionIngester: the ingest server upserts the sessions row from
+// the envelope's session block itself.
+//
+// PutRawTurn blocks until its turn's batch is flushed and reports that
+// turn's outcome, so the caller's retry and spool machinery (see
+// proxy/worker) sees an unreachable or failing server as a failed write
+// and keeps the turn for later.
+type Driver struct {
+	url    string
+	token  string
+	client *http.Client
+	logger *slog.Logger
+
+	batchSize     int
+	flushInterval time.Duration
+
+	pending chan *forward
+	done    chan struct{}
+	wg      sync.WaitGroup
+	close   sync.Once
+}
+
+// forward is one turn waiting in a batch, and the channel its outcome is
+// delivered on.
+type forward struct {
+	requestID string
+	payload   []byte
+	result    chan error
+}
+
+var (
+	// errRejected marks a turn the ingest server refused outright (4xx).
+	// Retrying cannot help, so PutRawTurn reports it as a deduplicated
+	// write rather than an error and the turn is dropped, loudly.
+	errRejected = errors.New("ingest rejected turn")
+
+	// errDeduped marks a turn the ingest server already held. It is a
+	// success, reported by PutRawTurn as a deduplicated write.
+	errDeduped = errors.New("ingest already held turn")
+)
+
+// NewDriver returns a remote Driver and starts its batcher.
+func NewDriver(c Config) (*Driver, error) {
+	if c.IngestURL == "" {
+		return nil, errors.New("remote driver requires an ingest URL")
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = DefaultBatchSize
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = defaultFlushInterval
+	}
+	if c.Client == nil {
+		c.Client = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+	if c.Logger == nil {
+		c.Logger = slog.Default()
+	}
+
+	d := &Driver{
+		url:           strings.TrimRight(c.IngestURL, "/"),
+		token:         c.Token,
+		client:        c.Client,
+		logger:        c.Logger,
+		batchSize:     c.BatchSize,
+		flushInterval: c.FlushInterval,
+		pending:       make(chan *forward),
+		done:          make(chan struct{}),
+	}
+	d.wg.Add(1)
+	go d.batcher()
+	return d, nil
+}
+
+// Open checks that the ingest server answers. An unreachable server is
+// logged, not returned: the proxy must start offline and catch up later.
+func (d *Driver) Open(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url+"/ping", nil)
+	if err != nil {
+		return fmt.Errorf("building ingest ping: %w", err)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		d.logger.Warn("ingest server unreachable, capturing offline", "ingest_url", d.url, "error", err)
+		return nil
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		d.logger.Warn("ingest server unhealthy, capturing offline", "ingest_url", d.url, "status", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close stops the batcher. Turns still waiting fail with an error, so a
+// spooling caller keeps them for the next start.
+func (d *Driver) Close() error {
+	d.close.Do(func() {
+		close(d.done)
+		d.wg.Wait()
+	})
+	return nil
+}
+
+// PutRawTurn forwards one turn and reports its outcome once its batch has
+// been sent. A turn the server already held, or rejects, is reported as
+// not stored (false, nil), the rejection logged; an unreachable or failing
+// server is an error.
+func (d *Driver) PutRawTurn(ctx context.Context, rec storage.RawTurnRecord) (bool, error) {
+	payload, err := envelope(rec)
+	if err != nil {
+		// A record that cannot be encoded never will be: marking it invalid
+		// content lets a spooling caller drop it instead of retrying.
+		return false, fmt.Errorf("%w: %w", storage.ErrInvalidContent, err)
+	}
+
+	f := &forward{requestID: rec.RequestID, payload: payload, result: make(chan error, 1)}
+	select {
+	case d.pending <- f:
+	case <-d.done:
+		return false, errors.New("remote driver closed")
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+
+	select {
+	case err := <-f.result:
+		if errors.Is(err, errRejected) || errors.Is(err, errDeduped) {
+			return false, nil
+		}
+		return err == nil, err
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// ListRawTurns is not supported: the raw layer lives on the ingest server.
+func (d *Driver) ListRawTurns(context.Context, int64, int32) ([]storage.RawTurnRecord, error) {
+	return nil, ErrUnsupported
+}
+
+// CountRawTurns is not supported: the raw layer lives on the ingest server.
+func (d *Driver) CountRawTurns(context.Context) (int64, error) {
+	return 0, ErrUnsupported
+}
+
+// batcher collects turns until a batch is full or has waited
+// flushInterval, then flushes it.
+func (d *Driver) batcher() {
+	defer d.wg.Done()
+
+	var (
+		batch []*forward
+		timer <-chan time.Time
+	)
+	for {
+		select {
+		case f := <-d.pending:
+			batch = append(batch, f)
+			if len(batch) == 1 {
+				timer = time.After(d.flushInterval)
+			}
+			if len(batch) < d.batchSize {
+				continue
+			}
+		case <-timer:
+		case <-d.done:
+			for _, f := range batch {
+				f.result <- errors.New("remote driver closed")
+			}
+			return
+		}
+		d.flush(batch)
+		batch, timer = nil, nil
+	}
+}
+
+// batchLineResult mirrors one line of the ingest batch response.
+type batchLineResult struct {
+	Line   int    `json:"line"`
+	Status string `json:"status"`
+	Code   int    `json:"code"`
+	Error  string `json:"error"`
+}
+
+// flush sends a batch as one POST /v1/ingest/batch request, a turn per
+// line in arrival order, and delivers each turn the result the server
+// answered for its line. A request that fails as a whole fails every turn
+// in it; the caller retries them with backoff.
+func (d *Driver) flush(batch []*forward) {
+	results, err := d.post(batch)
+	for i, f := range batch {
+		switch {
+		case err != nil:
+			f.result <- err
+		case results[i] == nil:
+			// The response ended before this line's result: the server
+			// stopped partway. Resending is safe because every turn dedupes.
+			f.result <- fmt.Errorf("ingest batch ended before line %d's result", i+1)
+		default:
+			f.result <- d.outcome(f, results[i])
+		}
+	}
+}
+
+// post sends the batch and returns its per-line results, indexed by the
+// turn's position; a line the response never answered is nil.
+func (d *Driver) post(batch []*forward) ([]*batchLineResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultHTTPTimeout)
+	defer cancel()
+
+	// Each envelope is marshaled compact, so it is one line as it stands.
+	var body bytes.Buffer
+	for _, f := range batch {
+		body.WriteString(`{"turn":`)
+		body.Write(f.payload)
+		body.WriteString("}\n")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url+"/v1/ingest/batch", &body)
+	if err != nil {
+		return nil, fmt.Errorf("building ingest request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if d.token != "" {
+		req.Header.Set("Authorization", "Bearer "+d.token)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("forwarding turns to ingest: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// The batch was refused before any line was read — bad
+		// credentials, an outage, a server without the batch route — so
+		// nothing the turns carry is at fault and all of them wait it out.
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return nil, fmt.Errorf("ingest returned %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	// The body carries no blank lines, so result line n is batch[n-1].
+	results := make([]*batchLineResult, len(batch))
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var r batchLineResult
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("decoding ingest batch result: %w", err)
+		}
+		if r.Line < 1 || r.Line > len(batch) || results[r.Line-1] != nil {
+			return nil, fmt.Errorf("ingest batch result for unexpected line %d", r.Line)
+		}
+		results[r.Line-1] = &r
+	}
+	// A response cut short leaves the remaining lines nil, which flush
+	// fails; the lines already answered stand.
+	return results, nil
+}
+
+// outcome maps a turn's line result to the error PutRawTurn delivers for
+// it, with the statuses a single POST /v1/ingest would have answered.
+func (d *Driver) outcome(f *forward, r *batchLineResult) error {
+	switch {
+	case r.Status == "accepted":
+		return nil
+	case r.Status == "deduped":
+		return errDeduped
+	case r.Code >= 500 || r.Code == http.StatusTooManyRequests ||
+		r.Code == http.StatusUnauthorized || r.Code == http.StatusForbidden:
+		// Outages, shedding and credentials are all fixed on the server
+		// side without the turn changing, so they are worth waiting out.
+		return fmt.Errorf("ingest returned %d: %s", r.Code, r.Error)
+	default:
+		d.logger.Error("ingest rejected turn, turn dropped",
+			"request_id", f.requestID,
+			"status", r.Code,
+			"body", r.Error,
+		)
+		return errRejected
+	}
+}
+
+// ingestEnvelope is the POST /v1/ingest body (ingest.TurnPayload), built
+// from the raw turn's already-serialized parts so nothing is re-encoded.
+type ingestEnvelope struct {
+	Provider  string          `json:"provider"`
+	AgentName string          `json:"agent_name,omitempty"`
+	Request   json.RawMessage `json:"request"`
+	Response  json.RawMessage `json:"response"`
+	Meta      json.RawMessage `json:"meta"`
+	Session   json.RawMessage `json:"session,omitempty"`
+}
+
+// envelope renders a raw turn as an ingest envelope. The record's request
+// id is carried in meta.request_id, which is what makes a replayed turn
+// dedupe on the server instead of landing twice.
+func envelope(rec storage.RawTurnRecord) ([]byte, error) {
+	meta := map[string]json.RawMessage{}
+	if len(rec.Meta) > 0 {
+		if err := json.Unmarshal(rec.Meta, &meta); err != nil {
+			return nil, fmt.Errorf("decoding raw turn meta: %w", err)
+		}
+	}
+	if _, ok := meta["request_id"]; !ok && rec.RequestID != "" {
+		id, err := json.Marshal(rec.RequestID)
+		if err != nil {
+			return nil, fmt.Errorf("encoding request id: %w", err)
+		}
+		meta["request_id"] = id
+	}
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return nil, fmt.Errorf("encoding raw turn meta: %w", err)
+	}
+
+	out, err := json.Marshal(ingestEnvelope{
+		Provider:  rec.Provider,
+		AgentName: rec.AgentName,
+		Request:   rec.RawRequest,
+		Response:  rec.Response,
+		Meta:      metaJSON,
+		Session:   rec.SessionEnvelope,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encoding ingest envelope: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,278 @@
+package remote_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	tapeslogger "github.com/papercomputeco/tapes/pkg/logger"
+	"github.com/papercomputeco/tapes/pkg/storage"
+	"github.com/papercomputeco/tapes/pkg/storage/remote"
+)
+
+// ingestStub records the envelopes POSTed to /v1/ingest/batch and answers
+// each request with the status the spec sets and, on a 200, each line with
+// the result the spec sets.
+type ingestStub struct {
+	mu        sync.Mutex
+	status    int
+	line      func(n int) string
+	answer    int
+	requests  int
+	bodies    []map[string]json.RawMessage
+	authorize []string
+}
+
+func (s *ingestStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/ping" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	Expect(r.URL.Path).To(Equal("/v1/ingest/batch"))
+	Expect(r.Header.Get("Content-Type")).To(Equal("application/x-ndjson"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	s.authorize = append(s.authorize, r.Header.Get("Authorization"))
+
+	var lines int
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+		var line struct {
+			Turn map[string]json.RawMessage `json:"turn"`
+		}
+		Expect(json.Unmarshal(scanner.Bytes(), &line)).To(Succeed())
+		s.bodies = append(s.bodies, line.Turn)
+		lines++
+	}
+	Expect(scanner.Err()).NotTo(HaveOccurred())
+
+	w.WriteHeader(s.status)
+	if s.status != http.StatusOK {
+		return
+	}
+	for n := 1; n <= lines; n++ {
+		if s.answer > 0 && n > s.answer {
+			return
+		}
+		result := `{"line":%d,"kind":"turn","status":"accepted"}`
+		if s.line != nil {
+			result = s.line(n)
+		}
+		fmt.Fprintf(w, result+"\n", n)
+	}
+}
+
+func (s *ingestStub) setStatus(status int) {
+	s.mu.Lock()
+	s.status = status
+	s.mu.Unlock()
+}
+
+// setLine sets the result every line is answered with: a format taking
+// the line number.
+func (s *ingestStub) setLine(format string) {
+	s.mu.Lock()
+	s.line = func(int) string { return format }
+	s.mu.Unlock()
+}
+
+func (s *ingestStub) received() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.bodies)
+}
+
+func sampleRecord(requestID string) storage.RawTurnRecord {
+	return storage.RawTurnRecord{
+		Provider:         "anthropic",
+		AgentName:        "claude",
+		HarnessSessionID: "harness-1",
+		RequestID:        requestID,
+		RawRequest:       json.RawMessage(`{"model":"m","messages":[]}`),
+		Response:         json.RawMessage(`{"model":"m","message":{"role":"assistant","content":[]}}`),
+		Meta:             json.RawMessage(`{"thread_id":"t-1"}`),
+		SessionEnvelope:  json.RawMessage(`{"harness_id":"claude","harness_session_id":"harness-1"}`),
+	}
+}
+
+var _ = Describe("Remote driver", func() {
+	var (
+		stub   *ingestStub
+		server *httptest.Server
+		driver *remote.Driver
+	)
+
+	BeforeEach(func() {
+		stub = &ingestStub{status: http.StatusOK}
+		server = httptest.NewServer(stub)
+		DeferCleanup(server.Close)
+
+		var err error
+		driver, err = remote.NewDriver(remote.Config{
+			IngestURL:     server.URL + "/",
+			Token:         "secret",
+			FlushInterval: 5 * time.Millisecond,
+			Logger:        tapeslogger.NewNoop(),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(driver.Open(context.Background())).To(Succeed())
+		DeferCleanup(driver.Close)
+	})
+
+	It("forwards a raw turn as an ingest envelope on a batch line", func() {
+		stored, err := driver.PutRawTurn(context.Background(), sampleRecord("req-1"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored).To(BeTrue())
+
+		Expect(stub.bodies).To(HaveLen(1))
+		body := stub.bodies[0]
+		Expect(body["provider"]).To(MatchJSON(`"anthropic"`))
+		Expect(body["agent_name"]).To(MatchJSON(`"claude"`))
+		Expect(body["request"]).To(MatchJSON(`{"model":"m","messages":[]}`))
+		Expect(body["session"]).To(MatchJSON(`{"harness_id":"claude","harness_session_id":"harness-1"}`))
+		// The request id rides in meta so a replay dedupes server-side.
+		Expect(body["meta"]).To(MatchJSON(`{"thread_id":"t-1","request_id":"req-1"}`))
+		Expect(stub.authorize).To(ConsistOf("Bearer secret"))
+	})
+
+	It("reports a failing server as an error so the turn is retried", func() {
+		stub.setStatus(http.StatusServiceUnavailable)
+		_, err := driver.PutRawTurn(context.Background(), sampleRecord("req-1"))
+		Expect(err).To(MatchError(ContainSubstring("503")))
+	})
+
+	It("drops a turn the server rejects without an error", func() {
+		stub.setLine(`{"line":%d,"kind":"turn","status":"rejected","code":422,"error":"unknown provider"}`)
+		stored, err := driver.PutRawTurn(context.Background(), sampleRecord("req-1"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored).To(BeFalse())
+	})
+
+	It("reports a line the server could not hand downstream as an error so the turn is retried", func() {
+		stub.setLine(`{"line":%d,"kind":"turn","status":"rejected","code":502,"error":"downstream: worker queue full"}`)
+		_, err := driver.PutRawTurn(context.Background(), sampleRecord("req-1"))
+		Expect(err).To(MatchError(ContainSubstring("502")))
+	})
+
+	It("reports a turn the server already held as deduplicated", func() {
+		stub.setLine(`{"line":%d,"kind":"turn","status":"deduped"}`)
+		stored, err := driver.PutRawTurn(context.Background(), sampleRecord("req-1"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored).To(BeFalse())
+	})
+
+	It("refuses a record it cannot encode as invalid content", func() {
+		rec := sampleRecord("req-1")
+		rec.Meta = json.RawMessage(`["not","an","object"]`)
+		_, err := driver.PutRawTurn(context.Background(), rec)
+		Expect(err).To(MatchError(storage.ErrInvalidContent))
+		Expect(stub.received()).To(BeZero())
+	})
+
+	Context("with a batch of three", func() {
+		BeforeEach(func() {
+			var err error
+			driver, err = remote.NewDriver(remote.Config{
+				IngestURL:     server.URL,
+				BatchSize:     3,
+				FlushInterval: time.Minute,
+				Logger:        tapeslogger.NewNoop(),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(driver.Close)
+		})
+
+		// putAll forwards the turns concurrently, so they fill one batch,
+		// and returns each one's outcome by request id.
+		putAll := func(ids ...string) map[string]error {
+			var (
+				wg   sync.WaitGroup
+				mu   sync.Mutex
+				errs = map[string]error{}
+			)
+			for _, id := range ids {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := driver.PutRawTurn(context.Background(), sampleRecord(id))
+					mu.Lock()
+					errs[id] = err
+					mu.Unlock()
+				}()
+			}
+			wg.Wait()
+			return errs
+		}
+
+		It("sends the batch as one request and reports each turn its own line's result", func() {
+			stub.mu.Lock()
+			stub.line = func(n int) string {
+				if n == 2 {
+					return `{"line":%d,"kind":"turn","status":"rejected","code":502,"error":"downstream"}`
+				}
+				return `{"line":%d,"kind":"turn","status":"accepted"}`
+			}
+			stub.mu.Unlock()
+
+			errs := putAll("a", "b", "c")
+			stub.mu.Lock()
+			defer stub.mu.Unlock()
+			Expect(stub.requests).To(Equal(1))
+			Expect(stub.bodies).To(HaveLen(3))
+
+			// The line order is the batch's arrival order: find which turn
+			// rode line 2.
+			var meta struct {
+				RequestID string `json:"request_id"`
+			}
+			Expect(json.Unmarshal(stub.bodies[1]["meta"], &meta)).To(Succeed())
+			for id, err := range errs {
+				if id == meta.RequestID {
+					Expect(err).To(MatchError(ContainSubstring("502")))
+				} else {
+					Expect(err).NotTo(HaveOccurred())
+				}
+			}
+		})
+
+		It("fails the turns whose lines the response never answered", func() {
+			stub.mu.Lock()
+			stub.answer = 1
+			stub.mu.Unlock()
+
+			errs := putAll("a", "b", "c")
+			var failed int
+			for _, err := range errs {
+				if err != nil {
+					Expect(err).To(MatchError(ContainSubstring("ended before line")))
+					failed++
+				}
+			}
+			Expect(failed).To(Equal(2))
+		})
+
+		It("fails the whole batch once the server is down", func() {
+			server.Close()
+
+			for _, err := range putAll("a", "b", "c") {
+				Expect(err).To(HaveOccurred())
+			}
+			Expect(stub.received()).To(BeZero())
+		})
+	})
+
+	It("refuses reads: the raw layer lives on the ingest server", func() {
+		_, err := driver.CountRawTurns(context.Background())
+		Expect(err).To(MatchError(remote.ErrUnsupported))
+	})
+})
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// Retention actions. A rule either removes a session outright or keeps
+// its cost metadata and removes the content.
+const (
+	// RetentionDelete removes the session subtree: raw turns, their
+	// attribution corrections, the derive-queue entry, and (by cascade)
+	// the span projection.
+	RetentionDelete = "delete"
+
+	// RetentionStrip removes prompt and response content — raw request,
+	// raw response, the reduced response's message, span input/output,
+	// and trace previews — and keeps the usage and cost rollups.
+	RetentionStrip = "strip"
+)
+
+// RetentionTierStripped is the sessions.retention_tier value a strip
+// leaves behind. The deriver's write path skips stripped sessions: their
+// raw layer no longer carries the content a re-derive would need, so the
+// projection folded before the strip is the record of what happened.
+const RetentionTierStripped = "stripped"
+
+// RetentionCandidateOpts selects sessions for one retention rule.
+type RetentionCandidateOpts struct {
+	// LastSeenBefore is the age cutoff: only sessions last seen strictly
+	// before it match, so a session still receiving turns never does.
+	LastSeenBefore time.Time
+
+	// HarnessID and AuthSubject narrow the match; empty matches any.
+	HarnessID   string
+	AuthSubject string
+
+	// SkipStripped excludes sessions a strip already acted on (a strip
+	// rule sets it; a delete rule must still see them).
+	SkipStripped bool
+
+	// Limit bounds one page. Candidates come back oldest first.
+	Limit int32
+}
+
+// RetentionCandidate is one session a retention rule matched.
+type RetentionCandidate struct {
+	ID               string
+	OrgID            string
+	HarnessID        string
+	HarnessSessionID string
+	AuthSubject      string
+	LastSeenAt       time.Time
+	RetentionTier    string
+}
+
+// RetentionResult reports what one retention action touched. Counts cover
+// the whole session subtree (subagent sessions included).
+type RetentionResult struct {
+	Sessions int64 `json:"sessions"`
+	RawTurns int64 `json:"raw_turns"`
+	Spans    int64 `json:"spans"`
+	Traces   int64 `json:"traces"`
+}
+
+// RetentionStore is an optional capability for a Driver: applying
+// retention rules to the raw layer and its projection. It is the one
+// sanctioned exception to raw_turns being append-only.
+//
+// Only drivers that host the raw layer implement this (Postgres does;
+// in-memory intentionally does not). Callers MUST type-assert.
+type RetentionStore interface {
+	// ListRetentionCandidates returns sessions matching opts, oldest
+	// first, across every org.
+	ListRetentionCandidates(ctx context.Context, opts RetentionCandidateOpts) ([]RetentionCandidate, error)
+
+	// StripSessionPayloads strips the content from one session subtree
+	// and marks it RetentionTierStripped, in one transaction. Stripping
+	// an already-stripped session is a no-op. A session that no longer
+	// exists returns a zero result and no error.
+	StripSessionPayloads(ctx context.Context, orgID, id string) (RetentionResult, error)
+
+	// PurgeSession deletes one session subtree together with its raw
+	// turns, in one transaction. Unlike DeleteSession, which leaves the
+	// raw layer to re-derive from, nothing is left behind. A session
+	// that no longer exists returns a zero result and no error.
+	PurgeSession(ctx context.Context, orgID, id string) (RetentionResult, error)
+}
@@ -46,6 +46,11 @@ type SessionRecord struct {
 	// abandoned / unknown), denormalized at ingest. 'unknown' until the first
 	// turn lands or, for pre-feature rows, until the status backfill runs.
 	DerivedStatus string
+	// Outcome is how the session ended (sessions.outcome: interrupted,
+	// errored, looped, …) and OutcomeReasons the deriver's JSONB evidence
+	// for it, served verbatim. 'unknown' and nil until the session derives.
+	Outcome        string
+	OutcomeReasons json.RawMessage
 	// Model is the dominant conversation-spine model, folded at derive
 	// time (sessions.derived_model). Empty until the session derives.
 	Model string
@@ -58,14 +63,22 @@ type SessionRecord struct {
 	// KindCounts the per-call_kind span tally (sessions.tasks /
 	// sessions.kind_counts), both JSONB served verbatim on the composite
 	// traces response. Nil until the session derives.
-	Tasks         json.RawMessage
-	KindCounts    json.RawMessage
+	Tasks      json.RawMessage
+	KindCounts json.RawMessage
+	// ContextUsage is the deriver's context-window fold
+	// (sessions.context_usage): peak occupancy, compactions, cache hit
+	// ratio. Nil until the session derives.
+	ContextUsage  json.RawMessage
 	Preview       string // first user turn text, truncated; empty when unavailable
 	PreviewIsJSON bool   // full prompt was valid JSON before preview truncation
 	// AuthSubject is the gateway-stamped JWT subject (the WorkOS user id)
 	// captured at ingest. Empty for rows captured before the edge began
 	// stamping the x-paper-auth-subject header.
 	AuthSubject string
+	// RetentionTier is the retention outcome recorded on the session:
+	// empty while its content is as captured, RetentionTierStripped once
+	// a retention rule removed the payloads and kept the rollups.
+	RetentionTier string
 	// SortVal is the canonical ::text form of this row's active sort column,
 	// populated by ListSessionRecords so the API can mint an exact next cursor.
 	// Empty on records returned by point lookups.
@@ -99,6 +112,32 @@ type SessionListOpts struct {
 	Since       *time.Time
 	Until       *time.Time
 	AuthSubject string
+	// Outcome "" lists every outcome; non-empty is an exact match on
+	// sessions.outcome, except that an awaiting_user session idle past
+	// derive.AbandonAfter matches abandoned instead. OutcomeReason "" applies no reason filter;
+	// non-empty keeps the sessions with a reason of that code or, for
+	// terminal errors, that category.
+	Outcome       string
+	OutcomeReason string
+	// Annotation narrows the list to sessions carrying an annotation, on
+	// the session or any of its traces and spans, that matches every set
+	// field at once. The zero value applies no filter.
+	Annotation AnnotationMatch
+}
+
+// AnnotationMatch is the annotation filter on the sessions list. Name and
+// Label are exact matches; MinScore and MaxScore bound the score
+// inclusively and so only match scored annotations.
+type AnnotationMatch struct {
+	Name     string
+	Label    string
+	MinScore *float64
+	MaxScore *float64
+}
+
+// IsZero reports whether the match applies no filter.
+func (m AnnotationMatch) IsZero() bool {
+	return m.Name == "" && m.Label == "" && m.MinScore == nil && m.MaxScore == nil
 }
 
 // SessionSortField is the validated column a sessions-list page is ordered by.
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// SpanEmbedCursor is a position in the span change feed, as the embed
+// worker checkpoints it per model. Seq is the span row's derive_seq; the
+// key columns break ties, because one derive pass stamps every row it
+// writes with the same seq and a page can end partway through it. The
+// zero cursor (Seq -1) precedes every row, including those derived
+// before the feed existed.
+type SpanEmbedCursor struct {
+	Seq     int64
+	OrgID   string
+	TraceID string
+	SpanID  string
+}
+
+// StartOfSpanFeed is the cursor a model with no checkpoint starts from:
+// the whole projection, i.e. a backfill.
+var StartOfSpanFeed = SpanEmbedCursor{Seq: -1}
+
+// SpanEmbedCandidate is a main-conversation llm span the change feed
+// reports after a cursor. Current marks a span whose stored vector for
+// the model was embedded from the same content_hash; its Input and
+// Output are not read, and it only advances the cursor.
+type SpanEmbedCandidate struct {
+	Cursor      SpanEmbedCursor
+	ContentHash string
+	Current     bool
+	Input       json.RawMessage
+	Output      json.RawMessage
+}
+
+// SpanEmbedding is one span's vector under a model, with the
+// content_hash it was embedded from.
+type SpanEmbedding struct {
+	OrgID       string
+	TraceID     string
+	SpanID      string
+	ContentHash string
+	Vector      []float32
+}
+
+// ErrSpanEmbeddingsUnavailable reports a database without the span
+// embedding tables: their migration creates them only where the pgvector
+// extension is installed.
+var ErrSpanEmbeddingsUnavailable = errors.New("span embedding tables are missing: their migration needs the pgvector extension installed first")
+
+// SpanEmbeddingStore is the optional capability behind the embed worker:
+// vectors keyed by (org, trace, span, model), and the per-model cursor
+// over the span change feed that produced them. Only Postgres implements
+// it, and only a database with the pgvector extension can serve it.
+type SpanEmbeddingStore interface {
+	// CheckSpanEmbeddings returns ErrSpanEmbeddingsUnavailable when the
+	// tables are missing.
+	CheckSpanEmbeddings(ctx context.Context) error
+
+	// SpanEmbedCursor returns the model's checkpoint, StartOfSpanFeed
+	// when it has none.
+	SpanEmbedCursor(ctx context.Context, model string) (SpanEmbedCursor, error)
+
+	// ListSpansToEmbed returns up to limit main-conversation llm spans
+	// after the cursor in feed order. It withholds rows from derive
+	// passes still in flight, so the cursor never skips a late commit.
+	ListSpansToEmbed(ctx context.Context, model string, after SpanEmbedCursor, limit int32) ([]SpanEmbedCandidate, error)
+
+	// PutSpanEmbeddings upserts the vectors and moves the model's cursor
+	// to next in one transaction.
+	PutSpanEmbeddings(ctx context.Context, model string, rows []SpanEmbedding, next SpanEmbedCursor) error
+
+	// PruneSpanEmbeddings deletes the vectors and cursors of every model
+	// but keep, returning the vectors removed.
+	PruneSpanEmbeddings(ctx context.Context, keep string) (int64, error)
+}
+
+// SpanSearchHit is one span a semantic search matched, with the turn it
+// belongs to. Score is cosine similarity: 1 is identical.
+type SpanSearchHit struct {
+	SessionID  string
+	TraceID    string
+	SpanID     string
+	Model      string
+	Score      float64
+	StartedAt  time.Time
+	UserPrompt string
+	Input      json.RawMessage
+	Output     json.RawMessage
+}
+
+// SpanSearcher is the capability interface behind
+// /v1/search/spans?mode=semantic: the limit spans nearest vector among
+// those embedded under model, best first. authSubject narrows them the
+// way it narrows SpanStatsReader's totals.
+type SpanSearcher interface {
+	SearchSpans(ctx context.Context, orgID, model string, vector []float32, authSubject string, limit int) ([]SpanSearchHit, error)
+}
@@ -22,7 +22,12 @@ type SpanTurnRecord struct {
 	Status          string
 	// Source is the capture origin of the turn's raw rows ("wire" |
 	// "transcript"), promoted from raw_turns.source at derive time.
-	Source            string
+	Source string
+	// ParentTraceID is the trace this one follows on its branch of the
+	// session ("" for the first); Abandoned marks traces a rewind or an
+	// edited earlier message left off the session's live branch.
+	ParentTraceID     string
+	Abandoned         bool
 	StartedAt         time.Time
 	EndedAt           *time.Time
 	DurationNS        int64
@@ -35,6 +40,13 @@ type SpanTurnRecord struct {
 	CacheReadTokens     int64
 	CacheCreationTokens int64
 	TotalCostUSD        float64
+	// Context-window fold over the trace's main-thread calls: the
+	// fullest call, growth over the parent trace, and the tokens a
+	// compaction reclaimed (post-compaction traces only).
+	PeakContextTokens    int64
+	PeakContextOccupancy float64
+	ContextGrowthTokens  int64
+	ReclaimedTokens      int64
 }
 
 // SpanRecord is one observed unit of work within a trace. Input and
@@ -64,10 +76,14 @@ type SpanRecord struct {
 	// Verdict is the deriver-written security-monitor disposition JSON
 	// (null on non-permission-check spans). Served verbatim on the wire.
 	Verdict json.RawMessage
+	// Tool is the deriver-written tool identity JSON on tool spans (null
+	// elsewhere); RetryOf names the errored tool span this one retries.
+	Tool    json.RawMessage
+	RetryOf string
 }
 
 // SpanLinkRecord is a dataflow edge between spans, possibly across
-// traces (compaction seams).
+// traces (compaction seams, forks).
 type SpanLinkRecord struct {
 	FromTraceID string
 	FromSpanID  string
@@ -132,6 +148,26 @@ type SpanStats struct {
 	TotalDurationNS     int64
 	TotalCostUSD        float64
 	ToolCalls           int
+
+	// ModelLatency holds latency percentiles per model over the window's
+	// llm spans that carry a capture-side latency profile, busiest model
+	// first. Empty when no call in the window was timed.
+	ModelLatency []ModelLatency
+}
+
+// ModelLatency is one model's latency percentiles. Each is taken over
+// per-call figures (see llm.Latency); InterTokenP95NS is the 95th
+// percentile of the calls' own p95 gaps. Zero means no call measured it.
+type ModelLatency struct {
+	Model                 string
+	Calls                 int
+	TTFBP50NS             int64
+	TTFBP95NS             int64
+	TTFTP50NS             int64
+	TTFTP95NS             int64
+	InterTokenP50NS       int64
+	InterTokenP95NS       int64
+	OutputTokensPerSecP50 float64
 }
 
 // SpanStatsReader is the capability interface for span-layer stats.
@@ -145,3 +181,76 @@ type SpanStats struct {
 type SpanStatsReader interface {
 	AggregateSpanStats(ctx context.Context, orgID string, since, until *time.Time, authSubject string) (SpanStats, error)
 }
+
+// FileTouchRecord is one trace's activity on one file: how often its tool
+// calls read, created, edited and deleted the file, and the lines the
+// edits added and removed. SessionID is the Tapes session UUID.
+type FileTouchRecord struct {
+	SessionID    string
+	TraceID      string
+	Path         string
+	Reads        int
+	Creates      int
+	Edits        int
+	Deletes      int
+	LinesAdded   int
+	LinesRemoved int
+	FirstAt      time.Time
+	LastAt       time.Time
+}
+
+// FileTouchReader is the capability interface behind /v1/files: every
+// trace that touched a file, newest activity first, at most limit rows.
+// A relative path matches the absolute paths ending with it. authSubject
+// narrows the rows the way it narrows SpanStatsReader's totals.
+type FileTouchReader interface {
+	ListFileTouches(ctx context.Context, orgID, path, authSubject string, limit int) ([]FileTouchRecord, error)
+}
+
+// GitArtifactRecord is a commit, push or pull request one of a trace's
+// shell calls produced. SHA is as git printed it, usually abbreviated;
+// URL is the pushed-to remote for a push and the page for a pull request.
+type GitArtifactRecord struct {
+	SessionID string
+	TraceID   string
+	SpanID    string
+	Kind      string
+	SHA       string
+	Branch    string
+	URL       string
+	Subject   string
+	At        time.Time
+}
+
+// CommitSessionReader is the capability interface behind
+// /v1/commits/{sha}/sessions. ListCommitSessions returns the artifacts of
+// at most limit sessions that committed or pushed the sha, every artifact
+// of each session included, so the caller sees the branches and pull
+// requests around the commit too. sha matches abbreviated shas either way.
+// authSubject narrows the sessions the way it narrows SpanStatsReader's
+// totals.
+type CommitSessionReader interface {
+	ListCommitSessions(ctx context.Context, orgID, sha, authSubject string, limit int) ([]GitArtifactRecord, error)
+}
+
+// ToolStats is one tool identity's execution figures over a window: the
+// tool, the MCP server providing it, and for shell tools the program run.
+// Duration percentiles cover the calls whose result arrived; zero means
+// none did. Retries counts calls repeating an errored call's input.
+type ToolStats struct {
+	Tool          string
+	Server        string
+	Command       string
+	Calls         int
+	Errors        int
+	Retries       int
+	DurationP50NS int64
+	DurationP95NS int64
+}
+
+// ToolStatsReader is the capability interface for tool analytics,
+// behind /v1/tools. Rows come busiest tool first. authSubject narrows
+// them the way it narrows SpanStatsReader's totals.
+type ToolStatsReader interface {
+	AggregateToolStats(ctx context.Context, orgID string, since, until *time.Time, authSubject string) ([]ToolStats, error)
+}
@@ -107,6 +107,13 @@ func (d *DocBuilder) OptionalJSONBody(description string, schema *oas.Schema) *D
 	return d
 }
 
+// ContentBody documents a required request body with an explicit media type.
+func (d *DocBuilder) ContentBody(description, mediaType string, schema *oas.Schema) *DocBuilder {
+	d.builder.RequestBody(description, true, oas.Content(mediaType, schema))
+
+	return d
+}
+
 // JSONResponse documents an application/json outcome.
 func (d *DocBuilder) JSONResponse(status int, description string, schema *oas.Schema) *DocBuilder {
 	d.builder.JSONResponse(status, description, schema)
@@ -142,13 +149,24 @@ func (d *DocBuilder) Security(requirement oas.SecurityRequirement) *DocBuilder {
 	return d
 }
 
-// build finalizes the operation, applying router-level tags.
-func (d *DocBuilder) build(routerTags []string) *oas.Operation {
+// build finalizes the operation, applying router-level tags and, where the
+// doc states none of its own, the router-level security requirements.
+func (d *DocBuilder) build(routerTags []string, routerSecurity []oas.SecurityRequirement) *oas.Operation {
 	if len(routerTags) > 0 {
 		d.builder.Tag(routerTags...)
 	}
+	operation := d.builder.Build()
+	if len(operation.Security) == 0 {
+		for _, requirement := range routerSecurity {
+			copied := make(oas.SecurityRequirement, len(requirement))
+			for name, scopes := range requirement {
+				copied[name] = append([]string(nil), scopes...)
+			}
+			operation.Security = append(operation.Security, copied)
+		}
+	}
 
-	return d.builder.Build()
+	return operation
 }
 
 // Method reports the method a [DocFor] doc is bound to, empty for a plain
@@ -66,6 +66,8 @@ type routerOptions struct {
 	prefix       string
 	tags         []string
 	skipPaths    map[string]struct{}
+	security     []oas.SecurityRequirement
+	guards       []fiber.Handler
 }
 
 // WithUndocumented sets the policy for routes registered without a Doc.
@@ -166,10 +168,34 @@ func (r *Router) Group(prefix string, handlers ...fiber.Handler) *Router {
 			prefix:       r.options.prefix + strings.TrimSuffix(prefix, "/"),
 			tags:         append([]string(nil), r.options.tags...),
 			skipPaths:    r.options.skipPaths,
+			security:     r.options.security,
+			guards:       r.options.guards,
 		},
 	}
 }
 
+// Secured returns a Router that registers where r does, but runs guard ahead
+// of every route it mounts and publishes requirement on every operation it
+// describes — the enforcement and its description, attached by one call.
+//
+// The guard is prepended per route rather than mounted with Use, because
+// Fiber's Use matches by prefix: a guard on a prefix-less group would run
+// ahead of every route registered on the app after it, secured or not. A nil
+// guard publishes the requirement without enforcing it, for a server whose
+// enforcement is switched off but whose contract must not change with it.
+//
+// A Doc that states its own Security keeps it; the router's requirement is
+// the default, not an override.
+func (r *Router) Secured(requirement oas.SecurityRequirement, guard fiber.Handler) *Router {
+	secured := r.Group("")
+	secured.options.security = append(append([]oas.SecurityRequirement(nil), r.options.security...), requirement)
+	if guard != nil {
+		secured.options.guards = append(append([]fiber.Handler(nil), r.options.guards...), guard)
+	}
+
+	return secured
+}
+
 // Use registers middleware. Middleware describes no operation, so nothing is
 // contributed to the parser.
 func (r *Router) Use(args ...any) *Router {
@@ -224,7 +250,7 @@ func (r *Router) Options(path string, handler fiber.Handler, doc ...*DocBuilder)
 // generator operations that 405.
 func (r *Router) All(path string, handler fiber.Handler, docs ...*DocBuilder) *Router {
 	location := callerLocation()
-	r.router.All(path, handler)
+	r.router.All(path, r.chain(handler)...)
 	for _, doc := range docs {
 		if doc == nil {
 			continue
@@ -245,12 +271,21 @@ func (r *Router) Add(method, path string, doc *DocBuilder, handlers ...fiber.Han
 func (r *Router) add(
 	method, path string, doc *DocBuilder, location string, handlers ...fiber.Handler,
 ) *Router {
-	r.router.Add(method, path, handlers...)
+	r.router.Add(method, path, r.chain(handlers...)...)
 	r.describe(method, path, doc, location)
 
 	return r
 }
 
+// chain prepends this router's guards to a route's handlers.
+func (r *Router) chain(handlers ...fiber.Handler) []fiber.Handler {
+	if len(r.options.guards) == 0 {
+		return handlers
+	}
+
+	return append(append([]fiber.Handler(nil), r.options.guards...), handlers...)
+}
+
 // Static mounts a static file handler. Static assets are not API surface, so
 // nothing is contributed.
 func (r *Router) Static(prefix, root string, config ...fiber.Static) *Router {
@@ -305,7 +340,7 @@ func (r *Router) describe(method, routePath string, doc *DocBuilder, location st
 			EmptyResponse(200, "undocumented response")
 	}
 
-	operation := doc.build(r.options.tags)
+	operation := doc.build(r.options.tags, r.options.security)
 	if err := r.parser.AddOperation(method, openAPIPath, operation, provenance); err != nil {
 		r.fail(fmt.Errorf("%s: %w", provenance.Name, err))
 	}
@@ -168,6 +168,46 @@ var _ = Describe("Wrap", func() {
 		Expect(operation["tags"]).To(ConsistOf("things", "v1"))
 	})
 
+	It("guards and describes the routes of a secured router, and only those", func() {
+		Expect(parser.AddSecurityScheme("bearerAuth", &oas.SecurityScheme{Type: "http", Scheme: "bearer"},
+			oas.Provenance{})).To(Succeed())
+		deny := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusUnauthorized) }
+		secured := router.Secured(oas.SecurityRequirement{"bearerAuth": {"read"}}, deny)
+		secured.Get("/v1/secret", noop, oasfiber.Doc("secret"))
+		router.Get("/v1/open", noop, oasfiber.Doc("open"))
+		Expect(router.Err()).NotTo(HaveOccurred())
+
+		response, err := app.Test(httpGet("/v1/secret"), -1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.StatusCode).To(Equal(fiber.StatusUnauthorized))
+
+		// The guard is per route: a route registered after the secured group,
+		// outside it, is not behind it.
+		response, err = app.Test(httpGet("/v1/open"), -1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.StatusCode).To(Equal(fiber.StatusNoContent))
+
+		tree := paths(compile(oas.WithLint()))
+		secret := tree["/v1/secret"].(map[string]any)["get"].(map[string]any)
+		Expect(secret["security"]).To(Equal([]any{map[string]any{"bearerAuth": []any{"read"}}}))
+		open := tree["/v1/open"].(map[string]any)["get"].(map[string]any)
+		Expect(open).NotTo(HaveKey("security"))
+	})
+
+	It("publishes a secured router's requirement even without a guard", func() {
+		Expect(parser.AddSecurityScheme("bearerAuth", &oas.SecurityScheme{Type: "http", Scheme: "bearer"},
+			oas.Provenance{})).To(Succeed())
+		secured := router.Secured(oas.SecurityRequirement{"bearerAuth": {"admin"}}, nil)
+		secured.Post("/v1/admin/run", noop, oasfiber.Doc("run"))
+
+		response, err := app.Test(httpRequest(fiber.MethodPost, "/v1/admin/run"), -1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.StatusCode).To(Equal(fiber.StatusNoContent))
+
+		operation := paths(compile(oas.WithLint()))["/v1/admin/run"].(map[string]any)["post"].(map[string]any)
+		Expect(operation).To(HaveKey("security"))
+	})
+
 	It("names the registration site in a conflict", func() {
 		router.Get("/v1/dup", noop, oasfiber.Doc("first"))
 		router.Get("/v1/dup", noop, oasfiber.Doc("second"))
@@ -278,7 +318,11 @@ var _ = Describe("Server", func() {
 })
 
 func httpGet(target string) *http.Request {
-	request, err := http.NewRequestWithContext(context.Background(), http.MethodGet, target, nil)
+	return httpRequest(http.MethodGet, target)
+}
+
+func httpRequest(method, target string) *http.Request {
+	request, err := http.NewRequestWithContext(context.Background(), method, target, nil)
 	if err != nil {
 		panic(err)
 	}
@@ -94,7 +94,7 @@ func Scan(app *fiber.App, parser *oas.Parser, options ...ScanOption) ([]string,
 		if len(resolved.tags) > 0 {
 			doc.Tag(resolved.tags...)
 		}
-		operation := doc.build(nil)
+		operation := doc.build(nil, nil)
 		provenance := oas.Provenance{
 			Kind:   oas.KindRoute,
 			Name:   method + " " + openAPIPath,
@@ -249,6 +249,22 @@ func (p *Parser) AddComponentSchema(name string, schema *Schema, provenance Prov
 	})
 }
 
+// AddSecurityScheme registers one security scheme under a bare component
+// name, for operations' security requirements to name.
+func (p *Parser) AddSecurityScheme(name string, scheme *SecurityScheme, provenance Provenance) error {
+	if provenance.Kind == "" {
+		provenance.Kind = KindManual
+	}
+	if provenance.Name == "" {
+		provenance.Name = "securitySchemes/" + name
+	}
+
+	return p.AddFragment(Fragment{
+		Provenance: provenance,
+		Components: &Components{SecuritySchemes: map[string]*SecurityScheme{name: scheme}},
+	})
+}
+
 // Fragments returns a snapshot of what the parser holds, for inspection and
 // tests. The returned fragments are copies.
 func (p *Parser) Fragments() []Fragment {
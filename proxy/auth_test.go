@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	tapeslogger "github.com/papercomputeco/tapes/pkg/logger"
+	"github.com/papercomputeco/tapes/pkg/oidc"
+	"github.com/papercomputeco/tapes/pkg/oidc/oidctest"
+	"github.com/papercomputeco/tapes/pkg/sessions"
+	"github.com/papercomputeco/tapes/proxy/header"
+)
+
+var _ = Describe("In-process identity verification", func() {
+	const reqBody = `{"model":"claude-3-5-sonnet-20241022","max_tokens":64,"stream":false,"messages":[{"role":"user","content":"hi"}]}`
+
+	var (
+		p        *Proxy
+		driver   *captureDriver
+		issuer   *oidctest.Issuer
+		upstream *httptest.Server
+		calls    atomic.Int64
+		seen     atomic.Value
+	)
+
+	BeforeEach(func() {
+		calls.Store(0)
+		seen.Store("")
+		upstream = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			seen.Store(r.Header.Get(header.IdentityHeader))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude-3-5-sonnet-20241022",`+
+				`"content":[{"type":"text","text":"hello"}],"stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":1}}`)
+		}))
+		DeferCleanup(upstream.Close)
+
+		issuer = oidctest.NewIssuer()
+		DeferCleanup(issuer.Close)
+		verifier, err := oidc.NewVerifier(oidc.Config{JWKSURL: issuer.JWKSURL()})
+		Expect(err).NotTo(HaveOccurred())
+
+		driver = newCaptureDriver()
+		p, err = New(Config{
+			ListenAddr:   ":0",
+			UpstreamURL:  upstream.URL,
+			ProviderType: "anthropic",
+			Verifier:     verifier,
+		}, driver, tapeslogger.NewNoop())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		if p != nil {
+			p.Close()
+		}
+	})
+
+	send := func(identity string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(reqBody))
+		if identity != "" {
+			req.Header.Set(header.IdentityHeader, identity)
+		}
+		resp, err := p.server.Test(req, -1)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(resp.Body.Close)
+		return resp
+	}
+
+	It("refuses an unverified request before it reaches the provider", func() {
+		resp := send("")
+		Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+
+		resp = send("not-a-jwt")
+		Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+		Expect(calls.Load()).To(BeZero())
+	})
+
+	It("stamps the verified subject on the capture and keeps the token from the provider", func() {
+		resp := send("Bearer " + issuer.Token(map[string]any{"sub": "user-42"}))
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(seen.Load()).To(Equal(""))
+
+		p.Close()
+		p = nil
+
+		raws := driver.RawTurns()
+		Expect(raws).To(HaveLen(1))
+		var envelope sessions.IngestEnvelope
+		Expect(json.Unmarshal(raws[0].SessionEnvelope, &envelope)).To(Succeed())
+		Expect(envelope.AuthSubject).To(Equal("user-42"))
+	})
+
+	It("stamps the verified org on the capture in multi-tenant mode", func() {
+		p.Close()
+		verifier, err := oidc.NewVerifier(oidc.Config{JWKSURL: issuer.JWKSURL(), OrgClaim: "org_id"})
+		Expect(err).NotTo(HaveOccurred())
+		p, err = New(Config{
+			ListenAddr:   ":0",
+			UpstreamURL:  upstream.URL,
+			ProviderType: "anthropic",
+			Verifier:     verifier,
+			MultiTenant:  true,
+		}, driver, tapeslogger.NewNoop())
+		Expect(err).NotTo(HaveOccurred())
+
+		const acme = "11111111-1111-1111-1111-111111111111"
+		resp := send("Bearer " + issuer.Token(map[string]any{"sub": "user-42", "org_id": acme}))
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		p.Close()
+		p = nil
+
+		raws := driver.RawTurns()
+		Expect(raws).To(HaveLen(1))
+		Expect(raws[0].OrgID).To(Equal(acme))
+	})
+})
@@ -1,5 +1,7 @@
 package proxy
 
+import "github.com/papercomputeco/tapes/pkg/oidc"
+
 // Config is the proxy server configuration.
 type Config struct {
 	// ListenAddr is the address to listen on (e.g., ":8080")
@@ -26,6 +28,25 @@ type Config struct {
 	// default. Exposed so the byte budget can be tuned (and exercised in
 	// tests) rather than hard-wired to the default.
 	QueueByteBudget int64
+
+	// SpoolDir, when set, write-ahead spools every capture to that
+	// directory before it is queued, so a proxy restart or a store outage
+	// never loses a turn. Empty keeps the capture queue in memory.
+	SpoolDir string
+
+	// CaptureWorkers is the number of capture workers persisting turns.
+	// Zero selects the worker pool's default. Remote capture raises it so
+	// its batches can fill.
+	CaptureWorkers uint
+
+	// Verifier, when set, requires a JWT in the X-Tapes-Identity header on
+	// every proxied request and stamps its verified subject as the captured
+	// session's auth_subject.
+	Verifier *oidc.Verifier
+
+	// MultiTenant stamps the verified org onto each capture, so the turn
+	// lands in the caller's tenant instead of the single-tenant sentinel.
+	MultiTenant bool
 }
 
 // AgentRoute defines proxy routing for a specific agent.
@@ -26,6 +26,11 @@ func NewHandler() *Handler {
 // AgentNameHeader is the optional header used to tag agent requests.
 const AgentNameHeader = "X-Tapes-Agent-Name"
 
+// IdentityHeader carries the caller's JWT when the proxy verifies identity
+// in process. It is separate from Authorization because that header belongs
+// to the upstream provider: agents bring their own provider credentials.
+const IdentityHeader = "X-Tapes-Identity"
+
 // ThreadIDHeaders maps each harness's native sub-thread header onto the
 // capture-side thread id. A harness that runs subagents fires their API calls
 // with a per-thread identifier — Claude Code stamps x-claude-code-agent-id on
@@ -124,6 +129,9 @@ var skipRequest = map[string]struct{}{
 
 	// Internal agent routing header.
 	AgentNameHeader: {},
+
+	// The caller's tapes identity token is for the proxy, never the provider.
+	IdentityHeader: {},
 }
 
 // skipResponse is the set of upstream response headers (client <-- proxy <-- upstream)
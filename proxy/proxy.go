@@ -22,6 +22,7 @@ import (
 	"github.com/papercomputeco/tapes/pkg/capture"
 	"github.com/papercomputeco/tapes/pkg/llm"
 	"github.com/papercomputeco/tapes/pkg/llm/provider"
+	"github.com/papercomputeco/tapes/pkg/oidc"
 	"github.com/papercomputeco/tapes/pkg/sessions"
 	"github.com/papercomputeco/tapes/pkg/sse"
 	"github.com/papercomputeco/tapes/pkg/storage"
@@ -97,11 +98,20 @@ func New(config Config, driver storage.Driver, log *slog.Logger) (*Proxy, error)
 	// Add compression middleware to handle responses
 	app.Use(compress.New())
 
+	// Identity is checked before anything is forwarded: an unverified
+	// request never reaches the provider, so it cannot spend on the
+	// provider credentials it carries under someone else's name.
+	if config.Verifier != nil {
+		app.Use(oidc.Middleware(config.Verifier, header.IdentityHeader, log))
+	}
+
 	wp, err := worker.NewPool(&worker.Config{
 		Driver:          driver,
 		Project:         config.Project,
 		Logger:          log,
 		QueueByteBudget: config.QueueByteBudget,
+		SpoolDir:        config.SpoolDir,
+		NumWorkers:      config.CaptureWorkers,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("could not create worker pool: %w", err)
@@ -168,6 +178,7 @@ func (p *Proxy) handleProxy(c *fiber.Ctx) error {
 	// carried down the handler chain alongside agentName: the enqueue sites are
 	// reached from goroutines that outlive the fiber context.
 	threadID := header.ThreadID(c)
+	owner := p.captureOwner(c)
 	prov, upstreamURL := p.resolveProvider(agentName, providerName, path)
 	method := c.Method()
 
@@ -234,10 +245,10 @@ func (p *Proxy) handleProxy(c *fiber.Ctx) error {
 	}
 
 	if streaming && isChatRequest {
-		return p.handleStreamingProxy(c, path, upstreamURL, prov, agentName, threadID, body, parsedReq, startTime)
+		return p.handleStreamingProxy(c, path, upstreamURL, prov, agentName, threadID, owner, body, parsedReq, startTime)
 	}
 
-	return p.handleNonStreamingProxy(c, path, method, upstreamURL, prov, agentName, threadID, body, parsedReq, startTime)
+	return p.handleNonStreamingProxy(c, path, method, upstreamURL, prov, agentName, threadID, owner, body, parsedReq, startTime)
 }
 
 // handleNonStreamingProxy handles non-streaming requests.
@@ -251,8 +262,37 @@ func (p *Proxy) handleProxy(c *fiber.Ctx) error {
 // spans. Without a non-nil envelope the turn lands as raw capture with
 // no sessions row, and the derive attributes its spans to NULL (the
 // turn never surfaces in the deck).
-func localCaptureSession() *sessions.IngestEnvelope {
-	return &sessions.IngestEnvelope{}
+//
+// owner is who the turn is captured for; see captureOwner.
+func localCaptureSession(owner captureOwner) *sessions.IngestEnvelope {
+	return &sessions.IngestEnvelope{AuthSubject: owner.subject, OrgID: owner.orgID}
+}
+
+// captureOwner is who a captured turn belongs to. It is resolved from the
+// inbound request and carried down the handler chain, whose enqueue sites
+// outlive the fiber context.
+type captureOwner struct {
+	// subject is the caller's verified subject when the proxy verifies
+	// identity, and empty otherwise.
+	subject string
+
+	// orgID is the caller's verified org in a multi-tenant deployment, and
+	// empty (the single-tenant sentinel) otherwise.
+	orgID string
+}
+
+// captureOwner resolves the owner of the request's turn.
+func (p *Proxy) captureOwner(c *fiber.Ctx) captureOwner {
+	identity := oidc.FromCtx(c)
+	if identity == nil {
+		return captureOwner{}
+	}
+	owner := captureOwner{subject: identity.Subject}
+	if p.config.MultiTenant {
+		owner.orgID = identity.OrgID
+	}
+
+	return owner
 }
 
 // responseWeight is the byte-budget contribution of the reduced response a
@@ -286,7 +326,7 @@ func captureWeight(rawRequestLen, responseBytes int) int {
 	return 2*rawRequestLen + responseBytes
 }
 
-func (p *Proxy) handleNonStreamingProxy(c *fiber.Ctx, path, method, upstreamURL string, prov provider.Provider, agentName, threadID string, body []byte, parsedReq *llm.ChatRequest, startTime time.Time) error {
+func (p *Proxy) handleNonStreamingProxy(c *fiber.Ctx, path, method, upstreamURL string, prov provider.Provider, agentName, threadID string, owner captureOwner, body []byte, parsedReq *llm.ChatRequest, startTime time.Time) error {
 	// Build upstream URL
 	upstreamURL += path
 
@@ -310,12 +350,14 @@ func (p *Proxy) handleNonStreamingProxy(c *fiber.Ctx, path, method, upstreamURL
 	)
 
 	// Make the request
+	latency := capture.NewLatencyObserver(startTime)
 	httpResp, err := p.httpClient.Do(httpReq)
 	if err != nil {
 		p.logger.Error("upstream request failed", "error", err)
 		return c.Status(fiber.StatusBadGateway).JSON(llm.ErrorResponse{Error: "upstream request failed"})
 	}
 	defer httpResp.Body.Close()
+	latency.FirstByte()
 
 	// Read response body
 	respBody, err := io.ReadAll(httpResp.Body)
@@ -359,7 +401,10 @@ func (p *Proxy) handleNonStreamingProxy(c *fiber.Ctx, path, method, upstreamURL
 				// proxy. Omitting the weight (the earlier bug) let proxy
 				// captures bypass the budget the ingest path already respects.
 				Weight:  captureWeight(len(body), len(respBody)),
-				Session: localCaptureSession(),
+				Session: localCaptureSession(owner),
+				// A buffered response arrives whole, so only the wait
+				// for its first byte is measurable.
+				Latency: latency.Latency(0),
 			})
 		}
 	}
@@ -369,7 +414,7 @@ func (p *Proxy) handleNonStreamingProxy(c *fiber.Ctx, path, method, upstreamURL
 }
 
 // handleStreamingProxy handles streaming requests.
-func (p *Proxy) handleStreamingProxy(c *fiber.Ctx, path, upstreamURL string, prov provider.Provider, agentName, threadID string, body []byte, parsedReq *llm.ChatRequest, startTime time.Time) error {
+func (p *Proxy) handleStreamingProxy(c *fiber.Ctx, path, upstreamURL string, prov provider.Provider, agentName, threadID string, owner captureOwner, body []byte, parsedReq *llm.ChatRequest, startTime time.Time) error {
 	// Build upstream URL
 	upstreamURL += path
 
@@ -390,11 +435,13 @@ func (p *Proxy) handleStreamingProxy(c *fiber.Ctx, path, upstreamURL string, pro
 	)
 
 	// Make the request
+	latency := capture.NewLatencyObserver(startTime)
 	httpResp, err := p.httpClient.Do(httpReq)
 	if err != nil {
 		p.logger.Error("upstream request failed", "error", err)
 		return c.Status(fiber.StatusBadGateway).JSON(llm.ErrorResponse{Error: "upstream request failed"})
 	}
+	latency.FirstByte()
 	if httpResp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(httpResp.Body)
 		httpResp.Body.Close()
@@ -418,7 +465,7 @@ func (p *Proxy) handleStreamingProxy(c *fiber.Ctx, path, upstreamURL string, pro
 	// every chunk. This gives direct backpressure and true per-chunk streaming
 	// for LLM based.
 	pr, pw := io.Pipe()
-	go p.handleHTTPRespToPipeWriter(httpResp, pw, parsedReq, prov, agentName, threadID, body, startTime)
+	go p.handleHTTPRespToPipeWriter(httpResp, pw, parsedReq, prov, agentName, threadID, owner, body, startTime, latency)
 
 	// Set the pipe reader as the body stream with unknown size (-1),
 	// which triggers chunked transfer encoding in fasthttp.
@@ -427,16 +474,16 @@ func (p *Proxy) handleStreamingProxy(c *fiber.Ctx, path, upstreamURL string, pro
 	return nil
 }
 
-func (p *Proxy) handleHTTPRespToPipeWriter(httpResp *http.Response, pw *io.PipeWriter, parsedReq *llm.ChatRequest, prov provider.Provider, agentName, threadID string, rawRequest []byte, startTime time.Time) {
+func (p *Proxy) handleHTTPRespToPipeWriter(httpResp *http.Response, pw *io.PipeWriter, parsedReq *llm.ChatRequest, prov provider.Provider, agentName, threadID string, owner captureOwner, rawRequest []byte, startTime time.Time, latency *capture.LatencyObserver) {
 	// Close the upstream response body once streaming is complete.
 	defer httpResp.Body.Close()
 	defer pw.Close()
 
 	switch ct := httpResp.Header.Get("Content-Type"); {
 	case strings.HasPrefix(ct, "text/event-stream"):
-		p.handleSSEStream(httpResp, pw, parsedReq, prov, agentName, threadID, rawRequest, startTime)
+		p.handleSSEStream(httpResp, pw, parsedReq, prov, agentName, threadID, owner, rawRequest, startTime, latency)
 	default:
-		p.handleNDJSONStream(httpResp, pw, parsedReq, prov, agentName, threadID, rawRequest, startTime)
+		p.handleNDJSONStream(httpResp, pw, parsedReq, prov, agentName, threadID, owner, rawRequest, startTime, latency)
 	}
 }
 
@@ -447,12 +494,12 @@ func (p *Proxy) handleHTTPRespToPipeWriter(httpResp *http.Response, pw *io.PipeW
 // Providers with a reducer in p.reducers go through capture for a canonical
 // reduction; everything else falls back to the in-proxy extraction helpers
 // until it migrates into the shared library.
-func (p *Proxy) handleSSEStream(httpResp *http.Response, pw *io.PipeWriter, parsedReq *llm.ChatRequest, prov provider.Provider, agentName, threadID string, rawRequest []byte, startTime time.Time) {
+func (p *Proxy) handleSSEStream(httpResp *http.Response, pw *io.PipeWriter, parsedReq *llm.ChatRequest, prov provider.Provider, agentName, threadID string, owner captureOwner, rawRequest []byte, startTime time.Time, latency *capture.LatencyObserver) {
 	if r, ok := p.reducers[prov.Name()]; ok {
-		p.handleSSEStreamViaCapture(r, httpResp, pw, parsedReq, prov, agentName, threadID, rawRequest, startTime)
+		p.handleSSEStreamViaCapture(r, httpResp, pw, parsedReq, prov, agentName, threadID, owner, rawRequest, startTime, latency)
 		return
 	}
-	p.handleSSEStreamLegacy(httpResp, pw, parsedReq, prov, agentName, threadID, rawRequest, startTime)
+	p.handleSSEStreamLegacy(httpResp, pw, parsedReq, prov, agentName, threadID, owner, rawRequest, startTime, latency)
 }
 
 // handleSSEStreamViaCapture forwards chunks to the client while teeing the
@@ -462,8 +509,10 @@ func (p *Proxy) handleSSEStream(httpResp *http.Response, pw *io.PipeWriter, pars
 // the reducer for event parsing. We stream directly into Reduce rather
 // than materializing the full body into an intermediate []byte — on a
 // large response that would double the resident memory for no gain.
-func (p *Proxy) handleSSEStreamViaCapture(r capture.Reducer, httpResp *http.Response, pw *io.PipeWriter, parsedReq *llm.ChatRequest, prov provider.Provider, agentName, threadID string, rawRequest []byte, startTime time.Time) {
-	reader := io.TeeReader(httpResp.Body, pw)
+func (p *Proxy) handleSSEStreamViaCapture(r capture.Reducer, httpResp *http.Response, pw *io.PipeWriter, parsedReq *llm.ChatRequest, prov provider.Provider, agentName, threadID string, owner captureOwner, rawRequest []byte, startTime time.Time, latency *capture.LatencyObserver) {
+	// The observer sits ahead of the client on the tee so a frame is
+	// timed on arrival, not after the client has drained it.
+	reader := io.TeeReader(httpResp.Body, io.MultiWriter(latency, pw))
 
 	resp, err := r.Reduce(
 		context.Background(),
@@ -511,19 +560,20 @@ func (p *Proxy) handleSSEStreamViaCapture(r capture.Reducer, httpResp *http.Resp
 		// raw response buffer, so the reduced form is measured via
 		// responseWeight.
 		Weight:  captureWeight(len(rawRequest), responseWeight(resp)),
-		Session: localCaptureSession(),
+		Session: localCaptureSession(owner),
+		Latency: latency.Latency(resp.Usage.CompletionTokens),
 	})
 }
 
 // handleSSEStreamLegacy preserves the pre-capture path for providers that
 // have not yet migrated into pkg/capture.
-func (p *Proxy) handleSSEStreamLegacy(httpResp *http.Response, pw *io.PipeWriter, parsedReq *llm.ChatRequest, prov provider.Provider, agentName, threadID string, rawRequest []byte, startTime time.Time) {
+func (p *Proxy) handleSSEStreamLegacy(httpResp *http.Response, pw *io.PipeWriter, parsedReq *llm.ChatRequest, prov provider.Provider, agentName, threadID string, owner captureOwner, rawRequest []byte, startTime time.Time, latency *capture.LatencyObserver) {
 	var allChunks [][]byte
 	var fullContent strings.Builder
 	var streamUsage llm.Usage
 	var meta streamMeta
 
-	tr := sse.NewTeeReader(httpResp.Body, pw)
+	tr := sse.NewTeeReader(httpResp.Body, io.MultiWriter(latency, pw))
 
 	for {
 		ev, err := tr.Next()
@@ -551,19 +601,19 @@ func (p *Proxy) handleSSEStreamLegacy(httpResp *http.Response, pw *io.PipeWriter
 		p.extractUsageFromSSE([]byte(ev.Data), prov.Name(), &streamUsage, &meta)
 	}
 
-	p.enqueueStreamedResponse(allChunks, fullContent.String(), &streamUsage, &meta, parsedReq, prov, agentName, threadID, rawRequest, startTime)
+	p.enqueueStreamedResponse(allChunks, fullContent.String(), &streamUsage, &meta, parsedReq, prov, agentName, threadID, owner, rawRequest, startTime, latency)
 }
 
 // handleNDJSONStream reads a newline-delimited JSON upstream response (used by
 // Ollama), forwarding raw bytes to the pipe writer while accumulating chunks
 // for telemetry.
-func (p *Proxy) handleNDJSONStream(httpResp *http.Response, pw *io.PipeWriter, parsedReq *llm.ChatRequest, prov provider.Provider, agentName, threadID string, rawRequest []byte, startTime time.Time) {
+func (p *Proxy) handleNDJSONStream(httpResp *http.Response, pw *io.PipeWriter, parsedReq *llm.ChatRequest, prov provider.Provider, agentName, threadID string, owner captureOwner, rawRequest []byte, startTime time.Time, latency *capture.LatencyObserver) {
 	var allChunks [][]byte
 	var fullContent strings.Builder
 	var streamUsage llm.Usage
 	var meta streamMeta
 
-	scanner := bufio.NewScanner(httpResp.Body)
+	scanner := bufio.NewScanner(io.TeeReader(httpResp.Body, latency))
 	// Increase buffer size for large chunks
 	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
 
@@ -601,7 +651,7 @@ func (p *Proxy) handleNDJSONStream(httpResp *http.Response, pw *io.PipeWriter, p
 		p.logger.Error("error reading NDJSON stream", "error", err)
 	}
 
-	p.enqueueStreamedResponse(allChunks, fullContent.String(), &streamUsage, &meta, parsedReq, prov, agentName, threadID, rawRequest, startTime)
+	p.enqueueStreamedResponse(allChunks, fullContent.String(), &streamUsage, &meta, parsedReq, prov, agentName, threadID, owner, rawRequest, startTime, latency)
 }
 
 // extractContentFromJSON performs best-effort content extraction from a JSON
@@ -662,6 +712,15 @@ func (p *Proxy) extractUsageFromSSE(data []byte, providerName string, usage *llm
 		if u, ok := chunkData["usage"].(map[string]any); ok {
 			usage.PromptTokens = jsonInt(u, "prompt_tokens")
 			usage.CompletionTokens = jsonInt(u, "completion_tokens")
+			if details, ok := u["prompt_tokens_details"].(map[string]any); ok {
+				usage.CacheReadInputTokens = jsonInt(details, "cached_tokens")
+			}
+			if details, ok := u["completion_tokens_details"].(map[string]any); ok {
+				usage.ReasoningTokens = jsonInt(details, "reasoning_tokens")
+			}
+		}
+		if tier, ok := chunkData["service_tier"].(string); ok {
+			usage.ServiceTier = tier
 		}
 	case providerOllama:
 		// Ollama includes usage in the final NDJSON line (done=true)
@@ -682,7 +741,7 @@ func jsonInt(m map[string]any, key string) int {
 
 // enqueueStreamedResponse handles post-stream telemetry: logging and
 // enqueuing the reconstructed response for async storage.
-func (p *Proxy) enqueueStreamedResponse(allChunks [][]byte, fullContent string, streamUsage *llm.Usage, meta *streamMeta, parsedReq *llm.ChatRequest, prov provider.Provider, agentName, threadID string, rawRequest []byte, startTime time.Time) {
+func (p *Proxy) enqueueStreamedResponse(allChunks [][]byte, fullContent string, streamUsage *llm.Usage, meta *streamMeta, parsedReq *llm.ChatRequest, prov provider.Provider, agentName, threadID string, owner captureOwner, rawRequest []byte, startTime time.Time, latency *capture.LatencyObserver) {
 	if parsedReq != nil && len(allChunks) > 0 {
 		p.logger.Debug("streaming complete",
 			"content_preview", fullContent,
@@ -713,7 +772,8 @@ func (p *Proxy) enqueueStreamedResponse(allChunks [][]byte, fullContent string,
 				Resp:       finalResp,
 				RawRequest: rawRequest,
 				Weight:     captureWeight(len(rawRequest), respBytes),
-				Session:    localCaptureSession(),
+				Session:    localCaptureSession(owner),
+				Latency:    latency.Latency(finalResp.Usage.CompletionTokens),
 			})
 		}
 	}
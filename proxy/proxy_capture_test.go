@@ -1,6 +1,7 @@
 package proxy
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/papercomputeco/tapes/pkg/llm"
 	tapeslogger "github.com/papercomputeco/tapes/pkg/logger"
 )
 
@@ -128,6 +130,29 @@ var _ = Describe("Anthropic streaming proxy (capture-backed)", func() {
 		Expect(string(raws[0].Meta)).To(ContainSubstring(`"thread_id":"agent_sub_7"`))
 	})
 
+	It("records the streamed call's latency profile in the raw turn meta", func() {
+		reqBody := `{"model":"claude-3-5-sonnet-20241022","max_tokens":64,"stream":true,"messages":[{"role":"user","content":"hi"}]}`
+
+		resp, err := p.server.Test(httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(reqBody)), -1)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		_, err = io.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+
+		p.Close()
+		p = nil
+
+		raws := driver.RawTurns()
+		Expect(raws).To(HaveLen(1))
+		var meta struct {
+			Latency *llm.Latency `json:"latency"`
+		}
+		Expect(json.Unmarshal(raws[0].Meta, &meta)).To(Succeed())
+		Expect(meta.Latency).NotTo(BeNil())
+		Expect(meta.Latency.TimeToFirstByteNs).To(BeNumerically(">", 0))
+		Expect(meta.Latency.TimeToFirstTokenNs).To(BeNumerically(">=", meta.Latency.TimeToFirstByteNs))
+	})
+
 	// The main conversation sends no sub-thread header. Absent must stay
 	// distinguishable from present-but-empty, so the field is omitted rather
 	// than written as "".
@@ -13,10 +13,13 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/papercomputeco/tapes/pkg/derive"
 	"github.com/papercomputeco/tapes/pkg/llm"
@@ -32,6 +35,15 @@ var (
 	// Each queued Job retains its parsed body live, so the slot count alone
 	// does not bound memory; this budget does.
 	defaultJobQueueByteBudget int64 = 256 << 20
+
+	// A spooled job whose raw-turn write fails is retried with exponential
+	// backoff between these bounds until the store comes back.
+	spoolRetryMin = 250 * time.Millisecond
+	spoolRetryMax = 30 * time.Second
+
+	// spoolFeedInterval is how often parked jobs are offered to the queue
+	// when no worker has signaled free capacity in the meantime.
+	spoolFeedInterval = time.Second
 )
 
 // RejectReason names the ceiling that declined a job so the ingest saturation
@@ -84,6 +96,14 @@ type Job struct {
 	// backend. The local proxy always attaches an envelope so its
 	// captured turns surface in the deck.
 	Session *sessions.IngestEnvelope
+
+	// Latency is the call's latency profile as the proxy observed it,
+	// recorded in the raw turn's meta block. Nil when nothing was measured.
+	Latency *llm.Latency
+
+	// spooled locates the job's spool record when the pool has a spool,
+	// so the worker can acknowledge it once the turn is persisted.
+	spooled *spoolRef
 }
 
 // Config is the configuration options for the worker pool.
@@ -106,6 +126,14 @@ type Config struct {
 	// Project is the git repository or project name to tag on stored nodes.
 	Project string
 
+	// SpoolDir, when set, enables the write-ahead spool in that directory.
+	// Every accepted job is fsync'd there before it is enqueued, a job the
+	// queue cannot hold waits there instead of being dropped, raw-turn
+	// writes are retried while the store is unavailable, and whatever was
+	// not persisted is replayed on the next start. Empty keeps capture
+	// purely in memory.
+	SpoolDir string
+
 	// Logger is the provided logger
 	Logger *slog.Logger
 }
@@ -122,6 +150,16 @@ type Pool struct {
 	// but not yet finished processing.
 	mu            sync.Mutex
 	inFlightBytes int64
+
+	// spool is the write-ahead log (nil without Config.SpoolDir). parked,
+	// guarded by mu, are spooled jobs waiting on disk for queue capacity,
+	// oldest first; the feeder hands them to the workers, nudged by wake.
+	// done is closed by Close to stop the feeder and any retry backoff.
+	spool    *spool
+	parked   []spoolRef
+	wake     chan struct{}
+	done     chan struct{}
+	feederWG sync.WaitGroup
 }
 
 // NewPool creates a new Storer and starts its worker goroutines.
@@ -147,6 +185,17 @@ func NewPool(c *Config) (*Pool, error) {
 		queue:      make(chan Job, c.QueueSize),
 		logger:     c.Logger,
 		byteBudget: c.QueueByteBudget,
+		wake:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+
+	if c.SpoolDir != "" {
+		sp, pending, err := openSpool(c.SpoolDir, c.Logger)
+		if err != nil {
+			return nil, fmt.Errorf("opening capture spool: %w", err)
+		}
+		wp.spool = sp
+		wp.parked = pending
 	}
 
 	wp.wg.Add(int(c.NumWorkers))
@@ -154,12 +203,19 @@ func NewPool(c *Config) (*Pool, error) {
 		go wp.worker(i)
 	}
 
+	if wp.spool != nil {
+		wp.feederWG.Add(1)
+		go wp.feedParked()
+		wp.wakeFeeder()
+	}
+
 	return wp, nil
 }
 
 // Enqueue submits a job for processing by the worker pool.
 // Returns true if enqueued, false if rejected by either the byte budget or the
 // slot-count cap, resulting in the job being dropped. Call Admit to learn which.
+// A pool with a spool does not drop jobs: see Admit.
 func (p *Pool) Enqueue(job Job) bool {
 	return p.Admit(job) == RejectNone
 }
@@ -168,8 +224,24 @@ func (p *Pool) Enqueue(job Job) bool {
 // and, when both admit it, hands it to the workers. The reserved weight is held
 // until the job finishes processing (released in worker). RejectNone means the
 // job was enqueued; the other reasons name the ceiling that declined it.
+//
+// With a spool the job is made durable first, and a job neither ceiling
+// admits is parked in the spool until there is room, so Admit reports
+// RejectNone. Only when the spool write itself fails does the job fall
+// back to the in-memory path and its ceilings.
 func (p *Pool) Admit(job Job) RejectReason {
 	log := tapeslogger.WithRequestFields(p.logger, job.RequestID, job.UpstreamRequestID)
+	if p.spool != nil {
+		ref, err := p.spool.Append(job)
+		if err == nil {
+			p.admitSpooled(job, ref)
+			return RejectNone
+		}
+		log.Error("spool append failed, job held in memory only",
+			"provider", job.Provider,
+			"error", err,
+		)
+	}
 	if !p.reserve(job.Weight) {
 		log.Error("job not queued, byte budget exceeded, job dropped",
 			"provider", job.Provider,
@@ -194,6 +266,108 @@ func (p *Pool) Admit(job Job) RejectReason {
 	}
 }
 
+// admitSpooled enqueues a job already written to the spool, or parks it
+// there when the queue or byte budget cannot take it. While older jobs are
+// parked a new one parks behind them, so replay keeps arrival order.
+func (p *Pool) admitSpooled(job Job, ref spoolRef) {
+	log := tapeslogger.WithRequestFields(p.logger, job.RequestID, job.UpstreamRequestID)
+	job.spooled = &ref
+
+	p.mu.Lock()
+	backlog := len(p.parked) > 0
+	p.mu.Unlock()
+
+	if !backlog && p.tryEnqueue(job) {
+		log.Debug("job queued",
+			"provider", job.Provider,
+			"model", job.Req.Model,
+		)
+		return
+	}
+
+	p.mu.Lock()
+	p.parked = append(p.parked, ref)
+	p.mu.Unlock()
+	log.Warn("job parked in spool, queue saturated",
+		"provider", job.Provider,
+		"model", job.Req.Model,
+	)
+}
+
+// tryEnqueue reserves a job's weight and hands it to the workers without
+// blocking, reporting whether both succeeded.
+func (p *Pool) tryEnqueue(job Job) bool {
+	if !p.reserve(job.Weight) {
+		return false
+	}
+	select {
+	case p.queue <- job:
+		return true
+	default:
+		p.release(job.Weight)
+		return false
+	}
+}
+
+// feedParked moves parked jobs from the spool into the queue as workers
+// free capacity, until Close.
+func (p *Pool) feedParked() {
+	defer p.feederWG.Done()
+	ticker := time.NewTicker(spoolFeedInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-p.wake:
+		case <-ticker.C:
+		}
+		p.drainParked()
+	}
+}
+
+// drainParked enqueues parked jobs, oldest first, until the queue or the
+// byte budget pushes back. A record that can no longer be read is
+// acknowledged and dropped rather than blocking everything behind it.
+func (p *Pool) drainParked() {
+	for {
+		p.mu.Lock()
+		if len(p.parked) == 0 {
+			p.mu.Unlock()
+			return
+		}
+		ref := p.parked[0]
+		p.mu.Unlock()
+
+		job, err := p.spool.Load(ref)
+		if err != nil {
+			p.logger.Error("spooled job unreadable, job dropped", "error", err)
+			p.spool.Ack(ref)
+		} else {
+			job.spooled = &ref
+			if !p.tryEnqueue(job) {
+				return
+			}
+		}
+
+		p.mu.Lock()
+		p.parked = p.parked[1:]
+		p.mu.Unlock()
+	}
+}
+
+// wakeFeeder tells the feeder there may be room for parked jobs.
+func (p *Pool) wakeFeeder() {
+	if p.spool == nil {
+		return
+	}
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
 // reserve claims a job's weight against the byte budget, admitting it only if
 // the in-flight total stays within budget. A non-positive weight is always
 // admitted, so callers that supply no estimate fall back to the slot cap.
@@ -229,11 +403,25 @@ func (p *Pool) Len() int {
 	return len(p.queue)
 }
 
+// Cap returns the queue's slot capacity, the ceiling Len is measured against.
+func (p *Pool) Cap() int {
+	return cap(p.queue)
+}
+
 // Close signals workers to stop and waits for in-flight jobs to drain.
 // Call this during graceful shutdown after the proxy HTTP server has stopped.
+// Spooled jobs that are still parked, or whose raw turn could not be
+// persisted before shutdown, stay in the spool for the next start.
 func (p *Pool) Close() {
+	if p.done != nil {
+		close(p.done)
+		p.feederWG.Wait()
+	}
 	close(p.queue)
 	p.wg.Wait()
+	if p.spool != nil {
+		p.spool.Close()
+	}
 }
 
 // worker is the inner worker thread that continuously pulls jobs off the jobs queue
@@ -242,8 +430,11 @@ func (p *Pool) worker(id uint) {
 	p.logger.Debug("worker started", "worker_id", id)
 
 	for job := range p.queue {
-		p.processJob(job)
+		if p.processJob(job) && job.spooled != nil {
+			p.spool.Ack(*job.spooled)
+		}
 		p.release(job.Weight)
+		p.wakeFeeder()
 	}
 
 	p.logger.Debug("storage worker stopped", "worker_id", id)
@@ -254,7 +445,11 @@ func (p *Pool) worker(id uint) {
 // appends the verbatim turn to the immutable raw-turn layer (the
 // deriver's input), and ensures the turn's sessions row exists so the
 // deriver can resolve it and attach the turn's spans.
-func (p *Pool) processJob(job Job) {
+//
+// It reports whether the job is finished with; false means the pool closed
+// while a spooled job's raw turn was still failing to persist, so the job
+// must stay in the spool.
+func (p *Pool) processJob(job Job) bool {
 	ctx := tapeslogger.WithRequestMetadata(
 		context.Background(),
 		job.RequestID,
@@ -268,22 +463,88 @@ func (p *Pool) processJob(job Job) {
 		log.Error("capture skipped: turn produced no nodes",
 			"provider", job.Provider,
 		)
-		return
+		return true
 	}
 
-	p.persistRawTurn(ctx, job, chain)
+	if err := p.persistRawTurn(ctx, job, chain); err != nil && !p.retryRawTurn(ctx, job, chain, err) {
+		return false
+	}
 	p.ingestSession(ctx, job, chain)
+	return true
+}
+
+// retryRawTurn retries a spooled job's failed raw-turn write with
+// exponential backoff until it succeeds, reporting false if the pool
+// closes first. A job without a spool record is not retried: nothing
+// would hold it across a restart, and blocking a worker on it would only
+// turn a store outage into dropped captures at admission.
+//
+// Only a transient failure is retried. A store that refused the content
+// itself refuses every replay of it too, and retrying would block the
+// worker for good — and again after each restart, since the job stays in
+// the spool — so the turn is dropped and the job acknowledged.
+func (p *Pool) retryRawTurn(ctx context.Context, job Job, chain []*merkle.Node, err error) bool {
+	if job.spooled == nil {
+		return true
+	}
+	log := tapeslogger.RequestLoggerFromContext(ctx)
+
+	backoff := spoolRetryMin
+	for {
+		if permanentRawTurnError(err) {
+			log.Error("raw turn dropped from spool: the store refused its content",
+				"provider", job.Provider,
+				"error", err,
+			)
+			return true
+		}
+		select {
+		case <-p.done:
+			log.Warn("raw turn left in spool for replay, pool closing",
+				"provider", job.Provider,
+			)
+			return false
+		case <-time.After(backoff):
+		}
+		if err = p.persistRawTurn(ctx, job, chain); err == nil {
+			return true
+		}
+		backoff = min(backoff*2, spoolRetryMax)
+	}
+}
+
+// permanentRawTurnError reports whether a failed raw-turn write would fail
+// the same way however often it is replayed. That is the driver refusing
+// the payload as unstorable (storage.ErrInvalidContent), or the database
+// refusing a value with a SQLSTATE in class 22 (data exception) or 23
+// (integrity constraint violation): both are about the row, not the
+// store. Anything else is treated as the store being unreachable or
+// failing, and waited out.
+//
+// The SQLSTATE is read through the SQLState method pgconn.PgError has, so
+// this package stays free of any one driver.
+func permanentRawTurnError(err error) bool {
+	if errors.Is(err, storage.ErrInvalidContent) {
+		return true
+	}
+	var coded interface{ SQLState() string }
+	if !errors.As(err, &coded) {
+		return false
+	}
+	state := coded.SQLState()
+	return strings.HasPrefix(state, "22") || strings.HasPrefix(state, "23")
 }
 
 // rawTurnMeta is the minimal capture-side meta block the proxy stamps
 // onto a raw turn. The deriver reads thread_id for sub-thread
-// attribution; ts_request is omitted because single-process local
-// capture inserts the row at ~capture time, so the deriver's
-// received_at fallback is accurate.
+// attribution and latency for the llm span; ts_request is omitted
+// because single-process local capture inserts the row at ~capture
+// time, so the deriver's received_at fallback is accurate.
 type rawTurnMeta struct {
-	ThreadID          string `json:"thread_id,omitempty"`
-	RequestID         string `json:"request_id,omitempty"`
-	UpstreamRequestID string `json:"upstream_request_id,omitempty"`
+	ThreadID          string       `json:"thread_id,omitempty"`
+	RequestID         string       `json:"request_id,omitempty"`
+	UpstreamRequestID string       `json:"upstream_request_id,omitempty"`
+	Latency           *llm.Latency `json:"latency,omitempty"`
 }
 
 // persistRawTurn appends one captured turn to the immutable raw-turn
@@ -297,13 +558,14 @@ type rawTurnMeta struct {
 // (the same scheme the node ingest path uses) — stable across a
 // conversation's turns, so they group into one derivable session.
 //
-// Failures are logged, never propagated: a raw-layer outage must not
-// take down capture.
-func (p *Pool) persistRawTurn(ctx context.Context, job Job, chain []*merkle.Node) {
+// Failures are logged, never fatal: a raw-layer outage must not take down
+// capture. Only the store write's error is returned, since it is the one
+// worth retrying; a turn that cannot be encoded never will be.
+func (p *Pool) persistRawTurn(ctx context.Context, job Job, chain []*merkle.Node) error {
 	log := tapeslogger.RequestLoggerFromContext(ctx)
 	rawStore, ok := p.config.Driver.(storage.RawTurnStore)
 	if !ok || len(job.RawRequest) == 0 {
-		return
+		return nil
 	}
 
 	root := chain[0]
@@ -313,39 +575,40 @@ func (p *Pool) persistRawTurn(ctx context.Context, job Job, chain []*merkle.Node
 	if err != nil {
 		log.Error("raw turn skipped: resolve harness_session_id",
 			"provider", job.Provider, "error", err)
-		return
+		return nil
 	}
 
 	response, err := json.Marshal(job.Resp)
 	if err != nil {
 		log.Error("raw turn skipped: marshal response",
 			"provider", job.Provider, "error", err)
-		return
+		return nil
 	}
 
 	meta, err := json.Marshal(rawTurnMeta{
 		ThreadID:          job.ThreadID,
 		RequestID:         job.RequestID,
 		UpstreamRequestID: job.UpstreamRequestID,
+		Latency:           job.Latency,
 	})
 	if err != nil {
 		log.Error("raw turn skipped: marshal meta",
 			"provider", job.Provider, "error", err)
-		return
+		return nil
 	}
 
 	sessionJSON, err := json.Marshal(envelope)
 	if err != nil {
 		log.Error("raw turn skipped: marshal session envelope",
 			"provider", job.Provider, "error", err)
-		return
+		return nil
 	}
 
 	requestID := job.RequestID
 	if requestID == "" {
 		requestID = head.Hash
 	}
-	if _, err := rawStore.PutRawTurn(ctx, storage.RawTurnRecord{
+	_, err = rawStore.PutRawTurn(ctx, storage.RawTurnRecord{
 		OrgID:            envelope.OrgID,
 		Source:           storage.RawTurnSourceWire,
 		Provider:         job.Provider,
@@ -360,13 +623,15 @@ func (p *Pool) persistRawTurn(ctx context.Context, job Job, chain []*merkle.Node
 		Response:        response,
 		Meta:            meta,
 		SessionEnvelope: sessionJSON,
-	}); err != nil {
+	})
+	if err != nil {
 		log.Error("raw turn persist failed",
 			"provider", job.Provider,
 			"harness_session_id", harnessSessionID,
 			"error", err,
 		)
 	}
+	return err
 }
 
 // ingestSession ensures the turn's sessions row exists (and folds its
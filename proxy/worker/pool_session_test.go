@@ -3,6 +3,7 @@ package worker
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -29,8 +30,22 @@ type captureDriver struct {
 	rawTurns    []storage.RawTurnRecord
 	failIngest  error
 	sessionID   string
+
+	// rawDown fails every PutRawTurn while set, modelling a store outage.
+	rawDown bool
+
+	// refuse maps harness sessions to the error PutRawTurn fails their
+	// turns with, however often they are replayed.
+	refuse map[string]error
 }
 
+// sqlStateError is a driver error carrying a SQLSTATE, as pgconn.PgError
+// does.
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "SQLSTATE " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
 func newCaptureDriver() *captureDriver {
 	return &captureDriver{
 		Driver:    inmemory.NewDriver(),
@@ -41,6 +56,12 @@ func newCaptureDriver() *captureDriver {
 func (d *captureDriver) PutRawTurn(_ context.Context, rec storage.RawTurnRecord) (bool, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
+	if d.rawDown {
+		return false, errors.New("simulated store outage")
+	}
+	if err := d.refuse[rec.HarnessSessionID]; err != nil {
+		return false, fmt.Errorf("insert raw turn: %w", err)
+	}
 	d.rawTurns = append(d.rawTurns, rec)
 	return true, nil
 }
@@ -72,6 +93,12 @@ func (d *captureDriver) IngestTurn(_ context.Context, req storage.IngestTurnRequ
 	return storage.IngestTurnResult{SessionID: d.sessionID}, nil
 }
 
+func (d *captureDriver) SetRawDown(down bool) {
+	d.mu.Lock()
+	d.rawDown = down
+	d.mu.Unlock()
+}
+
 func (d *captureDriver) IngestCalls() []storage.IngestTurnRequest {
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -0,0 +1,390 @@
+package worker
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The spool is an append-only segment log. Each record is a fixed header
+// followed by its payload:
+//
+//	kind (1) | id (8) | payload length (4) | crc32 (4) | payload
+//
+// The checksum covers kind, id, length and payload, so a record torn by a
+// crash mid-write is detected on replay and the segment is truncated back
+// to its last whole record.
+const (
+	spoolRecordJob byte = 1
+	spoolRecordAck byte = 2
+
+	spoolHeaderSize = 17
+	spoolSegmentExt = ".wal"
+)
+
+var (
+	// Segments rotate once they pass this size; a fully acknowledged
+	// segment is deleted, so the spool's footprint tracks the backlog.
+	defaultSpoolSegmentBytes int64 = 64 << 20
+
+	// spoolMaxRecordBytes rejects a corrupt length field on replay before
+	// it turns into a huge allocation.
+	spoolMaxRecordBytes uint32 = 1 << 30
+)
+
+var spoolCRC = crc32.MakeTable(crc32.Castagnoli)
+
+// spoolRef locates one job record inside the spool. It is what the pool
+// carries alongside a spooled job so the record can be acknowledged once
+// the turn is durably in the store, and what a parked job is reloaded
+// from when the queue has room for it again.
+type spoolRef struct {
+	seg    *spoolSegment
+	id     uint64
+	offset int64
+	length uint32
+}
+
+// spoolSegment is one file of the log. live counts its job records that
+// have not been acknowledged yet; at zero the segment holds nothing worth
+// replaying and is compacted away.
+type spoolSegment struct {
+	seq  uint64
+	path string
+	file *os.File
+	size int64
+	live int
+}
+
+// spool is the worker pool's optional write-ahead log. Every job the pool
+// accepts is appended and fsync'd here before it is enqueued, and
+// acknowledged once its raw turn is persisted, so a capture survives a
+// process restart or a store outage between acceptance and persistence.
+//
+// Acknowledgements are written to the segment that holds the job, which
+// keeps each segment self-contained: it can be deleted as soon as its own
+// records are all acknowledged. Acks are not fsync'd — losing one only
+// replays a turn the store already has, and PutRawTurn dedupes it by
+// request id.
+type spool struct {
+	dir          string
+	logger       *slog.Logger
+	segmentBytes int64
+
+	mu       sync.Mutex
+	active   *spoolSegment
+	nextSeq  uint64
+	nextID   uint64
+	segments map[uint64]*spoolSegment
+}
+
+// openSpool opens (creating if needed) the spool in dir and replays it.
+// The returned refs are the unacknowledged jobs, in the order they were
+// accepted; the caller owns feeding them back to the workers.
+func openSpool(dir string, logger *slog.Logger) (*spool, []spoolRef, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, nil, fmt.Errorf("creating spool directory %s: %w", dir, err)
+	}
+
+	s := &spool{
+		dir:          dir,
+		logger:       logger,
+		segmentBytes: defaultSpoolSegmentBytes,
+		nextSeq:      1,
+		nextID:       1,
+		segments:     map[uint64]*spoolSegment{},
+	}
+
+	seqs, err := s.listSegments()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pending []spoolRef
+	for _, seq := range seqs {
+		seg, refs, err := s.replaySegment(seq)
+		if err != nil {
+			s.Close()
+			return nil, nil, err
+		}
+		s.nextSeq = seq + 1
+		if seg.live == 0 {
+			s.removeSegment(seg)
+			continue
+		}
+		s.segments[seq] = seg
+		s.active = seg
+		pending = append(pending, refs...)
+	}
+
+	if len(pending) > 0 {
+		s.logger.Info("spool replayed",
+			"dir", dir,
+			"pending", len(pending),
+			"segments", len(s.segments),
+		)
+	}
+	return s, pending, nil
+}
+
+// Append writes a job to the spool and fsyncs it before returning, so a
+// nil error means the capture survives a crash from here on.
+func (s *spool) Append(job Job) (spoolRef, error) {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return spoolRef{}, fmt.Errorf("encoding spooled job: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seg, err := s.writableSegment()
+	if err != nil {
+		return spoolRef{}, err
+	}
+
+	id := s.nextID
+	offset := seg.size
+	if err := s.writeRecord(seg, spoolRecordJob, id, payload); err != nil {
+		return spoolRef{}, err
+	}
+	if err := seg.file.Sync(); err != nil {
+		return spoolRef{}, fmt.Errorf("syncing spool segment: %w", err)
+	}
+
+	s.nextID++
+	seg.live++
+	return spoolRef{seg: seg, id: id, offset: offset, length: uint32(len(payload))}, nil //nolint:gosec // bounded by the marshaled job
+}
+
+// Load reads a spooled job back, for a job that was parked on disk
+// instead of held in the queue.
+func (s *spool) Load(ref spoolRef) (Job, error) {
+	buf := make([]byte, ref.length)
+	if _, err := ref.seg.file.ReadAt(buf, ref.offset+spoolHeaderSize); err != nil {
+		return Job{}, fmt.Errorf("reading spooled job %d: %w", ref.id, err)
+	}
+	var job Job
+	if err := json.Unmarshal(buf, &job); err != nil {
+		return Job{}, fmt.Errorf("decoding spooled job %d: %w", ref.id, err)
+	}
+	return job, nil
+}
+
+// Ack marks a spooled job as done. When that was the last live record in
+// its segment the segment is compacted: an older segment is deleted, and
+// the active one is truncated so the next append starts it afresh.
+func (s *spool) Ack(ref spoolRef) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seg := ref.seg
+	if s.segments[seg.seq] != seg {
+		return
+	}
+	seg.live--
+	if seg.live > 0 {
+		if err := s.writeRecord(seg, spoolRecordAck, ref.id, nil); err != nil {
+			s.logger.Warn("spool ack failed; turn will replay on restart",
+				"error", err)
+		}
+		return
+	}
+
+	if seg != s.active {
+		s.removeSegment(seg)
+		delete(s.segments, seg.seq)
+		return
+	}
+	if err := seg.file.Truncate(0); err != nil {
+		s.logger.Warn("spool compaction failed", "segment", seg.path, "error", err)
+		return
+	}
+	seg.size = 0
+}
+
+// Close closes the spool's segment files. Unacknowledged jobs stay on
+// disk for the next openSpool to replay.
+func (s *spool) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, seg := range s.segments {
+		_ = seg.file.Close()
+	}
+	s.segments = map[uint64]*spoolSegment{}
+	s.active = nil
+}
+
+// writableSegment returns the segment appends go to, rotating to a new
+// one when the active segment has outgrown segmentBytes.
+func (s *spool) writableSegment() (*spoolSegment, error) {
+	if s.active != nil && s.active.size < s.segmentBytes {
+		return s.active, nil
+	}
+
+	seq := s.nextSeq
+	path := filepath.Join(s.dir, fmt.Sprintf("%016d%s", seq, spoolSegmentExt))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("creating spool segment: %w", err)
+	}
+	if err := syncDir(s.dir); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	seg := &spoolSegment{seq: seq, path: path, file: f}
+	s.nextSeq++
+	s.segments[seq] = seg
+	s.active = seg
+	return seg, nil
+}
+
+func (s *spool) writeRecord(seg *spoolSegment, kind byte, id uint64, payload []byte) error {
+	record := make([]byte, spoolHeaderSize+len(payload))
+	record[0] = kind
+	binary.BigEndian.PutUint64(record[1:9], id)
+	binary.BigEndian.PutUint32(record[9:13], uint32(len(payload))) //nolint:gosec // a job payload is far below 4 GiB
+	copy(record[spoolHeaderSize:], payload)
+	binary.BigEndian.PutUint32(record[13:17], recordChecksum(record))
+
+	if _, err := seg.file.Write(record); err != nil {
+		// Cut a partial write back off so later records are not stranded
+		// behind a torn one that replay would stop at.
+		_ = seg.file.Truncate(seg.size)
+		return fmt.Errorf("writing spool segment: %w", err)
+	}
+	seg.size += int64(len(record))
+	return nil
+}
+
+// replaySegment scans one segment, returning its unacknowledged job
+// records in file order. A torn or corrupt tail is truncated away: the
+// record never finished its fsync, so its job was never accepted.
+func (s *spool) replaySegment(seq uint64) (*spoolSegment, []spoolRef, error) {
+	path := filepath.Join(s.dir, fmt.Sprintf("%016d%s", seq, spoolSegmentExt))
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening spool segment: %w", err)
+	}
+	seg := &spoolSegment{seq: seq, path: path, file: f}
+
+	var (
+		order []uint64
+		refs  = map[uint64]spoolRef{}
+	)
+	header := make([]byte, spoolHeaderSize)
+	for {
+		if _, err := f.ReadAt(header, seg.size); err != nil {
+			if !errors.Is(err, io.EOF) {
+				s.logger.Warn("spool segment truncated at torn record", "segment", path, "offset", seg.size)
+			}
+			break
+		}
+		kind := header[0]
+		id := binary.BigEndian.Uint64(header[1:9])
+		length := binary.BigEndian.Uint32(header[9:13])
+		if length > spoolMaxRecordBytes {
+			s.logger.Warn("spool segment truncated at corrupt record", "segment", path, "offset", seg.size)
+			break
+		}
+		record := make([]byte, spoolHeaderSize+int(length))
+		if _, err := f.ReadAt(record, seg.size); err != nil ||
+			binary.BigEndian.Uint32(record[13:17]) != recordChecksum(record) {
+			s.logger.Warn("spool segment truncated at corrupt record", "segment", path, "offset", seg.size)
+			break
+		}
+
+		switch kind {
+		case spoolRecordJob:
+			order = append(order, id)
+			refs[id] = spoolRef{seg: seg, id: id, offset: seg.size, length: length}
+		case spoolRecordAck:
+			delete(refs, id)
+		}
+		if id >= s.nextID {
+			s.nextID = id + 1
+		}
+		seg.size += int64(len(record))
+	}
+
+	info, err := f.Stat()
+	if err == nil && info.Size() > seg.size {
+		err = f.Truncate(seg.size)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, fmt.Errorf("recovering spool segment %s: %w", path, err)
+	}
+
+	pending := make([]spoolRef, 0, len(refs))
+	for _, id := range order {
+		if ref, ok := refs[id]; ok {
+			pending = append(pending, ref)
+		}
+	}
+	seg.live = len(pending)
+	return seg, pending, nil
+}
+
+// listSegments returns the sequence numbers of the segment files in the
+// spool directory, oldest first.
+func (s *spool) listSegments() ([]uint64, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading spool directory: %w", err)
+	}
+	var seqs []uint64
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), spoolSegmentExt)
+		if !ok || e.IsDir() {
+			continue
+		}
+		seq, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs, nil
+}
+
+func (s *spool) removeSegment(seg *spoolSegment) {
+	_ = seg.file.Close()
+	if err := os.Remove(seg.path); err != nil {
+		s.logger.Warn("spool segment removal failed", "segment", seg.path, "error", err)
+	}
+	if s.active == seg {
+		s.active = nil
+	}
+}
+
+func recordChecksum(record []byte) uint32 {
+	crc := crc32.Update(0, spoolCRC, record[:13])
+	return crc32.Update(crc, spoolCRC, record[spoolHeaderSize:])
+}
+
+// syncDir fsyncs a directory so a newly created segment file's entry is
+// itself durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("opening spool directory: %w", err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("syncing spool directory: %w", err)
+	}
+	return nil
+}
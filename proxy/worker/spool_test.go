@@ -0,0 +1,237 @@
+package worker
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	tapeslogger "github.com/papercomputeco/tapes/pkg/logger"
+	"github.com/papercomputeco/tapes/pkg/sessions"
+	"github.com/papercomputeco/tapes/pkg/storage"
+)
+
+var _ = Describe("Spool", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	open := func() (*spool, []spoolRef) {
+		sp, pending, err := openSpool(dir, tapeslogger.NewNoop())
+		Expect(err).NotTo(HaveOccurred())
+		return sp, pending
+	}
+
+	job := func(requestID string) Job {
+		j := sampleCaptureJob(nil, rawBody)
+		j.RequestID = requestID
+		return j
+	}
+
+	segments := func() []string {
+		matches, err := filepath.Glob(filepath.Join(dir, "*"+spoolSegmentExt))
+		Expect(err).NotTo(HaveOccurred())
+		return matches
+	}
+
+	It("replays unacknowledged jobs in arrival order", func() {
+		sp, pending := open()
+		Expect(pending).To(BeEmpty())
+
+		var refs []spoolRef
+		for _, id := range []string{"req-1", "req-2", "req-3"} {
+			ref, err := sp.Append(job(id))
+			Expect(err).NotTo(HaveOccurred())
+			refs = append(refs, ref)
+		}
+		sp.Ack(refs[1])
+		sp.Close()
+
+		sp, pending = open()
+		defer sp.Close()
+		Expect(pending).To(HaveLen(2))
+
+		first, err := sp.Load(pending[0])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(first.RequestID).To(Equal("req-1"))
+		Expect([]byte(first.RawRequest)).To(MatchJSON(rawBody))
+		Expect(first.Resp.Message.Content[0].Text).To(Equal("pong"))
+
+		third, err := sp.Load(pending[1])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(third.RequestID).To(Equal("req-3"))
+
+		// New appends continue past the replayed ids.
+		ref, err := sp.Append(job("req-4"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ref.id).To(BeNumerically(">", pending[1].id))
+	})
+
+	It("truncates a torn tail and keeps the whole records before it", func() {
+		sp, _ := open()
+		_, err := sp.Append(job("req-1"))
+		Expect(err).NotTo(HaveOccurred())
+		sp.Close()
+
+		files := segments()
+		Expect(files).To(HaveLen(1))
+		whole, err := os.Stat(files[0])
+		Expect(err).NotTo(HaveOccurred())
+
+		f, err := os.OpenFile(files[0], os.O_APPEND|os.O_WRONLY, 0o600)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = f.Write([]byte{spoolRecordJob, 0, 0, 0, 0, 0, 0, 0, 9, 0, 0})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.Close()).To(Succeed())
+
+		sp, pending := open()
+		defer sp.Close()
+		Expect(pending).To(HaveLen(1))
+		after, err := os.Stat(files[0])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(after.Size()).To(Equal(whole.Size()))
+	})
+
+	It("compacts segments once every job in them is acknowledged", func() {
+		sp, _ := open()
+		sp.segmentBytes = 1 // rotate on every append
+
+		var refs []spoolRef
+		for _, id := range []string{"req-1", "req-2"} {
+			ref, err := sp.Append(job(id))
+			Expect(err).NotTo(HaveOccurred())
+			refs = append(refs, ref)
+		}
+		Expect(segments()).To(HaveLen(2))
+
+		// The older segment is deleted outright.
+		sp.Ack(refs[0])
+		Expect(segments()).To(HaveLen(1))
+
+		// The active one is emptied in place.
+		sp.Ack(refs[1])
+		files := segments()
+		Expect(files).To(HaveLen(1))
+		info, err := os.Stat(files[0])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Size()).To(BeZero())
+		sp.Close()
+
+		sp, pending := open()
+		defer sp.Close()
+		Expect(pending).To(BeEmpty())
+		Expect(segments()).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Worker pool with a spool", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+
+		minBackoff, feed := spoolRetryMin, spoolFeedInterval
+		spoolRetryMin, spoolFeedInterval = 5*time.Millisecond, 5*time.Millisecond
+		DeferCleanup(func() { spoolRetryMin, spoolFeedInterval = minBackoff, feed })
+	})
+
+	newSpooledPool := func(driver *captureDriver, queueSize uint) *Pool {
+		wp, err := NewPool(&Config{
+			Driver:     driver,
+			NumWorkers: 1,
+			QueueSize:  queueSize,
+			SpoolDir:   dir,
+			Logger:     tapeslogger.NewNoop(),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return wp
+	}
+
+	envelope := func(id string) *sessions.IngestEnvelope {
+		return &sessions.IngestEnvelope{HarnessID: "claude", HarnessSessionID: id}
+	}
+
+	It("retries the raw write until the store comes back", func() {
+		driver := newCaptureDriver()
+		driver.SetRawDown(true)
+		wp := newSpooledPool(driver, 0)
+
+		Expect(wp.Admit(sampleCaptureJob(envelope("s-1"), rawBody))).To(Equal(RejectNone))
+		Consistently(driver.RawTurns, 30*time.Millisecond).Should(BeEmpty())
+
+		driver.SetRawDown(false)
+		Eventually(driver.RawTurns).Should(HaveLen(1))
+		Eventually(driver.IngestCalls).Should(HaveLen(1))
+		wp.Close()
+
+		sp, pending, err := openSpool(dir, tapeslogger.NewNoop())
+		Expect(err).NotTo(HaveOccurred())
+		defer sp.Close()
+		Expect(pending).To(BeEmpty())
+	})
+
+	It("drops a turn the store refuses for its content and moves on", func() {
+		driver := newCaptureDriver()
+		driver.refuse = map[string]error{
+			"s-bad":  storage.ErrInvalidContent,
+			"s-long": sqlStateError("22001"), // string_data_right_truncation
+			"s-null": sqlStateError("23502"), // not_null_violation
+		}
+		wp := newSpooledPool(driver, 0)
+
+		for _, id := range []string{"s-bad", "s-long", "s-null", "s-good"} {
+			Expect(wp.Admit(sampleCaptureJob(envelope(id), rawBody))).To(Equal(RejectNone))
+		}
+		Eventually(driver.RawTurns).Should(HaveLen(1))
+		Expect(driver.RawTurns()[0].HarnessSessionID).To(Equal("s-good"))
+		wp.Close()
+
+		// Acknowledged, not left behind to block the next start.
+		sp, pending, err := openSpool(dir, tapeslogger.NewNoop())
+		Expect(err).NotTo(HaveOccurred())
+		defer sp.Close()
+		Expect(pending).To(BeEmpty())
+	})
+
+	It("parks jobs the queue cannot hold instead of dropping them", func() {
+		driver := newCaptureDriver()
+		driver.SetRawDown(true)
+		wp := newSpooledPool(driver, 1)
+
+		// One job is stuck retrying in the worker, one fills the queue,
+		// and the rest would have been dropped without the spool.
+		for _, id := range []string{"s-1", "s-2", "s-3", "s-4"} {
+			Expect(wp.Admit(sampleCaptureJob(envelope(id), rawBody))).To(Equal(RejectNone))
+		}
+
+		driver.SetRawDown(false)
+		Eventually(driver.RawTurns).Should(HaveLen(4))
+		wp.Close()
+
+		var order []string
+		for _, rec := range driver.RawTurns() {
+			order = append(order, rec.HarnessSessionID)
+		}
+		Expect(order).To(Equal([]string{"s-1", "s-2", "s-3", "s-4"}))
+	})
+
+	It("replays turns a closed pool could not persist", func() {
+		down := newCaptureDriver()
+		down.SetRawDown(true)
+		wp := newSpooledPool(down, 0)
+		Expect(wp.Admit(sampleCaptureJob(envelope("s-1"), rawBody))).To(Equal(RejectNone))
+		Expect(wp.Admit(sampleCaptureJob(envelope("s-2"), rawBody))).To(Equal(RejectNone))
+		wp.Close()
+		Expect(down.RawTurns()).To(BeEmpty())
+
+		up := newCaptureDriver()
+		wp = newSpooledPool(up, 0)
+		Eventually(up.RawTurns).Should(HaveLen(2))
+		wp.Close()
+		Expect(up.RawTurns()[0].HarnessSessionID).To(Equal("s-1"))
+	})
+})